@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sarchlab/akita/v5/simulation/sweep"
+	"github.com/spf13/cobra"
+)
+
+var sweepCmd = &cobra.Command{
+	Use:   "sweep --base system.yaml --space space.yaml [flags] -- simulator [args]",
+	Short: "Run a parameter sweep over a system description.",
+	Long: "`sweep` runs the simulator once per point of a design space. Each " +
+		"run gets its own directory under --out holding the point's system " +
+		"description and the simulator's output; the simulator finds it in " +
+		"the " + sweep.PointDirEnv + " environment variable and writes its " +
+		"summary metrics to metrics.json there. The gathered results are " +
+		"written to results.csv. Rerunning the same sweep resumes it.",
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		base, _ := cmd.Flags().GetString("base")
+		spacePath, _ := cmd.Flags().GetString("space")
+		out, _ := cmd.Flags().GetString("out")
+		workers, _ := cmd.Flags().GetInt("workers")
+
+		space, err := sweep.LoadSpace(spacePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		results, err := sweep.Run(sweep.Config{
+			Base:    base,
+			Space:   space,
+			OutDir:  out,
+			Workers: workers,
+			Command: args,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		failed := 0
+		for _, r := range results.Rows {
+			if r.Status != sweep.StatusOK {
+				failed++
+			}
+		}
+
+		fmt.Printf("%d points, %d failed; results in %s/results.csv\n",
+			len(results.Rows), failed, out)
+
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sweepCmd)
+	sweepCmd.Flags().String("base", "", "System description every point starts from")
+	sweepCmd.Flags().String("space", "", "Design space file (YAML or JSON)")
+	sweepCmd.Flags().String("out", "sweep", "Output directory")
+	sweepCmd.Flags().Int("workers", 0, "Points run at once (default: number of CPUs)")
+	_ = sweepCmd.MarkFlagRequired("base")
+	_ = sweepCmd.MarkFlagRequired("space")
+}
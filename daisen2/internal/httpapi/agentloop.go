@@ -408,29 +408,48 @@ func runDataQuery(ctx context.Context, reader *SQLiteTraceReader, query string)
 	qctx, cancel := context.WithTimeout(ctx, dataQueryTimeout)
 	defer cancel()
 
-	// Engine-level read-only guard. The replay server opens a writable connection
-	// (Init), and sanitizeReadonlySQL's SELECT/WITH prefix check can be bypassed by
-	// a write smuggled through a CTE (e.g. `WITH x AS (...) DELETE ... RETURNING`).
-	// PRAGMA query_only makes SQLite itself reject any write on this connection, so
-	// model-influenced SQL can only observe the trace. Reset it before the
-	// connection returns to the pool.
-	conn, err := reader.DB.Conn(qctx)
+	var out string
+	err = queryReadonly(qctx, reader, safe, nil, func(rows *sql.Rows) error {
+		var ferr error
+		out, ferr = formatRows(rows, dataQueryRowCap, dataQueryByteCap)
+		return ferr
+	})
+
+	return out, err
+}
+
+// queryReadonly runs an already-sanitized query on a connection that SQLite
+// itself holds read-only, and hands the rows to fn.
+//
+// The replay server opens a writable connection (Init), and sanitizeReadonlySQL's
+// SELECT/WITH prefix check can be bypassed by a write smuggled through a CTE
+// (e.g. `WITH x AS (...) DELETE ... RETURNING`). PRAGMA query_only makes SQLite
+// reject any write on this connection, so user- or model-influenced SQL can only
+// observe the trace. It is reset before the connection returns to the pool.
+func queryReadonly(
+	ctx context.Context,
+	reader *SQLiteTraceReader,
+	query string,
+	args []any,
+	fn func(rows *sql.Rows) error,
+) error {
+	conn, err := reader.DB.Conn(ctx)
 	if err != nil {
-		return "", fmt.Errorf("query failed: %w", err)
+		return fmt.Errorf("query failed: %w", err)
 	}
 	defer conn.Close()
-	if _, err := conn.ExecContext(qctx, "PRAGMA query_only = ON"); err != nil {
-		return "", fmt.Errorf("query failed: %w", err)
+	if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return fmt.Errorf("query failed: %w", err)
 	}
-	defer func() { _, _ = conn.ExecContext(qctx, "PRAGMA query_only = OFF") }()
+	defer func() { _, _ = conn.ExecContext(ctx, "PRAGMA query_only = OFF") }()
 
-	rows, err := conn.QueryContext(qctx, safe)
+	rows, err := conn.QueryContext(ctx, query, args...)
 	if err != nil {
-		return "", fmt.Errorf("query failed: %w", err)
+		return fmt.Errorf("query failed: %w", err)
 	}
 	defer rows.Close()
 
-	return formatRows(rows, dataQueryRowCap, dataQueryByteCap)
+	return fn(rows)
 }
 
 // formatRows serializes rows as CSV, hard-capped at rowCap rows and byteCap bytes
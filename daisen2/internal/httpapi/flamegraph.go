@@ -0,0 +1,343 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// ---- Flame graph of task hierarchies -----------------------------------------
+//
+// The flame graph aggregates task trees (linked by ParentID) into one tree of
+// frames. A task's frame is its (location, kind, what); its stack is the chain
+// of frames from its topmost loaded ancestor down to itself. Every task adds its
+// exclusive time — its span minus the part covered by its children — to the
+// frame at the end of its stack, so a frame's width is where simulated time
+// actually went, not time merely spent waiting on sub-tasks.
+
+const (
+	// flameMaxTasks caps how many tasks one flame graph loads. A whole-trace
+	// request on a large trace is truncated rather than exhausting memory;
+	// narrowing the time range, scope, or root brings it under the cap.
+	flameMaxTasks = 2_000_000
+
+	// flameSampleTasks is how many task ids each frame keeps for drill-down.
+	flameSampleTasks = 50
+)
+
+// FlameQuery selects the tasks a flame graph aggregates. Root selects a task
+// and its whole subtree (e.g. one kernel); Scope restricts to a location
+// subtree; the time range keeps tasks overlapping [StartTime, EndTime).
+type FlameQuery struct {
+	Root            uint64
+	Scope           string
+	EnableTimeRange bool
+	StartTime       float64
+	EndTime         float64
+}
+
+// FlameNode is one frame of the aggregated tree. Self is the exclusive time of
+// the tasks ending at this frame, Total is Self plus every descendant's Self,
+// and Count is how many tasks end at this frame. TaskIDs is a sample of those
+// tasks, for drilling into the frame.
+type FlameNode struct {
+	Location string       `json:"location"`
+	Kind     string       `json:"kind"`
+	What     string       `json:"what"`
+	Self     float64      `json:"self"`
+	Total    float64      `json:"total"`
+	Count    int          `json:"count"`
+	TaskIDs  []uint64     `json:"task_ids"`
+	Children []*FlameNode `json:"children"`
+
+	index map[flameFrame]*FlameNode
+}
+
+// FlameGraph is the aggregated tree. The root is a synthetic frame holding every
+// top-level stack.
+type FlameGraph struct {
+	Root      *FlameNode `json:"root"`
+	TaskCount int        `json:"task_count"`
+	Truncated bool       `json:"truncated"`
+}
+
+type flameFrame struct {
+	location, kind, what string
+}
+
+type flameTask struct {
+	id, parentID uint64
+	frame        flameFrame
+	start, end   float64
+}
+
+func newFlameNode(f flameFrame) *FlameNode {
+	return &FlameNode{
+		Location: f.location,
+		Kind:     f.kind,
+		What:     f.what,
+		TaskIDs:  []uint64{},
+		Children: []*FlameNode{},
+		index:    map[flameFrame]*FlameNode{},
+	}
+}
+
+func (n *FlameNode) child(f flameFrame) *FlameNode {
+	if c, ok := n.index[f]; ok {
+		return c
+	}
+
+	c := newFlameNode(f)
+	n.index[f] = c
+	n.Children = append(n.Children, c)
+
+	return c
+}
+
+// BuildFlameGraph loads the selected tasks and aggregates them into a flame
+// graph.
+func (r *SQLiteTraceReader) BuildFlameGraph(
+	ctx context.Context, q FlameQuery,
+) (FlameGraph, error) {
+	tasks, truncated, err := r.loadFlameTasks(ctx, q)
+	if err != nil {
+		return FlameGraph{}, err
+	}
+
+	return FlameGraph{
+		Root:      aggregateFlame(tasks),
+		TaskCount: len(tasks),
+		Truncated: truncated,
+	}, nil
+}
+
+func (r *SQLiteTraceReader) loadFlameTasks(
+	ctx context.Context, q FlameQuery,
+) ([]flameTask, bool, error) {
+	r.ensureIndex(ctx, "Building index idx_trace_ParentID",
+		"CREATE INDEX IF NOT EXISTS idx_trace_ParentID ON trace(ParentID)")
+
+	id := r.activity.Begin("query", "Building flame graph", scopeLabel(q.Scope))
+	defer r.activity.End(id)
+
+	args := []any{}
+	from := "trace t"
+	if q.Root != 0 {
+		r.ensureIndex(ctx, "Building index idx_trace_ID",
+			"CREATE INDEX IF NOT EXISTS idx_trace_ID ON trace(ID)")
+		from = `(
+			WITH RECURSIVE sub(ID) AS (
+				SELECT ID FROM trace WHERE ID = ?
+				UNION
+				SELECT c.ID FROM trace c JOIN sub ON c.ParentID = sub.ID
+			)
+			SELECT trace.* FROM trace JOIN sub ON trace.ID = sub.ID
+		) t`
+		args = append(args, q.Root)
+	}
+
+	sqlStr := `SELECT t.ID, t.ParentID, t.Kind, t.What, loc.Locale, t.StartTime, t.EndTime
+		FROM ` + from + ` JOIN location loc ON t.Location = loc.ID WHERE 1=1`
+
+	if q.Scope != "" {
+		lo, hi := scopePrefixBounds(q.Scope)
+		sqlStr += `
+			AND t.Location IN (
+				SELECT ID FROM location WHERE Locale = ? OR (Locale >= ? AND Locale < ?)
+			)`
+		args = append(args, q.Scope, lo, hi)
+	}
+	if q.EnableTimeRange {
+		sqlStr += ` AND t.EndTime > ? AND t.StartTime < ?`
+		args = append(args, q.StartTime, q.EndTime)
+	}
+	sqlStr += ` LIMIT ` + strconv.Itoa(flameMaxTasks+1)
+
+	rows, err := r.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	tasks := []flameTask{}
+	for rows.Next() {
+		var t flameTask
+		if err := rows.Scan(&t.id, &t.parentID, &t.frame.kind, &t.frame.what,
+			&t.frame.location, &t.start, &t.end); err != nil {
+			return nil, false, err
+		}
+		tasks = append(tasks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	if len(tasks) > flameMaxTasks {
+		return tasks[:flameMaxTasks], true, nil
+	}
+
+	return tasks, false, nil
+}
+
+// aggregateFlame folds tasks into a frame tree. Parents outside the loaded set
+// (filtered out, or never traced) make a task a stack root.
+func aggregateFlame(tasks []flameTask) *FlameNode {
+	root := newFlameNode(flameFrame{location: "all"})
+
+	byID := make(map[uint64]int, len(tasks))
+	for i, t := range tasks {
+		byID[t.id] = i
+	}
+
+	childSpans := make(map[uint64][][2]float64)
+	for _, t := range tasks {
+		if _, ok := byID[t.parentID]; ok && t.parentID != t.id {
+			childSpans[t.parentID] = append(childSpans[t.parentID], [2]float64{t.start, t.end})
+		}
+	}
+
+	// nodeOf walks up from a task to its nearest ancestor with a frame, then
+	// creates the frames on the way back down. The walk is iterative, as task
+	// chains can be as long as the trace. A task whose parent is already on the
+	// walk (a corrupt ParentID cycle) is treated as a stack root.
+	nodes := make([]*FlameNode, len(tasks))
+	onPath := make([]bool, len(tasks))
+	path := []int{}
+	nodeOf := func(i int) *FlameNode {
+		if nodes[i] != nil {
+			return nodes[i]
+		}
+
+		parent := root
+		path = path[:0]
+		for j := i; ; {
+			path = append(path, j)
+			onPath[j] = true
+
+			pj, ok := byID[tasks[j].parentID]
+			if !ok || onPath[pj] {
+				break
+			}
+			if nodes[pj] != nil {
+				parent = nodes[pj]
+				break
+			}
+			j = pj
+		}
+
+		for k := len(path) - 1; k >= 0; k-- {
+			j := path[k]
+			nodes[j] = parent.child(tasks[j].frame)
+			onPath[j] = false
+			parent = nodes[j]
+		}
+
+		return nodes[i]
+	}
+
+	for i, t := range tasks {
+		n := nodeOf(i)
+		n.Self += exclusiveTime(t.start, t.end, childSpans[t.id])
+		n.Count++
+		if len(n.TaskIDs) < flameSampleTasks {
+			n.TaskIDs = append(n.TaskIDs, t.id)
+		}
+	}
+
+	finalizeFlame(root)
+
+	return root
+}
+
+// exclusiveTime is the part of [start, end) not covered by any child span.
+// Children may overlap each other (parallel sub-requests), so the covered part
+// is the length of their union, clipped to the parent.
+func exclusiveTime(start, end float64, children [][2]float64) float64 {
+	total := end - start
+	if total <= 0 {
+		return 0
+	}
+	if len(children) == 0 {
+		return total
+	}
+
+	spans := make([][2]float64, 0, len(children))
+	for _, c := range children {
+		s, e := max(c[0], start), min(c[1], end)
+		if e > s {
+			spans = append(spans, [2]float64{s, e})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	covered := 0.0
+	curS, curE := 0.0, -1.0
+	for _, s := range spans {
+		if s[0] > curE {
+			if curE > curS {
+				covered += curE - curS
+			}
+			curS, curE = s[0], s[1]
+			continue
+		}
+		curE = max(curE, s[1])
+	}
+	if curE > curS {
+		covered += curE - curS
+	}
+
+	return max(total-covered, 0)
+}
+
+// finalizeFlame fills in Total bottom-up and orders children widest first.
+func finalizeFlame(n *FlameNode) float64 {
+	n.Total = n.Self
+	for _, c := range n.Children {
+		n.Total += finalizeFlame(c)
+	}
+
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		return n.Children[i].Total > n.Children[j].Total
+	})
+
+	return n.Total
+}
+
+// httpFlameGraph serves the aggregated flame graph. Parameters: root (a task
+// id, to aggregate one task subtree), scope (a location subtree), and
+// starttime/endtime (keep tasks overlapping the range).
+func (s *Server) httpFlameGraph(w http.ResponseWriter, r *http.Request) {
+	if s.traceReader == nil {
+		http.Error(w, "trace data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := FlameQuery{Scope: r.FormValue("scope")}
+	if v := r.FormValue("root"); v != "" {
+		root, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid root", http.StatusBadRequest)
+			return
+		}
+		q.Root = root
+	}
+	if r.FormValue("starttime") != "" && r.FormValue("endtime") != "" {
+		start, err1 := strconv.ParseFloat(r.FormValue("starttime"), 64)
+		end, err2 := strconv.ParseFloat(r.FormValue("endtime"), 64)
+		if err1 != nil || err2 != nil {
+			http.Error(w, "invalid time range", http.StatusBadRequest)
+			return
+		}
+		q.EnableTimeRange = true
+		q.StartTime, q.EndTime = start, end
+	}
+
+	g, err := s.traceReader.BuildFlameGraph(r.Context(), q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, g)
+}
@@ -0,0 +1,182 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// seedFlameTrace builds two kernels. Kernel 1 (0-100) issues two overlapping
+// L2 reads (10-50, 30-70), each of which issues a DRAM read (20-40, 40-60).
+// Kernel 2 (200-300) issues one L2 read (210-260) with no children.
+func seedFlameTrace(t *testing.T) *SQLiteTraceReader {
+	t.Helper()
+	reader := newTestTraceReader(t)
+	stmts := []string{
+		`INSERT INTO location (ID, Locale) VALUES (1, 'GPU'), (2, 'L2'), (3, 'DRAM')`,
+		`INSERT INTO trace VALUES (1, 0, 'kernel', 'Launch', 1, 0, 100)`,
+		`INSERT INTO trace VALUES (2, 1, 'req_in', 'ReadReq', 2, 10, 50)`,
+		`INSERT INTO trace VALUES (3, 1, 'req_in', 'ReadReq', 2, 30, 70)`,
+		`INSERT INTO trace VALUES (4, 2, 'req_in', 'ReadReq', 3, 20, 40)`,
+		`INSERT INTO trace VALUES (5, 3, 'req_in', 'ReadReq', 3, 40, 60)`,
+		`INSERT INTO trace VALUES (6, 0, 'kernel', 'Launch', 1, 200, 300)`,
+		`INSERT INTO trace VALUES (7, 6, 'req_in', 'ReadReq', 2, 210, 260)`,
+	}
+	for _, s := range stmts {
+		if _, err := reader.Exec(s); err != nil {
+			t.Fatalf("seed %q: %v", s, err)
+		}
+	}
+	return reader
+}
+
+func TestExclusiveTimeUnionsOverlappingChildren(t *testing.T) {
+	cases := []struct {
+		children [][2]float64
+		want     float64
+	}{
+		{nil, 100},
+		{[][2]float64{{10, 50}, {30, 70}}, 40},
+		{[][2]float64{{-20, 10}, {90, 150}}, 80},
+		{[][2]float64{{0, 100}, {20, 30}}, 0},
+	}
+	for _, c := range cases {
+		if got := exclusiveTime(0, 100, c.children); got != c.want {
+			t.Errorf("exclusiveTime(%v) = %v, want %v", c.children, got, c.want)
+		}
+	}
+}
+
+func TestBuildFlameGraphAggregatesStacks(t *testing.T) {
+	reader := seedFlameTrace(t)
+
+	g, err := reader.BuildFlameGraph(context.Background(), FlameQuery{})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if g.TaskCount != 7 || g.Truncated {
+		t.Fatalf("unexpected counts: %+v", g)
+	}
+	if len(g.Root.Children) != 1 {
+		t.Fatalf("both kernels share one frame, got %d", len(g.Root.Children))
+	}
+
+	kernel := g.Root.Children[0]
+	// Kernel 1 excl = 100 - |[10,70)| = 40; kernel 2 excl = 100 - 50 = 50.
+	if kernel.Kind != "kernel" || kernel.Count != 2 || kernel.Self != 90 {
+		t.Fatalf("unexpected kernel frame: %+v", kernel)
+	}
+	// Total sums exclusive time over the subtree: 90 (kernels) + 90 (L2) + 40
+	// (DRAM). The overlapping L2 reads each count their own busy time.
+	if kernel.Total != 220 || g.Root.Total != 220 {
+		t.Fatalf("kernel total = %v, root total = %v, want 220", kernel.Total, g.Root.Total)
+	}
+
+	l2 := kernel.Children[0]
+	// L2 reads: (40-20) + (40-20) + 50 = 90 exclusive across three tasks.
+	if l2.Location != "L2" || l2.Count != 3 || l2.Self != 90 {
+		t.Fatalf("unexpected L2 frame: %+v", l2)
+	}
+	dram := l2.Children[0]
+	if dram.Location != "DRAM" || dram.Self != 40 || len(dram.TaskIDs) != 2 {
+		t.Fatalf("unexpected DRAM frame: %+v", dram)
+	}
+}
+
+func TestBuildFlameGraphRootSubtree(t *testing.T) {
+	reader := seedFlameTrace(t)
+
+	g, err := reader.BuildFlameGraph(context.Background(), FlameQuery{Root: 6})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if g.TaskCount != 2 || g.Root.Total != 100 {
+		t.Fatalf("expected kernel 2's subtree only, got %d tasks, total %v",
+			g.TaskCount, g.Root.Total)
+	}
+}
+
+func TestBuildFlameGraphScopeMakesOrphansRoots(t *testing.T) {
+	reader := seedFlameTrace(t)
+
+	// Scoped to DRAM, the L2 parents are not loaded: DRAM reads become roots.
+	g, err := reader.BuildFlameGraph(context.Background(), FlameQuery{Scope: "DRAM"})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(g.Root.Children) != 1 || g.Root.Children[0].Location != "DRAM" {
+		t.Fatalf("expected a single DRAM root frame, got %+v", g.Root.Children)
+	}
+}
+
+func TestAggregateFlameWalksLongChainsAndCycles(t *testing.T) {
+	// A chain of n nested tasks, and two tasks that are each other's parent.
+	const n = 100_000
+	tasks := make([]flameTask, 0, n+2)
+	for i := uint64(1); i <= n; i++ {
+		tasks = append(tasks, flameTask{id: i, parentID: i - 1,
+			frame: flameFrame{location: "L", what: strconv.FormatUint(i%2, 10)},
+			start: 0, end: 1})
+	}
+	tasks = append(tasks,
+		flameTask{id: n + 1, parentID: n + 2, frame: flameFrame{location: "A"}, end: 1},
+		flameTask{id: n + 2, parentID: n + 1, frame: flameFrame{location: "B"}, end: 1})
+
+	root := aggregateFlame(tasks)
+	if len(root.Children) != 2 {
+		t.Fatalf("expected the chain and the cycle as roots, got %d", len(root.Children))
+	}
+
+	chain := root.Children[0]
+	if chain.Location != "L" {
+		chain = root.Children[1]
+	}
+	depth := 1
+	for ; len(chain.Children) > 0; depth++ {
+		chain = chain.Children[0]
+	}
+	if depth != n {
+		t.Fatalf("expected a stack %d frames deep, got %d", n, depth)
+	}
+}
+
+func TestHTTPFlameGraphReportsQueryErrors(t *testing.T) {
+	reader := seedFlameTrace(t)
+	if _, err := reader.Exec(`DROP TABLE location`); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	s := &Server{traceReader: reader}
+
+	rec := httptest.NewRecorder()
+	s.httpFlameGraph(rec, httptest.NewRequest(http.MethodGet, "/api/flamegraph", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 for a failed query, got %d", rec.Code)
+	}
+}
+
+func TestHTTPFlameGraph(t *testing.T) {
+	s := &Server{traceReader: seedFlameTrace(t)}
+
+	rec := httptest.NewRecorder()
+	s.httpFlameGraph(rec, httptest.NewRequest(http.MethodGet,
+		"/api/flamegraph?starttime=150&endtime=400", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var g FlameGraph
+	if err := json.Unmarshal(rec.Body.Bytes(), &g); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if g.TaskCount != 2 {
+		t.Fatalf("expected the two tasks in range, got %d", g.TaskCount)
+	}
+
+	rec = httptest.NewRecorder()
+	s.httpFlameGraph(rec, httptest.NewRequest(http.MethodGet, "/api/flamegraph?root=x", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad root, got %d", rec.Code)
+	}
+}
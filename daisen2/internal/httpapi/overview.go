@@ -1,10 +1,16 @@
 package httpapi
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+
+	"github.com/sarchlab/akita/v5/simulation/topology"
 )
 
 // SimInfoEntry is one key/value row of the exec_info table (e.g. "Command",
@@ -50,65 +56,109 @@ func (s *Server) httpSimInfo(w http.ResponseWriter, r *http.Request) {
 }
 
 // TopologyComponent is one component with its spec. Spec is the recorded spec
-// embedded as raw JSON (null when the component recorded no spec).
+// embedded as raw JSON (null when the component recorded no spec). Domain is the
+// innermost domain the component belongs to, or empty.
 type TopologyComponent struct {
-	Name string          `json:"name"`
-	Type string          `json:"type"`
-	Spec json.RawMessage `json:"spec"`
+	Name   string          `json:"name"`
+	Type   string          `json:"type"`
+	Spec   json.RawMessage `json:"spec"`
+	Domain string          `json:"domain"`
 }
 
 // TopologyPort is one port of a component and the connection it is plugged into.
-// Connection is empty for an unconnected port.
+// Connection is empty for an unconnected port. Roles are the protocol roles the
+// port speaks, e.g. "mem requester".
 type TopologyPort struct {
-	Component  string `json:"component"`
-	Port       string `json:"port"`
-	Connection string `json:"connection"`
+	Component  string   `json:"component"`
+	Port       string   `json:"port"`
+	Connection string   `json:"connection"`
+	Roles      []string `json:"roles"`
+}
+
+// TopologyDomain is a domain and the domain that encloses it, if any.
+type TopologyDomain struct {
+	Name   string `json:"name"`
+	Parent string `json:"parent"`
+}
+
+// TopologyConnection is one connection with its spec, e.g. a link's latency and
+// bandwidth. Spec is null when the connection recorded no spec.
+type TopologyConnection struct {
+	Name string          `json:"name"`
+	Type string          `json:"type"`
+	Spec json.RawMessage `json:"spec"`
 }
 
 // Topology is the static structure of a simulation: every component (with its
-// spec) and the full port inventory. The connection graph is the subset of
-// ports with a non-empty Connection — ports sharing a Connection are that
-// connection's endpoints.
+// spec and domain), the full port inventory, the domains, and the connections.
+// The connection graph is the subset of ports with a non-empty Connection —
+// ports sharing a Connection are that connection's endpoints.
 type Topology struct {
-	Components []TopologyComponent `json:"components"`
-	Ports      []TopologyPort      `json:"ports"`
+	Components  []TopologyComponent  `json:"components"`
+	Ports       []TopologyPort       `json:"ports"`
+	Domains     []TopologyDomain     `json:"domains"`
+	Connections []TopologyConnection `json:"connections"`
 }
 
-// ReadTopology reads the component_spec and port tables. Missing tables (a trace
-// recorded before topology recording existed) yield empty slices, so the
-// frontend can render an empty state instead of failing.
+// ReadTopology reads the component_spec, port, domain, and connection_spec
+// tables. Missing tables or columns (a trace recorded before they existed)
+// yield empty values, so the frontend can render an empty state instead of
+// failing.
 func (r *SQLiteTraceReader) ReadTopology(ctx context.Context) Topology {
 	return Topology{
-		Components: r.listComponentSpecs(ctx),
-		Ports:      r.listPorts(ctx),
+		Components:  r.listComponentSpecs(ctx),
+		Ports:       r.listPorts(ctx),
+		Domains:     r.listDomains(ctx),
+		Connections: r.listConnectionSpecs(ctx),
 	}
 }
 
+// queryWithFallback runs query, or fallback if query fails, e.g. because the
+// trace predates a column that query selects.
+func (r *SQLiteTraceReader) queryWithFallback(
+	ctx context.Context,
+	query, fallback string,
+) (*sql.Rows, error) {
+	rows, err := r.QueryContext(ctx, query)
+	if err == nil {
+		return rows, nil
+	}
+
+	return r.QueryContext(ctx, fallback)
+}
+
+// rawSpec embeds a spec stored as JSON text as raw JSON, so the client gets a
+// real object rather than a quoted string. It falls back to null for an empty
+// or non-JSON spec.
+func rawSpec(spec string) json.RawMessage {
+	if spec != "" && json.Valid([]byte(spec)) {
+		return json.RawMessage(spec)
+	}
+
+	return json.RawMessage("null")
+}
+
 func (r *SQLiteTraceReader) listComponentSpecs(
 	ctx context.Context,
 ) []TopologyComponent {
 	components := []TopologyComponent{}
 
-	rows, err := r.QueryContext(ctx, "SELECT Name, Type, Spec FROM component_spec")
+	rows, err := r.queryWithFallback(ctx,
+		"SELECT Name, Type, Spec, Domain FROM component_spec",
+		"SELECT Name, Type, Spec, '' FROM component_spec")
 	if err != nil {
 		return components
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var name, typ, spec string
-		if err := rows.Scan(&name, &typ, &spec); err != nil {
+		var c TopologyComponent
+		var spec string
+		if err := rows.Scan(&c.Name, &c.Type, &spec, &c.Domain); err != nil {
 			continue
 		}
 
-		c := TopologyComponent{Name: name, Type: typ, Spec: json.RawMessage("null")}
-		// Spec is stored as JSON text; embed it as raw JSON so the client gets a
-		// real object rather than a quoted string. Fall back to null if a row
-		// somehow holds non-JSON.
-		if spec != "" && json.Valid([]byte(spec)) {
-			c.Spec = json.RawMessage(spec)
-		}
-
+		c.Spec = rawSpec(spec)
 		components = append(components, c)
 	}
 	if err := rows.Err(); err != nil {
@@ -121,7 +171,9 @@ func (r *SQLiteTraceReader) listComponentSpecs(
 func (r *SQLiteTraceReader) listPorts(ctx context.Context) []TopologyPort {
 	ports := []TopologyPort{}
 
-	rows, err := r.QueryContext(ctx, "SELECT Component, Port, Connection FROM port")
+	rows, err := r.queryWithFallback(ctx,
+		"SELECT Component, Port, Connection, Roles FROM port",
+		"SELECT Component, Port, Connection, '' FROM port")
 	if err != nil {
 		return ports
 	}
@@ -129,9 +181,16 @@ func (r *SQLiteTraceReader) listPorts(ctx context.Context) []TopologyPort {
 
 	for rows.Next() {
 		var p TopologyPort
-		if err := rows.Scan(&p.Component, &p.Port, &p.Connection); err != nil {
+		var roles string
+		if err := rows.Scan(&p.Component, &p.Port, &p.Connection, &roles); err != nil {
 			continue
 		}
+
+		p.Roles = []string{}
+		if roles != "" {
+			p.Roles = strings.Split(roles, ", ")
+		}
+
 		ports = append(ports, p)
 	}
 	if err := rows.Err(); err != nil {
@@ -141,6 +200,57 @@ func (r *SQLiteTraceReader) listPorts(ctx context.Context) []TopologyPort {
 	return ports
 }
 
+func (r *SQLiteTraceReader) listDomains(ctx context.Context) []TopologyDomain {
+	domains := []TopologyDomain{}
+
+	rows, err := r.QueryContext(ctx, "SELECT Name, Parent FROM domain")
+	if err != nil {
+		return domains
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d TopologyDomain
+		if err := rows.Scan(&d.Name, &d.Parent); err != nil {
+			continue
+		}
+		domains = append(domains, d)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("domain query: %v", err)
+	}
+
+	return domains
+}
+
+func (r *SQLiteTraceReader) listConnectionSpecs(
+	ctx context.Context,
+) []TopologyConnection {
+	connections := []TopologyConnection{}
+
+	rows, err := r.QueryContext(ctx, "SELECT Name, Type, Spec FROM connection_spec")
+	if err != nil {
+		return connections
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c TopologyConnection
+		var spec string
+		if err := rows.Scan(&c.Name, &c.Type, &spec); err != nil {
+			continue
+		}
+
+		c.Spec = rawSpec(spec)
+		connections = append(connections, c)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("connection_spec query: %v", err)
+	}
+
+	return connections
+}
+
 func (s *Server) httpTopology(w http.ResponseWriter, r *http.Request) {
 	if s.traceReader == nil {
 		http.Error(w, "trace data not available", http.StatusServiceUnavailable)
@@ -149,3 +259,104 @@ func (s *Server) httpTopology(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, s.traceReader.ReadTopology(r.Context()))
 }
+
+// Graph converts the recorded topology to the graph the simulation/topology
+// package exports: components clustered by domain, and each connection an edge
+// joining the components of its ports.
+func (t Topology) Graph() topology.Graph {
+	g := topology.Graph{
+		Clusters: []topology.Cluster{},
+		Nodes:    []topology.Node{},
+		Edges:    []topology.Edge{},
+	}
+
+	for _, d := range t.Domains {
+		g.Clusters = append(g.Clusters, topology.Cluster{Name: d.Name, Parent: d.Parent})
+	}
+
+	for _, c := range t.Components {
+		g.Nodes = append(g.Nodes, topology.Node{
+			Name:    c.Name,
+			Type:    c.Type,
+			Cluster: c.Domain,
+			Spec:    nullToNil(c.Spec),
+		})
+	}
+
+	specs := make(map[string]TopologyConnection)
+	for _, c := range t.Connections {
+		specs[c.Name] = c
+	}
+
+	index := make(map[string]int)
+
+	for _, p := range t.Ports {
+		if p.Connection == "" {
+			continue
+		}
+
+		i, found := index[p.Connection]
+		if !found {
+			spec := specs[p.Connection]
+			g.Edges = append(g.Edges, topology.Edge{
+				Connection: p.Connection,
+				Type:       spec.Type,
+				Params:     nullToNil(spec.Spec),
+			})
+			i = len(g.Edges) - 1
+			index[p.Connection] = i
+		}
+
+		g.Edges[i].Endpoints = append(g.Edges[i].Endpoints, topology.Endpoint{
+			Component: p.Component,
+			Port:      p.Port,
+			Roles:     p.Roles,
+		})
+	}
+
+	return g
+}
+
+func nullToNil(spec json.RawMessage) json.RawMessage {
+	if string(spec) == "null" {
+		return nil
+	}
+
+	return spec
+}
+
+// topologyContentTypes maps each export format to the content type served.
+var topologyContentTypes = map[topology.Format]string{
+	topology.FormatDOT:     "text/vnd.graphviz",
+	topology.FormatGraphML: "application/graphml+xml",
+	topology.FormatJSON:    "application/json",
+}
+
+// httpTopologyExport serves the topology as a downloadable graph file in the
+// ?format= given: dot (the default), graphml, or json.
+func (s *Server) httpTopologyExport(w http.ResponseWriter, r *http.Request) {
+	if s.traceReader == nil {
+		http.Error(w, "trace data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	format := topology.Format(r.FormValue("format"))
+	if format == "" {
+		format = topology.FormatDOT
+	}
+
+	contentType, ok := topologyContentTypes[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	dieOnErr(topology.Write(&buf, s.traceReader.ReadTopology(r.Context()).Graph(), format))
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", "topology."+string(format)))
+	_, err := w.Write(buf.Bytes())
+	dieOnErr(err)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -187,3 +188,60 @@ func TestHTTPSimInfoServesJSON(t *testing.T) {
 		t.Fatalf("unexpected entries: %+v", entries)
 	}
 }
+
+func TestHTTPTopologyExport(t *testing.T) {
+	reader := newTestReader(t)
+	exec := func(q string) {
+		if _, err := reader.Exec(q); err != nil {
+			t.Fatalf("exec %q: %v", q, err)
+		}
+	}
+	exec(`CREATE TABLE component_spec (Name TEXT, Type TEXT, Spec TEXT, Domain TEXT)`)
+	exec(`INSERT INTO component_spec VALUES ('Agent', '', '', '')`)
+	exec(`INSERT INTO component_spec VALUES ('GPU.L1', 'cache.Spec', '{"freq":1000}', 'GPU')`)
+	exec(`CREATE TABLE port (Component TEXT, Port TEXT, Connection TEXT, Roles TEXT)`)
+	exec(`INSERT INTO port VALUES ('Agent', 'Agent.Mem', 'Link', 'mem requester')`)
+	exec(`INSERT INTO port VALUES ('GPU.L1', 'GPU.L1.Top', 'Link', 'mem responder')`)
+	exec(`CREATE TABLE domain (Name TEXT, Parent TEXT)`)
+	exec(`INSERT INTO domain VALUES ('GPU', '')`)
+	exec(`CREATE TABLE connection_spec (Name TEXT, Type TEXT, Spec TEXT)`)
+	exec(`INSERT INTO connection_spec VALUES ('Link', 'link.Spec', '{"latency":20}')`)
+
+	s := &Server{traceReader: reader}
+
+	rec := httptest.NewRecorder()
+	s.httpTopologyExport(rec, httptest.NewRequest(http.MethodGet, "/api/topology/export", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/vnd.graphviz" {
+		t.Fatalf("content-type = %q", ct)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`subgraph "cluster_GPU" {`,
+		`"Agent" -- "GPU.L1" [label="Link\nlatency=20", ` +
+			`taillabel="mem requester", headlabel="mem responder"];`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("export lacks %s:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	s.httpTopologyExport(rec,
+		httptest.NewRequest(http.MethodGet, "/api/topology/export?format=graphml", nil))
+	if rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `sourceport="Agent.Mem"`) {
+		t.Fatalf("graphml export failed: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.httpTopologyExport(rec,
+		httptest.NewRequest(http.MethodGet, "/api/topology/export?format=png", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown format status = %d", rec.Code)
+	}
+}
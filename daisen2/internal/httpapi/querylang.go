@@ -0,0 +1,890 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ---- Task query language -----------------------------------------------------
+//
+// A small, safe query language for exploring tasks, e.g.
+//
+//	tasks where kind=req_in and location~"L2*" and duration>500ns
+//	      group by what order by p99 desc
+//
+// Grammar (keywords are case-insensitive):
+//
+//	query    := "tasks" ["where" expr] ["group" "by" field {"," field}]
+//	            ["order" "by" key ["asc" | "desc"]]
+//	            ["histogram" field ["bins" N]] ["limit" N]
+//	expr     := term {"or" term}
+//	term     := factor {"and" factor}
+//	factor   := "not" factor | "(" expr ")" | field op value
+//	op       := "=" | "!=" | "~" | "!~" | "<" | "<=" | ">" | ">="
+//	value    := word | "quoted string" | number [ps|ns|us|ms|s]
+//
+// Fields are id, parent, kind, what, location, start, end, and duration. "~"
+// is a case-sensitive glob match ("*" and "?") on kind, what, or location. Time
+// values default to picoseconds. With "group by", every group reports count,
+// avg, min, max, total, p50, p90, p95, and p99 of task duration; "order by" may
+// then name any of those or a grouped field. "histogram" bins a time field
+// instead of returning rows.
+//
+// Queries compile to parameterized SQL over the same indexed access paths the
+// rest of the reader uses (location filters resolve location ids first, then
+// probe trace by its Location index), go through sanitizeReadonlySQL, and run
+// on a query_only connection. No user text ever reaches the SQL string: values
+// are bound parameters and identifiers come from fixed tables below.
+
+const (
+	taskQueryRowCap       = 1000
+	taskQueryDefaultBins  = 20
+	taskQueryMaxBins      = 200
+	taskQueryTimeout      = 30 * time.Second
+	taskQueryMaxQueryText = 4096
+)
+
+// tqlField describes one queryable task field.
+type tqlField struct {
+	expr    string // SQL expression over trace t / location loc
+	column  string // result column name
+	numeric bool
+	isTime  bool
+}
+
+var tqlFields = map[string]tqlField{
+	"id":       {expr: "t.ID", column: "id", numeric: true},
+	"parent":   {expr: "t.ParentID", column: "parent_id", numeric: true},
+	"kind":     {expr: "t.Kind", column: "kind"},
+	"what":     {expr: "t.What", column: "what"},
+	"location": {expr: "loc.Locale", column: "location"},
+	"start":    {expr: "t.StartTime", column: "start_time", numeric: true, isTime: true},
+	"end":      {expr: "t.EndTime", column: "end_time", numeric: true, isTime: true},
+	"duration": {expr: "(t.EndTime - t.StartTime)", column: "duration", numeric: true, isTime: true},
+}
+
+// tqlFieldAliases maps accepted spellings to canonical field names.
+var tqlFieldAliases = map[string]string{
+	"parentid": "parent", "parent_id": "parent",
+	"loc": "location", "where": "location", "component": "location",
+	"start_time": "start", "starttime": "start",
+	"end_time": "end", "endtime": "end",
+	"latency": "duration",
+}
+
+// tqlListColumns is the column order of a plain (ungrouped) task listing.
+var tqlListColumns = []string{"id", "parent", "kind", "what", "location", "start", "end", "duration"}
+
+// tqlAggregates are the per-group statistics, all over task duration. A zero
+// percentile marks a plain SQL aggregate.
+var tqlAggregates = []struct {
+	name       string
+	sql        string
+	percentile int
+}{
+	{name: "count", sql: "COUNT(*)"},
+	{name: "avg", sql: "AVG(d)"},
+	{name: "min", sql: "MIN(d)"},
+	{name: "max", sql: "MAX(d)"},
+	{name: "total", sql: "SUM(d)"},
+	{name: "p50", percentile: 50},
+	{name: "p90", percentile: 90},
+	{name: "p95", percentile: 95},
+	{name: "p99", percentile: 99},
+}
+
+var tqlTimeUnits = map[string]float64{
+	"ps": 1, "ns": 1e3, "us": 1e6, "ms": 1e9, "s": 1e12,
+}
+
+// ---- Lexer ----
+
+type tqlTokenKind int
+
+const (
+	tqlWord tqlTokenKind = iota
+	tqlString
+	tqlNumber
+	tqlOp
+	tqlComma
+	tqlLParen
+	tqlRParen
+	tqlEOF
+)
+
+type tqlToken struct {
+	kind tqlTokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func tqlLex(src string) ([]tqlToken, error) {
+	var tokens []tqlToken
+
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == ',':
+			tokens = append(tokens, tqlToken{kind: tqlComma, text: ",", pos: i})
+			i++
+		case c == '(':
+			tokens = append(tokens, tqlToken{kind: tqlLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, tqlToken{kind: tqlRParen, text: ")", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(src[i+1:], byte(c))
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, tqlToken{kind: tqlString, text: src[i+1 : i+1+end], pos: i})
+			i += end + 2
+		case strings.ContainsRune("=!~<>", c):
+			op := string(c)
+			if i+1 < len(src) && (src[i+1] == '=' || (c == '!' && src[i+1] == '~')) {
+				op += string(src[i+1])
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected '!' at %d", i)
+			}
+			tokens = append(tokens, tqlToken{kind: tqlOp, text: op, pos: i})
+			i += len(op)
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1]))):
+			tok, n, err := tqlLexNumber(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%v at %d", err, i)
+			}
+			tok.pos = i
+			tokens = append(tokens, tok)
+			i += n
+		case tqlIsWordRune(c):
+			j := i
+			for j < len(src) && tqlIsWordRune(rune(src[j])) {
+				j++
+			}
+			tokens = append(tokens, tqlToken{kind: tqlWord, text: src[i:j], pos: i})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+		}
+	}
+
+	return append(tokens, tqlToken{kind: tqlEOF, pos: len(src)}), nil
+}
+
+func tqlIsWordRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("_.[]*?-$", c)
+}
+
+// tqlLexNumber reads a number with an optional time-unit suffix, returning the
+// value in picoseconds when a unit is present.
+func tqlLexNumber(s string) (tqlToken, int, error) {
+	j := 0
+	for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.' || s[j] == 'e' ||
+		((s[j] == '+' || s[j] == '-') && j > 0 && s[j-1] == 'e')) {
+		j++
+	}
+
+	v, err := strconv.ParseFloat(s[:j], 64)
+	if err != nil {
+		return tqlToken{}, 0, fmt.Errorf("bad number %q", s[:j])
+	}
+
+	k := j
+	for k < len(s) && unicode.IsLetter(rune(s[k])) {
+		k++
+	}
+	if unit := strings.ToLower(s[j:k]); unit != "" {
+		scale, ok := tqlTimeUnits[unit]
+		if !ok {
+			return tqlToken{}, 0, fmt.Errorf("unknown time unit %q", unit)
+		}
+		v *= scale
+	}
+
+	return tqlToken{kind: tqlNumber, text: s[:k], num: v}, k, nil
+}
+
+// ---- Parser ----
+
+// tqlCond is a node of the where-expression tree: either a leaf comparison
+// (field/op/value) or an and/or/not over children.
+type tqlCond struct {
+	logic    string // "", "and", "or", "not"
+	children []*tqlCond
+
+	field string
+	op    string
+	value any
+}
+
+type tqlQuery struct {
+	where     *tqlCond
+	groupBy   []string
+	orderBy   string
+	desc      bool
+	histogram string
+	bins      int
+	limit     int
+}
+
+type tqlParser struct {
+	tokens []tqlToken
+	pos    int
+}
+
+func (p *tqlParser) peek() tqlToken { return p.tokens[p.pos] }
+
+func (p *tqlParser) next() tqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != tqlEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *tqlParser) isKeyword(words ...string) bool {
+	t := p.peek()
+	if t.kind != tqlWord {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *tqlParser) expectKeyword(word string) error {
+	if !p.isKeyword(word) {
+		return p.errorf("expected %q", word)
+	}
+	p.next()
+	return nil
+}
+
+func (p *tqlParser) errorf(format string, args ...any) error {
+	t := p.peek()
+	near := t.text
+	if t.kind == tqlEOF {
+		near = "end of query"
+	}
+	return fmt.Errorf("%s (at %d, near %q)", fmt.Sprintf(format, args...), t.pos, near)
+}
+
+// parseTaskQuery parses a task query into its AST.
+func parseTaskQuery(src string) (*tqlQuery, error) {
+	if len(src) > taskQueryMaxQueryText {
+		return nil, fmt.Errorf("query too long")
+	}
+
+	tokens, err := tqlLex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &tqlParser{tokens: tokens}
+	if err := p.expectKeyword("tasks"); err != nil {
+		return nil, err
+	}
+
+	q := &tqlQuery{limit: taskQueryRowCap}
+	if err := p.parseClauses(q); err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tqlEOF {
+		return nil, p.errorf("unexpected input")
+	}
+
+	return q, q.validate()
+}
+
+func (p *tqlParser) parseClauses(q *tqlQuery) error {
+	if p.isKeyword("where") {
+		p.next()
+		cond, err := p.parseOr()
+		if err != nil {
+			return err
+		}
+		q.where = cond
+	}
+
+	if p.isKeyword("group") {
+		p.next()
+		if err := p.expectKeyword("by"); err != nil {
+			return err
+		}
+		for {
+			f, err := p.parseField()
+			if err != nil {
+				return err
+			}
+			q.groupBy = append(q.groupBy, f)
+			if p.peek().kind != tqlComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.isKeyword("order") {
+		p.next()
+		if err := p.expectKeyword("by"); err != nil {
+			return err
+		}
+		key := p.next()
+		if key.kind != tqlWord {
+			return p.errorf("expected an order key")
+		}
+		q.orderBy = strings.ToLower(key.text)
+		if p.isKeyword("asc", "desc") {
+			q.desc = strings.EqualFold(p.next().text, "desc")
+		}
+	}
+
+	if p.isKeyword("histogram") {
+		p.next()
+		f, err := p.parseField()
+		if err != nil {
+			return err
+		}
+		q.histogram = f
+		q.bins = taskQueryDefaultBins
+		if p.isKeyword("bins") {
+			p.next()
+			n, err := p.parseCount()
+			if err != nil {
+				return err
+			}
+			q.bins = min(n, taskQueryMaxBins)
+		}
+	}
+
+	if p.isKeyword("limit") {
+		p.next()
+		n, err := p.parseCount()
+		if err != nil {
+			return err
+		}
+		q.limit = min(n, taskQueryRowCap)
+	}
+
+	return nil
+}
+
+func (p *tqlParser) parseCount() (int, error) {
+	t := p.next()
+	if t.kind != tqlNumber || t.num < 1 || t.num != math.Trunc(t.num) {
+		return 0, p.errorf("expected a positive integer")
+	}
+	return int(t.num), nil
+}
+
+func (p *tqlParser) parseField() (string, error) {
+	t := p.next()
+	if t.kind != tqlWord {
+		return "", p.errorf("expected a field name")
+	}
+	name := strings.ToLower(t.text)
+	if alias, ok := tqlFieldAliases[name]; ok {
+		name = alias
+	}
+	if _, ok := tqlFields[name]; !ok {
+		return "", fmt.Errorf("unknown field %q (fields: %s)", t.text,
+			strings.Join(tqlListColumns, ", "))
+	}
+	return name, nil
+}
+
+func (p *tqlParser) parseOr() (*tqlCond, error) {
+	return p.parseLogic("or", p.parseAnd)
+}
+
+func (p *tqlParser) parseAnd() (*tqlCond, error) {
+	return p.parseLogic("and", p.parseFactor)
+}
+
+func (p *tqlParser) parseLogic(word string, sub func() (*tqlCond, error)) (*tqlCond, error) {
+	first, err := sub()
+	if err != nil {
+		return nil, err
+	}
+
+	node := first
+	for p.isKeyword(word) {
+		p.next()
+		rhs, err := sub()
+		if err != nil {
+			return nil, err
+		}
+		if node == first {
+			node = &tqlCond{logic: word, children: []*tqlCond{first}}
+		}
+		node.children = append(node.children, rhs)
+	}
+
+	return node, nil
+}
+
+func (p *tqlParser) parseFactor() (*tqlCond, error) {
+	if p.isKeyword("not") {
+		p.next()
+		inner, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &tqlCond{logic: "not", children: []*tqlCond{inner}}, nil
+	}
+
+	if p.peek().kind == tqlLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tqlRParen {
+			return nil, p.errorf("expected ')'")
+		}
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *tqlParser) parseComparison() (*tqlCond, error) {
+	field, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+	op := p.next()
+	if op.kind != tqlOp {
+		return nil, p.errorf("expected a comparison operator after %q", field)
+	}
+
+	f := tqlFields[field]
+	v := p.next()
+	cond := &tqlCond{field: field, op: op.text}
+
+	switch {
+	case op.text == "~" || op.text == "!~":
+		if f.numeric {
+			return nil, fmt.Errorf("%q does not support glob matching", field)
+		}
+		if v.kind != tqlWord && v.kind != tqlString {
+			return nil, p.errorf("expected a glob pattern")
+		}
+		cond.value = v.text
+	case f.numeric:
+		if v.kind != tqlNumber {
+			return nil, p.errorf("expected a number for %q", field)
+		}
+		cond.value = v.num
+	default:
+		if op.text != "=" && op.text != "!=" {
+			return nil, fmt.Errorf("%q supports only =, !=, ~, and !~", field)
+		}
+		if v.kind == tqlEOF || v.kind == tqlOp || v.kind == tqlComma ||
+			v.kind == tqlLParen || v.kind == tqlRParen {
+			return nil, p.errorf("expected a value for %q", field)
+		}
+		cond.value = v.text
+	}
+
+	return cond, nil
+}
+
+func (q *tqlQuery) validate() error {
+	if q.histogram != "" {
+		if len(q.groupBy) > 0 || q.orderBy != "" {
+			return fmt.Errorf("histogram cannot be combined with group by or order by")
+		}
+		if !tqlFields[q.histogram].isTime {
+			return fmt.Errorf("histogram needs a time field (start, end, or duration)")
+		}
+		return nil
+	}
+
+	if q.orderBy == "" {
+		return nil
+	}
+	if alias, ok := tqlFieldAliases[q.orderBy]; ok {
+		q.orderBy = alias
+	}
+
+	if len(q.groupBy) == 0 {
+		if _, ok := tqlFields[q.orderBy]; !ok {
+			return fmt.Errorf("cannot order ungrouped tasks by %q", q.orderBy)
+		}
+		return nil
+	}
+
+	for _, g := range q.groupBy {
+		if g == q.orderBy {
+			return nil
+		}
+	}
+	for _, a := range tqlAggregates {
+		if a.name == q.orderBy {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot order groups by %q", q.orderBy)
+}
+
+// ---- Compiler ----
+
+// compileWhere renders the where-expression as SQL with bound arguments.
+func compileWhere(c *tqlCond, args *[]any) string {
+	switch c.logic {
+	case "and", "or":
+		parts := make([]string, len(c.children))
+		for i, child := range c.children {
+			parts[i] = compileWhere(child, args)
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(c.logic)+" ") + ")"
+	case "not":
+		return "NOT " + compileWhere(c.children[0], args)
+	}
+
+	*args = append(*args, c.value)
+
+	if c.field == "location" {
+		// Resolve the location ids first and probe trace by its Location index,
+		// as addQueryConditionsToQueryStr does for Where/Scope.
+		match, negate := tqlStringMatch(c.op)
+		in := "IN"
+		if negate {
+			in = "NOT IN"
+		}
+		return fmt.Sprintf("t.Location %s (SELECT ID FROM location WHERE Locale %s ?)", in, match)
+	}
+
+	expr := tqlFields[c.field].expr
+	switch c.op {
+	case "~", "!~":
+		match, negate := tqlStringMatch(c.op)
+		if negate {
+			return fmt.Sprintf("NOT (%s %s ?)", expr, match)
+		}
+		return fmt.Sprintf("%s %s ?", expr, match)
+	default:
+		return fmt.Sprintf("%s %s ?", expr, c.op)
+	}
+}
+
+// tqlStringMatch maps a string operator to its SQL comparison and whether the
+// comparison is negated.
+func tqlStringMatch(op string) (match string, negate bool) {
+	switch op {
+	case "~":
+		return "GLOB", false
+	case "!~":
+		return "GLOB", true
+	case "!=":
+		return "=", true
+	default:
+		return "=", false
+	}
+}
+
+// usesField reports whether the where-expression references field.
+func (c *tqlCond) usesField(field string) bool {
+	if c == nil {
+		return false
+	}
+	if c.field == field {
+		return true
+	}
+	for _, child := range c.children {
+		if child.usesField(field) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *tqlQuery) fromWhere(args *[]any) string {
+	s := "FROM trace t JOIN location loc ON t.Location = loc.ID"
+	if q.where != nil {
+		s += " WHERE " + compileWhere(q.where, args)
+	}
+	return s
+}
+
+// compileList renders an ungrouped task listing.
+func (q *tqlQuery) compileList() (string, []any) {
+	args := []any{}
+	cols := make([]string, len(tqlListColumns))
+	for i, name := range tqlListColumns {
+		f := tqlFields[name]
+		cols[i] = fmt.Sprintf("%s AS %s", f.expr, f.column)
+	}
+
+	s := "SELECT " + strings.Join(cols, ", ") + " " + q.fromWhere(&args)
+	if q.orderBy != "" {
+		s += " ORDER BY " + tqlFields[q.orderBy].column + tqlDirection(q.desc)
+	}
+	s += " LIMIT " + strconv.Itoa(q.limit)
+
+	return s, args
+}
+
+// compileGrouped renders a grouped aggregation. Percentiles are nearest-rank,
+// computed in SQL with window functions: each task is ranked by duration within
+// its group, and the pN row is the one at rank ceil(n*N/100).
+func (q *tqlQuery) compileGrouped() (string, []any) {
+	args := []any{}
+
+	groupCols := make([]string, len(q.groupBy))
+	selCols := make([]string, len(q.groupBy))
+	for i, g := range q.groupBy {
+		f := tqlFields[g]
+		groupCols[i] = f.column
+		selCols[i] = fmt.Sprintf("%s AS %s", f.expr, f.column)
+	}
+	partition := strings.Join(groupCols, ", ")
+
+	aggs := make([]string, len(tqlAggregates))
+	for i, a := range tqlAggregates {
+		expr := a.sql
+		if a.percentile > 0 {
+			expr = fmt.Sprintf("MAX(CASE WHEN rn = MAX(1, (n * %d + 99) / 100) THEN d END)",
+				a.percentile)
+		}
+		aggs[i] = fmt.Sprintf("%s AS %s", expr, a.name)
+	}
+
+	s := fmt.Sprintf(`WITH sel AS (SELECT %s, (t.EndTime - t.StartTime) AS d %s),
+ranked AS (SELECT *, ROW_NUMBER() OVER (PARTITION BY %s ORDER BY d) AS rn,
+	COUNT(*) OVER (PARTITION BY %s) AS n FROM sel)
+SELECT %s, %s FROM ranked GROUP BY %s`,
+		strings.Join(selCols, ", "), q.fromWhere(&args),
+		partition, partition,
+		partition, strings.Join(aggs, ", "), partition)
+
+	orderKey := q.orderBy
+	if f, ok := tqlFields[orderKey]; ok {
+		orderKey = f.column
+	}
+	if orderKey == "" {
+		orderKey, q.desc = "count", true
+	}
+	s += " ORDER BY " + orderKey + tqlDirection(q.desc) + " LIMIT " + strconv.Itoa(q.limit)
+
+	return s, args
+}
+
+func tqlDirection(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+	return " ASC"
+}
+
+// ---- Execution ----
+
+// TaskQueryHistogramBin is one histogram bucket over [Low, High).
+type TaskQueryHistogramBin struct {
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	Count int64   `json:"count"`
+}
+
+// TaskQueryResult is the result of a task query: a table (Columns/Rows) or, for
+// a histogram query, Bins over Field.
+type TaskQueryResult struct {
+	Kind      string                  `json:"kind"` // "table" or "histogram"
+	SQL       string                  `json:"sql"`
+	Columns   []string                `json:"columns,omitempty"`
+	Rows      [][]any                 `json:"rows,omitempty"`
+	Truncated bool                    `json:"truncated,omitempty"`
+	Field     string                  `json:"field,omitempty"`
+	Bins      []TaskQueryHistogramBin `json:"bins,omitempty"`
+}
+
+// RunTaskQuery parses, compiles, and runs a task query.
+func (r *SQLiteTraceReader) RunTaskQuery(ctx context.Context, src string) (TaskQueryResult, error) {
+	q, err := parseTaskQuery(src)
+	if err != nil {
+		return TaskQueryResult{}, err
+	}
+
+	r.ensureTaskLanguageIndexes(ctx, q)
+
+	id := r.activity.Begin("query", "Running task query", clip(src, 120))
+	defer r.activity.End(id)
+
+	qctx, cancel := context.WithTimeout(ctx, taskQueryTimeout)
+	defer cancel()
+
+	if q.histogram != "" {
+		return r.runTaskHistogram(qctx, q)
+	}
+
+	var sqlStr string
+	var args []any
+	if len(q.groupBy) > 0 {
+		sqlStr, args = q.compileGrouped()
+	} else {
+		sqlStr, args = q.compileList()
+	}
+
+	return r.runTaskTable(qctx, sqlStr, args)
+}
+
+// ensureTaskLanguageIndexes builds the indexes the compiled query's filters
+// probe, mirroring ensureTaskQueryIndexes.
+func (r *SQLiteTraceReader) ensureTaskLanguageIndexes(ctx context.Context, q *tqlQuery) {
+	if q.where.usesField("location") {
+		r.ensureIndex(ctx, "Building index idx_trace_loc_time_id",
+			`CREATE INDEX IF NOT EXISTS idx_trace_loc_time_id `+
+				`ON trace(Location, StartTime, EndTime, ID)`)
+	}
+	if q.where.usesField("id") {
+		r.ensureIndex(ctx, "Building index idx_trace_ID",
+			"CREATE INDEX IF NOT EXISTS idx_trace_ID ON trace(ID)")
+	}
+	if q.where.usesField("parent") {
+		r.ensureIndex(ctx, "Building index idx_trace_ParentID",
+			"CREATE INDEX IF NOT EXISTS idx_trace_ParentID ON trace(ParentID)")
+	}
+}
+
+func (r *SQLiteTraceReader) runTaskTable(
+	ctx context.Context, sqlStr string, args []any,
+) (TaskQueryResult, error) {
+	safe, err := sanitizeReadonlySQL(sqlStr, taskQueryRowCap)
+	if err != nil {
+		return TaskQueryResult{}, err
+	}
+
+	res := TaskQueryResult{Kind: "table", SQL: safe, Rows: [][]any{}}
+	err = queryReadonly(ctx, r, safe, args, func(rows *sql.Rows) error {
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		res.Columns = cols
+
+		for rows.Next() {
+			vals := make([]any, len(cols))
+			ptrs := make([]any, len(cols))
+			for i := range vals {
+				ptrs[i] = &vals[i]
+			}
+			if err := rows.Scan(ptrs...); err != nil {
+				return err
+			}
+			for i, v := range vals {
+				if b, ok := v.([]byte); ok {
+					vals[i] = string(b)
+				}
+			}
+			res.Rows = append(res.Rows, vals)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return TaskQueryResult{}, err
+	}
+
+	res.Truncated = len(res.Rows) >= taskQueryRowCap
+
+	return res, nil
+}
+
+func (r *SQLiteTraceReader) runTaskHistogram(
+	ctx context.Context, q *tqlQuery,
+) (TaskQueryResult, error) {
+	args := []any{}
+	inner := fmt.Sprintf("SELECT %s AS v %s", tqlFields[q.histogram].expr, q.fromWhere(&args))
+
+	res := TaskQueryResult{Kind: "histogram", Field: q.histogram,
+		Bins: []TaskQueryHistogramBin{}}
+
+	rangeSQL, err := sanitizeReadonlySQL(
+		"SELECT MIN(v), MAX(v) FROM ("+inner+")", taskQueryRowCap)
+	if err != nil {
+		return res, err
+	}
+
+	var lo, hi sql.NullFloat64
+	err = queryReadonly(ctx, r, rangeSQL, args, func(rows *sql.Rows) error {
+		if rows.Next() {
+			return rows.Scan(&lo, &hi)
+		}
+		return rows.Err()
+	})
+	if err != nil || !lo.Valid {
+		return res, err
+	}
+
+	width := (hi.Float64 - lo.Float64) / float64(q.bins)
+	if width <= 0 {
+		width = 1
+	}
+	for i := 0; i < q.bins; i++ {
+		res.Bins = append(res.Bins, TaskQueryHistogramBin{
+			Low:  lo.Float64 + float64(i)*width,
+			High: lo.Float64 + float64(i+1)*width,
+		})
+	}
+
+	binSQL, err := sanitizeReadonlySQL(fmt.Sprintf(
+		"SELECT MIN(CAST((v - ?) / ? AS INTEGER), %d) AS b, COUNT(*) FROM (%s) GROUP BY b",
+		q.bins-1, inner), taskQueryRowCap)
+	if err != nil {
+		return res, err
+	}
+	res.SQL = binSQL
+
+	binArgs := append([]any{lo.Float64, width}, args...)
+	err = queryReadonly(ctx, r, binSQL, binArgs, func(rows *sql.Rows) error {
+		for rows.Next() {
+			var b int
+			var n int64
+			if err := rows.Scan(&b, &n); err != nil {
+				return err
+			}
+			if b >= 0 && b < len(res.Bins) {
+				res.Bins[b].Count += n
+			}
+		}
+		return rows.Err()
+	})
+
+	return res, err
+}
+
+// httpTaskQuery runs the task query in ?q= and returns a TaskQueryResult. Parse
+// and compile errors are the user's to fix, so they come back as 400s with the
+// message as the body.
+func (s *Server) httpTaskQuery(w http.ResponseWriter, r *http.Request) {
+	if s.traceReader == nil {
+		http.Error(w, "trace data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	res, err := s.traceReader.RunTaskQuery(r.Context(), r.FormValue("q"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, res)
+}
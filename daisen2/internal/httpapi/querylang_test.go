@@ -0,0 +1,191 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// seedQueryTrace builds a trace with two L2 banks and one L1: L2 read requests
+// take 1, 2, and 9 us; the L2 write takes 400 ns; the L1 read 600 ns.
+func seedQueryTrace(t *testing.T) *SQLiteTraceReader {
+	t.Helper()
+	reader := newTestTraceReader(t)
+	stmts := []string{
+		`INSERT INTO location (ID, Locale) VALUES
+			(1, 'L2[0].Bank'), (2, 'L2[1].Bank'), (3, 'L1.Top')`,
+		`INSERT INTO trace VALUES (1, 0, 'req_in', 'ReadReq', 1, 0, 1000000)`,
+		`INSERT INTO trace VALUES (2, 0, 'req_in', 'ReadReq', 2, 0, 2000000)`,
+		`INSERT INTO trace VALUES (3, 0, 'req_in', 'ReadReq', 1, 500000, 9500000)`,
+		`INSERT INTO trace VALUES (4, 0, 'req_in', 'WriteReq', 2, 0, 400000)`,
+		`INSERT INTO trace VALUES (5, 1, 'req_in', 'ReadReq', 3, 0, 600000)`,
+		`INSERT INTO trace VALUES (6, 1, 'req_out', 'ReadReq', 3, 0, 100000)`,
+	}
+	for _, s := range stmts {
+		if _, err := reader.Exec(s); err != nil {
+			t.Fatalf("seed %q: %v", s, err)
+		}
+	}
+	return reader
+}
+
+func TestParseTaskQueryErrors(t *testing.T) {
+	bad := []string{
+		"",
+		"task where kind=x",
+		"tasks where bogus=1",
+		"tasks where duration>fast",
+		"tasks where duration>5parsecs",
+		"tasks where kind>3",
+		"tasks where duration~\"5*\"",
+		"tasks where kind=\"unterminated",
+		"tasks where (kind=a",
+		"tasks order by p99",
+		"tasks group by what order by nonsense",
+		"tasks histogram what",
+		"tasks group by what histogram duration",
+		"tasks limit 0",
+		"tasks where kind=a; DROP TABLE trace",
+	}
+	for _, src := range bad {
+		if _, err := parseTaskQuery(src); err == nil {
+			t.Errorf("expected %q to be rejected", src)
+		}
+	}
+}
+
+func TestParseTaskQueryUnitsAndAliases(t *testing.T) {
+	q, err := parseTaskQuery(`TASKS WHERE latency >= 1.5us AND loc ~ 'L2*' ORDER BY duration DESC LIMIT 5`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if q.where.logic != "and" || len(q.where.children) != 2 {
+		t.Fatalf("unexpected where tree: %+v", q.where)
+	}
+	dur := q.where.children[0]
+	if dur.field != "duration" || dur.op != ">=" || dur.value != 1.5e6 {
+		t.Fatalf("unexpected duration condition: %+v", dur)
+	}
+	if loc := q.where.children[1]; loc.field != "location" || loc.value != "L2*" {
+		t.Fatalf("unexpected location condition: %+v", loc)
+	}
+	if q.orderBy != "duration" || !q.desc || q.limit != 5 {
+		t.Fatalf("unexpected order/limit: %+v", q)
+	}
+}
+
+func TestRunTaskQueryGroupedPercentiles(t *testing.T) {
+	reader := seedQueryTrace(t)
+
+	res, err := reader.RunTaskQuery(context.Background(),
+		`tasks where kind=req_in and location~"L2*" and duration>500ns group by what order by p99 desc`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if res.Kind != "table" {
+		t.Fatalf("kind = %q", res.Kind)
+	}
+
+	col := map[string]int{}
+	for i, c := range res.Columns {
+		col[c] = i
+	}
+	// Only the three L2 reads exceed 500ns; the write (400ns) and L1 are filtered.
+	if len(res.Rows) != 1 {
+		t.Fatalf("expected one group, got %+v", res.Rows)
+	}
+	row := res.Rows[0]
+	if row[col["what"]] != "ReadReq" || row[col["count"]] != int64(3) {
+		t.Fatalf("unexpected group row: %v", row)
+	}
+	if row[col["p50"]] != 2e6 || row[col["p99"]] != 9e6 || row[col["min"]] != 1e6 {
+		t.Fatalf("unexpected percentiles: p50=%v p99=%v min=%v",
+			row[col["p50"]], row[col["p99"]], row[col["min"]])
+	}
+}
+
+func TestRunTaskQueryListOrOrderNot(t *testing.T) {
+	reader := seedQueryTrace(t)
+
+	res, err := reader.RunTaskQuery(context.Background(),
+		`tasks where (what=WriteReq or location="L1.Top") and not kind=req_out order by duration desc`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(res.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", res.Rows)
+	}
+	if res.Rows[0][0] != int64(5) || res.Rows[1][0] != int64(4) {
+		t.Fatalf("expected tasks 5 then 4 by duration desc, got %v", res.Rows)
+	}
+	if !strings.Contains(res.SQL, "LIMIT") {
+		t.Fatalf("compiled SQL must carry a LIMIT: %s", res.SQL)
+	}
+}
+
+func TestRunTaskQueryHistogram(t *testing.T) {
+	reader := seedQueryTrace(t)
+
+	res, err := reader.RunTaskQuery(context.Background(),
+		`tasks where kind=req_in histogram duration bins 4`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if res.Kind != "histogram" || len(res.Bins) != 4 {
+		t.Fatalf("unexpected histogram: %+v", res)
+	}
+	var total int64
+	for _, b := range res.Bins {
+		total += b.Count
+	}
+	if total != 5 {
+		t.Fatalf("expected 5 req_in tasks across bins, got %d (%+v)", total, res.Bins)
+	}
+	// The 9us outlier lands alone in the last bin.
+	if res.Bins[3].Count != 1 {
+		t.Fatalf("expected the outlier alone in the last bin, got %+v", res.Bins)
+	}
+}
+
+func TestRunTaskQueryValuesAreBound(t *testing.T) {
+	reader := seedQueryTrace(t)
+
+	// A quoted value with SQL in it is just a string that matches nothing.
+	res, err := reader.RunTaskQuery(context.Background(),
+		`tasks where what="x' OR '1'='1"`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(res.Rows) != 0 {
+		t.Fatalf("expected no rows, got %v", res.Rows)
+	}
+}
+
+func TestHTTPTaskQuery(t *testing.T) {
+	s := &Server{traceReader: seedQueryTrace(t)}
+
+	rec := httptest.NewRecorder()
+	s.httpTaskQuery(rec, httptest.NewRequest(http.MethodGet,
+		"/api/task_query?q="+url.QueryEscape("tasks group by kind"), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	var res TaskQueryResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(res.Rows) != 2 {
+		t.Fatalf("expected 2 kinds, got %v", res.Rows)
+	}
+
+	rec = httptest.NewRecorder()
+	s.httpTaskQuery(rec, httptest.NewRequest(http.MethodGet,
+		"/api/task_query?q="+url.QueryEscape("tasks where nope=1"), nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad query, got %d", rec.Code)
+	}
+}
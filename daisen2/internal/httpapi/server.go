@@ -36,6 +36,10 @@ type Server struct {
 	// browser that will POST the image back to /api/agent/capture (Phase 5).
 	captures   map[string]chan string
 	capturesMu sync.Mutex
+
+	// sessions stores saved analysis sessions in a sidecar file next to the
+	// trace. Nil when no trace file is known.
+	sessions *SessionStore
 }
 
 // NewReplayServer creates a new Server in replay mode. It reads trace data
@@ -54,11 +58,13 @@ func NewReplayServer(sqliteFile, addr string) *Server {
 		traceReader: reader,
 		fs:          static.GetAssets(),
 		codeSource:  loadCodeSource(reader),
+		sessions:    NewSessionStore(sessionStorePath(sqliteFile)),
 	}
 }
 
 // NewReplayServerReadOnly creates a Server with a read-only SQLite connection.
-// Used for concurrent trace access while DBTracer writes.
+// Used for concurrent trace access while DBTracer writes. Saved sessions can be
+// listed and opened, but not saved, imported, or deleted.
 func NewReplayServerReadOnly(sqliteFile string) *Server {
 	if sqliteFile == "" {
 		panic("must specify a SQLite file")
@@ -72,6 +78,7 @@ func NewReplayServerReadOnly(sqliteFile string) *Server {
 		traceReader: reader,
 		fs:          static.GetAssets(),
 		codeSource:  loadCodeSource(reader),
+		sessions:    NewReadOnlySessionStore(sessionStorePath(sqliteFile)),
 	}
 }
 
@@ -124,6 +131,12 @@ func (s *Server) Stop() {
 			log.Printf("Error shutting down server: %v", err)
 		}
 	}
+
+	if s.sessions != nil {
+		if err := s.sessions.Close(); err != nil {
+			log.Printf("Error closing session store: %v", err)
+		}
+	}
 }
 
 // StopServer stops the server (alias for Stop).
@@ -164,12 +177,21 @@ func (s *Server) RegisterTraceAPIRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/segments", s.httpSegments)
 	mux.HandleFunc("/api/sim_info", s.httpSimInfo)
 	mux.HandleFunc("/api/topology", s.httpTopology)
+	mux.HandleFunc("/api/topology/export", s.httpTopologyExport)
 	mux.HandleFunc("/api/components", s.httpComponents)
 	mux.HandleFunc("/api/db_info", s.httpDBInfo)
 	mux.HandleFunc("/api/db_activity", s.httpDBActivity)
+	mux.HandleFunc("/api/task_query", s.httpTaskQuery)
+	mux.HandleFunc("/api/flamegraph", s.httpFlameGraph)
 	mux.HandleFunc("/api/code/ls", s.httpCodeLs)
 	mux.HandleFunc("/api/code/read", s.httpCodeRead)
 
+	// Saved analysis sessions (sidecar store next to the trace).
+	mux.HandleFunc("/api/sessions", s.httpSessions)
+	mux.HandleFunc("/api/session", s.httpSession)
+	mux.HandleFunc("/api/session/export", s.httpSessionExport)
+	mux.HandleFunc("/api/session/import", s.httpSessionImport)
+
 	// Chat / LLM proxy endpoints. The LLM provider is configured entirely from
 	// the frontend; the server holds no credentials.
 	mux.HandleFunc("/api/gpt", s.httpChatProxy)
@@ -189,6 +211,8 @@ func (s *Server) RegisterTraceRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/component", s.serveIndex)
 	mux.HandleFunc("/task", s.serveIndex)
 	mux.HandleFunc("/resource", s.serveIndex)
+	mux.HandleFunc("/query", s.serveIndex)
+	mux.HandleFunc("/flamegraph", s.serveIndex)
 	// Enlarged single-widget pages (/view/<widget>). A trailing-slash pattern
 	// matches the whole subtree so a hard refresh serves the SPA shell.
 	mux.HandleFunc("/view/", s.serveIndex)
@@ -222,21 +246,28 @@ func (s *Server) apiMode(w http.ResponseWriter, _ *http.Request) {
 // are never touched.
 func (s *Server) httpTraceInfo(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	id := ""
-	if s.traceReader != nil {
-		path := s.traceReader.filename
-		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
-		// Suffix a short hash of the absolute path so two different traces that share
-		// a basename (e.g. repeated experiment outputs named trace.sqlite in separate
-		// directories) get distinct ids and don't share browser-stored conversations.
-		abs, err := filepath.Abs(path)
-		if err != nil {
-			abs = path
-		}
-		sum := sha256.Sum256([]byte(abs))
-		id = fmt.Sprintf("%s-%x", base, sum[:4])
+	fmt.Fprintf(w, `{"traceId":%q}`, s.traceID())
+}
+
+// traceID derives the loaded trace's stable id from its file name, or "" when no
+// trace is loaded.
+func (s *Server) traceID() string {
+	if s.traceReader == nil {
+		return ""
+	}
+
+	path := s.traceReader.filename
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	// Suffix a short hash of the absolute path so two different traces that share
+	// a basename (e.g. repeated experiment outputs named trace.sqlite in separate
+	// directories) get distinct ids and don't share browser-stored conversations.
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
 	}
-	fmt.Fprintf(w, `{"traceId":%q}`, id)
+	sum := sha256.Sum256([]byte(abs))
+
+	return fmt.Sprintf("%s-%x", base, sum[:4])
 }
 
 func (s *Server) serveIndex(w http.ResponseWriter, _ *http.Request) {
@@ -0,0 +1,577 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---- Saved analysis sessions -------------------------------------------------
+//
+// A session is a named snapshot of an investigation: the view the user was on,
+// the components and time ranges they selected, the tasks they pinned, the notes
+// they wrote on those tasks, and the Daisen Bot conversations they had. Sessions
+// live in a sidecar SQLite file next to the trace (never in the trace itself, so
+// a trace opened read-only while a DBTracer writes it can still carry sessions),
+// which makes them travel with the trace when a teammate copies both files. A
+// session can also be exported as JSON and imported on another server.
+//
+// Every session is stamped with the id of the trace it was saved on, derived
+// from the trace's content rather than its path, so a session loaded or imported
+// onto a different trace is caught.
+
+// sessionStoreSuffix is appended to the trace file name to form the sidecar path.
+const sessionStoreSuffix = ".sessions.sqlite3"
+
+// maxSessionBytes bounds an uploaded session. Conversations are the only large
+// part, and their captured images are stripped by the frontend before saving.
+const maxSessionBytes = 8 << 20
+
+// SessionTimeRange is one selected span of simulated time.
+type SessionTimeRange struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// SessionAnnotation is a note the user attached to a task.
+type SessionAnnotation struct {
+	TaskID  uint64 `json:"task_id"`
+	Note    string `json:"note"`
+	Created int64  `json:"created"`
+}
+
+// Session is a saved analysis session. View is the app-relative URL (path and
+// query) of the page being viewed, so loading a session reopens exactly that
+// view. Conversations are stored verbatim: their shape belongs to the frontend's
+// conversation store.
+type Session struct {
+	Name          string              `json:"name"`
+	TraceID       string              `json:"trace_id"`
+	Created       int64               `json:"created"`
+	Updated       int64               `json:"updated"`
+	View          string              `json:"view"`
+	Components    []string            `json:"components"`
+	TimeRanges    []SessionTimeRange  `json:"time_ranges"`
+	TaskIDs       []uint64            `json:"task_ids"`
+	Annotations   []SessionAnnotation `json:"annotations"`
+	Conversations json.RawMessage     `json:"conversations"`
+}
+
+// SessionSummary is the list-view form of a session.
+type SessionSummary struct {
+	Name            string `json:"name"`
+	Created         int64  `json:"created"`
+	Updated         int64  `json:"updated"`
+	View            string `json:"view"`
+	AnnotationCount int    `json:"annotation_count"`
+}
+
+// errSessionNotFound is returned when a named session does not exist.
+var errSessionNotFound = errors.New("session not found")
+
+// errSessionsReadOnly is returned when a read-only store is asked to change.
+var errSessionsReadOnly = errors.New("sessions are read-only")
+
+// errSessionTraceMismatch is returned when a session was saved on a different
+// trace than the one loaded.
+var errSessionTraceMismatch = errors.New("session was saved on a different trace")
+
+// SessionStore persists sessions in a sidecar SQLite file. The file is created
+// by the first save; listing and loading read it only if it exists, so simply
+// viewing a trace never leaves a sidecar behind.
+type SessionStore struct {
+	filename string
+	readOnly bool
+
+	mu sync.Mutex
+	db *sql.DB
+}
+
+// NewSessionStore creates a store backed by the given SQLite file.
+func NewSessionStore(filename string) *SessionStore {
+	return &SessionStore{filename: filename}
+}
+
+// NewReadOnlySessionStore creates a store that lists and loads the sessions in
+// the given SQLite file, if it exists, but never creates or changes it.
+func NewReadOnlySessionStore(filename string) *SessionStore {
+	return &SessionStore{filename: filename, readOnly: true}
+}
+
+// sessionStorePath returns the sidecar session-store path for a trace file.
+func sessionStorePath(traceFile string) string {
+	return traceFile + sessionStoreSuffix
+}
+
+// open returns the store's database. Unless create is set, it returns a nil
+// database, and no error, if the file does not exist yet.
+func (s *SessionStore) open(ctx context.Context, create bool) (*sql.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db, nil
+	}
+
+	if create && s.readOnly {
+		return nil, errSessionsReadOnly
+	}
+
+	if !create {
+		_, err := os.Stat(s.filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.readOnly {
+		db, err := sql.Open("sqlite3", s.filename+"?mode=ro")
+		if err != nil {
+			return nil, err
+		}
+
+		s.db = db
+
+		return db, nil
+	}
+
+	db, err := sql.Open("sqlite3", s.filename)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS session (
+			Name    TEXT PRIMARY KEY,
+			TraceID TEXT NOT NULL,
+			Created INTEGER NOT NULL,
+			Updated INTEGER NOT NULL,
+			View    TEXT NOT NULL,
+			Data    TEXT NOT NULL
+		)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s.db = db
+
+	return db, nil
+}
+
+// Close releases the underlying database, if it was ever opened.
+func (s *SessionStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return nil
+	}
+
+	err := s.db.Close()
+	s.db = nil
+
+	return err
+}
+
+// Save creates or replaces the named session. The creation time of an existing
+// session is preserved; the update time is always refreshed.
+func (s *SessionStore) Save(ctx context.Context, session Session) (Session, error) {
+	session.Name = strings.TrimSpace(session.Name)
+	if session.Name == "" {
+		return Session{}, fmt.Errorf("session name must not be empty")
+	}
+
+	db, err := s.open(ctx, true)
+	if err != nil {
+		return Session{}, err
+	}
+
+	now := time.Now().Unix()
+	session.Updated = now
+	session.Created = now
+
+	var created int64
+	err = db.QueryRowContext(ctx,
+		`SELECT Created FROM session WHERE Name = ?`, session.Name).Scan(&created)
+	if err == nil {
+		session.Created = created
+	}
+
+	normalizeSession(&session)
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return Session{}, err
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO session (Name, TraceID, Created, Updated, View, Data)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(Name) DO UPDATE SET
+			TraceID = excluded.TraceID,
+			Updated = excluded.Updated,
+			View    = excluded.View,
+			Data    = excluded.Data`,
+		session.Name, session.TraceID, session.Created, session.Updated,
+		session.View, string(data))
+	if err != nil {
+		return Session{}, err
+	}
+
+	return session, nil
+}
+
+// normalizeSession replaces nil collections with empty ones so a stored session
+// always serializes to the same shape.
+func normalizeSession(session *Session) {
+	if session.Components == nil {
+		session.Components = []string{}
+	}
+	if session.TimeRanges == nil {
+		session.TimeRanges = []SessionTimeRange{}
+	}
+	if session.TaskIDs == nil {
+		session.TaskIDs = []uint64{}
+	}
+	if session.Annotations == nil {
+		session.Annotations = []SessionAnnotation{}
+	}
+	if len(session.Conversations) == 0 {
+		session.Conversations = json.RawMessage("[]")
+	}
+}
+
+// Load returns the named session, or errSessionNotFound.
+func (s *SessionStore) Load(ctx context.Context, name string) (Session, error) {
+	db, err := s.open(ctx, false)
+	if err != nil {
+		return Session{}, err
+	}
+	if db == nil {
+		return Session{}, errSessionNotFound
+	}
+
+	var data string
+	err = db.QueryRowContext(ctx,
+		`SELECT Data FROM session WHERE Name = ?`, name).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, errSessionNotFound
+	}
+	if err != nil {
+		return Session{}, err
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return Session{}, err
+	}
+	normalizeSession(&session)
+
+	return session, nil
+}
+
+// List returns a summary of every saved session, most recently updated first.
+func (s *SessionStore) List(ctx context.Context) ([]SessionSummary, error) {
+	summaries := []SessionSummary{}
+
+	db, err := s.open(ctx, false)
+	if err != nil || db == nil {
+		return summaries, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT Name, Created, Updated, View, json_array_length(Data, '$.annotations')
+		FROM session
+		ORDER BY Updated DESC, Name`)
+	if err != nil {
+		return summaries, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sum SessionSummary
+		var annotations sql.NullInt64
+		if err := rows.Scan(&sum.Name, &sum.Created, &sum.Updated, &sum.View,
+			&annotations); err != nil {
+			return summaries, err
+		}
+		sum.AnnotationCount = int(annotations.Int64)
+		summaries = append(summaries, sum)
+	}
+
+	return summaries, rows.Err()
+}
+
+// Delete removes the named session, or returns errSessionNotFound.
+func (s *SessionStore) Delete(ctx context.Context, name string) error {
+	if s.readOnly {
+		return errSessionsReadOnly
+	}
+
+	db, err := s.open(ctx, false)
+	if err != nil {
+		return err
+	}
+	if db == nil {
+		return errSessionNotFound
+	}
+
+	res, err := db.ExecContext(ctx, `DELETE FROM session WHERE Name = ?`, name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errSessionNotFound
+	}
+
+	return nil
+}
+
+// sessionTraceIDRows is how many of the first trace rows identify a trace.
+const sessionTraceIDRows = 16
+
+// sessionTraceID identifies the loaded trace by its content: the start of run
+// metadata in exec_info and the first rows of the trace table. Both are
+// written when the simulation starts and never change afterwards, so the id
+// survives copying and renaming the trace, and stays the same while a
+// DBTracer is still appending to it. Missing tables are skipped.
+func (s *Server) sessionTraceID(ctx context.Context) string {
+	if s.traceReader == nil {
+		return ""
+	}
+
+	h := sha256.New()
+	queries := []string{
+		`SELECT Property, Value FROM exec_info
+			WHERE Property IN ('Start Time', 'Command', 'Working Directory')
+			ORDER BY Property`,
+		fmt.Sprintf(`SELECT ID, ParentID, Kind, What, Location, StartTime, EndTime
+			FROM trace ORDER BY rowid LIMIT %d`, sessionTraceIDRows),
+	}
+
+	for _, q := range queries {
+		rows, err := s.traceReader.QueryContext(ctx, q)
+		if err != nil {
+			continue
+		}
+
+		cols, _ := rows.Columns()
+		values := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+
+		for rows.Next() {
+			if err := rows.Scan(ptrs...); err != nil {
+				continue
+			}
+			fmt.Fprintln(h, values...)
+		}
+		rows.Close()
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)[:8])
+}
+
+// checkSessionTrace returns errSessionTraceMismatch if a session was saved on
+// a different trace than the loaded one, unless the request sets force=1.
+func (s *Server) checkSessionTrace(r *http.Request, session Session) error {
+	if r.FormValue("force") == "1" || session.TraceID == "" {
+		return nil
+	}
+
+	if session.TraceID != s.sessionTraceID(r.Context()) {
+		return errSessionTraceMismatch
+	}
+
+	return nil
+}
+
+// ---- HTTP handlers -----------------------------------------------------------
+
+// httpSessions lists saved sessions (GET) or saves one (POST, JSON Session
+// body). Saving stamps the session with the loaded trace's content id, so the
+// session can be checked against the trace it is later opened on.
+func (s *Server) httpSessions(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		http.Error(w, "sessions not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		summaries, err := s.sessions.List(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, summaries)
+	case http.MethodPost:
+		var session Session
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSessionBytes)).
+			Decode(&session)
+		if err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+		session.TraceID = s.sessionTraceID(r.Context())
+
+		saved, err := s.sessions.Save(r.Context(), session)
+		if errors.Is(err, errSessionsReadOnly) {
+			writeSessionErr(w, err)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, saved)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// httpSession loads (GET) or deletes (DELETE) the session named by ?name=.
+// Loading a session saved on a different trace fails with 409 Conflict unless
+// ?force=1 is given.
+func (s *Server) httpSession(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		http.Error(w, "sessions not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := r.FormValue("name")
+	if name == "" {
+		http.Error(w, "missing name", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		session, err := s.sessions.Load(r.Context(), name)
+		if err == nil {
+			err = s.checkSessionTrace(r, session)
+		}
+		if !writeSessionErr(w, err) {
+			writeJSON(w, session)
+		}
+	case http.MethodDelete:
+		if !writeSessionErr(w, s.sessions.Delete(r.Context(), name)) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// httpSessionExport serves the session named by ?name= as a downloadable JSON
+// file, for sharing outside the sidecar (e.g. attached to a bug report). The
+// file can be imported back with httpSessionImport.
+func (s *Server) httpSessionExport(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		http.Error(w, "sessions not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	session, err := s.sessions.Load(r.Context(), r.FormValue("name"))
+	if writeSessionErr(w, err) {
+		return
+	}
+
+	rsp, err := json.MarshalIndent(session, "", "  ")
+	dieOnErr(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", sessionFileName(session.Name)))
+	_, err = w.Write(rsp)
+	dieOnErr(err)
+}
+
+// httpSessionImport saves a session from an exported JSON file (POST body),
+// replacing any session of the same name. A session exported from a different
+// trace is rejected with 409 Conflict unless ?force=1 is given; a forced import
+// is restamped with the loaded trace's id.
+func (s *Server) httpSessionImport(w http.ResponseWriter, r *http.Request) {
+	if s.sessions == nil {
+		http.Error(w, "sessions not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var session Session
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSessionBytes)).
+		Decode(&session)
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if writeSessionErr(w, s.checkSessionTrace(r, session)) {
+		return
+	}
+	session.TraceID = s.sessionTraceID(r.Context())
+
+	saved, err := s.sessions.Save(r.Context(), session)
+	if errors.Is(err, errSessionsReadOnly) {
+		writeSessionErr(w, err)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, saved)
+}
+
+// writeSessionErr writes the HTTP error for a session-store error and reports
+// whether it wrote one.
+func writeSessionErr(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, errSessionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errSessionsReadOnly):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, errSessionTraceMismatch):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+
+	return true
+}
+
+// sessionFileName turns a session name into a safe download file name.
+func sessionFileName(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+
+	return "daisen-session-" + safe + ".json"
+}
@@ -0,0 +1,256 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestSessionServer(t *testing.T) *Server {
+	t.Helper()
+
+	reader := newTestReader(t)
+	store := NewSessionStore(sessionStorePath(reader.filename))
+	t.Cleanup(func() {
+		store.Close()
+		reader.Close()
+	})
+
+	return &Server{traceReader: reader, sessions: store}
+}
+
+func TestSessionStoreSaveLoadRoundTrip(t *testing.T) {
+	store := NewSessionStore(filepath.Join(t.TempDir(), "trace.sqlite3.sessions.sqlite3"))
+	defer store.Close()
+	ctx := context.Background()
+
+	saved, err := store.Save(ctx, Session{
+		Name:          " l2-hang ",
+		View:          "/component?name=L2&starttime=10&endtime=20",
+		Components:    []string{"L2", "DRAM"},
+		TimeRanges:    []SessionTimeRange{{StartTime: 10, EndTime: 20}},
+		TaskIDs:       []uint64{7, 9},
+		Annotations:   []SessionAnnotation{{TaskID: 7, Note: "stuck on MSHR"}},
+		Conversations: json.RawMessage(`[{"id":"c1","messages":[]}]`),
+	})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if saved.Name != "l2-hang" {
+		t.Fatalf("name should be trimmed, got %q", saved.Name)
+	}
+
+	got, err := store.Load(ctx, "l2-hang")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got.View != saved.View || len(got.Components) != 2 || got.TaskIDs[1] != 9 {
+		t.Fatalf("unexpected loaded session: %+v", got)
+	}
+	if got.Annotations[0].Note != "stuck on MSHR" {
+		t.Fatalf("annotation lost: %+v", got.Annotations)
+	}
+	if string(got.Conversations) != `[{"id":"c1","messages":[]}]` {
+		t.Fatalf("conversations not stored verbatim: %s", got.Conversations)
+	}
+
+	// Re-saving keeps the creation time and replaces the content.
+	resaved, err := store.Save(ctx, Session{Name: "l2-hang", View: "/dashboard"})
+	if err != nil {
+		t.Fatalf("resave: %v", err)
+	}
+	if resaved.Created != saved.Created {
+		t.Fatalf("created changed on resave: %d -> %d", saved.Created, resaved.Created)
+	}
+	got, _ = store.Load(ctx, "l2-hang")
+	if got.View != "/dashboard" || len(got.Components) != 0 {
+		t.Fatalf("resave did not replace the session: %+v", got)
+	}
+}
+
+func TestSessionStoreRejectsEmptyName(t *testing.T) {
+	store := NewSessionStore(filepath.Join(t.TempDir(), "s.sqlite3"))
+	defer store.Close()
+
+	if _, err := store.Save(context.Background(), Session{Name: "  "}); err == nil {
+		t.Fatal("expected an error for an empty session name")
+	}
+}
+
+func TestSessionStoreDeleteMissing(t *testing.T) {
+	store := NewSessionStore(filepath.Join(t.TempDir(), "s.sqlite3"))
+	defer store.Close()
+
+	err := store.Delete(context.Background(), "nope")
+	if err != errSessionNotFound {
+		t.Fatalf("expected errSessionNotFound, got %v", err)
+	}
+}
+
+func TestHTTPSessionsSaveListLoadExport(t *testing.T) {
+	s := newTestSessionServer(t)
+
+	body := `{"name":"roi","view":"/dashboard?starttime=1","annotations":[{"task_id":3,"note":"slow"}]}`
+	rec := httptest.NewRecorder()
+	s.httpSessions(rec, httptest.NewRequest(http.MethodPost, "/api/sessions",
+		bytes.NewBufferString(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("save status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	var saved Session
+	if err := json.Unmarshal(rec.Body.Bytes(), &saved); err != nil {
+		t.Fatalf("unmarshal saved: %v", err)
+	}
+	if saved.TraceID == "" || saved.TraceID != s.sessionTraceID(context.Background()) {
+		t.Fatalf("session not stamped with the trace id: %q", saved.TraceID)
+	}
+
+	rec = httptest.NewRecorder()
+	s.httpSessions(rec, httptest.NewRequest(http.MethodGet, "/api/sessions", nil))
+	var list []SessionSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("unmarshal list: %v (%s)", err, rec.Body.String())
+	}
+	if len(list) != 1 || list[0].Name != "roi" || list[0].AnnotationCount != 1 {
+		t.Fatalf("unexpected session list: %+v", list)
+	}
+
+	rec = httptest.NewRecorder()
+	s.httpSession(rec, httptest.NewRequest(http.MethodGet, "/api/session?name=roi", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"slow"`) {
+		t.Fatalf("load: status %d body %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.httpSessionExport(rec, httptest.NewRequest(http.MethodGet,
+		"/api/session/export?name=roi", nil))
+	if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd,
+		"daisen-session-roi.json") {
+		t.Fatalf("unexpected Content-Disposition %q", cd)
+	}
+
+	rec = httptest.NewRecorder()
+	s.httpSession(rec, httptest.NewRequest(http.MethodDelete, "/api/session?name=roi", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.httpSession(rec, httptest.NewRequest(http.MethodGet, "/api/session?name=roi", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("load after delete status = %d", rec.Code)
+	}
+}
+
+func TestSessionStoreListDoesNotCreateTheSidecar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.sqlite3")
+	store := NewSessionStore(path)
+	defer store.Close()
+	ctx := context.Background()
+
+	list, err := store.List(ctx)
+	if err != nil || len(list) != 0 {
+		t.Fatalf("list = %v, %v", list, err)
+	}
+	if _, err := store.Load(ctx, "roi"); err != errSessionNotFound {
+		t.Fatalf("expected errSessionNotFound, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("listing created the sidecar: %v", err)
+	}
+}
+
+func TestReadOnlySessionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.sqlite3")
+	ctx := context.Background()
+
+	readOnly := NewReadOnlySessionStore(path)
+	defer readOnly.Close()
+	if _, err := readOnly.Save(ctx, Session{Name: "roi"}); err != errSessionsReadOnly {
+		t.Fatalf("expected errSessionsReadOnly, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("a read-only store created the sidecar: %v", err)
+	}
+
+	store := NewSessionStore(path)
+	if _, err := store.Save(ctx, Session{Name: "roi"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	store.Close()
+
+	if list, err := readOnly.List(ctx); err != nil || len(list) != 1 {
+		t.Fatalf("list = %v, %v", list, err)
+	}
+	if err := readOnly.Delete(ctx, "roi"); err != errSessionsReadOnly {
+		t.Fatalf("expected errSessionsReadOnly, got %v", err)
+	}
+}
+
+func TestHTTPSessionImportChecksTheTrace(t *testing.T) {
+	s := newTestSessionServer(t)
+	if _, err := s.traceReader.Exec(`CREATE TABLE exec_info (Property TEXT, Value TEXT);
+		INSERT INTO exec_info VALUES ('Start Time', '2026-01-01 10:00:00')`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	traceID := s.sessionTraceID(context.Background())
+
+	importSession := func(session Session, query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(session)
+		rec := httptest.NewRecorder()
+		s.httpSessionImport(rec, httptest.NewRequest(http.MethodPost,
+			"/api/session/import"+query, bytes.NewReader(body)))
+		return rec
+	}
+
+	rec := importSession(Session{Name: "mine", TraceID: traceID, View: "/dashboard"}, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import status = %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	other := Session{Name: "theirs", TraceID: "0123456789abcdef"}
+	if rec := importSession(other, ""); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for another trace's session, got %d", rec.Code)
+	}
+	if rec := importSession(other, "?force=1"); rec.Code != http.StatusOK {
+		t.Fatalf("forced import status = %d", rec.Code)
+	}
+
+	// A session stored with another trace's id fails to load unless forced.
+	other.Name = "stale"
+	if _, err := s.sessions.Save(context.Background(), other); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	s.httpSession(rec, httptest.NewRequest(http.MethodGet, "/api/session?name=stale", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 loading another trace's session, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	s.httpSession(rec, httptest.NewRequest(http.MethodGet,
+		"/api/session?name=stale&force=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("forced load status = %d", rec.Code)
+	}
+
+	// The trace id comes from the run, not from the metadata written at its end.
+	if _, err := s.traceReader.Exec(
+		`INSERT INTO exec_info VALUES ('End Time', '2026-01-01 11:00:00')`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if got := s.sessionTraceID(context.Background()); got != traceID {
+		t.Fatalf("trace id changed from %s to %s", traceID, got)
+	}
+}
+
+func TestSessionFileNameIsSafe(t *testing.T) {
+	if got := sessionFileName(`a/b "c"`); got != "daisen-session-a_b__c_.json" {
+		t.Fatalf("unexpected file name %q", got)
+	}
+}
@@ -7,6 +7,9 @@ import DashboardPage from "./pages/DashboardPage";
 import TaskChartPage from "./pages/TaskChartPage";
 import ComponentPage from "./pages/ComponentPage";
 import ResourcePage from "./pages/ResourcePage";
+import QueryPage from "./pages/QueryPage";
+import FlameGraphPage from "./pages/FlameGraphPage";
+import TopologyPage from "./pages/TopologyPage";
 
 // Redirect to the canonical /dashboard while preserving any query state, so
 // shared/back-compat links like /dashboard?widget=…&starttime=… are not discarded.
@@ -50,6 +53,9 @@ export default function App() {
         <Route path="task" element={<TaskChartPage />} />
         <Route path="component" element={<ComponentPage />} />
         <Route path="resource" element={<ResourcePage />} />
+        <Route path="query" element={<QueryPage />} />
+        <Route path="flamegraph" element={<FlameGraphPage />} />
+        <Route path="topology" element={<TopologyPage />} />
         <Route path="*" element={<RedirectToDashboard />} />
       </Route>
     </Routes>
@@ -2,6 +2,7 @@ import { useEffect, useState } from "react";
 import { Link, Outlet } from "react-router-dom";
 import { Bot } from "lucide-react";
 import ChatPanel from "./chat/ChatPanel";
+import SessionMenu from "./SessionMenu";
 import { Button } from "./ui/button";
 import { useRenderReadyOnNavigation } from "../hooks/useRenderReady";
 import { useSimInfo } from "../hooks/useSimInfo";
@@ -35,11 +36,23 @@ export default function Layout() {
             </span>
           ) : null}
         </Link>
+        <Link to="/query" className="text-sm text-slate-300 hover:text-white">
+          Query
+        </Link>
+        <Link to="/flamegraph" className="ml-4 text-sm text-slate-300 hover:text-white">
+          Flame graph
+        </Link>
+        <Link to="/topology" className="ml-4 text-sm text-slate-300 hover:text-white">
+          Topology
+        </Link>
+        <div className="ml-auto">
+          <SessionMenu />
+        </div>
         <Button
           type="button"
           size="sm"
           variant={chatOpen ? "secondary" : "default"}
-          className="ml-auto"
+          className="ml-2"
           onClick={() => setChatOpen((value) => !value)}
         >
           <Bot />
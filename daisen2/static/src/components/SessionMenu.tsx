@@ -0,0 +1,301 @@
+import { useEffect, useRef, useState } from "react";
+import { Link, useLocation, useSearchParams } from "react-router-dom";
+import { Bookmark, Download, Trash2, Upload } from "lucide-react";
+import { Button } from "./ui/button";
+import { Input } from "./ui/input";
+import {
+  useSessions,
+  fetchSession,
+  sessionExportUrl,
+  sessionBookmarkUrl,
+  SessionTraceMismatch,
+} from "../hooks/useSessions";
+import { useTraceId } from "../hooks/useTraceId";
+import { parseView } from "../utils/viewState.mjs";
+import { loadConversations, saveConversations } from "../utils/conversationStore.mjs";
+import type { Session, SessionAnnotation } from "../types/session";
+
+// The session the user last saved or opened in this tab, so re-saving and adding
+// notes apply to it without re-picking it from the list.
+const ACTIVE_SESSION_KEY = "daisen.session.active";
+
+// captureView derives a session's selection fields from the current URL: the
+// component(s) in view, the selected time range, and the selected task. The URL
+// is the app's single source of view state (see viewState.mjs), so this is the
+// whole selection.
+function captureView(pathname: string, search: string) {
+  const view = parseView(pathname, search);
+  const components = [view.name, view.where, view.scope, view.widget].filter(
+    (c): c is string => !!c,
+  );
+  const timeRanges =
+    view.startTime !== undefined && view.endTime !== undefined
+      ? [{ start_time: view.startTime, end_time: view.endTime }]
+      : [];
+  const taskIds = [view.taskId, view.id, view.sel]
+    .map((id) => Number(id))
+    .filter((id) => Number.isFinite(id) && id > 0);
+  return { components, timeRanges, taskIds };
+}
+
+// openSession restores a session: its DaisenBot conversations are merged into
+// the browser's conversation store (dedup by id), then the page reloads at the
+// session's view so the chat panel and every widget start from that state.
+function openSession(session: Session, traceId: string) {
+  const stored = loadConversations(traceId) as { id: unknown }[];
+  const have = new Set(stored.map((c) => c.id));
+  const incoming = (session.conversations as { id: unknown }[]).filter((c) => !have.has(c.id));
+  if (incoming.length) saveConversations(traceId, [...incoming, ...stored]);
+  window.sessionStorage.setItem(ACTIVE_SESSION_KEY, session.name);
+  window.location.assign(session.view || "/dashboard");
+}
+
+// withTraceCheck runs a session request and, if the session was saved on a
+// different trace, asks whether to retry it anyway.
+async function withTraceCheck<T>(request: (force: boolean) => Promise<T>): Promise<T | null> {
+  try {
+    return await request(false);
+  } catch (err: unknown) {
+    if (!(err instanceof SessionTraceMismatch)) throw err;
+    if (!window.confirm("This session was saved on a different trace. Use it anyway?")) return null;
+    return request(true);
+  }
+}
+
+// SessionMenu is the nav-bar control for saved analysis sessions: save the
+// current view (with its selection, task notes, and DaisenBot conversations)
+// under a name, reopen or delete saved sessions, download a session's JSON or
+// import one, and copy a bookmark link a teammate can open on the same trace. A
+// `?session=<name>` URL (the bookmark form) opens that session on load.
+export default function SessionMenu() {
+  const location = useLocation();
+  const [searchParams] = useSearchParams();
+  const traceId = useTraceId();
+  const { sessions, error, save, importFile, remove } = useSessions();
+  const [open, setOpen] = useState(false);
+  const [name, setName] = useState(() => window.sessionStorage.getItem(ACTIVE_SESSION_KEY) ?? "");
+  const [annotations, setAnnotations] = useState<SessionAnnotation[]>([]);
+  const [note, setNote] = useState("");
+  const [status, setStatus] = useState<string | null>(null);
+  const bookmarkHandled = useRef(false);
+  const importInput = useRef<HTMLInputElement>(null);
+
+  const { components, timeRanges, taskIds } = captureView(location.pathname, location.search);
+  const selectedTask = taskIds[0];
+
+  // Open a bookmarked session once the trace id (which scopes conversations) is known.
+  useEffect(() => {
+    const bookmarked = searchParams.get("session");
+    if (!bookmarked || !traceId || bookmarkHandled.current) return;
+    bookmarkHandled.current = true;
+    handleOpen(bookmarked);
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [searchParams, traceId]);
+
+  // Pick up the active session's notes so new ones are appended, not replacing.
+  useEffect(() => {
+    if (!name || !sessions.some((s) => s.name === name)) return;
+    fetchSession(name)
+      .then((session) => setAnnotations(session.annotations))
+      .catch(() => setAnnotations([]));
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [sessions]);
+
+  const handleOpen = (sessionName: string) => {
+    if (!traceId) return;
+    withTraceCheck((force) => fetchSession(sessionName, force))
+      .then((session) => {
+        if (session) openSession(session, traceId);
+      })
+      .catch((err: unknown) => setStatus(err instanceof Error ? err.message : String(err)));
+  };
+
+  const handleImport = async (file: File | undefined) => {
+    if (!file) return;
+    try {
+      const imported = await withTraceCheck((force) => importFile(file, force));
+      if (imported) setStatus(`Imported “${imported.name}”.`);
+    } catch (err: unknown) {
+      setStatus(err instanceof Error ? err.message : String(err));
+    }
+  };
+
+  const handleSave = async () => {
+    if (!name.trim() || !traceId) return;
+    try {
+      const saved = await save({
+        name: name.trim(),
+        view: location.pathname + location.search,
+        components,
+        time_ranges: timeRanges,
+        task_ids: taskIds,
+        annotations,
+        conversations: loadConversations(traceId),
+      });
+      window.sessionStorage.setItem(ACTIVE_SESSION_KEY, saved.name);
+      setStatus(`Saved “${saved.name}”.`);
+    } catch (err: unknown) {
+      setStatus(err instanceof Error ? err.message : String(err));
+    }
+  };
+
+  const handleAddNote = () => {
+    if (!note.trim() || !selectedTask) return;
+    setAnnotations((current) => [
+      ...current,
+      { task_id: selectedTask, note: note.trim(), created: Math.floor(Date.now() / 1000) },
+    ]);
+    setNote("");
+  };
+
+  const handleCopyBookmark = async (sessionName: string) => {
+    try {
+      await navigator.clipboard.writeText(sessionBookmarkUrl(sessionName));
+      setStatus("Bookmark link copied.");
+    } catch {
+      setStatus(sessionBookmarkUrl(sessionName));
+    }
+  };
+
+  return (
+    <div className="relative">
+      <Button
+        type="button"
+        size="sm"
+        variant={open ? "secondary" : "outline"}
+        onClick={() => setOpen((value) => !value)}
+      >
+        <Bookmark />
+        Sessions
+      </Button>
+      {open ? (
+        <div className="absolute right-0 top-full z-50 mt-1 flex w-96 flex-col gap-3 rounded-md border bg-white p-3 text-sm shadow-lg">
+          <div className="flex gap-2">
+            <Input
+              value={name}
+              placeholder="Session name"
+              onChange={(event) => setName(event.target.value)}
+              onKeyDown={(event) => {
+                if (event.key === "Enter") void handleSave();
+              }}
+            />
+            <Button type="button" size="sm" onClick={() => void handleSave()} disabled={!name.trim()}>
+              Save
+            </Button>
+          </div>
+          <div className="text-xs text-muted-foreground">
+            Saves this view
+            {components.length ? ` (${components.join(", ")})` : ""}, its time range and
+            selected task, your task notes, and DaisenBot conversations.
+          </div>
+
+          <div className="flex flex-col gap-1">
+            <div className="text-xs font-medium">Task notes</div>
+            {annotations.length === 0 ? (
+              <div className="text-xs text-muted-foreground">No notes yet.</div>
+            ) : (
+              <ul className="flex max-h-32 flex-col gap-1 overflow-auto">
+                {annotations.map((a, index) => (
+                  <li key={`${a.task_id}-${index}`} className="flex gap-2 text-xs">
+                    <Link to={`/task?id=${a.task_id}`} className="shrink-0 font-mono text-primary">
+                      #{a.task_id}
+                    </Link>
+                    <span className="min-w-0 flex-1 break-words">{a.note}</span>
+                  </li>
+                ))}
+              </ul>
+            )}
+            <div className="flex gap-2">
+              <Input
+                value={note}
+                disabled={!selectedTask}
+                placeholder={selectedTask ? `Note on task #${selectedTask}` : "Select a task to add a note"}
+                onChange={(event) => setNote(event.target.value)}
+                onKeyDown={(event) => {
+                  if (event.key === "Enter") handleAddNote();
+                }}
+              />
+              <Button type="button" size="sm" variant="outline" onClick={handleAddNote} disabled={!selectedTask || !note.trim()}>
+                Add
+              </Button>
+            </div>
+          </div>
+
+          <div className="flex flex-col gap-1">
+            <div className="flex items-center justify-between">
+              <div className="text-xs font-medium">Saved sessions</div>
+              <button
+                type="button"
+                title="Import session JSON"
+                className="flex items-center gap-1 text-xs text-muted-foreground hover:text-foreground"
+                onClick={() => importInput.current?.click()}
+              >
+                <Upload className="h-3.5 w-3.5" />
+                Import
+              </button>
+              <input
+                ref={importInput}
+                type="file"
+                accept="application/json,.json"
+                className="hidden"
+                onChange={(event) => {
+                  void handleImport(event.target.files?.[0]);
+                  event.target.value = "";
+                }}
+              />
+            </div>
+            {error ? (
+              <div className="text-xs text-destructive">{error}</div>
+            ) : sessions.length === 0 ? (
+              <div className="text-xs text-muted-foreground">No saved sessions for this trace.</div>
+            ) : (
+              <ul className="flex max-h-48 flex-col gap-1 overflow-auto">
+                {sessions.map((s) => (
+                  <li key={s.name} className="flex items-center gap-1">
+                    <button
+                      type="button"
+                      className="min-w-0 flex-1 truncate rounded px-1 py-0.5 text-left hover:bg-muted"
+                      title={`Open ${s.view}`}
+                      onClick={() => handleOpen(s.name)}
+                    >
+                      {s.name}
+                      {s.annotation_count ? (
+                        <span className="ml-1 text-xs text-muted-foreground">
+                          ({s.annotation_count} note{s.annotation_count === 1 ? "" : "s"})
+                        </span>
+                      ) : null}
+                    </button>
+                    <button
+                      type="button"
+                      title="Copy bookmark link"
+                      className="text-muted-foreground hover:text-foreground"
+                      onClick={() => void handleCopyBookmark(s.name)}
+                    >
+                      <Bookmark className="h-3.5 w-3.5" />
+                    </button>
+                    <a
+                      href={sessionExportUrl(s.name)}
+                      title="Download session JSON"
+                      className="text-muted-foreground hover:text-foreground"
+                    >
+                      <Download className="h-3.5 w-3.5" />
+                    </a>
+                    <button
+                      type="button"
+                      title="Delete session"
+                      className="text-muted-foreground hover:text-destructive"
+                      onClick={() => void remove(s.name)}
+                    >
+                      <Trash2 className="h-3.5 w-3.5" />
+                    </button>
+                  </li>
+                ))}
+              </ul>
+            )}
+          </div>
+          {status ? <div className="break-all text-xs text-muted-foreground">{status}</div> : null}
+        </div>
+      ) : null}
+    </div>
+  );
+}
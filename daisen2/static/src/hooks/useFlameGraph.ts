@@ -0,0 +1,52 @@
+import { useEffect, useState } from "react";
+import { useRenderReady } from "./useRenderReady";
+import type { FlameGraph } from "../types/flamegraph";
+
+export interface FlameGraphParams {
+  root?: string;
+  scope?: string;
+  startTime?: number;
+  endTime?: number;
+}
+
+// useFlameGraph fetches the aggregated flame graph for a task subtree (root), a
+// location scope, and/or a time range. Modeled on the other data hooks: abort on
+// change, render-ready wiring, abort-aware loading.
+export function useFlameGraph({ root, scope, startTime, endTime }: FlameGraphParams) {
+  const [data, setData] = useState<FlameGraph | null>(null);
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    const controller = new AbortController();
+    const params = new URLSearchParams();
+    if (root) params.set("root", root);
+    if (scope) params.set("scope", scope);
+    if (startTime !== undefined && endTime !== undefined) {
+      params.set("starttime", String(startTime));
+      params.set("endtime", String(endTime));
+    }
+
+    setLoading(true);
+    setError(null);
+    fetch(`/api/flamegraph?${params.toString()}`, { signal: controller.signal })
+      .then(async (response) => {
+        if (!response.ok) throw new Error((await response.text()).trim() || `HTTP ${response.status}`);
+        return response.json();
+      })
+      .then((json: FlameGraph) => setData(json))
+      .catch((err: unknown) => {
+        if (err instanceof DOMException && err.name === "AbortError") return;
+        setError(err instanceof Error ? err.message : String(err));
+      })
+      .finally(() => {
+        if (!controller.signal.aborted) setLoading(false);
+      });
+
+    return () => controller.abort();
+  }, [root, scope, startTime, endTime]);
+
+  useRenderReady(loading, error !== null);
+
+  return { data, loading, error };
+}
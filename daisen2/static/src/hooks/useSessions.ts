@@ -0,0 +1,99 @@
+import { useCallback, useEffect, useState } from "react";
+import type { Session, SessionSummary } from "../types/session";
+
+// SessionTraceMismatch is thrown when a session was saved on a different trace;
+// retry with `force` to open or import it anyway.
+export class SessionTraceMismatch extends Error {}
+
+// sessionResponse returns a session response's JSON, or throws its error text.
+async function sessionResponse(response: Response): Promise<Session> {
+  if (response.status === 409) throw new SessionTraceMismatch(await response.text());
+  if (!response.ok) throw new Error(await response.text());
+  return (await response.json()) as Session;
+}
+
+// useSessions lists the saved analysis sessions of the loaded trace and exposes
+// save/load/import/delete against the server's sidecar session store. `refresh`
+// re-reads the list; save, import, and delete refresh it themselves.
+export function useSessions() {
+  const [sessions, setSessions] = useState<SessionSummary[]>([]);
+  const [error, setError] = useState<string | null>(null);
+
+  const refresh = useCallback(async () => {
+    try {
+      const response = await fetch("/api/sessions");
+      if (!response.ok) throw new Error(`HTTP ${response.status}`);
+      setSessions((await response.json()) as SessionSummary[]);
+      setError(null);
+    } catch (err: unknown) {
+      setError(err instanceof Error ? err.message : String(err));
+    }
+  }, []);
+
+  useEffect(() => {
+    void refresh();
+  }, [refresh]);
+
+  const save = useCallback(
+    async (session: Partial<Session> & { name: string }) => {
+      const response = await fetch("/api/sessions", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(session),
+      });
+      if (!response.ok) throw new Error(await response.text());
+      const saved = (await response.json()) as Session;
+      await refresh();
+      return saved;
+    },
+    [refresh],
+  );
+
+  const importFile = useCallback(
+    async (file: File, force = false) => {
+      const query = force ? "?force=1" : "";
+      const saved = await sessionResponse(
+        await fetch(`/api/session/import${query}`, {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          body: await file.text(),
+        }),
+      );
+      await refresh();
+      return saved;
+    },
+    [refresh],
+  );
+
+  const remove = useCallback(
+    async (name: string) => {
+      const params = new URLSearchParams({ name });
+      const response = await fetch(`/api/session?${params.toString()}`, { method: "DELETE" });
+      if (!response.ok) throw new Error(await response.text());
+      await refresh();
+    },
+    [refresh],
+  );
+
+  return { sessions, error, refresh, save, importFile, remove };
+}
+
+/**
+ * Fetch one saved session by name. Throws SessionTraceMismatch if it was saved
+ * on a different trace, unless `force` is set.
+ */
+export async function fetchSession(name: string, force = false): Promise<Session> {
+  const params = new URLSearchParams({ name });
+  if (force) params.set("force", "1");
+  return sessionResponse(await fetch(`/api/session?${params.toString()}`));
+}
+
+/** The download URL of a session's JSON export. */
+export function sessionExportUrl(name: string): string {
+  return `/api/session/export?${new URLSearchParams({ name }).toString()}`;
+}
+
+/** A shareable bookmark that reopens the named session on this server. */
+export function sessionBookmarkUrl(name: string): string {
+  return `${window.location.origin}/dashboard?${new URLSearchParams({ session: name }).toString()}`;
+}
@@ -0,0 +1,46 @@
+import { useEffect, useState } from "react";
+import { useRenderReady } from "./useRenderReady";
+import type { TaskQueryResult } from "../types/query";
+
+// useTaskQuery runs a task-language query through /api/task_query. An empty
+// query runs nothing. Query errors (parse or compile) come back as the 400 body
+// and are surfaced verbatim, since they tell the user what to fix.
+export function useTaskQuery(query: string) {
+  const [data, setData] = useState<TaskQueryResult | null>(null);
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    if (!query.trim()) {
+      setData(null);
+      setError(null);
+      return;
+    }
+
+    const controller = new AbortController();
+    const params = new URLSearchParams({ q: query });
+
+    setLoading(true);
+    setError(null);
+    fetch(`/api/task_query?${params.toString()}`, { signal: controller.signal })
+      .then(async (response) => {
+        if (!response.ok) throw new Error((await response.text()).trim() || `HTTP ${response.status}`);
+        return response.json();
+      })
+      .then((json: TaskQueryResult) => setData(json))
+      .catch((err: unknown) => {
+        if (err instanceof DOMException && err.name === "AbortError") return;
+        setData(null);
+        setError(err instanceof Error ? err.message : String(err));
+      })
+      .finally(() => {
+        if (!controller.signal.aborted) setLoading(false);
+      });
+
+    return () => controller.abort();
+  }, [query]);
+
+  useRenderReady(loading, error !== null);
+
+  return { data, loading, error };
+}
@@ -0,0 +1,159 @@
+import { useMemo, useState } from "react";
+import { Link, useSearchParams } from "react-router-dom";
+import { useFlameGraph } from "../hooks/useFlameGraph";
+import { formatVirtualTime } from "../lib/time";
+import type { FlameNode } from "../types/flamegraph";
+
+// Frames narrower than this fraction of the zoomed root are not drawn; their
+// time is still part of their parent's width.
+const MIN_FRACTION = 0.002;
+
+function frameLabel(node: FlameNode): string {
+  return [node.location, node.kind, node.what].filter(Boolean).join(" · ");
+}
+
+// frameColor hashes a frame's location to a stable warm hue, so the same
+// component keeps its color across zoom levels and reloads.
+function frameColor(node: FlameNode): string {
+  let h = 0;
+  for (const c of node.location) h = (h * 31 + c.charCodeAt(0)) % 360;
+  return `hsl(${(h % 60) + 5}, 75%, ${62 + (h % 3) * 6}%)`;
+}
+
+function Frame({
+  node,
+  rootTotal,
+  path,
+  selected,
+  onSelect,
+  onZoom,
+}: {
+  node: FlameNode;
+  rootTotal: number;
+  path: number[];
+  selected: string;
+  onSelect: (path: number[]) => void;
+  onZoom: (path: number[]) => void;
+}) {
+  const key = path.join(".");
+  return (
+    <div className="flex min-w-0 flex-col" style={{ width: `${(node.total / rootTotal) * 100}%` }}>
+      <button
+        type="button"
+        className={`h-5 truncate border border-white px-1 text-left text-[11px] leading-4 ${
+          selected === key ? "ring-2 ring-slate-900" : ""
+        }`}
+        style={{ background: frameColor(node) }}
+        title={`${frameLabel(node)}\nself ${formatVirtualTime(node.self)}, total ${formatVirtualTime(
+          node.total,
+        )}, ${node.count.toLocaleString()} task${node.count === 1 ? "" : "s"}`}
+        onClick={() => onSelect(path)}
+        onDoubleClick={() => onZoom(path)}
+      >
+        {frameLabel(node)}
+      </button>
+      <div className="flex">
+        {node.children.map((child, i) =>
+          child.total / rootTotal < MIN_FRACTION ? null : (
+            <Frame
+              key={i}
+              node={child}
+              rootTotal={rootTotal}
+              path={[...path, i]}
+              selected={selected}
+              onSelect={onSelect}
+              onZoom={onZoom}
+            />
+          ),
+        )}
+      </div>
+    </div>
+  );
+}
+
+function nodeAt(root: FlameNode, path: number[]): FlameNode {
+  return path.reduce((node, i) => node.children[i] ?? node, root);
+}
+
+// FlameGraphPage (route /flamegraph) is an icicle view of the task hierarchy:
+// tasks are aggregated by their (location, kind, what) stack along parent
+// links and weighted by exclusive time. Click a frame to see its totals and
+// sample tasks; double-click to zoom into it. URL params: root (a task id, e.g.
+// a kernel), scope (a location subtree), starttime/endtime.
+export default function FlameGraphPage() {
+  const [searchParams] = useSearchParams();
+  const startTime = searchParams.get("starttime");
+  const endTime = searchParams.get("endtime");
+  const { data, loading, error } = useFlameGraph({
+    root: searchParams.get("root") ?? undefined,
+    scope: searchParams.get("scope") ?? undefined,
+    startTime: startTime && endTime ? Number(startTime) : undefined,
+    endTime: startTime && endTime ? Number(endTime) : undefined,
+  });
+  const [zoom, setZoom] = useState<number[]>([]);
+  const [selected, setSelected] = useState<number[] | null>(null);
+
+  const zoomed = useMemo(() => (data ? nodeAt(data.root, zoom) : null), [data, zoom]);
+  const selectedNode = data && selected ? nodeAt(data.root, selected) : null;
+
+  if (loading) return <div className="p-4 text-sm text-muted-foreground">Building flame graph…</div>;
+  if (error) return <div className="p-4 text-sm text-destructive">{error}</div>;
+  if (!data || !zoomed || zoomed.total <= 0) {
+    return <div className="p-4 text-sm text-muted-foreground">No tasks to aggregate.</div>;
+  }
+
+  return (
+    <div className="flex h-full min-h-0 flex-col gap-3 bg-white p-4">
+      <div className="flex flex-wrap items-center gap-2 text-xs text-muted-foreground">
+        <span>
+          {data.task_count.toLocaleString()} tasks, {formatVirtualTime(data.root.total)} exclusive time
+          {data.truncated ? " (truncated — narrow the range, scope, or root)" : ""}
+        </span>
+        {zoom.length > 0 ? (
+          <button type="button" className="rounded bg-muted px-1.5 hover:text-foreground" onClick={() => setZoom([])}>
+            Reset zoom
+          </button>
+        ) : null}
+        <span className="ml-auto">Click a frame for details; double-click to zoom.</span>
+      </div>
+      <div className="min-h-0 flex-1 overflow-auto">
+        <div className="flex">
+          {zoomed.children.map((child, i) =>
+            child.total / zoomed.total < MIN_FRACTION ? null : (
+              <Frame
+                key={i}
+                node={child}
+                rootTotal={zoomed.total}
+                path={[...zoom, i]}
+                selected={selected?.join(".") ?? ""}
+                onSelect={setSelected}
+                onZoom={setZoom}
+              />
+            ),
+          )}
+        </div>
+      </div>
+      {selectedNode ? (
+        <div className="max-h-48 shrink-0 overflow-auto rounded border p-2 text-xs">
+          <div className="font-medium">{frameLabel(selectedNode)}</div>
+          <div className="text-muted-foreground">
+            self {formatVirtualTime(selectedNode.self)} · total {formatVirtualTime(selectedNode.total)} ·{" "}
+            {selectedNode.count.toLocaleString()} task{selectedNode.count === 1 ? "" : "s"}
+          </div>
+          <div className="mt-1 flex flex-wrap gap-1">
+            {selectedNode.task_ids.map((id) => (
+              <Link key={id} to={`/task?id=${id}`} className="font-mono text-primary hover:underline">
+                #{id}
+              </Link>
+            ))}
+            {selectedNode.count > selectedNode.task_ids.length ? (
+              <span className="text-muted-foreground">
+                … and {(selectedNode.count - selectedNode.task_ids.length).toLocaleString()} more
+              </span>
+            ) : null}
+          </div>
+        </div>
+      ) : null}
+    </div>
+  );
+}
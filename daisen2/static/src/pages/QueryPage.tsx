@@ -0,0 +1,158 @@
+import { useState } from "react";
+import { Link, useSearchParams } from "react-router-dom";
+import { Play } from "lucide-react";
+import { Button } from "../components/ui/button";
+import { Input } from "../components/ui/input";
+import { useTaskQuery } from "../hooks/useTaskQuery";
+import { formatVirtualTime } from "../lib/time";
+import type { TaskQueryResult } from "../types/query";
+
+const EXAMPLES = [
+  'tasks where kind=req_in and location~"L2*" and duration>500ns group by what order by p99 desc',
+  "tasks where kind=req_in group by location order by total desc limit 20",
+  'tasks where location~"*DRAM*" histogram duration bins 30',
+  "tasks where duration>10us order by duration desc limit 50",
+];
+
+// Result columns holding virtual times (picoseconds), rendered with units.
+const TIME_COLUMNS = new Set([
+  "start_time", "end_time", "duration",
+  "avg", "min", "max", "total", "p50", "p90", "p95", "p99",
+]);
+
+function Cell({ column, value }: { column: string; value: string | number | null }) {
+  if (value === null) return <span className="text-muted-foreground">—</span>;
+  if ((column === "id" || column === "parent_id") && Number(value) > 0) {
+    return (
+      <Link to={`/task?id=${value}`} className="font-mono text-primary hover:underline">
+        {value}
+      </Link>
+    );
+  }
+  if (TIME_COLUMNS.has(column) && typeof value === "number") return <>{formatVirtualTime(value)}</>;
+  if (typeof value === "number") return <>{value.toLocaleString()}</>;
+  return <>{value}</>;
+}
+
+function ResultTable({ result }: { result: TaskQueryResult }) {
+  const columns = result.columns ?? [];
+  const rows = result.rows ?? [];
+  if (rows.length === 0) return <div className="text-sm text-muted-foreground">No matching tasks.</div>;
+  return (
+    <div className="min-h-0 flex-1 overflow-auto rounded border">
+      <table className="w-full text-xs">
+        <thead className="sticky top-0 bg-muted">
+          <tr>
+            {columns.map((c) => (
+              <th key={c} className="px-2 py-1 text-left font-medium">{c}</th>
+            ))}
+          </tr>
+        </thead>
+        <tbody>
+          {rows.map((row, i) => (
+            <tr key={i} className="border-t hover:bg-muted/50">
+              {row.map((v, j) => (
+                <td key={j} className="whitespace-nowrap px-2 py-0.5 tabular-nums">
+                  <Cell column={columns[j]} value={v} />
+                </td>
+              ))}
+            </tr>
+          ))}
+        </tbody>
+      </table>
+    </div>
+  );
+}
+
+function Histogram({ result }: { result: TaskQueryResult }) {
+  const bins = result.bins ?? [];
+  const max = bins.reduce((m, b) => Math.max(m, b.count), 0);
+  if (bins.length === 0) return <div className="text-sm text-muted-foreground">No matching tasks.</div>;
+  return (
+    <ol className="flex min-h-0 flex-1 flex-col gap-0.5 overflow-auto">
+      {bins.map((b) => (
+        <li key={b.low} className="flex items-center gap-2 text-xs">
+          <span className="w-48 shrink-0 text-right tabular-nums text-muted-foreground">
+            {formatVirtualTime(b.low)} – {formatVirtualTime(b.high)}
+          </span>
+          <span className="relative h-4 flex-1">
+            <span
+              className="absolute inset-y-0 left-0 rounded bg-primary/40"
+              style={{ width: `${max > 0 ? (b.count / max) * 100 : 0}%` }}
+            />
+          </span>
+          <span className="w-16 shrink-0 tabular-nums">{b.count.toLocaleString()}</span>
+        </li>
+      ))}
+    </ol>
+  );
+}
+
+// QueryPage (route /query) is the task query box: type a query in the task
+// language (see /api/task_query), run it, and see a table or a histogram. The
+// query lives in the URL (?q=) so a result is shareable as a link.
+export default function QueryPage() {
+  const [searchParams, setSearchParams] = useSearchParams();
+  const query = searchParams.get("q") ?? "";
+  const [draft, setDraft] = useState(query || EXAMPLES[0]);
+  const { data, loading, error } = useTaskQuery(query);
+
+  const run = (text: string) => {
+    setDraft(text);
+    setSearchParams(text.trim() ? { q: text } : {});
+  };
+
+  return (
+    <div className="flex h-full min-h-0 flex-col gap-3 bg-white p-4">
+      <form
+        className="flex gap-2"
+        onSubmit={(event) => {
+          event.preventDefault();
+          run(draft);
+        }}
+      >
+        <Input
+          value={draft}
+          spellCheck={false}
+          className="font-mono text-xs"
+          onChange={(event) => setDraft(event.target.value)}
+          aria-label="Task query"
+        />
+        <Button type="submit" size="sm" disabled={loading}>
+          <Play />
+          Run
+        </Button>
+      </form>
+      <div className="flex flex-wrap gap-2 text-xs text-muted-foreground">
+        Examples:
+        {EXAMPLES.map((example) => (
+          <button
+            key={example}
+            type="button"
+            className="max-w-full truncate rounded bg-muted px-1.5 font-mono hover:text-foreground"
+            onClick={() => run(example)}
+          >
+            {example}
+          </button>
+        ))}
+      </div>
+      {loading ? (
+        <div className="text-sm text-muted-foreground">Running…</div>
+      ) : error ? (
+        <pre className="whitespace-pre-wrap text-sm text-destructive">{error}</pre>
+      ) : data ? (
+        <>
+          {data.kind === "histogram" ? <Histogram result={data} /> : <ResultTable result={data} />}
+          <details className="text-xs text-muted-foreground">
+            <summary>
+              Compiled SQL{data.truncated ? " (result truncated — add a limit or aggregate)" : ""}
+            </summary>
+            <pre className="whitespace-pre-wrap font-mono">{data.sql}</pre>
+          </details>
+        </>
+      ) : (
+        <div className="text-sm text-muted-foreground">Enter a query and press Run.</div>
+      )}
+    </div>
+  );
+}
@@ -0,0 +1,169 @@
+import { useMemo, useState } from "react";
+import { Link } from "react-router-dom";
+import { useTopology } from "../hooks/useTopology";
+import type { Topology, TopologyComponent, TopologyConnection, TopologyPort } from "../types/overview";
+
+const EXPORT_FORMATS = [
+  { format: "dot", label: "DOT" },
+  { format: "graphml", label: "GraphML" },
+  { format: "json", label: "JSON" },
+];
+
+interface DomainNode {
+  name: string;
+  children: DomainNode[];
+  components: TopologyComponent[];
+}
+
+// buildDomainTree nests the domains by parent and files each component under its
+// innermost domain. Components and domains whose parent is unknown go to the root.
+function buildDomainTree(topology: Topology): DomainNode {
+  const root: DomainNode = { name: "", children: [], components: [] };
+  const byName = new Map<string, DomainNode>([["", root]]);
+  for (const d of topology.domains ?? []) {
+    byName.set(d.name, { name: d.name, children: [], components: [] });
+  }
+  for (const d of topology.domains ?? []) {
+    (byName.get(d.parent) ?? root).children.push(byName.get(d.name)!);
+  }
+  for (const c of topology.components) {
+    (byName.get(c.domain ?? "") ?? root).components.push(c);
+  }
+  return root;
+}
+
+// specParams renders the scalar fields of a spec as "name=value", sorted.
+function specParams(spec: Record<string, unknown> | null): string {
+  if (!spec) return "";
+  return Object.entries(spec)
+    .filter(([, v]) => v !== null && typeof v !== "object")
+    .map(([k, v]) => `${k}=${String(v)}`)
+    .sort()
+    .join(", ");
+}
+
+function DomainBox({ node, depth }: { node: DomainNode; depth: number }) {
+  return (
+    <div className={depth > 0 ? "rounded border border-slate-300 p-2" : "flex flex-col gap-2"}>
+      {depth > 0 ? <div className="mb-1 text-xs font-semibold text-slate-600">{node.name}</div> : null}
+      {node.components.length > 0 ? (
+        <div className="flex flex-wrap gap-1">
+          {node.components.map((c) => (
+            <Link
+              key={c.name}
+              to={`/component?name=${encodeURIComponent(c.name)}`}
+              className="rounded bg-sky-50 px-1.5 py-0.5 font-mono text-[11px] text-sky-900 hover:bg-sky-100"
+              title={c.type}
+            >
+              {c.name}
+            </Link>
+          ))}
+        </div>
+      ) : null}
+      {node.children.length > 0 ? (
+        <div className="mt-2 flex flex-wrap gap-2">
+          {node.children.map((child) => (
+            <DomainBox key={child.name} node={child} depth={depth + 1} />
+          ))}
+        </div>
+      ) : null}
+    </div>
+  );
+}
+
+// TopologyPage (route /topology) shows the components clustered by domain and
+// every connection with the roles its ports speak and its parameters, and
+// downloads the same graph as DOT, GraphML, or JSON from /api/topology/export.
+export default function TopologyPage() {
+  const { data, loading, error } = useTopology();
+  const [filter, setFilter] = useState("");
+
+  const tree = useMemo(() => (data ? buildDomainTree(data) : null), [data]);
+  const connections = useMemo(() => {
+    if (!data) return [];
+    const specs = new Map<string, TopologyConnection>((data.connections ?? []).map((c) => [c.name, c]));
+    const endpoints = new Map<string, TopologyPort[]>();
+    for (const p of data.ports) {
+      if (!p.connection) continue;
+      endpoints.set(p.connection, [...(endpoints.get(p.connection) ?? []), p]);
+    }
+    return [...endpoints.entries()].map(([name, ports]) => ({ name, ports, spec: specs.get(name) }));
+  }, [data]);
+
+  if (loading) return <div className="p-4 text-sm text-muted-foreground">Loading topology…</div>;
+  if (error) return <div className="p-4 text-sm text-destructive">{error}</div>;
+  if (!data || !tree || data.components.length === 0) {
+    return <div className="p-4 text-sm text-muted-foreground">This trace recorded no topology.</div>;
+  }
+
+  const shown = connections.filter(
+    (c) => !filter || c.name.includes(filter) || c.ports.some((p) => p.port.includes(filter)),
+  );
+
+  return (
+    <div className="flex h-full min-h-0 flex-col gap-3 overflow-auto bg-white p-4">
+      <div className="flex flex-wrap items-center gap-2 text-xs text-muted-foreground">
+        <span>
+          {data.components.length.toLocaleString()} components, {connections.length.toLocaleString()} connections,{" "}
+          {(data.domains ?? []).length.toLocaleString()} domains
+        </span>
+        <span className="ml-auto">Export:</span>
+        {EXPORT_FORMATS.map(({ format, label }) => (
+          <a
+            key={format}
+            href={`/api/topology/export?format=${format}`}
+            download={`topology.${format}`}
+            className="rounded bg-muted px-1.5 hover:text-foreground"
+          >
+            {label}
+          </a>
+        ))}
+      </div>
+      <section>
+        <h2 className="mb-2 text-sm font-semibold">Domains</h2>
+        <DomainBox node={tree} depth={0} />
+      </section>
+      <section className="min-h-0">
+        <div className="mb-2 flex items-center gap-2">
+          <h2 className="text-sm font-semibold">Connections</h2>
+          <input
+            className="ml-auto rounded border px-2 py-0.5 text-xs"
+            placeholder="Filter by connection or port"
+            value={filter}
+            onChange={(e) => setFilter(e.target.value)}
+          />
+        </div>
+        <table className="w-full text-left text-xs">
+          <thead className="text-muted-foreground">
+            <tr>
+              <th className="py-1 pr-3 font-medium">Connection</th>
+              <th className="py-1 pr-3 font-medium">Parameters</th>
+              <th className="py-1 font-medium">Endpoints (roles)</th>
+            </tr>
+          </thead>
+          <tbody>
+            {shown.map((c) => (
+              <tr key={c.name} className="border-t align-top">
+                <td className="py-1 pr-3 font-mono">
+                  {c.name}
+                  {c.spec?.type ? <div className="text-muted-foreground">{c.spec.type}</div> : null}
+                </td>
+                <td className="py-1 pr-3 font-mono">{specParams(c.spec?.spec ?? null)}</td>
+                <td className="py-1">
+                  {c.ports.map((p) => (
+                    <div key={p.port}>
+                      <span className="font-mono">{p.port}</span>
+                      {p.roles && p.roles.length > 0 ? (
+                        <span className="text-muted-foreground"> ({p.roles.join(", ")})</span>
+                      ) : null}
+                    </div>
+                  ))}
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </section>
+    </div>
+  );
+}
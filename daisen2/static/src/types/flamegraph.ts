@@ -0,0 +1,23 @@
+// Types for the aggregated flame graph, mirroring the Go structs served by
+// /api/flamegraph.
+
+/** One aggregated frame: tasks sharing a (location, kind, what) stack. */
+export interface FlameNode {
+  location: string;
+  kind: string;
+  what: string;
+  /** Exclusive virtual time (ps) of the tasks ending at this frame. */
+  self: number;
+  /** self plus every descendant's self. */
+  total: number;
+  count: number;
+  /** A sample of the frame's task ids, for drill-down. */
+  task_ids: number[];
+  children: FlameNode[];
+}
+
+export interface FlameGraph {
+  root: FlameNode;
+  task_count: number;
+  truncated: boolean;
+}
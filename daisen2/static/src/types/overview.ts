@@ -12,6 +12,8 @@ export interface TopologyComponent {
   name: string;
   type: string;
   spec: Record<string, unknown> | null;
+  /** Innermost domain the component belongs to ("" when none). */
+  domain?: string;
 }
 
 /** A single port and the connection it is plugged into ("" when unconnected). */
@@ -19,12 +21,32 @@ export interface TopologyPort {
   component: string;
   port: string;
   connection: string;
+  /** Protocol roles the port speaks, e.g. "mem requester". */
+  roles?: string[];
 }
 
-/** The static structure of a simulation: components and the full port inventory. */
+/** A domain and the domain that encloses it ("" for a top-level domain). */
+export interface TopologyDomain {
+  name: string;
+  parent: string;
+}
+
+/** A connection and its spec, e.g. a link's latency and bandwidth. */
+export interface TopologyConnection {
+  name: string;
+  type: string;
+  spec: Record<string, unknown> | null;
+}
+
+/**
+ * The static structure of a simulation: components, the full port inventory,
+ * and — for traces that recorded them — the domains and connection specs.
+ */
 export interface Topology {
   components: TopologyComponent[];
   ports: TopologyPort[];
+  domains?: TopologyDomain[];
+  connections?: TopologyConnection[];
 }
 
 /** A component ranked by the total in-flight time of its tasks (virtual time). */
@@ -0,0 +1,20 @@
+// Types for the task query language, mirroring the Go structs served by
+// /api/task_query.
+
+/** One histogram bucket over [low, high). */
+export interface TaskQueryHistogramBin {
+  low: number;
+  high: number;
+  count: number;
+}
+
+/** A task query result: a table, or histogram bins over `field`. */
+export interface TaskQueryResult {
+  kind: "table" | "histogram";
+  sql: string;
+  columns?: string[];
+  rows?: (string | number | null)[][];
+  truncated?: boolean;
+  field?: string;
+  bins?: TaskQueryHistogramBin[];
+}
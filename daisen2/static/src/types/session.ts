@@ -0,0 +1,41 @@
+// Types for saved analysis sessions, mirroring the Go structs served by
+// /api/sessions and /api/session.
+
+/** One selected span of simulated time. */
+export interface SessionTimeRange {
+  start_time: number;
+  end_time: number;
+}
+
+/** A note attached to a task. `created` is a Unix timestamp in seconds. */
+export interface SessionAnnotation {
+  task_id: number;
+  note: string;
+  created: number;
+}
+
+/**
+ * A saved analysis session. `view` is the app-relative URL of the page being
+ * viewed; `conversations` is the DaisenBot history in conversationStore's shape.
+ */
+export interface Session {
+  name: string;
+  trace_id: string;
+  created: number;
+  updated: number;
+  view: string;
+  components: string[];
+  time_ranges: SessionTimeRange[];
+  task_ids: number[];
+  annotations: SessionAnnotation[];
+  conversations: unknown[];
+}
+
+/** The list-view form of a session. */
+export interface SessionSummary {
+  name: string;
+  created: number;
+  updated: number;
+  view: string;
+  annotation_count: number;
+}
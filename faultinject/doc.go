@@ -0,0 +1,41 @@
+// Package faultinject injects faults into a simulated system for resilience
+// studies.
+//
+// An Injector installs faults at sites:
+//
+//   - AtPort and AtConnection drop, delay, duplicate, or reorder messages.
+//     At a port, a fault applies where the owning component sends
+//     (messaging.HookPosPortMsgSend) or where the connection delivers
+//     (messaging.HookPosPortMsgRecvd).
+//   - AtStorage flips bits in the data read from a mem.Storage, through its
+//     mem.HookPosStorageRead hook.
+//   - AtPageTable makes vm.PageTable.Find fail.
+//
+// Each Fault has a Trigger that hits chosen candidates (the Nth message,
+// read, or lookup) or draws them with a probability from the injector's
+// seeded generator, optionally within a time window and up to a cap. The same
+// seed injects the same faults into the same serial simulation.
+//
+// Every injection is logged with its time, site, and the ID of the affected
+// message, which is also the ID of the message's tasks in a trace. Log returns
+// the log; RecordTo writes it to the "fault_injection" table of a data
+// recording, so it can be joined with the trace tables.
+//
+// Usage:
+//
+//	inj := faultinject.NewInjector("Faults", sim.GetEngine(), 42)
+//	inj.RecordTo(sim.GetDataRecorder())
+//
+//	port := inj.AtPort(rawPort, faultinject.Fault{
+//		Kind:    faultinject.Drop,
+//		Trigger: faultinject.Trigger{Probability: 0.001},
+//	})
+//	comp.AssignPort("Top", port)
+//	conn.PlugIn(port)
+//
+//	inj.AtStorage(storage, faultinject.Fault{
+//		Kind:    faultinject.Corrupt,
+//		Trigger: faultinject.Trigger{Nth: []uint64{100}},
+//		Bits:    2,
+//	})
+package faultinject
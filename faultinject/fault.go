@@ -0,0 +1,115 @@
+package faultinject
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// Kind is the kind of fault to inject.
+type Kind string
+
+// The fault kinds. Drop, Delay, Duplicate, and Reorder apply to messages,
+// Corrupt to storage reads, and PageFault to page table lookups.
+const (
+	// Drop discards the message.
+	Drop Kind = "drop"
+	// Delay holds the message back for Fault.Delay.
+	Delay Kind = "delay"
+	// Duplicate passes the message on twice.
+	Duplicate Kind = "duplicate"
+	// Reorder holds the message back until the next message has passed, or
+	// for at most Fault.Delay.
+	Reorder Kind = "reorder"
+	// Corrupt flips Fault.Bits random bits in the data read.
+	Corrupt Kind = "corrupt"
+	// PageFault makes the page table report the page as not found.
+	PageFault Kind = "page_fault"
+)
+
+// Trigger selects which of the candidate events at a site a fault hits. A
+// candidate is a message passing the site, a storage read, or a page table
+// lookup, counted from 1. A candidate is hit if it falls in the time window
+// and is either listed in Nth or drawn with Probability from the injector's
+// seeded generator. Both the schedule and the draws are deterministic for a
+// given seed and simulation.
+type Trigger struct {
+	// Nth lists the candidates to hit, counting from 1.
+	Nth []uint64
+	// Probability is the chance that any other candidate is hit.
+	Probability float64
+	// From and Until bound the simulated time window in which the fault is
+	// active. A zero Until leaves the window open.
+	From, Until timing.VTimeInPicoSec
+	// Max caps the number of injections. Zero means no cap.
+	Max uint64
+}
+
+// Fault describes one fault to inject at a site.
+type Fault struct {
+	Kind    Kind
+	Trigger Trigger
+
+	// Pos chooses where a message fault applies at a port:
+	// messaging.HookPosPortMsgSend when the owning component sends, or
+	// messaging.HookPosPortMsgRecvd (the default) when the connection
+	// delivers. Faults at a connection always apply on delivery.
+	Pos *hooking.HookPos
+
+	// Match restricts a message fault to the messages it returns true for.
+	// A nil Match accepts all messages.
+	Match func(msg messaging.Msg) bool
+
+	// Delay is how long Delay holds a message back and the longest Reorder
+	// waits for a following message. It is required for both.
+	Delay timing.VTimeInPicoSec
+
+	// Bits is the number of bits Corrupt flips. It defaults to 1.
+	Bits int
+}
+
+func (f Fault) mustBeValid(site string) {
+	if (f.Kind == Delay || f.Kind == Reorder) && f.Delay == 0 {
+		panic(fmt.Sprintf("fault injection at %s: %s requires a Delay",
+			site, f.Kind))
+	}
+
+	if f.Trigger.Probability < 0 || f.Trigger.Probability > 1 {
+		panic(fmt.Sprintf("fault injection at %s: probability %v is "+
+			"not in [0, 1]", site, f.Trigger.Probability))
+	}
+}
+
+// armedFault is a fault installed at a site, with the counters its trigger
+// needs.
+type armedFault struct {
+	Fault
+
+	candidates uint64
+	injected   uint64
+}
+
+func (a *armedFault) fires(now timing.VTimeInPicoSec, rng *rand.Rand) bool {
+	a.candidates++
+
+	t := a.Trigger
+	if now < t.From || (t.Until > 0 && now >= t.Until) {
+		return false
+	}
+
+	if t.Max > 0 && a.injected >= t.Max {
+		return false
+	}
+
+	hit := slices.Contains(t.Nth, a.candidates) ||
+		(t.Probability > 0 && rng.Float64() < t.Probability)
+	if hit {
+		a.injected++
+	}
+
+	return hit
+}
@@ -0,0 +1,211 @@
+package faultinject_test
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/faultinject"
+	"github.com/sarchlab/akita/v5/mem"
+	"github.com/sarchlab/akita/v5/mem/vm"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+type testMsg struct{ messaging.MsgMeta }
+
+type link struct {
+	engine timing.Engine
+	inj    *faultinject.Injector
+	conn   messaging.Connection
+	a, b   messaging.Port
+}
+
+// newLink connects port A to port B with a direct connection. wrap may
+// replace the connection or the ports with faulty ones before plugging in.
+func newLink(seed uint64, wrap func(l *link)) *link {
+	l := &link{engine: timing.NewSerialEngine()}
+	l.inj = faultinject.NewInjector("Faults", l.engine, seed)
+	l.conn = directconnection.MakeBuilder().
+		WithRegistrar(modeling.NewStandaloneRegistrar(l.engine)).
+		Build("Conn")
+	l.a = messaging.NewPort(nil, 16, 16, "A.Port")
+	l.b = messaging.NewPort(nil, 16, 16, "B.Port")
+
+	if wrap != nil {
+		wrap(l)
+	}
+
+	l.conn.PlugIn(l.a)
+	l.conn.PlugIn(l.b)
+
+	return l
+}
+
+// run sends messages with IDs 1 to n from A to B and returns the IDs B
+// received, in order.
+func (l *link) run(t *testing.T, n uint64) []uint64 {
+	t.Helper()
+
+	for id := uint64(1); id <= n; id++ {
+		l.a.Send(testMsg{messaging.MsgMeta{
+			ID: id, Src: l.a.AsRemote(), Dst: l.b.AsRemote()}})
+	}
+
+	if err := l.engine.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []uint64
+	for msg := l.b.RetrieveIncoming(); msg != nil; msg = l.b.RetrieveIncoming() {
+		got = append(got, msg.Meta().ID)
+	}
+
+	return got
+}
+
+func expectIDs(t *testing.T, got []uint64, want ...uint64) {
+	t.Helper()
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected messages %v, got %v", want, got)
+	}
+}
+
+func TestDropAndDuplicateAtPort(t *testing.T) {
+	l := newLink(1, func(l *link) {
+		l.b = l.inj.AtPort(l.b,
+			faultinject.Fault{Kind: faultinject.Drop,
+				Trigger: faultinject.Trigger{Nth: []uint64{2}}},
+			faultinject.Fault{Kind: faultinject.Duplicate,
+				Trigger: faultinject.Trigger{Nth: []uint64{4}}},
+		)
+	})
+
+	expectIDs(t, l.run(t, 4), 1, 3, 4, 4)
+
+	log := l.inj.Log()
+	if len(log) != 2 || log[0].Kind != faultinject.Drop || log[0].MsgID != 2 ||
+		log[0].Site != "B.Port" || log[1].Kind != faultinject.Duplicate {
+		t.Fatalf("unexpected injection log %+v", log)
+	}
+}
+
+func TestDelayAtSend(t *testing.T) {
+	l := newLink(1, func(l *link) {
+		l.a = l.inj.AtPort(l.a, faultinject.Fault{
+			Kind:    faultinject.Delay,
+			Pos:     messaging.HookPosPortMsgSend,
+			Trigger: faultinject.Trigger{Nth: []uint64{1}},
+			Delay:   500,
+		})
+	})
+
+	expectIDs(t, l.run(t, 3), 2, 3, 1)
+
+	if now := l.engine.CurrentTime(); now < 500 {
+		t.Fatalf("the delayed message must leave at 500 ps, engine stopped at %d", now)
+	}
+}
+
+func TestReorderAtConnection(t *testing.T) {
+	l := newLink(1, func(l *link) {
+		l.conn = l.inj.AtConnection(l.conn, faultinject.Fault{
+			Kind:    faultinject.Reorder,
+			Trigger: faultinject.Trigger{Nth: []uint64{1, 3}},
+			Delay:   100,
+		})
+	})
+
+	// Message 3 has no follower and goes on after the reorder window.
+	expectIDs(t, l.run(t, 3), 2, 1, 3)
+}
+
+func TestProbabilityIsSeeded(t *testing.T) {
+	drop := func(seed uint64) []uint64 {
+		l := newLink(seed, func(l *link) {
+			l.conn = l.inj.AtConnection(l.conn, faultinject.Fault{
+				Kind:    faultinject.Drop,
+				Trigger: faultinject.Trigger{Probability: 0.5, Max: 4},
+			})
+		})
+
+		return l.run(t, 12)
+	}
+
+	first := drop(7)
+	if len(first) != 8 {
+		t.Fatalf("expected 4 of 12 messages dropped, got %v", first)
+	}
+
+	expectIDs(t, drop(7), first...)
+}
+
+func TestCorruptStorageReads(t *testing.T) {
+	inj := faultinject.NewInjector("Faults", timing.NewSerialEngine(), 3)
+	storage := mem.NewStorage(4 * mem.KB)
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	if err := storage.Write(64, data); err != nil {
+		t.Fatal(err)
+	}
+
+	inj.AtStorage(storage, faultinject.Fault{
+		Kind:    faultinject.Corrupt,
+		Trigger: faultinject.Trigger{Nth: []uint64{1}},
+		Bits:    1,
+	})
+
+	corrupted, _ := storage.Read(64, 8)
+	flips := 0
+
+	for i := range data {
+		for x := corrupted[i] ^ data[i]; x != 0; x &= x - 1 {
+			flips++
+		}
+	}
+
+	if flips != 1 {
+		t.Fatalf("expected one flipped bit, got %v", corrupted)
+	}
+
+	if again, _ := storage.Read(64, 8); !bytes.Equal(again, data) {
+		t.Fatalf("corruption must not persist, read %v", again)
+	}
+
+	if log := inj.Log(); len(log) != 1 || log[0].Address != 64 {
+		t.Fatalf("unexpected injection log %+v", log)
+	}
+}
+
+func TestPageTableFindFails(t *testing.T) {
+	inj := faultinject.NewInjector("Faults", timing.NewSerialEngine(), 3)
+	pt := vm.NewPageTable(12)
+	pt.Insert(vm.Page{PID: 1, VAddr: 0x1000, PAddr: 0x8000, PageSize: 4096, Valid: true})
+
+	faulty := inj.AtPageTable(pt, faultinject.Fault{
+		Kind:    faultinject.PageFault,
+		Trigger: faultinject.Trigger{Nth: []uint64{1}},
+	})
+
+	if _, found := faulty.Find(1, 0x1010); found {
+		t.Fatal("the first lookup must fail")
+	}
+
+	if page, found := faulty.Find(1, 0x1010); !found || page.PAddr != 0x8000 {
+		t.Fatal("later lookups must find the page")
+	}
+}
+
+func TestRejectsFaultsTheSiteCannotInject(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a drop fault at a storage")
+		}
+	}()
+
+	inj := faultinject.NewInjector("Faults", timing.NewSerialEngine(), 3)
+	inj.AtStorage(mem.NewStorage(mem.KB), faultinject.Fault{Kind: faultinject.Drop})
+}
@@ -0,0 +1,180 @@
+package faultinject
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+
+	"github.com/sarchlab/akita/v5/datarecording"
+	"github.com/sarchlab/akita/v5/naming"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// RetryInterval is how often a held message that finds its port full is
+// retried.
+const RetryInterval timing.VTimeInPicoSec = 1000
+
+const logTableName = "fault_injection"
+
+// Record is one entry of the injection log.
+type Record struct {
+	Time timing.VTimeInPicoSec
+	Kind Kind
+	// Site is the name of the port, connection, storage, or page table where
+	// the fault was injected.
+	Site string
+	// MsgID is the ID of the affected message. It equals the ID of the
+	// message's tasks in a trace, so the log can be joined with traces.
+	MsgID uint64
+	// Address is the address of the affected storage read or page lookup.
+	Address uint64
+	Detail  string
+}
+
+// logEntry is the table structure of the injection log in a data recording.
+type logEntry struct {
+	Time    float64 `json:"time"`
+	Kind    string  `json:"kind"`
+	Site    string  `json:"site"`
+	MsgID   uint64  `json:"msg_id"`
+	Address uint64  `json:"address"`
+	Detail  string  `json:"detail"`
+}
+
+// Injector injects faults at the sites it is installed at and logs every
+// injection. All sites of an injector share one seeded random generator, so a
+// serial simulation injects the same faults on every run with the same seed.
+type Injector struct {
+	mu       sync.Mutex
+	name     string
+	engine   timing.EventScheduler
+	rng      *rand.Rand
+	log      []Record
+	recorder datarecording.DataRecorder
+}
+
+// NewInjector creates an injector that schedules its events on the engine and
+// draws probabilities from a generator seeded with seed.
+func NewInjector(
+	name string,
+	engine timing.EventScheduler,
+	seed uint64,
+) *Injector {
+	naming.MustBeValid(name)
+
+	inj := &Injector{
+		name:   name,
+		engine: engine,
+		rng:    rand.New(rand.NewPCG(seed, seed)),
+	}
+
+	if registrar, ok := engine.(timing.HandlerRegistrar); ok {
+		registrar.RegisterHandler(name, inj)
+	}
+
+	return inj
+}
+
+// Name returns the name of the injector.
+func (inj *Injector) Name() string {
+	return inj.name
+}
+
+// RecordTo also writes the injection log to the "fault_injection" table of a
+// data recording, typically the simulation's, next to its traces.
+func (inj *Injector) RecordTo(recorder datarecording.DataRecorder) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	if !slices.Contains(recorder.ListTables(), logTableName) {
+		recorder.CreateTable(logTableName, logEntry{})
+	}
+
+	inj.recorder = recorder
+
+	for _, r := range inj.log {
+		inj.insert(r)
+	}
+}
+
+// Log returns the injections so far, in the order they happened.
+func (inj *Injector) Log() []Record {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	return append([]Record(nil), inj.log...)
+}
+
+// Handle implements timing.Handler. It releases held messages.
+func (inj *Injector) Handle(e timing.Event) error {
+	switch e := e.(type) {
+	case releaseEvent:
+		e.lane.release(e.Time())
+	default:
+		panic(fmt.Sprintf("fault injector cannot handle event %T", e))
+	}
+
+	return nil
+}
+
+func (inj *Injector) fires(f *armedFault) bool {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	return f.fires(inj.engine.CurrentTime(), inj.rng)
+}
+
+func (inj *Injector) skip(f *armedFault) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	f.candidates++
+}
+
+func (inj *Injector) intn(n int) int {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	return inj.rng.IntN(n)
+}
+
+func (inj *Injector) record(r Record) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	r.Time = inj.engine.CurrentTime()
+	inj.log = append(inj.log, r)
+
+	if inj.recorder != nil {
+		inj.insert(r)
+	}
+}
+
+func (inj *Injector) insert(r Record) {
+	inj.recorder.InsertData(logTableName, logEntry{
+		Time:    float64(r.Time),
+		Kind:    string(r.Kind),
+		Site:    r.Site,
+		MsgID:   r.MsgID,
+		Address: r.Address,
+		Detail:  r.Detail,
+	})
+}
+
+func (inj *Injector) arm(site string, faults []Fault, kinds ...Kind) []*armedFault {
+	armed := make([]*armedFault, 0, len(faults))
+
+	for _, f := range faults {
+		f.mustBeValid(site)
+
+		if !slices.Contains(kinds, f.Kind) {
+			panic(fmt.Sprintf("fault injection at %s: %s faults "+
+				"cannot be injected here", site, f.Kind))
+		}
+
+		armed = append(armed, &armedFault{Fault: f})
+	}
+
+	return armed
+}
@@ -0,0 +1,52 @@
+package faultinject
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v5/mem/vm"
+	"github.com/sarchlab/akita/v5/naming"
+)
+
+// faultyPageTable is a page table whose lookups may fail.
+type faultyPageTable struct {
+	vm.PageTable
+
+	inj    *Injector
+	site   string
+	faults []*armedFault
+}
+
+// AtPageTable injects PageFault faults into the lookups of a page table. It
+// returns the page table to give to the MMU or driver in place of the
+// original. Every Find is a candidate; a hit reports the page as not found.
+func (inj *Injector) AtPageTable(pt vm.PageTable, faults ...Fault) vm.PageTable {
+	site := "page table"
+	if named, ok := pt.(naming.Named); ok && named.Name() != "" {
+		site = named.Name()
+	}
+
+	return &faultyPageTable{
+		PageTable: pt,
+		inj:       inj,
+		site:      site,
+		faults:    inj.arm(site, faults, PageFault),
+	}
+}
+
+// Find looks up the page, unless a fault makes the lookup fail.
+func (pt *faultyPageTable) Find(pid vm.PID, vAddr uint64) (vm.Page, bool) {
+	for _, f := range pt.faults {
+		if pt.inj.fires(f) {
+			pt.inj.record(Record{
+				Kind:    PageFault,
+				Site:    pt.site,
+				Address: vAddr,
+				Detail:  fmt.Sprintf("PID %d", pid),
+			})
+
+			return vm.Page{}, false
+		}
+	}
+
+	return pt.PageTable.Find(pid, vAddr)
+}
@@ -0,0 +1,252 @@
+package faultinject
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// releaseEvent makes the injector release the messages a lane holds back.
+type releaseEvent struct {
+	timing.EventBase
+	lane *lane
+}
+
+type heldMsg struct {
+	msg       messaging.Msg
+	releaseAt timing.VTimeInPicoSec
+	// reordered messages are also released as soon as the next message has
+	// passed.
+	reordered bool
+}
+
+// lane applies message faults at one position of a port: where the owning
+// component sends, or where the connection delivers.
+type lane struct {
+	mu      sync.Mutex
+	inj     *Injector
+	site    string
+	faults  []*armedFault
+	held    []heldMsg
+	canPass func() bool
+	pass    func(msg messaging.Msg)
+}
+
+func (l *lane) handle(msg messaging.Msg) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f := l.pick(msg)
+	if f == nil {
+		l.pass(msg)
+		l.releaseReordered()
+
+		return
+	}
+
+	l.inj.record(Record{
+		Kind:   f.Kind,
+		Site:   l.site,
+		MsgID:  msg.Meta().ID,
+		Detail: fmt.Sprintf("%T from %s to %s", msg, msg.Meta().Src, msg.Meta().Dst),
+	})
+
+	now := l.inj.engine.CurrentTime()
+
+	switch f.Kind {
+	case Drop:
+	case Delay:
+		l.hold(heldMsg{msg: msg, releaseAt: now + f.Delay})
+	case Duplicate:
+		l.pass(msg)
+		l.hold(heldMsg{msg: msg, releaseAt: now})
+	case Reorder:
+		l.hold(heldMsg{msg: msg, releaseAt: now + f.Delay, reordered: true})
+	}
+}
+
+// pick returns the first fault that hits the message, or nil. Faults after
+// the hit still count the message as a candidate, so each fault's Nth counts
+// every message it matches.
+func (l *lane) pick(msg messaging.Msg) *armedFault {
+	var hit *armedFault
+
+	for _, f := range l.faults {
+		if f.Match != nil && !f.Match(msg) {
+			continue
+		}
+
+		if hit != nil {
+			l.inj.skip(f)
+			continue
+		}
+
+		if l.inj.fires(f) {
+			hit = f
+		}
+	}
+
+	return hit
+}
+
+func (l *lane) hold(h heldMsg) {
+	l.held = append(l.held, h)
+	l.schedule(h.releaseAt)
+}
+
+func (l *lane) schedule(t timing.VTimeInPicoSec) {
+	l.inj.engine.Schedule(releaseEvent{
+		EventBase: timing.MakeEventBase(t, l.inj.name),
+		lane:      l,
+	})
+}
+
+// releaseReordered lets the messages held for reordering follow the message
+// that just passed, as far as there is room.
+func (l *lane) releaseReordered() {
+	kept := l.held[:0]
+
+	for _, h := range l.held {
+		if h.reordered && l.canPass() {
+			l.pass(h.msg)
+			continue
+		}
+
+		kept = append(kept, h)
+	}
+
+	l.held = kept
+}
+
+func (l *lane) release(now timing.VTimeInPicoSec) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	kept := l.held[:0]
+	blocked := false
+
+	for _, h := range l.held {
+		if h.releaseAt > now {
+			kept = append(kept, h)
+			continue
+		}
+
+		if !blocked && l.canPass() {
+			l.pass(h.msg)
+			continue
+		}
+
+		blocked = true
+
+		kept = append(kept, h)
+	}
+
+	l.held = kept
+
+	if blocked {
+		l.schedule(now + RetryInterval)
+	}
+}
+
+// faultyPort is a port with message faults. It forwards everything else to
+// the port it wraps.
+type faultyPort struct {
+	messaging.Port
+
+	send  *lane
+	recvd *lane
+}
+
+// AtPort injects message faults at a port. It returns the port to use in
+// place of the original: assign it to the component and plug it into the
+// connection, so that both the component's sends and the connection's
+// deliveries pass through it. The original port still fires its hooks for
+// the messages that actually pass, so traces show the faulty traffic.
+func (inj *Injector) AtPort(port messaging.Port, faults ...Fault) messaging.Port {
+	var send, recvd []*armedFault
+
+	for _, f := range inj.arm(port.Name(), faults, Drop, Delay, Duplicate, Reorder) {
+		if f.Pos == messaging.HookPosPortMsgSend {
+			send = append(send, f)
+		} else {
+			recvd = append(recvd, f)
+		}
+	}
+
+	return inj.wrapPort(port, port.Name(), send, recvd)
+}
+
+func (inj *Injector) wrapPort(
+	port messaging.Port,
+	site string,
+	send, recvd []*armedFault,
+) *faultyPort {
+	p := &faultyPort{Port: port}
+
+	if len(send) > 0 {
+		p.send = &lane{
+			inj: inj, site: site, faults: send,
+			canPass: port.CanSend, pass: port.Send,
+		}
+	}
+
+	if len(recvd) > 0 {
+		p.recvd = &lane{
+			inj: inj, site: site, faults: recvd,
+			canPass: port.CanDeliver, pass: port.Deliver,
+		}
+	}
+
+	return p
+}
+
+// Send sends the message through the send-side faults.
+func (p *faultyPort) Send(msg messaging.Msg) {
+	if p.send == nil {
+		p.Port.Send(msg)
+		return
+	}
+
+	p.send.handle(msg)
+}
+
+// Deliver delivers the message through the receive-side faults.
+func (p *faultyPort) Deliver(msg messaging.Msg) {
+	if p.recvd == nil {
+		p.Port.Deliver(msg)
+		return
+	}
+
+	p.recvd.handle(msg)
+}
+
+// faultyConn is a connection that plugs in ports with receive-side faults.
+type faultyConn struct {
+	messaging.Connection
+
+	inj    *Injector
+	faults []*armedFault
+}
+
+// AtConnection injects message faults into the traffic of a connection. It
+// returns the connection to plug ports into in place of the original. The
+// faults apply as the connection delivers messages, whatever their Pos, and
+// the ports share the triggers, so Nth counts the messages of the whole
+// connection.
+func (inj *Injector) AtConnection(
+	conn messaging.Connection,
+	faults ...Fault,
+) messaging.Connection {
+	return &faultyConn{
+		Connection: conn,
+		inj:        inj,
+		faults:     inj.arm(conn.Name(), faults, Drop, Delay, Duplicate, Reorder),
+	}
+}
+
+// PlugIn plugs the port into the wrapped connection behind the faults.
+func (c *faultyConn) PlugIn(port messaging.Port) {
+	c.Connection.PlugIn(c.inj.wrapPort(port, c.Name(), nil, c.faults))
+}
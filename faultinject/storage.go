@@ -0,0 +1,65 @@
+package faultinject
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/mem"
+)
+
+// storageHook corrupts the data of storage reads.
+type storageHook struct {
+	inj    *Injector
+	site   string
+	faults []*armedFault
+}
+
+// AtStorage injects Corrupt faults into the reads from a storage. Every read
+// is a candidate; the data written stays intact.
+func (inj *Injector) AtStorage(storage *mem.Storage, faults ...Fault) {
+	site := storage.Name()
+	if site == "" {
+		site = "storage"
+	}
+
+	storage.AcceptHook(&storageHook{
+		inj:    inj,
+		site:   site,
+		faults: inj.arm(site, faults, Corrupt),
+	})
+}
+
+// Func implements hooking.Hook.
+func (h *storageHook) Func(ctx hooking.HookCtx) {
+	if ctx.Pos != mem.HookPosStorageRead {
+		return
+	}
+
+	access, ok := ctx.Item.(mem.StorageAccess)
+	if !ok || len(access.Data) == 0 {
+		return
+	}
+
+	for _, f := range h.faults {
+		if !h.inj.fires(f) {
+			continue
+		}
+
+		bits := max(f.Bits, 1)
+		flipped := make([]int, bits)
+
+		for i := range flipped {
+			bit := h.inj.intn(len(access.Data) * 8)
+			access.Data[bit/8] ^= 1 << (bit % 8)
+			flipped[i] = bit
+		}
+
+		h.inj.record(Record{
+			Kind:    Corrupt,
+			Site:    h.site,
+			Address: access.Address,
+			Detail: fmt.Sprintf("flipped bits %v of a %d-byte read",
+				flipped, len(access.Data)),
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package dram
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// Spec.AddrMapper selects how an address is decoded into a location. Besides
+// the registered names ("default", "xor", "rit"), it accepts a bit string that
+// orders the location fields from the highest address bits to the lowest, as
+// Ramulator2 names its mappings: "RoRaBaBgCoCh" puts the row in the highest
+// bits and the channel right above the access unit. The fields are Ch
+// (channel), Pc (pseudo-channel), Ra (rank), Bg (bank group), Ba (bank), Ro
+// (row), and Co (column);
+// a field with no bits may be left out. A "_xor" suffix adds XOR hashing, and
+// a "_rit" suffix a randomized interleaving table, e.g. "RoBaRaCoCh_xor_rit".
+
+// addrField is a field of a location, as ordered by an address mapping.
+type addrField int
+
+// A list of location fields.
+const (
+	addrFieldChannel addrField = iota
+	addrFieldPseudoChannel
+	addrFieldRank
+	addrFieldBankGroup
+	addrFieldBank
+	addrFieldRow
+	addrFieldColumn
+	numAddrField
+)
+
+// addrFieldTokens are the bit-string names of the location fields.
+var addrFieldTokens = [numAddrField]string{
+	addrFieldChannel:       "Ch",
+	addrFieldPseudoChannel: "Pc",
+	addrFieldRank:          "Ra",
+	addrFieldBankGroup:     "Bg",
+	addrFieldBank:          "Ba",
+	addrFieldRow:           "Ro",
+	addrFieldColumn:        "Co",
+}
+
+// defaultAddrFieldOrder is the field order of the default mapping, highest
+// bits first.
+const defaultAddrFieldOrder = "RoChPcRaBaBgCo"
+
+const (
+	addrMapperSuffixXOR = "_xor"
+	addrMapperSuffixRIT = "_rit"
+)
+
+// addrMapping is a parsed Spec.AddrMapper.
+type addrMapping struct {
+	// order lists the fields from the highest address bits to the lowest.
+	order []addrField
+	xor   bool
+	rit   bool
+}
+
+// parseAddrMapping parses a Spec.AddrMapper value. The registered names map to
+// the default field order.
+func parseAddrMapping(name string) (addrMapping, error) {
+	m := addrMapping{}
+
+	for {
+		switch {
+		case strings.HasSuffix(name, addrMapperSuffixRIT):
+			m.rit = true
+			name = strings.TrimSuffix(name, addrMapperSuffixRIT)
+
+			continue
+		case strings.HasSuffix(name, addrMapperSuffixXOR):
+			m.xor = true
+			name = strings.TrimSuffix(name, addrMapperSuffixXOR)
+
+			continue
+		}
+
+		break
+	}
+
+	switch name {
+	case "", addrMapperDefault:
+		name = defaultAddrFieldOrder
+	case addrMapperXOR:
+		m.xor = true
+		name = defaultAddrFieldOrder
+	case addrMapperRIT:
+		m.rit = true
+		name = defaultAddrFieldOrder
+	}
+
+	order, err := parseAddrFieldOrder(name)
+	if err != nil {
+		return addrMapping{}, err
+	}
+
+	m.order = order
+
+	return m, nil
+}
+
+// parseAddrFieldOrder parses a bit string of two-letter field names.
+func parseAddrFieldOrder(s string) ([]addrField, error) {
+	if len(s)%2 != 0 {
+		return nil, fmt.Errorf("%q is not a sequence of two-letter fields", s)
+	}
+
+	seen := [numAddrField]bool{}
+	order := []addrField{}
+
+	for i := 0; i < len(s); i += 2 {
+		token := s[i : i+2]
+
+		f, found := addrField(0), false
+		for j, t := range addrFieldTokens {
+			if strings.EqualFold(token, t) {
+				f, found = addrField(j), true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("unknown field %q", token)
+		}
+
+		if seen[f] {
+			return nil, fmt.Errorf("field %q appears twice", token)
+		}
+
+		seen[f] = true
+		order = append(order, f)
+	}
+
+	return order, nil
+}
+
+// missingFields returns the fields that have address bits but are not in the
+// mapping's order.
+func (m addrMapping) missingFields(w addrBitWidths) []string {
+	in := [numAddrField]bool{}
+	for _, f := range m.order {
+		in[f] = true
+	}
+
+	missing := []string{}
+
+	for f := range numAddrField {
+		if !in[f] && w.of(f) > 0 {
+			missing = append(missing, addrFieldTokens[f])
+		}
+	}
+
+	return missing
+}
+
+// hashedAddrMapper decodes the configured bit fields and then hashes the bank
+// and channel bits.
+type hashedAddrMapper struct {
+	name string
+	xor  bool
+	rit  *interleavingTable
+}
+
+func (m hashedAddrMapper) Name() string { return m.name }
+
+func (m hashedAddrMapper) Map(spec *Spec, addr uint64) location {
+	l := mapAddress(spec, addr)
+
+	if m.xor {
+		xorHash(spec, &l)
+	}
+
+	if m.rit != nil {
+		m.rit.apply(spec, &l)
+	}
+
+	return l
+}
+
+// xorHash XORs the bank, bank-group, rank, pseudo-channel, and channel bits
+// with the lowest row bits, in that order (permutation-based interleaving). Rows that differ
+// only above the column bits, and would otherwise map to the same bank, are
+// spread over the banks. The row is unchanged, so the hash is a bijection.
+func xorHash(spec *Spec, l *location) {
+	row := l.Row
+
+	hash := func(v *uint64, mask uint64) {
+		*v ^= row & mask
+		row >>= bitCount(mask)
+	}
+
+	hash(&l.Bank, spec.BankMask)
+	hash(&l.BankGroup, spec.BankGroupMask)
+	hash(&l.Rank, spec.RankMask)
+	hash(&l.PseudoChannel, spec.PseudoChannelMask)
+	hash(&l.Channel, spec.ChannelMask)
+}
+
+// bitCount returns the number of set bits of a contiguous mask.
+func bitCount(mask uint64) uint64 {
+	n := uint64(0)
+	for ; mask != 0; mask >>= 1 {
+		n += mask & 1
+	}
+
+	return n
+}
+
+// ritIndexBits is the number of low row bits that index the randomized
+// interleaving table.
+const ritIndexBits = 8
+
+// interleavingTable is a Ramulator-style randomized interleaving table: a
+// random permutation of the banks of a rank for each value of the lowest row
+// bits. Consecutive rows visit the banks in different random orders, which
+// breaks the bank conflicts of strided access patterns. Each entry is a
+// permutation, so the mapping stays a bijection.
+type interleavingTable struct {
+	perms [][]int
+}
+
+// newInterleavingTable draws the permutations from Spec.AddrMapperSeed, so a
+// seed always builds the same table.
+func newInterleavingTable(spec *Spec) *interleavingTable {
+	rowBits, _ := log2(uint64(spec.NumRow))
+	rows := 1 << min(ritIndexBits, rowBits)
+	banks := spec.NumBankGroup * spec.NumBank
+
+	rng := rand.New(rand.NewPCG(spec.AddrMapperSeed, 0))
+	t := &interleavingTable{perms: make([][]int, rows)}
+
+	for i := range t.perms {
+		t.perms[i] = rng.Perm(banks)
+	}
+
+	return t
+}
+
+func (t *interleavingTable) apply(spec *Spec, l *location) {
+	perm := t.perms[l.Row%uint64(len(t.perms))]
+	bank := perm[int(l.BankGroup)*spec.NumBank+int(l.Bank)]
+
+	l.BankGroup = uint64(bank / spec.NumBank)
+	l.Bank = uint64(bank % spec.NumBank)
+}
+
+// newMappedAddrMapper builds the mapper of a parsed mapping.
+func newMappedAddrMapper(name string, spec *Spec, m addrMapping) addrMapper {
+	if !m.xor && !m.rit {
+		return fixedAddrMapper{name: name}
+	}
+
+	mapper := hashedAddrMapper{name: name, xor: m.xor}
+	if m.rit {
+		mapper.rit = newInterleavingTable(spec)
+	}
+
+	return mapper
+}
+
+// addrMapperMustBeValid rejects an AddrMapper that is neither registered nor
+// a valid bit string, or that leaves out a field with address bits.
+func (b *Builder) addrMapperMustBeValid() {
+	name := b.spec.AddrMapper
+	if _, registered := addrMapperRegistry[name]; registered {
+		return
+	}
+
+	m, err := parseAddrMapping(name)
+	if err != nil {
+		panic(fmt.Sprintf("dram: unknown address mapper %q: %v", name, err))
+	}
+
+	if missing := m.missingFields(b.addrBitWidths()); len(missing) > 0 {
+		panic(fmt.Sprintf("dram: address mapper %q leaves out %s",
+			name, strings.Join(missing, ", ")))
+	}
+}
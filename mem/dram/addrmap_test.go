@@ -0,0 +1,123 @@
+package dram
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Address Mapping", func() {
+	// buildMapper returns the built spec and address mapper of a DDR4 spec
+	// with a small geometry and the given AddrMapper.
+	buildMapper := func(name string, seed uint64) (Spec, addrMapper) {
+		spec := DDR4Spec
+		spec.NumRow = 1024
+		spec.NumCol = 64
+		spec.AddrMapper = name
+		spec.AddrMapperSeed = seed
+
+		b := MakeBuilder().WithSpec(spec)
+		b.normalizeSpec()
+		built := b.buildSpec()
+
+		return built, b.buildController().addrMapper
+	}
+
+	// sweep maps every access unit of the capacity and expects no two of them
+	// to share a location.
+	expectBijective := func(spec Spec, m addrMapper) {
+		unit := uint64(1) << spec.Log2AccessUnitSize
+		units := uint64(spec.NumRank*spec.NumBankGroup*spec.NumBank*spec.NumRow) *
+			uint64(spec.NumCol/spec.BurstLength)
+
+		seen := make(map[location]bool, units)
+		for i := range units {
+			l := m.Map(&spec, i*unit)
+			Expect(seen[l]).To(BeFalse(), "address %#x", i*unit)
+			seen[l] = true
+		}
+	}
+
+	It("should keep the default field order", func() {
+		spec, m := buildMapper("", 0)
+
+		Expect(m.Name()).To(Equal("default"))
+		// Column, bank group, bank, then row above a 64-byte access unit.
+		Expect(spec.ColPos).To(Equal(6))
+		Expect(spec.BankGroupPos).To(Equal(9))
+		Expect(spec.BankPos).To(Equal(11))
+		Expect(spec.RowPos).To(Equal(13))
+	})
+
+	It("should lay the fields out in bit-string order", func() {
+		spec, m := buildMapper("RoRaBgBaCoCh", 0)
+
+		Expect(m.Name()).To(Equal("RoRaBgBaCoCh"))
+		Expect(spec.ColPos).To(Equal(6))
+		Expect(spec.BankPos).To(Equal(9))
+		Expect(spec.BankGroupPos).To(Equal(11))
+		Expect(spec.RowPos).To(Equal(13))
+
+		l := m.Map(&spec, 3<<13|2<<11|1<<9|5<<6)
+		Expect(l).To(Equal(location{Row: 3, BankGroup: 2, Bank: 1, Column: 5}))
+		expectBijective(spec, m)
+	})
+
+	It("should accept DRAMSim3's lowercase field names", func() {
+		spec, _ := buildMapper("rochrababgco", 0)
+
+		Expect(spec.ColPos).To(Equal(6))
+		Expect(spec.BankGroupPos).To(Equal(9))
+		Expect(spec.BankPos).To(Equal(11))
+		Expect(spec.RowPos).To(Equal(13))
+	})
+
+	It("should reject invalid bit strings", func() {
+		for _, name := range []string{"RoBaBgCoXx", "RoRoBaBgCo", "RoBaCo", "RoBaBgC"} {
+			Expect(func() { buildMapper(name, 0) }).To(Panic(), name)
+		}
+	})
+
+	It("should spread same-bank rows over the banks with XOR hashing", func() {
+		spec, m := buildMapper("RoBaBgCo_xor", 0)
+
+		banks := map[[2]uint64]bool{}
+		for row := range uint64(16) {
+			l := m.Map(&spec, row<<spec.RowPos)
+			Expect(l.Row).To(Equal(row))
+			banks[[2]uint64{l.BankGroup, l.Bank}] = true
+		}
+
+		Expect(banks).To(HaveLen(16))
+		expectBijective(spec, m)
+	})
+
+	It("should select XOR hashing of the default order by name", func() {
+		spec, m := buildMapper("xor", 0)
+
+		Expect(m.Name()).To(Equal("xor"))
+		Expect(m.Map(&spec, 1<<spec.RowPos).Bank).To(Equal(uint64(1)))
+	})
+
+	It("should interleave banks through a seeded random table", func() {
+		spec, m := buildMapper("RoBaBgCo_rit", 7)
+		expectBijective(spec, m)
+
+		_, same := buildMapper("RoBaBgCo_rit", 7)
+		_, other := buildMapper("RoBaBgCo_rit", 8)
+
+		differs := false
+		for row := range uint64(16) {
+			addr := row << spec.RowPos
+			Expect(same.Map(&spec, addr)).To(Equal(m.Map(&spec, addr)))
+			differs = differs || other.Map(&spec, addr) != m.Map(&spec, addr)
+		}
+		Expect(differs).To(BeTrue())
+	})
+
+	It("should combine XOR hashing and the interleaving table", func() {
+		spec, m := buildMapper("RoRaBaBgCoCh_xor_rit", 3)
+
+		Expect(m.Name()).To(Equal("RoRaBaBgCoCh_xor_rit"))
+		expectBijective(spec, m)
+	})
+})
@@ -81,6 +81,11 @@ func isReadOrWrite(kind commandKind) bool {
 // getReadyCommand checks if a command can be issued to the bank.
 // It returns a copy of the command with the required kind, or nil.
 func getReadyCommand(spec *Spec, state *State, bs *bankState, cmd *commandState) *commandState {
+	if bs.RefreshPending || bs.MitigationPending ||
+		commandBusBusy(state, int(cmd.Location.Channel)) {
+		return nil
+	}
+
 	requiredKind := getRequiredCommandKind(bs, cmd)
 	if requiredKind == numCmdKind {
 		return nil
@@ -89,7 +94,7 @@ func getReadyCommand(spec *Spec, state *State, bs *bankState, cmd *commandState)
 	if bs.CyclesToCmdAvailable[requiredKind] == 0 {
 		// Check tFAW for activate commands
 		if requiredKind == cmdKindActivate && spec.TFAW > 0 {
-			if !canActivateUnderTFAW(spec, state, int(cmd.Location.Rank)) {
+			if !canActivateUnderTFAW(spec, state, rankIndex(&state.BankStates, cmd.Location)) {
 				return nil
 			}
 		}
@@ -101,6 +106,22 @@ func getReadyCommand(spec *Spec, state *State, bs *bankState, cmd *commandState)
 	return nil
 }
 
+// commandBusBusy returns true if a command already used the command bus of a
+// channel this cycle.
+func commandBusBusy(state *State, ch int) bool {
+	return ch < len(state.CommandBusBusy) && state.CommandBusBusy[ch]
+}
+
+// useCommandBus marks the command bus of a channel as used for this cycle.
+func useCommandBus(state *State, ch int) {
+	if ch >= len(state.CommandBusBusy) {
+		state.CommandBusBusy = append(state.CommandBusBusy,
+			make([]bool, ch+1-len(state.CommandBusBusy))...)
+	}
+
+	state.CommandBusBusy[ch] = true
+}
+
 // canActivateUnderTFAW checks whether issuing an activate on the given rank
 // would violate the tFAW constraint.
 func canActivateUnderTFAW(spec *Spec, state *State, rank int) bool {
@@ -191,6 +212,20 @@ func startCommand(cmdCycles map[commandKind]int, state *State, bs *bankState, cm
 		state.TotalPrecharges++
 	}
 
+	if ch := int(cmd.Location.Channel); ch < len(state.Channels) {
+		countChannelCommand(&state.Channels[ch], kind)
+	}
+
+	// The row-hammer middleware, if row hammer is modeled, counts the
+	// activations and shows the mitigation the activations and refreshes.
+	switch kind {
+	case cmdKindActivate, cmdKindRefresh, cmdKindRefreshBank:
+		if state.RowHammer.Enabled {
+			state.RowHammer.Observed = append(state.RowHammer.Observed,
+				observedCommand{Kind: int(kind), Location: cmd.Location})
+		}
+	}
+
 	// Update bank state based on the command
 	bankSt := bankStateKind(bs.State)
 
@@ -203,7 +238,7 @@ func startCommand(cmdCycles map[commandKind]int, state *State, bs *bankState, cm
 	case key{bankStateClosed, cmdKindActivate}:
 		bs.OpenRow = cmd.Location.Row
 		bs.State = int(bankStateOpen)
-		recordActivateTimestamp(state, int(cmd.Location.Rank))
+		recordActivateTimestamp(state, rankIndex(&state.BankStates, cmd.Location))
 	case key{bankStateOpen, cmdKindPrecharge},
 		key{bankStateOpen, cmdKindReadPrecharge},
 		key{bankStateOpen, cmdKindWritePrecharge}:
@@ -214,6 +249,20 @@ func startCommand(cmdCycles map[commandKind]int, state *State, bs *bankState, cm
 	}
 }
 
+// countChannelCommand counts a command in its channel's statistics.
+func countChannelCommand(c *channelStats, kind commandKind) {
+	switch kind {
+	case cmdKindRead, cmdKindReadPrecharge:
+		c.ReadCommands++
+	case cmdKindWrite, cmdKindWritePrecharge:
+		c.WriteCommands++
+	case cmdKindActivate:
+		c.Activates++
+	case cmdKindPrecharge:
+		c.Precharges++
+	}
+}
+
 // updateTiming updates timing constraints across all banks after a command
 // is issued.
 func updateTiming(timing dramTiming, state *State, cmd *commandState) {
@@ -223,24 +272,46 @@ func updateTiming(timing dramTiming, state *State, cmd *commandState) {
 	case cmdKindActivate,
 		cmdKindRead, cmdKindReadPrecharge,
 		cmdKindWrite, cmdKindWritePrecharge,
-		cmdKindPrecharge, cmdKindRefreshBank:
+		cmdKindPrecharge, cmdKindRefreshBank,
+		cmdKindRefresh, cmdKindSRefEnter, cmdKindSRefExit,
+		cmdKindPDEnter, cmdKindPDExit,
+		cmdKindVRR, cmdKindRFMBank, cmdKindRFM:
 		updateAllBankTiming(timing, state, cmd)
 	}
 }
 
+// isRankCommand returns true if the command kind addresses a whole rank rather
+// than a bank.
+func isRankCommand(kind commandKind) bool {
+	return kind == cmdKindRefresh ||
+		kind == cmdKindSRefEnter || kind == cmdKindSRefExit ||
+		kind == cmdKindPDEnter || kind == cmdKindPDExit ||
+		kind == cmdKindRFM
+}
+
 // updateAllBankTiming iterates over all banks and applies timing constraints.
+// A rank-level command applies the same-rank table to every bank of its rank.
+// Pseudo-channels have their own data buses, so a command constrains only the
+// banks of its own pseudo-channel.
 func updateAllBankTiming(timing dramTiming, state *State, cmd *commandState) {
 	kind := commandKind(cmd.Kind)
 	flat := &state.BankStates
 
 	for i := range flat.Entries {
 		entry := &flat.Entries[i]
+		if uint64(entry.Channel) != cmd.Location.Channel ||
+			uint64(entry.PseudoChannel) != cmd.Location.PseudoChannel {
+			continue
+		}
+
 		rank := uint64(entry.Rank)
 		bankGroup := uint64(entry.BankGroup)
 		bank := uint64(entry.BankIndex)
 
 		var timingTable timeTable
-		if cmd.Location.Rank == rank {
+		if cmd.Location.Rank == rank && isRankCommand(kind) {
+			timingTable = timing.SameRank
+		} else if cmd.Location.Rank == rank {
 			if cmd.Location.BankGroup == bankGroup {
 				if cmd.Location.Bank == bank {
 					timingTable = timing.SameBank
@@ -10,12 +10,14 @@ type bankTickMW struct {
 	comp      *modeling.Component[Spec, State, Resources]
 	timing    dramTiming
 	cmdCycles map[commandKind]int
+	energy    *energyModel
 	ctrl      *controller
 }
 
-// Tick advances per-bank timing, issues a command, and refills the command
-// queue. Refresh runs in a separate middleware ahead of this one and stalls
-// issue via State.RefreshInProgress. Paused DRAM freezes the timing pipeline;
+// Tick advances per-bank timing, issues up to one command per channel's
+// command bus, and refills the command queue. Refresh runs in a separate
+// middleware ahead of this one; it holds off the banks it refreshes and may
+// take a command bus for the cycle. Paused DRAM freezes the timing pipeline;
 // draining DRAM continues so the drain can converge.
 func (m *bankTickMW) Tick() bool {
 	next := &m.comp.State
@@ -33,19 +35,15 @@ func (m *bankTickMW) Tick() bool {
 	progress := len(completed) > 0
 	progress = tickBanks(next) || progress
 
-	// Only issue new commands when refresh (a separate middleware) is not
-	// holding the stall flag. While refresh holds the stall, remember that the
-	// issue step was blocked — but only when commands were actually queued, so
-	// the first command afterward can be charged a refresh milestone. Latching
-	// unconditionally would charge a refresh wait to an unrelated later command
-	// on cycles where nothing was waiting (e.g. the command queue is empty while
-	// pending completions keep the DRAM ticking). The queue length is a
-	// side-effect-free signal; the scheduler's Pick mutates state, so it must
-	// not be called speculatively here.
-	if !next.RefreshInProgress {
-		progress = m.issue(&spec, next) || progress
-	} else if len(next.CommandQueues.Entries) > 0 {
-		next.RefreshBlockedIssue = true
+	// Refresh (a separate middleware) runs first and may have used a command
+	// bus this cycle; the scheduler only sees commands whose bus is free, and
+	// each issue takes its bus.
+	for range spec.NumChannel {
+		if !m.issue(&spec, next) {
+			break
+		}
+
+		progress = true
 	}
 
 	progress = m.ctrl.fillCommandQueue(&spec, next) || progress
@@ -71,27 +69,46 @@ func (m *bankTickMW) issue(spec *Spec, next *State) bool {
 		return false
 	}
 
+	countChannelRowBuffer(next, cmd.Location, bankStateKind(bs.State) == bankStateOpen &&
+		bs.OpenRow == cmd.Location.Row)
 	startCommand(m.cmdCycles, next, bs, cmd)
 	updateTiming(m.timing, next, cmd)
+	chargeCommand(m.energy, next, cmd)
+	useCommandBus(next, int(cmd.Location.Channel))
 	m.traceCmdIssue(next, cmd)
 
-	// This command is the first to issue after a refresh stall window: charge
-	// its sub-transaction the refresh wait, then clear the flag so later
-	// commands in the same window are not double-charged.
-	if next.RefreshBlockedIssue {
+	// This command is the first to issue to its refresh target after it held
+	// off queued commands: charge its sub-transaction the refresh wait, then
+	// clear the flag so later commands are not double-charged.
+	if t := findRefreshTarget(spec, next, cmd.Location); t != nil && t.Stalled {
 		m.traceRefreshStall(next, cmd)
-		next.RefreshBlockedIssue = false
+		t.Stalled = false
 	}
 
 	return true
 }
 
+// countChannelRowBuffer counts a scheduled command as a row-buffer hit or
+// miss of its channel. Like the scheduler, it counts a command to the open row
+// as a hit and any other as a miss.
+func countChannelRowBuffer(next *State, loc location, hit bool) {
+	ch := int(loc.Channel)
+	if ch >= len(next.Channels) {
+		return
+	}
+
+	if hit {
+		next.Channels[ch].RowBufferHits++
+	} else {
+		next.Channels[ch].RowBufferMisses++
+	}
+}
+
 // traceRefreshStall records a refresh stall as a hardware_resource milestone on
-// the command's sub-transaction trace task. The fake global tRFC stall
-// (deviation D2) holds the issue step for tRFC cycles without issuing real
-// refresh commands, so without this the refresh window would be invisible in
-// the trace; attributing it to the first command that issues afterward charges
-// the wait to the sub-transaction that was actually held off.
+// the command's sub-transaction trace task. Refresh commands belong to no
+// sub-transaction, so without this the refresh window would be invisible in
+// the trace; attributing it to the first command that issues to the refreshed
+// banks afterward charges the wait to a sub-transaction that was held off.
 func (m *bankTickMW) traceRefreshStall(next *State, cmd *commandState) {
 	if m.comp.NumHooks() == 0 {
 		return
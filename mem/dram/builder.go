@@ -1,11 +1,14 @@
 package dram
 
 import (
+	"fmt"
+
 	"github.com/sarchlab/akita/v5/mem"
 	"github.com/sarchlab/akita/v5/mem/memcontrolprotocol"
 	"github.com/sarchlab/akita/v5/mem/memprotocol"
 	"github.com/sarchlab/akita/v5/modeling"
 
+	"github.com/sarchlab/akita/v5/stats"
 	"github.com/sarchlab/akita/v5/timing"
 	"github.com/sarchlab/akita/v5/tracing"
 )
@@ -36,6 +39,19 @@ var defaultSpec = Spec{
 	TRFCb:                1950,
 	TCKESR:               5,
 	TXS:                  216,
+	TXP:                  5,
+	TCKE:                 4,
+	VDD:                  1.5,
+	IDD0:                 75,
+	IDD2N:                32,
+	IDD2P:                12,
+	IDD3N:                45,
+	IDD3P:                38,
+	IDD4R:                157,
+	IDD4W:                165,
+	IDD5AB:               235,
+	IDD5PB:               0,
+	IDD6:                 12,
 	BusWidth:             64,
 	BurstLength:          8,
 	DeviceWidth:          16,
@@ -98,7 +114,7 @@ func (b Builder) WithResources(r Resources) Builder {
 // Strategy and behavior selection is by configuration, not by injecting
 // objects: the scheduler and address mapper are chosen by the Spec.Scheduler /
 // Spec.AddrMapper registry keys, the row policy by Spec.PagePolicy, and refresh
-// is a middleware added by Build. New strategies/behaviors are added in-tree and
+// is a middleware added by Build, configured by Spec.RefreshPolicy. New strategies/behaviors are added in-tree and
 // registered — the model the reference simulators use. Command observers attach
 // to the built component with AcceptHook (see hook.go).
 
@@ -120,11 +136,17 @@ func (b Builder) Build(name string) *Comp {
 			Entries: []subTransRef{},
 		},
 		CommandQueues: commandQueueState{
-			NumQueues: b.spec.NumChannel * b.spec.NumRank,
+			NumQueues: numRanks(&b.spec),
 			Entries:   []queueEntry{},
 		},
-		BankStates: initBankStatesFlat(
-			b.spec.NumRank, b.spec.NumBankGroup, b.spec.NumBank),
+		BankStates:     initBankStates(&b.spec),
+		Refresh:        initRefreshState(&b.spec),
+		LowPower:       make([]rankLowPower, numRanks(&b.spec)),
+		RowHammer:      initRowHammerState(&b.spec),
+		Energy:         initEnergyState(&b.spec),
+		Thermal:        initThermalState(&b.spec, 0),
+		CommandBusBusy: make([]bool, b.spec.NumChannel),
+		Channels:       make([]channelStats, b.spec.NumChannel),
 	}
 
 	storage := b.resolveStorage(name)
@@ -140,20 +162,30 @@ func (b Builder) Build(name string) *Comp {
 	modelComp.DeclarePort("Top", memprotocol.Responder)
 	modelComp.DeclarePort("Control", memcontrolprotocol.Responder)
 
-	b.addMiddlewares(modelComp, timing, cmdCycles, b.buildController())
+	energy := newEnergyModel(&spec)
+
+	b.addMiddlewares(modelComp, timing, cmdCycles, &energy, b.buildController())
 
 	for _, tracer := range b.tracers {
 		tracing.CollectTrace(modelComp, tracer)
 	}
 
 	b.registrar.RegisterComponent(modelComp)
+	registerStats(stats.Of(b.registrar), modelComp, &energy)
 
 	return modelComp
 }
 
 // normalizeSpec computes the derived timing fields from the configured spec.
 func (b *Builder) normalizeSpec() {
-	b.channelCountMustBeOne()
+	b.channelsMustBeValid()
+	b.refreshMustBeFeasible()
+	b.powerMustBeValid()
+	b.addrMapperMustBeValid()
+	b.schedulerMustBeValid()
+	b.lowPowerMustBeValid()
+	b.rowHammerMustBeValid()
+	b.thermalMustBeValid()
 	b.calculateBurstCycle()
 	b.spec.TRL = b.spec.TAL + b.spec.TCL
 	b.spec.TWL = b.spec.TAL + b.spec.TCWL
@@ -170,7 +202,7 @@ func (b Builder) resolveStorage(name string) *mem.Storage {
 	devicePerRank := b.spec.BusWidth / b.spec.DeviceWidth
 	bankSize := b.spec.NumCol * b.spec.NumRow * b.spec.DeviceWidth / 8
 	rankSize := bankSize * b.spec.NumBank * devicePerRank
-	totalSize := rankSize * b.spec.NumRank * b.spec.NumChannel
+	totalSize := rankSize * numRanks(&b.spec)
 
 	return mem.MakeStorageBuilder().
 		WithCapacity(uint64(totalSize)).
@@ -180,9 +212,10 @@ func (b Builder) resolveStorage(name string) *mem.Storage {
 
 func (b Builder) addMiddlewares(
 	modelComp *modeling.Component[Spec, State, Resources],
-	timing dramTiming, cmdCycles map[commandKind]int, ctrl *controller,
+	timing dramTiming, cmdCycles map[commandKind]int,
+	energy *energyModel, ctrl *controller,
 ) {
-	cMW := &ctrlMiddleware{comp: modelComp}
+	cMW := &ctrlMiddleware{comp: modelComp, energy: energy}
 	modelComp.AddMiddleware(cMW)
 
 	rMW := &respondMW{
@@ -190,14 +223,59 @@ func (b Builder) addMiddlewares(
 	}
 	modelComp.AddMiddleware(rMW)
 
-	// Refresh runs ahead of the bank-tick middleware so its stall flag
-	// (State.RefreshInProgress) is set before the issue step reads it.
-	modelComp.AddMiddleware(&refreshMiddleware{comp: modelComp})
+	// The thermal epochs end ahead of the power accounting, which they
+	// extrapolate to the epochs' ends, and ahead of refresh, whose interval
+	// follows the temperature.
+	modelComp.AddMiddleware(&thermalMiddleware{
+		comp:  modelComp,
+		model: energy,
+	})
+
+	// Background energy is accounted ahead of the middlewares that issue
+	// commands, in the power states the ranks were in since the last tick.
+	modelComp.AddMiddleware(&powerMiddleware{
+		comp:  modelComp,
+		model: *energy,
+	})
+
+	// Refresh runs ahead of the bank-tick middleware so the banks it holds
+	// for refresh, and its use of the command bus, are set before the issue
+	// step reads them.
+	modelComp.AddMiddleware(&refreshMiddleware{
+		comp:      modelComp,
+		timing:    timing,
+		cmdCycles: cmdCycles,
+		energy:    energy,
+	})
+
+	// Row-hammer mitigation runs after refresh, whose refreshes it observes,
+	// and ahead of the low-power and bank-tick middlewares, so the banks it
+	// holds are set before they read them.
+	if rowHammerEnabled(&b.spec) {
+		modelComp.AddMiddleware(&rowHammerMiddleware{
+			comp:       modelComp,
+			timing:     timing,
+			cmdCycles:  cmdCycles,
+			energy:     energy,
+			mitigation: newRowHammerMitigation(b.spec.RowHammerMitigation),
+		})
+	}
+
+	// Low-power entry and exit run after refresh, whose pending refreshes wake
+	// the ranks, and ahead of the bank-tick middleware, whose commands wait for
+	// the ranks to wake.
+	modelComp.AddMiddleware(&lowPowerMiddleware{
+		comp:      modelComp,
+		timing:    timing,
+		cmdCycles: cmdCycles,
+		energy:    energy,
+	})
 
 	btMW := &bankTickMW{
 		comp:      modelComp,
 		timing:    timing,
 		cmdCycles: cmdCycles,
+		energy:    energy,
 		ctrl:      ctrl,
 	}
 	modelComp.AddMiddleware(btMW)
@@ -240,6 +318,11 @@ func (b Builder) buildCmdCycles() map[commandKind]int {
 		cmdKindRefresh:        1,
 		cmdKindSRefEnter:      1,
 		cmdKindSRefExit:       1,
+		cmdKindPDEnter:        1,
+		cmdKindPDExit:         1,
+		cmdKindVRR:            1,
+		cmdKindRFMBank:        1,
+		cmdKindRFM:            1,
 	}
 
 	if proto.isGDDR() || proto.isHBM() {
@@ -256,7 +339,7 @@ func (b Builder) buildController() *controller {
 	return &controller{
 		scheduler:  newScheduler(b.spec.Scheduler),
 		rowPolicy:  b.resolveRowPolicy(),
-		addrMapper: newAddrMapper(b.spec.AddrMapper),
+		addrMapper: newAddrMapper(&b.spec),
 	}
 }
 
@@ -276,6 +359,8 @@ func newDefaultController(spec *Spec) *controller {
 func (b Builder) applyAddrMapping(spec *Spec, m addrMappingResult) {
 	spec.ChannelPos = m.channelPos
 	spec.ChannelMask = m.channelMask
+	spec.PseudoChannelPos = m.pseudoChannelPos
+	spec.PseudoChannelMask = m.pseudoChannelMask
 	spec.RankPos = m.rankPos
 	spec.RankMask = m.rankMask
 	spec.BankGroupPos = m.bankGroupPos
@@ -289,98 +374,117 @@ func (b Builder) applyAddrMapping(spec *Spec, m addrMappingResult) {
 }
 
 type addrMappingResult struct {
-	channelPos    int
-	channelMask   uint64
-	rankPos       int
-	rankMask      uint64
-	bankGroupPos  int
-	bankGroupMask uint64
-	bankPos       int
-	bankMask      uint64
-	rowPos        int
-	rowMask       uint64
-	colPos        int
-	colMask       uint64
+	channelPos        int
+	channelMask       uint64
+	pseudoChannelPos  int
+	pseudoChannelMask uint64
+	rankPos           int
+	rankMask          uint64
+	bankGroupPos      int
+	bankGroupMask     uint64
+	bankPos           int
+	bankMask          uint64
+	rowPos            int
+	rowMask           uint64
+	colPos            int
+	colMask           uint64
 }
 
 func (b Builder) buildAddressMapping() addrMappingResult {
+	w := b.addrBitWidths()
+
+	r := addrMappingResult{
+		channelMask:       (1 << w.channel) - 1,
+		pseudoChannelMask: (1 << w.pseudoChannel) - 1,
+		rankMask:          (1 << w.rank) - 1,
+		bankGroupMask:     (1 << w.bankGroup) - 1,
+		bankMask:          (1 << w.bank) - 1,
+		rowMask:           (1 << w.row) - 1,
+		colMask:           (1 << w.colHi) - 1,
+	}
+
+	// The spec was validated by addrMapperMustBeValid.
+	m, _ := parseAddrMapping(b.spec.AddrMapper)
+	accessUnitBit, _ := log2(uint64(b.spec.BusWidth / 8 * b.spec.BurstLength))
+
+	r.assignBitPositions(accessUnitBit, w, m.order)
+
+	return r
+}
+
+// addrBitWidths returns the number of address bits of each location field.
+func (b Builder) addrBitWidths() addrBitWidths {
 	channelBit, _ := log2(uint64(b.spec.NumChannel))
+	pseudoChannelBit, _ := log2(uint64(max(b.spec.NumPseudoChannel, 1)))
 	rankBit, _ := log2(uint64(b.spec.NumRank))
 	bankGroupBit, _ := log2(uint64(b.spec.NumBankGroup))
 	bankBit, _ := log2(uint64(b.spec.NumBank))
 	rowBit, _ := log2(uint64(b.spec.NumRow))
 	colBit, _ := log2(uint64(b.spec.NumCol))
 	colLoBit, _ := log2(uint64(b.spec.BurstLength))
-	colHiBit := colBit - colLoBit
-	accessUnitBit, _ := log2(uint64(b.spec.BusWidth / 8 * b.spec.BurstLength))
-
-	r := addrMappingResult{
-		channelMask:   (1 << channelBit) - 1,
-		rankMask:      (1 << rankBit) - 1,
-		bankGroupMask: (1 << bankGroupBit) - 1,
-		bankMask:      (1 << bankBit) - 1,
-		rowMask:       (1 << rowBit) - 1,
-		colMask:       (1 << colHiBit) - 1,
-	}
 
-	bitWidths := addrBitWidths{
-		channel:   channelBit,
-		rank:      rankBit,
-		bankGroup: bankGroupBit,
-		bank:      bankBit,
-		row:       rowBit,
-		colHi:     colHiBit,
+	return addrBitWidths{
+		channel:       channelBit,
+		pseudoChannel: pseudoChannelBit,
+		rank:          rankBit,
+		bankGroup:     bankGroupBit,
+		bank:          bankBit,
+		row:           rowBit,
+		colHi:         colBit - colLoBit,
 	}
-
-	r.assignBitPositions(accessUnitBit, bitWidths)
-
-	return r
 }
 
 type addrBitWidths struct {
-	channel, rank, bankGroup, bank, row, colHi uint64
+	channel, pseudoChannel, rank, bankGroup, bank, row, colHi uint64
 }
 
-func (r *addrMappingResult) assignBitPositions(
-	startPos uint64, w addrBitWidths,
-) {
-	// Default bit order high→low: Row, Channel, Rank, Bank, BankGroup, Column
-	type locItem int
-	const (
-		liChannel locItem = iota
-		liRank
-		liBankGroup
-		liBank
-		liRow
-		liColumn
-	)
-
-	bitOrder := []locItem{
-		liRow, liChannel, liRank, liBank, liBankGroup, liColumn,
+// of returns the width of a field.
+func (w addrBitWidths) of(f addrField) uint64 {
+	switch f {
+	case addrFieldChannel:
+		return w.channel
+	case addrFieldPseudoChannel:
+		return w.pseudoChannel
+	case addrFieldRank:
+		return w.rank
+	case addrFieldBankGroup:
+		return w.bankGroup
+	case addrFieldBank:
+		return w.bank
+	case addrFieldRow:
+		return w.row
+	default:
+		return w.colHi
 	}
+}
 
+// assignBitPositions lays the fields out contiguously from startPos, in the
+// given order (highest bits first). A field left out of the order has no bits.
+func (r *addrMappingResult) assignBitPositions(
+	startPos uint64, w addrBitWidths, order []addrField,
+) {
 	pos := startPos
-	for i := len(bitOrder) - 1; i >= 0; i-- {
-		switch bitOrder[i] {
-		case liChannel:
+	for i := len(order) - 1; i >= 0; i-- {
+		f := order[i]
+
+		switch f {
+		case addrFieldChannel:
 			r.channelPos = int(pos)
-			pos += w.channel
-		case liRank:
+		case addrFieldPseudoChannel:
+			r.pseudoChannelPos = int(pos)
+		case addrFieldRank:
 			r.rankPos = int(pos)
-			pos += w.rank
-		case liBankGroup:
+		case addrFieldBankGroup:
 			r.bankGroupPos = int(pos)
-			pos += w.bankGroup
-		case liBank:
+		case addrFieldBank:
 			r.bankPos = int(pos)
-			pos += w.bank
-		case liRow:
+		case addrFieldRow:
 			r.rowPos = int(pos)
-			pos += w.row
-		case liColumn:
+		case addrFieldColumn:
 			r.colPos = int(pos)
-			pos += w.colHi
 		}
+
+		pos += w.of(f)
 	}
 }
 
@@ -433,12 +537,19 @@ func (b *Builder) generateTiming() dramTiming {
 
 	activateToRefresh := s.TRC
 
-	refreshToRefresh := s.TREFI
 	refreshToActivate := s.TRFC
 	refreshToActivateBank := s.TRFCb
+	refreshBankToOtherBank := s.TRREFD
+	if refreshBankToOtherBank == 0 {
+		refreshBankToOtherBank = s.TRRDS
+	}
 
 	selfRefreshEntryToExit := s.TCKESR
 	selfRefreshExit := s.TXS
+	powerDownEntryToExit := s.TCKE
+	powerDownExit := s.TXP
+	readToPowerDown := s.ReadDelay + 1
+	writeToPowerDown := writeToPrecharge
 
 	if s.NumBankGroup == 1 {
 		readToReadL = max(s.BurstCycle, s.TCCDS)
@@ -586,22 +697,28 @@ func (b *Builder) generateTiming() dramTiming {
 		}
 	}
 
-	// REFRESH_BANK
-	t.SameRank[cmdKindRefreshBank] = []timeTableEntry{
-		{NextCmdKind: cmdKindActivate, MinCycleInBetween: max(refreshToActivateBank, refreshToActivate)},
+	// REFRESH_BANK: the refreshed bank is busy for tRFCb; the other banks of
+	// the rank may activate or refresh tRREFD later, but an all-bank refresh
+	// waits for the bank refresh to finish.
+	t.SameBank[cmdKindRefreshBank] = []timeTableEntry{
+		{NextCmdKind: cmdKindActivate, MinCycleInBetween: refreshToActivateBank},
 		{NextCmdKind: cmdKindRefresh, MinCycleInBetween: refreshToActivateBank},
-		{NextCmdKind: cmdKindRefreshBank, MinCycleInBetween: max(refreshToActivateBank, refreshToRefresh)},
+		{NextCmdKind: cmdKindRefreshBank, MinCycleInBetween: refreshToActivateBank},
 		{NextCmdKind: cmdKindSRefEnter, MinCycleInBetween: refreshToActivateBank},
 	}
 	t.OtherBanksInBankGroup[cmdKindRefreshBank] = []timeTableEntry{
-		{NextCmdKind: cmdKindActivate, MinCycleInBetween: refreshToActivate},
-		{NextCmdKind: cmdKindRefreshBank, MinCycleInBetween: refreshToRefresh},
+		{NextCmdKind: cmdKindActivate, MinCycleInBetween: refreshBankToOtherBank},
+		{NextCmdKind: cmdKindRefresh, MinCycleInBetween: refreshToActivateBank},
+		{NextCmdKind: cmdKindRefreshBank, MinCycleInBetween: refreshBankToOtherBank},
+		{NextCmdKind: cmdKindSRefEnter, MinCycleInBetween: refreshToActivateBank},
 	}
+	t.SameRank[cmdKindRefreshBank] = t.OtherBanksInBankGroup[cmdKindRefreshBank]
 
-	// REFRESH
+	// REFRESH: a rank-level command, applied to every bank of the rank.
 	t.SameRank[cmdKindRefresh] = []timeTableEntry{
 		{NextCmdKind: cmdKindActivate, MinCycleInBetween: refreshToActivate},
 		{NextCmdKind: cmdKindRefresh, MinCycleInBetween: refreshToActivate},
+		{NextCmdKind: cmdKindRefreshBank, MinCycleInBetween: refreshToActivate},
 		{NextCmdKind: cmdKindSRefEnter, MinCycleInBetween: refreshToActivate},
 	}
 
@@ -616,11 +733,93 @@ func (b *Builder) generateTiming() dramTiming {
 		{NextCmdKind: cmdKindRefresh, MinCycleInBetween: selfRefreshExit},
 		{NextCmdKind: cmdKindRefreshBank, MinCycleInBetween: selfRefreshExit},
 		{NextCmdKind: cmdKindSRefEnter, MinCycleInBetween: selfRefreshExit},
+		{NextCmdKind: cmdKindPDEnter, MinCycleInBetween: selfRefreshExit},
 	}
 
+	// Power-down entry waits for the rank's reads and writes to finish
+	// (tRDPDEN, tWRPDEN) and for its refreshes to complete.
+	for _, table := range []timeTable{t.SameBank, t.OtherBanksInBankGroup, t.SameRank} {
+		for _, kind := range []commandKind{cmdKindRead, cmdKindReadPrecharge} {
+			table[kind] = append(table[kind], timeTableEntry{
+				NextCmdKind: cmdKindPDEnter, MinCycleInBetween: readToPowerDown})
+		}
+
+		for _, kind := range []commandKind{cmdKindWrite, cmdKindWritePrecharge} {
+			table[kind] = append(table[kind], timeTableEntry{
+				NextCmdKind: cmdKindPDEnter, MinCycleInBetween: writeToPowerDown})
+		}
+	}
+
+	t.SameBank[cmdKindRefreshBank] = append(t.SameBank[cmdKindRefreshBank],
+		timeTableEntry{NextCmdKind: cmdKindPDEnter, MinCycleInBetween: refreshToActivateBank})
+	t.SameRank[cmdKindRefresh] = append(t.SameRank[cmdKindRefresh],
+		timeTableEntry{NextCmdKind: cmdKindPDEnter, MinCycleInBetween: refreshToActivate})
+
+	// PD_ENTER: the rank stays powered down for at least tCKE.
+	t.SameRank[cmdKindPDEnter] = []timeTableEntry{
+		{NextCmdKind: cmdKindPDExit, MinCycleInBetween: powerDownEntryToExit},
+	}
+
+	// PD_EXIT: every command waits tXP.
+	t.SameRank[cmdKindPDExit] = []timeTableEntry{}
+	for kind := range cmdKindPDExit {
+		if kind == cmdKindSRefExit {
+			continue
+		}
+
+		t.SameRank[cmdKindPDExit] = append(t.SameRank[cmdKindPDExit],
+			timeTableEntry{NextCmdKind: kind, MinCycleInBetween: powerDownExit})
+	}
+
+	b.addMitigationTiming(t)
+
 	return t
 }
 
+// addMitigationTiming adds the row-hammer mitigation commands to a timing
+// table. A victim-row refresh (VRR) or bank RFM waits for its bank as a
+// per-bank refresh does, and an all-bank RFM waits for the rank as an all-bank
+// refresh does. They hold their banks for tVRR and tRFM.
+func (b *Builder) addMitigationTiming(t dramTiming) {
+	s := &b.spec
+
+	for _, table := range []timeTable{
+		t.SameBank, t.OtherBanksInBankGroup, t.SameRank, t.OtherRanks,
+	} {
+		for kind := range table {
+			n := len(table[kind])
+			for _, te := range table[kind][:n:n] {
+				switch te.NextCmdKind {
+				case cmdKindRefreshBank:
+					table[kind] = append(table[kind],
+						timeTableEntry{NextCmdKind: cmdKindVRR, MinCycleInBetween: te.MinCycleInBetween},
+						timeTableEntry{NextCmdKind: cmdKindRFMBank, MinCycleInBetween: te.MinCycleInBetween})
+				case cmdKindRefresh:
+					table[kind] = append(table[kind],
+						timeTableEntry{NextCmdKind: cmdKindRFM, MinCycleInBetween: te.MinCycleInBetween})
+				}
+			}
+		}
+	}
+
+	// after returns the entries that keep a bank busy for the given cycles.
+	after := func(cycles int) []timeTableEntry {
+		entries := []timeTableEntry{}
+		for _, kind := range []commandKind{
+			cmdKindActivate, cmdKindRefresh, cmdKindRefreshBank, cmdKindSRefEnter,
+			cmdKindPDEnter, cmdKindVRR, cmdKindRFMBank, cmdKindRFM,
+		} {
+			entries = append(entries, timeTableEntry{NextCmdKind: kind, MinCycleInBetween: cycles})
+		}
+
+		return entries
+	}
+
+	t.SameBank[cmdKindVRR] = after(s.TVRR)
+	t.SameBank[cmdKindRFMBank] = after(s.TRFM)
+	t.SameRank[cmdKindRFM] = after(s.TRFM)
+}
+
 func (b *Builder) calculateBurstCycle() {
 	b.burstLengthMustNotBeZero()
 
@@ -642,15 +841,52 @@ func (b *Builder) burstLengthMustNotBeZero() {
 	}
 }
 
-// channelCountMustBeOne enforces the one-component-per-channel model. The
-// address decode produces a Channel field that the single-channel controller
-// does not act on, so NumChannel > 1 would silently alias all channels onto the
-// same banks. Multi-channel is a first-class feature deferred to a later phase;
-// until then, instantiate one dram.Comp per channel.
-func (b *Builder) channelCountMustBeOne() {
-	if b.spec.NumChannel > 1 {
-		panic("dram: NumChannel > 1 is not supported; " +
-			"instantiate one dram.Comp per channel")
+// refreshMustBeFeasible rejects refresh configurations the schedule cannot
+// keep up with: a refresh that takes longer than its interval, per-bank refresh
+// without tRFCb, and postpone or pull-in limits beyond the JEDEC maximum.
+func (b *Builder) refreshMustBeFeasible() {
+	s := &b.spec
+	if s.TREFI <= 0 {
+		return
+	}
+
+	if s.RefreshMaxPostpone < 0 || s.RefreshMaxPostpone > maxRefreshDebit ||
+		s.RefreshMaxPullIn < 0 || s.RefreshMaxPullIn > maxRefreshDebit {
+		panic(fmt.Sprintf("dram: refresh postpone and pull-in limits "+
+			"must be between 0 and %d", maxRefreshDebit))
+	}
+
+	switch s.RefreshPolicy {
+	case RefreshPolicyRankStaggered, RefreshPolicyRankSimultaneous:
+		if s.TRFC >= s.TREFI {
+			panic("dram: TRFC must be shorter than TREFI")
+		}
+	case RefreshPolicyBankStaggered, RefreshPolicySameBank:
+		if s.TRFCb <= 0 || s.TRFCb >= s.TREFI {
+			panic("dram: per-bank refresh needs a TRFCb shorter than TREFI")
+		}
+	default:
+		panic(fmt.Sprintf("dram: unknown refresh policy %d", s.RefreshPolicy))
+	}
+}
+
+// channelsMustBeValid rejects channel and pseudo-channel counts the address
+// decode cannot select with whole bits, which would alias locations.
+func (b *Builder) channelsMustBeValid() {
+	if b.spec.NumChannel < 1 {
+		panic("dram: NumChannel must be at least 1")
+	}
+
+	if _, ok := log2(uint64(b.spec.NumChannel)); !ok {
+		panic("dram: NumChannel must be a power of 2")
+	}
+
+	if b.spec.NumPseudoChannel < 0 {
+		panic("dram: NumPseudoChannel must not be negative")
+	}
+
+	if _, ok := log2(uint64(max(b.spec.NumPseudoChannel, 1))); !ok {
+		panic("dram: NumPseudoChannel must be a power of 2")
 	}
 }
 
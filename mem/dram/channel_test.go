@@ -0,0 +1,169 @@
+package dram
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/akita/v5/mem/memprotocol"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/stats"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+var _ = Describe("Channels", func() {
+	// normalized returns a DDR4 spec with two channels of two pseudo-channels
+	// each, tweaked before it is built.
+	normalized := func(tweak func(s *Spec)) Spec {
+		s := DDR4Spec
+		s.NumChannel = 2
+		s.NumPseudoChannel = 2
+		s.NumRow = 1024
+		if tweak != nil {
+			tweak(&s)
+		}
+
+		b := MakeBuilder().WithSpec(s)
+		b.normalizeSpec()
+
+		return b.buildSpec()
+	}
+
+	It("should decode the channel and pseudo-channel bits", func() {
+		spec := normalized(func(s *Spec) { s.AddrMapper = "RoRaBaBgCoPcCh" })
+
+		Expect(spec.ChannelPos).To(Equal(6))
+		Expect(spec.PseudoChannelPos).To(Equal(7))
+
+		l := mapAddress(&spec, 3<<6)
+		Expect(l.Channel).To(Equal(uint64(1)))
+		Expect(l.PseudoChannel).To(Equal(uint64(1)))
+	})
+
+	It("should reject mappings that leave out the pseudo-channel", func() {
+		Expect(func() {
+			normalized(func(s *Spec) { s.AddrMapper = "RoRaBaBgCoCh" })
+		}).To(Panic())
+	})
+
+	It("should reject channel counts that are not a power of 2", func() {
+		Expect(func() {
+			normalized(func(s *Spec) { s.NumPseudoChannel = 3 })
+		}).To(Panic())
+	})
+
+	It("should number the ranks over channels and pseudo-channels", func() {
+		spec := normalized(nil)
+		flat := initBankStates(&spec)
+
+		Expect(flat.Entries).To(HaveLen(2 * 2 * 1 * 4 * 4))
+		Expect(numRankIndices(&flat)).To(Equal(4))
+
+		loc := location{Channel: 1, PseudoChannel: 0, BankGroup: 2, Bank: 3}
+		Expect(rankIndex(&flat, loc)).To(Equal(2))
+		Expect(rankChannel(&flat, 2)).To(Equal(1))
+		Expect(bankLocation(&flat.Entries[bankIndex(&flat, loc)])).To(Equal(loc))
+	})
+
+	It("should keep the timing of each pseudo-channel separate", func() {
+		spec := normalized(nil)
+		b := MakeBuilder().WithSpec(spec)
+		timing := b.generateTiming()
+		state := &State{BankStates: initBankStates(&spec)}
+
+		updateTiming(timing, state, &commandState{
+			Kind:     int(cmdKindActivate),
+			Location: location{Channel: 0, PseudoChannel: 0},
+		})
+
+		sameRank := findBankStateByLocation(&state.BankStates, location{Bank: 1})
+		otherPC := findBankStateByLocation(&state.BankStates, location{PseudoChannel: 1})
+		otherCh := findBankStateByLocation(&state.BankStates, location{Channel: 1})
+
+		Expect(sameRank.CyclesToCmdAvailable[cmdKindActivate]).To(BeNumerically(">", 0))
+		Expect(otherPC.CyclesToCmdAvailable).To(Equal([numCmdKind]int{}))
+		Expect(otherCh.CyclesToCmdAvailable).To(Equal([numCmdKind]int{}))
+	})
+
+	It("should issue one command per channel's command bus", func() {
+		spec := normalized(nil)
+		state := &State{BankStates: initBankStates(&spec)}
+
+		useCommandBus(state, 0)
+
+		for _, loc := range []location{{}, {PseudoChannel: 1}, {Channel: 1}} {
+			bs := findBankStateByLocation(&state.BankStates, loc)
+			cmd := &commandState{Kind: int(cmdKindRead), Location: loc}
+
+			ready := getReadyCommand(&spec, state, bs, cmd)
+			if loc.Channel == 0 {
+				Expect(ready).To(BeNil(), "%+v", loc)
+			} else {
+				Expect(ready).NotTo(BeNil(), "%+v", loc)
+			}
+		}
+	})
+
+	It("should serve every channel in one component", func() {
+		engine := timing.NewSerialEngine()
+		registry := stats.NewRegistry(stats.DefaultName)
+		reg := powerStatsRegistrar{
+			Registrar: modeling.NewStandaloneRegistrar(engine),
+			registry:  registry,
+		}
+		conn := directconnection.MakeBuilder().
+			WithRegistrar(reg).
+			Build("ChannelConn")
+
+		spec := DefaultSpec()
+		spec.NumChannel = 4
+		spec.AddrMapper = "RoRaBaBgCoCh"
+		comp := MakeBuilder().
+			WithRegistrar(reg).
+			WithSpec(spec).
+			Build("ChannelDRAM")
+
+		for _, name := range []string{"Top", "Control"} {
+			p := modeling.MakePortBuilder().
+				WithRegistrar(reg).
+				WithComponent(comp).
+				WithSpec(modeling.PortSpec{BufSize: 1024}).
+				Build(name)
+			comp.AssignPort(name, p)
+		}
+
+		top := comp.GetPortByName("Top")
+		src := messaging.NewPort(nil, 1024, 1024, "Src.Top")
+		conn.PlugIn(top)
+		conn.PlugIn(src)
+
+		unit := uint64(1) << comp.Spec().Log2AccessUnitSize
+		for ch := range uint64(4) {
+			read := memprotocol.ReadReq{}
+			read.ID = timing.GetIDGenerator().Generate()
+			read.Address = ch * unit
+			read.AccessByteSize = 4
+			read.Src = src.AsRemote()
+			read.Dst = top.AsRemote()
+			read.TrafficBytes = 12
+			src.Send(read)
+		}
+
+		engine.Run()
+
+		Expect(comp.State.CompletedReads).To(Equal(uint64(4)))
+
+		for ch := range 4 {
+			Expect(comp.State.Channels[ch].ReadCommands).To(Equal(uint64(1)))
+			Expect(comp.State.Channels[ch].Activates).To(Equal(uint64(1)))
+
+			reads, found := registry.Lookup(
+				fmt.Sprintf("ChannelDRAM.Channel[%d].ReadCommands", ch))
+			Expect(found).To(BeTrue())
+			Expect(reads.(*stats.Counter).Value()).To(Equal(uint64(1)))
+		}
+	})
+})
@@ -48,6 +48,46 @@ const (
 	PagePolicyOpen  PagePolicy = 1
 )
 
+// RefreshPolicy selects how the controller spreads refresh commands over the
+// ranks and banks.
+type RefreshPolicy int
+
+// A list of supported refresh policies.
+const (
+	// RefreshPolicyRankStaggered refreshes one rank at a time with all-bank
+	// refresh (REFab), the ranks' schedules offset by tREFI/NumRank.
+	RefreshPolicyRankStaggered RefreshPolicy = iota
+	// RefreshPolicyRankSimultaneous refreshes every rank at once with
+	// all-bank refresh.
+	RefreshPolicyRankSimultaneous
+	// RefreshPolicyBankStaggered refreshes one bank at a time with per-bank
+	// refresh (REFpb), the banks' schedules spread evenly over tREFI.
+	RefreshPolicyBankStaggered
+	// RefreshPolicySameBank refreshes the same bank index in every bank
+	// group of a rank at once (DDR5 REFsb).
+	RefreshPolicySameBank
+)
+
+// PowerDownPolicy selects how an idle rank powers down.
+type PowerDownPolicy int
+
+// A list of supported power-down policies.
+const (
+	// PowerDownPolicyNone keeps idle ranks in standby.
+	PowerDownPolicyNone PowerDownPolicy = iota
+	// PowerDownPolicyPrecharge precharges an idle rank and enters precharge
+	// power-down (IDD2P).
+	PowerDownPolicyPrecharge
+	// PowerDownPolicyActive powers an idle rank down with its rows left open:
+	// active power-down (IDD3P) while a row is open, precharge power-down
+	// otherwise. The rows are still open when the rank exits.
+	PowerDownPolicyActive
+)
+
+// maxRefreshDebit is the JEDEC limit on the number of refresh commands that
+// may be postponed, or pulled in, at a time.
+const maxRefreshDebit = 8
+
 // Spec contains immutable configuration for the DRAM memory controller.
 type Spec struct {
 	// Frequency
@@ -59,10 +99,61 @@ type Spec struct {
 	// Page policy
 	PagePolicy PagePolicy `json:"page_policy"`
 
+	// Refresh policy and how many refreshes may be postponed while the
+	// refreshed banks are busy, or pulled in while they are idle (0 to 8).
+	RefreshPolicy      RefreshPolicy `json:"refresh_policy"`
+	RefreshMaxPostpone int           `json:"refresh_max_postpone"`
+	RefreshMaxPullIn   int           `json:"refresh_max_pull_in"`
+
+	// Low-power states (see lowpowermw.go). A rank idle for PowerDownTimeout
+	// cycles powers down as PowerDownPolicy selects, and one idle for
+	// SelfRefreshTimeout cycles enters self-refresh. A SelfRefreshTimeout of 0
+	// turns self-refresh off.
+	PowerDownPolicy    PowerDownPolicy `json:"power_down_policy"`
+	PowerDownTimeout   int             `json:"power_down_timeout"`
+	SelfRefreshTimeout int             `json:"self_refresh_timeout"`
+
+	// RowHammer (see rowhammer.go). Row hammer is modeled only if
+	// RowHammerMitigation or RowHammerThreshold is set. The controller then
+	// counts every row's activations until its neighbors are refreshed, every
+	// RowHammerWindow cycles (0 selects 8192 × TREFI) or by a mitigation, and
+	// reports how often a row reached RowHammerThreshold. RowHammerMitigation
+	// selects a mitigation by registry key ("none" to only count). The
+	// mitigations act on
+	// RowHammerMitigationThreshold activations of a row, refresh
+	// RowHammerBlastRadius rows on each side of it, and keep
+	// RowHammerTableSize counters per bank. Zero selects the default.
+	RowHammerMitigation          string  `json:"row_hammer_mitigation"`
+	RowHammerThreshold           int     `json:"row_hammer_threshold"`
+	RowHammerMitigationThreshold int     `json:"row_hammer_mitigation_threshold"`
+	RowHammerBlastRadius         int     `json:"row_hammer_blast_radius"`
+	RowHammerTableSize           int     `json:"row_hammer_table_size"`
+	RowHammerWindow              int     `json:"row_hammer_window"`
+	RowHammerSeed                uint64  `json:"row_hammer_seed"`
+	PARAProbability              float64 `json:"para_probability"`
+	RFMThreshold                 int     `json:"rfm_threshold"`
+	PRACBackOffRFMs              int     `json:"prac_back_off_rfms"`
+	PRACBackOffDelay             int     `json:"prac_back_off_delay"`
+
 	// Strategy selection (registry keys; "" selects the default). The row
-	// policy is selected from PagePolicy. See plugins.go.
-	Scheduler  string `json:"scheduler"`
-	AddrMapper string `json:"addr_mapper"`
+	// policy is selected from PagePolicy. See plugins.go. AddrMapper also
+	// takes bit-string mappings such as "RoRaBaBgCoCh_xor" (see addrmap.go);
+	// AddrMapperSeed seeds its randomized interleaving table.
+	Scheduler      string `json:"scheduler"`
+	AddrMapper     string `json:"addr_mapper"`
+	AddrMapperSeed uint64 `json:"addr_mapper_seed"`
+
+	// Scheduler parameters (see schedulers.go); zero selects the default.
+	// SchedulerSource groups requests into sources by "src" (the requesting
+	// port, the default), "pid", or "traffic_class". SchedulerPriorities gives
+	// the Priority scheduler's priority of each source; higher goes first.
+	SchedulerSource             string         `json:"scheduler_source"`
+	SchedulerPriorities         map[string]int `json:"scheduler_priorities"`
+	SchedulerRowHitCap          int            `json:"scheduler_row_hit_cap"`
+	SchedulerBlacklistThreshold int            `json:"scheduler_blacklist_threshold"`
+	SchedulerClearInterval      int            `json:"scheduler_clear_interval"`
+	SchedulerMarkingCap         int            `json:"scheduler_marking_cap"`
+	SchedulerQuantum            int            `json:"scheduler_quantum"`
 
 	// Timing params
 	TAL        int `json:"t_al"`
@@ -92,22 +183,67 @@ type Spec struct {
 	TREFI      int `json:"t_refi"`
 	TRFC       int `json:"t_rfc"`
 	TRFCb      int `json:"t_rfcb"`
+	TRREFD     int `json:"t_rrefd"`
 	TCKESR     int `json:"t_ckesr"`
 	TXS        int `json:"t_xs"`
+	TXP        int `json:"t_xp"`
+	TCKE       int `json:"t_cke"`
+	TVRR       int `json:"t_vrr"`
+	TRFM       int `json:"t_rfm"`
+	TABOACT    int `json:"t_abo_act"`
 	BurstCycle int `json:"burst_cycle"`
 
+	// Supply voltage (V) and IDD currents (mA) of one device, as in the
+	// datasheet, for the power model (see power.go). IDD5AB is the all-bank
+	// and IDD5PB the per-bank refresh current. A VDD of 0 turns the power
+	// model off.
+	VDD    float64 `json:"vdd"`
+	IDD0   float64 `json:"idd0"`
+	IDD2N  float64 `json:"idd2n"`
+	IDD2P  float64 `json:"idd2p"`
+	IDD3N  float64 `json:"idd3n"`
+	IDD3P  float64 `json:"idd3p"`
+	IDD4R  float64 `json:"idd4r"`
+	IDD4W  float64 `json:"idd4w"`
+	IDD5AB float64 `json:"idd5ab"`
+	IDD5PB float64 `json:"idd5pb"`
+	IDD6   float64 `json:"idd6"`
+
+	// Thermal model (see thermal.go). Every ThermalEpoch cycles (0 turns the
+	// model off), the power of the ranks heats an RC network with a node per
+	// rank, or per stack layer if ThermalLayers > 1, from AmbientTemperature
+	// (°C). A node conducts to the ambient through ThermalResistance (°C/W)
+	// and stores ThermalCapacitance (J/°C); adjacent layers conduct through
+	// LayerThermalResistance. Every ThermalRefreshThresholds temperature a
+	// node exceeds doubles its ranks' refresh rate (nil selects 85°C). The
+	// temperature trace keeps the last ThermalTraceLength epochs. Zero
+	// selects the default.
+	ThermalEpoch             int       `json:"thermal_epoch"`
+	ThermalLayers            int       `json:"thermal_layers"`
+	AmbientTemperature       float64   `json:"ambient_temperature"`
+	ThermalResistance        float64   `json:"thermal_resistance"`
+	ThermalCapacitance       float64   `json:"thermal_capacitance"`
+	LayerThermalResistance   float64   `json:"layer_thermal_resistance"`
+	ThermalRefreshThresholds []float64 `json:"thermal_refresh_thresholds"`
+	ThermalTraceLength       int       `json:"thermal_trace_length"`
+
 	// Bus / burst / device params
 	BusWidth    int `json:"bus_width"`
 	BurstLength int `json:"burst_length"`
 	DeviceWidth int `json:"device_width"`
 
-	// Bank / rank / channel counts
-	NumChannel   int `json:"num_channel"`
-	NumRank      int `json:"num_rank"`
-	NumBankGroup int `json:"num_bank_group"`
-	NumBank      int `json:"num_bank"`
-	NumRow       int `json:"num_row"`
-	NumCol       int `json:"num_col"`
+	// Bank / rank / channel counts. Every channel has its own command bus; the
+	// pseudo-channels of a channel (NumPseudoChannel, 0 means 1) share it, but
+	// each has its own ranks, banks, and data bus. The geometry and bus width
+	// below are per pseudo-channel. DDR5 sub-channels, which have separate
+	// command buses, are channels.
+	NumChannel       int `json:"num_channel"`
+	NumPseudoChannel int `json:"num_pseudo_channel"`
+	NumRank          int `json:"num_rank"`
+	NumBankGroup     int `json:"num_bank_group"`
+	NumBank          int `json:"num_bank"`
+	NumRow           int `json:"num_row"`
+	NumCol           int `json:"num_col"`
 
 	// Queue sizes
 	TransactionQueueSize int `json:"transaction_queue_size"`
@@ -120,18 +256,20 @@ type Spec struct {
 	WriteLowWatermark  int `json:"write_low_watermark"`
 
 	// Address mapping: position/mask pairs
-	ChannelPos    int    `json:"channel_pos"`
-	ChannelMask   uint64 `json:"channel_mask"`
-	RankPos       int    `json:"rank_pos"`
-	RankMask      uint64 `json:"rank_mask"`
-	BankGroupPos  int    `json:"bank_group_pos"`
-	BankGroupMask uint64 `json:"bank_group_mask"`
-	BankPos       int    `json:"bank_pos"`
-	BankMask      uint64 `json:"bank_mask"`
-	RowPos        int    `json:"row_pos"`
-	RowMask       uint64 `json:"row_mask"`
-	ColPos        int    `json:"col_pos"`
-	ColMask       uint64 `json:"col_mask"`
+	ChannelPos        int    `json:"channel_pos"`
+	ChannelMask       uint64 `json:"channel_mask"`
+	PseudoChannelPos  int    `json:"pseudo_channel_pos"`
+	PseudoChannelMask uint64 `json:"pseudo_channel_mask"`
+	RankPos           int    `json:"rank_pos"`
+	RankMask          uint64 `json:"rank_mask"`
+	BankGroupPos      int    `json:"bank_group_pos"`
+	BankGroupMask     uint64 `json:"bank_group_mask"`
+	BankPos           int    `json:"bank_pos"`
+	BankMask          uint64 `json:"bank_mask"`
+	RowPos            int    `json:"row_pos"`
+	RowMask           uint64 `json:"row_mask"`
+	ColPos            int    `json:"col_pos"`
+	ColMask           uint64 `json:"col_mask"`
 
 	// Sub-transaction splitting
 	Log2AccessUnitSize uint64 `json:"log2_access_unit_size"`
@@ -152,6 +290,11 @@ const (
 	cmdKindRefresh
 	cmdKindSRefEnter
 	cmdKindSRefExit
+	cmdKindPDEnter
+	cmdKindPDExit
+	cmdKindVRR
+	cmdKindRFMBank
+	cmdKindRFM
 	numCmdKind
 )
 
@@ -179,6 +322,16 @@ func (k commandKind) String() string {
 		return "SREFE"
 	case cmdKindSRefExit:
 		return "SREFX"
+	case cmdKindPDEnter:
+		return "PDE"
+	case cmdKindPDExit:
+		return "PDX"
+	case cmdKindVRR:
+		return "VRR"
+	case cmdKindRFMBank:
+		return "RFMpb"
+	case cmdKindRFM:
+		return "RFMab"
 	default:
 		return "UNKNOWN"
 	}
@@ -186,12 +339,13 @@ func (k commandKind) String() string {
 
 // location determines where to find the data to access.
 type location struct {
-	Channel   uint64 `json:"channel"`
-	Rank      uint64 `json:"rank"`
-	BankGroup uint64 `json:"bank_group"`
-	Bank      uint64 `json:"bank"`
-	Row       uint64 `json:"row"`
-	Column    uint64 `json:"column"`
+	Channel       uint64 `json:"channel"`
+	PseudoChannel uint64 `json:"pseudo_channel"`
+	Rank          uint64 `json:"rank"`
+	BankGroup     uint64 `json:"bank_group"`
+	Bank          uint64 `json:"bank"`
+	Row           uint64 `json:"row"`
+	Column        uint64 `json:"column"`
 }
 
 // bankStateKind represents the current state of a bank.
@@ -249,18 +403,34 @@ type State struct {
 	// TickCount tracks the global cycle counter for tFAW enforcement.
 	TickCount uint64 `json:"tick_count"`
 
-	// RefreshCycleCounter counts cycles since last refresh.
-	RefreshCycleCounter int `json:"refresh_cycle_counter"`
-	// RefreshInProgress is true when a refresh is currently blocking.
-	RefreshInProgress bool `json:"refresh_in_progress"`
-	// RefreshCyclesRemaining counts remaining cycles of the current refresh.
-	RefreshCyclesRemaining int `json:"refresh_cycles_remaining"`
-	// RefreshBlockedIssue is set while a refresh window is holding off the issue
-	// step (deviation D2: a global tRFC stall). It is cleared by the first
-	// command that issues once the window ends, so that command's
-	// sub-transaction can be charged a hardware_resource (refresh) milestone for
-	// the otherwise-invisible stall.
-	RefreshBlockedIssue bool `json:"refresh_blocked_issue"`
+	// Refresh is the refresh schedule, maintained by the refresh middleware.
+	Refresh refreshState `json:"refresh"`
+
+	// Energy is the energy consumed, per rank and per bank.
+	Energy energyState `json:"energy"`
+
+	// Thermal is the temperature of every thermal node and its trace,
+	// maintained by the thermal middleware.
+	Thermal thermalState `json:"thermal"`
+
+	// LowPower is the idle time and wake-up of every rank, maintained by the
+	// low-power middleware.
+	LowPower []rankLowPower `json:"low_power"`
+
+	// RowHammer is the per-row activation counts and the selected mitigation's
+	// state, maintained by the row-hammer middleware.
+	RowHammer rowHammerState `json:"row_hammer"`
+
+	// Scheduler is the history the selected scheduler ranks commands by.
+	Scheduler schedulerState `json:"scheduler"`
+
+	// CommandBusBusy is set, per channel, once a command has used the
+	// channel's command bus in the current cycle. The refresh middleware
+	// clears it at the start of every cycle.
+	CommandBusBusy []bool `json:"command_bus_busy"`
+
+	// Channels holds the per-channel statistics.
+	Channels []channelStats `json:"channels"`
 
 	// Statistics
 	TotalReadCommands       uint64 `json:"total_read_commands"`
@@ -276,6 +446,72 @@ type State struct {
 	CompletedWrites         uint64 `json:"completed_writes"`
 	BytesRead               uint64 `json:"bytes_read"`
 	BytesWritten            uint64 `json:"bytes_written"`
+	TotalRefreshes          uint64 `json:"total_refreshes"`
+	TotalBankRefreshes      uint64 `json:"total_bank_refreshes"`
+	PostponedRefreshes      uint64 `json:"postponed_refreshes"`
+	PulledInRefreshes       uint64 `json:"pulled_in_refreshes"`
+	RefreshStallCycles      uint64 `json:"refresh_stall_cycles"`
+	PowerDownEntries        uint64 `json:"power_down_entries"`
+	SelfRefreshEntries      uint64 `json:"self_refresh_entries"`
+	LowPowerStallCycles     uint64 `json:"low_power_stall_cycles"`
+	PreventiveRefreshes     uint64 `json:"preventive_refreshes"`
+	TotalRFMs               uint64 `json:"total_rfms"`
+	BackOffs                uint64 `json:"back_offs"`
+	RowHammerStallCycles    uint64 `json:"row_hammer_stall_cycles"`
+	MaxRowActivations       uint64 `json:"max_row_activations"`
+	RowHammerCrossings      uint64 `json:"row_hammer_crossings"`
+}
+
+// refreshState is the refresh schedule of every refresh target.
+type refreshState struct {
+	Targets []refreshTarget `json:"targets"`
+	// Cycle is the last cycle the schedule has counted down.
+	Cycle uint64 `json:"cycle"`
+}
+
+// rankLowPower is the low-power bookkeeping of a rank. Whether the rank is in
+// power-down or self-refresh is kept in its bank states.
+type rankLowPower struct {
+	// IdleCycles is the number of cycles the rank has been idle.
+	IdleCycles int `json:"idle_cycles"`
+	// ExitUntil is the tick at which the rank's last exit from power-down or
+	// self-refresh completes (tXP or tXS).
+	ExitUntil uint64 `json:"exit_until"`
+}
+
+// channelStats counts the commands issued on a channel.
+type channelStats struct {
+	ReadCommands    uint64 `json:"read_commands"`
+	WriteCommands   uint64 `json:"write_commands"`
+	Activates       uint64 `json:"activates"`
+	Precharges      uint64 `json:"precharges"`
+	RowBufferHits   uint64 `json:"row_buffer_hits"`
+	RowBufferMisses uint64 `json:"row_buffer_misses"`
+}
+
+// refreshTarget is the unit one refresh command refreshes: a rank for
+// all-bank refresh, a bank for per-bank refresh, or one bank index across
+// every bank group of a rank for same-bank refresh. BankGroup and Bank are -1
+// where the target spans them.
+type refreshTarget struct {
+	Rank      int `json:"rank"`
+	BankGroup int `json:"bank_group"`
+	Bank      int `json:"bank"`
+
+	// Countdown is the number of cycles until the next refresh falls due.
+	Countdown int `json:"countdown"`
+	// Owed is the number of refreshes that fell due but have not issued. It
+	// is negative when refreshes were pulled in ahead of their time.
+	Owed int `json:"owed"`
+	// Pending is set once the target is being refreshed: its banks accept no
+	// new commands and are precharged until the refresh command issues.
+	Pending bool `json:"pending"`
+	// BusyUntil is the tick at which the target's last refresh completes.
+	BusyUntil uint64 `json:"busy_until"`
+	// Stalled is set when a queued command waited on the target's refresh. The
+	// next command issued to the target clears it and is charged a refresh
+	// milestone for the wait.
+	Stalled bool `json:"stalled"`
 }
 
 // subTransRef identifies a SubTransaction by its parent transaction's stable
@@ -324,12 +560,15 @@ type commandState struct {
 	SubTransRef subTransRef `json:"sub_trans_ref"`
 }
 
-// bankEntry is a bankState tagged with its rank/bankGroup/bank indices.
+// bankEntry is a bankState tagged with its channel/pseudoChannel/rank/
+// bankGroup/bank indices. Rank is the rank within the pseudo-channel.
 type bankEntry struct {
-	Rank      int       `json:"rank"`
-	BankGroup int       `json:"bank_group"`
-	BankIndex int       `json:"bank_index"`
-	Data      bankState `json:"data"`
+	Channel       int       `json:"channel"`
+	PseudoChannel int       `json:"pseudo_channel"`
+	Rank          int       `json:"rank"`
+	BankGroup     int       `json:"bank_group"`
+	BankIndex     int       `json:"bank_index"`
+	Data          bankState `json:"data"`
 }
 
 // bankState is a serializable representation of a Bank. It holds only the
@@ -341,19 +580,35 @@ type bankState struct {
 	State   int    `json:"state"`
 	OpenRow uint64 `json:"open_row"`
 
+	// RefreshPending holds off every command to the bank while refresh
+	// precharges it for a refresh command.
+	RefreshPending bool `json:"refresh_pending"`
+
+	// OpenInPowerDown is set on a bank that powered down with its row open;
+	// the row is open again when the rank exits power-down.
+	OpenInPowerDown bool `json:"open_in_power_down"`
+
+	// MitigationPending holds off every command to the bank while a
+	// row-hammer mitigation precharges it for a mitigation command.
+	MitigationPending bool `json:"mitigation_pending"`
+
 	// CyclesToCmdAvailable[k] is the number of cycles before a command of kind
 	// k may be issued to this bank. Indexed directly by commandKind.
 	CyclesToCmdAvailable [numCmdKind]int `json:"cycles_to_cmd_available"`
 }
 
-// rankActivateHistory stores the last 4 activate timestamps for a rank.
+// rankActivateHistory stores the last 4 activate timestamps for a rank. Rank
+// is the rank index (see rankIndex).
 type rankActivateHistory struct {
 	Rank       int      `json:"rank"`
 	Timestamps []uint64 `json:"timestamps"`
 }
 
-// bankStatesFlat is a flattened representation of the 3D bank array.
+// bankStatesFlat is a flattened representation of the bank array. NumRanks is
+// the number of ranks per pseudo-channel.
 type bankStatesFlat struct {
+	NumChannels       int                   `json:"num_channels"`
+	NumPseudoChannels int                   `json:"num_pseudo_channels"`
 	NumRanks          int                   `json:"num_ranks"`
 	NumBankGroups     int                   `json:"num_bank_groups"`
 	NumBanks          int                   `json:"num_banks"`
@@ -366,6 +621,14 @@ type queueEntry struct {
 	QueueIndex int          `json:"queue_index"`
 	Command    commandState `json:"command"`
 	IsWrite    bool         `json:"is_write"`
+
+	// Source identifies the request's issuer for the fairness- and QoS-aware
+	// schedulers (see Spec.SchedulerSource). EnqueueTick is the tick the
+	// command was queued, and Marked is set while the command belongs to the
+	// PAR-BS batch.
+	Source      string `json:"source"`
+	EnqueueTick uint64 `json:"enqueue_tick"`
+	Marked      bool   `json:"marked"`
 }
 
 // commandQueueState is a serializable representation of CommandQueues.
@@ -413,30 +676,52 @@ func transactionAccessByteSize(t *transactionState) uint64 {
 	return uint64(len(t.WriteMsg.Data))
 }
 
-// initBankStatesFlat creates initial bank states for all banks (all closed).
-// Entries are laid out rank-major, then bank-group, then bank, so a bank's
-// position is computable directly (see bankFlatIndex) without a linear scan.
+// initBankStatesFlat creates initial bank states for the banks of a single
+// channel (all closed).
 func initBankStatesFlat(numRanks, numBankGroups, numBanks int) bankStatesFlat {
-	histories := make([]rankActivateHistory, numRanks)
-	for i := range numRanks {
+	return initChannelBankStatesFlat(1, 1, numRanks, numBankGroups, numBanks)
+}
+
+// initBankStates creates initial bank states for every bank of a spec.
+func initBankStates(spec *Spec) bankStatesFlat {
+	return initChannelBankStatesFlat(spec.NumChannel, max(spec.NumPseudoChannel, 1),
+		spec.NumRank, spec.NumBankGroup, spec.NumBank)
+}
+
+// initChannelBankStatesFlat creates initial bank states for all banks (all
+// closed). Entries are laid out rank-major, with the ranks in rank-index order
+// (channel, then pseudo-channel, then rank), then bank-group, then bank, so a
+// bank's position is computable directly (see bankFlatIndex) without a linear
+// scan.
+func initChannelBankStatesFlat(
+	numChannels, numPseudoChannels, numRanks, numBankGroups, numBanks int,
+) bankStatesFlat {
+	totalRanks := numChannels * numPseudoChannels * numRanks
+
+	histories := make([]rankActivateHistory, totalRanks)
+	for i := range totalRanks {
 		histories[i] = rankActivateHistory{Rank: i}
 	}
 
 	flat := bankStatesFlat{
+		NumChannels:       numChannels,
+		NumPseudoChannels: numPseudoChannels,
 		NumRanks:          numRanks,
 		NumBankGroups:     numBankGroups,
 		NumBanks:          numBanks,
-		Entries:           make([]bankEntry, 0, numRanks*numBankGroups*numBanks),
+		Entries:           make([]bankEntry, 0, totalRanks*numBankGroups*numBanks),
 		ActivateHistories: histories,
 	}
 
-	for i := range numRanks {
+	for i := range totalRanks {
 		for j := range numBankGroups {
 			for k := range numBanks {
 				flat.Entries = append(flat.Entries, bankEntry{
-					Rank:      i,
-					BankGroup: j,
-					BankIndex: k,
+					Channel:       i / (numPseudoChannels * numRanks),
+					PseudoChannel: i / numRanks % numPseudoChannels,
+					Rank:          i % numRanks,
+					BankGroup:     j,
+					BankIndex:     k,
 					Data: bankState{
 						State: int(bankStateClosed),
 					},
@@ -448,13 +733,39 @@ func initBankStatesFlat(numRanks, numBankGroups, numBanks int) bankStatesFlat {
 	return flat
 }
 
+// numRankIndices returns the number of ranks over every channel and
+// pseudo-channel.
+func numRankIndices(flat *bankStatesFlat) int {
+	return max(flat.NumChannels, 1) * max(flat.NumPseudoChannels, 1) * flat.NumRanks
+}
+
+// rankIndex numbers a location's rank over every channel and pseudo-channel:
+// the ranks of channel 0, pseudo-channel 0 come first. Per-rank state (the
+// activate history, refresh targets, energy, command queues) is indexed by it.
+func rankIndex(flat *bankStatesFlat, loc location) int {
+	pseudoChannel := int(loc.Channel)*max(flat.NumPseudoChannels, 1) +
+		int(loc.PseudoChannel)
+
+	return pseudoChannel*flat.NumRanks + int(loc.Rank)
+}
+
+// rankChannel returns the channel of a rank index.
+func rankChannel(flat *bankStatesFlat, rank int) int {
+	return rank / (max(flat.NumPseudoChannels, 1) * flat.NumRanks)
+}
+
 // bankFlatIndex returns the index into bankStatesFlat.Entries for the bank at
-// the given (rank, bankGroup, bank) coordinates, matching the layout produced
-// by initBankStatesFlat.
+// the given (rank index, bankGroup, bank) coordinates, matching the layout
+// produced by initChannelBankStatesFlat.
 func bankFlatIndex(flat *bankStatesFlat, rank, bankGroup, bank int) int {
 	return (rank*flat.NumBankGroups+bankGroup)*flat.NumBanks + bank
 }
 
+// bankIndex returns the index into bankStatesFlat.Entries of a location's bank.
+func bankIndex(flat *bankStatesFlat, loc location) int {
+	return bankFlatIndex(flat, rankIndex(flat, loc), int(loc.BankGroup), int(loc.Bank))
+}
+
 // findBankState returns a pointer to the bankState for the given indices, or
 // nil if the coordinates are out of range. O(1) — direct index, no scan.
 func findBankState(flat *bankStatesFlat, rank, bankGroup, bank int) *bankState {
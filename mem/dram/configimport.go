@@ -0,0 +1,174 @@
+package dram
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// ImportDRAMSim3 and ImportRamulator2 convert the memory configurations of the
+// reference simulators into a Spec. The conversion starts from the Akita
+// preset of the configured protocol (DefaultSpec for protocols without one),
+// so settings the file leaves out keep the preset's values. It maps the
+// timings, the organization, the address mapping, and the controller policies
+// that Spec can express. Every other setting is reported as a ConfigWarning
+// instead of being dropped silently. The returned Spec has been checked by the
+// same validation Build runs, and can be tweaked and passed to WithSpec.
+
+// ConfigWarning reports a setting of an imported configuration that the Spec
+// does not express, or expresses only approximately.
+type ConfigWarning struct {
+	// Key names the setting as the file spells it, e.g. "system.unified_queue"
+	// or "MemorySystem.Controller.plugins[0]".
+	Key    string
+	Reason string
+}
+
+// String returns the warning as "key: reason".
+func (w ConfigWarning) String() string {
+	return w.Key + ": " + w.Reason
+}
+
+// configImport accumulates the Spec and the warnings of one import.
+type configImport struct {
+	spec     Spec
+	warnings []ConfigWarning
+}
+
+func (c *configImport) warn(key, format string, args ...any) {
+	c.warnings = append(c.warnings,
+		ConfigWarning{Key: key, Reason: fmt.Sprintf(format, args...)})
+}
+
+// ignore warns about every key of a section that was not consumed, in sorted
+// order so the warnings are deterministic.
+func (c *configImport) ignore(prefix string, keys []string) {
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		c.warn(prefix+k, "no dram.Spec equivalent; ignored")
+	}
+}
+
+// finish checks the derived tRC and validates the Spec.
+func (c *configImport) finish(trcKey string, trc int) (Spec, []ConfigWarning, error) {
+	if trc > 0 && trc != c.spec.TRAS+c.spec.TRP {
+		c.warn(trcKey, "Akita derives tRC as tRAS + tRP = %d; %d is ignored",
+			c.spec.TRAS+c.spec.TRP, trc)
+	}
+
+	if err := validateSpec(c.spec); err != nil {
+		return Spec{}, c.warnings, err
+	}
+
+	return c.spec, c.warnings, nil
+}
+
+// validateSpec runs the checks Build runs on a copy of the Spec and turns their
+// panics into an error.
+func validateSpec(s Spec) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("dram: imported config is invalid: %v", r)
+		}
+	}()
+
+	b := MakeBuilder().WithSpec(s)
+	b.normalizeSpec()
+	b.buildSpec()
+
+	return nil
+}
+
+// protocolNames maps the protocol names of the reference simulators to the
+// protocols.
+var protocolNames = map[string]protocol{
+	"DDR3":   protoDDR3,
+	"DDR4":   protoDDR4,
+	"DDR5":   protoDDR5,
+	"GDDR5":  protoGDDR5,
+	"GDDR5X": protoGDDR5X,
+	"GDDR6":  protoGDDR6,
+	"LPDDR":  protoLPDDR,
+	"LPDDR3": protoLPDDR3,
+	"LPDDR4": protoLPDDR4,
+	"LPDDR5": protoLPDDR5,
+	"HBM":    protoHBM,
+	"HBM2":   protoHBM2,
+	"HBM3":   protoHBM3,
+	"HBM3E":  protoHBM3E,
+	"HMC":    protoHMC,
+}
+
+func parseProtocol(name string) (protocol, error) {
+	p, ok := protocolNames[strings.ToUpper(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("dram: unknown protocol %q", name)
+	}
+
+	return p, nil
+}
+
+// protocolPreset returns the Akita preset of a protocol, or DefaultSpec with
+// the protocol set if it has none.
+func protocolPreset(p protocol) Spec {
+	var s Spec
+
+	switch p {
+	case protoDDR4:
+		s = DDR4Spec
+	case protoDDR5:
+		s = DDR5Spec
+	case protoHBM2:
+		s = HBM2Spec
+	case protoHBM3, protoHBM3E:
+		s = HBM3Spec
+	case protoGDDR6:
+		s = GDDR6Spec
+	default:
+		s = defaultSpec
+	}
+
+	s.Protocol = int(p)
+
+	return s
+}
+
+// presetName names the Akita preset protocolPreset returns, for warnings.
+func presetName(p protocol) string {
+	switch p {
+	case protoDDR4:
+		return "DDR4Spec"
+	case protoDDR5:
+		return "DDR5Spec"
+	case protoHBM2:
+		return "HBM2Spec"
+	case protoHBM3, protoHBM3E:
+		return "HBM3Spec"
+	case protoGDDR6:
+		return "GDDR6Spec"
+	default:
+		return "DefaultSpec"
+	}
+}
+
+// freqOfPeriod converts a clock period in ps to a frequency. Configurations
+// give rounded periods (833 ps for 1200 MHz), so the frequency is rounded to
+// the MHz.
+func freqOfPeriod(ps float64) timing.Freq {
+	return timing.Freq(math.Round(1e6/ps)) * timing.MHz
+}
+
+// addrFieldOrderString renders a field order as a bit string.
+func addrFieldOrderString(order []addrField) string {
+	var sb strings.Builder
+
+	for _, f := range order {
+		sb.WriteString(addrFieldTokens[f])
+	}
+
+	return sb.String()
+}
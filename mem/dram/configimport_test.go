@@ -0,0 +1,245 @@
+package dram
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// warningKeys returns the keys of the warnings.
+func warningKeys(warnings []ConfigWarning) []string {
+	keys := []string{}
+	for _, w := range warnings {
+		keys = append(keys, w.Key)
+	}
+
+	return keys
+}
+
+var _ = Describe("Config import", func() {
+	importFile := func(path string) (Spec, []ConfigWarning, error) {
+		f, err := os.Open(path)
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+
+		if strings.HasSuffix(path, ".ini") {
+			return ImportDRAMSim3(f)
+		}
+
+		return ImportRamulator2(f)
+	}
+
+	Context("DRAMSim3", func() {
+		importINI := func(ini string) (Spec, []ConfigWarning, error) {
+			return ImportDRAMSim3(strings.NewReader(ini))
+		}
+
+		It("should import the canonical config to the scenarios' parameters", func() {
+			raw, err := os.ReadFile(tier5ScenariosPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			var doc struct {
+				Canonical map[string]any `json:"canonical"`
+			}
+			Expect(json.Unmarshal(raw, &doc)).To(Succeed())
+			c := func(key string) int { return int(doc.Canonical[key].(float64)) }
+
+			spec, warnings, err := importFile(tier5ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(spec.Protocol).To(Equal(int(protoDDR4)))
+			Expect(spec.Freq).To(Equal(1200 * timing.MHz))
+			Expect(spec.AddrMapper).To(Equal("RoChRaBaBgCo"))
+			Expect(spec.PagePolicy).To(Equal(PagePolicyClose))
+			Expect(spec.NumRank).To(Equal(c("ranks")))
+			Expect(spec.NumBankGroup).To(Equal(c("bankgroups")))
+			Expect(spec.NumBank).To(Equal(c("banks_per_group")))
+			Expect(spec.NumRow).To(Equal(c("rows")))
+			Expect(spec.NumCol).To(Equal(c("columns")))
+			Expect(spec.TCL).To(Equal(c("CL")))
+			Expect(spec.TRAS).To(Equal(c("tRAS")))
+			Expect(spec.TFAW).To(Equal(c("tFAW")))
+			Expect(spec.TWTRL).To(Equal(c("tWTR_L")))
+			Expect(spec.TRFC).To(Equal(c("tRFC")))
+			Expect(spec.CommandQueueCapacity).To(Equal(c("cmd_queue_size")))
+			Expect(warningKeys(warnings)).To(ConsistOf(
+				"system.queue_structure", "other.epoch_period", "other.output_level"))
+		})
+
+		It("should derive the ranks from the channel size", func() {
+			spec, warnings, err := importINI(`
+[dram_structure]
+protocol = DDR4
+bankgroups = 4
+banks_per_group = 4
+rows = 65536
+columns = 1024
+device_width = 8
+[system]
+channel_size = 20000 ; MB
+bus_width = 64
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec.NumRank).To(Equal(2))
+			Expect(warningKeys(warnings)).To(ConsistOf("system.channel_size"))
+		})
+
+		It("should map the policies and warn about what the Spec lacks", func() {
+			spec, warnings, err := importINI(`
+[dram_structure]
+protocol = HBM2
+[timing]
+tRC = 60
+tREFIb = 128
+tRPRE = 1
+[system]
+channels = 2
+address_mapping = rorabgbachco
+refresh_policy = BANK_LEVEL_STAGGERED
+row_buf_policy = CLOSE_PAGE
+queue_structure = PER_RANK
+enable_self_refresh = true
+sref_threshold = 500
+[power]
+IDD6x = 20
+IPP0 = 3
+[thermal]
+power_epoch_period = 10000
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec.NumBankGroup).To(Equal(HBM2Spec.NumBankGroup))
+			Expect(spec.NumChannel).To(Equal(2))
+			Expect(spec.AddrMapper).To(Equal("RoRaBgBaChCo"))
+			Expect(spec.RefreshPolicy).To(Equal(RefreshPolicyBankStaggered))
+			Expect(spec.PagePolicy).To(Equal(PagePolicyClose))
+			Expect(spec.SelfRefreshTimeout).To(Equal(500))
+			Expect(spec.IDD6).To(Equal(20.0))
+			Expect(warningKeys(warnings)).To(ConsistOf("timing.tRC", "timing.tREFIb",
+				"timing.tRPRE", "power.IPP0", "thermal.power_epoch_period"))
+			Expect(warnings).To(ContainElement(
+				HaveField("Reason", ContainSubstring("tRAS + tRP = 48"))))
+		})
+
+		It("should reject malformed and invalid configs", func() {
+			for _, ini := range []string{
+				"[dram_structure]\nprotocol = DDR9",
+				"[timing]\nCL = fast",
+				"[timing\nCL = 11",
+				"[system]\naddress_mapping = rochrababgxx",
+				"[system]\nrow_buf_policy = ADAPTIVE",
+				"[dram_structure]\nBL = 0",
+			} {
+				_, _, err := importINI(ini)
+				Expect(err).To(HaveOccurred(), ini)
+			}
+		})
+	})
+
+	Context("Ramulator2", func() {
+		importYAML := func(yaml string) (Spec, []ConfigWarning, error) {
+			return ImportRamulator2(strings.NewReader(yaml))
+		}
+
+		It("should import the canonical config like the DRAMSim3 one", func() {
+			ini, _, err := importFile(tier5ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			spec, warnings, err := importFile("validation/configs/ddr4_canonical.yaml")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(spec.Freq).To(Equal(ini.Freq))
+			Expect(spec.TCL).To(Equal(ini.TCL))
+			Expect(spec.TFAW).To(Equal(ini.TFAW))
+			Expect(spec.NumRow).To(Equal(ini.NumRow))
+			Expect(spec.NumBankGroup).To(Equal(ini.NumBankGroup))
+			Expect(spec.DeviceWidth).To(Equal(ini.DeviceWidth))
+			Expect(spec.PagePolicy).To(Equal(PagePolicyClose))
+			Expect(spec.RefreshPolicy).To(Equal(RefreshPolicyRankSimultaneous))
+			Expect(spec.AddrMapper).To(Equal("RoBaBgRaPcCoCh"))
+			Expect(warningKeys(warnings)).To(ConsistOf(
+				"MemorySystem.DRAM.timing.preset",
+				"MemorySystem.Controller.plugins[0]",
+				"MemorySystem.clock_ratio",
+				"Frontend"))
+		})
+
+		It("should map explicit organization, timing, and plugins", func() {
+			spec, warnings, err := importYAML(`
+MemorySystem:
+  impl: GenericDRAM
+  DRAM:
+    impl: DDR5-VRR
+    org:
+      channel: 2
+      rank: 2
+      bankgroup: 8
+      bank: 4
+      column: 1024
+      dq: 8
+      density: 16384
+    timing:
+      rate: 4800
+      nBL: 8
+      nCL: 40
+      nRC: 120
+      nRFC1: 400
+      nRFCsb: 200
+      nFAW: -1
+      nCS: 2
+  Controller:
+    impl: Generic
+    Scheduler: {impl: BLISS}
+    RowPolicy: {impl: OpenRowPolicy}
+    plugins:
+      - ControllerPlugin:
+          impl: Graphene
+          num_table_entries: 64
+          activation_threshold: 500
+          reset_period_ns: 1000
+  AddrMapper:
+    impl: MOP4CLXOR
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec.Protocol).To(Equal(int(protoDDR5)))
+			Expect(spec.Freq).To(Equal(2400 * timing.MHz))
+			Expect(spec.NumChannel).To(Equal(2))
+			Expect(spec.NumRank).To(Equal(2))
+			Expect(spec.NumRow).To(Equal(65536))
+			Expect(spec.BurstLength).To(Equal(16))
+			Expect(spec.TCL).To(Equal(40))
+			Expect(spec.TRFC).To(Equal(400))
+			Expect(spec.TRFCb).To(Equal(200))
+			Expect(spec.TFAW).To(Equal(DDR5Spec.TFAW))
+			Expect(spec.Scheduler).To(Equal(schedulerBLISS))
+			Expect(spec.PagePolicy).To(Equal(PagePolicyOpen))
+			Expect(spec.RowHammerMitigation).To(Equal(rowHammerMitigationGraphene))
+			Expect(spec.RowHammerTableSize).To(Equal(64))
+			Expect(spec.RowHammerMitigationThreshold).To(Equal(500))
+			Expect(spec.RowHammerWindow).To(Equal(2400))
+			Expect(spec.AddrMapper).To(BeEmpty())
+			Expect(warningKeys(warnings)).To(ConsistOf(
+				"MemorySystem.DRAM.timing.nFAW",
+				"MemorySystem.DRAM.timing.nCS",
+				"MemorySystem.DRAM.timing.nRC",
+				"MemorySystem.AddrMapper.impl"))
+		})
+
+		It("should reject configs without a DRAM or with bad values", func() {
+			for _, yaml := range []string{
+				"Frontend: {impl: LoadStoreTrace}",
+				"MemorySystem: {DRAM: {impl: SDRAM}}",
+				"MemorySystem: {DRAM: {impl: DDR4, timing: {nCL: fast}}}",
+				"MemorySystem: {DRAM: {impl: DDR4, timing: {nBL: 0}}}",
+				"MemorySystem: [",
+			} {
+				_, _, err := importYAML(yaml)
+				Expect(err).To(HaveOccurred(), yaml)
+			}
+		})
+	})
+})
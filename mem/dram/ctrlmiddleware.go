@@ -9,7 +9,8 @@ import (
 )
 
 type ctrlMiddleware struct {
-	comp *modeling.Component[Spec, State, Resources]
+	comp   *modeling.Component[Spec, State, Resources]
+	energy *energyModel
 }
 
 func (m *ctrlMiddleware) ctrlPort() messaging.Port {
@@ -51,10 +52,26 @@ func (m *ctrlMiddleware) completePendingDrain() bool {
 
 	m.ctrlPort().Send(makeCtrlRsp(m.ctrlPort(), memcontrolprotocol.CmdDrain,
 		state.CurrentCmdSrc, state.CurrentCmdID, true, ""))
+	m.freezeRefresh()
 	state.ControlState = memcontrolprotocol.StatePaused
 	return true
 }
 
+// freezeRefresh catches the refresh schedule up to the current cycle before
+// the controller pauses, as the schedule does not count paused cycles.
+func (m *ctrlMiddleware) freezeRefresh() {
+	spec := m.comp.Spec()
+	catchUpRefresh(&spec, &m.comp.State, m.energy,
+		spec.Freq.Cycle(m.comp.CurrentTime()))
+}
+
+// resumeRefresh restarts the refresh schedule at the current cycle, skipping
+// the paused cycles.
+func (m *ctrlMiddleware) resumeRefresh() {
+	spec := m.comp.Spec()
+	m.comp.State.Refresh.Cycle = spec.Freq.Cycle(m.comp.CurrentTime())
+}
+
 func (m *ctrlMiddleware) handleIncoming() bool {
 	msg := m.ctrlPort().PeekIncoming()
 	if msg == nil {
@@ -85,6 +102,9 @@ func (m *ctrlMiddleware) handlePause(req memcontrolprotocol.Req) bool {
 	if !m.ctrlPort().CanSend() {
 		return false
 	}
+	if m.comp.State.ControlState != memcontrolprotocol.StatePaused {
+		m.freezeRefresh()
+	}
 	m.comp.State.ControlState = memcontrolprotocol.StatePaused
 	m.ctrlPort().Send(makeCtrlRsp(m.ctrlPort(), memcontrolprotocol.CmdPause,
 		req.Src, req.ID, true, ""))
@@ -96,6 +116,9 @@ func (m *ctrlMiddleware) handleEnable(req memcontrolprotocol.Req) bool {
 	if !m.ctrlPort().CanSend() {
 		return false
 	}
+	if m.comp.State.ControlState == memcontrolprotocol.StatePaused {
+		m.resumeRefresh()
+	}
 	m.comp.State.ControlState = memcontrolprotocol.StateEnabled
 	m.ctrlPort().Send(makeCtrlRsp(m.ctrlPort(), memcontrolprotocol.CmdEnable,
 		req.Src, req.ID, true, ""))
@@ -127,22 +150,28 @@ func (m *ctrlMiddleware) handleReset(req memcontrolprotocol.Req) bool {
 	state.Transactions = nil
 	state.SubTransQueue = subTransQueueState{Entries: []subTransRef{}}
 	state.CommandQueues = commandQueueState{
-		NumQueues: spec.NumChannel * spec.NumRank,
+		NumQueues: numRanks(&spec),
 		Entries:   []queueEntry{},
 	}
-	state.BankStates = initBankStatesFlat(
-		spec.NumRank, spec.NumBankGroup, spec.NumBank)
+	state.BankStates = initBankStates(&spec)
+	state.CommandBusBusy = make([]bool, spec.NumChannel)
 	state.PendingCompletions = nil
 	state.TickCount = 0
-	state.RefreshCycleCounter = 0
-	state.RefreshInProgress = false
-	state.RefreshCyclesRemaining = 0
-	state.RefreshBlockedIssue = false
+	state.Refresh = initRefreshState(&spec)
+	state.LowPower = make([]rankLowPower, numRanks(&spec))
+	state.RowHammer = initRowHammerState(&spec)
+	state.Scheduler = schedulerState{}
 	state.CurrentCmdID = 0
 	state.CurrentCmdSrc = ""
 	state.ControlState = memcontrolprotocol.StateEnabled
 
 	resetStatistics(state)
+	state.Energy.AccountedCycle = spec.Freq.Cycle(m.comp.CurrentTime())
+	state.Refresh.Cycle = state.Energy.AccountedCycle
+
+	// The thermal model restarts with the energy it measures and the refresh
+	// schedule it scales.
+	state.Thermal = initThermalState(&spec, state.Energy.AccountedCycle)
 
 	for m.topPort().RetrieveIncoming() != nil {
 	}
@@ -171,6 +200,23 @@ func resetStatistics(state *State) {
 	state.CompletedWrites = 0
 	state.BytesRead = 0
 	state.BytesWritten = 0
+	state.TotalRefreshes = 0
+	state.TotalBankRefreshes = 0
+	state.PostponedRefreshes = 0
+	state.PulledInRefreshes = 0
+	state.RefreshStallCycles = 0
+	state.PowerDownEntries = 0
+	state.SelfRefreshEntries = 0
+	state.LowPowerStallCycles = 0
+	state.PreventiveRefreshes = 0
+	state.TotalRFMs = 0
+	state.BackOffs = 0
+	state.RowHammerStallCycles = 0
+	state.MaxRowActivations = 0
+	state.RowHammerCrossings = 0
+	state.Energy.Ranks = make([]rankEnergy, len(state.Energy.Ranks))
+	state.Energy.Banks = make([]commandEnergy, len(state.Energy.Banks))
+	state.Channels = make([]channelStats, len(state.Channels))
 }
 
 // endInflightTasks completes the req_in tracing task of every admitted
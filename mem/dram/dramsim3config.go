@@ -0,0 +1,358 @@
+package dram
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// dramsim3IntKeys maps the integer settings of a DRAMSim3 .ini file, as
+// "section.key", to the Spec fields they set. Timings are in cycles in both.
+var dramsim3IntKeys = map[string]func(s *Spec) *int{
+	"dram_structure.bankgroups":      func(s *Spec) *int { return &s.NumBankGroup },
+	"dram_structure.banks_per_group": func(s *Spec) *int { return &s.NumBank },
+	"dram_structure.rows":            func(s *Spec) *int { return &s.NumRow },
+	"dram_structure.columns":         func(s *Spec) *int { return &s.NumCol },
+	"dram_structure.device_width":    func(s *Spec) *int { return &s.DeviceWidth },
+	"dram_structure.BL":              func(s *Spec) *int { return &s.BurstLength },
+	"timing.AL":                      func(s *Spec) *int { return &s.TAL },
+	"timing.CL":                      func(s *Spec) *int { return &s.TCL },
+	"timing.CWL":                     func(s *Spec) *int { return &s.TCWL },
+	"timing.tRCD":                    func(s *Spec) *int { return &s.TRCD },
+	"timing.tRCDRD":                  func(s *Spec) *int { return &s.TRCDRD },
+	"timing.tRCDWR":                  func(s *Spec) *int { return &s.TRCDWR },
+	"timing.tRP":                     func(s *Spec) *int { return &s.TRP },
+	"timing.tRAS":                    func(s *Spec) *int { return &s.TRAS },
+	"timing.tRFC":                    func(s *Spec) *int { return &s.TRFC },
+	"timing.tRFCb":                   func(s *Spec) *int { return &s.TRFCb },
+	"timing.tREFI":                   func(s *Spec) *int { return &s.TREFI },
+	"timing.tRREFD":                  func(s *Spec) *int { return &s.TRREFD },
+	"timing.tRRD_S":                  func(s *Spec) *int { return &s.TRRDS },
+	"timing.tRRD_L":                  func(s *Spec) *int { return &s.TRRDL },
+	"timing.tWTR_S":                  func(s *Spec) *int { return &s.TWTRS },
+	"timing.tWTR_L":                  func(s *Spec) *int { return &s.TWTRL },
+	"timing.tFAW":                    func(s *Spec) *int { return &s.TFAW },
+	"timing.tWR":                     func(s *Spec) *int { return &s.TWR },
+	"timing.tRTP":                    func(s *Spec) *int { return &s.TRTP },
+	"timing.tCCD_S":                  func(s *Spec) *int { return &s.TCCDS },
+	"timing.tCCD_L":                  func(s *Spec) *int { return &s.TCCDL },
+	"timing.tRTRS":                   func(s *Spec) *int { return &s.TRTRS },
+	"timing.tPPD":                    func(s *Spec) *int { return &s.TPPD },
+	"timing.tCKE":                    func(s *Spec) *int { return &s.TCKE },
+	"timing.tCKESR":                  func(s *Spec) *int { return &s.TCKESR },
+	"timing.tXS":                     func(s *Spec) *int { return &s.TXS },
+	"timing.tXP":                     func(s *Spec) *int { return &s.TXP },
+	"system.channels":                func(s *Spec) *int { return &s.NumChannel },
+	"system.bus_width":               func(s *Spec) *int { return &s.BusWidth },
+	"system.cmd_queue_size":          func(s *Spec) *int { return &s.CommandQueueCapacity },
+	"system.trans_queue_size":        func(s *Spec) *int { return &s.TransactionQueueSize },
+}
+
+// dramsim3FloatKeys maps the power settings of a DRAMSim3 .ini file to the
+// Spec fields they set. DRAMSim3 calls the self-refresh current IDD6x.
+var dramsim3FloatKeys = map[string]func(s *Spec) *float64{
+	"power.VDD":    func(s *Spec) *float64 { return &s.VDD },
+	"power.IDD0":   func(s *Spec) *float64 { return &s.IDD0 },
+	"power.IDD2N":  func(s *Spec) *float64 { return &s.IDD2N },
+	"power.IDD2P":  func(s *Spec) *float64 { return &s.IDD2P },
+	"power.IDD3N":  func(s *Spec) *float64 { return &s.IDD3N },
+	"power.IDD3P":  func(s *Spec) *float64 { return &s.IDD3P },
+	"power.IDD4R":  func(s *Spec) *float64 { return &s.IDD4R },
+	"power.IDD4W":  func(s *Spec) *float64 { return &s.IDD4W },
+	"power.IDD5AB": func(s *Spec) *float64 { return &s.IDD5AB },
+	"power.IDD5PB": func(s *Spec) *float64 { return &s.IDD5PB },
+	"power.IDD6x":  func(s *Spec) *float64 { return &s.IDD6 },
+}
+
+// The defaults DRAMSim3 uses for settings the file leaves out, where they
+// differ from the Akita preset.
+const (
+	dramsim3DefaultAddrMapping   = "chrobabgraco"
+	dramsim3DefaultSrefThreshold = 1000
+)
+
+// iniEntry is one "key = value" line of an .ini file.
+type iniEntry struct {
+	key   string // "section.key"
+	value string
+}
+
+// dramsim3Import is the state of a DRAMSim3 import: the settings that take
+// effect only once the whole file is read.
+type dramsim3Import struct {
+	configImport
+
+	trc           int
+	channelSize   int
+	selfRefresh   bool
+	srefThreshold int
+}
+
+// ImportDRAMSim3 converts a DRAMSim3 .ini configuration into a Spec. The
+// [dram_structure], [timing], [system], and [power] settings are mapped;
+// channel_size sets the number of ranks, as DRAMSim3 derives it. Settings the
+// Spec cannot express, such as per-bank command queues and the [thermal] and
+// [other] sections, produce warnings. Unset settings DRAMSim3 defaults
+// differently from the Akita preset (open page, the "chrobabgraco" mapping)
+// take DRAMSim3's default.
+func ImportDRAMSim3(r io.Reader) (Spec, []ConfigWarning, error) {
+	entries, err := parseINI(r)
+	if err != nil {
+		return Spec{}, nil, err
+	}
+
+	protoName := "DDR3"
+	for _, e := range entries {
+		if e.key == "dram_structure.protocol" {
+			protoName = e.value
+		}
+	}
+
+	proto, err := parseProtocol(protoName)
+	if err != nil {
+		return Spec{}, nil, err
+	}
+
+	d := &dramsim3Import{
+		configImport:  configImport{spec: protocolPreset(proto)},
+		srefThreshold: dramsim3DefaultSrefThreshold,
+	}
+	d.spec.PagePolicy = PagePolicyOpen
+
+	if err := d.setDRAMSim3AddrMapping(dramsim3DefaultAddrMapping); err != nil {
+		return Spec{}, nil, err
+	}
+
+	for _, e := range entries {
+		if err := d.apply(e); err != nil {
+			return Spec{}, nil, fmt.Errorf("dram: %s: %w", e.key, err)
+		}
+	}
+
+	d.spec.SelfRefreshTimeout = 0
+	if d.selfRefresh {
+		d.spec.SelfRefreshTimeout = d.srefThreshold
+	}
+
+	if d.channelSize > 0 {
+		if err := d.setDRAMSim3Ranks(d.channelSize); err != nil {
+			return Spec{}, nil, err
+		}
+	}
+
+	return d.finish("timing.tRC", d.trc)
+}
+
+// apply applies one setting.
+func (d *dramsim3Import) apply(e iniEntry) error {
+	if field, ok := dramsim3IntKeys[e.key]; ok {
+		v, err := strconv.Atoi(e.value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", e.value)
+		}
+
+		*field(&d.spec) = v
+
+		return nil
+	}
+
+	if field, ok := dramsim3FloatKeys[e.key]; ok {
+		v, err := strconv.ParseFloat(e.value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", e.value)
+		}
+
+		*field(&d.spec) = v
+
+		return nil
+	}
+
+	var err error
+
+	switch e.key {
+	case "dram_structure.protocol":
+	case "timing.tCK":
+		ns, perr := strconv.ParseFloat(e.value, 64)
+		if perr != nil || ns <= 0 {
+			return fmt.Errorf("%q is not a clock period", e.value)
+		}
+
+		d.spec.Freq = freqOfPeriod(ns * 1000)
+	case "timing.tRC":
+		d.trc, err = strconv.Atoi(e.value)
+	case "timing.tREFIb":
+		d.warn(e.key, "Akita spreads per-bank refreshes evenly over tREFI; ignored")
+	case "system.channel_size":
+		d.channelSize, err = strconv.Atoi(e.value)
+	case "system.address_mapping":
+		err = d.setDRAMSim3AddrMapping(e.value)
+	case "system.row_buf_policy":
+		err = d.setDRAMSim3RowBufPolicy(e.value)
+	case "system.refresh_policy":
+		err = d.setDRAMSim3RefreshPolicy(e.value)
+	case "system.queue_structure":
+		err = d.checkDRAMSim3QueueStructure(e.key, e.value)
+	case "system.enable_self_refresh":
+		d.selfRefresh, err = strconv.ParseBool(strings.ToLower(e.value))
+	case "system.sref_threshold":
+		d.srefThreshold, err = strconv.Atoi(e.value)
+	default:
+		d.warn(e.key, "no dram.Spec equivalent; ignored")
+	}
+
+	return err
+}
+
+// setDRAMSim3AddrMapping converts a DRAMSim3 address mapping, two-letter
+// fields from the highest bits to the lowest, into a bit string.
+func (c *configImport) setDRAMSim3AddrMapping(mapping string) error {
+	for i := 0; i+2 <= len(mapping); i += 2 {
+		switch strings.ToLower(mapping[i : i+2]) {
+		case "ch", "ra", "bg", "ba", "ro", "co":
+		default:
+			return fmt.Errorf("unknown address mapping field %q", mapping[i:i+2])
+		}
+	}
+
+	order, err := parseAddrFieldOrder(mapping)
+	if err != nil {
+		return err
+	}
+
+	c.spec.AddrMapper = addrFieldOrderString(order)
+
+	return nil
+}
+
+func (c *configImport) setDRAMSim3RowBufPolicy(policy string) error {
+	switch policy {
+	case "OPEN_PAGE":
+		c.spec.PagePolicy = PagePolicyOpen
+	case "CLOSE_PAGE":
+		c.spec.PagePolicy = PagePolicyClose
+	default:
+		return fmt.Errorf("unknown row buffer policy %q", policy)
+	}
+
+	return nil
+}
+
+func (c *configImport) setDRAMSim3RefreshPolicy(policy string) error {
+	switch policy {
+	case "RANK_LEVEL_STAGGERED":
+		c.spec.RefreshPolicy = RefreshPolicyRankStaggered
+	case "RANK_LEVEL_SIMULTANEOUS":
+		c.spec.RefreshPolicy = RefreshPolicyRankSimultaneous
+	case "BANK_LEVEL_STAGGERED":
+		c.spec.RefreshPolicy = RefreshPolicyBankStaggered
+	default:
+		return fmt.Errorf("unknown refresh policy %q", policy)
+	}
+
+	return nil
+}
+
+// checkDRAMSim3QueueStructure warns about per-bank command queues, which the
+// controller does not have.
+func (c *configImport) checkDRAMSim3QueueStructure(key, structure string) error {
+	switch structure {
+	case "PER_RANK":
+	case "PER_BANK":
+		c.warn(key, "Akita keeps one command queue per rank; "+
+			"cmd_queue_size sizes the per-rank queues")
+	default:
+		return fmt.Errorf("unknown queue structure %q", structure)
+	}
+
+	return nil
+}
+
+// setDRAMSim3Ranks sets the number of ranks from the channel size in MB, as
+// DRAMSim3 derives it from the device geometry.
+func (c *configImport) setDRAMSim3Ranks(channelSize int) error {
+	s := &c.spec
+	if s.DeviceWidth <= 0 || s.BusWidth%s.DeviceWidth != 0 {
+		return fmt.Errorf("dram: bus width %d is not a multiple of device width %d",
+			s.BusWidth, s.DeviceWidth)
+	}
+
+	bankBits := uint64(s.NumRow) * uint64(s.NumCol) * uint64(s.DeviceWidth)
+	rankMB := int(bankBits / (8 << 20) * uint64(s.NumBankGroup*s.NumBank) *
+		uint64(s.BusWidth/s.DeviceWidth))
+
+	switch {
+	case rankMB == 0:
+		return fmt.Errorf("dram: a rank of the configured geometry is smaller than 1 MB")
+	case channelSize < rankMB:
+		c.warn("system.channel_size", "%d MB is less than one %d MB rank; using one rank",
+			channelSize, rankMB)
+		s.NumRank = 1
+	default:
+		if channelSize%rankMB != 0 {
+			c.warn("system.channel_size", "%d MB is not a whole number of %d MB ranks; "+
+				"using %d ranks", channelSize, rankMB, channelSize/rankMB)
+		}
+
+		s.NumRank = channelSize / rankMB
+	}
+
+	return nil
+}
+
+// parseINI reads the "key = value" lines of an .ini file in order, qualifying
+// each key with its section. Comments start with ';' or '#', at the start of a
+// line or after whitespace.
+func parseINI(r io.Reader) ([]iniEntry, error) {
+	var (
+		entries []iniEntry
+		section string
+	)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := stripINIComment(scanner.Text())
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("dram: ini line %d: unterminated section %q", n, line)
+			}
+
+			section = strings.TrimSpace(line[1 : len(line)-1])
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("dram: ini line %d: expected key = value, got %q", n, line)
+			}
+
+			entries = append(entries, iniEntry{
+				key:   section + "." + strings.TrimSpace(key),
+				value: strings.TrimSpace(value),
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func stripINIComment(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+		return ""
+	}
+
+	for _, marker := range []string{" ;", "\t;", " #", "\t#"} {
+		if i := strings.Index(line, marker); i >= 0 {
+			line = line[:i]
+		}
+	}
+
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,264 @@
+package dram
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/akita/v5/mem/memprotocol"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+var _ = Describe("Low-power states", func() {
+	var (
+		spec  Spec
+		state *State
+		mw    *lowPowerMiddleware
+	)
+
+	// setup builds a DDR4 spec without refresh, tweaked before it is
+	// normalized, an idle state, and a low-power middleware for them.
+	setup := func(tweak func(s *Spec)) {
+		s := DDR4Spec
+		s.TREFI = 0
+		if tweak != nil {
+			tweak(&s)
+		}
+
+		b := MakeBuilder().WithSpec(s)
+		b.normalizeSpec()
+		spec = b.spec
+
+		state = &State{
+			BankStates:     initBankStates(&spec),
+			Refresh:        initRefreshState(&spec),
+			LowPower:       make([]rankLowPower, numRanks(&spec)),
+			CommandBusBusy: make([]bool, spec.NumChannel),
+			Channels:       make([]channelStats, spec.NumChannel),
+		}
+
+		mw = &lowPowerMiddleware{
+			timing:    b.generateTiming(),
+			cmdCycles: b.buildCmdCycles(),
+		}
+	}
+
+	// step runs the middleware for one cycle and counts the timing down.
+	step := func() {
+		clear(state.CommandBusBusy)
+		mw.runLowPower(&spec, state)
+		tickBanks(state)
+		state.TickCount++
+	}
+
+	openRow := func(bank, row uint64) {
+		bs := findBankState(&state.BankStates, 0, 0, int(bank))
+		bs.State = int(bankStateOpen)
+		bs.OpenRow = row
+	}
+
+	queueRead := func(bank, row uint64) {
+		acceptCommand(state, &commandState{
+			ID:       1,
+			Kind:     int(cmdKindRead),
+			Location: location{Bank: bank, Row: row},
+		})
+	}
+
+	It("should reject unknown policies and negative timeouts", func() {
+		Expect(func() {
+			setup(func(s *Spec) { s.PowerDownPolicy = 7 })
+		}).To(Panic())
+		Expect(func() {
+			setup(func(s *Spec) { s.SelfRefreshTimeout = -1 })
+		}).To(Panic())
+		Expect(func() {
+			setup(func(s *Spec) { s.TXP = -1 })
+		}).To(Panic())
+	})
+
+	It("should do nothing when no low-power state is enabled", func() {
+		setup(nil)
+
+		Expect(mw.runLowPower(&spec, state)).To(BeFalse())
+		Expect(state.LowPower[0].IdleCycles).To(Equal(0))
+	})
+
+	It("should precharge an idle rank and power it down after the timeout", func() {
+		setup(func(s *Spec) {
+			s.PowerDownPolicy = PowerDownPolicyPrecharge
+			s.PowerDownTimeout = 4
+		})
+		openRow(1, 3)
+
+		for range 3 {
+			step()
+		}
+
+		Expect(state.TotalPrecharges).To(BeZero())
+
+		for range spec.TRP + 2 {
+			step()
+		}
+
+		Expect(state.TotalPrecharges).To(Equal(uint64(1)))
+		Expect(state.PowerDownEntries).To(Equal(uint64(1)))
+		Expect(rankLowPowerState(state, 0)).To(Equal(bankStatePD))
+		Expect(rankPowerStateOf(state, 0)).To(Equal(powerPrechargePowerDown))
+		Expect(mw.runLowPower(&spec, state)).To(BeFalse())
+	})
+
+	It("should keep rows open in active power-down and wake on demand", func() {
+		setup(func(s *Spec) { s.PowerDownPolicy = PowerDownPolicyActive })
+		openRow(1, 3)
+
+		step()
+
+		Expect(rankLowPowerState(state, 0)).To(Equal(bankStatePD))
+		Expect(rankPowerStateOf(state, 0)).To(Equal(powerActivePowerDown))
+
+		queueRead(1, 3)
+		for range spec.TCKE - 1 {
+			step()
+		}
+
+		Expect(rankLowPowerState(state, 0)).To(Equal(bankStatePD))
+		bs := findBankState(&state.BankStates, 0, 0, 1)
+		Expect(getReadyCommand(&spec, state, bs, &state.CommandQueues.Entries[0].Command)).
+			To(BeNil())
+
+		step()
+
+		Expect(bankStateKind(bs.State)).To(Equal(bankStateOpen))
+		Expect(bs.OpenRow).To(Equal(uint64(3)))
+		Expect(bs.CyclesToCmdAvailable[cmdKindRead]).To(Equal(spec.TXP - 1))
+		Expect(state.LowPower[0].ExitUntil).To(Equal(state.TickCount - 1 + uint64(spec.TXP)))
+		Expect(state.LowPowerStallCycles).To(Equal(uint64(spec.TCKE)))
+	})
+
+	It("should enter self-refresh after its timeout, by way of power-down", func() {
+		setup(func(s *Spec) {
+			s.PowerDownPolicy = PowerDownPolicyPrecharge
+			s.SelfRefreshTimeout = 20
+		})
+
+		for range 19 {
+			step()
+		}
+
+		Expect(rankLowPowerState(state, 0)).To(Equal(bankStatePD))
+
+		for range spec.TXP + 2 {
+			step()
+		}
+
+		Expect(rankLowPowerState(state, 0)).To(Equal(bankStateSRef))
+		Expect(rankPowerStateOf(state, 0)).To(Equal(powerSelfRefresh))
+		Expect(state.SelfRefreshEntries).To(Equal(uint64(1)))
+		Expect(mw.runLowPower(&spec, state)).To(BeFalse())
+
+		queueRead(0, 1)
+		for range spec.TCKESR {
+			step()
+		}
+
+		Expect(rankLowPowerState(state, 0)).To(Equal(bankStateClosed))
+		bs := findBankState(&state.BankStates, 0, 0, 0)
+		Expect(bs.CyclesToCmdAvailable[cmdKindActivate]).To(BeNumerically(">", spec.TXS-spec.TCKESR))
+	})
+
+	It("should wake a powered-down rank for refresh and owe none in self-refresh", func() {
+		setup(func(s *Spec) {
+			s.TREFI = DDR4Spec.TREFI
+			s.PowerDownPolicy = PowerDownPolicyPrecharge
+		})
+		step()
+		Expect(rankLowPowerState(state, 0)).To(Equal(bankStatePD))
+
+		refresh := &refreshMiddleware{timing: mw.timing, cmdCycles: mw.cmdCycles}
+		t := &state.Refresh.Targets[0]
+		t.Countdown = 1
+
+		clear(state.CommandBusBusy)
+		refresh.runRefresh(&spec, state)
+		Expect(t.Pending).To(BeTrue())
+		Expect(state.TotalRefreshes).To(BeZero())
+
+		for range spec.TCKE {
+			step()
+		}
+		Expect(rankLowPowerState(state, 0)).To(Equal(bankStateClosed))
+
+		for i := range state.BankStates.Entries {
+			state.BankStates.Entries[i].Data.State = int(bankStateSRef)
+		}
+		t.Pending = false
+		t.Owed = 0
+		t.Countdown = 1
+
+		refresh.runRefresh(&spec, state)
+		Expect(t.Owed).To(BeZero())
+		Expect(t.Countdown).To(Equal(spec.TREFI))
+	})
+
+	It("should trade read latency for power-down", func() {
+		// secondReadLatency returns the latency of a read to a rank that has
+		// been idle since the first read.
+		secondReadLatency := func(policy PowerDownPolicy) (uint64, *Comp) {
+			engine := timing.NewSerialEngine()
+			reg := modeling.NewStandaloneRegistrar(engine)
+			conn := directconnection.MakeBuilder().WithRegistrar(reg).Build("Conn")
+
+			s := DefaultSpec()
+			s.TREFI = 0
+			s.PowerDownPolicy = policy
+			s.PowerDownTimeout = 8
+			comp := MakeBuilder().WithRegistrar(reg).WithSpec(s).Build("DRAM")
+
+			for _, name := range []string{"Top", "Control"} {
+				p := modeling.MakePortBuilder().
+					WithRegistrar(reg).
+					WithComponent(comp).
+					WithSpec(modeling.PortSpec{BufSize: 16}).
+					Build(name)
+				comp.AssignPort(name, p)
+			}
+
+			top := comp.GetPortByName("Top")
+			src := messaging.NewPort(nil, 16, 16, "Src.Top")
+			conn.PlugIn(top)
+			conn.PlugIn(src)
+
+			read := func() {
+				req := memprotocol.ReadReq{}
+				req.ID = timing.GetIDGenerator().Generate()
+				req.AccessByteSize = 4
+				req.Src = src.AsRemote()
+				req.Dst = top.AsRemote()
+				req.TrafficBytes = 12
+				src.Send(req)
+				engine.Run()
+
+				for src.RetrieveIncoming() != nil {
+				}
+			}
+
+			read()
+			before := comp.State.TotalReadLatencyCycles
+			read()
+
+			return comp.State.TotalReadLatencyCycles - before, comp
+		}
+
+		standby, _ := secondReadLatency(PowerDownPolicyNone)
+		powerDown, comp := secondReadLatency(PowerDownPolicyPrecharge)
+
+		// Both ranks power down after the first read, and the read rank again
+		// after the second.
+		Expect(comp.State.PowerDownEntries).To(Equal(uint64(3)))
+		// Like refresh, the exit issues ahead of the cycle's timing countdown.
+		Expect(powerDown).To(BeNumerically(">=", standby+uint64(DefaultSpec().TXP-1)))
+	})
+})
@@ -0,0 +1,314 @@
+package dram
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v5/mem/memcontrolprotocol"
+	"github.com/sarchlab/akita/v5/modeling"
+)
+
+// lowPowerMiddleware puts idle ranks into power-down and self-refresh, and
+// wakes them when work arrives. Like refresh, it is a middleware that issues
+// real commands: the builder adds it after the refresh middleware and ahead of
+// the bank-tick middleware, and it communicates with both through State.
+//
+// A rank is idle while no command is queued for it and none of its refreshes
+// or row-hammer mitigations is pending. Once idle for Spec.PowerDownTimeout
+// cycles it powers down (PDE) as Spec.PowerDownPolicy selects, and once idle
+// for Spec.SelfRefreshTimeout cycles it leaves power-down, precharges its
+// banks, and enters self-refresh (SREFE). A queued command, pending refresh,
+// or pending mitigation wakes the rank (PDX or SREFX) once it has stayed down
+// for tCKE or tCKESR, and the timing table holds its commands for tXP or tXS
+// after the exit.
+//
+// The rank's banks record the low-power state, so queued commands cannot
+// issue to them and the power model charges the power-down and self-refresh
+// currents. A rank in self-refresh refreshes itself, and the refresh
+// middleware owes it no refreshes.
+type lowPowerMiddleware struct {
+	comp      *modeling.Component[Spec, State, Resources]
+	timing    dramTiming
+	cmdCycles map[commandKind]int
+	energy    *energyModel
+
+	// demand is the number of queued commands, pending refreshes, and pending
+	// mitigations per rank, recounted every cycle.
+	demand []int
+}
+
+// Tick moves every rank toward the low-power state its idle time calls for,
+// or wakes it, issuing at most one command per command bus. It keeps ticking
+// until every idle rank has reached the deepest state it will enter. Paused
+// DRAM freezes it.
+func (m *lowPowerMiddleware) Tick() bool {
+	next := &m.comp.State
+
+	if next.ControlState == memcontrolprotocol.StatePaused {
+		return false
+	}
+
+	spec := m.comp.Spec()
+
+	return m.runLowPower(&spec, next)
+}
+
+func (m *lowPowerMiddleware) runLowPower(spec *Spec, next *State) bool {
+	if !lowPowerEnabled(spec) || len(next.LowPower) == 0 {
+		return false
+	}
+
+	m.countDemand(next)
+
+	progress := false
+	stalled := false
+
+	for r := range next.LowPower {
+		lp := &next.LowPower[r]
+
+		if m.demand[r] > 0 {
+			lp.IdleCycles = 0
+			progress = m.wake(spec, next, r) || progress
+
+			// The rank's commands wait while it is down or exiting.
+			st := rankLowPowerState(next, r)
+			if st == bankStatePD || st == bankStateSRef || lp.ExitUntil > next.TickCount {
+				stalled = true
+			}
+
+			continue
+		}
+
+		if rankRefreshing(next, r) {
+			progress = true
+			continue
+		}
+
+		lp.IdleCycles++
+		progress = m.sleep(spec, next, r) || progress
+	}
+
+	if stalled {
+		next.LowPowerStallCycles++
+	}
+
+	return progress
+}
+
+// countDemand counts the queued commands, pending refreshes, and pending
+// row-hammer mitigations of each rank.
+func (m *lowPowerMiddleware) countDemand(next *State) {
+	if len(m.demand) != len(next.LowPower) {
+		m.demand = make([]int, len(next.LowPower))
+	}
+
+	clear(m.demand)
+
+	for i := range next.CommandQueues.Entries {
+		r := rankIndex(&next.BankStates, next.CommandQueues.Entries[i].Command.Location)
+		if r < len(m.demand) {
+			m.demand[r]++
+		}
+	}
+
+	for i := range next.Refresh.Targets {
+		t := &next.Refresh.Targets[i]
+		if t.Pending && t.Rank < len(m.demand) {
+			m.demand[t.Rank]++
+		}
+	}
+
+	for _, req := range next.RowHammer.Pending {
+		if req.Rank < len(m.demand) {
+			m.demand[req.Rank]++
+		}
+	}
+}
+
+// wake issues the exit command of a rank in power-down or self-refresh. It
+// returns true while the rank is still down, so the controller keeps ticking
+// until the exit issues.
+func (m *lowPowerMiddleware) wake(spec *Spec, next *State, r int) bool {
+	switch rankLowPowerState(next, r) {
+	case bankStatePD:
+		m.exit(next, r, cmdKindPDExit, spec.TXP)
+	case bankStateSRef:
+		m.exit(next, r, cmdKindSRefExit, spec.TXS)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// sleep moves an idle rank toward the low-power state its idle time calls for.
+// It returns false once the rank is in the deepest state it will enter.
+func (m *lowPowerMiddleware) sleep(spec *Spec, next *State, r int) bool {
+	lp := &next.LowPower[r]
+	st := rankLowPowerState(next, r)
+
+	switch {
+	case spec.SelfRefreshTimeout > 0 && lp.IdleCycles >= spec.SelfRefreshTimeout:
+		switch st {
+		case bankStateSRef:
+			return false
+		case bankStatePD:
+			m.exit(next, r, cmdKindPDExit, spec.TXP)
+		default:
+			m.enter(next, r, cmdKindSRefEnter, true)
+		}
+	case spec.PowerDownPolicy != PowerDownPolicyNone &&
+		lp.IdleCycles >= spec.PowerDownTimeout:
+		if st == bankStatePD {
+			return spec.SelfRefreshTimeout > 0
+		}
+
+		m.enter(next, r, cmdKindPDEnter,
+			spec.PowerDownPolicy == PowerDownPolicyPrecharge)
+	}
+
+	return true
+}
+
+// enter issues the next command a rank needs to enter power-down or
+// self-refresh: a precharge for one of its open banks if the state needs them
+// closed, or the entry command once every bank's timing allows it.
+func (m *lowPowerMiddleware) enter(next *State, r int, kind commandKind, precharge bool) {
+	flat := &next.BankStates
+	if commandBusBusy(next, rankChannel(flat, r)) {
+		return
+	}
+
+	first := bankFlatIndex(flat, r, 0, 0)
+	ready := true
+
+	for i := range flat.NumBankGroups * flat.NumBanks {
+		e := &flat.Entries[first+i]
+		bs := &e.Data
+
+		if precharge && bankStateKind(bs.State) == bankStateOpen {
+			ready = false
+
+			if bs.CyclesToCmdAvailable[cmdKindPrecharge] == 0 {
+				m.issue(next, bs, cmdKindPrecharge, bankLocation(e))
+				useCommandBus(next, e.Channel)
+
+				return
+			}
+
+			continue
+		}
+
+		if bs.CyclesToCmdAvailable[kind] > 0 {
+			ready = false
+		}
+	}
+
+	if ready {
+		m.issueRankCommand(next, r, kind)
+	}
+}
+
+// exit issues a rank's power-down or self-refresh exit once the rank has
+// stayed down long enough, and records when the exit latency elapses.
+func (m *lowPowerMiddleware) exit(next *State, r int, kind commandKind, latency int) {
+	flat := &next.BankStates
+	first := &flat.Entries[bankFlatIndex(flat, r, 0, 0)].Data
+
+	if commandBusBusy(next, rankChannel(flat, r)) ||
+		first.CyclesToCmdAvailable[kind] > 0 {
+		return
+	}
+
+	m.issueRankCommand(next, r, kind)
+	next.LowPower[r].ExitUntil = next.TickCount + uint64(latency)
+}
+
+// issueRankCommand issues a low-power entry or exit command to a rank and
+// moves every bank of the rank into the state the command leads to.
+func (m *lowPowerMiddleware) issueRankCommand(next *State, r int, kind commandKind) {
+	flat := &next.BankStates
+	first := bankFlatIndex(flat, r, 0, 0)
+	e := &flat.Entries[first]
+
+	m.issue(next, &e.Data, kind, bankLocation(e))
+	useCommandBus(next, e.Channel)
+
+	switch kind {
+	case cmdKindPDEnter:
+		next.PowerDownEntries++
+	case cmdKindSRefEnter:
+		next.SelfRefreshEntries++
+	}
+
+	for i := range flat.NumBankGroups * flat.NumBanks {
+		bs := &flat.Entries[first+i].Data
+
+		switch kind {
+		case cmdKindPDEnter:
+			bs.OpenInPowerDown = bankStateKind(bs.State) == bankStateOpen
+			bs.State = int(bankStatePD)
+		case cmdKindPDExit:
+			bs.State = int(bankStateClosed)
+			if bs.OpenInPowerDown {
+				bs.State = int(bankStateOpen)
+			}
+
+			bs.OpenInPowerDown = false
+		case cmdKindSRefEnter:
+			bs.State = int(bankStateSRef)
+		case cmdKindSRefExit:
+			bs.State = int(bankStateClosed)
+		}
+	}
+}
+
+func (m *lowPowerMiddleware) issue(
+	next *State, bs *bankState, kind commandKind, loc location,
+) {
+	cmd := &commandState{Kind: int(kind), Location: loc}
+	startCommand(m.cmdCycles, next, bs, cmd)
+	updateTiming(m.timing, next, cmd)
+
+	if m.energy != nil {
+		chargeCommand(m.energy, next, cmd)
+	}
+}
+
+// lowPowerEnabled returns true if idle ranks power down or self-refresh.
+func lowPowerEnabled(spec *Spec) bool {
+	return spec.PowerDownPolicy != PowerDownPolicyNone || spec.SelfRefreshTimeout > 0
+}
+
+// rankLowPowerState returns the state of a rank's first bank. Low-power
+// commands move every bank of a rank together, so it is bankStatePD or
+// bankStateSRef while the rank is in power-down or self-refresh.
+func rankLowPowerState(state *State, rank int) bankStateKind {
+	flat := &state.BankStates
+	idx := bankFlatIndex(flat, rank, 0, 0)
+
+	if idx < 0 || idx >= len(flat.Entries) {
+		return bankStateInvalid
+	}
+
+	return bankStateKind(flat.Entries[idx].Data.State)
+}
+
+// lowPowerMustBeValid rejects unknown power-down policies and negative
+// timeouts and exit latencies.
+func (b *Builder) lowPowerMustBeValid() {
+	s := &b.spec
+
+	switch s.PowerDownPolicy {
+	case PowerDownPolicyNone, PowerDownPolicyPrecharge, PowerDownPolicyActive:
+	default:
+		panic(fmt.Sprintf("dram: unknown power-down policy %d", s.PowerDownPolicy))
+	}
+
+	if s.PowerDownTimeout < 0 || s.SelfRefreshTimeout < 0 {
+		panic("dram: low-power timeouts must not be negative")
+	}
+
+	if s.TXP < 0 || s.TCKE < 0 || s.TXS < 0 || s.TCKESR < 0 {
+		panic("dram: low-power exit latencies must not be negative")
+	}
+}
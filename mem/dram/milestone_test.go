@@ -123,11 +123,12 @@ var _ = Describe("DRAM refresh-stall attribution", func() {
 		engine := timing.NewSerialEngine()
 		reg := modeling.NewStandaloneRegistrar(engine)
 
-		// A short tREFI/tRFC so a refresh window opens quickly and the request
-		// that arrives during it is the one charged the stall.
+		// A short tREFI/tRFC so the rank's first refresh falls due while the
+		// request waits in the command queue, and the request is the one
+		// charged the stall.
 		spec := DefaultSpec()
-		spec.TREFI = 1
-		spec.TRFC = 3
+		spec.TREFI = 4
+		spec.TRFC = 2
 
 		memCtrl := MakeBuilder().
 			WithRegistrar(reg).
@@ -32,9 +32,9 @@ type rowPolicy interface {
 	CommandFor(spec *Spec, st *State, ref subTransRef, loc location) *commandState
 }
 
-// addrMapper maps a physical address to a DRAM location. The location keeps a
-// Channel field so the interface stays stable if first-class channels are added
-// later; today the controller models one channel per component.
+// addrMapper maps a physical address to a DRAM location, including its channel
+// and pseudo-channel. Mappers are selected by Spec.AddrMapper: a registered
+// name or a bit-string mapping.
 type addrMapper interface {
 	Name() string
 	Map(spec *Spec, addr uint64) location
@@ -107,12 +107,19 @@ func buildColumnCommand(
 	return cmd
 }
 
-const addrMapperDefault = "default"
+const (
+	addrMapperDefault = "default"
+	addrMapperXOR     = "xor"
+	addrMapperRIT     = "rit"
+)
 
-// fixedAddrMapper applies the single fixed bit-decode scheme configured on Spec.
-type fixedAddrMapper struct{}
+// fixedAddrMapper decodes the bit fields configured on Spec, whose order the
+// builder derives from Spec.AddrMapper (see addrmap.go).
+type fixedAddrMapper struct {
+	name string
+}
 
-func (fixedAddrMapper) Name() string { return addrMapperDefault }
+func (m fixedAddrMapper) Name() string { return m.name }
 
 func (fixedAddrMapper) Map(spec *Spec, addr uint64) location {
 	return mapAddress(spec, addr)
@@ -121,11 +128,24 @@ func (fixedAddrMapper) Map(spec *Spec, addr uint64) location {
 // --- Registries ----------------------------------------------------------
 
 var schedulerRegistry = map[string]func() scheduler{
-	schedulerFRFCFS: func() scheduler { return frfcfsScheduler{} },
+	schedulerFRFCFS:    func() scheduler { return frfcfsScheduler{} },
+	schedulerFRFCFSCap: func() scheduler { return &frfcfsCapScheduler{} },
+	schedulerBLISS:     func() scheduler { return &blissScheduler{} },
+	schedulerPARBS:     func() scheduler { return &parbsScheduler{} },
+	schedulerATLAS:     func() scheduler { return &atlasScheduler{} },
+	schedulerPriority:  func() scheduler { return &priorityScheduler{} },
 }
 
-var addrMapperRegistry = map[string]func() addrMapper{
-	addrMapperDefault: func() addrMapper { return fixedAddrMapper{} },
+var addrMapperRegistry = map[string]func(spec *Spec) addrMapper{
+	addrMapperDefault: func(*Spec) addrMapper {
+		return fixedAddrMapper{name: addrMapperDefault}
+	},
+	addrMapperXOR: func(*Spec) addrMapper {
+		return hashedAddrMapper{name: addrMapperXOR, xor: true}
+	},
+	addrMapperRIT: func(spec *Spec) addrMapper {
+		return hashedAddrMapper{name: addrMapperRIT, rit: newInterleavingTable(spec)}
+	},
 }
 
 func newScheduler(name string) scheduler {
@@ -139,15 +159,22 @@ func newScheduler(name string) scheduler {
 	return factory()
 }
 
-func newAddrMapper(name string) addrMapper {
+// newAddrMapper selects a registered mapper by name, or builds one from a
+// bit-string mapping such as "RoRaBaBgCoCh_xor" (see addrmap.go).
+func newAddrMapper(spec *Spec) addrMapper {
+	name := spec.AddrMapper
 	if name == "" {
 		name = addrMapperDefault
 	}
-	factory, ok := addrMapperRegistry[name]
-	if !ok {
-		panic(fmt.Sprintf("dram: unknown address mapper %q", name))
+	if factory, ok := addrMapperRegistry[name]; ok {
+		return factory(spec)
 	}
-	return factory()
+
+	m, err := parseAddrMapping(name)
+	if err != nil {
+		panic(fmt.Sprintf("dram: unknown address mapper %q: %v", name, err))
+	}
+	return newMappedAddrMapper(name, spec, m)
 }
 
 // --- Controller ----------------------------------------------------------
@@ -159,33 +186,60 @@ type controller struct {
 	scheduler  scheduler
 	rowPolicy  rowPolicy
 	addrMapper addrMapper
+
+	// filled marks the channels that received a command this cycle.
+	filled []bool
 }
 
-// fillCommandQueue moves at most one ready sub-transaction from the
-// sub-transaction queue into a command queue: it maps the address and turns the
-// sub-transaction into a column command via the configured strategies. Returns
-// true if a sub-transaction was enqueued.
+// fillCommandQueue moves ready sub-transactions from the sub-transaction queue
+// into the command queues, at most one per channel: it maps the address and
+// turns the sub-transaction into a column command via the configured
+// strategies. Returns true if a sub-transaction was enqueued.
 func (c *controller) fillCommandQueue(spec *Spec, state *State) bool {
-	for i, ref := range state.SubTransQueue.Entries {
+	channels := max(spec.NumChannel, 1)
+	if len(c.filled) != channels {
+		c.filled = make([]bool, channels)
+	}
+
+	clear(c.filled)
+	filled := 0
+
+	for i := 0; i < len(state.SubTransQueue.Entries) && filled < channels; {
+		ref := state.SubTransQueue.Entries[i]
+
 		sub := subTransByRef(state, ref)
 		if sub == nil {
+			i++
 			continue
 		}
 
 		loc := c.addrMapper.Map(spec, sub.Address)
-		cmd := c.rowPolicy.CommandFor(spec, state, ref, loc)
+		ch := int(loc.Channel) % channels
+
+		if c.filled[ch] {
+			i++
+			continue
+		}
 
-		if canAcceptCommand(state, cmd, spec) {
-			acceptCommand(state, cmd)
-			state.SubTransQueue.Entries = append(
-				state.SubTransQueue.Entries[:i],
-				state.SubTransQueue.Entries[i+1:]...,
-			)
-			return true
+		cmd := c.rowPolicy.CommandFor(spec, state, ref, loc)
+		if !canAcceptCommand(state, cmd, spec) {
+			i++
+			continue
 		}
+
+		acceptCommand(state, cmd)
+		queued := &state.CommandQueues.Entries[len(state.CommandQueues.Entries)-1]
+		queued.Source = requestSource(spec, findTransaction(state, ref.TxID))
+
+		state.SubTransQueue.Entries = append(
+			state.SubTransQueue.Entries[:i],
+			state.SubTransQueue.Entries[i+1:]...,
+		)
+		c.filled[ch] = true
+		filled++
 	}
 
-	return false
+	return filled > 0
 }
 
 // subTransByRef resolves a sub-transaction reference to its current state, or
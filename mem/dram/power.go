@@ -0,0 +1,320 @@
+package dram
+
+import (
+	"fmt"
+)
+
+// The power model follows DRAMSim3's (and DRAMPower's) IDD-based accounting.
+// Every command adds the energy its current draws above the active-standby
+// baseline, and every cycle adds the background energy of each rank's power
+// state. Energies are in picojoules: currents are in mA, VDD in V, and cycles
+// are converted to ns by the clock period, so mA × V × ns = pJ.
+
+// energyModel holds the energy increments of a spec, per rank (all the devices
+// of a rank draw the current together).
+type energyModel struct {
+	// Per command, in pJ. activate covers the ACT and the PRE that closes the
+	// row, as in DRAMSim3; it is charged on the ACT.
+	activate    float64
+	read        float64
+	write       float64
+	refresh     float64
+	refreshBank float64
+
+	// Per row-hammer mitigation command, in pJ: a victim-row refresh
+	// activates and precharges the victim rows, and an RFM draws the refresh
+	// current for tRFM. They count as refresh energy.
+	victimRefresh float64
+	rfmBank       float64
+	rfm           float64
+
+	// Per cycle, in pJ, indexed by rankPowerState.
+	background [numRankPowerState]float64
+}
+
+// rankPowerState is the background power state of a rank.
+type rankPowerState int
+
+// A list of rank power states.
+const (
+	powerActiveStandby rankPowerState = iota
+	powerPrechargeStandby
+	powerActivePowerDown
+	powerPrechargePowerDown
+	powerSelfRefresh
+	numRankPowerState
+)
+
+// newEnergyModel computes the energy increments of a spec. A spec with VDD 0
+// consumes no energy.
+func newEnergyModel(spec *Spec) energyModel {
+	tCK := float64(spec.Freq.Period()) / 1000
+	devices := float64(devicesPerRank(spec))
+	scale := spec.VDD * devices * tCK
+
+	// above returns the energy of drawing current idd over the active-standby
+	// baseline for the given number of cycles.
+	above := func(idd float64, cycles int) float64 {
+		return scale * max(idd-spec.IDD3N, 0) * float64(cycles)
+	}
+
+	m := energyModel{
+		activate: scale * max(spec.IDD0*float64(spec.TRC)-
+			(spec.IDD3N*float64(spec.TRAS)+
+				spec.IDD2N*float64(spec.TRC-spec.TRAS)), 0),
+		read:        above(spec.IDD4R, spec.BurstCycle),
+		write:       above(spec.IDD4W, spec.BurstCycle),
+		refresh:     above(spec.IDD5AB, spec.TRFC),
+		refreshBank: above(spec.IDD5PB, spec.TRFCb),
+		rfmBank:     above(spec.IDD5PB, spec.TRFM),
+		rfm:         above(spec.IDD5AB, spec.TRFM),
+	}
+
+	m.victimRefresh = m.activate * float64(2*spec.RowHammerBlastRadius)
+
+	m.background[powerActiveStandby] = scale * spec.IDD3N
+	m.background[powerPrechargeStandby] = scale * spec.IDD2N
+	m.background[powerActivePowerDown] = scale * spec.IDD3P
+	m.background[powerPrechargePowerDown] = scale * spec.IDD2P
+	m.background[powerSelfRefresh] = scale * spec.IDD6
+
+	return m
+}
+
+// devicesPerRank returns the number of devices that make up the data bus of a
+// rank.
+func devicesPerRank(spec *Spec) int {
+	if spec.DeviceWidth <= 0 {
+		return 1
+	}
+
+	return max(spec.BusWidth/spec.DeviceWidth, 1)
+}
+
+// energyState is the energy the controller's DRAM consumed, in picojoules.
+type energyState struct {
+	// AccountedCycle is the cycle up to which background energy is included.
+	// A sleeping controller does not tick, so the background energy of the
+	// cycles it slept is added when it wakes.
+	AccountedCycle uint64 `json:"accounted_cycle"`
+
+	Ranks []rankEnergy `json:"ranks"`
+	// Banks holds the command energy of each bank, in bankFlatIndex order.
+	Banks []commandEnergy `json:"banks"`
+}
+
+// commandEnergy is the energy of the commands issued to a rank or bank.
+type commandEnergy struct {
+	ActivatePrecharge float64 `json:"activate_precharge"`
+	Read              float64 `json:"read"`
+	Write             float64 `json:"write"`
+	Refresh           float64 `json:"refresh"`
+}
+
+// Total returns the sum of the command energies.
+func (e commandEnergy) Total() float64 {
+	return e.ActivatePrecharge + e.Read + e.Write + e.Refresh
+}
+
+// rankEnergy is the energy of a rank: its commands, and its background
+// energy per power state, indexed by rankPowerState. Cycles counts the cycles
+// the rank spent in each power state.
+type rankEnergy struct {
+	Command    commandEnergy              `json:"command"`
+	Background [numRankPowerState]float64 `json:"background"`
+	Cycles     [numRankPowerState]uint64  `json:"cycles"`
+}
+
+// Total returns the rank's command and background energy.
+func (e rankEnergy) Total() float64 {
+	total := e.Command.Total()
+	for _, b := range e.Background {
+		total += b
+	}
+
+	return total
+}
+
+// numRanks returns the number of ranks over every channel and pseudo-channel
+// of a spec.
+func numRanks(spec *Spec) int {
+	return spec.NumChannel * max(spec.NumPseudoChannel, 1) * spec.NumRank
+}
+
+// initEnergyState creates an empty energy account for every rank and bank.
+func initEnergyState(spec *Spec) energyState {
+	return energyState{
+		Ranks: make([]rankEnergy, numRanks(spec)),
+		Banks: make([]commandEnergy, numRanks(spec)*spec.NumBankGroup*spec.NumBank),
+	}
+}
+
+// chargeCommand adds the energy of an issued command to its rank and bank. An
+// all-bank refresh or RFM is shared evenly by the banks of the rank.
+func chargeCommand(m *energyModel, state *State, cmd *commandState) {
+	loc := cmd.Location
+	flat := &state.BankStates
+	rankIdx := rankIndex(flat, loc)
+	rank := &state.Energy.Ranks[rankIdx].Command
+
+	bank := func() *commandEnergy {
+		return &state.Energy.Banks[bankIndex(flat, loc)]
+	}
+
+	switch commandKind(cmd.Kind) {
+	case cmdKindActivate:
+		rank.ActivatePrecharge += m.activate
+		bank().ActivatePrecharge += m.activate
+	case cmdKindRead, cmdKindReadPrecharge:
+		rank.Read += m.read
+		bank().Read += m.read
+	case cmdKindWrite, cmdKindWritePrecharge:
+		rank.Write += m.write
+		bank().Write += m.write
+	case cmdKindRefreshBank:
+		rank.Refresh += m.refreshBank
+		bank().Refresh += m.refreshBank
+	case cmdKindVRR:
+		rank.Refresh += m.victimRefresh
+		bank().Refresh += m.victimRefresh
+	case cmdKindRFMBank:
+		rank.Refresh += m.rfmBank
+		bank().Refresh += m.rfmBank
+	case cmdKindRefresh, cmdKindRFM:
+		e := m.refresh
+		if commandKind(cmd.Kind) == cmdKindRFM {
+			e = m.rfm
+		}
+
+		rank.Refresh += e
+
+		n := flat.NumBankGroups * flat.NumBanks
+		first := bankFlatIndex(flat, rankIdx, 0, 0)
+
+		for i := range n {
+			state.Energy.Banks[first+i].Refresh += e / float64(n)
+		}
+	}
+}
+
+// accountBackground adds the background energy and cycles of every rank from
+// the last accounted cycle up to now, in the power state the rank has been in
+// since.
+func accountBackground(m *energyModel, state *State, now uint64) {
+	if now <= state.Energy.AccountedCycle {
+		return
+	}
+
+	cycles := now - state.Energy.AccountedCycle
+	for r := range state.Energy.Ranks {
+		s := rankPowerStateOf(state, r)
+		state.Energy.Ranks[r].Background[s] += m.background[s] * float64(cycles)
+		state.Energy.Ranks[r].Cycles[s] += cycles
+	}
+
+	state.Energy.AccountedCycle = now
+}
+
+// energyAt returns the energy consumed up to cycle now, including the
+// background energy not yet accounted, without changing the state.
+func energyAt(m *energyModel, state *State, now uint64) energyState {
+	e := energyState{
+		AccountedCycle: max(now, state.Energy.AccountedCycle),
+		Ranks:          append([]rankEnergy(nil), state.Energy.Ranks...),
+		Banks:          state.Energy.Banks,
+	}
+
+	if now > state.Energy.AccountedCycle {
+		cycles := now - state.Energy.AccountedCycle
+		for r := range e.Ranks {
+			s := rankPowerStateOf(state, r)
+			e.Ranks[r].Background[s] += m.background[s] * float64(cycles)
+			e.Ranks[r].Cycles[s] += cycles
+		}
+	}
+
+	return e
+}
+
+// rankPowerStateOf returns the background power state of a rank: active
+// standby while a bank is open or the rank refreshes, and precharge standby
+// otherwise. Banks in power-down or self-refresh put the rank in those states;
+// power-down is active power-down if a bank powered down with its row open.
+func rankPowerStateOf(state *State, rank int) rankPowerState {
+	flat := &state.BankStates
+	n := flat.NumBankGroups * flat.NumBanks
+	first := bankFlatIndex(flat, rank, 0, 0)
+
+	open, powerDown, selfRefresh := false, false, false
+
+	for i := range n {
+		switch bankStateKind(flat.Entries[first+i].Data.State) {
+		case bankStateOpen:
+			open = true
+		case bankStatePD:
+			powerDown = true
+			open = open || flat.Entries[first+i].Data.OpenInPowerDown
+		case bankStateSRef:
+			selfRefresh = true
+		}
+	}
+
+	switch {
+	case selfRefresh:
+		return powerSelfRefresh
+	case powerDown && open:
+		return powerActivePowerDown
+	case powerDown:
+		return powerPrechargePowerDown
+	case open || rankRefreshing(state, rank):
+		return powerActiveStandby
+	default:
+		return powerPrechargeStandby
+	}
+}
+
+// rankRefreshing returns true if a refresh of the rank is in progress.
+func rankRefreshing(state *State, rank int) bool {
+	for i := range state.Refresh.Targets {
+		t := &state.Refresh.Targets[i]
+		if t.Rank == rank && t.BusyUntil > state.TickCount {
+			return true
+		}
+	}
+
+	return false
+}
+
+// powerMiddleware accounts the background energy of the ranks. It runs ahead
+// of the middlewares that issue commands, so the cycles since its last tick are
+// charged in the power states the ranks were in during them. It accounts
+// paused cycles too: a paused controller's DRAM is still powered.
+type powerMiddleware struct {
+	comp  *Comp
+	model energyModel
+}
+
+// Tick accounts background energy up to the current cycle. It never makes
+// progress on its own.
+func (m *powerMiddleware) Tick() bool {
+	now := m.comp.Spec().Freq.Cycle(m.comp.CurrentTime())
+	accountBackground(&m.model, &m.comp.State, now)
+
+	return false
+}
+
+// powerMustBeValid rejects negative supply voltages and currents.
+func (b *Builder) powerMustBeValid() {
+	s := &b.spec
+	values := map[string]float64{
+		"VDD": s.VDD, "IDD0": s.IDD0, "IDD2N": s.IDD2N, "IDD2P": s.IDD2P,
+		"IDD3N": s.IDD3N, "IDD3P": s.IDD3P, "IDD4R": s.IDD4R, "IDD4W": s.IDD4W,
+		"IDD5AB": s.IDD5AB, "IDD5PB": s.IDD5PB, "IDD6": s.IDD6,
+	}
+
+	for name, v := range values {
+		if v < 0 {
+			panic(fmt.Sprintf("dram: %s must not be negative", name))
+		}
+	}
+}
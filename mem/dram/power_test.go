@@ -0,0 +1,177 @@
+package dram
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/stats"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// powerStatsRegistrar is a standalone registrar that provides a stats
+// registry, as a simulation does.
+type powerStatsRegistrar struct {
+	modeling.Registrar
+	registry *stats.Registry
+}
+
+func (r powerStatsRegistrar) Stats() *stats.Registry { return r.registry }
+
+var _ = Describe("Power Model", func() {
+	var (
+		spec  Spec
+		model energyModel
+		state *State
+	)
+
+	BeforeEach(func() {
+		b := MakeBuilder().WithSpec(DDR4Spec)
+		b.normalizeSpec()
+		spec = b.spec
+		model = newEnergyModel(&spec)
+		state = &State{
+			BankStates: initBankStatesFlat(
+				spec.NumRank, spec.NumBankGroup, spec.NumBank),
+			Refresh: initRefreshState(&spec),
+			Energy:  initEnergyState(&spec),
+		}
+	})
+
+	It("should compute the DRAMSim3 energy increments", func() {
+		// 8 x8 devices at 1.2 V with a 1/1.2 GHz clock.
+		scale := 1.2 * 8 * (833.0 / 1000)
+
+		Expect(model.activate).To(BeNumerically("~",
+			scale*(48*55-(43*39+34*16)), 1e-6))
+		Expect(model.read).To(BeNumerically("~", scale*(135-43)*4, 1e-6))
+		Expect(model.write).To(BeNumerically("~", scale*(123-43)*4, 1e-6))
+		Expect(model.refresh).To(BeNumerically("~", scale*(250-43)*312, 1e-6))
+		Expect(model.background[powerActiveStandby]).To(
+			BeNumerically("~", scale*43, 1e-6))
+		Expect(model.background[powerPrechargeStandby]).To(
+			BeNumerically("~", scale*34, 1e-6))
+	})
+
+	It("should consume nothing without VDD", func() {
+		spec.VDD = 0
+		model = newEnergyModel(&spec)
+
+		Expect(model).To(Equal(energyModel{}))
+	})
+
+	It("should charge commands to their rank and bank", func() {
+		loc := location{BankGroup: 1, Bank: 2}
+		chargeCommand(&model, state, &commandState{Kind: int(cmdKindActivate), Location: loc})
+		chargeCommand(&model, state, &commandState{Kind: int(cmdKindRead), Location: loc})
+		chargeCommand(&model, state, &commandState{Kind: int(cmdKindWritePrecharge), Location: loc})
+
+		bank := state.Energy.Banks[bankFlatIndex(&state.BankStates, 0, 1, 2)]
+		Expect(bank.ActivatePrecharge).To(Equal(model.activate))
+		Expect(bank.Read).To(Equal(model.read))
+		Expect(bank.Write).To(Equal(model.write))
+		Expect(state.Energy.Ranks[0].Command).To(Equal(bank))
+		Expect(state.Energy.Banks[0]).To(Equal(commandEnergy{}))
+	})
+
+	It("should share all-bank refresh energy among the banks", func() {
+		chargeCommand(&model, state, &commandState{Kind: int(cmdKindRefresh)})
+
+		Expect(state.Energy.Ranks[0].Command.Refresh).To(Equal(model.refresh))
+
+		sum := 0.0
+		for _, b := range state.Energy.Banks {
+			Expect(b.Refresh).To(BeNumerically("~", model.refresh/16, 1e-9))
+			sum += b.Refresh
+		}
+		Expect(sum).To(BeNumerically("~", model.refresh, 1e-6))
+	})
+
+	It("should account background energy by rank power state", func() {
+		accountBackground(&model, state, 10)
+		Expect(state.Energy.Ranks[0].Background[powerPrechargeStandby]).To(
+			BeNumerically("~", 10*model.background[powerPrechargeStandby], 1e-6))
+
+		findBankState(&state.BankStates, 0, 0, 0).State = int(bankStateOpen)
+		accountBackground(&model, state, 15)
+		Expect(state.Energy.Ranks[0].Background[powerActiveStandby]).To(
+			BeNumerically("~", 5*model.background[powerActiveStandby], 1e-6))
+		Expect(state.Energy.AccountedCycle).To(Equal(uint64(15)))
+	})
+
+	It("should count a refreshing rank as active", func() {
+		state.Refresh.Targets[0].BusyUntil = 100
+
+		Expect(rankPowerStateOf(state, 0)).To(Equal(powerActiveStandby))
+	})
+
+	It("should report unaccounted background energy without accounting it", func() {
+		e := energyAt(&model, state, 20)
+
+		Expect(e.Ranks[0].Background[powerPrechargeStandby]).To(
+			BeNumerically("~", 20*model.background[powerPrechargeStandby], 1e-6))
+		Expect(state.Energy.Ranks[0].Background[powerPrechargeStandby]).To(BeZero())
+		Expect(state.Energy.AccountedCycle).To(BeZero())
+	})
+
+	It("should reject negative currents", func() {
+		s := DDR4Spec
+		s.IDD4R = -1
+
+		b := MakeBuilder().WithSpec(s)
+		Expect(b.normalizeSpec).To(Panic())
+	})
+
+	It("should charge the refreshes of an idle interval with its background", func() {
+		h := newP0Harness(spec)
+		h.readAcrossIdle(20 * spec.TREFI)
+
+		// Refresh and background energy both cover the idle interval: each
+		// rank is charged one refresh per tREFI of the accounted cycles.
+		energy := h.dram.State.Energy
+		Expect(energy.AccountedCycle).To(BeNumerically(">=", 20*spec.TREFI))
+		refreshes := float64(energy.AccountedCycle) / float64(spec.TREFI)
+		for r := 0; r < spec.NumRank; r++ {
+			Expect(energy.Ranks[r].Command.Refresh).To(
+				BeNumerically("~", refreshes*model.refresh, model.refresh))
+		}
+		Expect(h.dram.State.TotalRefreshes).To(
+			BeNumerically("~", refreshes*float64(spec.NumRank), spec.NumRank))
+	})
+
+	It("should publish energy and power statistics", func() {
+		engine := timing.NewSerialEngine()
+		registry := stats.NewRegistry(stats.DefaultName)
+		reg := powerStatsRegistrar{
+			Registrar: modeling.NewStandaloneRegistrar(engine),
+			registry:  registry,
+		}
+
+		comp := MakeBuilder().
+			WithRegistrar(reg).
+			WithSpec(DDR4Spec).
+			Build("PowerDRAM")
+
+		loc := location{Rank: 0, BankGroup: 3, Bank: 1}
+		chargeCommand(&model, &comp.State, &commandState{Kind: int(cmdKindActivate), Location: loc})
+		accountBackground(&model, &comp.State, 100)
+
+		value := func(name string) float64 {
+			s, found := registry.Lookup(name)
+			Expect(found).To(BeTrue(), name)
+
+			return s.Entries()[0].Value
+		}
+
+		total := model.activate + 100*model.background[powerPrechargeStandby]
+		Expect(value("PowerDRAM.Energy.Total")).To(BeNumerically("~", total, 1))
+		Expect(value("PowerDRAM.Energy.Rank[0].Total")).To(BeNumerically("~", total, 1))
+		Expect(value("PowerDRAM.Energy.Rank[0].BankGroup[3].Bank[1].ActivatePrecharge")).
+			To(BeNumerically("~", model.activate, 1))
+		Expect(value("PowerDRAM.EnergyCycles")).To(Equal(100.0))
+
+		tCK := float64(DDR4Spec.Freq.Period()) / 1000
+		Expect(value("PowerDRAM.AveragePower")).To(
+			BeNumerically("~", total/(100*tCK), 0.1))
+	})
+})
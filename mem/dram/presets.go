@@ -34,12 +34,25 @@ var DDR4Spec = Spec{
 	TRFCb:                0,
 	TCKESR:               7,
 	TXS:                  320,
+	TXP:                  8,
+	TCKE:                 6,
 	TAL:                  0,
 	TCWL:                 12,
 	TRTRS:                2,
 	TPPD:                 0,
 	TRCDRD:               0,
 	TRCDWR:               0,
+	VDD:                  1.2,
+	IDD0:                 48,
+	IDD2N:                34,
+	IDD2P:                25,
+	IDD3N:                43,
+	IDD3P:                37,
+	IDD4R:                135,
+	IDD4W:                123,
+	IDD5AB:               250,
+	IDD5PB:               0,
+	IDD6:                 30,
 	NumChannel:           1,
 	TransactionQueueSize: 32,
 	CommandQueueCapacity: 8,
@@ -75,12 +88,25 @@ var DDR5Spec = Spec{
 	TRFCb:                0,
 	TCKESR:               9,
 	TXS:                  418,
+	TXP:                  18,
+	TCKE:                 8,
 	TAL:                  0,
 	TCWL:                 34,
 	TRTRS:                2,
 	TPPD:                 0,
 	TRCDRD:               0,
 	TRCDWR:               0,
+	VDD:                  1.1,
+	IDD0:                 70,
+	IDD2N:                46,
+	IDD2P:                41,
+	IDD3N:                56,
+	IDD3P:                46,
+	IDD4R:                230,
+	IDD4W:                225,
+	IDD5AB:               277,
+	IDD5PB:               117,
+	IDD6:                 46,
 	NumChannel:           1,
 	TransactionQueueSize: 32,
 	CommandQueueCapacity: 8,
@@ -115,12 +141,25 @@ var HBM2Spec = Spec{
 	TRFCb:                128,
 	TCKESR:               5,
 	TXS:                  268,
+	TXP:                  8,
+	TCKE:                 5,
 	TAL:                  0,
 	TCWL:                 4,
 	TRTRS:                2,
 	TPPD:                 0,
 	TRCDRD:               14,
 	TRCDWR:               10,
+	VDD:                  1.2,
+	IDD0:                 65,
+	IDD2N:                40,
+	IDD2P:                28,
+	IDD3N:                55,
+	IDD3P:                40,
+	IDD4R:                390,
+	IDD4W:                500,
+	IDD5AB:               250,
+	IDD5PB:               90,
+	IDD6:                 31,
 	NumChannel:           1,
 	TransactionQueueSize: 32,
 	CommandQueueCapacity: 8,
@@ -155,12 +194,25 @@ var HBM3Spec = Spec{
 	TRFCb:                256,
 	TCKESR:               10,
 	TXS:                  528,
+	TXP:                  24,
+	TCKE:                 16,
 	TAL:                  0,
 	TCWL:                 8,
 	TRTRS:                2,
 	TPPD:                 0,
 	TRCDRD:               36,
 	TRCDWR:               24,
+	VDD:                  1.1,
+	IDD0:                 60,
+	IDD2N:                36,
+	IDD2P:                25,
+	IDD3N:                50,
+	IDD3P:                36,
+	IDD4R:                420,
+	IDD4W:                440,
+	IDD5AB:               240,
+	IDD5PB:               85,
+	IDD6:                 28,
 	NumChannel:           1,
 	TransactionQueueSize: 32,
 	CommandQueueCapacity: 8,
@@ -195,12 +247,25 @@ var GDDR6Spec = Spec{
 	TRFCb:                0,
 	TCKESR:               7,
 	TXS:                  218,
+	TXP:                  11,
+	TCKE:                 7,
 	TAL:                  0,
 	TCWL:                 8,
 	TRTRS:                2,
 	TPPD:                 2,
 	TRCDRD:               20,
 	TRCDWR:               14,
+	VDD:                  1.35,
+	IDD0:                 80,
+	IDD2N:                60,
+	IDD2P:                45,
+	IDD3N:                75,
+	IDD3P:                55,
+	IDD4R:                300,
+	IDD4W:                290,
+	IDD5AB:               280,
+	IDD5PB:               0,
+	IDD6:                 35,
 	NumChannel:           1,
 	TransactionQueueSize: 32,
 	CommandQueueCapacity: 8,
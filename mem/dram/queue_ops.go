@@ -94,9 +94,10 @@ func createOpenPageCommand(
 		spec, state, ref, mapAddress(spec, st.Address))
 }
 
-// getQueueIndex returns the command queue index for a command (by rank).
-func getQueueIndex(cmd *commandState) int {
-	return int(cmd.Location.Rank)
+// getQueueIndex returns the command queue index for a command: its rank
+// index.
+func getQueueIndex(flat *bankStatesFlat, cmd *commandState) int {
+	return rankIndex(flat, cmd.Location)
 }
 
 // isWriteCommand returns true if the command is a write or write-precharge.
@@ -113,7 +114,7 @@ func canAcceptCommand(
 	cmd *commandState,
 	spec *Spec,
 ) bool {
-	queueIdx := getQueueIndex(cmd)
+	queueIdx := getQueueIndex(&state.BankStates, cmd)
 	isWrite := isWriteCommand(cmd)
 
 	// If R/W queue separation is configured (sizes > 0), use separate limits
@@ -142,13 +143,14 @@ func canAcceptCommand(
 
 // acceptCommand adds a command to the command queue.
 func acceptCommand(state *State, cmd *commandState) {
-	queueIdx := getQueueIndex(cmd)
+	queueIdx := getQueueIndex(&state.BankStates, cmd)
 	state.CommandQueues.Entries = append(
 		state.CommandQueues.Entries,
 		queueEntry{
-			QueueIndex: queueIdx,
-			Command:    *cmd,
-			IsWrite:    isWriteCommand(cmd),
+			QueueIndex:  queueIdx,
+			Command:     *cmd,
+			IsWrite:     isWriteCommand(cmd),
+			EnqueueTick: state.TickCount,
 		},
 	)
 }
@@ -161,23 +163,10 @@ func acceptCommand(state *State, cmd *commandState) {
 // row, and the command is ready) over other ready commands. Among commands
 // of equal priority, the oldest (earliest in the queue) wins.
 func getCommandToIssue(spec *Spec, next *State) *commandState {
-	// Write drain logic (only when R/W queue separation is configured)
-	if spec.ReadQueueSize > 0 && spec.WriteQueueSize > 0 {
-		writeCount := countWriteCommands(next)
-		if !next.CommandQueues.WriteDrainMode &&
-			writeCount >= spec.WriteHighWatermark {
-			next.CommandQueues.WriteDrainMode = true
-		}
-		if next.CommandQueues.WriteDrainMode &&
-			writeCount <= spec.WriteLowWatermark {
-			next.CommandQueues.WriteDrainMode = false
-		}
-
-		if next.CommandQueues.WriteDrainMode {
-			cmd := getFirstReadyWrite(spec, next)
-			if cmd != nil {
-				return cmd
-			}
+	if updateWriteDrainMode(spec, next) {
+		cmd := getFirstReadyWrite(spec, next)
+		if cmd != nil {
+			return cmd
 		}
 	}
 
@@ -198,6 +187,27 @@ func getCommandToIssue(spec *Spec, next *State) *commandState {
 	return nil
 }
 
+// updateWriteDrainMode enters write-drain mode once the pending writes reach
+// the high watermark and leaves it at the low watermark, and returns whether
+// the controller drains writes. Draining needs read/write queue separation.
+func updateWriteDrainMode(spec *Spec, next *State) bool {
+	if spec.ReadQueueSize <= 0 || spec.WriteQueueSize <= 0 {
+		return false
+	}
+
+	writeCount := countWriteCommands(next)
+	if !next.CommandQueues.WriteDrainMode &&
+		writeCount >= spec.WriteHighWatermark {
+		next.CommandQueues.WriteDrainMode = true
+	}
+	if next.CommandQueues.WriteDrainMode &&
+		writeCount <= spec.WriteLowWatermark {
+		next.CommandQueues.WriteDrainMode = false
+	}
+
+	return next.CommandQueues.WriteDrainMode
+}
+
 // findRowBufferHitCommand scans the command queue for a row-buffer hit
 // (bank is open, matching row, and the command is ready). Returns the first
 // (oldest) such command, or nil if none found.
@@ -256,8 +266,11 @@ func removeCommandFromQueueByIndex(next *State, idx int) {
 
 // findBankStateByLocation finds the bank state for a given Location.
 func findBankStateByLocation(flat *bankStatesFlat, loc location) *bankState {
-	return findBankState(flat,
-		int(loc.Rank), int(loc.BankGroup), int(loc.Bank))
+	idx := bankIndex(flat, loc)
+	if idx < 0 || idx >= len(flat.Entries) {
+		return nil
+	}
+	return &flat.Entries[idx].Data
 }
 
 // countWriteCommands returns the total number of write commands in the
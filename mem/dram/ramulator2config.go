@@ -0,0 +1,511 @@
+package dram
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ramulator2OrgKeys maps the organization settings of a Ramulator2 DRAM to the
+// Spec fields they set.
+var ramulator2OrgKeys = map[string]func(s *Spec) *int{
+	"channel":       func(s *Spec) *int { return &s.NumChannel },
+	"pseudochannel": func(s *Spec) *int { return &s.NumPseudoChannel },
+	"rank":          func(s *Spec) *int { return &s.NumRank },
+	"bankgroup":     func(s *Spec) *int { return &s.NumBankGroup },
+	"bank":          func(s *Spec) *int { return &s.NumBank },
+	"row":           func(s *Spec) *int { return &s.NumRow },
+	"column":        func(s *Spec) *int { return &s.NumCol },
+	"dq":            func(s *Spec) *int { return &s.DeviceWidth },
+}
+
+// ramulator2TimingKeys maps the timings of a Ramulator2 DRAM, in cycles, to
+// the Spec fields they set. The per-bank and same-bank refresh times both set
+// TRFCb.
+var ramulator2TimingKeys = map[string]func(s *Spec) *int{
+	"nCL":    func(s *Spec) *int { return &s.TCL },
+	"nCWL":   func(s *Spec) *int { return &s.TCWL },
+	"nRCD":   func(s *Spec) *int { return &s.TRCD },
+	"nRCDRD": func(s *Spec) *int { return &s.TRCDRD },
+	"nRCDWR": func(s *Spec) *int { return &s.TRCDWR },
+	"nRP":    func(s *Spec) *int { return &s.TRP },
+	"nRAS":   func(s *Spec) *int { return &s.TRAS },
+	"nWR":    func(s *Spec) *int { return &s.TWR },
+	"nRTP":   func(s *Spec) *int { return &s.TRTP },
+	"nCCDS":  func(s *Spec) *int { return &s.TCCDS },
+	"nCCDL":  func(s *Spec) *int { return &s.TCCDL },
+	"nRRDS":  func(s *Spec) *int { return &s.TRRDS },
+	"nRRDL":  func(s *Spec) *int { return &s.TRRDL },
+	"nWTRS":  func(s *Spec) *int { return &s.TWTRS },
+	"nWTRL":  func(s *Spec) *int { return &s.TWTRL },
+	"nFAW":   func(s *Spec) *int { return &s.TFAW },
+	"nRTRS":  func(s *Spec) *int { return &s.TRTRS },
+	"nPPD":   func(s *Spec) *int { return &s.TPPD },
+	"nRFC":   func(s *Spec) *int { return &s.TRFC },
+	"nRFC1":  func(s *Spec) *int { return &s.TRFC },
+	"nRFCpb": func(s *Spec) *int { return &s.TRFCb },
+	"nRFCsb": func(s *Spec) *int { return &s.TRFCb },
+	"nREFI":  func(s *Spec) *int { return &s.TREFI },
+	"nRREFD": func(s *Spec) *int { return &s.TRREFD },
+	"nXS":    func(s *Spec) *int { return &s.TXS },
+	"nXP":    func(s *Spec) *int { return &s.TXP },
+	"nCKE":   func(s *Spec) *int { return &s.TCKE },
+	"nCKESR": func(s *Spec) *int { return &s.TCKESR },
+}
+
+// ramulator2AddrMappers maps Ramulator2's address mappers to bit strings.
+// Ramulator2's "Ba" covers the bank group and the bank, and its "Ra" the rank
+// or, on HBM, the pseudo-channel.
+var ramulator2AddrMappers = map[string]string{
+	"RoBaRaCoCh": "RoBaBgRaPcCoCh",
+	"ChRaBaRoCo": "ChRaPcBgBaRoCo",
+}
+
+// ramulator2DDR4OrgPreset matches the names of Ramulator2's DDR4 organization
+// presets, e.g. "DDR4_8Gb_x8".
+var ramulator2DDR4OrgPreset = regexp.MustCompile(`^DDR4_(\d+)Gb_x(4|8|16)$`)
+
+// ramulator2Import is the state of a Ramulator2 import.
+type ramulator2Import struct {
+	configImport
+
+	proto     protocol
+	trc       int
+	mitigated bool
+}
+
+// ImportRamulator2 converts a Ramulator2 YAML configuration into a Spec. The
+// MemorySystem's DRAM organization and timing, its controller's scheduler,
+// refresh manager, row policy, and PARA or Graphene plugin, and its address
+// mapper are mapped. Named organization presets are expanded for DDR4; named
+// timing presets are not built in, so the explicit timings override the Akita
+// preset's and the warnings name the preset. The Frontend and every setting
+// the Spec cannot express produce warnings.
+func ImportRamulator2(r io.Reader) (Spec, []ConfigWarning, error) {
+	var doc map[string]any
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return Spec{}, nil, fmt.Errorf("dram: ramulator2 config: %w", err)
+	}
+
+	memSys, ok := take(doc, "MemorySystem").(map[string]any)
+	if !ok {
+		return Spec{}, nil, fmt.Errorf("dram: ramulator2 config has no MemorySystem")
+	}
+
+	dram, ok := take(memSys, "DRAM").(map[string]any)
+	if !ok {
+		return Spec{}, nil, fmt.Errorf("dram: ramulator2 config has no MemorySystem.DRAM")
+	}
+
+	// Variants such as "DDR5-VRR" add commands Akita already models.
+	impl, _ := take(dram, "impl").(string)
+	base, _, _ := strings.Cut(impl, "-")
+
+	proto, err := parseProtocol(base)
+	if err != nil {
+		return Spec{}, nil, err
+	}
+
+	m := &ramulator2Import{
+		configImport: configImport{spec: protocolPreset(proto)},
+		proto:        proto,
+	}
+
+	if err := m.importOrg(take(dram, "org")); err != nil {
+		return Spec{}, nil, err
+	}
+
+	if err := m.importTiming(take(dram, "timing")); err != nil {
+		return Spec{}, nil, err
+	}
+
+	if err := m.importController(take(memSys, "Controller")); err != nil {
+		return Spec{}, nil, err
+	}
+
+	m.importAddrMapper(take(memSys, "AddrMapper"))
+
+	if impl, ok := take(memSys, "impl").(string); ok && impl != "GenericDRAM" {
+		m.warn("MemorySystem.impl", "only GenericDRAM is modeled; %q ignored", impl)
+	}
+
+	m.ignore("MemorySystem.DRAM.", slices.Collect(maps.Keys(dram)))
+	m.ignore("MemorySystem.", slices.Collect(maps.Keys(memSys)))
+	m.ignore("", slices.Collect(maps.Keys(doc)))
+
+	return m.finish("MemorySystem.DRAM.timing.nRC", m.trc)
+}
+
+func (m *ramulator2Import) importOrg(v any) error {
+	const prefix = "MemorySystem.DRAM.org."
+
+	org, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	if preset, ok := take(org, "preset").(string); ok {
+		m.expandOrgPreset(prefix+"preset", preset)
+	}
+
+	_, rowSet := org["row"]
+
+	for key, field := range ramulator2OrgKeys {
+		v, ok := org[key]
+		if !ok {
+			continue
+		}
+
+		delete(org, key)
+
+		n, err := yamlInt(v)
+		if err != nil {
+			return fmt.Errorf("dram: %s%s: %w", prefix, key, err)
+		}
+
+		*field(&m.spec) = n
+	}
+
+	// Without a row count, the rows follow from the density (in Mb).
+	if v := take(org, "density"); v != nil && !rowSet {
+		density, err := yamlInt(v)
+		if err != nil {
+			return fmt.Errorf("dram: %sdensity: %w", prefix, err)
+		}
+
+		s := &m.spec
+
+		bits := s.NumBankGroup * s.NumBank * s.NumCol * s.DeviceWidth
+		if bits <= 0 {
+			return fmt.Errorf("dram: %sdensity: the organization has no banks", prefix)
+		}
+
+		s.NumRow = density << 20 / bits
+	}
+
+	m.ignore(prefix, slices.Collect(maps.Keys(org)))
+
+	return nil
+}
+
+// expandOrgPreset sets the geometry of a named organization preset. The DDR4
+// presets follow the JEDEC geometry: 1K columns and 16 banks in 4 bank groups,
+// or 8 in 2 for x16 devices.
+func (m *ramulator2Import) expandOrgPreset(key, preset string) {
+	match := ramulator2DDR4OrgPreset.FindStringSubmatch(preset)
+	if m.proto != protoDDR4 || match == nil {
+		m.warn(key, "organization preset %q is not built in; "+
+			"using the organization of %s", preset, presetName(m.proto))
+
+		return
+	}
+
+	gb, _ := strconv.Atoi(match[1])
+	dq, _ := strconv.Atoi(match[2])
+
+	s := &m.spec
+	s.DeviceWidth = dq
+	s.NumBankGroup = 4
+	s.NumBank = 4
+	s.NumCol = 1024
+
+	if dq == 16 {
+		s.NumBankGroup = 2
+	}
+
+	s.NumRow = gb << 30 / (s.NumBankGroup * s.NumBank * s.NumCol * dq)
+}
+
+func (m *ramulator2Import) importTiming(v any) error {
+	const prefix = "MemorySystem.DRAM.timing."
+
+	t, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	if preset, ok := take(t, "preset").(string); ok {
+		m.warn(prefix+"preset", "timing preset %q is not built in; "+
+			"timings not set explicitly keep the values of %s", preset, presetName(m.proto))
+	}
+
+	ints := map[string]int{}
+
+	for _, key := range slices.Sorted(maps.Keys(t)) {
+		if _, known := ramulator2TimingKeys[key]; !known &&
+			key != "nBL" && key != "nRC" && key != "rate" && key != "tCK_ps" {
+			continue
+		}
+
+		n, err := yamlInt(take(t, key))
+		if err != nil {
+			return fmt.Errorf("dram: %s%s: %w", prefix, key, err)
+		}
+
+		// Ramulator2 derives the timings set to -1 from its presets.
+		if n < 0 {
+			m.warn(prefix+key, "derived by Ramulator2 from its preset; "+
+				"keeping the value of %s", presetName(m.proto))
+
+			continue
+		}
+
+		ints[key] = n
+	}
+
+	for key, n := range ints {
+		if field, ok := ramulator2TimingKeys[key]; ok {
+			*field(&m.spec) = n
+		}
+	}
+
+	if n, ok := ints["nBL"]; ok {
+		m.spec.BurstLength = n * burstBeatsPerCycle(m.proto)
+	}
+
+	if rate, ok := ints["rate"]; ok {
+		m.spec.Freq = freqOfPeriod(2e6 / float64(rate))
+	}
+
+	if ps, ok := ints["tCK_ps"]; ok && ps > 0 {
+		m.spec.Freq = freqOfPeriod(float64(ps))
+	}
+
+	m.trc = ints["nRC"]
+	m.ignore(prefix, slices.Collect(maps.Keys(t)))
+
+	return nil
+}
+
+// burstBeatsPerCycle is the number of data beats per DRAM clock, the inverse
+// of calculateBurstCycle.
+func burstBeatsPerCycle(p protocol) int {
+	switch p {
+	case protoGDDR5:
+		return 4
+	case protoGDDR5X:
+		return 8
+	case protoGDDR6:
+		return 16
+	default:
+		return 2
+	}
+}
+
+func (m *ramulator2Import) importController(v any) error {
+	const prefix = "MemorySystem.Controller."
+
+	ctrl, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	if impl, ok := take(ctrl, "impl").(string); ok && impl != "Generic" {
+		m.warn(prefix+"impl", "only the Generic controller is modeled; %q ignored", impl)
+	}
+
+	m.importScheduler(prefix+"Scheduler", take(ctrl, "Scheduler"))
+	m.importRefreshManager(prefix+"RefreshManager", take(ctrl, "RefreshManager"))
+	m.importRowPolicy(prefix+"RowPolicy", take(ctrl, "RowPolicy"))
+
+	plugins, _ := take(ctrl, "plugins").([]any)
+	for i, p := range plugins {
+		if err := m.importPlugin(fmt.Sprintf("%splugins[%d]", prefix, i), p); err != nil {
+			return err
+		}
+	}
+
+	m.ignore(prefix, slices.Collect(maps.Keys(ctrl)))
+
+	return nil
+}
+
+func (m *ramulator2Import) importScheduler(key string, v any) {
+	sec, _ := v.(map[string]any)
+	impl, _ := take(sec, "impl").(string)
+
+	switch impl {
+	case "":
+	case "FRFCFS":
+		m.spec.Scheduler = schedulerFRFCFS
+	case "BLISS":
+		m.spec.Scheduler = schedulerBLISS
+	default:
+		m.warn(key+".impl", "scheduler %q has no Akita equivalent; using FRFCFS", impl)
+	}
+
+	m.ignore(key+".", slices.Collect(maps.Keys(sec)))
+}
+
+func (m *ramulator2Import) importRefreshManager(key string, v any) {
+	sec, _ := v.(map[string]any)
+	impl, _ := take(sec, "impl").(string)
+
+	switch impl {
+	case "":
+	case "AllBank":
+		m.spec.RefreshPolicy = RefreshPolicyRankSimultaneous
+	default:
+		m.warn(key+".impl", "refresh manager %q has no Akita equivalent; "+
+			"keeping the preset's refresh policy", impl)
+	}
+
+	m.ignore(key+".", slices.Collect(maps.Keys(sec)))
+}
+
+// importRowPolicy maps the row policies. Ramulator2's closed-row policy may
+// keep a row open for cap accesses; Akita's closes it after every access.
+func (m *ramulator2Import) importRowPolicy(key string, v any) {
+	sec, _ := v.(map[string]any)
+	impl, _ := take(sec, "impl").(string)
+
+	switch impl {
+	case "":
+	case "OpenRowPolicy":
+		m.spec.PagePolicy = PagePolicyOpen
+	case "ClosedRowPolicy":
+		m.spec.PagePolicy = PagePolicyClose
+
+		if c, ok := take(sec, "cap").(int); ok && c != 1 {
+			m.warn(key+".cap", "Akita's close-page policy closes the row after "+
+				"every access; cap %d ignored", c)
+		}
+	default:
+		m.warn(key+".impl", "row policy %q has no Akita equivalent; "+
+			"keeping the preset's page policy", impl)
+	}
+
+	m.ignore(key+".", slices.Collect(maps.Keys(sec)))
+}
+
+// importPlugin maps the PARA and Graphene row-hammer plugins to mitigations.
+// Other plugins, such as the command counter, produce a warning.
+func (m *ramulator2Import) importPlugin(key string, v any) error {
+	wrapper, _ := v.(map[string]any)
+	plugin, _ := wrapper["ControllerPlugin"].(map[string]any)
+	impl, _ := take(plugin, "impl").(string)
+
+	if impl != "PARA" && impl != "Graphene" {
+		m.warn(key, "controller plugin %q has no dram.Spec equivalent; ignored", impl)
+		return nil
+	}
+
+	if m.mitigated {
+		m.warn(key, "Akita runs one row-hammer mitigation; %q ignored", impl)
+		return nil
+	}
+
+	m.mitigated = true
+	s := &m.spec
+	prefix := key + ".ControllerPlugin."
+
+	var err error
+
+	switch impl {
+	case "PARA":
+		s.RowHammerMitigation = rowHammerMitigationPARA
+		s.PARAProbability, err = yamlFloat(take(plugin, "threshold"), s.PARAProbability)
+	case "Graphene":
+		s.RowHammerMitigation = rowHammerMitigationGraphene
+		s.RowHammerTableSize, err = yamlIntOr(take(plugin, "num_table_entries"),
+			s.RowHammerTableSize)
+
+		if err == nil {
+			s.RowHammerMitigationThreshold, err = yamlIntOr(
+				take(plugin, "activation_threshold"), s.RowHammerMitigationThreshold)
+		}
+
+		if err == nil {
+			var ns int
+
+			ns, err = yamlIntOr(take(plugin, "reset_period_ns"), 0)
+			s.RowHammerWindow = int(math.Round(float64(ns) * float64(s.Freq) / 1e9))
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("dram: %s: %w", key, err)
+	}
+
+	m.ignore(prefix, slices.Collect(maps.Keys(plugin)))
+
+	return nil
+}
+
+func (m *ramulator2Import) importAddrMapper(v any) {
+	const key = "MemorySystem.AddrMapper"
+
+	sec, _ := v.(map[string]any)
+	impl, _ := take(sec, "impl").(string)
+
+	if impl != "" {
+		mapping, ok := ramulator2AddrMappers[impl]
+		if ok {
+			m.spec.AddrMapper = mapping
+		} else {
+			m.warn(key+".impl", "address mapper %q has no bit-string equivalent; "+
+				"using Akita's default mapping", impl)
+		}
+	}
+
+	m.ignore(key+".", slices.Collect(maps.Keys(sec)))
+}
+
+// take removes a key from a decoded YAML mapping and returns its value, so the
+// keys left over at the end are the ones the import did not use.
+func take(m map[string]any, key string) any {
+	v, ok := m[key]
+	if !ok {
+		return nil
+	}
+
+	delete(m, key)
+
+	return v
+}
+
+// yamlInt converts a decoded YAML scalar to an integer.
+func yamlInt(v any) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case float64:
+		if n == math.Trunc(n) {
+			return int(n), nil
+		}
+	}
+
+	return 0, fmt.Errorf("%v is not an integer", v)
+}
+
+// yamlIntOr converts a decoded YAML scalar to an integer, or returns def if it
+// is absent.
+func yamlIntOr(v any, def int) (int, error) {
+	if v == nil {
+		return def, nil
+	}
+
+	return yamlInt(v)
+}
+
+// yamlFloat converts a decoded YAML scalar to a number, or returns def if it
+// is absent.
+func yamlFloat(v any, def float64) (float64, error) {
+	switch n := v.(type) {
+	case nil:
+		return def, nil
+	case int:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	}
+
+	return 0, fmt.Errorf("%v is not a number", v)
+}
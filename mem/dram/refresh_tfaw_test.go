@@ -167,61 +167,222 @@ var _ = Describe("tFAW and Refresh", func() {
 		})
 	})
 
-	Describe("Periodic Refresh", func() {
-		BeforeEach(func() {
+	Describe("Refresh Commands", func() {
+		var m *refreshMiddleware
+
+		setup := func(tweak func(s *Spec)) {
 			spec = DDR4Spec
-			spec.TREFI = 20 // small value for testing
-			spec.TRFC = 5
+			spec.TREFI = 100
+			spec.TRFC = 20
+			if tweak != nil {
+				tweak(&spec)
+			}
+
+			b := MakeBuilder().WithSpec(spec)
+			b.normalizeSpec()
+			spec = b.spec
 
+			m = &refreshMiddleware{
+				timing:    b.generateTiming(),
+				cmdCycles: b.buildCmdCycles(),
+			}
 			state = &State{
 				BankStates: initBankStatesFlat(
 					spec.NumRank, spec.NumBankGroup, spec.NumBank),
+				Refresh: initRefreshState(&spec),
+			}
+		}
+
+		tick := func(n int) {
+			for range n {
+				m.runRefresh(&spec, state)
+				tickBanks(state)
+				state.TickCount++
+			}
+		}
+
+		queueCommandFor := func(rank uint64) {
+			state.CommandQueues.Entries = append(state.CommandQueues.Entries,
+				queueEntry{Command: commandState{
+					Kind:     int(cmdKindRead),
+					Location: location{Rank: rank, Row: 7},
+				}})
+		}
+
+		It("should precharge open banks and issue REFab after tREFI", func() {
+			setup(nil)
+
+			open := findBankState(&state.BankStates, 0, 1, 2)
+			open.State = int(bankStateOpen)
+			open.OpenRow = 5
+
+			tick(99)
+			Expect(state.TotalRefreshes).To(BeZero())
+
+			tick(1)
+			Expect(state.TotalPrecharges).To(Equal(uint64(1)))
+			Expect(bankStateKind(open.State)).To(Equal(bankStateClosed))
+			Expect(open.RefreshPending).To(BeTrue())
+
+			tick(spec.TRP)
+			Expect(state.TotalRefreshes).To(Equal(uint64(1)))
+			Expect(open.RefreshPending).To(BeFalse())
+
+			// Every bank of the rank is held closed for tRFC.
+			for _, e := range state.BankStates.Entries {
+				Expect(e.Data.CyclesToCmdAvailable[cmdKindActivate]).
+					To(BeNumerically(">=", spec.TRFC-1))
 			}
 		})
 
-		It("should trigger refresh after TREFI ticks and stall for TRFC", func() {
-			// Simulate the countdown
-			Expect(state.RefreshInProgress).To(BeFalse())
+		It("should stagger all-bank refresh across ranks", func() {
+			setup(func(s *Spec) { s.NumRank = 2 })
 
-			// After TREFI ticks, refresh should trigger
-			for i := range spec.TREFI {
-				_ = i
-				progress := runFakeStallRefresh(&spec, state)
-				if i < spec.TREFI-1 {
-					Expect(state.RefreshInProgress).To(BeFalse())
-				}
-				_ = progress
-			}
+			tick(50)
+			Expect(state.TotalRefreshes).To(Equal(uint64(1)))
+			Expect(findBankState(&state.BankStates, 1, 0, 0).
+				CyclesToCmdAvailable[cmdKindActivate]).To(BeZero())
 
-			Expect(state.RefreshInProgress).To(BeTrue())
-			Expect(state.RefreshCyclesRemaining).To(Equal(spec.TRFC))
-			Expect(state.RefreshCycleCounter).To(Equal(0))
+			tick(50)
+			Expect(state.TotalRefreshes).To(Equal(uint64(2)))
 		})
 
-		It("should complete refresh after TRFC cycles", func() {
-			// Set up state as if refresh just triggered
-			state.RefreshInProgress = true
-			state.RefreshCyclesRemaining = spec.TRFC
-			state.RefreshCycleCounter = 0
+		It("should refresh every rank at once when simultaneous", func() {
+			setup(func(s *Spec) {
+				s.NumRank = 2
+				s.RefreshPolicy = RefreshPolicyRankSimultaneous
+			})
 
-			// Tick TRFC times
-			for range spec.TRFC {
-				Expect(state.RefreshInProgress).To(BeTrue())
-				runFakeStallRefresh(&spec, state)
-			}
+			tick(99)
+			Expect(state.TotalRefreshes).To(BeZero())
 
-			// After TRFC ticks, refresh should be complete
-			Expect(state.RefreshInProgress).To(BeFalse())
+			tick(2)
+			Expect(state.TotalRefreshes).To(Equal(uint64(2)))
 		})
 
-		It("should not trigger refresh when TREFI is 0", func() {
-			spec.TREFI = 0
+		It("should refresh one bank at a time with REFpb", func() {
+			setup(func(s *Spec) {
+				s.RefreshPolicy = RefreshPolicyBankStaggered
+				s.TRFCb = 10
+			})
+
+			// 16 banks share the interval, so the first falls due at 6.
+			tick(6)
+			Expect(state.TotalBankRefreshes).To(Equal(uint64(1)))
+			Expect(findBankState(&state.BankStates, 0, 0, 0).
+				CyclesToCmdAvailable[cmdKindActivate]).To(Equal(spec.TRFCb - 1))
+			Expect(findBankState(&state.BankStates, 0, 0, 1).
+				CyclesToCmdAvailable[cmdKindActivate]).To(Equal(spec.TRRDS - 1))
+
+			tick(94)
+			Expect(state.TotalBankRefreshes).To(Equal(uint64(16)))
+			Expect(state.TotalRefreshes).To(BeZero())
+		})
 
-			for range 100 {
-				runFakeStallRefresh(&spec, state)
+		It("should refresh a bank in every bank group with REFsb", func() {
+			setup(func(s *Spec) {
+				s.RefreshPolicy = RefreshPolicySameBank
+				s.TRFCb = 10
+			})
+
+			tick(25)
+			Expect(state.TotalBankRefreshes).To(Equal(uint64(1)))
+			for bg := range spec.NumBankGroup {
+				Expect(findBankState(&state.BankStates, 0, bg, 0).
+					CyclesToCmdAvailable[cmdKindActivate]).To(Equal(spec.TRFCb - 1))
 			}
+		})
+
+		It("should settle the refreshes that fall due while the controller sleeps", func() {
+			setup(nil)
+
+			open := findBankState(&state.BankStates, 0, 1, 2)
+			open.State = int(bankStateOpen)
+
+			// Nine refreshes fall due in the first 1000 cycles, each long
+			// enough before the end to complete.
+			catchUpRefresh(&spec, state, nil, 1000)
+			Expect(state.TotalRefreshes).To(Equal(uint64(9)))
+			Expect(state.TotalPrecharges).To(Equal(uint64(1)))
+			Expect(bankStateKind(open.State)).To(Equal(bankStateClosed))
+			Expect(open.CyclesToCmdAvailable[cmdKindActivate]).To(BeZero())
+			Expect(state.Refresh.Targets[0].Owed).To(BeZero())
+
+			// The tenth falls due 5 cycles before the controller wakes, too
+			// late to complete, so it is owed and issues on the next tick.
+			catchUpRefresh(&spec, state, nil, 1105)
+			Expect(state.TotalRefreshes).To(Equal(uint64(10)))
+			Expect(state.Refresh.Targets[0].Owed).To(Equal(1))
+
+			tick(1)
+			Expect(state.TotalRefreshes).To(Equal(uint64(11)))
+			Expect(state.Refresh.Targets[0].Owed).To(BeZero())
+		})
+
+		It("should refresh an idle controller once per tREFI", func() {
+			h := newP0Harness(DefaultSpec())
+			spec = h.dram.Spec()
+			h.readAcrossIdle(25 * spec.TREFI)
+
+			// Every rank refreshes once per tREFI, asleep or not.
+			ranks := numRanks(&spec)
+			Expect(h.dram.State.TotalRefreshes).
+				To(BeNumerically("~", 25*ranks, ranks))
+		})
+
+		It("should postpone refresh while commands wait, up to the limit", func() {
+			setup(func(s *Spec) { s.RefreshMaxPostpone = 2 })
+			queueCommandFor(0)
+
+			tick(250)
+			Expect(state.TotalRefreshes).To(BeZero())
+			Expect(state.PostponedRefreshes).To(Equal(uint64(2)))
+			Expect(state.Refresh.Targets[0].Owed).To(Equal(2))
+
+			tick(60)
+			Expect(state.TotalRefreshes).To(Equal(uint64(1)))
+			Expect(state.RefreshStallCycles).NotTo(BeZero())
+			Expect(state.Refresh.Targets[0].Stalled).To(BeTrue())
+		})
+
+		It("should catch up on postponed refreshes once idle", func() {
+			setup(func(s *Spec) { s.RefreshMaxPostpone = 2 })
+			queueCommandFor(0)
+
+			tick(250)
+			state.CommandQueues.Entries = nil
+
+			tick(45)
+			Expect(state.TotalRefreshes).To(Equal(uint64(2)))
+			Expect(state.Refresh.Targets[0].Owed).To(BeZero())
+		})
+
+		It("should pull refreshes in while idle, up to the limit", func() {
+			setup(func(s *Spec) { s.RefreshMaxPullIn = 2 })
+
+			tick(60)
+			Expect(state.TotalRefreshes).To(Equal(uint64(2)))
+			Expect(state.PulledInRefreshes).To(Equal(uint64(2)))
+			Expect(state.Refresh.Targets[0].Owed).To(Equal(-2))
+
+			tick(60)
+			Expect(state.TotalRefreshes).To(Equal(uint64(3)))
+		})
+
+		It("should not refresh when TREFI is 0", func() {
+			setup(func(s *Spec) { s.TREFI = 0 })
+
+			tick(1000)
+			Expect(state.Refresh.Targets).To(BeEmpty())
+			Expect(state.TotalRefreshes).To(BeZero())
+		})
 
-			Expect(state.RefreshInProgress).To(BeFalse())
+		It("should reject infeasible refresh configurations", func() {
+			Expect(func() { setup(func(s *Spec) { s.TRFC = 100 }) }).To(Panic())
+			Expect(func() {
+				setup(func(s *Spec) { s.RefreshPolicy = RefreshPolicyBankStaggered })
+			}).To(Panic())
+			Expect(func() { setup(func(s *Spec) { s.RefreshMaxPostpone = 9 }) }).To(Panic())
 		})
 	})
 })
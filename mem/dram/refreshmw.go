@@ -5,55 +5,461 @@ import (
 	"github.com/sarchlab/akita/v5/modeling"
 )
 
-// refreshMiddleware models refresh as a reactive per-cycle behavior. Following
-// Akita convention, a controller behavior that runs every cycle and mutates
-// State is a Middleware (not a bespoke plugin): the builder adds it ahead of the
-// bank-tick middleware, and it communicates with the issue step through
-// State.RefreshInProgress (the stall flag).
+// refreshMiddleware issues refresh as real commands. Following Akita
+// convention, a controller behavior that runs every cycle and mutates State is
+// a Middleware (not a bespoke plugin): the builder adds it ahead of the
+// bank-tick middleware, and it communicates with the issue step through State.
 //
-// Today it implements the fake global tRFC stall (deviation D2). Real refresh
-// (P2) is a different refresh middleware selected by config; the bank-tick
-// issue step does not change — it just honors the stall flag.
+// Every refresh target (see refreshTarget) falls due once per tREFI, the
+// targets' schedules staggered over the interval unless the policy refreshes
+// every rank at once, and the interval halved per thermal refresh threshold
+// the rank's temperature exceeds (see thermal.go). A due refresh holds off new
+// commands to the target's banks, precharges the open ones, and issues REFab
+// or REFpb, after which the timing table keeps the banks closed for tRFC or
+// tRFCb. A refresh that falls due while commands are queued for the target
+// may be postponed, up to Spec.RefreshMaxPostpone times, and an idle,
+// precharged target may be refreshed up to Spec.RefreshMaxPullIn times ahead
+// of its schedule.
+//
+// The schedule runs on simulated time. An idle controller stops ticking, so
+// when it ticks again, the schedule first catches up on the cycles it slept
+// (see catchUpRefresh): the refreshes that fell due and would have completed
+// while it slept are counted and charged as issued then, and the ones still in
+// progress are owed.
 type refreshMiddleware struct {
-	comp *modeling.Component[Spec, State, Resources]
+	comp      *modeling.Component[Spec, State, Resources]
+	timing    dramTiming
+	cmdCycles map[commandKind]int
+	energy    *energyModel
+
+	// demand is the number of queued commands per refresh target, recounted
+	// every cycle.
+	demand []int
 }
 
-// Tick advances the refresh schedule by one cycle. Paused DRAM freezes it, so
-// the refresh phase does not drift while the controller is suspended.
+// Tick catches the refresh schedule up on the cycles since the last tick,
+// advances it by the current cycle, and issues at most one refresh or
+// precharge command per command bus. It runs first in the cycle, so it also
+// frees the command buses for the cycle. Paused DRAM freezes it, so the
+// refresh phase does not drift while the controller is suspended.
 func (m *refreshMiddleware) Tick() bool {
 	next := &m.comp.State
+
 	if next.ControlState == memcontrolprotocol.StatePaused {
 		return false
 	}
+
 	spec := m.comp.Spec()
-	return runFakeStallRefresh(&spec, next)
+	now := spec.Freq.Cycle(m.comp.CurrentTime())
+
+	catchUpRefresh(&spec, next, m.energy, now)
+	next.Refresh.Cycle = now
+
+	return m.runRefresh(&spec, next)
 }
 
-// runFakeStallRefresh implements periodic refresh scheduling: it stalls command
-// issuance for tRFC cycles every tREFI interval by holding State.RefreshInProgress,
-// without issuing real refresh commands or closing rows (deviation D2).
-func runFakeStallRefresh(spec *Spec, next *State) bool {
-	if spec.TREFI <= 0 {
+// catchUpRefresh counts the schedule down over the cycles from the last one
+// counted up to, but not including, a cycle, during which the controller did
+// not tick. Every refresh that fell due in them and would have completed by
+// the cycle, precharge included, is recorded as issued while the controller
+// slept: it is counted and charged, and closes its banks, but leaves no timing
+// constraint behind. The others are owed. Paused DRAM counts nothing.
+func catchUpRefresh(spec *Spec, state *State, energy *energyModel, cycle uint64) {
+	rs := &state.Refresh
+
+	if state.ControlState == memcontrolprotocol.StatePaused ||
+		cycle <= rs.Cycle+1 {
+		return
+	}
+
+	elapsed := int(cycle - 1 - rs.Cycle)
+	rs.Cycle = cycle - 1
+
+	kind := refreshCommandKind(spec)
+	settle := spec.TRP + spec.TRFC
+	if kind == cmdKindRefreshBank {
+		settle = spec.TRP + spec.TRFCb
+	}
+
+	for i := range rs.Targets {
+		t := &rs.Targets[i]
+
+		t.Countdown -= elapsed
+		for t.Countdown <= 0 {
+			dueFor := 1 - t.Countdown
+			t.Countdown += refreshInterval(spec, state, t.Rank)
+
+			if rankLowPowerState(state, t.Rank) == bankStateSRef {
+				continue
+			}
+
+			t.Owed++
+			if t.Owed > 0 && !t.Pending && dueFor >= settle {
+				settleRefresh(state, energy, t, kind)
+			}
+		}
+	}
+}
+
+// settleRefresh records a refresh, and the precharges of the banks it closes,
+// as issued while the controller slept.
+func settleRefresh(state *State, energy *energyModel, t *refreshTarget, kind commandKind) {
+	record := func(bs *bankState, kind commandKind, loc location) {
+		cmd := &commandState{Kind: int(kind), Location: loc}
+		startCommand(nil, state, bs, cmd)
+
+		if energy != nil {
+			chargeCommand(energy, state, cmd)
+		}
+	}
+
+	first, n, stride := refreshTargetBanks(&state.BankStates, t)
+	for k := range n {
+		e := &state.BankStates.Entries[first+k*stride]
+		if bankStateKind(e.Data.State) == bankStateOpen {
+			record(&e.Data, cmdKindPrecharge, bankLocation(e))
+		}
+	}
+
+	forRefreshCommands(state, t, kind, record)
+	t.Owed--
+}
+
+func (m *refreshMiddleware) runRefresh(spec *Spec, next *State) bool {
+	clear(next.CommandBusBusy)
+
+	targets := next.Refresh.Targets
+	if len(targets) == 0 {
 		return false
 	}
 
-	// If refresh is in progress, count down.
-	if next.RefreshInProgress {
-		next.RefreshCyclesRemaining--
-		if next.RefreshCyclesRemaining <= 0 {
-			next.RefreshInProgress = false
+	m.countDemand(spec, next)
+	m.advanceSchedule(spec, next)
+
+	progress := false
+	pending := false
+
+	for i := range targets {
+		if !targets[i].Pending && m.shouldStart(spec, next, i) {
+			setRefreshPending(next, &targets[i], true)
+			progress = true
 		}
-		return true
+
+		pending = pending || targets[i].Pending
 	}
 
-	// Countdown to next refresh.
-	next.RefreshCycleCounter++
-	if next.RefreshCycleCounter >= spec.TREFI {
-		next.RefreshInProgress = true
-		next.RefreshCyclesRemaining = spec.TRFC
-		next.RefreshCycleCounter = 0
+	for i := range targets {
+		ch := rankChannel(&next.BankStates, targets[i].Rank)
+		if !targets[i].Pending || commandBusBusy(next, ch) {
+			continue
+		}
+
+		if m.issueFor(spec, next, i) {
+			useCommandBus(next, ch)
+			progress = true
+		}
+	}
+
+	// A pending refresh waits for timing gaps to drain; keep ticking until it
+	// issues.
+	return progress || pending
+}
+
+// countDemand counts the queued commands of each refresh target.
+func (m *refreshMiddleware) countDemand(spec *Spec, next *State) {
+	if len(m.demand) != len(next.Refresh.Targets) {
+		m.demand = make([]int, len(next.Refresh.Targets))
+	}
+
+	clear(m.demand)
+
+	for i := range next.CommandQueues.Entries {
+		idx := refreshTargetIndex(spec, &next.BankStates,
+			next.CommandQueues.Entries[i].Command.Location)
+		if idx < len(m.demand) {
+			m.demand[idx]++
+		}
+	}
+}
+
+// advanceSchedule counts down every target's interval, records the refreshes
+// that fall due, and marks the targets that hold off queued commands.
+func (m *refreshMiddleware) advanceSchedule(spec *Spec, next *State) {
+	stalled := false
+
+	for i := range next.Refresh.Targets {
+		t := &next.Refresh.Targets[i]
+
+		t.Countdown--
+		if t.Countdown <= 0 {
+			t.Countdown += refreshInterval(spec, next, t.Rank)
+
+			// A rank in self-refresh refreshes itself.
+			if rankLowPowerState(next, t.Rank) != bankStateSRef {
+				t.Owed++
+
+				if m.demand[i] > 0 && t.Owed <= spec.RefreshMaxPostpone {
+					next.PostponedRefreshes++
+				}
+			}
+		}
+
+		if m.demand[i] > 0 && (t.Pending || t.BusyUntil > next.TickCount) {
+			t.Stalled = true
+			stalled = true
+		}
+	}
+
+	if stalled {
+		next.RefreshStallCycles++
+	}
+}
+
+// shouldStart decides whether a target starts refreshing: when it cannot
+// postpone any further, when a refresh is due and no command waits for it,
+// or, to pull a refresh in, when the target is idle and precharged.
+func (m *refreshMiddleware) shouldStart(spec *Spec, next *State, i int) bool {
+	t := &next.Refresh.Targets[i]
+	idle := m.demand[i] == 0
+
+	switch {
+	case t.Owed > spec.RefreshMaxPostpone:
 		return true
+	case t.Owed > 0:
+		return idle
+	default:
+		return idle && -t.Owed < spec.RefreshMaxPullIn &&
+			t.BusyUntil <= next.TickCount && refreshTargetClosed(next, t)
+	}
+}
+
+// issueFor issues the next command a pending target needs: a precharge for
+// one of its open banks, or the refresh command once every bank is closed and
+// the timing table allows it. It returns false if neither can issue yet, or
+// while the target's rank is in power-down or self-refresh (the low-power
+// middleware wakes it).
+func (m *refreshMiddleware) issueFor(spec *Spec, next *State, i int) bool {
+	t := &next.Refresh.Targets[i]
+	kind := refreshCommandKind(spec)
+	ready := true
+
+	switch rankLowPowerState(next, t.Rank) {
+	case bankStatePD, bankStateSRef:
+		return false
 	}
 
-	return false
+	first, n, stride := refreshTargetBanks(&next.BankStates, t)
+	for k := range n {
+		e := &next.BankStates.Entries[first+k*stride]
+		bs := &e.Data
+
+		if bankStateKind(bs.State) == bankStateOpen {
+			ready = false
+
+			if bs.CyclesToCmdAvailable[cmdKindPrecharge] == 0 {
+				m.issue(next, bs, cmdKindPrecharge, bankLocation(e))
+				return true
+			}
+
+			continue
+		}
+
+		if bs.CyclesToCmdAvailable[kind] > 0 {
+			ready = false
+		}
+	}
+
+	if !ready {
+		return false
+	}
+
+	m.issueRefresh(spec, next, t, kind)
+
+	return true
+}
+
+func (m *refreshMiddleware) issueRefresh(
+	spec *Spec, next *State, t *refreshTarget, kind commandKind,
+) {
+	forRefreshCommands(next, t, kind, func(bs *bankState, kind commandKind, loc location) {
+		m.issue(next, bs, kind, loc)
+	})
+
+	if kind == cmdKindRefresh {
+		t.BusyUntil = next.TickCount + uint64(spec.TRFC)
+	} else {
+		t.BusyUntil = next.TickCount + uint64(spec.TRFCb)
+	}
+
+	if t.Owed <= 0 {
+		next.PulledInRefreshes++
+	}
+
+	t.Owed--
+	setRefreshPending(next, t, false)
+}
+
+// forRefreshCommands passes the refresh commands of a target to issue, and
+// counts the refresh.
+func forRefreshCommands(
+	state *State, t *refreshTarget, kind commandKind,
+	issue func(bs *bankState, kind commandKind, loc location),
+) {
+	if kind == cmdKindRefresh {
+		first := &state.BankStates.Entries[bankFlatIndex(&state.BankStates, t.Rank, 0, 0)]
+		loc := bankLocation(first)
+		loc.BankGroup, loc.Bank = 0, 0
+		issue(&first.Data, kind, loc)
+		state.TotalRefreshes++
+
+		return
+	}
+
+	// A same-bank refresh is one command that refreshes a bank in every bank
+	// group, so each of them gets the per-bank timing.
+	first, n, stride := refreshTargetBanks(&state.BankStates, t)
+	for k := range n {
+		e := &state.BankStates.Entries[first+k*stride]
+		issue(&e.Data, kind, bankLocation(e))
+	}
+
+	state.TotalBankRefreshes++
+}
+
+func (m *refreshMiddleware) issue(
+	next *State, bs *bankState, kind commandKind, loc location,
+) {
+	cmd := &commandState{Kind: int(kind), Location: loc}
+	startCommand(m.cmdCycles, next, bs, cmd)
+	updateTiming(m.timing, next, cmd)
+
+	if m.energy != nil {
+		chargeCommand(m.energy, next, cmd)
+	}
+}
+
+// initRefreshState creates the refresh targets of the spec's policy, with
+// their first refresh staggered over tREFI unless the policy refreshes every
+// rank at once. Targets are per rank index, so every channel and
+// pseudo-channel refreshes its own ranks. Refresh is off if TREFI is 0.
+func initRefreshState(spec *Spec) refreshState {
+	rs := refreshState{Targets: []refreshTarget{}}
+	if spec.TREFI <= 0 {
+		return rs
+	}
+
+	for r := range numRanks(spec) {
+		switch spec.RefreshPolicy {
+		case RefreshPolicyBankStaggered:
+			for bg := range spec.NumBankGroup {
+				for b := range spec.NumBank {
+					rs.Targets = append(rs.Targets,
+						refreshTarget{Rank: r, BankGroup: bg, Bank: b})
+				}
+			}
+		case RefreshPolicySameBank:
+			for b := range spec.NumBank {
+				rs.Targets = append(rs.Targets,
+					refreshTarget{Rank: r, BankGroup: -1, Bank: b})
+			}
+		default:
+			rs.Targets = append(rs.Targets,
+				refreshTarget{Rank: r, BankGroup: -1, Bank: -1})
+		}
+	}
+
+	n := len(rs.Targets)
+	for i := range rs.Targets {
+		rs.Targets[i].Countdown = spec.TREFI
+		if spec.RefreshPolicy != RefreshPolicyRankSimultaneous {
+			rs.Targets[i].Countdown = (i + 1) * spec.TREFI / n
+		}
+	}
+
+	return rs
+}
+
+// refreshTargetIndex returns the index of the refresh target that covers a
+// location, in the order initRefreshState creates the targets.
+func refreshTargetIndex(spec *Spec, flat *bankStatesFlat, loc location) int {
+	rank := rankIndex(flat, loc)
+
+	switch spec.RefreshPolicy {
+	case RefreshPolicyBankStaggered:
+		return (rank*spec.NumBankGroup+int(loc.BankGroup))*spec.NumBank +
+			int(loc.Bank)
+	case RefreshPolicySameBank:
+		return rank*spec.NumBank + int(loc.Bank)
+	default:
+		return rank
+	}
+}
+
+// findRefreshTarget returns the refresh target that covers a location, or nil if
+// refresh is off.
+func findRefreshTarget(spec *Spec, state *State, loc location) *refreshTarget {
+	idx := refreshTargetIndex(spec, &state.BankStates, loc)
+	if idx < 0 || idx >= len(state.Refresh.Targets) {
+		return nil
+	}
+
+	return &state.Refresh.Targets[idx]
+}
+
+// refreshCommandKind returns the refresh command the policy issues.
+func refreshCommandKind(spec *Spec) commandKind {
+	switch spec.RefreshPolicy {
+	case RefreshPolicyBankStaggered, RefreshPolicySameBank:
+		return cmdKindRefreshBank
+	default:
+		return cmdKindRefresh
+	}
+}
+
+// refreshTargetBanks returns the flat index of the first bank a target
+// covers, the number of banks it covers, and the index stride between them.
+func refreshTargetBanks(flat *bankStatesFlat, t *refreshTarget) (first, n, stride int) {
+	switch {
+	case t.BankGroup >= 0:
+		return bankFlatIndex(flat, t.Rank, t.BankGroup, t.Bank), 1, 1
+	case t.Bank >= 0:
+		return bankFlatIndex(flat, t.Rank, 0, t.Bank), flat.NumBankGroups, flat.NumBanks
+	default:
+		return bankFlatIndex(flat, t.Rank, 0, 0), flat.NumBankGroups * flat.NumBanks, 1
+	}
+}
+
+// refreshTargetClosed returns true if every bank of the target is closed.
+func refreshTargetClosed(state *State, t *refreshTarget) bool {
+	first, n, stride := refreshTargetBanks(&state.BankStates, t)
+	for k := range n {
+		idx := first + k*stride
+		if bankStateKind(state.BankStates.Entries[idx].Data.State) != bankStateClosed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setRefreshPending marks a target, and the banks it covers, as held for
+// refresh or released.
+func setRefreshPending(state *State, t *refreshTarget, pending bool) {
+	t.Pending = pending
+
+	first, n, stride := refreshTargetBanks(&state.BankStates, t)
+	for k := range n {
+		state.BankStates.Entries[first+k*stride].Data.RefreshPending = pending
+	}
+}
+
+// bankLocation returns the location of a bank.
+func bankLocation(e *bankEntry) location {
+	return location{
+		Channel:       uint64(e.Channel),
+		PseudoChannel: uint64(e.PseudoChannel),
+		Rank:          uint64(e.Rank),
+		BankGroup:     uint64(e.BankGroup),
+		Bank:          uint64(e.BankIndex),
+	}
 }
@@ -0,0 +1,817 @@
+package dram
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sarchlab/akita/v5/mem/memcontrolprotocol"
+	"github.com/sarchlab/akita/v5/modeling"
+)
+
+// Row-hammer modeling is off unless the spec selects a mitigation or sets
+// RowHammerThreshold. When on, the controller counts the activations of every
+// row and runs the mitigation, which observes the issued activations and
+// refreshes.
+// A mitigation acts through real commands, which the row-hammer middleware
+// issues like refresh: it holds off the banks it mitigates, precharges them,
+// and issues the command once the timing table allows it.
+//
+//   - "PARA" refreshes the neighbors of an activated row with probability
+//     PARAProbability (VRR).
+//   - "Graphene" tracks the most activated rows of each bank with
+//     RowHammerTableSize Misra-Gries counters and refreshes the neighbors of a
+//     row every RowHammerMitigationThreshold activations (VRR).
+//   - "TRR" keeps the same counters, but refreshes the neighbors of a bank's
+//     most activated row when the bank is refreshed, if that row was activated
+//     RowHammerMitigationThreshold times.
+//   - "RFM" counts the activations of each bank and issues a refresh
+//     management command (RFMpb) every RFMThreshold of them. A refresh of the
+//     bank takes RFMThreshold off the count. The DRAM mitigates the bank's most
+//     activated row.
+//   - "PRAC" raises an alert when a row's count reaches
+//     RowHammerMitigationThreshold. The controller keeps issuing for tABO_ACT,
+//     then issues PRACBackOffRFMs RFMab to the rank, and the rank raises no
+//     alert for PRACBackOffDelay cycles after them.
+//
+// A VRR clears the count of its row, whose neighbors it refreshes, and an RFM
+// clears the count of the most activated row of each of its banks. Every
+// count is cleared each RowHammerWindow cycles, as every row is refreshed.
+
+// A list of row-hammer mitigation names.
+const (
+	rowHammerMitigationNone     = "none"
+	rowHammerMitigationPARA     = "PARA"
+	rowHammerMitigationGraphene = "Graphene"
+	rowHammerMitigationTRR      = "TRR"
+	rowHammerMitigationRFM      = "RFM"
+	rowHammerMitigationPRAC     = "PRAC"
+)
+
+// Default row-hammer parameters.
+const (
+	defaultRowHammerThreshold   = 4800
+	defaultRowHammerBlastRadius = 1
+	defaultRowHammerTableSize   = 32
+	defaultPARAProbability      = 0.001
+	defaultRFMThreshold         = 80
+	defaultPRACBackOffRFMs      = 1
+	defaultRowHammerRefreshes   = 8192
+	defaultRowHammerSeed        = 0x9e3779b97f4a7c15
+
+	// defaultTABOACT is the time a rank keeps taking activations after a PRAC
+	// alert, in ps.
+	defaultTABOACT = 180000
+)
+
+// observedCommand is an issued activation or refresh the row-hammer
+// middleware has not counted yet.
+type observedCommand struct {
+	Kind     int      `json:"kind"`
+	Location location `json:"location"`
+}
+
+// mitigationRequest is a mitigation command waiting to issue. Row is the row
+// whose neighbors a VRR refreshes, and BankGroup and Bank are ignored for an
+// all-bank RFM.
+type mitigationRequest struct {
+	Kind      int    `json:"kind"`
+	Rank      int    `json:"rank"`
+	BankGroup int    `json:"bank_group"`
+	Bank      int    `json:"bank"`
+	Row       uint64 `json:"row"`
+}
+
+// pracPhase is the alert back-off phase of a rank.
+type pracPhase int
+
+// A list of PRAC alert back-off phases.
+const (
+	pracNormal pracPhase = iota
+	pracPreRecovery
+	pracRecovery
+	pracDelay
+)
+
+// pracBackOff is the alert back-off state of a rank. Until is when the
+// pre-recovery or delay phase ends.
+type pracBackOff struct {
+	Phase int    `json:"phase"`
+	Until uint64 `json:"until"`
+}
+
+// rowHammerState is the activation counts and the mitigations' state. Per-bank
+// slices are in bankFlatIndex order.
+type rowHammerState struct {
+	// Enabled tells whether row-hammer modeling is on. When off, the other
+	// fields stay empty and nothing is observed.
+	Enabled bool `json:"enabled"`
+
+	// Observed is the activations and refreshes issued since the row-hammer
+	// middleware last ran.
+	Observed []observedCommand `json:"observed"`
+
+	// Rows counts each row's activations since its neighbors were refreshed.
+	Rows []map[uint64]uint64 `json:"rows"`
+	// NextWindow is the tick at which every count is cleared, or 0 if never.
+	NextWindow uint64 `json:"next_window"`
+
+	// Pending is the mitigation commands waiting to issue, oldest first.
+	Pending []mitigationRequest `json:"pending"`
+	// BusyUntil is the tick at which each bank's last mitigation completes.
+	BusyUntil []uint64 `json:"busy_until"`
+
+	// Random is the state of PARA's random number generator.
+	Random uint64 `json:"random"`
+
+	// Tables and Spillover are the Misra-Gries counters of each bank
+	// (Graphene, TRR).
+	Tables    []map[uint64]uint64 `json:"tables"`
+	Spillover []uint64            `json:"spillover"`
+
+	// RAA is the rolling activation count of each bank (RFM).
+	RAA []int `json:"raa"`
+
+	// BackOff is the alert back-off state of each rank (PRAC).
+	BackOff []pracBackOff `json:"back_off"`
+}
+
+// initRowHammerState creates empty activation counts and mitigation state for
+// every bank of a spec, or an empty, disabled state if the spec does not model
+// row hammer.
+func initRowHammerState(spec *Spec) rowHammerState {
+	if !rowHammerEnabled(spec) {
+		return rowHammerState{
+			Observed: []observedCommand{},
+			Pending:  []mitigationRequest{},
+		}
+	}
+
+	banks := numRanks(spec) * spec.NumBankGroup * spec.NumBank
+
+	rh := rowHammerState{
+		Enabled:   true,
+		Observed:  []observedCommand{},
+		Rows:      make([]map[uint64]uint64, banks),
+		Pending:   []mitigationRequest{},
+		BusyUntil: make([]uint64, banks),
+		Random:    spec.RowHammerSeed,
+		Tables:    make([]map[uint64]uint64, banks),
+		Spillover: make([]uint64, banks),
+		RAA:       make([]int, banks),
+		BackOff:   make([]pracBackOff, numRanks(spec)),
+	}
+
+	for i := range banks {
+		rh.Rows[i] = map[uint64]uint64{}
+		rh.Tables[i] = map[uint64]uint64{}
+	}
+
+	if spec.RowHammerWindow > 0 {
+		rh.NextWindow = uint64(spec.RowHammerWindow)
+	}
+
+	return rh
+}
+
+// rowHammerEnabled tells whether a normalized spec models row hammer.
+func rowHammerEnabled(spec *Spec) bool {
+	return spec.RowHammerThreshold > 0
+}
+
+// rowHammerMitigation is a row-hammer mitigation. It keeps its state in
+// State.RowHammer and acts by requesting mitigation commands.
+type rowHammerMitigation interface {
+	Name() string
+
+	// Observe is shown every issued activation and refresh, after the
+	// activated row is counted.
+	Observe(spec *Spec, st *State, cmd observedCommand)
+
+	// Tick runs once per cycle and returns true while the mitigation waits
+	// for a timer.
+	Tick(spec *Spec, st *State) bool
+}
+
+var rowHammerMitigationRegistry = map[string]func() rowHammerMitigation{
+	rowHammerMitigationNone:     func() rowHammerMitigation { return noMitigation{} },
+	rowHammerMitigationPARA:     func() rowHammerMitigation { return paraMitigation{} },
+	rowHammerMitigationGraphene: func() rowHammerMitigation { return grapheneMitigation{} },
+	rowHammerMitigationTRR:      func() rowHammerMitigation { return trrMitigation{} },
+	rowHammerMitigationRFM:      func() rowHammerMitigation { return rfmMitigation{} },
+	rowHammerMitigationPRAC:     func() rowHammerMitigation { return pracMitigation{} },
+}
+
+func newRowHammerMitigation(name string) rowHammerMitigation {
+	if name == "" {
+		name = rowHammerMitigationNone
+	}
+	factory, ok := rowHammerMitigationRegistry[name]
+	if !ok {
+		panic(fmt.Sprintf("dram: unknown row-hammer mitigation %q", name))
+	}
+	return factory()
+}
+
+// noMitigation only counts the activations.
+type noMitigation struct{}
+
+func (noMitigation) Name() string { return rowHammerMitigationNone }
+
+func (noMitigation) Observe(*Spec, *State, observedCommand) {}
+
+func (noMitigation) Tick(*Spec, *State) bool { return false }
+
+// paraMitigation is PARA (probabilistic adjacent row activation).
+type paraMitigation struct{ noMitigation }
+
+func (paraMitigation) Name() string { return rowHammerMitigationPARA }
+
+func (paraMitigation) Observe(spec *Spec, st *State, cmd observedCommand) {
+	if commandKind(cmd.Kind) != cmdKindActivate {
+		return
+	}
+
+	if nextRandom(&st.RowHammer) < spec.PARAProbability {
+		requestVictimRefresh(st, cmd.Location)
+	}
+}
+
+// grapheneMitigation is Graphene.
+type grapheneMitigation struct{ noMitigation }
+
+func (grapheneMitigation) Name() string { return rowHammerMitigationGraphene }
+
+func (grapheneMitigation) Observe(spec *Spec, st *State, cmd observedCommand) {
+	if commandKind(cmd.Kind) != cmdKindActivate {
+		return
+	}
+
+	count := countInTable(spec, &st.RowHammer, bankIndex(&st.BankStates, cmd.Location),
+		cmd.Location.Row)
+	if count%uint64(spec.RowHammerMitigationThreshold) == 0 {
+		requestVictimRefresh(st, cmd.Location)
+	}
+}
+
+// trrMitigation is counter-based target row refresh.
+type trrMitigation struct{ noMitigation }
+
+func (trrMitigation) Name() string { return rowHammerMitigationTRR }
+
+func (trrMitigation) Observe(spec *Spec, st *State, cmd observedCommand) {
+	rh := &st.RowHammer
+
+	if commandKind(cmd.Kind) == cmdKindActivate {
+		countInTable(spec, rh, bankIndex(&st.BankStates, cmd.Location), cmd.Location.Row)
+		return
+	}
+
+	forRefreshedBanks(st, cmd, func(idx int, e *bankEntry) {
+		row, count := hottestRow(rh.Tables[idx])
+		if count < uint64(spec.RowHammerMitigationThreshold) {
+			return
+		}
+
+		delete(rh.Tables[idx], row)
+
+		loc := bankLocation(e)
+		loc.Row = row
+		requestVictimRefresh(st, loc)
+	})
+}
+
+// rfmMitigation issues refresh management commands by a rolling activation
+// count.
+type rfmMitigation struct{ noMitigation }
+
+func (rfmMitigation) Name() string { return rowHammerMitigationRFM }
+
+func (rfmMitigation) Observe(spec *Spec, st *State, cmd observedCommand) {
+	rh := &st.RowHammer
+
+	if commandKind(cmd.Kind) != cmdKindActivate {
+		forRefreshedBanks(st, cmd, func(idx int, _ *bankEntry) {
+			rh.RAA[idx] = max(rh.RAA[idx]-spec.RFMThreshold, 0)
+		})
+
+		return
+	}
+
+	idx := bankIndex(&st.BankStates, cmd.Location)
+
+	rh.RAA[idx]++
+	if rh.RAA[idx] >= spec.RFMThreshold {
+		rh.RAA[idx] -= spec.RFMThreshold
+		requestMitigation(st, mitigationRequest{
+			Kind:      int(cmdKindRFMBank),
+			Rank:      rankIndex(&st.BankStates, cmd.Location),
+			BankGroup: int(cmd.Location.BankGroup),
+			Bank:      int(cmd.Location.Bank),
+		})
+	}
+}
+
+// pracMitigation is per-row activation counting with alert back-off. The
+// DRAM's per-row counters are the controller's activation counts.
+type pracMitigation struct{}
+
+func (pracMitigation) Name() string { return rowHammerMitigationPRAC }
+
+func (pracMitigation) Observe(spec *Spec, st *State, cmd observedCommand) {
+	if commandKind(cmd.Kind) != cmdKindActivate {
+		return
+	}
+
+	rh := &st.RowHammer
+	rank := rankIndex(&st.BankStates, cmd.Location)
+	bo := &rh.BackOff[rank]
+	count := rh.Rows[bankIndex(&st.BankStates, cmd.Location)][cmd.Location.Row]
+
+	if pracPhase(bo.Phase) == pracNormal &&
+		count >= uint64(spec.RowHammerMitigationThreshold) {
+		bo.Phase = int(pracPreRecovery)
+		bo.Until = st.TickCount + uint64(spec.TABOACT)
+		st.BackOffs++
+	}
+}
+
+// Tick moves every alerting rank through the back-off: after tABO_ACT it
+// requests the recovery RFMs, and once they issued it waits out the delay.
+func (pracMitigation) Tick(spec *Spec, st *State) bool {
+	rh := &st.RowHammer
+	waiting := false
+
+	for r := range rh.BackOff {
+		bo := &rh.BackOff[r]
+
+		switch pracPhase(bo.Phase) {
+		case pracNormal:
+			continue
+		case pracPreRecovery:
+			if st.TickCount >= bo.Until {
+				bo.Phase = int(pracRecovery)
+				for range spec.PRACBackOffRFMs {
+					requestMitigation(st, mitigationRequest{Kind: int(cmdKindRFM), Rank: r})
+				}
+			}
+		case pracRecovery:
+			if !rankHasPendingMitigation(rh, r) {
+				bo.Phase = int(pracDelay)
+				bo.Until = st.TickCount + uint64(spec.PRACBackOffDelay)
+			}
+		case pracDelay:
+			if st.TickCount >= bo.Until {
+				bo.Phase = int(pracNormal)
+				continue
+			}
+		}
+
+		waiting = true
+	}
+
+	return waiting
+}
+
+// nextRandom advances a splitmix64 generator and returns a number in [0, 1).
+func nextRandom(rh *rowHammerState) float64 {
+	rh.Random += 0x9e3779b97f4a7c15
+
+	z := rh.Random
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+
+	return float64(z>>11) / (1 << 53)
+}
+
+// countInTable counts an activation of a row in a bank's Misra-Gries table and
+// returns the row's estimated count. A row not in a full table replaces an
+// entry at the spillover count, or else raises the spillover count.
+func countInTable(spec *Spec, rh *rowHammerState, idx int, row uint64) uint64 {
+	table := rh.Tables[idx]
+
+	if _, ok := table[row]; !ok && len(table) >= spec.RowHammerTableSize {
+		victim, found := uint64(0), false
+		for r, c := range table {
+			if c == rh.Spillover[idx] && (!found || r < victim) {
+				victim, found = r, true
+			}
+		}
+
+		if !found {
+			rh.Spillover[idx]++
+			return rh.Spillover[idx]
+		}
+
+		delete(table, victim)
+	}
+
+	if _, ok := table[row]; !ok {
+		table[row] = rh.Spillover[idx]
+	}
+
+	table[row]++
+
+	return table[row]
+}
+
+// hottestRow returns the row with the highest count, the lowest such row on a
+// tie, and its count.
+func hottestRow(counts map[uint64]uint64) (row, count uint64) {
+	for r, c := range counts {
+		if c > count || (c == count && r < row) {
+			row, count = r, c
+		}
+	}
+
+	return row, count
+}
+
+// forRefreshedBanks calls f with the flat index and entry of every bank a
+// refresh covers.
+func forRefreshedBanks(st *State, cmd observedCommand, f func(idx int, e *bankEntry)) {
+	flat := &st.BankStates
+
+	if commandKind(cmd.Kind) == cmdKindRefreshBank {
+		idx := bankIndex(flat, cmd.Location)
+		f(idx, &flat.Entries[idx])
+
+		return
+	}
+
+	first := bankFlatIndex(flat, rankIndex(flat, cmd.Location), 0, 0)
+	for i := range flat.NumBankGroups * flat.NumBanks {
+		f(first+i, &flat.Entries[first+i])
+	}
+}
+
+// requestVictimRefresh requests a VRR of the neighbors of a row.
+func requestVictimRefresh(st *State, loc location) {
+	requestMitigation(st, mitigationRequest{
+		Kind:      int(cmdKindVRR),
+		Rank:      rankIndex(&st.BankStates, loc),
+		BankGroup: int(loc.BankGroup),
+		Bank:      int(loc.Bank),
+		Row:       loc.Row,
+	})
+}
+
+// requestMitigation queues a mitigation command and holds off new commands to
+// the banks it covers.
+func requestMitigation(st *State, req mitigationRequest) {
+	st.RowHammer.Pending = append(st.RowHammer.Pending, req)
+	setMitigationPending(st, req, true)
+}
+
+// rankHasPendingMitigation returns true if a mitigation command waits to issue
+// to a rank.
+func rankHasPendingMitigation(rh *rowHammerState, rank int) bool {
+	for _, req := range rh.Pending {
+		if req.Rank == rank {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mitigationBanks returns the flat index of the first bank a mitigation
+// command covers and the number of banks it covers.
+func mitigationBanks(flat *bankStatesFlat, req mitigationRequest) (first, n int) {
+	if commandKind(req.Kind) == cmdKindRFM {
+		return bankFlatIndex(flat, req.Rank, 0, 0), flat.NumBankGroups * flat.NumBanks
+	}
+
+	return bankFlatIndex(flat, req.Rank, req.BankGroup, req.Bank), 1
+}
+
+// setMitigationPending marks the banks of a mitigation command as held for it
+// or released. A bank another pending command covers stays held.
+func setMitigationPending(st *State, req mitigationRequest, pending bool) {
+	flat := &st.BankStates
+
+	first, n := mitigationBanks(flat, req)
+	for i := first; i < first+n; i++ {
+		flat.Entries[i].Data.MitigationPending = pending
+	}
+
+	if pending {
+		return
+	}
+
+	for _, other := range st.RowHammer.Pending {
+		first, n := mitigationBanks(flat, other)
+		for i := first; i < first+n; i++ {
+			flat.Entries[i].Data.MitigationPending = true
+		}
+	}
+}
+
+// rowHammerMiddleware counts the activations of every row and issues the
+// commands of the selected mitigation. Like refresh, it is a middleware that
+// issues real commands: the builder adds it after the refresh middleware and
+// ahead of the low-power and bank-tick middlewares, and it communicates with
+// them through State.
+//
+// The activations and refreshes issued in a cycle are counted and shown to
+// the mitigation at the start of the next. A mitigation command holds off new
+// commands to its banks, precharges the open ones, and issues once the timing
+// table allows it, after which the timing table keeps the banks closed for
+// tVRR or tRFM.
+type rowHammerMiddleware struct {
+	comp       *modeling.Component[Spec, State, Resources]
+	timing     dramTiming
+	cmdCycles  map[commandKind]int
+	energy     *energyModel
+	mitigation rowHammerMitigation
+}
+
+// Tick counts the commands issued since the last tick and issues at most one
+// mitigation or precharge command per command bus. It keeps ticking while a
+// mitigation command waits to issue or the mitigation waits for a timer.
+// Paused DRAM freezes it.
+func (m *rowHammerMiddleware) Tick() bool {
+	next := &m.comp.State
+
+	if next.ControlState == memcontrolprotocol.StatePaused {
+		return false
+	}
+
+	spec := m.comp.Spec()
+
+	return m.runRowHammer(&spec, next)
+}
+
+func (m *rowHammerMiddleware) runRowHammer(spec *Spec, next *State) bool {
+	rh := &next.RowHammer
+
+	if rh.NextWindow > 0 && next.TickCount >= rh.NextWindow {
+		m.clearWindow(spec, next)
+	}
+
+	for _, cmd := range rh.Observed {
+		if commandKind(cmd.Kind) == cmdKindActivate {
+			countActivation(spec, next, cmd.Location)
+		}
+
+		m.mitigation.Observe(spec, next, cmd)
+	}
+
+	rh.Observed = rh.Observed[:0]
+
+	progress := m.mitigation.Tick(spec, next)
+
+	m.countStall(next)
+
+	for i := 0; i < len(rh.Pending); {
+		req := rh.Pending[i]
+		ch := rankChannel(&next.BankStates, req.Rank)
+
+		if commandBusBusy(next, ch) {
+			i++
+			continue
+		}
+
+		issued, done := m.issueFor(spec, next, req)
+		if issued {
+			useCommandBus(next, ch)
+		}
+
+		if !done {
+			i++
+			continue
+		}
+
+		rh.Pending = append(rh.Pending[:i], rh.Pending[i+1:]...)
+		setMitigationPending(next, req, false)
+	}
+
+	return progress || len(rh.Pending) > 0
+}
+
+// clearWindow clears every count, as every row has been refreshed, and starts
+// the next window.
+func (m *rowHammerMiddleware) clearWindow(spec *Spec, next *State) {
+	rh := &next.RowHammer
+
+	for i := range rh.Rows {
+		clear(rh.Rows[i])
+		clear(rh.Tables[i])
+		rh.Spillover[i] = 0
+	}
+
+	for rh.NextWindow <= next.TickCount {
+		rh.NextWindow += uint64(spec.RowHammerWindow)
+	}
+}
+
+// countActivation counts an activation of a row, recording the highest count
+// any row reached and every count that reaches RowHammerThreshold.
+func countActivation(spec *Spec, next *State, loc location) {
+	counts := next.RowHammer.Rows[bankIndex(&next.BankStates, loc)]
+
+	counts[loc.Row]++
+	c := counts[loc.Row]
+
+	next.MaxRowActivations = max(next.MaxRowActivations, c)
+	if c == uint64(spec.RowHammerThreshold) {
+		next.RowHammerCrossings++
+	}
+}
+
+// countStall counts the cycle as a row-hammer stall if a queued command waits
+// on a bank held or busy for a mitigation.
+func (m *rowHammerMiddleware) countStall(next *State) {
+	rh := &next.RowHammer
+
+	for i := range next.CommandQueues.Entries {
+		idx := bankIndex(&next.BankStates, next.CommandQueues.Entries[i].Command.Location)
+		if idx < 0 || idx >= len(rh.BusyUntil) {
+			continue
+		}
+
+		if next.BankStates.Entries[idx].Data.MitigationPending ||
+			rh.BusyUntil[idx] > next.TickCount {
+			next.RowHammerStallCycles++
+			return
+		}
+	}
+}
+
+// issueFor issues the next command a mitigation request needs: a precharge
+// for one of its open banks, or the mitigation command once every bank is
+// closed and the timing table allows it. It returns whether a command issued
+// and whether the mitigation command did. Nothing issues while the rank is in
+// power-down or self-refresh (the low-power middleware wakes it).
+func (m *rowHammerMiddleware) issueFor(
+	spec *Spec, next *State, req mitigationRequest,
+) (issued, done bool) {
+	flat := &next.BankStates
+	kind := commandKind(req.Kind)
+
+	switch rankLowPowerState(next, req.Rank) {
+	case bankStatePD, bankStateSRef:
+		return false, false
+	}
+
+	ready := true
+
+	first, n := mitigationBanks(flat, req)
+	for i := first; i < first+n; i++ {
+		e := &flat.Entries[i]
+		bs := &e.Data
+
+		if bankStateKind(bs.State) == bankStateOpen {
+			ready = false
+
+			if bs.CyclesToCmdAvailable[cmdKindPrecharge] == 0 {
+				m.issue(next, bs, cmdKindPrecharge, bankLocation(e))
+				return true, false
+			}
+
+			continue
+		}
+
+		if bs.CyclesToCmdAvailable[kind] > 0 {
+			ready = false
+		}
+	}
+
+	if !ready {
+		return false, false
+	}
+
+	m.issueMitigation(spec, next, req)
+
+	return true, true
+}
+
+// issueMitigation issues a mitigation command and clears the counts of the
+// rows it mitigates.
+func (m *rowHammerMiddleware) issueMitigation(
+	spec *Spec, next *State, req mitigationRequest,
+) {
+	flat := &next.BankStates
+	rh := &next.RowHammer
+	kind := commandKind(req.Kind)
+
+	first, n := mitigationBanks(flat, req)
+	e := &flat.Entries[first]
+	loc := bankLocation(e)
+	loc.Row = req.Row
+
+	m.issue(next, &e.Data, kind, loc)
+
+	busy := spec.TRFM
+	if kind == cmdKindVRR {
+		busy = spec.TVRR
+		next.PreventiveRefreshes++
+	} else {
+		next.TotalRFMs++
+	}
+
+	for i := first; i < first+n; i++ {
+		rh.BusyUntil[i] = next.TickCount + uint64(busy)
+
+		if kind == cmdKindVRR {
+			delete(rh.Rows[i], req.Row)
+			continue
+		}
+
+		if row, count := hottestRow(rh.Rows[i]); count > 0 {
+			delete(rh.Rows[i], row)
+		}
+	}
+}
+
+func (m *rowHammerMiddleware) issue(
+	next *State, bs *bankState, kind commandKind, loc location,
+) {
+	cmd := &commandState{Kind: int(kind), Location: loc}
+	startCommand(m.cmdCycles, next, bs, cmd)
+	updateTiming(m.timing, next, cmd)
+
+	if m.energy != nil {
+		chargeCommand(m.energy, next, cmd)
+	}
+}
+
+// rowHammerMustBeValid rejects unknown mitigations and negative parameters,
+// and fills in the defaults of the unset ones. RowHammerThreshold stays zero,
+// and row hammer unmodeled, unless a mitigation is selected.
+func (b *Builder) rowHammerMustBeValid() {
+	s := &b.spec
+	enabled := s.RowHammerMitigation != "" || s.RowHammerThreshold > 0
+
+	if _, ok := rowHammerMitigationRegistry[s.RowHammerMitigation]; !ok &&
+		s.RowHammerMitigation != "" {
+		panic(fmt.Sprintf("dram: unknown row-hammer mitigation %q", s.RowHammerMitigation))
+	}
+
+	if s.PARAProbability < 0 || s.PARAProbability > 1 {
+		panic("dram: PARAProbability must be between 0 and 1")
+	}
+
+	// The defaults of the later parameters depend on the earlier ones.
+	refreshManagement := s.TRFC
+	if s.TRFCb > 0 {
+		refreshManagement = s.TRFCb
+	}
+
+	params := []struct {
+		name  string
+		value *int
+		def   func() int
+	}{
+		{"RowHammerThreshold", &s.RowHammerThreshold, func() int {
+			if !enabled {
+				return 0
+			}
+
+			return defaultRowHammerThreshold
+		}},
+		{"RowHammerMitigationThreshold", &s.RowHammerMitigationThreshold,
+			func() int { return max(s.RowHammerThreshold/2, 1) }},
+		{"RowHammerBlastRadius", &s.RowHammerBlastRadius,
+			func() int { return defaultRowHammerBlastRadius }},
+		{"RowHammerTableSize", &s.RowHammerTableSize,
+			func() int { return defaultRowHammerTableSize }},
+		{"RowHammerWindow", &s.RowHammerWindow,
+			func() int { return defaultRowHammerRefreshes * s.TREFI }},
+		{"RFMThreshold", &s.RFMThreshold, func() int { return defaultRFMThreshold }},
+		{"PRACBackOffRFMs", &s.PRACBackOffRFMs,
+			func() int { return defaultPRACBackOffRFMs }},
+		{"TVRR", &s.TVRR,
+			func() int { return 2 * s.RowHammerBlastRadius * (s.TRAS + s.TRP) }},
+		{"TRFM", &s.TRFM, func() int { return refreshManagement }},
+		{"TABOACT", &s.TABOACT, func() int { return b.cyclesOf(defaultTABOACT) }},
+		{"PRACBackOffDelay", &s.PRACBackOffDelay, func() int { return s.TABOACT }},
+	}
+
+	for _, p := range params {
+		switch {
+		case *p.value < 0:
+			panic(fmt.Sprintf("dram: %s must not be negative", p.name))
+		case *p.value == 0:
+			*p.value = p.def()
+		}
+	}
+
+	if s.PARAProbability == 0 {
+		s.PARAProbability = defaultPARAProbability
+	}
+
+	if s.RowHammerSeed == 0 {
+		s.RowHammerSeed = defaultRowHammerSeed
+	}
+}
+
+// cyclesOf returns the number of cycles, rounded up, that a time in ps spans.
+func (b *Builder) cyclesOf(ps float64) int {
+	period := float64(b.spec.Freq.Period())
+	if period == 0 {
+		return 0
+	}
+
+	return int(math.Ceil(ps / period))
+}
@@ -0,0 +1,331 @@
+package dram
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/akita/v5/mem/memprotocol"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+var _ = Describe("Row hammer", func() {
+	var (
+		spec  Spec
+		state *State
+		mw    *rowHammerMiddleware
+	)
+
+	// setup builds a DDR4 spec without refresh, tweaked before it is
+	// normalized, an idle state, and a row-hammer middleware for them.
+	setup := func(tweak func(s *Spec)) {
+		s := DDR4Spec
+		s.TREFI = 0
+		if tweak != nil {
+			tweak(&s)
+		}
+
+		b := MakeBuilder().WithSpec(s)
+		b.normalizeSpec()
+		spec = b.spec
+
+		state = &State{
+			BankStates:     initBankStates(&spec),
+			Refresh:        initRefreshState(&spec),
+			LowPower:       make([]rankLowPower, numRanks(&spec)),
+			RowHammer:      initRowHammerState(&spec),
+			CommandBusBusy: make([]bool, spec.NumChannel),
+			Channels:       make([]channelStats, spec.NumChannel),
+		}
+
+		mw = &rowHammerMiddleware{
+			timing:     b.generateTiming(),
+			cmdCycles:  b.buildCmdCycles(),
+			mitigation: newRowHammerMitigation(spec.RowHammerMitigation),
+		}
+	}
+
+	// step runs the middleware for one cycle and counts the timing down.
+	step := func() {
+		clear(state.CommandBusBusy)
+		mw.runRowHammer(&spec, state)
+		tickBanks(state)
+		state.TickCount++
+	}
+
+	// observe records an issued command for the middleware to count.
+	observe := func(kind commandKind, bank, row uint64) {
+		state.RowHammer.Observed = append(state.RowHammer.Observed,
+			observedCommand{Kind: int(kind), Location: location{Bank: bank, Row: row}})
+	}
+
+	hammer := func(bank, row uint64, n int) {
+		for range n {
+			observe(cmdKindActivate, bank, row)
+			step()
+		}
+	}
+
+	It("should reject unknown mitigations and bad parameters", func() {
+		Expect(func() {
+			setup(func(s *Spec) { s.RowHammerMitigation = "Oracle" })
+		}).To(Panic())
+		Expect(func() {
+			setup(func(s *Spec) { s.RFMThreshold = -1 })
+		}).To(Panic())
+		Expect(func() {
+			setup(func(s *Spec) { s.PARAProbability = 2 })
+		}).To(Panic())
+	})
+
+	It("should fill in the defaults", func() {
+		setup(func(s *Spec) {
+			s.TREFI = DDR4Spec.TREFI
+			s.RowHammerMitigation = rowHammerMitigationNone
+		})
+
+		Expect(spec.RowHammerThreshold).To(Equal(defaultRowHammerThreshold))
+		Expect(spec.RowHammerMitigationThreshold).To(Equal(defaultRowHammerThreshold / 2))
+		Expect(spec.RowHammerWindow).To(Equal(8192 * DDR4Spec.TREFI))
+		Expect(spec.TVRR).To(Equal(2 * (spec.TRAS + spec.TRP)))
+		Expect(spec.TRFM).To(Equal(spec.TRFC))
+		Expect(spec.TABOACT).To(BeNumerically("~", 216, 1))
+		Expect(spec.PRACBackOffDelay).To(Equal(spec.TABOACT))
+	})
+
+	It("should count activations per row and clear them each window", func() {
+		setup(func(s *Spec) {
+			s.RowHammerThreshold = 4
+			s.RowHammerWindow = 100
+		})
+
+		hammer(1, 7, 5)
+		hammer(1, 8, 4)
+		hammer(2, 7, 1)
+
+		Expect(state.RowHammer.Rows[1][7]).To(Equal(uint64(5)))
+		Expect(state.MaxRowActivations).To(Equal(uint64(5)))
+		Expect(state.RowHammerCrossings).To(Equal(uint64(2)))
+
+		for state.TickCount <= 100 {
+			step()
+		}
+
+		Expect(state.RowHammer.Rows[1]).To(BeEmpty())
+		Expect(state.RowHammer.NextWindow).To(Equal(uint64(200)))
+		Expect(state.MaxRowActivations).To(Equal(uint64(5)))
+	})
+
+	It("should precharge the bank and refresh the victims with Graphene", func() {
+		setup(func(s *Spec) {
+			s.RowHammerMitigation = rowHammerMitigationGraphene
+			s.RowHammerMitigationThreshold = 3
+		})
+		bs := findBankState(&state.BankStates, 0, 0, 1)
+		bs.State = int(bankStateOpen)
+		bs.OpenRow = 5
+
+		hammer(1, 5, 2)
+		Expect(state.RowHammer.Pending).To(BeEmpty())
+
+		hammer(1, 5, 1)
+		Expect(bs.MitigationPending).To(BeTrue())
+		Expect(state.TotalPrecharges).To(Equal(uint64(1)))
+
+		for range spec.TRP + 1 {
+			step()
+		}
+
+		Expect(state.PreventiveRefreshes).To(Equal(uint64(1)))
+		Expect(state.RowHammer.Pending).To(BeEmpty())
+		Expect(bs.MitigationPending).To(BeFalse())
+		Expect(state.RowHammer.Rows[1]).NotTo(HaveKey(uint64(5)))
+		Expect(bs.CyclesToCmdAvailable[cmdKindActivate]).To(BeNumerically(">", spec.TVRR-spec.TRP-2))
+	})
+
+	It("should replace the entries at the spillover count in a full table", func() {
+		setup(func(s *Spec) {
+			s.RowHammerMitigation = rowHammerMitigationGraphene
+			s.RowHammerTableSize = 2
+		})
+		rh := &state.RowHammer
+
+		countInTable(&spec, rh, 0, 1)
+		countInTable(&spec, rh, 0, 1)
+		countInTable(&spec, rh, 0, 2)
+		Expect(countInTable(&spec, rh, 0, 3)).To(Equal(uint64(1)))
+		Expect(rh.Tables[0]).To(HaveKey(uint64(1)))
+		Expect(rh.Tables[0]).To(HaveKey(uint64(2)))
+
+		Expect(countInTable(&spec, rh, 0, 3)).To(Equal(uint64(2)))
+		Expect(rh.Tables[0]).To(HaveKey(uint64(3)))
+		Expect(rh.Tables[0]).NotTo(HaveKey(uint64(2)))
+	})
+
+	It("should refresh the victims of every activated row with PARA at probability 1", func() {
+		setup(func(s *Spec) {
+			s.RowHammerMitigation = rowHammerMitigationPARA
+			s.PARAProbability = 1
+		})
+
+		hammer(0, 1, 1)
+		hammer(3, 2, 1)
+		for range 2 {
+			step()
+		}
+
+		Expect(state.PreventiveRefreshes).To(Equal(uint64(2)))
+	})
+
+	It("should refresh the hottest row's victims on refresh with TRR", func() {
+		setup(func(s *Spec) {
+			s.RowHammerMitigation = rowHammerMitigationTRR
+			s.RowHammerMitigationThreshold = 4
+		})
+
+		hammer(2, 9, 3)
+		observe(cmdKindRefresh, 0, 0)
+		step()
+		Expect(state.RowHammer.Pending).To(BeEmpty())
+
+		hammer(2, 9, 1)
+		hammer(2, 4, 2)
+		observe(cmdKindRefresh, 0, 0)
+		step()
+		step()
+
+		Expect(state.PreventiveRefreshes).To(Equal(uint64(1)))
+		Expect(state.RowHammer.Tables[2]).NotTo(HaveKey(uint64(9)))
+		Expect(state.RowHammer.Tables[2]).To(HaveKey(uint64(4)))
+	})
+
+	It("should issue RFM by the rolling activation count", func() {
+		setup(func(s *Spec) {
+			s.RowHammerMitigation = rowHammerMitigationRFM
+			s.RFMThreshold = 4
+		})
+
+		hammer(1, 3, 3)
+		observe(cmdKindRefreshBank, 1, 0)
+		step()
+		Expect(state.RowHammer.RAA[1]).To(BeZero())
+
+		hammer(1, 3, 3)
+		hammer(1, 6, 1)
+		step()
+
+		Expect(state.TotalRFMs).To(Equal(uint64(1)))
+		Expect(state.RowHammer.Rows[1]).NotTo(HaveKey(uint64(3)))
+		Expect(state.RowHammer.Rows[1][6]).To(Equal(uint64(1)))
+	})
+
+	It("should back off with RFMs after a PRAC alert", func() {
+		setup(func(s *Spec) {
+			s.RowHammerMitigation = rowHammerMitigationPRAC
+			s.RowHammerMitigationThreshold = 3
+			s.TABOACT = 10
+			s.PRACBackOffRFMs = 2
+			s.PRACBackOffDelay = 50
+		})
+
+		hammer(0, 1, 3)
+		Expect(state.BackOffs).To(Equal(uint64(1)))
+		Expect(pracPhase(state.RowHammer.BackOff[0].Phase)).To(Equal(pracPreRecovery))
+
+		for range 10 {
+			step()
+		}
+
+		Expect(pracPhase(state.RowHammer.BackOff[0].Phase)).To(Equal(pracRecovery))
+		Expect(state.RowHammer.Pending).To(HaveLen(1))
+		Expect(state.TotalRFMs).To(Equal(uint64(1)))
+		Expect(findBankState(&state.BankStates, 0, 3, 3).MitigationPending).To(BeTrue())
+
+		for range spec.TRFM + 1 {
+			step()
+		}
+
+		Expect(state.TotalRFMs).To(Equal(uint64(2)))
+		Expect(pracPhase(state.RowHammer.BackOff[0].Phase)).To(Equal(pracDelay))
+		Expect(state.RowHammer.Rows[0]).To(BeEmpty())
+
+		hammer(0, 1, 3)
+		Expect(state.BackOffs).To(Equal(uint64(1)))
+
+		for range 50 {
+			step()
+		}
+
+		Expect(pracPhase(state.RowHammer.BackOff[0].Phase)).To(Equal(pracNormal))
+		Expect(mw.runRowHammer(&spec, state)).To(BeFalse())
+	})
+
+	It("should trade performance for a lower activation count", func() {
+		// run alternates reads to two rows of a bank and returns the component.
+		run := func(mitigation string) *Comp {
+			engine := timing.NewSerialEngine()
+			reg := modeling.NewStandaloneRegistrar(engine)
+			conn := directconnection.MakeBuilder().WithRegistrar(reg).Build("Conn")
+
+			s := DefaultSpec()
+			s.TREFI = 0
+			s.PagePolicy = PagePolicyClose
+			s.RowHammerMitigation = mitigation
+			s.RowHammerMitigationThreshold = 16
+			comp := MakeBuilder().WithRegistrar(reg).WithSpec(s).Build("DRAM")
+
+			for _, name := range []string{"Top", "Control"} {
+				p := modeling.MakePortBuilder().
+					WithRegistrar(reg).
+					WithComponent(comp).
+					WithSpec(modeling.PortSpec{BufSize: 16}).
+					Build(name)
+				comp.AssignPort(name, p)
+			}
+
+			top := comp.GetPortByName("Top")
+			src := messaging.NewPort(nil, 16, 16, "Src.Top")
+			conn.PlugIn(top)
+			conn.PlugIn(src)
+
+			rowSize := uint64(1) << comp.Spec().RowPos
+			for i := range 128 {
+				req := memprotocol.ReadReq{}
+				req.ID = timing.GetIDGenerator().Generate()
+				req.Address = uint64(i%2) * rowSize
+				req.AccessByteSize = 4
+				req.Src = src.AsRemote()
+				req.Dst = top.AsRemote()
+				req.TrafficBytes = 12
+				src.Send(req)
+				engine.Run()
+
+				for src.RetrieveIncoming() != nil {
+				}
+			}
+
+			return comp
+		}
+
+		off := run("")
+		none := run(rowHammerMitigationNone)
+		graphene := run(rowHammerMitigationGraphene)
+
+		// Without a mitigation or threshold, nothing is counted or observed.
+		Expect(off.State.RowHammer.Enabled).To(BeFalse())
+		Expect(off.State.RowHammer.Rows).To(BeEmpty())
+		Expect(off.State.RowHammer.Observed).To(BeEmpty())
+		Expect(off.State.MaxRowActivations).To(BeZero())
+		Expect(off.State.TotalReadLatencyCycles).
+			To(Equal(none.State.TotalReadLatencyCycles))
+
+		Expect(none.State.MaxRowActivations).To(Equal(uint64(64)))
+		Expect(none.State.PreventiveRefreshes).To(BeZero())
+		Expect(graphene.State.MaxRowActivations).To(BeNumerically("<=", 16))
+		Expect(graphene.State.PreventiveRefreshes).To(BeNumerically(">=", 6))
+		Expect(graphene.State.TotalReadLatencyCycles).
+			To(BeNumerically(">", none.State.TotalReadLatencyCycles))
+	})
+})
@@ -0,0 +1,258 @@
+package dram
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/akita/v5/mem/memprotocol"
+	"github.com/sarchlab/akita/v5/messaging"
+)
+
+var _ = Describe("Schedulers", func() {
+	var (
+		spec  Spec
+		state *State
+	)
+
+	// setup builds a DDR4 spec for the named scheduler, tweaked before it is
+	// normalized, and an idle state.
+	setup := func(name string, tweak func(s *Spec)) scheduler {
+		s := DDR4Spec
+		s.Scheduler = name
+		if tweak != nil {
+			tweak(&s)
+		}
+
+		b := MakeBuilder().WithSpec(s)
+		b.normalizeSpec()
+		spec = b.spec
+
+		state = &State{
+			BankStates: initBankStatesFlat(
+				spec.NumRank, spec.NumBankGroup, spec.NumBank),
+		}
+
+		return newScheduler(name)
+	}
+
+	// enqueue queues a read from source to a bank and row.
+	enqueue := func(source string, bank, row uint64) {
+		acceptCommand(state, &commandState{
+			ID:       uint64(len(state.CommandQueues.Entries) + 1),
+			Kind:     int(cmdKindRead),
+			Location: location{Bank: bank, Row: row},
+		})
+
+		last := &state.CommandQueues.Entries[len(state.CommandQueues.Entries)-1]
+		last.Source = source
+	}
+
+	// openRow opens a row of bank group 0 with no timing constraint pending.
+	openRow := func(bank, row uint64) {
+		bs := findBankState(&state.BankStates, 0, 0, int(bank))
+		bs.State = int(bankStateOpen)
+		bs.OpenRow = row
+	}
+
+	It("should be selectable by name", func() {
+		for _, name := range []string{"FRFCFS-Cap", "BLISS", "PAR-BS", "ATLAS", "Priority"} {
+			Expect(setup(name, nil).Name()).To(Equal(name))
+		}
+	})
+
+	It("should reject unknown schedulers and sources", func() {
+		Expect(func() { setup("Lottery", nil) }).To(Panic())
+		Expect(func() {
+			setup("BLISS", func(s *Spec) { s.SchedulerSource = "core" })
+		}).To(Panic())
+	})
+
+	It("should fill in the default parameters", func() {
+		setup("BLISS", nil)
+
+		Expect(spec.SchedulerSource).To(Equal("src"))
+		Expect(spec.SchedulerRowHitCap).To(Equal(4))
+		Expect(spec.SchedulerBlacklistThreshold).To(Equal(4))
+		Expect(spec.SchedulerMarkingCap).To(Equal(5))
+	})
+
+	It("should take the request source from the configured field", func() {
+		trans := &transactionState{
+			HasRead: true,
+			ReadMsg: memprotocol.ReadReq{
+				MsgMeta: messaging.MsgMeta{Src: "GPU.L2", TrafficClass: "display"},
+				PID:     7,
+			},
+		}
+
+		spec.SchedulerSource = "src"
+		Expect(requestSource(&spec, trans)).To(Equal("GPU.L2"))
+		spec.SchedulerSource = "pid"
+		Expect(requestSource(&spec, trans)).To(Equal("7"))
+		spec.SchedulerSource = "traffic_class"
+		Expect(requestSource(&spec, trans)).To(Equal("display"))
+	})
+
+	It("should tag queued commands with their request source", func() {
+		setup("PAR-BS", func(s *Spec) { s.SchedulerSource = "pid" })
+		state.CommandQueues.NumQueues = spec.NumRank
+		state.Transactions = []transactionState{{
+			ID:              3,
+			HasWrite:        true,
+			WriteMsg:        memprotocol.WriteReq{PID: 5},
+			SubTransactions: []subTransState{{Address: 0x40}},
+		}}
+		state.SubTransQueue.Entries = []subTransRef{{TxID: 3}}
+		state.TickCount = 12
+
+		Expect(tickSubTransQueue(&spec, state)).To(BeTrue())
+		Expect(state.CommandQueues.Entries).To(HaveLen(1))
+		Expect(state.CommandQueues.Entries[0].Source).To(Equal("5"))
+		Expect(state.CommandQueues.Entries[0].EnqueueTick).To(Equal(uint64(12)))
+	})
+
+	It("should stop preferring row hits after the cap", func() {
+		s := setup("FRFCFS-Cap", func(s *Spec) { s.SchedulerRowHitCap = 2 })
+		openRow(0, 1)
+		enqueue("a", 0, 2)
+		enqueue("b", 0, 1)
+		enqueue("b", 0, 1)
+		enqueue("b", 0, 1)
+
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(2)))
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(3)))
+
+		// The cap is reached, so the older miss goes first: a precharge.
+		cmd := s.Pick(&spec, state, nil)
+		Expect(commandKind(cmd.Kind)).To(Equal(cmdKindPrecharge))
+		Expect(cmd.ID).To(Equal(uint64(1)))
+	})
+
+	It("should blacklist a source served too many commands in a row", func() {
+		s := setup("BLISS", func(s *Spec) { s.SchedulerBlacklistThreshold = 2 })
+		openRow(0, 1)
+		openRow(1, 5)
+		enqueue("a", 0, 1)
+		enqueue("a", 0, 1)
+		enqueue("a", 0, 1)
+		enqueue("b", 1, 6)
+
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(1)))
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(2)))
+		Expect(state.Scheduler.Blacklist).To(HaveKey("a"))
+
+		// Source b's row miss now goes ahead of a's row hit.
+		cmd := s.Pick(&spec, state, nil)
+		Expect(cmd.ID).To(Equal(uint64(4)))
+		Expect(commandKind(cmd.Kind)).To(Equal(cmdKindPrecharge))
+	})
+
+	It("should clear the blacklist every interval", func() {
+		s := setup("BLISS", func(s *Spec) { s.SchedulerClearInterval = 100 })
+		state.Scheduler.Blacklist = map[string]bool{"a": true}
+		state.Scheduler.NextClear = 100
+		state.TickCount = 100
+
+		s.Pick(&spec, state, nil)
+
+		Expect(state.Scheduler.Blacklist).To(BeEmpty())
+		Expect(state.Scheduler.NextClear).To(Equal(uint64(200)))
+	})
+
+	It("should serve the PAR-BS batch first, ranking light sources first", func() {
+		s := setup("PAR-BS", func(s *Spec) { s.SchedulerMarkingCap = 2 })
+		openRow(0, 1)
+		openRow(1, 1)
+		enqueue("heavy", 0, 1)
+		enqueue("heavy", 0, 1)
+		enqueue("heavy", 0, 1)
+		enqueue("light", 1, 1)
+
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(4)))
+		Expect(state.Scheduler.BatchRanks).To(Equal(map[string]int{"light": 0, "heavy": 1}))
+
+		marked := []bool{}
+		for _, e := range state.CommandQueues.Entries {
+			marked = append(marked, e.Marked)
+		}
+		Expect(marked).To(Equal([]bool{true, true, false}))
+
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(1)))
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(2)))
+
+		// The batch is done; the next one marks the remaining command.
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(3)))
+	})
+
+	It("should serve the source with the least attained service first", func() {
+		s := setup("ATLAS", func(s *Spec) { s.SchedulerQuantum = 10 })
+		openRow(0, 1)
+		enqueue("a", 0, 1)
+		enqueue("b", 0, 1)
+		enqueue("a", 0, 1)
+		state.Scheduler.AttainedService = map[string]float64{"a": 8}
+		state.Scheduler.NextQuantum = 10
+
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(2)))
+		Expect(state.Scheduler.QuantumService).To(
+			HaveKeyWithValue("b", float64(spec.BurstCycle)))
+
+		state.TickCount = 10
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(1)))
+		Expect(state.Scheduler.AttainedService["a"]).To(BeNumerically("~", 7, 1e-9))
+		Expect(state.Scheduler.AttainedService["b"]).To(
+			BeNumerically("~", 0.125*float64(spec.BurstCycle), 1e-9))
+	})
+
+	It("should serve a starving command ahead of its ranking", func() {
+		s := setup("ATLAS", nil)
+		openRow(0, 1)
+		enqueue("b", 0, 1)
+		enqueue("a", 0, 1)
+		state.CommandQueues.Entries[1].EnqueueTick = 1
+		state.Scheduler.AttainedService = map[string]float64{"b": 100}
+		state.Scheduler.NextQuantum = atlasStarvationCycles + 1
+		state.TickCount = atlasStarvationCycles
+
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(1)))
+	})
+
+	It("should serve higher-priority sources first", func() {
+		s := setup("Priority", func(s *Spec) {
+			s.SchedulerPriorities = map[string]int{"display": 2, "gpu": 1}
+		})
+		openRow(0, 1)
+		enqueue("cpu", 0, 1)
+		enqueue("gpu", 0, 3)
+		enqueue("display", 1, 1)
+
+		cmd := s.Pick(&spec, state, nil)
+		Expect(cmd.ID).To(Equal(uint64(3)))
+		Expect(commandKind(cmd.Kind)).To(Equal(cmdKindActivate))
+
+		openRow(1, 1)
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(3)))
+
+		cmd = s.Pick(&spec, state, nil)
+		Expect(cmd.ID).To(Equal(uint64(2)))
+		Expect(commandKind(cmd.Kind)).To(Equal(cmdKindPrecharge))
+	})
+
+	It("should only pick writes while draining writes", func() {
+		s := setup("Priority", func(s *Spec) {
+			s.ReadQueueSize = 8
+			s.WriteQueueSize = 8
+			s.WriteHighWatermark = 1
+			s.SchedulerPriorities = map[string]int{"cpu": 1}
+		})
+		openRow(0, 1)
+		enqueue("cpu", 0, 1)
+		acceptCommand(state, &commandState{
+			ID:       9,
+			Kind:     int(cmdKindWrite),
+			Location: location{Row: 1},
+		})
+
+		Expect(s.Pick(&spec, state, nil).ID).To(Equal(uint64(9)))
+	})
+})
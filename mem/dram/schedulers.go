@@ -0,0 +1,555 @@
+package dram
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// Besides the default FR-FCFS scheduler, the controller offers schedulers
+// that trade some row-buffer locality for fairness or quality of service.
+// They all rank the ready commands of the command queue and issue the first;
+// ties go to the oldest command. Like FR-FCFS, they only pick writes while the
+// controller drains writes.
+//
+//   - "FRFCFS-Cap" is FR-FCFS that stops preferring row hits to a bank after
+//     SchedulerRowHitCap consecutive hits, so a streaming source cannot starve
+//     the others.
+//   - "BLISS" blacklists a source that is served SchedulerBlacklistThreshold
+//     column commands in a row and serves non-blacklisted sources first. The
+//     blacklist is cleared every SchedulerClearInterval cycles.
+//   - "PAR-BS" marks the oldest SchedulerMarkingCap commands of each source
+//     and bank as a batch, serves the batch first, and inside it ranks the
+//     sources shortest job first.
+//   - "ATLAS" serves the source with the least attained service first, where
+//     the service is aged by a long-term average every SchedulerQuantum
+//     cycles.
+//   - "Priority" serves the source with the highest SchedulerPriorities value
+//     first.
+//
+// A request's source is taken from the request message as selected by
+// Spec.SchedulerSource.
+
+// A list of scheduler names.
+const (
+	schedulerFRFCFSCap = "FRFCFS-Cap"
+	schedulerBLISS     = "BLISS"
+	schedulerPARBS     = "PAR-BS"
+	schedulerATLAS     = "ATLAS"
+	schedulerPriority  = "Priority"
+)
+
+// A list of Spec.SchedulerSource values.
+const (
+	schedulerSourceSrc          = "src"
+	schedulerSourcePID          = "pid"
+	schedulerSourceTrafficClass = "traffic_class"
+)
+
+// Default scheduler parameters.
+const (
+	defaultSchedulerRowHitCap          = 4
+	defaultSchedulerBlacklistThreshold = 4
+	defaultSchedulerClearInterval      = 10000
+	defaultSchedulerMarkingCap         = 5
+	defaultSchedulerQuantum            = 10000
+)
+
+// atlasHistoryWeight is the weight ATLAS keeps of the attained service of
+// earlier quanta when a quantum ends.
+const atlasHistoryWeight = 0.875
+
+// atlasStarvationCycles is how long a command may wait before ATLAS serves it
+// ahead of its ranking.
+const atlasStarvationCycles = 100000
+
+// schedulerState is what the schedulers remember across cycles.
+type schedulerState struct {
+	// RowHitStreaks counts the row hits served to each bank since its last
+	// activate, in bankFlatIndex order (FRFCFS-Cap).
+	RowHitStreaks []int `json:"row_hit_streaks"`
+
+	// BLISS: the source served last, how many column commands in a row it
+	// has been served, the blacklisted sources, and when the blacklist clears.
+	LastSource string          `json:"last_source"`
+	Streak     int             `json:"streak"`
+	Blacklist  map[string]bool `json:"blacklist"`
+	NextClear  uint64          `json:"next_clear"`
+
+	// BatchRanks is the rank of each source in the current PAR-BS batch,
+	// 0 first.
+	BatchRanks map[string]int `json:"batch_ranks"`
+
+	// ATLAS: the aged service of each source, the service in the current
+	// quantum, and when the quantum ends.
+	AttainedService map[string]float64 `json:"attained_service"`
+	QuantumService  map[string]float64 `json:"quantum_service"`
+	NextQuantum     uint64             `json:"next_quantum"`
+}
+
+// requestSource returns the source of a transaction's request, as selected by
+// Spec.SchedulerSource.
+func requestSource(spec *Spec, trans *transactionState) string {
+	if trans == nil {
+		return ""
+	}
+
+	meta, pid := trans.WriteMsg.MsgMeta, trans.WriteMsg.PID
+	if trans.HasRead {
+		meta, pid = trans.ReadMsg.MsgMeta, trans.ReadMsg.PID
+	}
+
+	switch spec.SchedulerSource {
+	case schedulerSourcePID:
+		return strconv.FormatUint(uint64(pid), 10)
+	case schedulerSourceTrafficClass:
+		return meta.TrafficClass
+	default:
+		return string(meta.Src)
+	}
+}
+
+// candidate is a queued command that can issue a command this cycle.
+type candidate struct {
+	index int
+	entry *queueEntry
+	ready *commandState
+	hit   bool
+}
+
+// readyCandidates appends the queued commands that can issue a command this
+// cycle to buf, in queue order. While the controller drains writes, only
+// writes are candidates, unless no write is ready.
+func readyCandidates(spec *Spec, st *State, buf []candidate) []candidate {
+	drain := updateWriteDrainMode(spec, st)
+
+	for pass := 0; pass < 2; pass++ {
+		for i := range st.CommandQueues.Entries {
+			e := &st.CommandQueues.Entries[i]
+			if drain && pass == 0 && !e.IsWrite {
+				continue
+			}
+
+			bs := findBankStateByLocation(&st.BankStates, e.Command.Location)
+			if bs == nil {
+				continue
+			}
+
+			ready := getReadyCommand(spec, st, bs, &e.Command)
+			if ready == nil {
+				continue
+			}
+
+			buf = append(buf, candidate{
+				index: i,
+				entry: e,
+				ready: ready,
+				hit: bankStateKind(bs.State) == bankStateOpen &&
+					bs.OpenRow == e.Command.Location.Row,
+			})
+		}
+
+		if !drain || len(buf) > 0 {
+			break
+		}
+	}
+
+	return buf
+}
+
+// bestCandidate returns the ready candidate that ranks first under before;
+// ties go to the older command.
+func bestCandidate(
+	spec *Spec, st *State, buf *[]candidate,
+	before func(a, b *candidate) bool,
+) (candidate, bool) {
+	*buf = readyCandidates(spec, st, (*buf)[:0])
+	cands := *buf
+
+	if len(cands) == 0 {
+		return candidate{}, false
+	}
+
+	best := 0
+	for i := 1; i < len(cands); i++ {
+		if before(&cands[i], &cands[best]) {
+			best = i
+		}
+	}
+
+	return cands[best], true
+}
+
+// issueCandidate counts the candidate as a row-buffer hit or miss, removes it
+// from its queue if it issues the queued command itself, and returns the
+// command to issue. The candidate's entry is not valid afterwards.
+func issueCandidate(st *State, c *candidate) *commandState {
+	if c.hit {
+		st.RowBufferHits++
+	} else {
+		st.RowBufferMisses++
+	}
+
+	if c.ready.Kind == c.entry.Command.Kind {
+		removeCommandFromQueueByIndex(st, c.index)
+	}
+
+	return c.ready
+}
+
+// byRowHit ranks row hits first.
+func byRowHit(a, b *candidate) (before, decided bool) {
+	if a.hit != b.hit {
+		return a.hit, true
+	}
+
+	return false, false
+}
+
+// frfcfsCapScheduler is FR-FCFS with a cap on consecutive row hits per bank.
+type frfcfsCapScheduler struct {
+	buf []candidate
+}
+
+func (*frfcfsCapScheduler) Name() string { return schedulerFRFCFSCap }
+
+func (s *frfcfsCapScheduler) Pick(spec *Spec, st *State, _ *dramTiming) *commandState {
+	streaks := bankCounters(st, &st.Scheduler.RowHitStreaks)
+
+	capped := func(c *candidate) bool {
+		idx := bankIndex(&st.BankStates, c.entry.Command.Location)
+
+		return c.hit && streaks[idx] < spec.SchedulerRowHitCap
+	}
+
+	c, found := bestCandidate(spec, st, &s.buf, func(a, b *candidate) bool {
+		return capped(a) && !capped(b)
+	})
+	if !found {
+		return nil
+	}
+
+	idx := bankIndex(&st.BankStates, c.ready.Location)
+
+	switch {
+	case commandKind(c.ready.Kind) == cmdKindActivate:
+		streaks[idx] = 0
+	case c.hit:
+		streaks[idx]++
+	}
+
+	return issueCandidate(st, &c)
+}
+
+// bankCounters returns a per-bank counter slice, creating it on first use.
+func bankCounters(st *State, counters *[]int) []int {
+	n := len(st.BankStates.Entries)
+	if len(*counters) != n {
+		*counters = make([]int, n)
+	}
+
+	return *counters
+}
+
+// blissScheduler is the Blacklisting memory scheduler (Subramanian et al.,
+// ICCD 2014).
+type blissScheduler struct {
+	buf []candidate
+}
+
+func (*blissScheduler) Name() string { return schedulerBLISS }
+
+func (s *blissScheduler) Pick(spec *Spec, st *State, _ *dramTiming) *commandState {
+	ss := &st.Scheduler
+
+	if st.TickCount >= ss.NextClear {
+		ss.Blacklist = nil
+		ss.NextClear = st.TickCount + uint64(spec.SchedulerClearInterval)
+	}
+
+	c, found := bestCandidate(spec, st, &s.buf, func(a, b *candidate) bool {
+		aListed, bListed := ss.Blacklist[a.entry.Source], ss.Blacklist[b.entry.Source]
+		if aListed != bListed {
+			return bListed
+		}
+
+		before, _ := byRowHit(a, b)
+
+		return before
+	})
+	if !found {
+		return nil
+	}
+
+	if isReadOrWrite(commandKind(c.ready.Kind)) {
+		s.serve(spec, ss, c.entry.Source)
+	}
+
+	return issueCandidate(st, &c)
+}
+
+// serve counts a column command served to a source and blacklists the source
+// once its streak passes the threshold.
+func (*blissScheduler) serve(spec *Spec, ss *schedulerState, source string) {
+	if source != ss.LastSource {
+		ss.LastSource = source
+		ss.Streak = 0
+	}
+
+	ss.Streak++
+
+	if ss.Streak >= spec.SchedulerBlacklistThreshold {
+		if ss.Blacklist == nil {
+			ss.Blacklist = map[string]bool{}
+		}
+
+		ss.Blacklist[source] = true
+	}
+}
+
+// parbsScheduler is the Parallelism-Aware Batch Scheduler (Mutlu and
+// Moscibroda, ISCA 2008).
+type parbsScheduler struct {
+	buf []candidate
+}
+
+func (*parbsScheduler) Name() string { return schedulerPARBS }
+
+func (s *parbsScheduler) Pick(spec *Spec, st *State, _ *dramTiming) *commandState {
+	if !batchInProgress(st) {
+		formBatch(spec, st)
+	}
+
+	ranks := st.Scheduler.BatchRanks
+	rank := func(c *candidate) int {
+		if r, ok := ranks[c.entry.Source]; ok {
+			return r
+		}
+
+		return len(ranks)
+	}
+
+	c, found := bestCandidate(spec, st, &s.buf, func(a, b *candidate) bool {
+		if a.entry.Marked != b.entry.Marked {
+			return a.entry.Marked
+		}
+
+		if before, decided := byRowHit(a, b); decided {
+			return before
+		}
+
+		return rank(a) < rank(b)
+	})
+	if !found {
+		return nil
+	}
+
+	return issueCandidate(st, &c)
+}
+
+// batchInProgress returns true while a command of the current batch waits.
+func batchInProgress(st *State) bool {
+	for i := range st.CommandQueues.Entries {
+		if st.CommandQueues.Entries[i].Marked {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formBatch marks the oldest SchedulerMarkingCap commands of each source to
+// each bank and ranks the sources: the source with the lowest load on any one
+// bank first, then the one with the fewest marked commands.
+func formBatch(spec *Spec, st *State) {
+	type load struct {
+		source   string
+		maxBank  int
+		total    int
+		perBanks map[int]int
+	}
+
+	loads := map[string]*load{}
+	sources := []string{}
+
+	for i := range st.CommandQueues.Entries {
+		e := &st.CommandQueues.Entries[i]
+		bank := bankIndex(&st.BankStates, e.Command.Location)
+
+		l, ok := loads[e.Source]
+		if !ok {
+			l = &load{source: e.Source, perBanks: map[int]int{}}
+			loads[e.Source] = l
+			sources = append(sources, e.Source)
+		}
+
+		if l.perBanks[bank] >= spec.SchedulerMarkingCap {
+			continue
+		}
+
+		e.Marked = true
+		l.perBanks[bank]++
+		l.total++
+		l.maxBank = max(l.maxBank, l.perBanks[bank])
+	}
+
+	slices.SortStableFunc(sources, func(a, b string) int {
+		la, lb := loads[a], loads[b]
+		if la.maxBank != lb.maxBank {
+			return la.maxBank - lb.maxBank
+		}
+
+		return la.total - lb.total
+	})
+
+	st.Scheduler.BatchRanks = make(map[string]int, len(sources))
+	for i, source := range sources {
+		st.Scheduler.BatchRanks[source] = i
+	}
+}
+
+// atlasScheduler is the Adaptive per-Thread Least-Attained-Service scheduler
+// (Kim et al., HPCA 2010), for a single controller.
+type atlasScheduler struct {
+	buf []candidate
+}
+
+func (*atlasScheduler) Name() string { return schedulerATLAS }
+
+func (s *atlasScheduler) Pick(spec *Spec, st *State, _ *dramTiming) *commandState {
+	ss := &st.Scheduler
+
+	if st.TickCount >= ss.NextQuantum {
+		endQuantum(ss)
+		ss.NextQuantum = st.TickCount + uint64(spec.SchedulerQuantum)
+	}
+
+	starving := func(c *candidate) bool {
+		return st.TickCount-c.entry.EnqueueTick >= atlasStarvationCycles
+	}
+
+	c, found := bestCandidate(spec, st, &s.buf, func(a, b *candidate) bool {
+		if starving(a) != starving(b) {
+			return starving(a)
+		}
+
+		aService := ss.AttainedService[a.entry.Source]
+		bService := ss.AttainedService[b.entry.Source]
+		if aService != bService {
+			return aService < bService
+		}
+
+		before, _ := byRowHit(a, b)
+
+		return before
+	})
+	if !found {
+		return nil
+	}
+
+	if ss.QuantumService == nil {
+		ss.QuantumService = map[string]float64{}
+	}
+
+	ss.QuantumService[c.entry.Source] += float64(commandService(spec, c.ready))
+
+	return issueCandidate(st, &c)
+}
+
+// endQuantum folds the service of the quantum into the attained service.
+func endQuantum(ss *schedulerState) {
+	if ss.AttainedService == nil {
+		ss.AttainedService = map[string]float64{}
+	}
+
+	for source, service := range ss.AttainedService {
+		ss.AttainedService[source] = atlasHistoryWeight * service
+	}
+
+	for source, service := range ss.QuantumService {
+		ss.AttainedService[source] += (1 - atlasHistoryWeight) * service
+	}
+
+	ss.QuantumService = nil
+}
+
+// commandService returns the bank cycles a command occupies, the service
+// ATLAS attributes to its source.
+func commandService(spec *Spec, cmd *commandState) int {
+	switch commandKind(cmd.Kind) {
+	case cmdKindActivate:
+		return spec.TRCD
+	case cmdKindPrecharge:
+		return spec.TRP
+	default:
+		return spec.BurstCycle
+	}
+}
+
+// priorityScheduler serves the sources with the highest configured priority
+// first, and FR-FCFS within a priority.
+type priorityScheduler struct {
+	buf []candidate
+}
+
+func (*priorityScheduler) Name() string { return schedulerPriority }
+
+func (s *priorityScheduler) Pick(spec *Spec, st *State, _ *dramTiming) *commandState {
+	c, found := bestCandidate(spec, st, &s.buf, func(a, b *candidate) bool {
+		aPrio := spec.SchedulerPriorities[a.entry.Source]
+		bPrio := spec.SchedulerPriorities[b.entry.Source]
+		if aPrio != bPrio {
+			return aPrio > bPrio
+		}
+
+		before, _ := byRowHit(a, b)
+
+		return before
+	})
+	if !found {
+		return nil
+	}
+
+	return issueCandidate(st, &c)
+}
+
+// schedulerMustBeValid rejects unknown schedulers and request sources, and
+// fills in the default scheduler parameters.
+func (b *Builder) schedulerMustBeValid() {
+	s := &b.spec
+
+	if _, ok := schedulerRegistry[s.Scheduler]; !ok && s.Scheduler != "" {
+		panic(fmt.Sprintf("dram: unknown scheduler %q", s.Scheduler))
+	}
+
+	switch s.SchedulerSource {
+	case "":
+		s.SchedulerSource = schedulerSourceSrc
+	case schedulerSourceSrc, schedulerSourcePID, schedulerSourceTrafficClass:
+	default:
+		panic(fmt.Sprintf("dram: unknown scheduler source %q", s.SchedulerSource))
+	}
+
+	params := []struct {
+		name  string
+		value *int
+		def   int
+	}{
+		{"SchedulerRowHitCap", &s.SchedulerRowHitCap, defaultSchedulerRowHitCap},
+		{"SchedulerBlacklistThreshold", &s.SchedulerBlacklistThreshold,
+			defaultSchedulerBlacklistThreshold},
+		{"SchedulerClearInterval", &s.SchedulerClearInterval,
+			defaultSchedulerClearInterval},
+		{"SchedulerMarkingCap", &s.SchedulerMarkingCap, defaultSchedulerMarkingCap},
+		{"SchedulerQuantum", &s.SchedulerQuantum, defaultSchedulerQuantum},
+	}
+
+	for _, p := range params {
+		switch {
+		case *p.value < 0:
+			panic(fmt.Sprintf("dram: %s must not be negative", p.name))
+		case *p.value == 0:
+			*p.value = p.def
+		}
+	}
+}
@@ -1,5 +1,378 @@
 package dram
 
+import (
+	"math"
+	"slices"
+
+	"github.com/sarchlab/akita/v5/naming"
+	"github.com/sarchlab/akita/v5/stats"
+)
+
+// registerStats publishes the statistics the controller keeps in its State to
+// a stats registry, under the component's name. The counts stay in State,
+// where the control protocol's reset and checkpoints see them; the registry
+// reads them and measures regions of interest from its own baselines.
+func registerStats(reg *stats.Registry, c *Comp, model *energyModel) {
+	name := func(stat string) string { return naming.BuildName(c.Name(), stat) }
+	counter := func(stat, desc string, field func(s *State) uint64) *stats.Counter {
+		return reg.NewCounterFunc(name(stat), desc, func() uint64 {
+			return field(&c.State)
+		})
+	}
+
+	counter("ReadCommands", "read commands issued",
+		func(s *State) uint64 { return s.TotalReadCommands })
+	counter("WriteCommands", "write commands issued",
+		func(s *State) uint64 { return s.TotalWriteCommands })
+	counter("Activates", "activate commands issued",
+		func(s *State) uint64 { return s.TotalActivates })
+	counter("Precharges", "precharge commands issued",
+		func(s *State) uint64 { return s.TotalPrecharges })
+	counter("Refreshes", "all-bank refresh commands issued",
+		func(s *State) uint64 { return s.TotalRefreshes })
+	counter("BankRefreshes", "per-bank and same-bank refresh commands issued",
+		func(s *State) uint64 { return s.TotalBankRefreshes })
+	counter("PostponedRefreshes", "refreshes postponed while commands waited",
+		func(s *State) uint64 { return s.PostponedRefreshes })
+	counter("PulledInRefreshes", "refreshes issued ahead of schedule while idle",
+		func(s *State) uint64 { return s.PulledInRefreshes })
+	counter("RefreshStallCycles", "cycles in which refresh held off a queued command",
+		func(s *State) uint64 { return s.RefreshStallCycles })
+	counter("PowerDownEntries", "power-down entry commands issued",
+		func(s *State) uint64 { return s.PowerDownEntries })
+	counter("SelfRefreshEntries", "self-refresh entry commands issued",
+		func(s *State) uint64 { return s.SelfRefreshEntries })
+	counter("LowPowerStallCycles",
+		"cycles in which a queued command waited for its rank to wake",
+		func(s *State) uint64 { return s.LowPowerStallCycles })
+	counter("PreventiveRefreshes", "victim-row refreshes issued",
+		func(s *State) uint64 { return s.PreventiveRefreshes })
+	counter("RFMs", "refresh management commands issued",
+		func(s *State) uint64 { return s.TotalRFMs })
+	counter("BackOffs", "PRAC alert back-offs",
+		func(s *State) uint64 { return s.BackOffs })
+	counter("RowHammerStallCycles",
+		"cycles in which a queued command waited on a row-hammer mitigation",
+		func(s *State) uint64 { return s.RowHammerStallCycles })
+	counter("MaxRowActivations",
+		"most activations of a row before its neighbors were refreshed",
+		func(s *State) uint64 { return s.MaxRowActivations })
+	counter("RowHammerCrossings", "times a row reached the row-hammer threshold",
+		func(s *State) uint64 { return s.RowHammerCrossings })
+
+	hits := counter("RowBufferHits", "accesses that hit an open row",
+		func(s *State) uint64 { return s.RowBufferHits })
+	misses := counter("RowBufferMisses", "accesses that missed the open row",
+		func(s *State) uint64 { return s.RowBufferMisses })
+	cycles := counter("Cycles", "cycles ticked",
+		func(s *State) uint64 { return s.TotalCycles })
+	reads := counter("CompletedReads", "read requests completed",
+		func(s *State) uint64 { return s.CompletedReads })
+	writes := counter("CompletedWrites", "write requests completed",
+		func(s *State) uint64 { return s.CompletedWrites })
+	readLatency := counter("ReadLatencyCycles", "total read latency in cycles",
+		func(s *State) uint64 { return s.TotalReadLatencyCycles })
+	writeLatency := counter("WriteLatencyCycles", "total write latency in cycles",
+		func(s *State) uint64 { return s.TotalWriteLatencyCycles })
+	bytesRead := counter("BytesRead", "bytes read",
+		func(s *State) uint64 { return s.BytesRead })
+	bytesWritten := counter("BytesWritten", "bytes written",
+		func(s *State) uint64 { return s.BytesWritten })
+
+	ratio := func(a, b *stats.Counter) func() float64 {
+		return func() float64 {
+			if b.Value() == 0 {
+				return 0
+			}
+
+			return float64(a.Value()) / float64(b.Value())
+		}
+	}
+
+	reg.NewFormula(name("RowBufferHitRate"), "row buffer hits per access",
+		func() float64 {
+			total := hits.Value() + misses.Value()
+			if total == 0 {
+				return 0
+			}
+
+			return float64(hits.Value()) / float64(total)
+		})
+	reg.NewFormula(name("AverageReadLatency"), "cycles per read",
+		ratio(readLatency, reads))
+	reg.NewFormula(name("AverageWriteLatency"), "cycles per write",
+		ratio(writeLatency, writes))
+	reg.NewFormula(name("ReadBandwidth"), "bytes read per cycle",
+		ratio(bytesRead, cycles))
+	reg.NewFormula(name("WriteBandwidth"), "bytes written per cycle",
+		ratio(bytesWritten, cycles))
+
+	registerChannelStats(reg, c, model)
+	registerEnergyStats(reg, c, model)
+	registerLowPowerStats(reg, c, model)
+	registerThermalStats(reg, c)
+}
+
+// registerThermalStats publishes, if the thermal model is on, the temperature
+// (°C) of every thermal node as of the last thermal epoch
+// (Thermal.Rank[r].Temperature, or Thermal.Layer[l].Temperature for a stack),
+// its peak, and the peak over every node.
+func registerThermalStats(reg *stats.Registry, c *Comp) {
+	spec := c.Spec()
+	if !thermalEnabled(&spec) {
+		return
+	}
+
+	prefix := naming.BuildName(c.Name(), "Thermal")
+
+	for i := range numThermalNodes(&spec) {
+		node := naming.BuildNameWithIndex(prefix, thermalNodeKind(&spec), i)
+
+		reg.NewFormula(naming.BuildName(node, "Temperature"),
+			"temperature at the last thermal epoch (°C)",
+			func() float64 { return c.State.Thermal.Temperatures[i] })
+		reg.NewFormula(naming.BuildName(node, "PeakTemperature"),
+			"highest temperature (°C)",
+			func() float64 { return c.State.Thermal.PeakTemperatures[i] })
+	}
+
+	reg.NewFormula(naming.BuildName(prefix, "PeakTemperature"),
+		"highest temperature of any thermal node (°C)",
+		func() float64 { return slices.Max(c.State.Thermal.PeakTemperatures) })
+}
+
+// registerLowPowerStats publishes the rank-cycles spent in power-down and in
+// self-refresh, summed over the ranks, including the cycles a sleeping
+// controller has not accounted yet.
+func registerLowPowerStats(reg *stats.Registry, c *Comp, model *energyModel) {
+	rankCycles := func(states ...rankPowerState) func() uint64 {
+		return func() uint64 {
+			now := c.Spec().Freq.Cycle(c.CurrentTime())
+
+			sum := uint64(0)
+			for _, r := range energyAt(model, &c.State, now).Ranks {
+				for _, s := range states {
+					sum += r.Cycles[s]
+				}
+			}
+
+			return sum
+		}
+	}
+
+	reg.NewCounterFunc(naming.BuildName(c.Name(), "PowerDownCycles"),
+		"rank-cycles in active or precharge power-down",
+		rankCycles(powerActivePowerDown, powerPrechargePowerDown))
+	reg.NewCounterFunc(naming.BuildName(c.Name(), "SelfRefreshCycles"),
+		"rank-cycles in self-refresh", rankCycles(powerSelfRefresh))
+}
+
+// channelCounters names the per-channel command counts, as stat names.
+var channelCounters = []struct {
+	stat, desc string
+	value      func(s *channelStats) uint64
+}{
+	{"ReadCommands", "read commands issued on the channel",
+		func(s *channelStats) uint64 { return s.ReadCommands }},
+	{"WriteCommands", "write commands issued on the channel",
+		func(s *channelStats) uint64 { return s.WriteCommands }},
+	{"Activates", "activate commands issued on the channel",
+		func(s *channelStats) uint64 { return s.Activates }},
+	{"Precharges", "precharge commands issued on the channel",
+		func(s *channelStats) uint64 { return s.Precharges }},
+	{"RowBufferHits", "accesses on the channel that hit an open row",
+		func(s *channelStats) uint64 { return s.RowBufferHits }},
+	{"RowBufferMisses", "accesses on the channel that missed the open row",
+		func(s *channelStats) uint64 { return s.RowBufferMisses }},
+}
+
+// registerChannelStats publishes the command counts of each channel
+// (Channel[c].*), with its row-buffer hit rate, its bandwidth from the
+// access units its column commands move, and its energy.
+func registerChannelStats(reg *stats.Registry, c *Comp, model *energyModel) {
+	spec := c.Spec()
+	unit := float64(uint64(1) << spec.Log2AccessUnitSize)
+	ranksPerChannel := numRanks(&spec) / spec.NumChannel
+
+	for ch := range spec.NumChannel {
+		prefix := naming.BuildNameWithIndex(c.Name(), "Channel", ch)
+		stat := func(s *State) *channelStats {
+			if ch >= len(s.Channels) {
+				return &channelStats{}
+			}
+
+			return &s.Channels[ch]
+		}
+
+		for _, counter := range channelCounters {
+			reg.NewCounterFunc(naming.BuildName(prefix, counter.stat), counter.desc,
+				func() uint64 { return counter.value(stat(&c.State)) })
+		}
+
+		perCycle := func(v uint64) float64 {
+			if c.State.TotalCycles == 0 {
+				return 0
+			}
+
+			return float64(v) * unit / float64(c.State.TotalCycles)
+		}
+
+		reg.NewFormula(naming.BuildName(prefix, "RowBufferHitRate"),
+			"row buffer hits per access on the channel",
+			func() float64 {
+				s := stat(&c.State)
+				total := s.RowBufferHits + s.RowBufferMisses
+				if total == 0 {
+					return 0
+				}
+
+				return float64(s.RowBufferHits) / float64(total)
+			})
+		reg.NewFormula(naming.BuildName(prefix, "ReadBandwidth"),
+			"bytes read on the channel per cycle",
+			func() float64 { return perCycle(stat(&c.State).ReadCommands) })
+		reg.NewFormula(naming.BuildName(prefix, "WriteBandwidth"),
+			"bytes written on the channel per cycle",
+			func() float64 { return perCycle(stat(&c.State).WriteCommands) })
+		reg.NewCounterFunc(naming.BuildName(prefix, "Energy"),
+			"energy of the channel's ranks (pJ)",
+			func() uint64 {
+				now := spec.Freq.Cycle(c.CurrentTime())
+				e := energyAt(model, &c.State, now)
+
+				sum := 0.0
+				for _, r := range e.Ranks[ch*ranksPerChannel : (ch+1)*ranksPerChannel] {
+					sum += r.Total()
+				}
+
+				return uint64(math.Round(sum))
+			})
+	}
+}
+
+// energyComponents names the parts of a rank's energy, as stat names.
+var energyComponents = []struct {
+	stat, desc string
+	value      func(e *rankEnergy) float64
+}{
+	{"ActivatePrecharge", "activate and precharge energy (pJ)",
+		func(e *rankEnergy) float64 { return e.Command.ActivatePrecharge }},
+	{"Read", "read energy (pJ)",
+		func(e *rankEnergy) float64 { return e.Command.Read }},
+	{"Write", "write energy (pJ)",
+		func(e *rankEnergy) float64 { return e.Command.Write }},
+	{"Refresh", "refresh energy (pJ)",
+		func(e *rankEnergy) float64 { return e.Command.Refresh }},
+	{"ActiveStandby", "active-standby background energy (pJ)",
+		func(e *rankEnergy) float64 { return e.Background[powerActiveStandby] }},
+	{"PrechargeStandby", "precharge-standby background energy (pJ)",
+		func(e *rankEnergy) float64 { return e.Background[powerPrechargeStandby] }},
+	{"ActivePowerDown", "active power-down background energy (pJ)",
+		func(e *rankEnergy) float64 { return e.Background[powerActivePowerDown] }},
+	{"PrechargePowerDown", "precharge power-down background energy (pJ)",
+		func(e *rankEnergy) float64 { return e.Background[powerPrechargePowerDown] }},
+	{"SelfRefresh", "self-refresh background energy (pJ)",
+		func(e *rankEnergy) float64 { return e.Background[powerSelfRefresh] }},
+	{"Total", "total energy (pJ)",
+		func(e *rankEnergy) float64 { return e.Total() }},
+}
+
+// registerEnergyStats publishes the energy, in whole picojoules, in total
+// (Energy.*), per rank (Energy.Rank[r].*, r numbering the ranks of every
+// channel and pseudo-channel, see rankIndex), and the command energy per bank
+// (Energy.Rank[r].BankGroup[g].Bank[b].*), with the average power. The
+// background energy of the cycles a sleeping controller has not accounted yet
+// is included.
+func registerEnergyStats(reg *stats.Registry, c *Comp, model *energyModel) {
+	energy := func() energyState {
+		now := c.Spec().Freq.Cycle(c.CurrentTime())
+		return energyAt(model, &c.State, now)
+	}
+	pJ := func(v float64) uint64 { return uint64(math.Round(v)) }
+
+	spec := c.Spec()
+	prefix := naming.BuildName(c.Name(), "Energy")
+
+	var total *stats.Counter
+
+	for _, comp := range energyComponents {
+		counter := reg.NewCounterFunc(naming.BuildName(prefix, comp.stat), comp.desc,
+			func() uint64 {
+				sum := 0.0
+				for _, r := range energy().Ranks {
+					sum += comp.value(&r)
+				}
+
+				return pJ(sum)
+			})
+
+		if comp.stat == "Total" {
+			total = counter
+		}
+	}
+
+	for r := range numRanks(&spec) {
+		rankName := naming.BuildNameWithIndex(prefix, "Rank", r)
+
+		for _, comp := range energyComponents {
+			reg.NewCounterFunc(naming.BuildName(rankName, comp.stat), comp.desc,
+				func() uint64 { return pJ(comp.value(&energy().Ranks[r])) })
+		}
+
+		registerBankEnergyStats(reg, c, rankName, r)
+	}
+
+	cycles := reg.NewCounterFunc(naming.BuildName(c.Name(), "EnergyCycles"),
+		"cycles the energy covers",
+		func() uint64 { return energy().AccountedCycle })
+
+	tCK := float64(spec.Freq.Period()) / 1000
+	reg.NewFormula(naming.BuildName(c.Name(), "AveragePower"),
+		"average power (mW)",
+		func() float64 {
+			if cycles.Value() == 0 {
+				return 0
+			}
+
+			return float64(total.Value()) / (float64(cycles.Value()) * tCK)
+		})
+}
+
+// bankEnergyComponents names the parts of a bank's command energy.
+var bankEnergyComponents = []struct {
+	stat, desc string
+	value      func(e *commandEnergy) float64
+}{
+	{"ActivatePrecharge", "activate and precharge energy of the bank (pJ)",
+		func(e *commandEnergy) float64 { return e.ActivatePrecharge }},
+	{"Read", "read energy of the bank (pJ)",
+		func(e *commandEnergy) float64 { return e.Read }},
+	{"Write", "write energy of the bank (pJ)",
+		func(e *commandEnergy) float64 { return e.Write }},
+	{"Refresh", "refresh energy of the bank (pJ)",
+		func(e *commandEnergy) float64 { return e.Refresh }},
+}
+
+// registerBankEnergyStats publishes the command energy of each bank of a rank.
+func registerBankEnergyStats(reg *stats.Registry, c *Comp, rankName string, rank int) {
+	spec := c.Spec()
+
+	for g := range spec.NumBankGroup {
+		for b := range spec.NumBank {
+			bankName := naming.BuildNameWithIndex(
+				naming.BuildNameWithIndex(rankName, "BankGroup", g), "Bank", b)
+			idx := bankFlatIndex(&c.State.BankStates, rank, g, b)
+
+			for _, comp := range bankEnergyComponents {
+				reg.NewCounterFunc(naming.BuildName(bankName, comp.stat), comp.desc,
+					func() uint64 {
+						return uint64(math.Round(comp.value(&c.State.Energy.Banks[idx])))
+					})
+			}
+		}
+	}
+}
+
 // RowBufferHitRate returns the row-buffer hit rate (0.0 to 1.0).
 func RowBufferHitRate(s *State) float64 {
 	total := s.RowBufferHits + s.RowBufferMisses
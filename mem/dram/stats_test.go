@@ -8,9 +8,19 @@ import (
 	"github.com/sarchlab/akita/v5/messaging"
 	"github.com/sarchlab/akita/v5/modeling"
 	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/stats"
 	"github.com/sarchlab/akita/v5/timing"
 )
 
+// statsRegistrar is a standalone registrar that provides a stats registry, as
+// a simulation does.
+type statsRegistrar struct {
+	modeling.Registrar
+	registry *stats.Registry
+}
+
+func (r statsRegistrar) Stats() *stats.Registry { return r.registry }
+
 var _ = Describe("DRAM Statistics", func() {
 	// Unit tests for stat computation functions
 	It("should compute row buffer hit rate", func() {
@@ -142,4 +152,36 @@ var _ = Describe("DRAM Statistics", func() {
 		Expect(dram.ReadBandwidth(state)).To(BeNumerically(">", 0))
 		Expect(dram.WriteBandwidth(state)).To(BeNumerically(">", 0))
 	})
+
+	It("should publish statistics to the registrar's registry", func() {
+		engine := timing.NewSerialEngine()
+		registry := stats.NewRegistry(stats.DefaultName)
+		reg := statsRegistrar{
+			Registrar: modeling.NewStandaloneRegistrar(engine),
+			registry:  registry,
+		}
+
+		dramComp := dram.MakeBuilder().
+			WithRegistrar(reg).
+			WithSpec(dram.DefaultSpec()).
+			Build("StatsDRAM")
+
+		dramComp.State.CompletedReads = 4
+		dramComp.State.RowBufferHits = 3
+		dramComp.State.RowBufferMisses = 1
+
+		reads, found := registry.Lookup("StatsDRAM.CompletedReads")
+		Expect(found).To(BeTrue())
+		Expect(reads.(*stats.Counter).Value()).To(Equal(uint64(4)))
+
+		hitRate, found := registry.Lookup("StatsDRAM.RowBufferHitRate")
+		Expect(found).To(BeTrue())
+		Expect(hitRate.(*stats.Formula).Value()).To(
+			BeNumerically("~", 0.75, 0.001))
+
+		registry.Reset()
+		dramComp.State.CompletedReads = 6
+		Expect(reads.(*stats.Counter).Value()).To(Equal(uint64(2)))
+		Expect(dramComp.State.CompletedReads).To(Equal(uint64(6)))
+	})
 })
@@ -0,0 +1,371 @@
+package dram
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+// The thermal model is a compact RC network driven by the power model. Each
+// node has a heat capacity and conducts heat to the ambient or to its
+// neighbors through thermal resistances:
+//
+//   - With ThermalLayers of 0 or 1, every rank is a node with its own path to
+//     the ambient, as the ranks of a DIMM or the dies of a planar package.
+//   - With ThermalLayers > 1, the nodes are the layers of a 3D stack. Layer 0
+//     sits under the heat sink and conducts to the ambient; every other layer
+//     conducts only through the layer above it, so the heat of the lower
+//     layers crosses the upper ones. The ranks are spread over the layers in
+//     rank-index order, and each layer draws the power of its ranks.
+//
+// Every ThermalEpoch cycles, the energy each rank consumed in the epoch gives
+// its power, and the node temperatures are integrated over the epoch. JEDEC
+// requires a DRAM to refresh twice as often above 85°C: every threshold of
+// ThermalRefreshThresholds a node exceeds halves the refresh interval of its
+// ranks. Temperatures are in °C, resistances in °C/W, and capacities in J/°C.
+
+// Defaults of the thermal parameters. The time constant of a rank, RC, is
+// 1 ms, much shorter than a package's seconds, so a simulation of
+// milliseconds reaches the temperatures a workload leads to.
+const (
+	defaultAmbientTemperature     = 45.0
+	defaultThermalResistance      = 10.0
+	defaultThermalCapacitance     = 1e-4
+	defaultLayerThermalResistance = 2.0
+)
+
+// defaultThermalTraceLength bounds the temperature trace, which is part of the
+// checkpointed state, to the last 4096 epochs.
+const defaultThermalTraceLength = 4096
+
+// defaultThermalRefreshThresholds doubles the refresh rate above 85°C, the
+// JEDEC extended temperature range.
+var defaultThermalRefreshThresholds = []float64{85}
+
+// TemperatureSample is the temperature of every thermal node at the end of a
+// thermal epoch.
+type TemperatureSample struct {
+	Cycle        uint64    `json:"cycle"`
+	Temperatures []float64 `json:"temperatures"`
+}
+
+// thermalState is the temperature of the thermal nodes, maintained by the
+// thermal middleware.
+type thermalState struct {
+	// NextEpoch is the cycle at which the current epoch ends.
+	NextEpoch uint64 `json:"next_epoch"`
+	// RankEnergy is the energy each rank had consumed at the start of the
+	// epoch, in pJ.
+	RankEnergy []float64 `json:"rank_energy"`
+
+	Temperatures     []float64 `json:"temperatures"`
+	PeakTemperatures []float64 `json:"peak_temperatures"`
+
+	// RefreshShift is, per rank, the number of refresh thresholds its node
+	// exceeds; the rank refreshes every TREFI >> RefreshShift cycles.
+	RefreshShift []int `json:"refresh_shift"`
+
+	// Trace holds the temperatures at the end of the last
+	// Spec.ThermalTraceLength epochs, oldest first.
+	Trace []TemperatureSample `json:"trace"`
+}
+
+// thermalEnabled returns true if the spec turns the thermal model on.
+func thermalEnabled(spec *Spec) bool {
+	return spec.ThermalEpoch > 0
+}
+
+// numThermalNodes returns the number of nodes of the thermal network.
+func numThermalNodes(spec *Spec) int {
+	if spec.ThermalLayers > 1 {
+		return spec.ThermalLayers
+	}
+
+	return numRanks(spec)
+}
+
+// thermalNode returns the thermal node of a rank index.
+func thermalNode(spec *Spec, rank int) int {
+	if spec.ThermalLayers > 1 {
+		return rank * spec.ThermalLayers / numRanks(spec)
+	}
+
+	return rank
+}
+
+// thermalNodeKind names the kind of the nodes, "Layer" or "Rank", for
+// statistics and traces.
+func thermalNodeKind(spec *Spec) string {
+	if spec.ThermalLayers > 1 {
+		return "Layer"
+	}
+
+	return "Rank"
+}
+
+// initThermalState starts every node at the ambient temperature, with the
+// first epoch ending ThermalEpoch cycles after a cycle.
+func initThermalState(spec *Spec, cycle uint64) thermalState {
+	if !thermalEnabled(spec) {
+		return thermalState{}
+	}
+
+	temps := make([]float64, numThermalNodes(spec))
+	for i := range temps {
+		temps[i] = spec.AmbientTemperature
+	}
+
+	return thermalState{
+		NextEpoch:        cycle + uint64(spec.ThermalEpoch),
+		RankEnergy:       make([]float64, numRanks(spec)),
+		Temperatures:     temps,
+		PeakTemperatures: slices.Clone(temps),
+		RefreshShift:     make([]int, numRanks(spec)),
+	}
+}
+
+// refreshInterval returns the cycles between two refreshes of a rank at its
+// temperature.
+func refreshInterval(spec *Spec, state *State, rank int) int {
+	if rank >= len(state.Thermal.RefreshShift) {
+		return spec.TREFI
+	}
+
+	return spec.TREFI >> state.Thermal.RefreshShift[rank]
+}
+
+// thermalMiddleware advances the temperatures by thermal epochs. It runs ahead
+// of the power middleware, so the background energy up to an epoch's end is
+// extrapolated in the power states the ranks have been in since the last tick,
+// which makes the epochs a sleeping controller skipped come out as if it had
+// ticked. Like the power model, it keeps going while the controller is paused.
+type thermalMiddleware struct {
+	comp  *Comp
+	model *energyModel
+}
+
+// Tick ends every epoch that has elapsed. It never makes progress on its own;
+// the epochs of a controller that sleeps until the end of the simulation are
+// not recorded.
+func (m *thermalMiddleware) Tick() bool {
+	spec := m.comp.Spec()
+	if !thermalEnabled(&spec) {
+		return false
+	}
+
+	state := &m.comp.State
+	now := spec.Freq.Cycle(m.comp.CurrentTime())
+
+	for state.Thermal.NextEpoch <= now {
+		// The refreshes of a sleeping controller count toward the epochs
+		// they fell in, at the rates those epochs set.
+		catchUpRefresh(&spec, state, m.model, state.Thermal.NextEpoch)
+		m.endEpoch(&spec, state, state.Thermal.NextEpoch)
+		state.Thermal.NextEpoch += uint64(spec.ThermalEpoch)
+	}
+
+	return false
+}
+
+// endEpoch integrates the node temperatures over the epoch that ends at a
+// cycle, records them, and updates the refresh rate of the ranks.
+func (m *thermalMiddleware) endEpoch(spec *Spec, state *State, cycle uint64) {
+	th := &state.Thermal
+	energy := energyAt(m.model, state, cycle)
+	seconds := float64(spec.ThermalEpoch) * float64(spec.Freq.Period()) * 1e-12
+
+	power := make([]float64, len(th.Temperatures))
+	for r := range energy.Ranks {
+		total := energy.Ranks[r].Total()
+		power[thermalNode(spec, r)] += (total - th.RankEnergy[r]) * 1e-12 / seconds
+		th.RankEnergy[r] = total
+	}
+
+	integrateTemperatures(spec, th.Temperatures, power, seconds)
+
+	for i, t := range th.Temperatures {
+		th.PeakTemperatures[i] = max(th.PeakTemperatures[i], t)
+	}
+
+	if len(th.Trace) >= spec.ThermalTraceLength {
+		th.Trace = slices.Delete(th.Trace, 0, len(th.Trace)-spec.ThermalTraceLength+1)
+	}
+
+	th.Trace = append(th.Trace, TemperatureSample{
+		Cycle:        cycle,
+		Temperatures: slices.Clone(th.Temperatures),
+	})
+
+	for r := range th.RefreshShift {
+		shift := refreshShiftAt(spec, th.Temperatures[thermalNode(spec, r)])
+		m.setRefreshShift(state, r, shift)
+	}
+}
+
+// refreshShiftAt counts the refresh thresholds a temperature exceeds.
+func refreshShiftAt(spec *Spec, temperature float64) int {
+	shift := 0
+
+	for _, threshold := range spec.ThermalRefreshThresholds {
+		if temperature > threshold {
+			shift++
+		}
+	}
+
+	return shift
+}
+
+// setRefreshShift changes the refresh rate of a rank. The countdowns of the
+// rank's refresh targets scale with the interval, so the targets keep their
+// stagger.
+func (m *thermalMiddleware) setRefreshShift(state *State, rank, shift int) {
+	old := state.Thermal.RefreshShift[rank]
+	if shift == old {
+		return
+	}
+
+	state.Thermal.RefreshShift[rank] = shift
+
+	for i := range state.Refresh.Targets {
+		t := &state.Refresh.Targets[i]
+		if t.Rank != rank {
+			continue
+		}
+
+		t.Countdown = max((t.Countdown<<old)>>shift, 1)
+	}
+}
+
+// integrateTemperatures advances the node temperatures over a time span under
+// the nodes' power, in W. It takes explicit Euler steps of a tenth of the
+// shortest time constant of a node, well within stability.
+func integrateTemperatures(spec *Spec, temps, power []float64, seconds float64) {
+	gAmbient := 1 / spec.ThermalResistance
+	gLayer := 0.0
+
+	if spec.ThermalLayers > 1 {
+		gLayer = 1 / spec.LayerThermalResistance
+	}
+
+	step := 0.1 * spec.ThermalCapacitance / (gAmbient + 2*gLayer)
+	steps := max(int(math.Ceil(seconds/step)), 1)
+	dt := seconds / float64(steps)
+	flow := make([]float64, len(temps))
+
+	for range steps {
+		for i := range temps {
+			flow[i] = power[i]
+
+			if spec.ThermalLayers <= 1 || i == 0 {
+				flow[i] -= gAmbient * (temps[i] - spec.AmbientTemperature)
+			}
+
+			if spec.ThermalLayers > 1 {
+				if i > 0 {
+					flow[i] -= gLayer * (temps[i] - temps[i-1])
+				}
+
+				if i+1 < len(temps) {
+					flow[i] -= gLayer * (temps[i] - temps[i+1])
+				}
+			}
+		}
+
+		for i := range temps {
+			temps[i] += flow[i] * dt / spec.ThermalCapacitance
+		}
+	}
+}
+
+// thermalMustBeValid rejects negative thermal parameters and fills in the
+// defaults of the unset ones. The refresh interval at the highest threshold
+// must still fit a refresh.
+func (b *Builder) thermalMustBeValid() {
+	s := &b.spec
+
+	if s.ThermalEpoch < 0 || s.ThermalLayers < 0 || s.ThermalTraceLength < 0 {
+		panic("dram: ThermalEpoch, ThermalLayers, and ThermalTraceLength " +
+			"must not be negative")
+	}
+
+	if !thermalEnabled(s) {
+		return
+	}
+
+	params := []struct {
+		name  string
+		value *float64
+		def   float64
+	}{
+		{"AmbientTemperature", &s.AmbientTemperature, defaultAmbientTemperature},
+		{"ThermalResistance", &s.ThermalResistance, defaultThermalResistance},
+		{"ThermalCapacitance", &s.ThermalCapacitance, defaultThermalCapacitance},
+		{"LayerThermalResistance", &s.LayerThermalResistance,
+			defaultLayerThermalResistance},
+	}
+
+	for _, p := range params {
+		switch {
+		case *p.value < 0:
+			panic(fmt.Sprintf("dram: %s must not be negative", p.name))
+		case *p.value == 0:
+			*p.value = p.def
+		}
+	}
+
+	if s.ThermalTraceLength == 0 {
+		s.ThermalTraceLength = defaultThermalTraceLength
+	}
+
+	if s.ThermalRefreshThresholds == nil {
+		s.ThermalRefreshThresholds = slices.Clone(defaultThermalRefreshThresholds)
+	}
+
+	refresh := s.TRFC
+	switch s.RefreshPolicy {
+	case RefreshPolicyBankStaggered, RefreshPolicySameBank:
+		refresh = s.TRFCb
+	}
+
+	if s.TREFI > 0 && s.TREFI>>len(s.ThermalRefreshThresholds) <= refresh {
+		panic("dram: the refresh interval above every thermal refresh " +
+			"threshold must be longer than a refresh")
+	}
+}
+
+// WriteTemperatureTrace writes the temperature trace of a controller as CSV:
+// the cycle, the time in ns, and the temperature of every thermal node (°C)
+// at the end of each of the last Spec.ThermalTraceLength thermal epochs.
+func WriteTemperatureTrace(w io.Writer, c *Comp) error {
+	spec := c.Spec()
+	columns := []string{"cycle", "time_ns"}
+
+	for i := range numThermalNodes(&spec) {
+		columns = append(columns, fmt.Sprintf("%s[%d]", thermalNodeKind(&spec), i))
+	}
+
+	if _, err := fmt.Fprintln(w, strings.Join(columns, ",")); err != nil {
+		return err
+	}
+
+	tCK := float64(spec.Freq.Period()) / 1000
+
+	for _, s := range c.State.Thermal.Trace {
+		row := []string{
+			fmt.Sprint(s.Cycle),
+			fmt.Sprintf("%g", float64(s.Cycle)*tCK),
+		}
+
+		for _, t := range s.Temperatures {
+			row = append(row, fmt.Sprintf("%.3f", t))
+		}
+
+		if _, err := fmt.Fprintln(w, strings.Join(row, ",")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
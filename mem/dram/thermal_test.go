@@ -0,0 +1,272 @@
+package dram
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/akita/v5/mem/memcontrolprotocol"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/stats"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+var _ = Describe("Thermal model", func() {
+	// normalized returns a DDR4 spec with the thermal model on, tweaked before
+	// it is normalized.
+	normalized := func(tweak func(s *Spec)) Spec {
+		s := DDR4Spec
+		s.ThermalEpoch = 1200
+		if tweak != nil {
+			tweak(&s)
+		}
+
+		b := MakeBuilder().WithSpec(s)
+		b.normalizeSpec()
+
+		return b.spec
+	}
+
+	It("should stay off by default", func() {
+		spec := DDR4Spec
+		Expect(thermalEnabled(&spec)).To(BeFalse())
+		Expect(initThermalState(&spec, 0).Temperatures).To(BeEmpty())
+	})
+
+	It("should fill in the defaults and reject invalid parameters", func() {
+		spec := normalized(nil)
+		Expect(spec.AmbientTemperature).To(Equal(defaultAmbientTemperature))
+		Expect(spec.ThermalResistance).To(Equal(defaultThermalResistance))
+		Expect(spec.ThermalCapacitance).To(Equal(defaultThermalCapacitance))
+		Expect(spec.ThermalRefreshThresholds).To(Equal([]float64{85}))
+
+		for _, tweak := range []func(s *Spec){
+			func(s *Spec) { s.ThermalEpoch = -1 },
+			func(s *Spec) { s.ThermalLayers = -1 },
+			func(s *Spec) { s.ThermalResistance = -1 },
+			func(s *Spec) { s.ThermalCapacitance = -1 },
+			// Five halvings of tREFI leave no room for tRFC.
+			func(s *Spec) { s.ThermalRefreshThresholds = []float64{85, 95, 105, 115, 125} },
+		} {
+			Expect(func() { normalized(tweak) }).To(Panic())
+		}
+	})
+
+	It("should heat a rank toward its steady state", func() {
+		spec := normalized(nil)
+		temps := []float64{spec.AmbientTemperature}
+		rc := spec.ThermalResistance * spec.ThermalCapacitance
+
+		integrateTemperatures(&spec, temps, []float64{2}, rc)
+
+		rise := 2 * spec.ThermalResistance * (1 - math.Exp(-1))
+		Expect(temps[0]).To(BeNumerically("~", spec.AmbientTemperature+rise, 0.5))
+
+		integrateTemperatures(&spec, temps, []float64{2}, 20*rc)
+		Expect(temps[0]).To(BeNumerically("~", spec.AmbientTemperature+20, 0.01))
+	})
+
+	It("should conduct the heat of a stack through its upper layers", func() {
+		spec := normalized(func(s *Spec) { s.ThermalLayers = 4 })
+		Expect(numThermalNodes(&spec)).To(Equal(4))
+
+		temps := make([]float64, 4)
+		for i := range temps {
+			temps[i] = spec.AmbientTemperature
+		}
+
+		// At steady state, layer 0 carries the power of the whole stack to the
+		// ambient, and layer i the power of the layers below it to layer i-1.
+		power := []float64{1, 1, 1, 1}
+		integrateTemperatures(&spec, temps, power, 1)
+
+		expected := spec.AmbientTemperature + 4*spec.ThermalResistance
+		for i := range temps {
+			if i > 0 {
+				expected += float64(4-i) * spec.LayerThermalResistance
+			}
+
+			Expect(temps[i]).To(BeNumerically("~", expected, 0.01))
+		}
+	})
+
+	It("should double the refresh rate of a hot rank", func() {
+		spec := normalized(func(s *Spec) { s.ThermalCapacitance = 1e-9 })
+		state := &State{
+			BankStates: initBankStates(&spec),
+			Refresh:    initRefreshState(&spec),
+			Energy:     initEnergyState(&spec),
+			Thermal:    initThermalState(&spec, 0),
+		}
+		model := newEnergyModel(&spec)
+		mw := &thermalMiddleware{model: &model}
+
+		Expect(refreshInterval(&spec, state, 0)).To(Equal(spec.TREFI))
+		countdown := state.Refresh.Targets[0].Countdown
+
+		// 5 W for an epoch, many time constants long, heat the rank to 95°C.
+		seconds := float64(spec.ThermalEpoch) * float64(spec.Freq.Period()) * 1e-12
+		state.Energy.Ranks[0].Command.Read = 5 / 1e-12 * seconds
+		mw.endEpoch(&spec, state, uint64(spec.ThermalEpoch))
+
+		Expect(state.Thermal.Temperatures[0]).To(BeNumerically(">", 85))
+		Expect(state.Thermal.PeakTemperatures[0]).
+			To(Equal(state.Thermal.Temperatures[0]))
+		Expect(state.Thermal.Trace).To(HaveLen(1))
+		Expect(state.Thermal.RefreshShift[0]).To(Equal(1))
+		Expect(refreshInterval(&spec, state, 0)).To(Equal(spec.TREFI / 2))
+		Expect(state.Refresh.Targets[0].Countdown).To(Equal(countdown / 2))
+
+		// Without power, the rank cools down to the normal refresh rate.
+		for i := 2; i < 10; i++ {
+			mw.endEpoch(&spec, state, uint64(i*spec.ThermalEpoch))
+		}
+
+		Expect(state.Thermal.Temperatures[0]).To(BeNumerically("<", 85))
+		Expect(state.Thermal.PeakTemperatures[0]).To(BeNumerically(">", 85))
+		Expect(refreshInterval(&spec, state, 0)).To(Equal(spec.TREFI))
+	})
+
+	It("should keep only the last epochs in the trace", func() {
+		spec := normalized(func(s *Spec) { s.ThermalTraceLength = 3 })
+		state := &State{
+			Energy:  initEnergyState(&spec),
+			Thermal: initThermalState(&spec, 0),
+		}
+		model := newEnergyModel(&spec)
+		mw := &thermalMiddleware{model: &model}
+
+		for i := 1; i <= 5; i++ {
+			mw.endEpoch(&spec, state, uint64(i*spec.ThermalEpoch))
+		}
+
+		Expect(state.Thermal.Trace).To(HaveLen(3))
+		Expect(state.Thermal.Trace[0].Cycle).To(Equal(uint64(3 * spec.ThermalEpoch)))
+		Expect(state.Thermal.Trace[2].Cycle).To(Equal(uint64(5 * spec.ThermalEpoch)))
+	})
+
+	It("should restart the temperatures on Reset", func() {
+		spec := DDR4Spec
+		spec.ThermalEpoch = 1200
+
+		engine := timing.NewSerialEngine()
+		comp := MakeBuilder().
+			WithRegistrar(modeling.NewStandaloneRegistrar(engine)).
+			WithSpec(spec).
+			Build("DRAM")
+
+		for _, name := range []string{"Top", "Control"} {
+			p := messaging.NewPort(comp, 16, 16, "DRAM."+name)
+			comp.AssignPort(name, p)
+			(&noopConn{}).PlugIn(p)
+		}
+
+		th := &comp.State.Thermal
+		th.RankEnergy[0] = 1e12
+		th.Temperatures[0] = 100
+		th.RefreshShift[0] = 1
+		th.Trace = append(th.Trace, TemperatureSample{Cycle: 1200})
+
+		req := memcontrolprotocol.Req{Command: memcontrolprotocol.CmdReset}
+		req.ID = timing.GetIDGenerator().Generate()
+		req.Src = messaging.RemotePort("Ctrl")
+		req.Dst = comp.GetPortByName("Control").AsRemote()
+		req.TrafficClass = "memcontrolprotocol.Req"
+		comp.GetPortByName("Control").Deliver(req)
+
+		for i := 0; i < 8 && len(th.Trace) > 0; i++ {
+			comp.Tick()
+		}
+
+		Expect(th.Trace).To(BeEmpty())
+		Expect(th.RankEnergy[0]).To(BeZero())
+		Expect(th.Temperatures[0]).To(Equal(comp.Spec().AmbientTemperature))
+		Expect(th.RefreshShift[0]).To(BeZero())
+		Expect(th.NextEpoch).To(Equal(
+			comp.State.Energy.AccountedCycle + uint64(spec.ThermalEpoch)))
+	})
+
+	// run streams reads that conflict in one bank through a controller and
+	// returns the controller once they complete.
+	run := func(spec Spec) *Comp {
+		h := newP0Harness(spec)
+		for i := range 1000 {
+			h.src.Send(h.read(uint64(i) << 20))
+		}
+
+		Expect(h.engine.Run()).To(Succeed())
+
+		reads, _ := h.collect()
+		Expect(reads).To(HaveLen(1000))
+
+		return h.dram
+	}
+
+	It("should refresh a hot DRAM more often", func() {
+		cold := run(DDR4Spec)
+
+		// A rank with a time constant of a few epochs and a steep path to
+		// the ambient heats past 85°C within the run.
+		hot := DDR4Spec
+		hot.ThermalEpoch = 1200
+		hot.ThermalResistance = 1000
+		hot.ThermalCapacitance = 2e-9
+
+		comp := run(hot)
+
+		Expect(comp.State.Thermal.Trace).NotTo(BeEmpty())
+		Expect(comp.State.Thermal.Temperatures[0]).To(BeNumerically(">", 85))
+		Expect(comp.State.TotalRefreshes).
+			To(BeNumerically(">", cold.State.TotalRefreshes*3/2))
+
+		registry := stats.NewRegistry(stats.DefaultName)
+		registerThermalStats(registry, comp)
+
+		s, found := registry.Lookup("P0DRAM.Thermal.Rank[0].Temperature")
+		Expect(found).To(BeTrue())
+		Expect(s.Entries()[0].Value).To(Equal(comp.State.Thermal.Temperatures[0]))
+
+		s, found = registry.Lookup("P0DRAM.Thermal.PeakTemperature")
+		Expect(found).To(BeTrue())
+		Expect(s.Entries()[0].Value).To(BeNumerically(">", 85))
+
+		var buf bytes.Buffer
+		Expect(WriteTemperatureTrace(&buf, comp)).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		Expect(lines[0]).To(Equal("cycle,time_ns,Rank[0]"))
+		Expect(lines).To(HaveLen(len(comp.State.Thermal.Trace) + 1))
+		Expect(lines[1]).To(HavePrefix("1200,"))
+		Expect(lines[len(lines)-1]).To(HaveSuffix(
+			fmt.Sprintf(",%.3f", comp.State.Thermal.Temperatures[0])))
+	})
+	It("should keep refreshing a hot DRAM more often while it idles", func() {
+		idle := func(spec Spec) *Comp {
+			h := newP0Harness(spec)
+			h.readAcrossIdle(40 * DDR4Spec.TREFI)
+
+			return h.dram
+		}
+
+		cold := idle(DDR4Spec)
+		Expect(cold.State.TotalRefreshes).To(BeNumerically(">=", 40))
+
+		// The background power of an idle rank alone keeps it past 85°C.
+		hot := DDR4Spec
+		hot.ThermalEpoch = 1200
+		hot.ThermalResistance = 1000
+		hot.ThermalCapacitance = 2e-9
+
+		comp := idle(hot)
+
+		Expect(comp.State.Thermal.Temperatures[0]).To(BeNumerically(">", 85))
+		Expect(comp.State.Thermal.RefreshShift[0]).To(Equal(1))
+		Expect(comp.State.TotalRefreshes).To(
+			BeNumerically("~", 2*cold.State.TotalRefreshes, cold.State.TotalRefreshes/10))
+	})
+})
@@ -314,6 +314,19 @@ def run_ramulator2(binary, scn, work):
     }
 
 
+def write_canonical_configs():
+    """Write the canonical close-page configs both oracles run to configs/, so
+    the Go side imports the very same files (dram.ImportDRAMSim3 and
+    dram.ImportRamulator2) instead of re-typing CANONICAL. The trace and
+    command-count paths are placeholders; each run writes its own."""
+    configs = HERE / "configs"
+    configs.mkdir(exist_ok=True)
+    scn = {"page_policy": "close"}
+    (configs / "ddr4_canonical.ini").write_text(dramsim3_ini(scn))
+    (configs / "ddr4_canonical.yaml").write_text(
+        ramulator2_yaml(scn, "trace.txt", "cmdcount.csv"))
+
+
 def main():
     ap = argparse.ArgumentParser()
     oracles = HERE / "oracles" / ".oracles"
@@ -334,6 +347,7 @@ def main():
     compact = [{k: v for k, v in s.items() if k != "ops"} for s in SCENARIOS]
     (HERE / "traces" / "scenarios.json").write_text(
         json.dumps({"canonical": CANONICAL, "scenarios": compact}, indent=2) + "\n")
+    write_canonical_configs()
 
     rows = []
     for scn in SCENARIOS:
@@ -16,7 +16,9 @@ import (
 // The committed reference data (validation/data/reference.csv) was produced by
 // running both oracles at pinned commits over the workload in
 // validation/traces/scenarios.json (see validation/run_oracles.py). These tests
-// drive the *same* workload through Akita's dram.Comp and compare:
+// drive the *same* workload through Akita's dram.Comp, configured by importing
+// the DRAMSim3 config the oracles ran (validation/configs/ddr4_canonical.ini),
+// and compare:
 //
 //   Tier 5 — command counts (activates/reads/writes), close-page count
 //            scenarios, compared EXACTLY against both oracles. These quantities
@@ -32,6 +34,7 @@ import (
 //            characterization assertion will fail — flip it to "enforced" then.
 
 const (
+	tier5ConfigPath    = "validation/configs/ddr4_canonical.ini"
 	tier5ScenariosPath = "validation/traces/scenarios.json"
 	tier5ReferencePath = "validation/data/reference.csv"
 	latencyTolerance   = 0.15
@@ -82,18 +85,17 @@ type akitaResult struct {
 	avgReadLatency           float64
 }
 
-// runAkita drives the scenario through a dram.Comp configured to match the
-// canonical oracle config (DDR4, refresh off, given page policy) and returns
-// the issued command counts and the average read latency in DRAM cycles.
+// runAkita drives the scenario through a dram.Comp configured from the
+// canonical DRAMSim3 config the oracles ran (DDR4, refresh off, given page
+// policy) and returns the issued command counts and the average read latency
+// in DRAM cycles.
 func runAkita(scn tier5Scenario) akitaResult {
-	spec := DDR4Spec
+	spec := tier5Spec
 	if scn.PagePolicy == "open" {
 		spec.PagePolicy = PagePolicyOpen
 	} else {
 		spec.PagePolicy = PagePolicyClose
 	}
-	spec.TransactionQueueSize = 32
-	spec.CommandQueueCapacity = 8
 	spec.TREFI = 0 // refresh off, matching the oracle reference runs
 
 	h := newP0Harness(spec)
@@ -120,6 +122,18 @@ func runAkita(scn tier5Scenario) akitaResult {
 	}
 }
 
+// loadTier5Spec imports the canonical config the oracles ran.
+func loadTier5Spec() (Spec, bool) {
+	f, err := os.Open(tier5ConfigPath)
+	if err != nil {
+		return Spec{}, false
+	}
+	defer f.Close()
+
+	spec, _, err := ImportDRAMSim3(f)
+	return spec, err == nil
+}
+
 func loadTier5Scenarios() ([]tier5Scenario, bool) {
 	raw, err := os.ReadFile(tier5ScenariosPath)
 	if err != nil {
@@ -172,13 +186,16 @@ func loadReference() (map[string]map[string]oracleResult, bool) {
 }
 
 var (
-	tier5Scen, tier5OKScn = loadTier5Scenarios()
-	tier5Ref, tier5OKRef  = loadReference()
+	tier5Spec, tier5OKSpec = loadTier5Spec()
+	tier5Scen, tier5OKScn  = loadTier5Scenarios()
+	tier5Ref, tier5OKRef   = loadReference()
 )
 
 // requireReference fails (not skips): the fixtures are committed and required,
 // so a missing or malformed file is a breakage CI must catch, not silently pass.
 func requireReference() {
+	Expect(tier5OKSpec).To(BeTrue(), "committed %s missing or not importable "+
+		"(required; regenerate with validation/run_oracles.py)", tier5ConfigPath)
 	Expect(tier5OKScn).To(BeTrue(), "committed %s missing or malformed "+
 		"(required; regenerate with validation/run_oracles.py)", tier5ScenariosPath)
 	Expect(tier5OKRef).To(BeTrue(), "committed %s missing or malformed "+
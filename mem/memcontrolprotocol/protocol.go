@@ -3,6 +3,7 @@ package memcontrolprotocol
 import (
 	"github.com/sarchlab/akita/v5/mem/vm"
 	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/messaging/txcheck"
 )
 
 // Protocol is the uniform control protocol for memory agents: a requester
@@ -22,6 +23,12 @@ var (
 	Responder = Protocol.Role("responder")
 )
 
+// TransactionRule pairs every control request with the response that answers
+// it, for checking traffic with a txcheck.Checker.
+var TransactionRule = txcheck.NewRule(Protocol,
+	txcheck.Answers(Req{}, Rsp{}),
+)
+
 // Command enumerates the verbs of the uniform control protocol for memory
 // agents. Every memory agent component implements its supported subset of
 // these verbs over its "Control" port.
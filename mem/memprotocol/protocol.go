@@ -7,6 +7,7 @@ package memprotocol
 import (
 	"github.com/sarchlab/akita/v5/mem/vm"
 	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/messaging/txcheck"
 )
 
 // Protocol is the memory access protocol: requesters issue reads and writes,
@@ -25,6 +26,13 @@ var (
 	Responder = Protocol.Role("responder")
 )
 
+// TransactionRule pairs every memory request with the response that answers
+// it, for checking traffic with a txcheck.Checker.
+var TransactionRule = txcheck.NewRule(Protocol,
+	txcheck.Answers(ReadReq{}, DataReadyRsp{}),
+	txcheck.Answers(WriteReq{}, WriteDoneRsp{}),
+)
+
 // AccessReq abstracts read and write requests sent to cache modules or memory
 // controllers.
 type AccessReq interface {
@@ -3,8 +3,21 @@ package mem
 import (
 	"errors"
 	"sync"
+
+	"github.com/sarchlab/akita/v5/hooking"
 )
 
+// HookPosStorageRead marks when data is read from a storage. The hook's Item
+// is a StorageAccess whose Data hooks may modify to change what the reader
+// gets, e.g. to inject bit flips.
+var HookPosStorageRead = &hooking.HookPos{Name: "Storage Read"}
+
+// StorageAccess describes a storage read or write to hooks.
+type StorageAccess struct {
+	Address uint64
+	Data    []byte
+}
+
 // For capacity
 const (
 	_         = iota
@@ -24,6 +37,7 @@ const (
 // it not touched by Read and Write function, no memory will be allocated.
 type Storage struct {
 	sync.Mutex
+	hooking.HookableBase
 
 	name     string
 	capacity uint64
@@ -133,6 +147,14 @@ func (s *Storage) Read(address uint64, len uint64) ([]byte, error) {
 		currAddr += lenToRead
 	}
 
+	if s.NumHooks() > 0 {
+		s.InvokeHook(hooking.HookCtx{
+			Domain: s,
+			Pos:    HookPosStorageRead,
+			Item:   StorageAccess{Address: address, Data: res},
+		})
+	}
+
 	return res, nil
 }
 
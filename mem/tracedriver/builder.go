@@ -0,0 +1,144 @@
+package tracedriver
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/sarchlab/akita/v5/mem/memprotocol"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/naming"
+	"github.com/sarchlab/akita/v5/stats"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// defaultSpec provides the default configuration of a trace driver.
+var defaultSpec = Spec{
+	Freq:           1 * timing.GHz,
+	AccessByteSize: 64,
+	MaxInFlight:    64,
+}
+
+// DefaultSpec returns a copy of the default configuration. Callers typically
+// obtain it, tweak the fields they care about, and pass it to WithSpec.
+func DefaultSpec() Spec {
+	return defaultSpec
+}
+
+// latencyBounds are the bucket bounds of the latency histograms, in cycles.
+var latencyBounds = []float64{16, 32, 64, 128, 256, 512, 1024, 2048, 4096, 8192}
+
+// Builder builds trace drivers. Configuration is supplied as a whole through
+// WithSpec; wiring and the trace are supplied through WithRegistrar and
+// WithResources. The component declares its "Mem" port; the port instance is
+// supplied externally after Build with AssignPort (the caller chooses the
+// buffer size).
+type Builder struct {
+	spec      Spec
+	registrar modeling.Registrar
+	resources Resources
+}
+
+// MakeBuilder returns a new Builder seeded with the default spec.
+func MakeBuilder() Builder {
+	return Builder{spec: defaultSpec}
+}
+
+// WithRegistrar wires the builder to a registrar (a *simulation.Simulation in
+// assembly, or modeling.NewStandaloneRegistrar(engine) in isolated tests). The
+// registrar provides the engine and registers the built component.
+func (b Builder) WithRegistrar(reg modeling.Registrar) Builder {
+	b.registrar = reg
+	return b
+}
+
+// WithSpec sets the entire configuration. Start from DefaultSpec() and tweak.
+func (b Builder) WithSpec(spec Spec) Builder {
+	b.spec = spec
+	return b
+}
+
+// WithResources injects the trace to replay and the downstream LowModule port
+// the accesses are sent to.
+func (b Builder) WithResources(r Resources) Builder {
+	b.resources = r
+	return b
+}
+
+// Build creates a new trace driver with the given name. It declares the
+// driver's "Mem" port; assign the port instance after Build with AssignPort.
+// Tick the driver once (TickLater) to start the replay.
+func (b Builder) Build(name string) *Comp {
+	if b.registrar == nil {
+		panic("tracedriver: WithRegistrar is required")
+	}
+
+	spec := b.spec
+	mustBeValid(spec)
+
+	modelComp := modeling.NewBuilder[Spec, State, Resources]().
+		WithEngine(b.registrar.GetEngine()).
+		WithFreq(spec.Freq).
+		WithSpec(spec).
+		WithResources(b.resources).
+		Build(name)
+	modelComp.State = State{Pending: make(map[uint64]pendingAccess)}
+
+	driver := &Comp{
+		Component: modelComp,
+		LowModule: b.resources.LowModule,
+	}
+
+	modelComp.AddMiddleware(&middleware{driver: driver})
+	modelComp.DeclarePort("Mem", memprotocol.Requester)
+
+	b.registrar.RegisterComponent(driver)
+	registerStats(stats.Of(b.registrar), driver)
+
+	return driver
+}
+
+func mustBeValid(spec Spec) {
+	switch {
+	case spec.Freq == 0:
+		panic("tracedriver: frequency must be positive")
+	case bits.OnesCount64(spec.AccessByteSize) != 1:
+		panic(fmt.Sprintf("tracedriver: access size %d is not a power of two",
+			spec.AccessByteSize))
+	case spec.MaxInFlight < 0:
+		panic(fmt.Sprintf("tracedriver: max in-flight %d is negative",
+			spec.MaxInFlight))
+	}
+}
+
+// registerStats publishes the driver's counters and latency histograms to a
+// stats registry, under the component's name.
+func registerStats(reg *stats.Registry, c *Comp) {
+	name := func(stat string) string { return naming.BuildName(c.Name(), stat) }
+
+	reg.NewCounterFunc(name("IssuedReads"), "read accesses issued",
+		func() uint64 { return c.State.IssuedReads })
+	reg.NewCounterFunc(name("IssuedWrites"), "write accesses issued",
+		func() uint64 { return c.State.IssuedWrites })
+	reg.NewCounterFunc(name("CompletedReads"), "read accesses completed",
+		func() uint64 { return c.State.CompletedReads })
+	reg.NewCounterFunc(name("CompletedWrites"), "write accesses completed",
+		func() uint64 { return c.State.CompletedWrites })
+	reg.NewCounterFunc(name("IssueDelayCycles"),
+		"cycles accesses were issued after their trace cycle",
+		func() uint64 { return c.State.IssueDelayCycles })
+
+	c.readLatency = reg.NewHistogram(name("ReadLatency"),
+		"read latency in cycles", latencyBounds)
+	c.writeLatency = reg.NewHistogram(name("WriteLatency"),
+		"write latency in cycles", latencyBounds)
+
+	reg.NewFormula(name("BytesPerCycle"),
+		"bytes transferred per cycle from the first issue to the last completion",
+		func() float64 {
+			if c.ActiveCycles() == 0 {
+				return 0
+			}
+
+			return float64(c.BytesTransferred()) / float64(c.ActiveCycles())
+		})
+}
@@ -0,0 +1,301 @@
+// Command tracedriver replays a DRAMSim3 or Ramulator address trace into an
+// Akita memory and reports the bandwidth, the latency distribution, and the
+// row-buffer hit rate.
+//
+//	tracedriver -trace stream.trace -dram-config ddr4.ini
+//	tracedriver -trace stream.trace -dram-preset hbm3 -cache
+//	tracedriver -trace stream.trace -target ideal -latency 100
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sarchlab/akita/v5/mem"
+	"github.com/sarchlab/akita/v5/mem/cache/writeback"
+	"github.com/sarchlab/akita/v5/mem/dram"
+	"github.com/sarchlab/akita/v5/mem/idealmemcontroller"
+	"github.com/sarchlab/akita/v5/mem/tracedriver"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/naming"
+	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/simulation"
+	"github.com/sarchlab/akita/v5/stats"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+var traceFlag = flag.String("trace", "", "Address trace to replay (required)")
+var targetFlag = flag.String("target", "dram", "Memory to drive: dram or ideal")
+var dramConfigFlag = flag.String("dram-config", "",
+	"DRAMSim3 .ini or Ramulator2 .yaml config of the DRAM")
+var dramPresetFlag = flag.String("dram-preset", "ddr4",
+	"DRAM preset when no config is given: default, ddr4, ddr5, hbm2, hbm3, or gddr6")
+var latencyFlag = flag.Int("latency", 100, "Latency of the ideal memory in cycles")
+var cacheFlag = flag.Bool("cache", false, "Put a writeback cache in front of the memory")
+var freqFlag = flag.Uint64("freq-mhz", 0,
+	"Clock of the trace cycles in MHz (default: the memory clock)")
+var accessSizeFlag = flag.Uint64("access-size", 64, "Bytes per access")
+var maxInFlightFlag = flag.Int("max-inflight", 64, "Accesses in flight (0 for no cap)")
+var statsFlag = flag.Bool("stats", false, "Print every stat of the simulation")
+
+// memoryName is the name of the driven memory, under which its stats are.
+const memoryName = "Mem"
+
+func main() {
+	flag.Parse()
+
+	if *traceFlag == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	trace, err := readTrace(*traceFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := simulation.MakeBuilder().WithoutMonitoring().Build()
+	driver := setup(s, trace)
+
+	driver.TickLater()
+
+	if err := s.GetEngine().Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	if !driver.Done() {
+		log.Fatal("not all accesses completed")
+	}
+
+	report(os.Stdout, s.Stats(), driver)
+
+	if *statsFlag {
+		if err := s.Stats().WriteText(os.Stdout, ""); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	s.Terminate()
+}
+
+func readTrace(path string) ([]tracedriver.Access, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return tracedriver.ParseTrace(f)
+}
+
+// setup builds the memory, the optional cache, and the driver, and connects
+// them.
+func setup(s *simulation.Simulation, trace []tracedriver.Access) *tracedriver.Comp {
+	conn := directconnection.MakeBuilder().
+		WithRegistrar(s).
+		Build("Conn")
+
+	memory, freq := buildMemory(s)
+	conn.PlugIn(memory.GetPortByName("Top"))
+
+	low := memory
+	if *cacheFlag {
+		low = buildCache(s, memory, freq)
+		conn.PlugIn(low.GetPortByName("Top"))
+		conn.PlugIn(low.GetPortByName("Bottom"))
+	}
+
+	if *freqFlag != 0 {
+		freq = timing.Freq(*freqFlag) * timing.MHz
+	}
+
+	spec := tracedriver.DefaultSpec()
+	spec.Freq = freq
+	spec.AccessByteSize = *accessSizeFlag
+	spec.MaxInFlight = *maxInFlightFlag
+
+	driver := tracedriver.MakeBuilder().
+		WithRegistrar(s).
+		WithSpec(spec).
+		WithResources(tracedriver.Resources{
+			LowModule: low.GetPortByName("Top"),
+			Trace:     trace,
+		}).
+		Build("Driver")
+	assignPorts(s, driver, "Mem")
+	conn.PlugIn(driver.GetPortByName("Mem"))
+
+	return driver
+}
+
+// buildMemory builds the driven memory and returns it with its clock.
+func buildMemory(s *simulation.Simulation) (messaging.Component, timing.Freq) {
+	switch *targetFlag {
+	case "dram":
+		spec := dramSpec()
+		ctrl := dram.MakeBuilder().
+			WithRegistrar(s).
+			WithSpec(spec).
+			Build(memoryName)
+		assignPorts(s, ctrl, "Top", "Control")
+
+		return ctrl, spec.Freq
+	case "ideal":
+		spec := idealmemcontroller.DefaultSpec()
+		spec.Latency = *latencyFlag
+		ctrl := idealmemcontroller.MakeBuilder().
+			WithRegistrar(s).
+			WithSpec(spec).
+			Build(memoryName)
+		assignPorts(s, ctrl, "Top", "Control")
+
+		return ctrl, spec.Freq
+	default:
+		log.Fatalf("unknown target %q", *targetFlag)
+		return nil, 0
+	}
+}
+
+// dramSpec loads the DRAM config, or returns the preset if there is none.
+func dramSpec() dram.Spec {
+	if *dramConfigFlag == "" {
+		return dramPreset(*dramPresetFlag)
+	}
+
+	f, err := os.Open(*dramConfigFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	importConfig := dram.ImportDRAMSim3
+	if ext := filepath.Ext(*dramConfigFlag); ext == ".yaml" || ext == ".yml" {
+		importConfig = dram.ImportRamulator2
+	}
+
+	spec, warnings, err := importConfig(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+
+	return spec
+}
+
+func dramPreset(name string) dram.Spec {
+	switch strings.ToLower(name) {
+	case "default":
+		return dram.DefaultSpec()
+	case "ddr4":
+		return dram.DDR4Spec
+	case "ddr5":
+		return dram.DDR5Spec
+	case "hbm2":
+		return dram.HBM2Spec
+	case "hbm3":
+		return dram.HBM3Spec
+	case "gddr6":
+		return dram.GDDR6Spec
+	default:
+		log.Fatalf("unknown DRAM preset %q", name)
+		return dram.Spec{}
+	}
+}
+
+func buildCache(
+	s *simulation.Simulation,
+	memory messaging.Component,
+	freq timing.Freq,
+) messaging.Component {
+	spec := writeback.DefaultSpec()
+	spec.Freq = freq
+	spec.AddressMapperType = "single"
+
+	cache := writeback.MakeBuilder().
+		WithRegistrar(s).
+		WithSpec(spec).
+		WithResources(writeback.Resources{
+			AddressToPortMapper: &mem.SinglePortMapper{
+				Port: memory.GetPortByName("Top").AsRemote(),
+			},
+		}).
+		Build("Cache")
+	assignPorts(s, cache, "Top", "Bottom", "Control")
+
+	return cache
+}
+
+// assignPorts builds a port for each declared name on the component and assigns
+// it, choosing a default buffer size.
+func assignPorts(
+	s *simulation.Simulation,
+	comp messaging.Component,
+	names ...string,
+) {
+	for _, name := range names {
+		p := modeling.MakePortBuilder().
+			WithRegistrar(s).
+			WithComponent(comp).
+			WithSpec(modeling.PortSpec{BufSize: 16}).
+			Build(name)
+		comp.AssignPort(name, p)
+	}
+}
+
+// report prints the bandwidth, the latency distributions, and the row-buffer
+// hit rate of the DRAM, if the memory is one.
+func report(w io.Writer, reg *stats.Registry, driver *tracedriver.Comp) {
+	state := driver.State
+	freq := driver.Spec().Freq
+
+	fmt.Fprintf(w, "accesses:        %d reads, %d writes\n",
+		state.CompletedReads, state.CompletedWrites)
+	fmt.Fprintf(w, "cycles:          %d at %d MHz\n", driver.ActiveCycles(), freq/timing.MHz)
+	fmt.Fprintf(w, "bandwidth:       %.3f GB/s\n", driver.Bandwidth()/1e9)
+	fmt.Fprintf(w, "issue delay:     %d cycles\n", state.IssueDelayCycles)
+
+	if rate, ok := reg.Lookup(naming.BuildName(memoryName, "RowBufferHitRate")); ok {
+		fmt.Fprintf(w, "row-buffer hits: %.2f%%\n", rate.Entries()[0].Value*100)
+	}
+
+	reportLatency(w, "read latency", driver.ReadLatency())
+	reportLatency(w, "write latency", driver.WriteLatency())
+}
+
+func reportLatency(w io.Writer, title string, h stats.HistogramSnapshot) {
+	if h.Count == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "%s (cycles): mean %.1f, min %g, max %g\n",
+		title, h.Mean(), h.Min, h.Max)
+
+	for i, n := range h.Buckets {
+		if n == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "  %-12s %8d  %5.1f%%\n",
+			bucketRange(h.Bounds, i), n, float64(n)/float64(h.Count)*100)
+	}
+}
+
+func bucketRange(bounds []float64, i int) string {
+	switch {
+	case i == 0:
+		return fmt.Sprintf("<%g", bounds[0])
+	case i == len(bounds):
+		return fmt.Sprintf(">=%g", bounds[i-1])
+	default:
+		return fmt.Sprintf("%g-%g", bounds[i-1], bounds[i])
+	}
+}
@@ -0,0 +1,122 @@
+// Package tracedriver provides a requester that replays a memory address trace
+// into any memprotocol responder, such as a DRAM controller, a cache, or an
+// ideal memory controller, and measures the bandwidth and the latencies it
+// sees.
+package tracedriver
+
+import (
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/stats"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// Spec contains the immutable configuration of a trace driver.
+type Spec struct {
+	// Freq is the clock of the driver. The cycles of the trace count cycles
+	// of this clock.
+	Freq timing.Freq `json:"freq"`
+
+	// AccessByteSize is the number of bytes each access reads or writes. It
+	// must be a power of two; addresses are aligned down to it.
+	AccessByteSize uint64 `json:"access_byte_size"`
+
+	// MaxInFlight caps the accesses issued but not yet completed. Zero means
+	// no cap.
+	MaxInFlight int `json:"max_in_flight"`
+}
+
+// Resources holds the external wiring of a trace driver. The LowModule is the
+// port the accesses are sent to. It can be supplied through WithResources, or
+// assigned to the public LowModule field after Build when construction
+// ordering requires it. Trace is the trace to replay.
+type Resources struct {
+	LowModule messaging.Port
+	Trace     []Access
+}
+
+// pendingAccess is an access issued and not yet completed.
+type pendingAccess struct {
+	Write      bool   `json:"write"`
+	IssueCycle uint64 `json:"issue_cycle"`
+}
+
+// State contains the mutable runtime data of a trace driver.
+type State struct {
+	// NextAccess is the index of the next access of the trace to issue.
+	NextAccess int                      `json:"next_access"`
+	Pending    map[uint64]pendingAccess `json:"pending"`
+
+	IssuedReads     uint64 `json:"issued_reads"`
+	IssuedWrites    uint64 `json:"issued_writes"`
+	CompletedReads  uint64 `json:"completed_reads"`
+	CompletedWrites uint64 `json:"completed_writes"`
+
+	TotalReadLatencyCycles  uint64 `json:"total_read_latency_cycles"`
+	TotalWriteLatencyCycles uint64 `json:"total_write_latency_cycles"`
+
+	// IssueDelayCycles sums the cycles the accesses were issued after their
+	// trace cycle, because the memory or the in-flight cap held them back.
+	IssueDelayCycles uint64 `json:"issue_delay_cycles"`
+
+	// FirstIssueCycle and LastCompletionCycle bound the replay. They are
+	// meaningful once an access has been issued and completed.
+	FirstIssueCycle     uint64 `json:"first_issue_cycle"`
+	LastCompletionCycle uint64 `json:"last_completion_cycle"`
+}
+
+// Comp is a trace driver. It issues the accesses of its trace in order, each no
+// earlier than its cycle, and records the latency of each.
+type Comp struct {
+	*modeling.Component[Spec, State, Resources]
+
+	// LowModule is the port the accesses are sent to. It is not serialized
+	// as part of the state.
+	LowModule messaging.Port
+
+	readLatency  *stats.Histogram
+	writeLatency *stats.Histogram
+}
+
+// Done returns whether every access of the trace has been issued and has
+// completed.
+func (c *Comp) Done() bool {
+	return c.State.NextAccess >= len(c.Resources().Trace) && len(c.State.Pending) == 0
+}
+
+// BytesTransferred returns the bytes read and written by the completed
+// accesses.
+func (c *Comp) BytesTransferred() uint64 {
+	completed := c.State.CompletedReads + c.State.CompletedWrites
+	return completed * c.Spec().AccessByteSize
+}
+
+// ActiveCycles returns the cycles from the first issue to the last completion,
+// or 0 before an access completes.
+func (c *Comp) ActiveCycles() uint64 {
+	if c.State.CompletedReads+c.State.CompletedWrites == 0 {
+		return 0
+	}
+
+	return c.State.LastCompletionCycle - c.State.FirstIssueCycle + 1
+}
+
+// Bandwidth returns the bytes transferred per second over the active cycles.
+func (c *Comp) Bandwidth() float64 {
+	cycles := c.ActiveCycles()
+	if cycles == 0 {
+		return 0
+	}
+
+	return float64(c.BytesTransferred()) / float64(cycles) * float64(c.Spec().Freq)
+}
+
+// ReadLatency returns the distribution of the read latencies, in cycles.
+func (c *Comp) ReadLatency() stats.HistogramSnapshot {
+	return c.readLatency.Snapshot()
+}
+
+// WriteLatency returns the distribution of the write latencies, in cycles.
+func (c *Comp) WriteLatency() stats.HistogramSnapshot {
+	return c.writeLatency.Snapshot()
+}
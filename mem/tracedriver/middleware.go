@@ -0,0 +1,168 @@
+package tracedriver
+
+import (
+	"log"
+	"reflect"
+
+	"github.com/sarchlab/akita/v5/mem/memprotocol"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/timing"
+	"github.com/sarchlab/akita/v5/tracing"
+)
+
+type middleware struct {
+	driver *Comp
+}
+
+func (m *middleware) memPort() messaging.Port {
+	return m.driver.GetPortByName("Mem")
+}
+
+func (m *middleware) now() uint64 {
+	return m.driver.Spec().Freq.Cycle(m.driver.CurrentTime())
+}
+
+// Tick collects the responses and issues the next access of the trace. While
+// the next access waits for its cycle, the driver keeps ticking.
+func (m *middleware) Tick() bool {
+	madeProgress := m.processRsp()
+	madeProgress = m.issue() || madeProgress
+
+	return madeProgress || m.waitingForCycle()
+}
+
+func (m *middleware) processRsp() bool {
+	msgI := m.memPort().RetrieveIncoming()
+	if msgI == nil {
+		return false
+	}
+
+	var rspTo uint64
+
+	switch msg := msgI.(type) {
+	case memprotocol.DataReadyRsp:
+		rspTo = msg.RspTo
+	case memprotocol.WriteDoneRsp:
+		rspTo = msg.RspTo
+	default:
+		log.Panicf("tracedriver: cannot process message of type %s",
+			reflect.TypeOf(msgI))
+	}
+
+	m.complete(rspTo)
+
+	return true
+}
+
+func (m *middleware) complete(rspTo uint64) {
+	state := &m.driver.State
+
+	access, ok := state.Pending[rspTo]
+	if !ok {
+		log.Panicf("tracedriver: response to unknown request %d", rspTo)
+	}
+
+	delete(state.Pending, rspTo)
+
+	now := m.now()
+	latency := now - access.IssueCycle
+	state.LastCompletionCycle = now
+
+	if access.Write {
+		state.CompletedWrites++
+		state.TotalWriteLatencyCycles += latency
+		m.driver.writeLatency.Observe(float64(latency))
+	} else {
+		state.CompletedReads++
+		state.TotalReadLatencyCycles += latency
+		m.driver.readLatency.Observe(float64(latency))
+	}
+
+	tracing.TraceReqFinalize(m.driver, messaging.MsgMeta{ID: rspTo})
+}
+
+func (m *middleware) nextAccess() (Access, bool) {
+	trace := m.driver.Resources().Trace
+	if m.driver.State.NextAccess >= len(trace) {
+		return Access{}, false
+	}
+
+	return trace[m.driver.State.NextAccess], true
+}
+
+func (m *middleware) waitingForCycle() bool {
+	access, ok := m.nextAccess()
+	return ok && access.Cycle > m.now()
+}
+
+func (m *middleware) issue() bool {
+	state := &m.driver.State
+	spec := m.driver.Spec()
+
+	access, ok := m.nextAccess()
+	if !ok || access.Cycle > m.now() {
+		return false
+	}
+
+	if spec.MaxInFlight > 0 && len(state.Pending) >= spec.MaxInFlight {
+		return false
+	}
+
+	if !m.memPort().CanSend() {
+		return false
+	}
+
+	msg := m.makeReq(access)
+	m.memPort().Send(msg)
+	tracing.TraceReqInitiate(m.driver, msg, 0)
+
+	now := m.now()
+	if state.IssuedReads+state.IssuedWrites == 0 {
+		state.FirstIssueCycle = now
+	}
+
+	state.IssueDelayCycles += now - access.Cycle
+	state.Pending[msg.Meta().ID] = pendingAccess{Write: access.Write, IssueCycle: now}
+	state.NextAccess++
+
+	if access.Write {
+		state.IssuedWrites++
+	} else {
+		state.IssuedReads++
+	}
+
+	return true
+}
+
+func (m *middleware) makeReq(access Access) messaging.Msg {
+	size := m.driver.Spec().AccessByteSize
+	address := access.Address &^ (size - 1)
+
+	meta := messaging.MsgMeta{
+		ID:  timing.GetIDGenerator().Generate(),
+		Src: m.memPort().AsRemote(),
+		Dst: m.driver.LowModule.AsRemote(),
+	}
+
+	if access.Write {
+		meta.TrafficBytes = int(size) + 12
+		meta.TrafficClass = "memprotocol.WriteReq"
+
+		return memprotocol.WriteReq{
+			MsgMeta: meta,
+			Address: address,
+			Data:    make([]byte, size),
+			PID:     1,
+		}
+	}
+
+	meta.TrafficBytes = 12
+	meta.TrafficClass = "memprotocol.ReadReq"
+
+	return memprotocol.ReadReq{
+		MsgMeta:        meta,
+		Address:        address,
+		AccessByteSize: size,
+		PID:            1,
+	}
+}
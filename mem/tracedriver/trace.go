@@ -0,0 +1,151 @@
+package tracedriver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Access is one memory access of a trace.
+type Access struct {
+	// Cycle is the driver cycle at which the access is issued at the
+	// earliest. Accesses of a trace without cycles all have cycle 0 and are
+	// issued as fast as the memory accepts them.
+	Cycle   uint64 `json:"cycle"`
+	Write   bool   `json:"write"`
+	Address uint64 `json:"address"`
+}
+
+// traceOps maps the operation tokens of the trace formats to whether the
+// access is a write.
+var traceOps = map[string]bool{
+	"R":     false,
+	"READ":  false,
+	"RD":    false,
+	"LD":    false,
+	"LOAD":  false,
+	"W":     true,
+	"WRITE": true,
+	"WR":    true,
+	"ST":    true,
+	"STORE": true,
+}
+
+// ParseTrace reads an address trace, one access per line. It accepts the
+// formats of the reference simulators and a plain one:
+//
+//	0x12345680 READ 121     DRAMSim3: address, operation, cycle
+//	0x12345680 R            Ramulator: address, operation
+//	LD 0x12345680           Ramulator2 load/store trace: operation, address
+//	121 W 0x12345680        cycle, operation, address
+//
+// Fields are separated by spaces, tabs, or commas, and operations are case
+// insensitive. An address is hexadecimal when it has a 0x prefix and decimal
+// otherwise. Of two numbers, a 0x-prefixed one is the address; without a
+// prefix, the first is the cycle. Blank lines and lines starting with # are
+// skipped.
+func ParseTrace(r io.Reader) ([]Access, error) {
+	var trace []Access
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		access, err := parseTraceLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("tracedriver: line %d: %w", lineNo, err)
+		}
+
+		trace = append(trace, access)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("tracedriver: %w", err)
+	}
+
+	return trace, nil
+}
+
+func parseTraceLine(line string) (Access, error) {
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ','
+	})
+
+	var (
+		access  Access
+		hasOp   bool
+		numbers []string
+	)
+
+	for _, f := range fields {
+		write, isOp := traceOps[strings.ToUpper(f)]
+		switch {
+		case isOp && hasOp:
+			return Access{}, fmt.Errorf("more than one operation in %q", line)
+		case isOp:
+			access.Write, hasOp = write, true
+		default:
+			numbers = append(numbers, f)
+		}
+	}
+
+	if !hasOp {
+		return Access{}, fmt.Errorf("no operation in %q", line)
+	}
+
+	addr, cycle, err := splitAddressAndCycle(numbers)
+	if err != nil {
+		return Access{}, fmt.Errorf("%w in %q", err, line)
+	}
+
+	if access.Address, err = parseTraceNumber(addr); err != nil {
+		return Access{}, err
+	}
+
+	if cycle != "" {
+		if access.Cycle, err = parseTraceNumber(cycle); err != nil {
+			return Access{}, err
+		}
+	}
+
+	return access, nil
+}
+
+// splitAddressAndCycle tells the address from the cycle among the numbers of a
+// line. The cycle is empty if the line has none.
+func splitAddressAndCycle(numbers []string) (addr, cycle string, err error) {
+	switch len(numbers) {
+	case 1:
+		return numbers[0], "", nil
+	case 2:
+		if isHex(numbers[0]) && !isHex(numbers[1]) {
+			return numbers[0], numbers[1], nil
+		}
+
+		return numbers[1], numbers[0], nil
+	default:
+		return "", "", fmt.Errorf("expected an address and an optional cycle, got %d numbers",
+			len(numbers))
+	}
+}
+
+func isHex(s string) bool {
+	return strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")
+}
+
+func parseTraceNumber(s string) (uint64, error) {
+	if isHex(s) {
+		return strconv.ParseUint(s[2:], 16, 64)
+	}
+
+	return strconv.ParseUint(s, 10, 64)
+}
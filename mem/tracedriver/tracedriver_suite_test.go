@@ -0,0 +1,13 @@
+package tracedriver_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTracedriver(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracedriver Suite")
+}
@@ -0,0 +1,183 @@
+package tracedriver_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/akita/v5/mem/dram"
+	"github.com/sarchlab/akita/v5/mem/idealmemcontroller"
+	"github.com/sarchlab/akita/v5/mem/tracedriver"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+var _ = Describe("ParseTrace", func() {
+	It("should parse the trace formats", func() {
+		trace, err := tracedriver.ParseTrace(strings.NewReader(`
+# DRAMSim3
+0x1000 READ 10
+0x2040 WRITE 12
+# Ramulator
+0x3000 R
+0x3040 w
+# Ramulator2
+LD 0x4000
+ST 0x4040
+# cycle, operation, address
+20, R, 0x5000
+21 W 20544
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(trace).To(Equal([]tracedriver.Access{
+			{Cycle: 10, Address: 0x1000},
+			{Cycle: 12, Write: true, Address: 0x2040},
+			{Address: 0x3000},
+			{Write: true, Address: 0x3040},
+			{Address: 0x4000},
+			{Write: true, Address: 0x4040},
+			{Cycle: 20, Address: 0x5000},
+			{Cycle: 21, Write: true, Address: 0x5040},
+		}))
+	})
+
+	It("should report the line of a malformed access", func() {
+		for _, line := range []string{
+			"0x1000",
+			"0x1000 READ WRITE",
+			"0x1000 FETCH 10",
+			"0x1000 READ 10 11",
+			"0xZZ READ",
+			"READ -1",
+		} {
+			_, err := tracedriver.ParseTrace(strings.NewReader("0x0 R\n" + line))
+			Expect(err).To(MatchError(ContainSubstring("line 2")), line)
+		}
+	})
+})
+
+var _ = Describe("Trace driver", func() {
+	var (
+		engine timing.Engine
+		reg    modeling.Registrar
+		conn   *directconnection.Comp
+	)
+
+	BeforeEach(func() {
+		engine = timing.NewSerialEngine()
+		reg = modeling.NewStandaloneRegistrar(engine)
+		conn = directconnection.MakeBuilder().
+			WithRegistrar(reg).
+			Build("Conn")
+	})
+
+	assignPorts := func(comp messaging.Component, names ...string) {
+		for _, name := range names {
+			comp.AssignPort(name,
+				messaging.NewPort(comp, 16, 16, comp.Name()+"."+name))
+		}
+	}
+
+	buildDriver := func(
+		spec tracedriver.Spec,
+		trace []tracedriver.Access,
+		low messaging.Component,
+	) *tracedriver.Comp {
+		driver := tracedriver.MakeBuilder().
+			WithRegistrar(reg).
+			WithSpec(spec).
+			WithResources(tracedriver.Resources{
+				LowModule: low.GetPortByName("Top"),
+				Trace:     trace,
+			}).
+			Build("Driver")
+		assignPorts(driver, "Mem")
+
+		conn.PlugIn(driver.GetPortByName("Mem"))
+		conn.PlugIn(low.GetPortByName("Top"))
+
+		return driver
+	}
+
+	buildIdeal := func(latency int) *idealmemcontroller.Comp {
+		spec := idealmemcontroller.DefaultSpec()
+		spec.Latency = latency
+		ctrl := idealmemcontroller.MakeBuilder().
+			WithRegistrar(reg).
+			WithSpec(spec).
+			Build("Mem")
+		assignPorts(ctrl, "Top", "Control")
+
+		return ctrl
+	}
+
+	It("should replay a trace into an ideal memory controller", func() {
+		trace := []tracedriver.Access{
+			{Cycle: 0, Address: 0x0},
+			{Cycle: 0, Write: true, Address: 0x40},
+			{Cycle: 500, Address: 0x80},
+		}
+		driver := buildDriver(tracedriver.DefaultSpec(), trace, buildIdeal(100))
+
+		driver.TickLater()
+		Expect(engine.Run()).To(Succeed())
+
+		Expect(driver.Done()).To(BeTrue())
+		Expect(driver.State.CompletedReads).To(Equal(uint64(2)))
+		Expect(driver.State.CompletedWrites).To(Equal(uint64(1)))
+		Expect(driver.BytesTransferred()).To(Equal(uint64(192)))
+		Expect(driver.State.FirstIssueCycle).To(BeNumerically("<=", 1))
+		Expect(driver.State.LastCompletionCycle).To(BeNumerically(">", 600))
+
+		reads := driver.ReadLatency()
+		Expect(reads.Count).To(Equal(uint64(2)))
+		Expect(reads.Min).To(BeNumerically(">=", 100))
+		Expect(reads.Max).To(BeNumerically("<", 128))
+		Expect(driver.WriteLatency().Count).To(Equal(uint64(1)))
+	})
+
+	It("should cap the accesses in flight", func() {
+		trace := make([]tracedriver.Access, 8)
+		for i := range trace {
+			trace[i].Address = uint64(i) * 64
+		}
+
+		spec := tracedriver.DefaultSpec()
+		spec.MaxInFlight = 1
+		driver := buildDriver(spec, trace, buildIdeal(100))
+
+		driver.TickLater()
+		Expect(engine.Run()).To(Succeed())
+
+		Expect(driver.Done()).To(BeTrue())
+		Expect(driver.ActiveCycles()).To(BeNumerically(">", 8*100))
+		Expect(driver.State.IssueDelayCycles).To(BeNumerically(">", 7*100))
+	})
+
+	It("should replay a trace into a DRAM controller", func() {
+		ctrl := dram.MakeBuilder().
+			WithRegistrar(reg).
+			WithSpec(dram.DDR4Spec).
+			Build("Mem")
+		assignPorts(ctrl, "Top", "Control")
+
+		trace := make([]tracedriver.Access, 32)
+		for i := range trace {
+			trace[i] = tracedriver.Access{Write: i%4 == 0, Address: uint64(i) * 64}
+		}
+
+		driver := buildDriver(tracedriver.DefaultSpec(), trace, ctrl)
+
+		driver.TickLater()
+		Expect(engine.Run()).To(Succeed())
+
+		Expect(driver.Done()).To(BeTrue())
+		Expect(driver.State.CompletedReads).To(Equal(uint64(24)))
+		Expect(driver.State.CompletedWrites).To(Equal(uint64(8)))
+		Expect(driver.Bandwidth()).To(BeNumerically(">", 0))
+		Expect(ctrl.State.RowBufferHits).To(BeNumerically(">", 0))
+	})
+})
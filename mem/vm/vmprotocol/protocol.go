@@ -6,6 +6,7 @@ package vmprotocol
 import (
 	"github.com/sarchlab/akita/v5/mem/vm"
 	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/messaging/txcheck"
 )
 
 // Protocol is the address translation protocol: requesters (TLBs, address
@@ -23,6 +24,12 @@ var (
 	Responder = Protocol.Role("responder")
 )
 
+// TransactionRule pairs every translation request with the response that answers
+// it, for checking traffic with a txcheck.Checker.
+var TransactionRule = txcheck.NewRule(Protocol,
+	txcheck.Answers(TranslationReq{}, TranslationRsp{}),
+)
+
 // TranslationReq is a translation request.
 type TranslationReq struct {
 	messaging.MsgMeta
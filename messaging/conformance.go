@@ -0,0 +1,247 @@
+package messaging
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sarchlab/akita/v5/hooking"
+)
+
+// HookPosConnPlugIn marks when a port is plugged into a connection. The hook's
+// Domain is the connection and its Item is the port.
+var HookPosConnPlugIn = &hooking.HookPos{Name: "Conn PlugIn"}
+
+// ProtocolViolation describes traffic or wiring that breaks the protocol roles
+// a port was declared with. A ProtocolChecker panics with it.
+type ProtocolViolation struct {
+	Component string
+	Port      string
+	Reason    string
+}
+
+func (v *ProtocolViolation) Error() string {
+	return fmt.Sprintf("protocol violation at port %s of component %s: %s",
+		v.Port, v.Component, v.Reason)
+}
+
+// roleLookup is implemented by PortOwnerBase and everything embedding it.
+type roleLookup interface {
+	LookupPortRoles(name string) ([]*Role, bool)
+}
+
+// outstandingReq identifies a request by its ID and the port that sent it, as
+// forwarding components may reuse an ID on a different port.
+type outstandingReq struct {
+	id        uint64
+	requester RemotePort
+}
+
+// ProtocolChecker enforces the roles ports are declared with (see
+// PortOwnerBase.DeclarePort) on live traffic. It is a hook, attached with
+// AcceptHook to the ports and connections to check. It checks that
+//
+//   - a port plugged into a connection shares a protocol with every typed port
+//     already plugged in, so a memory port cannot be wired to a translation
+//     port. Only connections that invoke HookPosConnPlugIn, such as direct
+//     connections, are checked; a network legitimately carries several
+//     protocols.
+//   - every message a typed port sends is a type its roles may send.
+//   - every response a typed port sends answers an outstanding request that
+//     the response's destination sent to this port. Responses to ports the
+//     checker has not seen send are not checked, as their requests were not
+//     recorded.
+//
+// Ports declared without roles are not checked. On a violation the checker
+// panics with a *ProtocolViolation, so checking is meant for tests and
+// debugging runs.
+type ProtocolChecker struct {
+	mu          sync.Mutex
+	senders     map[RemotePort]bool
+	plugged     map[string][]Port
+	outstanding map[outstandingReq]RemotePort
+}
+
+// NewProtocolChecker creates a checker that watches no ports yet.
+func NewProtocolChecker() *ProtocolChecker {
+	return &ProtocolChecker{
+		senders:     map[RemotePort]bool{},
+		plugged:     map[string][]Port{},
+		outstanding: map[outstandingReq]RemotePort{},
+	}
+}
+
+// Func implements hooking.Hook.
+func (c *ProtocolChecker) Func(ctx hooking.HookCtx) {
+	switch ctx.Pos {
+	case HookPosConnPlugIn:
+		conn, _ := ctx.Domain.(Connection)
+		port, _ := ctx.Item.(Port)
+
+		if conn != nil && port != nil {
+			c.checkPlugIn(conn, port)
+		}
+	case HookPosPortMsgSend:
+		port, _ := ctx.Domain.(Port)
+		msg, _ := ctx.Item.(Msg)
+
+		if port != nil && msg != nil {
+			c.checkSend(port, msg)
+		}
+	}
+}
+
+func (c *ProtocolChecker) checkPlugIn(conn Connection, port Port) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	roles := PortRolesOf(port)
+
+	for _, peer := range c.plugged[conn.Name()] {
+		peerRoles := PortRolesOf(peer)
+		if len(roles) == 0 || len(peerRoles) == 0 || shareProtocol(roles, peerRoles) {
+			continue
+		}
+
+		panic(violation(port, fmt.Sprintf(
+			"plugged into %s, where port %s of component %s speaks %s; "+
+				"this port speaks %s",
+			conn.Name(), peer.Name(), componentName(peer),
+			describeRoles(peerRoles), describeRoles(roles))))
+	}
+
+	c.plugged[conn.Name()] = append(c.plugged[conn.Name()], port)
+}
+
+func (c *ProtocolChecker) checkSend(port Port, msg Msg) {
+	roles := PortRolesOf(port)
+	if len(roles) == 0 {
+		return
+	}
+
+	if !rolesSend(roles, msg) {
+		panic(violation(port, fmt.Sprintf(
+			"sends %s, which %s does not send", msgTypeName(msg), describeRoles(roles))))
+	}
+
+	meta := msg.Meta()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.senders[meta.Src] = true
+
+	if !meta.IsRsp() {
+		c.outstanding[outstandingReq{id: meta.ID, requester: meta.Src}] = meta.Dst
+		return
+	}
+
+	if !c.senders[meta.Dst] {
+		return
+	}
+
+	key := outstandingReq{id: meta.RspTo, requester: meta.Dst}
+
+	reqDst, found := c.outstanding[key]
+	if !found || reqDst != meta.Src {
+		panic(violation(port, fmt.Sprintf(
+			"sends %s to %s with RspTo %d, but %s has no outstanding "+
+				"request %d to this port",
+			msgTypeName(msg), meta.Dst, meta.RspTo, meta.Dst, meta.RspTo)))
+	}
+
+	delete(c.outstanding, key)
+}
+
+func violation(port Port, reason string) *ProtocolViolation {
+	return &ProtocolViolation{
+		Component: componentName(port),
+		Port:      port.Name(),
+		Reason:    reason,
+	}
+}
+
+func componentName(port Port) string {
+	if comp := port.Component(); comp != nil {
+		return comp.Name()
+	}
+
+	return "<none>"
+}
+
+// groupIndex matches the index suffix of a port group member's name.
+var groupIndex = regexp.MustCompile(`\[\d+\]$`)
+
+// PortRolesOf returns the roles the port's component declared for it, or nil if
+// the port is untyped or its component does not declare roles.
+func PortRolesOf(port Port) []*Role {
+	comp := port.Component()
+	if comp == nil {
+		return nil
+	}
+
+	lookup, ok := comp.(roleLookup)
+	if !ok {
+		return nil
+	}
+
+	name := strings.TrimPrefix(port.Name(), comp.Name()+".")
+
+	if roles, found := lookup.LookupPortRoles(name); found {
+		return roles
+	}
+
+	roles, _ := lookup.LookupPortRoles(groupIndex.ReplaceAllString(name, ""))
+
+	return roles
+}
+
+func shareProtocol(a, b []*Role) bool {
+	for _, ra := range a {
+		for _, rb := range b {
+			if ra.Protocol() == rb.Protocol() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func rolesSend(roles []*Role, msg Msg) bool {
+	t := msgType(msg)
+
+	for _, r := range roles {
+		for _, allowed := range r.sends {
+			if msgType(allowed) == t {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func msgType(msg Msg) reflect.Type {
+	t := reflect.TypeOf(msg)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+func msgTypeName(msg Msg) string {
+	return msgType(msg).String()
+}
+
+func describeRoles(roles []*Role) string {
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = r.Protocol().Name() + " " + r.Name()
+	}
+
+	return strings.Join(names, " and ")
+}
@@ -0,0 +1,132 @@
+package messaging_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+type confReq struct{ messaging.MsgMeta }
+type confRsp struct{ messaging.MsgMeta }
+type confOtherReq struct{ messaging.MsgMeta }
+
+var (
+	confProtocol = messaging.DefineProtocol("test.conformance",
+		messaging.RoleDef{Name: "requester", Sends: []messaging.Msg{confReq{}}},
+		messaging.RoleDef{Name: "responder", Sends: []messaging.Msg{confRsp{}}},
+	)
+	confOtherProtocol = messaging.DefineProtocol("test.conformance.other",
+		messaging.RoleDef{Name: "requester", Sends: []messaging.Msg{confOtherReq{}}},
+	)
+)
+
+type confComp struct {
+	hooking.HookableBase
+	*messaging.PortOwnerBase
+
+	name string
+}
+
+func (c *confComp) Name() string                  { return c.name }
+func (c *confComp) NotifyRecv(messaging.Port)     {}
+func (c *confComp) NotifyPortFree(messaging.Port) {}
+
+func newConfPort(
+	checker *messaging.ProtocolChecker,
+	compName string,
+	role *messaging.Role,
+) messaging.Port {
+	c := &confComp{PortOwnerBase: messaging.NewPortOwnerBase(), name: compName}
+	c.DeclarePort("Port", role)
+
+	port := messaging.NewPort(c, 4, 4, compName+".Port")
+	c.AssignPort("Port", port)
+	port.AcceptHook(checker)
+
+	return port
+}
+
+func newConfConn(checker *messaging.ProtocolChecker) *directconnection.Comp {
+	conn := directconnection.MakeBuilder().
+		WithRegistrar(modeling.NewStandaloneRegistrar(timing.NewSerialEngine())).
+		Build("Conn")
+	conn.AcceptHook(checker)
+
+	return conn
+}
+
+func expectViolation(t *testing.T, want string, f func()) {
+	t.Helper()
+
+	defer func() {
+		err, _ := recover().(error)
+
+		var v *messaging.ProtocolViolation
+		if !errors.As(err, &v) || !strings.Contains(v.Error(), want) {
+			t.Fatalf("expected a violation containing %q, got %v", want, err)
+		}
+	}()
+
+	f()
+}
+
+func meta(src, dst messaging.Port, id, rspTo uint64) messaging.MsgMeta {
+	return messaging.MsgMeta{ID: id, Src: src.AsRemote(), Dst: dst.AsRemote(), RspTo: rspTo}
+}
+
+func TestProtocolCheckerAcceptsConformingTraffic(t *testing.T) {
+	checker := messaging.NewProtocolChecker()
+	conn := newConfConn(checker)
+	req := newConfPort(checker, "A", confProtocol.Role("requester"))
+	rsp := newConfPort(checker, "B", confProtocol.Role("responder"))
+	conn.PlugIn(req)
+	conn.PlugIn(rsp)
+
+	req.Send(confReq{meta(req, rsp, 1, 0)})
+	rsp.Send(confRsp{meta(rsp, req, 2, 1)})
+}
+
+func TestProtocolCheckerRejectsIncompatiblePlugIn(t *testing.T) {
+	checker := messaging.NewProtocolChecker()
+	conn := newConfConn(checker)
+	conn.PlugIn(newConfPort(checker, "A", confProtocol.Role("requester")))
+
+	expectViolation(t, "port B.Port of component B: plugged into Conn", func() {
+		conn.PlugIn(newConfPort(checker, "B", confOtherProtocol.Role("requester")))
+	})
+}
+
+func TestProtocolCheckerRejectsMsgTypeOutsideRole(t *testing.T) {
+	checker := messaging.NewProtocolChecker()
+	conn := newConfConn(checker)
+	req := newConfPort(checker, "A", confProtocol.Role("requester"))
+	rsp := newConfPort(checker, "B", confProtocol.Role("responder"))
+	conn.PlugIn(req)
+	conn.PlugIn(rsp)
+
+	expectViolation(t, "sends messaging_test.confRsp, which test.conformance requester", func() {
+		req.Send(confRsp{meta(req, rsp, 1, 0)})
+	})
+}
+
+func TestProtocolCheckerRejectsUnmatchedResponse(t *testing.T) {
+	checker := messaging.NewProtocolChecker()
+	conn := newConfConn(checker)
+	req := newConfPort(checker, "A", confProtocol.Role("requester"))
+	rsp := newConfPort(checker, "B", confProtocol.Role("responder"))
+	conn.PlugIn(req)
+	conn.PlugIn(rsp)
+
+	req.Send(confReq{meta(req, rsp, 1, 0)})
+	rsp.Send(confRsp{meta(rsp, req, 2, 1)})
+
+	expectViolation(t, "no outstanding request 1", func() {
+		rsp.Send(confRsp{meta(rsp, req, 3, 1)})
+	})
+}
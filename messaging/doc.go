@@ -3,5 +3,7 @@
 // A protocol is a named set of message types organized into roles. Packages
 // that define message types declare their protocol once with DefineProtocol,
 // which registers every message type with the checkpoint codec, and ports
-// declare the role(s) they speak in DeclarePort.
+// declare the role(s) they speak in DeclarePort. A ProtocolChecker, attached
+// as a hook to ports and connections, enforces the declared roles on live
+// traffic.
 package messaging
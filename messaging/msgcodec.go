@@ -1,6 +1,10 @@
 package messaging
 
-import "github.com/sarchlab/akita/v5/internal/codec"
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v5/internal/codec"
+)
 
 // msgCodec decodes the polymorphic messages held in port buffers across a
 // checkpoint. Each concrete message type is registered with RegisterMsg; the
@@ -21,3 +25,41 @@ var msgCodec = codec.NewRegistry[Msg]("message")
 func RegisterMsg(msg Msg) {
 	msgCodec.Register(msg)
 }
+
+// HeldMsg holds a message in a component's State, such as a message in flight
+// on a link. It marshals the message together with its type tag, so that the
+// State survives a checkpoint like the messages in port buffers do; the
+// message's type must be registered.
+type HeldMsg struct {
+	Msg Msg
+}
+
+// MarshalJSON encodes the message with its type tag.
+func (h HeldMsg) MarshalJSON() ([]byte, error) {
+	if h.Msg == nil {
+		return []byte("null"), nil
+	}
+
+	return msgCodec.EncodeSlice([]Msg{h.Msg})
+}
+
+// UnmarshalJSON decodes a message encoded by MarshalJSON.
+func (h *HeldMsg) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		h.Msg = nil
+		return nil
+	}
+
+	msgs, err := msgCodec.DecodeSlice(data)
+	if err != nil {
+		return err
+	}
+
+	if len(msgs) != 1 {
+		return fmt.Errorf("messaging: held message holds %d messages", len(msgs))
+	}
+
+	h.Msg = msgs[0]
+
+	return nil
+}
@@ -32,3 +32,29 @@ func TestMsgRegistryUnknownType(t *testing.T) {
 		t.Fatalf("expected unknown-type error, got %v", err)
 	}
 }
+
+func TestHeldMsgRoundTrip(t *testing.T) {
+	RegisterMsg(registryTestMsg{})
+
+	msg := registryTestMsg{Value: 42}
+	msg.ID = 7
+
+	type state struct {
+		Held  HeldMsg `json:"held"`
+		Empty HeldMsg `json:"empty"`
+	}
+
+	data, err := json.Marshal(state{Held: HeldMsg{Msg: msg}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var restored state
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Held.Msg != msg || restored.Empty.Msg != nil {
+		t.Fatalf("unexpected restored state %+v", restored)
+	}
+}
@@ -81,6 +81,29 @@ func (po PortOwnerBase) PortRoles(name string) []*Role {
 	return po.roles[name]
 }
 
+// LookupPortRoles is PortRoles for callers that cannot be sure the name is
+// declared: found is false, instead of a panic, when name is neither a port
+// nor a port group.
+func (po PortOwnerBase) LookupPortRoles(name string) (roles []*Role, found bool) {
+	_, isPort := po.declared[name]
+	_, isGroup := po.groups[name]
+
+	return po.roles[name], isPort || isGroup
+}
+
+// DeclaredPorts returns the logical names of the declared ports, excluding
+// port groups, sorted.
+func (po PortOwnerBase) DeclaredPorts() []string {
+	names := make([]string, 0, len(po.declared))
+	for name := range po.declared {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
 // AssignPortToGroup appends a port instance to a previously declared port group
 // and returns the indexed name it is stored under ("name[i]"). It panics if the
 // group was not declared.
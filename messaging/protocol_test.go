@@ -140,4 +140,9 @@ func TestPortRoles(t *testing.T) {
 	mustPanic(t, "not declared", func() {
 		po.PortRoles("Nonexistent")
 	})
+
+	if names := po.DeclaredPorts(); len(names) != 2 ||
+		names[0] != "Legacy" || names[1] != "Top" {
+		t.Errorf("DeclaredPorts() = %v", names)
+	}
 }
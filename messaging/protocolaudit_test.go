@@ -20,6 +20,7 @@ import (
 	_ "github.com/sarchlab/akita/v5/mem/vm/vmprotocol"
 	_ "github.com/sarchlab/akita/v5/noc/acceptance"
 	_ "github.com/sarchlab/akita/v5/noc/packetization"
+	_ "github.com/sarchlab/akita/v5/simulation/phasecontrol"
 )
 
 const modulePath = "github.com/sarchlab/akita/v5"
@@ -0,0 +1,385 @@
+package txcheck
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// IssueKind classifies a transaction issue.
+type IssueKind string
+
+// The kinds of issues a Checker reports.
+const (
+	// Orphan is a response that answers no request the checker has seen.
+	Orphan IssueKind = "orphan_response"
+	// Duplicate is a second, different response to an answered request, or
+	// the answering response delivered again at the same port.
+	Duplicate IssueKind = "duplicate_response"
+	// Mismatched is a response whose type the rule does not allow as an
+	// answer to the request.
+	Mismatched IssueKind = "mismatched_response"
+	// Unanswered is a request still waiting for its response.
+	Unanswered IssueKind = "unanswered_request"
+	// LatencyExceeded is a request answered later than the rule's latency
+	// bound.
+	LatencyExceeded IssueKind = "latency_exceeded"
+)
+
+// Issue is one transaction that broke a rule.
+type Issue struct {
+	Kind     IssueKind
+	Protocol string
+	// Port is the port where the offending message was observed.
+	Port     string
+	Time     timing.VTimeInPicoSec
+	Request  messaging.Msg
+	Response messaging.Msg
+	// Latency is the time from the request to the response, or to the report
+	// for unanswered requests.
+	Latency timing.VTimeInPicoSec
+}
+
+func (i Issue) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %s at %s, t=%d", i.Kind, i.Protocol, i.Port, i.Time)
+
+	if i.Request != nil {
+		meta := i.Request.Meta()
+		fmt.Fprintf(&b, ", request %T %d from %s to %s",
+			i.Request, meta.ID, meta.Src, meta.Dst)
+	}
+
+	if i.Response != nil {
+		meta := i.Response.Meta()
+		fmt.Fprintf(&b, ", response %T %d (rsp to %d) from %s",
+			i.Response, meta.ID, meta.RspTo, meta.Src)
+	}
+
+	if i.Kind == Unanswered || i.Kind == LatencyExceeded {
+		fmt.Fprintf(&b, ", latency %d", i.Latency)
+	}
+
+	return b.String()
+}
+
+// txKey identifies a request by its ID and the port that sent it, as
+// forwarding components may reuse an ID on a different port.
+type txKey struct {
+	id        uint64
+	requester messaging.RemotePort
+}
+
+type pendingReq struct {
+	req  messaging.Msg
+	rule *Rule
+	port string
+	time timing.VTimeInPicoSec
+}
+
+type answeredReq struct {
+	req   messaging.Msg
+	rule  *Rule
+	rspID uint64
+	// seenAt counts the deliveries of the answering response at each port.
+	seenAt map[string]int
+}
+
+// flaggedRsp is a response already reported and the number of times it was
+// observed at each port.
+type flaggedRsp struct {
+	issue  Issue
+	seenAt map[string]int
+}
+
+// newDelivery counts an observation of a message at a port and tells whether
+// it is a new delivery, rather than the other end of one already counted.
+func newDelivery(seenAt map[string]int, port string) bool {
+	seenAt[port]++
+
+	for other, n := range seenAt {
+		if other != port && n >= seenAt[port] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DefaultHistory is the number of answered requests and reported responses a
+// checker remembers by default.
+const DefaultHistory = 1 << 16
+
+// Checker pairs the requests and responses observed at the ports it is
+// attached to. It is a hook, attached with AcceptHook to any set of ports, and
+// observes both sent and received messages, so watching either end of a
+// connection is enough. A message seen at both ends is counted once.
+//
+// Unlike messaging.ProtocolChecker, the checker does not panic. Problems with
+// responses are collected as they happen; requests still unanswered are added
+// when a report is taken. Call Report at the end of a simulation or at any
+// time in between.
+//
+// To find duplicates, the checker remembers the most recent answered requests
+// and reported responses, up to a history length (see WithHistory). Older ones
+// are forgotten, so memory stays bounded on long runs.
+type Checker struct {
+	mu         sync.Mutex
+	timeTeller timing.TimeTeller
+	rules      []*Rule
+	history    int
+	pending    map[txKey]pendingReq
+	answered   map[txKey]*answeredReq
+	// answeredOrder lists the keys of answered, oldest first.
+	answeredOrder []txKey
+	// flagged holds the responses already reported, keyed by their own ID and
+	// sender, so that a response seen at both ends is reported once.
+	flagged      map[txKey]*flaggedRsp
+	flaggedOrder []txKey
+	issues       []Issue
+}
+
+// NewChecker creates a checker that enforces the given rules, timestamping
+// observations with the time teller.
+func NewChecker(timeTeller timing.TimeTeller, rules ...*Rule) *Checker {
+	return &Checker{
+		timeTeller: timeTeller,
+		rules:      rules,
+		history:    DefaultHistory,
+		pending:    map[txKey]pendingReq{},
+		answered:   map[txKey]*answeredReq{},
+		flagged:    map[txKey]*flaggedRsp{},
+	}
+}
+
+// WithHistory sets the number of answered requests and reported responses the
+// checker remembers, and returns the checker. A duplicate of a forgotten
+// response is reported as an orphan. Set it before attaching the checker.
+func (c *Checker) WithHistory(n int) *Checker {
+	if n <= 0 {
+		panic("txcheck: history must be positive")
+	}
+
+	c.history = n
+
+	return c
+}
+
+// Func implements hooking.Hook.
+func (c *Checker) Func(ctx hooking.HookCtx) {
+	if ctx.Pos != messaging.HookPosPortMsgSend &&
+		ctx.Pos != messaging.HookPosPortMsgRecvd {
+		return
+	}
+
+	port, _ := ctx.Domain.(messaging.Port)
+	msg, _ := ctx.Item.(messaging.Msg)
+
+	if port == nil || msg == nil {
+		return
+	}
+
+	c.observe(port.Name(), msg)
+}
+
+func (c *Checker) observe(port string, msg messaging.Msg) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.timeTeller.CurrentTime()
+	meta := msg.Meta()
+
+	for _, rule := range c.rules {
+		switch {
+		case meta.IsRsp() && rule.isRsp(msg):
+			c.observeRsp(port, now, rule, msg)
+			return
+		case !meta.IsRsp() && rule.isReq(msg):
+			c.observeReq(port, now, rule, msg)
+			return
+		}
+	}
+}
+
+func (c *Checker) observeReq(
+	port string,
+	now timing.VTimeInPicoSec,
+	rule *Rule,
+	req messaging.Msg,
+) {
+	key := txKey{id: req.Meta().ID, requester: req.Meta().Src}
+
+	if _, found := c.pending[key]; found {
+		return
+	}
+
+	if _, found := c.answered[key]; found {
+		return
+	}
+
+	c.pending[key] = pendingReq{req: req, rule: rule, port: port, time: now}
+}
+
+func (c *Checker) observeRsp(
+	port string,
+	now timing.VTimeInPicoSec,
+	rule *Rule,
+	rsp messaging.Msg,
+) {
+	meta := rsp.Meta()
+	key := txKey{id: meta.RspTo, requester: meta.Dst}
+	issue := Issue{Protocol: rule.Protocol(), Port: port, Time: now, Response: rsp}
+	rspKey := txKey{id: meta.ID, requester: meta.Src}
+
+	if flagged, found := c.flagged[rspKey]; found {
+		if newDelivery(flagged.seenAt, port) {
+			issue.Kind = flagged.issue.Kind
+			issue.Request = flagged.issue.Request
+			c.issues = append(c.issues, issue)
+		}
+
+		return
+	}
+
+	if done, found := c.answered[key]; found {
+		switch {
+		case done.rspID != meta.ID:
+			issue.Kind = Duplicate
+			issue.Request = done.req
+			c.flag(port, rspKey, issue)
+		case newDelivery(done.seenAt, port):
+			// The answering response, delivered again.
+			issue.Kind = Duplicate
+			issue.Request = done.req
+			c.issues = append(c.issues, issue)
+		}
+
+		return
+	}
+
+	pending, found := c.pending[key]
+	if !found {
+		issue.Kind = Orphan
+		c.flag(port, rspKey, issue)
+
+		return
+	}
+
+	delete(c.pending, key)
+	c.answered[key] = &answeredReq{
+		req:    pending.req,
+		rule:   pending.rule,
+		rspID:  meta.ID,
+		seenAt: map[string]int{port: 1},
+	}
+	c.answeredOrder = c.remember(c.answeredOrder, key, func(k txKey) {
+		delete(c.answered, k)
+	})
+
+	issue.Request = pending.req
+	issue.Latency = now - pending.time
+
+	if !pending.rule.answers(pending.req, rsp) {
+		issue.Kind = Mismatched
+		c.issues = append(c.issues, issue)
+	}
+
+	bound := pending.rule.latencyBound
+	if bound > 0 && issue.Latency > bound {
+		issue.Kind = LatencyExceeded
+		c.issues = append(c.issues, issue)
+	}
+}
+
+func (c *Checker) flag(port string, rspKey txKey, issue Issue) {
+	c.flagged[rspKey] = &flaggedRsp{issue: issue, seenAt: map[string]int{port: 1}}
+	c.flaggedOrder = c.remember(c.flaggedOrder, rspKey, func(k txKey) {
+		delete(c.flagged, k)
+	})
+	c.issues = append(c.issues, issue)
+}
+
+// remember appends a key to a history, oldest first, and forgets the oldest
+// key once the history is longer than the checker keeps.
+func (c *Checker) remember(order []txKey, key txKey, forget func(txKey)) []txKey {
+	order = append(order, key)
+
+	if len(order) > c.history {
+		forget(order[0])
+		order = order[1:]
+	}
+
+	return order
+}
+
+// Issues returns the issues found with responses so far, in the order they
+// were observed. It does not include unanswered requests; see Report.
+func (c *Checker) Issues() []Issue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Issue(nil), c.issues...)
+}
+
+// NumPending returns the number of requests waiting for a response.
+func (c *Checker) NumPending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.pending)
+}
+
+// Report returns the issues found so far followed by one Unanswered issue for
+// every request still waiting for its response, oldest first.
+func (c *Checker) Report() []Issue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.timeTeller.CurrentTime()
+	report := append([]Issue(nil), c.issues...)
+	unanswered := make([]Issue, 0, len(c.pending))
+
+	for _, p := range c.pending {
+		unanswered = append(unanswered, Issue{
+			Kind:     Unanswered,
+			Protocol: p.rule.Protocol(),
+			Port:     p.port,
+			Time:     p.time,
+			Request:  p.req,
+			Latency:  now - p.time,
+		})
+	}
+
+	sort.Slice(unanswered, func(i, j int) bool {
+		if unanswered[i].Time != unanswered[j].Time {
+			return unanswered[i].Time < unanswered[j].Time
+		}
+
+		return unanswered[i].Request.Meta().ID < unanswered[j].Request.Meta().ID
+	})
+
+	return append(report, unanswered...)
+}
+
+// Check returns an error that lists every issue in the report, or nil if the
+// report is empty.
+func (c *Checker) Check() error {
+	report := c.Report()
+	if len(report) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(report))
+	for i, issue := range report {
+		errs[i] = errors.New(issue.String())
+	}
+
+	return fmt.Errorf("%d transaction issue(s):\n%w", len(report), errors.Join(errs...))
+}
@@ -0,0 +1,178 @@
+package txcheck_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/messaging/txcheck"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+type txReq struct{ messaging.MsgMeta }
+type txRsp struct{ messaging.MsgMeta }
+type txNack struct{ messaging.MsgMeta }
+
+var txProtocol = messaging.DefineProtocol("test.txcheck",
+	messaging.RoleDef{Name: "requester", Sends: []messaging.Msg{txReq{}}},
+	messaging.RoleDef{Name: "responder",
+		Sends: []messaging.Msg{txRsp{}, txNack{}}},
+)
+
+type clock struct{ now timing.VTimeInPicoSec }
+
+func (c *clock) CurrentTime() timing.VTimeInPicoSec { return c.now }
+
+type harness struct {
+	t       *testing.T
+	clock   *clock
+	checker *txcheck.Checker
+	a, b    messaging.Port
+}
+
+func newHarness(t *testing.T, rule *txcheck.Rule) *harness {
+	h := &harness{t: t, clock: &clock{}}
+	h.checker = txcheck.NewChecker(h.clock, rule)
+	h.a = messaging.NewPort(nil, 4, 4, "A.Port")
+	h.b = messaging.NewPort(nil, 4, 4, "B.Port")
+
+	return h
+}
+
+// send reports msg to the checker as sent by its source port and received by
+// its destination port, the way both ends of a connection would.
+func (h *harness) send(msg messaging.Msg) {
+	src, dst := h.a, h.b
+	if msg.Meta().Src == h.b.AsRemote() {
+		src, dst = h.b, h.a
+	}
+
+	h.checker.Func(hooking.HookCtx{
+		Domain: src, Pos: messaging.HookPosPortMsgSend, Item: msg})
+	h.checker.Func(hooking.HookCtx{
+		Domain: dst, Pos: messaging.HookPosPortMsgRecvd, Item: msg})
+}
+
+func (h *harness) req(id uint64) txReq {
+	return txReq{messaging.MsgMeta{ID: id, Src: h.a.AsRemote(), Dst: h.b.AsRemote()}}
+}
+
+func (h *harness) rsp(id, rspTo uint64) txRsp {
+	return txRsp{messaging.MsgMeta{
+		ID: id, Src: h.b.AsRemote(), Dst: h.a.AsRemote(), RspTo: rspTo}}
+}
+
+func (h *harness) expectKinds(want ...txcheck.IssueKind) {
+	h.t.Helper()
+
+	report := h.checker.Report()
+	if len(report) != len(want) {
+		h.t.Fatalf("expected issues %v, got %v", want, report)
+	}
+
+	for i, issue := range report {
+		if issue.Kind != want[i] {
+			h.t.Fatalf("expected issues %v, got %v", want, report)
+		}
+	}
+}
+
+func TestCheckerAcceptsPairedTraffic(t *testing.T) {
+	h := newHarness(t, txcheck.NewRule(txProtocol,
+		txcheck.Answers(txReq{}, txRsp{}, txNack{})))
+
+	h.send(h.req(1))
+	h.send(h.req(2))
+	h.send(h.rsp(3, 1))
+	h.send(txNack{messaging.MsgMeta{
+		ID: 4, Src: h.b.AsRemote(), Dst: h.a.AsRemote(), RspTo: 2}})
+
+	h.expectKinds()
+
+	if err := h.checker.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckerReportsBrokenTransactions(t *testing.T) {
+	h := newHarness(t, txcheck.NewRule(txProtocol,
+		txcheck.Answers(txReq{}, txRsp{})))
+
+	h.send(h.rsp(10, 99))
+	h.send(h.req(1))
+	h.send(h.rsp(2, 1))
+	h.send(h.rsp(3, 1))
+	h.send(h.req(4))
+	h.send(txNack{messaging.MsgMeta{
+		ID: 5, Src: h.b.AsRemote(), Dst: h.a.AsRemote(), RspTo: 4}})
+	h.send(h.req(6))
+
+	h.expectKinds(txcheck.Orphan, txcheck.Duplicate, txcheck.Unanswered,
+		txcheck.Unanswered)
+
+	// A nack is not a response of this rule, so request 4 stays open.
+	if got := h.checker.Report()[2].Request.Meta().ID; got != 4 {
+		t.Fatalf("expected the oldest unanswered request first, got %d", got)
+	}
+
+	err := h.checker.Check()
+	if err == nil || !strings.Contains(err.Error(), "orphan_response") {
+		t.Fatalf("expected the error to list the issues, got %v", err)
+	}
+}
+
+func TestCheckerReportsMismatchedAndSlowResponses(t *testing.T) {
+	rule := txcheck.NewRule(txProtocol,
+		txcheck.Answers(txReq{}, txRsp{}),
+		txcheck.Answers(txNack{}, txNack{}))
+	h := newHarness(t, rule.WithLatencyBound(100))
+
+	h.send(h.req(1))
+	h.clock.now = 50
+	h.send(h.rsp(2, 1))
+
+	h.send(h.req(3))
+	h.clock.now = 500
+	h.send(txNack{messaging.MsgMeta{
+		ID: 4, Src: h.b.AsRemote(), Dst: h.a.AsRemote(), RspTo: 3}})
+
+	h.expectKinds(txcheck.Mismatched, txcheck.LatencyExceeded)
+
+	if latency := h.checker.Issues()[1].Latency; latency != 450 {
+		t.Fatalf("expected latency 450, got %d", latency)
+	}
+}
+
+func TestCheckerReportsRedeliveredResponses(t *testing.T) {
+	h := newHarness(t, txcheck.NewRule(txProtocol,
+		txcheck.Answers(txReq{}, txRsp{})))
+
+	h.send(h.req(1))
+	h.send(h.rsp(2, 1))
+	h.expectKinds()
+
+	// The same response, with the same ID, delivered again.
+	h.send(h.rsp(2, 1))
+	h.expectKinds(txcheck.Duplicate)
+
+	h.send(h.rsp(3, 99))
+	h.send(h.rsp(3, 99))
+	h.expectKinds(txcheck.Duplicate, txcheck.Orphan, txcheck.Orphan)
+}
+
+func TestCheckerForgetsOldTransactions(t *testing.T) {
+	h := newHarness(t, txcheck.NewRule(txProtocol,
+		txcheck.Answers(txReq{}, txRsp{})))
+	h.checker.WithHistory(2)
+
+	for id := uint64(1); id <= 6; id += 2 {
+		h.send(h.req(id))
+		h.send(h.rsp(id+1, id))
+	}
+
+	// Request 5 is still remembered, request 1 is not.
+	h.send(h.rsp(100, 5))
+	h.send(h.rsp(101, 1))
+	h.expectKinds(txcheck.Duplicate, txcheck.Orphan)
+}
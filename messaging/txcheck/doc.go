@@ -0,0 +1,32 @@
+// Package txcheck checks request/response pairing on live traffic.
+//
+// A Rule lists, for one protocol, which response types answer which request
+// types. Protocol packages ship their rule, e.g. memprotocol.TransactionRule,
+// vmprotocol.TransactionRule, and memcontrolprotocol.TransactionRule. A
+// Checker is a hook that tracks requests by ID and responses by RspTo at the
+// ports it is attached to and reports
+//
+//   - orphan responses, which answer no request seen,
+//   - duplicate responses to an already answered request, including the
+//     answering response delivered twice at the same port,
+//   - responses of a type the rule does not pair with the request,
+//   - requests never answered, and
+//   - requests answered later than a rule's latency bound.
+//
+// Usage:
+//
+//	checker := txcheck.NewChecker(engine,
+//		memprotocol.TransactionRule.WithLatencyBound(1_000_000), // 1 us
+//		vmprotocol.TransactionRule)
+//	port.AcceptHook(checker)
+//	...
+//	if err := checker.Check(); err != nil {
+//		log.Print(err)
+//	}
+//
+// The checker remembers the last DefaultHistory answered requests, or as many
+// as set with Checker.WithHistory, to find duplicates.
+//
+// simulation.Builder.WithTransactionChecking attaches a checker to every
+// registered port and prints its report when the simulation terminates.
+package txcheck
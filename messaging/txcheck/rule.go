@@ -0,0 +1,88 @@
+package txcheck
+
+import (
+	"reflect"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// Pair names a request message type and the response types that may answer
+// it.
+type Pair struct {
+	Req  messaging.Msg
+	Rsps []messaging.Msg
+}
+
+// Answers is a shorthand for a Pair.
+func Answers(req messaging.Msg, rsps ...messaging.Msg) Pair {
+	return Pair{Req: req, Rsps: rsps}
+}
+
+// Rule describes the request/response transactions of one protocol. Protocol
+// packages define their rule next to their protocol, e.g.
+// memprotocol.TransactionRule.
+type Rule struct {
+	protocol     string
+	rspsFor      map[reflect.Type]map[reflect.Type]bool
+	rsps         map[reflect.Type]bool
+	latencyBound timing.VTimeInPicoSec
+}
+
+// NewRule creates the rule for a protocol from its request/response pairs.
+func NewRule(protocol *messaging.Protocol, pairs ...Pair) *Rule {
+	r := &Rule{
+		protocol: protocol.Name(),
+		rspsFor:  map[reflect.Type]map[reflect.Type]bool{},
+		rsps:     map[reflect.Type]bool{},
+	}
+
+	for _, p := range pairs {
+		allowed := map[reflect.Type]bool{}
+
+		for _, rsp := range p.Rsps {
+			allowed[msgType(rsp)] = true
+			r.rsps[msgType(rsp)] = true
+		}
+
+		r.rspsFor[msgType(p.Req)] = allowed
+	}
+
+	return r
+}
+
+// Protocol returns the name of the protocol the rule checks.
+func (r *Rule) Protocol() string {
+	return r.protocol
+}
+
+// WithLatencyBound returns a copy of the rule that also reports requests
+// answered later than bound after they were sent.
+func (r *Rule) WithLatencyBound(bound timing.VTimeInPicoSec) *Rule {
+	copied := *r
+	copied.latencyBound = bound
+
+	return &copied
+}
+
+func (r *Rule) isReq(msg messaging.Msg) bool {
+	_, found := r.rspsFor[msgType(msg)]
+	return found
+}
+
+func (r *Rule) isRsp(msg messaging.Msg) bool {
+	return r.rsps[msgType(msg)]
+}
+
+func (r *Rule) answers(req, rsp messaging.Msg) bool {
+	return r.rspsFor[msgType(req)][msgType(rsp)]
+}
+
+func msgType(msg messaging.Msg) reflect.Type {
+	t := reflect.TypeOf(msg)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
@@ -1,5 +1,7 @@
 package modeling
 
+import "github.com/sarchlab/akita/v5/profiling"
+
 // None is the resource type for components that reference no shared resources.
 // It is a zero-size sentinel used as the third type argument of Component:
 // Component[Spec, State, None].
@@ -41,6 +43,17 @@ func (c *Component[S, T, R]) Resources() R {
 	return c.resources
 }
 
+// EnableProfiling times the component's ticks and its middlewares on the
+// profiler, under the given component name. The simulation calls it on
+// registered components when profiling is enabled.
+func (c *Component[S, T, R]) EnableProfiling(
+	p *profiling.Profiler,
+	component string,
+) {
+	c.TickingComponent.EnableProfiling(p, component)
+	c.MiddlewareHolder.EnableProfiling(p, component)
+}
+
 // Tick runs the middleware pipeline.
 func (c *Component[S, T, R]) Tick() bool {
 	return c.MiddlewareHolder.Tick()
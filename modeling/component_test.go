@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/profiling"
 	"github.com/sarchlab/akita/v5/timing"
 )
 
@@ -207,6 +208,47 @@ func TestComponentMiddlewareTick(t *testing.T) {
 	}
 }
 
+func TestComponentProfiling(t *testing.T) {
+	engine := timing.NewSerialEngine()
+	comp := modeling.NewBuilder[TestSpec, TestState, modeling.None]().
+		WithEngine(engine).
+		WithFreq(1 * timing.GHz).
+		Build("TestComp")
+
+	count := 0
+	comp.AddMiddleware(&countMiddleware{count: &count})
+
+	p := profiling.NewProfiler()
+	comp.EnableProfiling(p, "TestComp")
+	comp.AddMiddleware(&stateModifyMiddleware{comp: comp})
+
+	for range 5 {
+		if err := comp.Handle(modeling.MakeTickEvent("TestComp", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	calls := map[profiling.Kind]uint64{}
+	noProgress := map[string]uint64{}
+
+	for _, e := range p.Entries() {
+		if e.Component != "TestComp" {
+			t.Fatalf("unexpected component %q", e.Component)
+		}
+
+		calls[e.Kind] += e.Calls
+		noProgress[e.Type] = e.NoProgress
+	}
+
+	if calls[profiling.KindTick] != 5 || calls[profiling.KindMiddleware] != 10 {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+
+	if noProgress["*modeling_test.countMiddleware"] != 2 {
+		t.Fatalf("unexpected no-progress counts %v", noProgress)
+	}
+}
+
 // --- Builder tests ---
 
 func TestBuilderWithSpec(t *testing.T) {
@@ -15,6 +15,10 @@ type EventProcessor[S any, T any, R any] interface {
 	Process(comp *EventDrivenComponent[S, T, R], now timing.VTimeInPicoSec) bool
 }
 
+// HookPosProcess marks an event processed by an EventDrivenComponent. The hook
+// context's Detail holds whether the processor made progress.
+var HookPosProcess = &hooking.HookPos{Name: "Process"}
+
 // TimerFiredEvent is the event scheduled by EventDrivenComponent to wake
 // itself up at a future time.
 type TimerFiredEvent struct {
@@ -86,13 +90,23 @@ func (c *EventDrivenComponent[S, T, R]) ScheduleWakeNow() {
 }
 
 // Handle processes an event. For TimerFiredEvent, it resets the dedup guard
-// and calls the processor.
+// and calls the processor, then reports whether it made progress at
+// HookPosProcess.
 func (c *EventDrivenComponent[S, T, R]) Handle(e timing.Event) error {
 	c.Lock()
 	defer c.Unlock()
 
 	c.pendingWakeup = math.MaxUint64
-	c.processor.Process(c, e.Time())
+	madeProgress := c.processor.Process(c, e.Time())
+
+	if c.NumHooks() > 0 {
+		c.InvokeHook(hooking.HookCtx{
+			Domain: c,
+			Pos:    HookPosProcess,
+			Item:   e,
+			Detail: madeProgress,
+		})
+	}
 
 	return nil
 }
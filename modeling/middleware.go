@@ -1,5 +1,12 @@
 package modeling
 
+import (
+	"reflect"
+	"time"
+
+	"github.com/sarchlab/akita/v5/profiling"
+)
+
 // Middleware defines the actions of a component.
 type Middleware interface {
 	// Tick processes a tick event. It returns true if progress is made.
@@ -9,11 +16,20 @@ type Middleware interface {
 // MiddlewareHolder can maintain a list of middleware.
 type MiddlewareHolder struct {
 	middlewares []Middleware
+
+	// The profiling sites, one per middleware, set by EnableProfiling.
+	profiler  *profiling.Profiler
+	component string
+	mwSites   []*profiling.Site
 }
 
 // AddMiddleware adds a middleware to the holder.
 func (holder *MiddlewareHolder) AddMiddleware(middleware Middleware) {
 	holder.middlewares = append(holder.middlewares, middleware)
+
+	if holder.profiler != nil {
+		holder.mwSites = append(holder.mwSites, holder.middlewareSite(middleware))
+	}
 }
 
 // Middlewares returns a copy of the middleware list. The copy prevents callers
@@ -26,8 +42,32 @@ func (holder *MiddlewareHolder) Middlewares() []Middleware {
 	return middlewares
 }
 
+// EnableProfiling times every middleware's Tick on the profiler, under the
+// name of the component that holds them.
+func (holder *MiddlewareHolder) EnableProfiling(
+	p *profiling.Profiler,
+	component string,
+) {
+	holder.profiler = p
+	holder.component = component
+	holder.mwSites = holder.mwSites[:0]
+
+	for _, m := range holder.middlewares {
+		holder.mwSites = append(holder.mwSites, holder.middlewareSite(m))
+	}
+}
+
+func (holder *MiddlewareHolder) middlewareSite(m Middleware) *profiling.Site {
+	return holder.profiler.Site(holder.component, profiling.KindMiddleware,
+		reflect.TypeOf(m).String())
+}
+
 // Tick processes a tick event. It returns true if progress is made.
 func (holder *MiddlewareHolder) Tick() bool {
+	if holder.profiler != nil {
+		return holder.profiledTick()
+	}
+
 	progress := false
 
 	for _, middleware := range holder.middlewares {
@@ -38,3 +78,17 @@ func (holder *MiddlewareHolder) Tick() bool {
 
 	return progress
 }
+
+func (holder *MiddlewareHolder) profiledTick() bool {
+	progress := false
+
+	for i, middleware := range holder.middlewares {
+		start := time.Now()
+		mwProgress := middleware.Tick()
+		holder.mwSites[i].ObserveTick(time.Since(start), mwProgress)
+
+		progress = progress || mwProgress
+	}
+
+	return progress
+}
@@ -2,10 +2,12 @@ package modeling
 
 import (
 	"sync"
+	"time"
 
 	"github.com/sarchlab/akita/v5/hooking"
 	"github.com/sarchlab/akita/v5/messaging"
 	"github.com/sarchlab/akita/v5/naming"
+	"github.com/sarchlab/akita/v5/profiling"
 	"github.com/sarchlab/akita/v5/timing"
 )
 
@@ -26,6 +28,10 @@ func MakeTickEvent(handlerID string, time timing.VTimeInPicoSec) TickEvent {
 	return evt
 }
 
+// HookPosTick marks a tick of a TickingComponent. The hook context's Detail
+// holds whether the tick made progress.
+var HookPosTick = &hooking.HookPos{Name: "Tick"}
+
 // A Ticker is an object that updates states with ticks.
 type Ticker interface {
 	Tick() bool
@@ -156,6 +162,9 @@ type TickingComponent struct {
 
 	name   string
 	ticker Ticker
+
+	// tickSite times the ticks once EnableProfiling is called.
+	tickSite *profiling.Site
 }
 
 // Name returns the component name.
@@ -179,7 +188,25 @@ func (c *TickingComponent) NotifyRecv(
 
 // Handle triggers the tick function of the TickingComponent
 func (c *TickingComponent) Handle(e timing.Event) error {
-	madeProgress := c.ticker.Tick()
+	var madeProgress bool
+
+	if c.tickSite != nil {
+		start := time.Now()
+		madeProgress = c.ticker.Tick()
+		c.tickSite.ObserveTick(time.Since(start), madeProgress)
+	} else {
+		madeProgress = c.ticker.Tick()
+	}
+
+	if c.NumHooks() > 0 {
+		c.InvokeHook(hooking.HookCtx{
+			Domain: c,
+			Pos:    HookPosTick,
+			Item:   e,
+			Detail: madeProgress,
+		})
+	}
+
 	if madeProgress {
 		c.TickLater()
 	}
@@ -187,6 +214,12 @@ func (c *TickingComponent) Handle(e timing.Event) error {
 	return nil
 }
 
+// EnableProfiling times the component's ticks on the profiler, under the
+// given component name, and counts the ticks that make no progress.
+func (c *TickingComponent) EnableProfiling(p *profiling.Profiler, component string) {
+	c.tickSite = p.Site(component, profiling.KindTick, "")
+}
+
 // NewTickingComponent creates a new ticking component
 func NewTickingComponent(
 	name string,
@@ -0,0 +1,961 @@
+package monitoring2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// ---- Alerting rules and watchpoints ----
+//
+// Alert rules turn the monitor's inspection endpoints into watchpoints that
+// fire on their own. Simulation-time rules (buffer level, field change, no
+// progress) are evaluated by a hook after every engine event; the wall-clock
+// stall rule is evaluated by a background goroutine, since a stalled engine
+// runs no events to hook.
+//
+// The engine and port hooks are installed when the engine and the ports are
+// registered, before the simulation runs, because an engine only looks for
+// hooks when Run starts and hook lists are not safe to change while events
+// dispatch. Rules added or removed later, for example through POST
+// /api/alerts, only change the rule list the hooks read. A parallel engine
+// runs its hooks on concurrent workers, where reading component state would
+// race, so its simulation-time rules are evaluated by a background goroutine
+// between rounds, with the engine paused.
+
+// AlertKind identifies what an alert rule watches.
+type AlertKind string
+
+// Supported alert kinds.
+const (
+	// AlertBufferLevel fires when a buffer stays at or above Threshold (a
+	// fraction of its capacity) for at least For picoseconds of simulated time.
+	AlertBufferLevel AlertKind = "buffer_level"
+
+	// AlertFieldChange fires every time the value at Field (a dot-separated
+	// path, as in /api/field) of Component changes.
+	AlertFieldChange AlertKind = "field_change"
+
+	// AlertNoProgress fires when Component has work waiting in its port
+	// buffers but sends or retrieves no message for Cycles cycles at Freq. An
+	// idle component with empty buffers is not considered stuck.
+	AlertNoProgress AlertKind = "no_progress"
+
+	// AlertTimeStall fires when simulated time does not advance for
+	// WallSeconds of wall-clock time while the engine is not paused.
+	AlertTimeStall AlertKind = "time_stall"
+)
+
+// AlertActions says what the monitor does when a rule fires. A rule with no
+// action set only logs.
+type AlertActions struct {
+	Pause bool `json:"pause,omitempty"`
+	Log   bool `json:"log,omitempty"`
+
+	// Webhook receives the AlertEvent as a JSON POST. Only loopback URLs are
+	// accepted, so a rule cannot make the simulator call out to other hosts.
+	Webhook string `json:"webhook,omitempty"`
+}
+
+// AlertRule is one watchpoint. Which fields apply depends on Kind.
+type AlertRule struct {
+	Name string    `json:"name"`
+	Kind AlertKind `json:"kind"`
+
+	Buffer    string                `json:"buffer,omitempty"`
+	Threshold float64               `json:"threshold,omitempty"`
+	For       timing.VTimeInPicoSec `json:"for_ps,omitempty"`
+
+	Component string      `json:"component,omitempty"`
+	Field     string      `json:"field,omitempty"`
+	Cycles    uint64      `json:"cycles,omitempty"`
+	Freq      timing.Freq `json:"freq_hz,omitempty"`
+
+	WallSeconds float64 `json:"wall_seconds,omitempty"`
+
+	Actions AlertActions `json:"actions"`
+}
+
+// AlertEvent records one firing of a rule.
+type AlertEvent struct {
+	Rule     string                `json:"rule"`
+	Kind     AlertKind             `json:"kind"`
+	SimTime  timing.VTimeInPicoSec `json:"sim_time"`
+	WallTime time.Time             `json:"wall_time"`
+	Message  string                `json:"message"`
+}
+
+// AlertStatus is a rule together with its current state, as served by
+// /api/alerts.
+type AlertStatus struct {
+	AlertRule
+
+	Active    bool `json:"active"`
+	FireCount int  `json:"fire_count"`
+}
+
+// BufferAboveRule watches a buffer (by the name /api/hangdetector/buffers
+// reports) staying at or above threshold, a fraction of its capacity, for the
+// given simulated duration.
+func BufferAboveRule(
+	name, buffer string,
+	threshold float64,
+	duration timing.VTimeInPicoSec,
+) AlertRule {
+	return AlertRule{
+		Name:      name,
+		Kind:      AlertBufferLevel,
+		Buffer:    buffer,
+		Threshold: threshold,
+		For:       duration,
+	}
+}
+
+// FieldChangeRule watches a field path of a component for changes.
+func FieldChangeRule(name, component, field string) AlertRule {
+	return AlertRule{
+		Name:      name,
+		Kind:      AlertFieldChange,
+		Component: component,
+		Field:     field,
+	}
+}
+
+// NoProgressRule watches a component that has pending work but makes no
+// progress for the given number of cycles.
+func NoProgressRule(
+	name, component string,
+	cycles uint64,
+	freq timing.Freq,
+) AlertRule {
+	return AlertRule{
+		Name:      name,
+		Kind:      AlertNoProgress,
+		Component: component,
+		Cycles:    cycles,
+		Freq:      freq,
+	}
+}
+
+// TimeStallRule watches for simulated time not advancing for the given number
+// of wall-clock seconds.
+func TimeStallRule(name string, wallSeconds float64) AlertRule {
+	return AlertRule{
+		Name:        name,
+		Kind:        AlertTimeStall,
+		WallSeconds: wallSeconds,
+	}
+}
+
+const (
+	maxAlertEvents     = 200
+	stallCheckInterval = 100 * time.Millisecond
+	sampleInterval     = 100 * time.Millisecond
+	webhookTimeout     = 5 * time.Second
+)
+
+var errAlertNotFound = errors.New("alert rule not found")
+
+// alertState is a rule's evaluation state.
+type alertState struct {
+	rule      AlertRule
+	fields    []string
+	buffer    bufferState
+	component Component
+	ports     []monitorPort
+	active    bool
+	fireCount int
+
+	// since is when the buffer first reached the threshold in the current
+	// episode.
+	since    timing.VTimeInPicoSec
+	hasSince bool
+
+	lastValue    string
+	hasLastValue bool
+
+	// lastProgress is the simulated time a no-progress rule last saw its
+	// component send or retrieve a message, or be idle.
+	lastProgress    timing.VTimeInPicoSec
+	hasLastProgress bool
+}
+
+type alertManager struct {
+	mu       sync.Mutex
+	rules    []*alertState
+	events   []AlertEvent
+	hooked   bool
+	stallOn  bool
+	sampling bool
+	stop     chan struct{}
+	client   *http.Client
+
+	// simTimeRules and progressRules count the rules the engine hook and the
+	// port hooks serve, so the hooks return at once while there are none.
+	simTimeRules  atomic.Int32
+	progressRules atomic.Int32
+
+	// sampled is set for an engine whose simulation-time rules the sampler
+	// evaluates instead of the engine hook.
+	sampled atomic.Bool
+
+	// progress holds, per port, the simulated time of its last message send
+	// or retrieve, recorded while a no-progress rule exists. It has its own
+	// lock because ports invoke the progress hook while holding their own
+	// lock, and evaluation holds mu while reading port buffer levels.
+	progressMu    sync.Mutex
+	progress      map[string]timing.VTimeInPicoSec
+	progressPorts map[string]bool
+}
+
+func (m *Monitor) alertManager() *alertManager {
+	m.alertsOnce.Do(func() {
+		m.alerts = &alertManager{
+			stop:          make(chan struct{}),
+			client:        &http.Client{Timeout: webhookTimeout},
+			progress:      map[string]timing.VTimeInPicoSec{},
+			progressPorts: map[string]bool{},
+		}
+	})
+
+	return m.alerts
+}
+
+// AddAlert registers an alert rule. Rules referring to buffers or components
+// must be added after those are registered with the monitor, and rules only
+// start evaluating once an engine is registered. Rules can be added while the
+// simulation runs.
+func (m *Monitor) AddAlert(rule AlertRule) error {
+	st, err := m.newAlertState(rule)
+	if err != nil {
+		return err
+	}
+
+	a := m.alertManager()
+
+	a.mu.Lock()
+	for _, existing := range a.rules {
+		if existing.rule.Name == rule.Name {
+			a.mu.Unlock()
+			return fmt.Errorf("alert rule %q already exists", rule.Name)
+		}
+	}
+
+	a.rules = append(a.rules, st)
+	a.countRuleLocked(rule.Kind, 1)
+	a.mu.Unlock()
+
+	if rule.Kind == AlertTimeStall {
+		m.startStallWatcher()
+	}
+
+	return nil
+}
+
+// RemoveAlert unregisters the named rule. Removing the last no-progress rule
+// turns the port progress hooks off and forgets the progress they recorded.
+func (m *Monitor) RemoveAlert(name string) error {
+	a := m.alertManager()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, st := range a.rules {
+		if st.rule.Name == name {
+			a.rules = append(a.rules[:i], a.rules[i+1:]...)
+			a.countRuleLocked(st.rule.Kind, -1)
+
+			return nil
+		}
+	}
+
+	return errAlertNotFound
+}
+
+// countRuleLocked updates the rule counts the hooks check when a rule of the
+// given kind is added (delta 1) or removed (delta -1).
+func (a *alertManager) countRuleLocked(kind AlertKind, delta int32) {
+	if kind == AlertTimeStall {
+		return
+	}
+
+	a.simTimeRules.Add(delta)
+
+	if kind != AlertNoProgress {
+		return
+	}
+
+	if a.progressRules.Add(delta) == 0 {
+		a.progressMu.Lock()
+		clear(a.progress)
+		a.progressMu.Unlock()
+	}
+}
+
+// Alerts returns the registered rules with their state.
+func (m *Monitor) Alerts() []AlertStatus {
+	a := m.alertManager()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	statuses := make([]AlertStatus, 0, len(a.rules))
+	for _, st := range a.rules {
+		statuses = append(statuses, AlertStatus{
+			AlertRule: st.rule,
+			Active:    st.active,
+			FireCount: st.fireCount,
+		})
+	}
+
+	return statuses
+}
+
+// AlertEvents returns the most recent alert firings, oldest first.
+func (m *Monitor) AlertEvents() []AlertEvent {
+	a := m.alertManager()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	events := make([]AlertEvent, len(a.events))
+	copy(events, a.events)
+
+	return events
+}
+
+func (m *Monitor) newAlertState(rule AlertRule) (*alertState, error) {
+	if rule.Name == "" {
+		return nil, errors.New("alert rule needs a name")
+	}
+
+	if err := validateWebhook(rule.Actions.Webhook); err != nil {
+		return nil, err
+	}
+
+	st := &alertState{rule: rule}
+
+	switch rule.Kind {
+	case AlertBufferLevel:
+		if rule.Threshold <= 0 || rule.Threshold > 1 {
+			return nil, errors.New("threshold must be in (0, 1]")
+		}
+
+		st.buffer = m.findBuffer(rule.Buffer)
+		if st.buffer == nil {
+			return nil, fmt.Errorf("buffer %q not found", rule.Buffer)
+		}
+	case AlertFieldChange:
+		if rule.Field == "" {
+			return nil, errors.New("field_change needs a field path")
+		}
+
+		st.component = m.findComponent(rule.Component)
+		if st.component == nil {
+			return nil, fmt.Errorf("component %q not found", rule.Component)
+		}
+
+		st.fields = strings.Split(rule.Field, ".")
+	case AlertNoProgress:
+		if rule.Cycles == 0 || rule.Freq == 0 || rule.Freq > 1e12 {
+			return nil, errors.New(
+				"no_progress needs cycles and a freq_hz of at most 1 THz")
+		}
+
+		st.component = m.findComponent(rule.Component)
+		if st.component == nil {
+			return nil, fmt.Errorf("component %q not found", rule.Component)
+		}
+
+		st.ports = componentPorts(st.component)
+		if len(st.ports) == 0 {
+			return nil, fmt.Errorf(
+				"component %q has no ports to observe progress on",
+				rule.Component)
+		}
+	case AlertTimeStall:
+		if rule.WallSeconds <= 0 {
+			return nil, errors.New("time_stall needs positive wall_seconds")
+		}
+	default:
+		return nil, fmt.Errorf("unknown alert kind %q", rule.Kind)
+	}
+
+	return st, nil
+}
+
+func validateWebhook(raw string) error {
+	if raw == "" {
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook must be an http(s) URL, got %q", raw)
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+
+	return fmt.Errorf("webhook %q must point to a local address", raw)
+}
+
+func (m *Monitor) findComponent(name string) Component {
+	for _, c := range m.components {
+		if c.Name() == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (m *Monitor) findBuffer(name string) bufferState {
+	for _, b := range m.buffers {
+		if b.Name() == name {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// ---- Simulation-time evaluation ----
+
+// alertEngineHook evaluates the simulation-time rules after each event.
+type alertEngineHook struct {
+	monitor *Monitor
+}
+
+func (h alertEngineHook) Func(ctx hooking.HookCtx) {
+	if ctx.Pos != timing.HookPosAfterEvent {
+		return
+	}
+
+	a := h.monitor.alertManager()
+	if a.sampled.Load() || a.simTimeRules.Load() == 0 {
+		return
+	}
+
+	h.monitor.evaluateAlerts(h.monitor.engine.CurrentTime())
+}
+
+// alertProgressHook records when a port sends or retrieves a message.
+type alertProgressHook struct {
+	monitor *Monitor
+	port    string
+}
+
+func (h alertProgressHook) Func(ctx hooking.HookCtx) {
+	if ctx.Pos != messaging.HookPosPortMsgSend &&
+		ctx.Pos != messaging.HookPosPortMsgRetrieveIncoming {
+		return
+	}
+
+	a := h.monitor.alertManager()
+	if a.progressRules.Load() == 0 || h.monitor.engine == nil {
+		return
+	}
+
+	now := h.monitor.engine.CurrentTime()
+
+	a.progressMu.Lock()
+	a.progress[h.port] = now
+	a.progressMu.Unlock()
+}
+
+// startAlerts installs the engine hook when the engine is registered, or, for
+// a parallel engine, starts the sampler, and starts watching for stalls if a
+// stall rule was added before.
+func (m *Monitor) startAlerts() {
+	a := m.alertManager()
+
+	a.mu.Lock()
+
+	hasStall := false
+	for _, st := range a.rules {
+		hasStall = hasStall || st.rule.Kind == AlertTimeStall
+	}
+
+	if !a.hooked {
+		m.engine.AcceptHook(alertEngineHook{monitor: m})
+		a.hooked = true
+	}
+
+	if _, parallel := m.engine.(*timing.ParallelEngine); parallel && !a.sampling {
+		a.sampled.Store(true)
+		a.sampling = true
+
+		go m.sampleAlerts(a.stop)
+	}
+
+	a.mu.Unlock()
+
+	if hasStall {
+		m.startStallWatcher()
+	}
+}
+
+// hookPortForAlerts installs the progress hook on a port as it is registered.
+// The hook records nothing while there is no no-progress rule.
+func (m *Monitor) hookPortForAlerts(p monitorPort) {
+	hookable, ok := p.(hooking.Hookable)
+	if !ok {
+		return
+	}
+
+	a := m.alertManager()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.progressPorts[p.Name()] {
+		return
+	}
+
+	a.progressPorts[p.Name()] = true
+	hookable.AcceptHook(alertProgressHook{monitor: m, port: p.Name()})
+}
+
+// sampleAlerts evaluates the simulation-time rules of a parallel engine
+// periodically. Pausing the engine waits for the running round to finish, so
+// no event runs while the rules read component state.
+func (m *Monitor) sampleAlerts(stop <-chan struct{}) {
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+
+	a := m.alertManager()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if a.simTimeRules.Load() == 0 {
+				continue
+			}
+
+			m.engineControlMu.Lock()
+			if !m.enginePaused {
+				m.engine.Pause()
+			}
+
+			fired := m.collectAlerts(m.engine.CurrentTime())
+
+			if !m.enginePaused {
+				m.engine.Continue()
+			}
+			m.engineControlMu.Unlock()
+
+			for _, e := range fired {
+				m.runAlertActions(e)
+			}
+		}
+	}
+}
+
+func (m *Monitor) evaluateAlerts(now timing.VTimeInPicoSec) {
+	for _, e := range m.collectAlerts(now) {
+		m.runAlertActions(e)
+	}
+}
+
+// collectAlerts evaluates the simulation-time rules and returns the events of
+// the rules that fire, whose actions the caller runs.
+func (m *Monitor) collectAlerts(now timing.VTimeInPicoSec) []AlertEvent {
+	a := m.alertManager()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var fired []AlertEvent
+	for _, st := range a.rules {
+		var msg string
+
+		switch st.rule.Kind {
+		case AlertBufferLevel:
+			msg = st.evalBufferLevel(now)
+		case AlertFieldChange:
+			msg = st.evalFieldChange()
+		case AlertNoProgress:
+			msg = a.evalNoProgress(st, now)
+		default:
+			continue
+		}
+
+		if msg != "" {
+			fired = append(fired, a.recordLocked(st, now, msg))
+		}
+	}
+
+	return fired
+}
+
+func (st *alertState) evalBufferLevel(now timing.VTimeInPicoSec) string {
+	capacity := st.buffer.Capacity()
+	size := st.buffer.Size()
+
+	if capacity <= 0 || float64(size)/float64(capacity) < st.rule.Threshold {
+		st.hasSince = false
+		st.active = false
+
+		return ""
+	}
+
+	if !st.hasSince {
+		st.since = now
+		st.hasSince = true
+	}
+
+	if st.active || now-st.since < st.rule.For {
+		return ""
+	}
+
+	st.active = true
+
+	return fmt.Sprintf("buffer %s at %d/%d since %d ps",
+		st.rule.Buffer, size, capacity, st.since)
+}
+
+func (st *alertState) evalFieldChange() string {
+	value, err := monitorEntryPointValue(st.component, st.fields)
+
+	current := "<missing>"
+	if err == nil {
+		// fmt formats a reflect.Value by its underlying value, including
+		// unexported fields that Interface() would refuse.
+		current = fmt.Sprint(monitorStrip(value))
+		if !monitorStrip(value).IsValid() {
+			current = "<nil>"
+		}
+	}
+
+	if !st.hasLastValue {
+		st.lastValue = current
+		st.hasLastValue = true
+
+		return ""
+	}
+
+	if current == st.lastValue {
+		return ""
+	}
+
+	previous := st.lastValue
+	st.lastValue = current
+	st.active = true
+
+	return fmt.Sprintf("%s.%s changed from %s to %s",
+		st.rule.Component, st.rule.Field,
+		truncateAlertValue(previous), truncateAlertValue(current))
+}
+
+func truncateAlertValue(s string) string {
+	const maxLen = 200
+	if len(s) <= maxLen {
+		return s
+	}
+
+	return s[:maxLen] + "…"
+}
+
+func (a *alertManager) evalNoProgress(
+	st *alertState,
+	now timing.VTimeInPicoSec,
+) string {
+	name := st.component.Name()
+	last := st.lastProgress
+
+	a.progressMu.Lock()
+	for _, p := range st.ports {
+		if t, ok := a.progress[p.Name()]; ok && t > last {
+			last = t
+		}
+	}
+	a.progressMu.Unlock()
+
+	if !st.hasPendingWork() || !st.hasLastProgress {
+		// Idle time does not count toward a stall.
+		last = now
+	}
+
+	st.lastProgress = last
+	st.hasLastProgress = true
+
+	if now-last < st.rule.Freq.Period()*timing.VTimeInPicoSec(st.rule.Cycles) {
+		st.active = false
+		return ""
+	}
+
+	if st.active {
+		return ""
+	}
+
+	st.active = true
+
+	return fmt.Sprintf("%s made no progress for %d cycles (since %d ps)",
+		name, st.rule.Freq.Cycle(now-last), last)
+}
+
+func (st *alertState) hasPendingWork() bool {
+	for _, p := range st.ports {
+		if p.NumIncoming() > 0 || p.NumOutgoing() > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ---- Wall-clock evaluation ----
+
+func (m *Monitor) startStallWatcher() {
+	a := m.alertManager()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stallOn || m.engine == nil {
+		return
+	}
+
+	a.stallOn = true
+
+	go m.watchTimeStall(a.stop)
+}
+
+func (m *Monitor) watchTimeStall(stop <-chan struct{}) {
+	ticker := time.NewTicker(stallCheckInterval)
+	defer ticker.Stop()
+
+	lastSimTime := m.engine.CurrentTime()
+	lastChange := time.Now()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case wall := <-ticker.C:
+			now := m.engine.CurrentTime()
+
+			m.engineControlMu.Lock()
+			paused := m.enginePaused
+			m.engineControlMu.Unlock()
+
+			if now != lastSimTime || paused {
+				lastSimTime = now
+				lastChange = wall
+			}
+
+			m.evaluateTimeStall(now, wall.Sub(lastChange))
+		}
+	}
+}
+
+func (m *Monitor) evaluateTimeStall(
+	now timing.VTimeInPicoSec,
+	stalled time.Duration,
+) {
+	a := m.alertManager()
+
+	a.mu.Lock()
+
+	var fired []AlertEvent
+	for _, st := range a.rules {
+		if st.rule.Kind != AlertTimeStall {
+			continue
+		}
+
+		if stalled.Seconds() < st.rule.WallSeconds {
+			st.active = false
+			continue
+		}
+
+		if st.active {
+			continue
+		}
+
+		st.active = true
+		fired = append(fired, a.recordLocked(st, now, fmt.Sprintf(
+			"simulated time stuck at %d ps for %.1f s", now, stalled.Seconds())))
+	}
+
+	a.mu.Unlock()
+
+	for _, e := range fired {
+		m.runAlertActions(e)
+	}
+}
+
+// ---- Actions ----
+
+func (a *alertManager) recordLocked(
+	st *alertState,
+	now timing.VTimeInPicoSec,
+	msg string,
+) AlertEvent {
+	st.fireCount++
+
+	e := AlertEvent{
+		Rule:     st.rule.Name,
+		Kind:     st.rule.Kind,
+		SimTime:  now,
+		WallTime: time.Now(),
+		Message:  msg,
+	}
+
+	a.events = append(a.events, e)
+	if len(a.events) > maxAlertEvents {
+		a.events = a.events[len(a.events)-maxAlertEvents:]
+	}
+
+	return e
+}
+
+func (m *Monitor) runAlertActions(e AlertEvent) {
+	actions := m.alertActions(e.Rule)
+
+	if actions.Log || (!actions.Pause && actions.Webhook == "") {
+		log.Printf("alert %s @ %d ps: %s", e.Rule, e.SimTime, e.Message)
+	}
+
+	if actions.Pause {
+		m.PauseEngine()
+	}
+
+	if actions.Webhook != "" {
+		go m.callWebhook(actions.Webhook, e)
+	}
+}
+
+func (m *Monitor) alertActions(rule string) AlertActions {
+	a := m.alertManager()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, st := range a.rules {
+		if st.rule.Name == rule {
+			return st.rule.Actions
+		}
+	}
+
+	return AlertActions{}
+}
+
+func (m *Monitor) callWebhook(target string, e AlertEvent) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("alert %s: encoding webhook body: %v", e.Rule, err)
+		return
+	}
+
+	rsp, err := m.alertManager().client.Post(
+		target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("alert %s: webhook %s failed: %v", e.Rule, target, err)
+		return
+	}
+
+	rsp.Body.Close()
+
+	if rsp.StatusCode >= 300 {
+		log.Printf("alert %s: webhook %s returned %s",
+			e.Rule, target, rsp.Status)
+	}
+}
+
+func (m *Monitor) stopAlerts() {
+	a := m.alertManager()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stallOn || a.sampling {
+		close(a.stop)
+		a.stallOn = false
+		a.sampling = false
+		a.stop = make(chan struct{})
+	}
+}
+
+// ---- HTTP ----
+
+type alertsRsp struct {
+	Rules  []AlertStatus `json:"rules"`
+	Events []AlertEvent  `json:"events"`
+}
+
+// apiAlerts lists rules and recent events (GET), adds a rule from a JSON
+// AlertRule body (POST), or removes the rule named by ?name= (DELETE).
+func (m *Monitor) apiAlerts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		m.writeAlertsJSON(w, http.StatusOK, alertsRsp{
+			Rules:  m.Alerts(),
+			Events: m.AlertEvents(),
+		})
+	case http.MethodPost:
+		var rule AlertRule
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(&rule); err != nil {
+			http.Error(w, "invalid alert rule: "+err.Error(),
+				http.StatusBadRequest)
+
+			return
+		}
+
+		if err := m.AddAlert(rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		m.writeAlertsJSON(w, http.StatusCreated, rule)
+	case http.MethodDelete:
+		err := m.RemoveAlert(r.URL.Query().Get("name"))
+		if errors.Is(err, errAlertNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *Monitor) writeAlertsJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
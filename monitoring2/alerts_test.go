@@ -0,0 +1,340 @@
+package monitoring2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+type alertTestMsg struct {
+	messaging.MsgMeta
+}
+
+func (m *alertTestMsg) Meta() messaging.MsgMeta { return m.MsgMeta }
+
+func TestBufferAlertFiresOnceAfterDurationAndPauses(t *testing.T) {
+	engine := &fakeEngine{}
+	comp := newBufferOnlyComponent("comp", 4, 4)
+	monitor := NewMonitor()
+	monitor.RegisterEngine(engine)
+	monitor.RegisterComponent(comp)
+
+	rule := BufferAboveRule("full", "comp.buf", 0.9, 100)
+	rule.Actions.Pause = true
+	if err := monitor.AddAlert(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	if engine.NumHooks() != 1 {
+		t.Fatalf("expected the engine hook to be installed")
+	}
+
+	monitor.evaluateAlerts(10)
+	monitor.evaluateAlerts(100)
+	if len(monitor.AlertEvents()) != 0 {
+		t.Fatalf("fired before the buffer was full for 100 ps")
+	}
+
+	monitor.evaluateAlerts(110)
+	monitor.evaluateAlerts(500)
+	events := monitor.AlertEvents()
+	if len(events) != 1 || events[0].Rule != "full" || events[0].SimTime != 110 {
+		t.Fatalf("expected one firing at 110 ps, got %#v", events)
+	}
+
+	if engine.pauseCalls != 1 || !monitor.enginePaused {
+		t.Fatalf("expected the alert to pause the engine")
+	}
+
+	// Draining the buffer rearms the rule.
+	comp.Buf.Pop()
+	monitor.evaluateAlerts(600)
+	comp.Buf.PushTyped(9)
+	monitor.evaluateAlerts(700)
+	monitor.evaluateAlerts(800)
+
+	if got := monitor.Alerts()[0].FireCount; got != 2 {
+		t.Fatalf("expected the rule to fire again after rearming, got %d", got)
+	}
+}
+
+func TestFieldChangeAlertReportsOldAndNewValue(t *testing.T) {
+	comp := newSliceFieldComponent("comp", []int{1, 2})
+	monitor := NewMonitor()
+	monitor.RegisterEngine(&fakeEngine{})
+	monitor.RegisterComponent(comp)
+
+	if err := monitor.AddAlert(
+		FieldChangeRule("watch", "comp", "State.Values.1")); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor.evaluateAlerts(1)
+	monitor.evaluateAlerts(2)
+	comp.State.Values[1] = 7
+	monitor.evaluateAlerts(3)
+	monitor.evaluateAlerts(4)
+
+	events := monitor.AlertEvents()
+	if len(events) != 1 ||
+		!strings.Contains(events[0].Message, "from 2 to 7") {
+		t.Fatalf("expected one change event, got %#v", events)
+	}
+}
+
+func TestNoProgressAlertIgnoresIdleAndFiresOnStuckWork(t *testing.T) {
+	engine := &fakeEngine{}
+	comp := newPortedComponent("comp")
+	monitor := NewMonitor()
+	monitor.RegisterEngine(engine)
+	monitor.RegisterComponent(comp)
+
+	if err := monitor.AddAlert(
+		NoProgressRule("stuck", "comp", 10, 1*timing.GHz)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Idle with empty buffers: never stuck.
+	monitor.evaluateAlerts(0)
+	monitor.evaluateAlerts(50_000)
+	if len(monitor.AlertEvents()) != 0 {
+		t.Fatalf("an idle component must not be reported")
+	}
+
+	port := comp.GetPortByName("p")
+	port.Deliver(&alertTestMsg{MsgMeta: messaging.MsgMeta{ID: 1}})
+	monitor.evaluateAlerts(55_000)
+	monitor.evaluateAlerts(59_000)
+	if len(monitor.AlertEvents()) != 0 {
+		t.Fatalf("fired before 10 cycles passed")
+	}
+
+	monitor.evaluateAlerts(60_000)
+	events := monitor.AlertEvents()
+	if len(events) != 1 || !strings.Contains(events[0].Message, "10 cycles") {
+		t.Fatalf("expected one no-progress event, got %#v", events)
+	}
+
+	// Retrieving the message is progress and clears the pending work.
+	engine.now = 80_000
+	port.RetrieveIncoming()
+	monitor.evaluateAlerts(80_000)
+	if monitor.Alerts()[0].Active {
+		t.Fatalf("expected the rule to clear after progress")
+	}
+}
+
+func TestTimeStallAlertFiresPerEpisode(t *testing.T) {
+	monitor := NewMonitor()
+	monitor.engine = &fakeEngine{}
+
+	if err := monitor.AddAlert(TimeStallRule("stall", 2)); err != nil {
+		t.Fatal(err)
+	}
+	defer monitor.stopAlerts()
+
+	monitor.evaluateTimeStall(5, time.Second)
+	monitor.evaluateTimeStall(5, 3*time.Second)
+	monitor.evaluateTimeStall(5, 4*time.Second)
+	monitor.evaluateTimeStall(9, 0)
+	monitor.evaluateTimeStall(9, 2*time.Second)
+
+	if got := monitor.Alerts()[0].FireCount; got != 2 {
+		t.Fatalf("expected one firing per stall episode, got %d", got)
+	}
+}
+
+func TestAlertWebhookReceivesEvent(t *testing.T) {
+	received := make(chan AlertEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var e AlertEvent
+			_ = json.NewDecoder(r.Body).Decode(&e)
+			received <- e
+		}))
+	defer server.Close()
+
+	monitor := NewMonitor()
+	monitor.RegisterEngine(&fakeEngine{})
+
+	rule := TimeStallRule("stall", 1)
+	rule.Actions.Webhook = server.URL
+	if err := monitor.AddAlert(rule); err != nil {
+		t.Fatal(err)
+	}
+	defer monitor.stopAlerts()
+
+	monitor.evaluateTimeStall(42, 2*time.Second)
+
+	select {
+	case e := <-received:
+		if e.Rule != "stall" || e.SimTime != 42 {
+			t.Fatalf("unexpected webhook payload %#v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+}
+
+func TestAddAlertValidatesRules(t *testing.T) {
+	monitor := NewMonitor()
+	monitor.RegisterComponent(newBufferOnlyComponent("comp", 4, 0))
+
+	bad := []AlertRule{
+		{Kind: AlertTimeStall, WallSeconds: 1},
+		{Name: "x", Kind: "bogus"},
+		BufferAboveRule("x", "missing.buf", 0.5, 0),
+		BufferAboveRule("x", "comp.buf", 1.5, 0),
+		FieldChangeRule("x", "missing", "State"),
+		NoProgressRule("x", "comp", 10, 1*timing.GHz),
+		TimeStallRule("x", 0),
+		{Name: "x", Kind: AlertTimeStall, WallSeconds: 1,
+			Actions: AlertActions{Webhook: "http://example.com/hook"}},
+	}
+
+	for _, rule := range bad {
+		if err := monitor.AddAlert(rule); err == nil {
+			t.Errorf("expected rule %#v to be rejected", rule)
+		}
+	}
+}
+
+func TestAlertsHandlerAddsListsAndRemovesRules(t *testing.T) {
+	monitor := NewMonitor()
+	monitor.RegisterComponent(newBufferOnlyComponent("comp", 4, 0))
+
+	body := `{"name":"full","kind":"buffer_level","buffer":"comp.buf",` +
+		`"threshold":0.75,"for_ps":1000,"actions":{"log":true}}`
+	recorder := httptest.NewRecorder()
+	monitor.apiAlerts(recorder, httptest.NewRequest(
+		http.MethodPost, "/api/alerts", strings.NewReader(body)))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", recorder.Code, recorder.Body)
+	}
+
+	recorder = httptest.NewRecorder()
+	monitor.apiAlerts(recorder, httptest.NewRequest(
+		http.MethodPost, "/api/alerts", strings.NewReader(body)))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a duplicate name, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	monitor.apiAlerts(recorder,
+		httptest.NewRequest(http.MethodGet, "/api/alerts", nil))
+
+	var rsp alertsRsp
+	if err := json.NewDecoder(recorder.Body).Decode(&rsp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rsp.Rules) != 1 || rsp.Rules[0].Threshold != 0.75 ||
+		rsp.Rules[0].For != 1000 || !rsp.Rules[0].Actions.Log {
+		t.Fatalf("unexpected rule list %#v", rsp.Rules)
+	}
+
+	recorder = httptest.NewRecorder()
+	monitor.apiAlerts(recorder, httptest.NewRequest(
+		http.MethodDelete, "/api/alerts?name=full", nil))
+	if recorder.Code != http.StatusNoContent || len(monitor.Alerts()) != 0 {
+		t.Fatalf("expected the rule to be removed, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	monitor.apiAlerts(recorder, httptest.NewRequest(
+		http.MethodDelete, "/api/alerts?name=full", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing rule, got %d", recorder.Code)
+	}
+}
+
+// alertTestHandler runs a callback per event, standing in for components and
+// for HTTP requests that arrive while the engine runs.
+type alertTestHandler struct {
+	handle func(now timing.VTimeInPicoSec)
+}
+
+func (h *alertTestHandler) Handle(e timing.Event) error {
+	h.handle(e.Time())
+	return nil
+}
+
+func TestAlertAddedWhileRunningFires(t *testing.T) {
+	engine := timing.NewSerialEngine()
+	comp := newBufferOnlyComponent("comp", 4, 0)
+	monitor := NewMonitor()
+	monitor.RegisterEngine(engine)
+	monitor.RegisterComponent(comp)
+
+	handler := &alertTestHandler{handle: func(now timing.VTimeInPicoSec) {
+		switch now {
+		case 10:
+			if err := monitor.AddAlert(
+				BufferAboveRule("full", "comp.buf", 1, 0)); err != nil {
+				t.Error(err)
+			}
+		case 20:
+			for i := 0; i < 4; i++ {
+				comp.Buf.PushTyped(i)
+			}
+		}
+	}}
+	engine.RegisterHandler("h", handler)
+
+	for _, at := range []timing.VTimeInPicoSec{10, 20, 30} {
+		engine.Schedule(timing.MakeEventBase(at, "h"))
+	}
+
+	if err := engine.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	events := monitor.AlertEvents()
+	if len(events) != 1 || events[0].SimTime != 20 {
+		t.Fatalf("expected the rule added during the run to fire, got %#v",
+			events)
+	}
+}
+
+func TestRemovingNoProgressRuleStopsRecordingProgress(t *testing.T) {
+	engine := &fakeEngine{}
+	comp := newPortedComponent("comp")
+	monitor := NewMonitor()
+	monitor.RegisterEngine(engine)
+	monitor.RegisterComponent(comp)
+
+	port := comp.GetPortByName("p")
+	if port.NumHooks() != 1 {
+		t.Fatalf("expected the progress hook to be installed at registration")
+	}
+
+	if err := monitor.AddAlert(
+		NoProgressRule("stuck", "comp", 10, 1*timing.GHz)); err != nil {
+		t.Fatal(err)
+	}
+
+	port.Deliver(&alertTestMsg{MsgMeta: messaging.MsgMeta{ID: 1}})
+	port.RetrieveIncoming()
+
+	a := monitor.alertManager()
+	if len(a.progress) != 1 {
+		t.Fatalf("expected the retrieve to be recorded")
+	}
+
+	if err := monitor.RemoveAlert("stuck"); err != nil {
+		t.Fatal(err)
+	}
+
+	port.Deliver(&alertTestMsg{MsgMeta: messaging.MsgMeta{ID: 2}})
+	port.RetrieveIncoming()
+
+	if len(a.progress) != 0 || port.NumHooks() != 1 {
+		t.Fatalf("expected no progress recorded without a rule")
+	}
+}
@@ -11,4 +11,20 @@
 //	monitor.RegisterComponent(component)
 //	monitor.RegisterVisTracer(tracer)
 //	monitor.StartServer()
+//
+// Alert rules act as watchpoints on a running simulation. They are added with
+// AddAlert or by POSTing a JSON AlertRule to /api/alerts, and can pause the
+// engine, log, or call a local webhook when they fire:
+//
+//	rule := monitoring2.BufferAboveRule(
+//		"l2-full", "GPU[0].L2[0].TopPort.in", 0.9, 10_000_000) // 10 us
+//	rule.Actions.Pause = true
+//	err := monitor.AddAlert(rule)
+//
+// The simulation's statistics (see package stats) are browsed at /stats and
+// served as JSON by /api/stats, optionally narrowed with ?prefix=GPU[0].
+//
+// A simulation built with profiling shows the wall-clock cost of each
+// component and middleware type at /wallclock, served as JSON by
+// /api/wallprofile.
 package monitoring2
@@ -0,0 +1,58 @@
+package monitoring2
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// ---- Wait-for graph analysis ----
+//
+// The monitor does not know the simulation's port and connection topology; the
+// simulation registers an analyzer that builds the wait-for report, and reports
+// the hang it detects at the end of a run. The monitor only serves both.
+
+// RegisterHangAnalyzer registers a function that analyzes the simulation's
+// current state for blocked ports and wait-for cycles. Its result must be
+// JSON-serializable. It is called with the engine paused.
+func (m *Monitor) RegisterHangAnalyzer(analyze func() any) {
+	m.hangMu.Lock()
+	defer m.hangMu.Unlock()
+
+	m.hangAnalyzer = analyze
+}
+
+// ReportHang records a deadlock or livelock detected by the simulation, so the
+// monitor can show it.
+func (m *Monitor) ReportHang(report any) {
+	m.hangMu.Lock()
+	defer m.hangMu.Unlock()
+
+	m.hangReport = report
+}
+
+type hangRsp struct {
+	Detected any `json:"detected"`
+	Current  any `json:"current"`
+}
+
+// hangDetectorWaitFor serves the hang detected at the end of the run, if any,
+// and a fresh analysis of the current state.
+func (m *Monitor) hangDetectorWaitFor(w http.ResponseWriter, _ *http.Request) {
+	m.hangMu.Lock()
+	analyze := m.hangAnalyzer
+	rsp := hangRsp{Detected: m.hangReport}
+	m.hangMu.Unlock()
+
+	if analyze != nil {
+		resume := m.pauseForInspection()
+		rsp.Current = analyze()
+		resume()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(rsp); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
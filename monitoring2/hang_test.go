@@ -0,0 +1,47 @@
+package monitoring2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHangDetectorWaitForServesReportAndAnalysis(t *testing.T) {
+	engine := &fakeEngine{}
+	monitor := NewMonitor()
+	monitor.RegisterEngine(engine)
+
+	recorder := httptest.NewRecorder()
+	monitor.hangDetectorWaitFor(recorder,
+		httptest.NewRequest(http.MethodGet, "/api/hangdetector/waitfor", nil))
+
+	if recorder.Body.String() != "{\"detected\":null,\"current\":null}\n" {
+		t.Fatalf("expected an empty report, got %s", recorder.Body)
+	}
+
+	monitor.RegisterHangAnalyzer(func() any {
+		return map[string]int{"blocked": 2}
+	})
+	monitor.ReportHang(map[string]string{"kind": "deadlock"})
+
+	recorder = httptest.NewRecorder()
+	monitor.hangDetectorWaitFor(recorder,
+		httptest.NewRequest(http.MethodGet, "/api/hangdetector/waitfor", nil))
+
+	var rsp struct {
+		Detected map[string]string `json:"detected"`
+		Current  map[string]int    `json:"current"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&rsp); err != nil {
+		t.Fatal(err)
+	}
+
+	if rsp.Detected["kind"] != "deadlock" || rsp.Current["blocked"] != 2 {
+		t.Fatalf("unexpected response %#v", rsp)
+	}
+
+	if engine.pauseCalls != 1 || engine.continueCalls != 1 {
+		t.Fatalf("expected the analysis to run with the engine paused")
+	}
+}
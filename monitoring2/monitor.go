@@ -61,6 +61,14 @@ type Monitor struct {
 	enginePaused     bool
 	progressBarsLock sync.Mutex
 	progressBars     []*daisen2.ProgressBar
+	alertsOnce       sync.Once
+	alerts           *alertManager
+	hangMu           sync.Mutex
+	hangAnalyzer     func() any
+	hangReport       any
+	statsMu          sync.Mutex
+	statsSource      func(prefix string) any
+	profileSource    func() any
 	httpServer       *http.Server
 	fs               http.FileSystem
 }
@@ -94,6 +102,7 @@ func (m *Monitor) WithPortNumber(port int) *Monitor {
 // RegisterEngine registers the simulation engine with the monitor.
 func (m *Monitor) RegisterEngine(e timing.Engine) {
 	m.engine = e
+	m.startAlerts()
 }
 
 // RegisterComponent registers a component with the monitor so its internal
@@ -169,6 +178,7 @@ func (m *Monitor) StartServer() {
 	mux.HandleFunc("/api/component/", m.listComponentDetails)
 	mux.HandleFunc("/api/field/", m.listFieldValue)
 	mux.HandleFunc("/api/hangdetector/buffers", m.hangDetectorBuffers)
+	mux.HandleFunc("/api/hangdetector/waitfor", m.hangDetectorWaitFor)
 	mux.HandleFunc("/api/progress", m.listProgressBars)
 	mux.HandleFunc("/api/execution/info", m.apiExecutionInfo)
 	mux.HandleFunc("/api/resource", m.listResources)
@@ -178,6 +188,9 @@ func (m *Monitor) StartServer() {
 	mux.HandleFunc("/api/trace/end", m.apiTraceEnd)
 	mux.HandleFunc("/api/trace/is_tracing", m.apiTraceIsTracing)
 	mux.HandleFunc("/api/trace/storage", m.apiTraceStorage)
+	mux.HandleFunc("/api/alerts", m.apiAlerts)
+	mux.HandleFunc("/api/stats", m.apiStats)
+	mux.HandleFunc("/api/wallprofile", m.apiWallProfile)
 
 	m.setupStaticRoutes(mux)
 
@@ -209,6 +222,9 @@ func (m *Monitor) setupStaticRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/analysis", m.serveIndex)
 	mux.HandleFunc("/debug", m.serveIndex)
 	mux.HandleFunc("/profiling", m.serveIndex)
+	mux.HandleFunc("/alerts", m.serveIndex)
+	mux.HandleFunc("/stats", m.serveIndex)
+	mux.HandleFunc("/wallclock", m.serveIndex)
 	mux.HandleFunc("/live", m.serveIndex)
 	mux.HandleFunc("/live/", m.serveIndex)
 	mux.Handle("/", fServer)
@@ -242,6 +258,8 @@ func (m *Monitor) findPort() net.Listener {
 
 // StopServer gracefully shuts down the monitoring server.
 func (m *Monitor) StopServer() {
+	m.stopAlerts()
+
 	if m.httpServer != nil {
 		m.httpServer.Close()
 	}
@@ -295,6 +313,8 @@ func (m *Monitor) registerPortBuffers(p monitorPort) {
 		&portBufferAdapter{port: p, direction: "in"},
 		&portBufferAdapter{port: p, direction: "out"},
 	)
+
+	m.hookPortForAlerts(p)
 }
 
 func componentPorts(c Component) []monitorPort {
@@ -375,12 +395,25 @@ func (m *Monitor) serveIndex(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
-func (m *Monitor) pauseEngine(w http.ResponseWriter, _ *http.Request) {
+// PauseEngine pauses the engine as the pause button does, so the monitor shows
+// it as paused and the continue button resumes it.
+func (m *Monitor) PauseEngine() {
 	m.engineControlMu.Lock()
+	defer m.engineControlMu.Unlock()
+
+	m.pauseEngineLocked()
+}
+
+func (m *Monitor) pauseEngineLocked() {
 	if !m.enginePaused {
 		m.engine.Pause()
 		m.enginePaused = true
 	}
+}
+
+func (m *Monitor) pauseEngine(w http.ResponseWriter, _ *http.Request) {
+	m.engineControlMu.Lock()
+	m.pauseEngineLocked()
 	response := m.engineStateResponseLocked()
 	m.engineControlMu.Unlock()
 
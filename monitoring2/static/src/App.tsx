@@ -1,10 +1,13 @@
 import { Navigate, Route, Routes } from "react-router-dom";
 import Layout from "./components/Layout";
+import AlertsPage from "./pages/AlertsPage";
 import AnalysisPage from "./pages/AnalysisPage";
 import DebugPage from "./pages/DebugPage";
 import LivePage from "./pages/LivePage";
 import ProfilingPage from "./pages/ProfilingPage";
 import ProgressPage from "./pages/ProgressPage";
+import StatsPage from "./pages/StatsPage";
+import WallClockPage from "./pages/WallClockPage";
 
 export default function App() {
   return (
@@ -17,6 +20,9 @@ export default function App() {
         <Route path="analysis" element={<AnalysisPage />} />
         <Route path="debug" element={<DebugPage />} />
         <Route path="profiling" element={<ProfilingPage />} />
+        <Route path="alerts" element={<AlertsPage />} />
+        <Route path="stats" element={<StatsPage />} />
+        <Route path="wallclock" element={<WallClockPage />} />
         <Route path="dashboard" element={<LivePage />} />
         <Route path="task" element={<LivePage />} />
         <Route path="component" element={<LivePage />} />
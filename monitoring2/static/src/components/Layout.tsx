@@ -1,4 +1,4 @@
-import { Activity, Bug, Gauge, ListChecks, Monitor as MonitorIcon } from "lucide-react";
+import { Activity, BarChart3, BellRing, Bug, Gauge, ListChecks, Monitor as MonitorIcon, Timer } from "lucide-react";
 import { NavLink, Outlet } from "react-router-dom";
 import { PropertyMonitoringCollector } from "../hooks/usePropertyMonitoringSamples";
 import { ResourceUsageCollector } from "../hooks/useResourceUsageHistory";
@@ -9,6 +9,9 @@ const navItems = [
   { to: "/analysis", label: "Analysis", icon: Gauge },
   { to: "/debug", label: "Debug", icon: Bug },
   { to: "/profiling", label: "Profiling", icon: Activity },
+  { to: "/alerts", label: "Alerts", icon: BellRing },
+  { to: "/stats", label: "Stats", icon: BarChart3 },
+  { to: "/wallclock", label: "Wall Clock", icon: Timer },
 ];
 
 export default function Layout() {
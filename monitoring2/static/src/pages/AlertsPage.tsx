@@ -0,0 +1,244 @@
+import { useCallback, useEffect, useState } from "react";
+import { BellRing, Plus, RefreshCcw, Trash2 } from "lucide-react";
+import { Button } from "../components/ui/button";
+import { Input } from "../components/ui/input";
+
+type AlertKind = "buffer_level" | "field_change" | "no_progress" | "time_stall";
+
+interface AlertRule {
+  name: string;
+  kind: AlertKind;
+  buffer?: string;
+  threshold?: number;
+  for_ps?: number;
+  component?: string;
+  field?: string;
+  cycles?: number;
+  freq_hz?: number;
+  wall_seconds?: number;
+  actions: { pause?: boolean; log?: boolean; webhook?: string };
+  active?: boolean;
+  fire_count?: number;
+}
+
+interface AlertEvent {
+  rule: string;
+  kind: AlertKind;
+  sim_time: number;
+  wall_time: string;
+  message: string;
+}
+
+const KIND_LABELS: Record<AlertKind, string> = {
+  buffer_level: "Buffer level",
+  field_change: "Field change",
+  no_progress: "No progress",
+  time_stall: "Time stall",
+};
+
+function useAlerts() {
+  const [rules, setRules] = useState<AlertRule[]>([]);
+  const [events, setEvents] = useState<AlertEvent[]>([]);
+
+  const refresh = useCallback(() => {
+    fetch("/api/alerts")
+      .then((response) => (response.ok ? response.json() : { rules: [], events: [] }))
+      .then((json: { rules?: AlertRule[]; events?: AlertEvent[] }) => {
+        setRules(json.rules ?? []);
+        setEvents(json.events ?? []);
+      })
+      .catch(() => {
+        setRules([]);
+        setEvents([]);
+      });
+  }, []);
+
+  useEffect(() => {
+    refresh();
+    const id = window.setInterval(refresh, 2000);
+    return () => window.clearInterval(id);
+  }, [refresh]);
+
+  return { rules, events, refresh };
+}
+
+function describeRule(rule: AlertRule): string {
+  switch (rule.kind) {
+    case "buffer_level":
+      return `${rule.buffer} ≥ ${Math.round((rule.threshold ?? 0) * 100)}% for ${rule.for_ps ?? 0} ps`;
+    case "field_change":
+      return `${rule.component}.${rule.field} changes`;
+    case "no_progress":
+      return `${rule.component} stuck for ${rule.cycles} cycles @ ${rule.freq_hz} Hz`;
+    case "time_stall":
+      return `sim time stalled for ${rule.wall_seconds} s`;
+  }
+}
+
+function describeActions(rule: AlertRule): string {
+  const actions = [];
+  if (rule.actions.pause) actions.push("pause");
+  if (rule.actions.log || actions.length === 0) actions.push("log");
+  if (rule.actions.webhook) actions.push(`webhook ${rule.actions.webhook}`);
+  return actions.join(", ");
+}
+
+// AlertsPage (route /alerts) lists the monitor's watchpoints, lets the user
+// add and remove them through /api/alerts, and shows recent firings.
+export default function AlertsPage() {
+  const { rules, events, refresh } = useAlerts();
+  const [kind, setKind] = useState<AlertKind>("buffer_level");
+  const [name, setName] = useState("");
+  const [target, setTarget] = useState("");
+  const [field, setField] = useState("");
+  const [amount, setAmount] = useState("");
+  const [freq, setFreq] = useState("1000000000");
+  const [pause, setPause] = useState(true);
+  const [webhook, setWebhook] = useState("");
+  const [status, setStatus] = useState("");
+
+  const addRule = async () => {
+    const rule: AlertRule = { name, kind, actions: { pause, log: true, webhook: webhook || undefined } };
+    const value = Number(amount);
+    if (kind === "buffer_level") {
+      rule.buffer = target;
+      rule.threshold = Number(field) / 100;
+      rule.for_ps = value;
+    } else if (kind === "field_change") {
+      rule.component = target;
+      rule.field = field;
+    } else if (kind === "no_progress") {
+      rule.component = target;
+      rule.cycles = value;
+      rule.freq_hz = Number(freq);
+    } else {
+      rule.wall_seconds = value;
+    }
+
+    const response = await fetch("/api/alerts", { method: "POST", body: JSON.stringify(rule) });
+    setStatus(response.ok ? `Added ${name}` : await response.text());
+    refresh();
+  };
+
+  const removeRule = async (ruleName: string) => {
+    await fetch(`/api/alerts?name=${encodeURIComponent(ruleName)}`, { method: "DELETE" });
+    refresh();
+  };
+
+  return (
+    <div className="h-full overflow-auto bg-slate-50 p-4">
+      <div className="mx-auto flex max-w-6xl flex-col gap-4">
+        <header className="flex flex-wrap items-center gap-3 border-b bg-white px-4 py-3">
+          <BellRing className="h-5 w-5 text-muted-foreground" />
+          <div className="min-w-0 flex-1">
+            <h1 className="text-base font-semibold">Alerts</h1>
+            <div className="text-xs text-muted-foreground">
+              {rules.length} rules, {events.length} recent events
+            </div>
+          </div>
+          <Button type="button" size="sm" variant="outline" onClick={refresh}>
+            <RefreshCcw /> Refresh
+          </Button>
+        </header>
+
+        <section className="flex flex-wrap items-center gap-2 border bg-white p-3 text-sm">
+          <Input className="w-32" value={name} placeholder="Name" onChange={(e) => setName(e.target.value)} />
+          <select
+            className="h-9 rounded border px-2"
+            value={kind}
+            onChange={(e) => setKind(e.target.value as AlertKind)}
+          >
+            {Object.entries(KIND_LABELS).map(([value, label]) => (
+              <option key={value} value={value}>
+                {label}
+              </option>
+            ))}
+          </select>
+          {kind !== "time_stall" ? (
+            <Input
+              className="w-56"
+              value={target}
+              placeholder={kind === "buffer_level" ? "Buffer" : "Component"}
+              onChange={(e) => setTarget(e.target.value)}
+            />
+          ) : null}
+          {kind === "buffer_level" || kind === "field_change" ? (
+            <Input
+              className="w-40"
+              value={field}
+              placeholder={kind === "buffer_level" ? "Threshold %" : "Field path"}
+              onChange={(e) => setField(e.target.value)}
+            />
+          ) : null}
+          {kind !== "field_change" ? (
+            <Input
+              className="w-36"
+              value={amount}
+              placeholder={
+                kind === "buffer_level" ? "For (ps)" : kind === "no_progress" ? "Cycles" : "Wall seconds"
+              }
+              onChange={(e) => setAmount(e.target.value)}
+            />
+          ) : null}
+          {kind === "no_progress" ? (
+            <Input className="w-36" value={freq} placeholder="Freq (Hz)" onChange={(e) => setFreq(e.target.value)} />
+          ) : null}
+          <label className="flex items-center gap-1 text-xs">
+            <input type="checkbox" checked={pause} onChange={(e) => setPause(e.target.checked)} /> Pause
+          </label>
+          <Input
+            className="w-56"
+            value={webhook}
+            placeholder="Webhook (http://localhost:…)"
+            onChange={(e) => setWebhook(e.target.value)}
+          />
+          <Button type="button" size="sm" disabled={!name} onClick={addRule}>
+            <Plus /> Add
+          </Button>
+          {status ? <div className="text-xs text-muted-foreground">{status}</div> : null}
+        </section>
+
+        <section className="border bg-white">
+          {rules.length ? (
+            <div className="divide-y">
+              {rules.map((rule) => (
+                <div key={rule.name} className="grid grid-cols-[minmax(0,1fr)_auto] items-center gap-3 px-4 py-2">
+                  <div className="min-w-0 text-sm">
+                    <span className={`font-medium ${rule.active ? "text-destructive" : ""}`}>{rule.name}</span>{" "}
+                    <span className="text-muted-foreground">
+                      {KIND_LABELS[rule.kind]}: {describeRule(rule)} → {describeActions(rule)} · fired{" "}
+                      {rule.fire_count ?? 0}×
+                    </span>
+                  </div>
+                  <Button type="button" size="sm" variant="outline" onClick={() => removeRule(rule.name)}>
+                    <Trash2 /> Remove
+                  </Button>
+                </div>
+              ))}
+            </div>
+          ) : (
+            <div className="p-6 text-center text-sm text-muted-foreground">No alert rules.</div>
+          )}
+        </section>
+
+        <section className="border bg-white">
+          {events.length ? (
+            <ol className="divide-y text-xs">
+              {[...events].reverse().map((event, i) => (
+                <li key={i} className="flex gap-3 px-4 py-1.5">
+                  <span className="w-40 shrink-0 tabular-nums text-muted-foreground">
+                    {new Date(event.wall_time).toLocaleTimeString()} · {event.sim_time} ps
+                  </span>
+                  <span className="font-medium">{event.rule}</span>
+                  <span className="min-w-0 truncate">{event.message}</span>
+                </li>
+              ))}
+            </ol>
+          ) : (
+            <div className="p-6 text-center text-sm text-muted-foreground">No alerts have fired.</div>
+          )}
+        </section>
+      </div>
+    </div>
+  );
+}
@@ -1,5 +1,5 @@
 import { useCallback, useEffect, useMemo, useState } from "react";
-import { Bug, RefreshCcw, Search, StepForward } from "lucide-react";
+import { Bug, Network, RefreshCcw, Search, StepForward } from "lucide-react";
 import { Button } from "../components/ui/button";
 import { Input } from "../components/ui/input";
 
@@ -24,6 +24,120 @@ function useComponentNames() {
   return { components, refresh };
 }
 
+interface WaitEdge {
+  from: string;
+  from_port: string;
+  connection: string;
+  to_port: string;
+  to: string;
+}
+
+interface BlockedPort {
+  port: string;
+  component: string;
+  connection?: string;
+  incoming: number;
+  outgoing: number;
+  incoming_full: boolean;
+  outgoing_full: boolean;
+}
+
+interface HangReport {
+  kind?: string;
+  time: number;
+  blocked: BlockedPort[];
+  cycle: WaitEdge[];
+  edges?: WaitEdge[];
+}
+
+function HangReportView({ title, report }: { title: string; report: HangReport }) {
+  return (
+    <div className="flex flex-col gap-2 p-3 text-xs">
+      <div className="font-semibold">
+        {title}
+        {report.kind ? `: ${report.kind}` : ""} at {report.time} ps
+      </div>
+      {report.cycle.length ? (
+        <div className="font-mono text-destructive">
+          {report.cycle[0].from}
+          {report.cycle.map((edge, i) => (
+            <span key={i}>
+              {" "}
+              -[{edge.to_port} full]→ {edge.to}
+            </span>
+          ))}
+        </div>
+      ) : (
+        <div className="text-muted-foreground">No wait-for cycle.</div>
+      )}
+      {report.blocked.length ? (
+        <table className="w-full">
+          <thead className="text-left text-muted-foreground">
+            <tr>
+              <th className="font-medium">Port</th>
+              <th className="font-medium">Connection</th>
+              <th className="font-medium">In</th>
+              <th className="font-medium">Out</th>
+            </tr>
+          </thead>
+          <tbody>
+            {report.blocked.map((port) => (
+              <tr key={port.port} className="border-t">
+                <td className="font-mono">{port.port}</td>
+                <td>{port.connection ?? ""}</td>
+                <td className={port.incoming_full ? "text-destructive" : ""}>{port.incoming}</td>
+                <td className={port.outgoing_full ? "text-destructive" : ""}>{port.outgoing}</td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      ) : (
+        <div className="text-muted-foreground">No port holds a message.</div>
+      )}
+    </div>
+  );
+}
+
+// HangAnalysis shows the hang Run detected, if any, and analyzes the current
+// wait-for graph on demand.
+function HangAnalysis() {
+  const [detected, setDetected] = useState<HangReport | null>(null);
+  const [current, setCurrent] = useState<HangReport | null>(null);
+  const [loading, setLoading] = useState(false);
+
+  const analyze = useCallback(() => {
+    setLoading(true);
+    fetch("/api/hangdetector/waitfor")
+      .then((response) => (response.ok ? response.json() : { detected: null, current: null }))
+      .then((json: { detected: HangReport | null; current: HangReport | null }) => {
+        setDetected(json.detected);
+        setCurrent(json.current);
+      })
+      .catch(() => setCurrent(null))
+      .finally(() => setLoading(false));
+  }, []);
+
+  useEffect(analyze, [analyze]);
+
+  return (
+    <section className="border bg-white">
+      <div className="flex items-center gap-3 border-b p-3">
+        <Network className="h-4 w-4 text-muted-foreground" />
+        <div className="flex-1 text-sm font-medium">Hang analysis</div>
+        <Button type="button" size="sm" variant="outline" disabled={loading} onClick={analyze}>
+          <RefreshCcw /> Analyze
+        </Button>
+      </div>
+      {detected ? <HangReportView title="Detected" report={detected} /> : null}
+      {current ? (
+        <HangReportView title="Current state" report={current} />
+      ) : (
+        <div className="p-3 text-xs text-muted-foreground">No hang analyzer registered.</div>
+      )}
+    </section>
+  );
+}
+
 async function post(path: string) {
   const response = await fetch(path, { method: "POST" });
   if (!response.ok) {
@@ -127,6 +241,8 @@ export default function DebugPage() {
             <div className="p-10 text-center text-sm text-muted-foreground">No components available.</div>
           )}
         </section>
+
+        <HangAnalysis />
       </div>
     </div>
   );
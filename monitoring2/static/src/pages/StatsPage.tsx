@@ -0,0 +1,170 @@
+import { useCallback, useEffect, useMemo, useState } from "react";
+import { BarChart3, ChevronRight, RefreshCcw } from "lucide-react";
+import { Button } from "../components/ui/button";
+import { Input } from "../components/ui/input";
+
+interface HistogramSnapshot {
+  bounds: number[];
+  buckets: number[];
+  count: number;
+  sum: number;
+  min: number;
+  max: number;
+}
+
+interface Stat {
+  name: string;
+  kind: "counter" | "gauge" | "histogram" | "formula";
+  desc?: string;
+  value: number;
+  histogram?: HistogramSnapshot;
+}
+
+function useStats(prefix: string) {
+  const [stats, setStats] = useState<Stat[]>([]);
+
+  const refresh = useCallback(() => {
+    fetch(`/api/stats?prefix=${encodeURIComponent(prefix)}`)
+      .then((response) => (response.ok ? response.json() : []))
+      .then((json: Stat[]) => setStats(json ?? []))
+      .catch(() => setStats([]));
+  }, [prefix]);
+
+  useEffect(() => {
+    refresh();
+    const id = window.setInterval(refresh, 1000);
+    return () => window.clearInterval(id);
+  }, [refresh]);
+
+  return { stats, refresh };
+}
+
+// children lists the next name level under the prefix, e.g. "GPU[0].L2" and
+// "GPU[0].DRAM" under "GPU[0]", with the number of stats below each.
+function children(stats: Stat[], prefix: string): [string, number][] {
+  const counts = new Map<string, number>();
+  const depth = prefix ? prefix.split(".").length : 0;
+  for (const stat of stats) {
+    const tokens = stat.name.split(".");
+    if (tokens.length <= depth + 1) continue;
+    const child = tokens.slice(0, depth + 1).join(".");
+    counts.set(child, (counts.get(child) ?? 0) + 1);
+  }
+  return [...counts.entries()].sort(([a], [b]) => a.localeCompare(b));
+}
+
+function formatValue(value: number): string {
+  return Number.isInteger(value) ? value.toLocaleString() : value.toPrecision(6);
+}
+
+function HistogramBars({ histogram }: { histogram: HistogramSnapshot }) {
+  const peak = Math.max(1, ...histogram.buckets);
+  const label = (i: number) =>
+    i === 0
+      ? `<${histogram.bounds[0]}`
+      : i === histogram.bounds.length
+        ? `≥${histogram.bounds[i - 1]}`
+        : `${histogram.bounds[i - 1]}–${histogram.bounds[i]}`;
+
+  return (
+    <div className="mt-1 flex h-10 items-end gap-px">
+      {histogram.buckets.map((count, i) => (
+        <div
+          key={i}
+          className="w-3 bg-sky-500"
+          style={{ height: `${(count / peak) * 100}%` }}
+          title={`${label(i)}: ${count}`}
+        />
+      ))}
+    </div>
+  );
+}
+
+// StatsPage (route /stats) browses the simulation's statistics registry by
+// hierarchical name and refreshes the values live.
+export default function StatsPage() {
+  const [prefix, setPrefix] = useState("");
+  const [filter, setFilter] = useState("");
+  const { stats, refresh } = useStats(prefix);
+
+  const depth = prefix ? prefix.split(".").length : 0;
+  const subtrees = useMemo(() => children(stats, prefix), [stats, prefix]);
+  const leaves = stats.filter(
+    (stat) => stat.name.split(".").length === depth + 1 && stat.name.toLowerCase().includes(filter.toLowerCase()),
+  );
+  const crumbs = prefix ? prefix.split(".") : [];
+
+  return (
+    <div className="h-full overflow-auto bg-slate-50 p-4">
+      <div className="mx-auto flex max-w-6xl flex-col gap-4">
+        <header className="flex flex-wrap items-center gap-3 border-b bg-white px-4 py-3">
+          <BarChart3 className="h-5 w-5 text-muted-foreground" />
+          <div className="min-w-0 flex-1">
+            <h1 className="text-base font-semibold">Statistics</h1>
+            <div className="flex flex-wrap items-center gap-1 text-xs text-muted-foreground">
+              <button type="button" className="hover:underline" onClick={() => setPrefix("")}>
+                All
+              </button>
+              {crumbs.map((crumb, i) => (
+                <span key={i} className="flex items-center gap-1">
+                  <ChevronRight className="h-3 w-3" />
+                  <button
+                    type="button"
+                    className="hover:underline"
+                    onClick={() => setPrefix(crumbs.slice(0, i + 1).join("."))}
+                  >
+                    {crumb}
+                  </button>
+                </span>
+              ))}
+            </div>
+          </div>
+          <Input className="w-56" value={filter} placeholder="Filter" onChange={(e) => setFilter(e.target.value)} />
+          <Button type="button" size="sm" variant="outline" onClick={refresh}>
+            <RefreshCcw /> Refresh
+          </Button>
+        </header>
+
+        {subtrees.length ? (
+          <section className="flex flex-wrap gap-2 border bg-white p-3 text-sm">
+            {subtrees.map(([child, count]) => (
+              <Button key={child} type="button" size="sm" variant="outline" onClick={() => setPrefix(child)}>
+                {child.split(".").pop()} <span className="text-muted-foreground">({count})</span>
+              </Button>
+            ))}
+          </section>
+        ) : null}
+
+        <section className="border bg-white">
+          {leaves.length ? (
+            <div className="divide-y">
+              {leaves.map((stat) => (
+                <div key={stat.name} className="grid grid-cols-[minmax(0,1fr)_auto] gap-3 px-4 py-2 text-sm">
+                  <div className="min-w-0">
+                    <div className="font-medium">{stat.name.split(".").pop()}</div>
+                    <div className="truncate text-xs text-muted-foreground">
+                      {stat.kind}
+                      {stat.desc ? ` · ${stat.desc}` : ""}
+                    </div>
+                    {stat.histogram ? <HistogramBars histogram={stat.histogram} /> : null}
+                  </div>
+                  <div className="text-right tabular-nums">
+                    {formatValue(stat.value)}
+                    {stat.histogram ? (
+                      <div className="text-xs text-muted-foreground">
+                        mean of {stat.histogram.count} · min {formatValue(stat.histogram.min)} · max{" "}
+                        {formatValue(stat.histogram.max)}
+                      </div>
+                    ) : null}
+                  </div>
+                </div>
+              ))}
+            </div>
+          ) : (
+            <div className="p-6 text-center text-sm text-muted-foreground">No statistics at this level.</div>
+          )}
+        </section>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,164 @@
+import { useCallback, useEffect, useState } from "react";
+import { RefreshCcw, Timer } from "lucide-react";
+import { Button } from "../components/ui/button";
+
+interface ComponentSummary {
+  component: string;
+  handles: number;
+  handle_time_ns: number;
+  ticks: number;
+  tick_time_ns: number;
+  no_progress_ticks: number;
+  middleware_time_ns: number;
+}
+
+interface TypeSummary {
+  type: string;
+  components: number;
+  calls: number;
+  wall_time_ns: number;
+  no_progress: number;
+}
+
+interface WallProfile {
+  elapsed_ns: number;
+  profiled_ns: number;
+  components: ComponentSummary[];
+  middlewares: TypeSummary[];
+}
+
+function useWallProfile() {
+  const [profile, setProfile] = useState<WallProfile | null>(null);
+
+  const refresh = useCallback(() => {
+    fetch("/api/wallprofile")
+      .then((response) => (response.ok ? response.json() : null))
+      .then((json: WallProfile | null) => setProfile(json))
+      .catch(() => setProfile(null));
+  }, []);
+
+  useEffect(() => {
+    refresh();
+    const id = window.setInterval(refresh, 2000);
+    return () => window.clearInterval(id);
+  }, [refresh]);
+
+  return { profile, refresh };
+}
+
+function formatDuration(ns: number): string {
+  if (ns >= 1e9) return `${(ns / 1e9).toFixed(2)} s`;
+  if (ns >= 1e6) return `${(ns / 1e6).toFixed(2)} ms`;
+  return `${(ns / 1e3).toFixed(1)} µs`;
+}
+
+function percent(n: number, d: number): string {
+  return d > 0 ? `${((100 * n) / d).toFixed(1)}%` : "–";
+}
+
+function ShareBar({ share }: { share: number }) {
+  return (
+    <div className="h-1.5 w-24 bg-slate-100">
+      <div className="h-full bg-sky-500" style={{ width: `${Math.min(100, share * 100)}%` }} />
+    </div>
+  );
+}
+
+// WallClockPage (route /wallclock) shows where the simulator's own wall time
+// goes: the most expensive components and middleware types, with the fraction
+// of their ticks that made no progress. It needs a simulation built
+// WithProfiling.
+export default function WallClockPage() {
+  const { profile, refresh } = useWallProfile();
+
+  if (!profile) {
+    return (
+      <div className="p-6 text-center text-sm text-muted-foreground">
+        Wall-clock profiling is off. Build the simulation with WithProfiling() to enable it.
+      </div>
+    );
+  }
+
+  const componentTime = (c: ComponentSummary) => Math.max(c.handle_time_ns, c.tick_time_ns);
+
+  return (
+    <div className="h-full overflow-auto bg-slate-50 p-4">
+      <div className="mx-auto flex max-w-6xl flex-col gap-4">
+        <header className="flex flex-wrap items-center gap-3 border-b bg-white px-4 py-3">
+          <Timer className="h-5 w-5 text-muted-foreground" />
+          <div className="min-w-0 flex-1">
+            <h1 className="text-base font-semibold">Wall Clock</h1>
+            <div className="text-xs text-muted-foreground">
+              {formatDuration(profile.profiled_ns)} in handlers of {formatDuration(profile.elapsed_ns)} elapsed
+            </div>
+          </div>
+          <Button type="button" size="sm" variant="outline" onClick={refresh}>
+            <RefreshCcw /> Refresh
+          </Button>
+        </header>
+
+        <section className="border bg-white">
+          <h2 className="border-b px-4 py-2 text-sm font-semibold">Components</h2>
+          <table className="w-full text-left text-xs">
+            <thead className="text-muted-foreground">
+              <tr>
+                <th className="px-4 py-1 font-medium">Component</th>
+                <th className="py-1 pr-3 text-right font-medium">Wall time</th>
+                <th className="py-1 pr-3 font-medium">Share</th>
+                <th className="py-1 pr-3 text-right font-medium">Handles</th>
+                <th className="py-1 pr-3 text-right font-medium">Ticks</th>
+                <th className="py-1 pr-3 text-right font-medium">No progress</th>
+                <th className="py-1 pr-4 text-right font-medium">Middlewares</th>
+              </tr>
+            </thead>
+            <tbody>
+              {profile.components.map((c) => (
+                <tr key={c.component} className="border-t tabular-nums">
+                  <td className="px-4 py-1 font-mono">{c.component}</td>
+                  <td className="py-1 pr-3 text-right">{formatDuration(componentTime(c))}</td>
+                  <td className="py-1 pr-3">
+                    <ShareBar share={profile.profiled_ns > 0 ? componentTime(c) / profile.profiled_ns : 0} />
+                  </td>
+                  <td className="py-1 pr-3 text-right">{c.handles.toLocaleString()}</td>
+                  <td className="py-1 pr-3 text-right">{c.ticks.toLocaleString()}</td>
+                  <td className="py-1 pr-3 text-right">{percent(c.no_progress_ticks, c.ticks)}</td>
+                  <td className="py-1 pr-4 text-right">{formatDuration(c.middleware_time_ns)}</td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        </section>
+
+        <section className="border bg-white">
+          <h2 className="border-b px-4 py-2 text-sm font-semibold">Middleware types</h2>
+          <table className="w-full text-left text-xs">
+            <thead className="text-muted-foreground">
+              <tr>
+                <th className="px-4 py-1 font-medium">Type</th>
+                <th className="py-1 pr-3 text-right font-medium">Wall time</th>
+                <th className="py-1 pr-3 font-medium">Share</th>
+                <th className="py-1 pr-3 text-right font-medium">Components</th>
+                <th className="py-1 pr-3 text-right font-medium">Ticks</th>
+                <th className="py-1 pr-4 text-right font-medium">No progress</th>
+              </tr>
+            </thead>
+            <tbody>
+              {profile.middlewares.map((t) => (
+                <tr key={t.type} className="border-t tabular-nums">
+                  <td className="px-4 py-1 font-mono">{t.type}</td>
+                  <td className="py-1 pr-3 text-right">{formatDuration(t.wall_time_ns)}</td>
+                  <td className="py-1 pr-3">
+                    <ShareBar share={profile.profiled_ns > 0 ? t.wall_time_ns / profile.profiled_ns : 0} />
+                  </td>
+                  <td className="py-1 pr-3 text-right">{t.components.toLocaleString()}</td>
+                  <td className="py-1 pr-3 text-right">{t.calls.toLocaleString()}</td>
+                  <td className="py-1 pr-4 text-right">{percent(t.no_progress, t.calls)}</td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        </section>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,81 @@
+package monitoring2
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// ---- Statistics ----
+//
+// Like the hang analyzer, the stats source is registered by the simulation,
+// so the monitor does not depend on the stats package. Some stats are computed
+// from component state, so they are read with the engine paused.
+
+// RegisterStatsSource registers a function that returns the simulation's
+// statistics under a hierarchical name prefix ("" for all). Its result must be
+// JSON-serializable.
+func (m *Monitor) RegisterStatsSource(source func(prefix string) any) {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+
+	m.statsSource = source
+}
+
+// apiStats serves the statistics under the "prefix" query parameter.
+func (m *Monitor) apiStats(w http.ResponseWriter, r *http.Request) {
+	m.statsMu.Lock()
+	source := m.statsSource
+	m.statsMu.Unlock()
+
+	var rsp any = []any{}
+
+	if source != nil {
+		if m.engine != nil {
+			resume := m.pauseForInspection()
+			defer resume()
+		}
+
+		rsp = source(r.URL.Query().Get("prefix"))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(rsp); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// ---- Wall-clock profile ----
+//
+// The profile source is registered by a simulation built with profiling, so
+// the monitor does not depend on the profiling package.
+
+// RegisterProfileSource registers a function that returns the simulation's
+// wall-clock profile report. Its result must be JSON-serializable.
+func (m *Monitor) RegisterProfileSource(source func() any) {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+
+	m.profileSource = source
+}
+
+// apiWallProfile serves the wall-clock profile report, or null if the
+// simulation is not profiled.
+func (m *Monitor) apiWallProfile(w http.ResponseWriter, _ *http.Request) {
+	m.statsMu.Lock()
+	source := m.profileSource
+	m.statsMu.Unlock()
+
+	var rsp any
+
+	if source != nil {
+		rsp = source()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(rsp); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
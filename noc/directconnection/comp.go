@@ -4,6 +4,7 @@ package directconnection
 import (
 	"fmt"
 
+	"github.com/sarchlab/akita/v5/hooking"
 	"github.com/sarchlab/akita/v5/messaging"
 	"github.com/sarchlab/akita/v5/modeling"
 	"github.com/sarchlab/akita/v5/timing"
@@ -65,6 +66,14 @@ func (c *Comp) PlugIn(port messaging.Port) {
 
 	c.mw().ports.addPort(port)
 	port.SetConnection(c)
+
+	if c.NumHooks() > 0 {
+		c.InvokeHook(hooking.HookCtx{
+			Domain: c,
+			Pos:    messaging.HookPosConnPlugIn,
+			Item:   port,
+		})
+	}
 }
 
 // Unplug marks the port no longer connects to this DirectConnection.
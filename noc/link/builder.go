@@ -0,0 +1,129 @@
+package link
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/naming"
+	"github.com/sarchlab/akita/v5/stats"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// defaultSpec provides the default configuration for a link.
+var defaultSpec = Spec{
+	Freq:          1 * timing.GHz,
+	Latency:       1,
+	BytesPerCycle: 32,
+	BufferSize:    16,
+	Arbitration:   ArbitrationNone,
+}
+
+// DefaultSpec returns a copy of the default configuration. Callers obtain it,
+// tweak the fields they care about, and pass it to WithSpec.
+func DefaultSpec() Spec {
+	return defaultSpec
+}
+
+// Builder builds links. A link owns no ports (ports plug in) and has no
+// resources, so it is configured by Spec alone and wired to the simulation
+// through a registrar.
+type Builder struct {
+	spec      Spec
+	registrar modeling.Registrar
+}
+
+// MakeBuilder creates a new Builder seeded with the default spec.
+func MakeBuilder() Builder {
+	return Builder{spec: defaultSpec}
+}
+
+// WithRegistrar wires the builder to a registrar (a *simulation.Simulation in
+// assembly, or modeling.NewStandaloneRegistrar(engine) in isolated tests). The
+// registrar provides the engine and registers the built connection.
+func (b Builder) WithRegistrar(reg modeling.Registrar) Builder {
+	b.registrar = reg
+	return b
+}
+
+// WithSpec sets the entire configuration. Start from DefaultSpec() and tweak.
+func (b Builder) WithSpec(spec Spec) Builder {
+	b.spec = spec
+	return b
+}
+
+// Build creates a new link with the given name.
+func (b Builder) Build(name string) *Comp {
+	if b.registrar == nil {
+		panic("link: WithRegistrar is required")
+	}
+
+	spec := b.spec
+	mustBeValid(spec)
+
+	engine := b.registrar.GetEngine()
+
+	modelComp := modeling.NewBuilder[Spec, State, modeling.None]().
+		WithEngine(engine).
+		WithFreq(spec.Freq).
+		WithSpec(spec).
+		Build(name)
+
+	// Like a direct connection, a link ticks after the components it
+	// connects, so that a zero-latency, unlimited-bandwidth link delivers in
+	// the cycle a message is sent.
+	modelComp.TickingComponent = modeling.NewSecondaryTickingComponent(
+		name, engine, spec.Freq, modelComp)
+
+	modelComp.AddMiddleware(&middleware{
+		comp:      modelComp,
+		portIndex: make(map[messaging.RemotePort]int),
+	})
+
+	conn := &Comp{Component: modelComp}
+
+	b.registrar.RegisterConnection(conn)
+	registerStats(stats.Of(b.registrar), conn)
+
+	return conn
+}
+
+func mustBeValid(spec Spec) {
+	switch {
+	case spec.Latency < 0:
+		panic(fmt.Sprintf("link: latency %d is negative", spec.Latency))
+	case spec.BytesPerCycle < 0:
+		panic(fmt.Sprintf("link: bytes per cycle %d is negative",
+			spec.BytesPerCycle))
+	case spec.BufferSize <= 0:
+		panic("link: buffer size must be positive")
+	}
+
+	switch spec.Arbitration {
+	case ArbitrationNone, ArbitrationRoundRobin, ArbitrationPriority:
+	default:
+		panic(fmt.Sprintf("link: unknown arbitration %q", spec.Arbitration))
+	}
+}
+
+// registerStats publishes the link's traffic counters to a stats registry.
+func registerStats(reg *stats.Registry, c *Comp) {
+	name := func(stat string) string { return naming.BuildName(c.Name(), stat) }
+
+	reg.NewCounterFunc(name("DeliveredMsgs"), "messages delivered",
+		func() uint64 { return c.State.DeliveredMsgs })
+	bytes := reg.NewCounterFunc(name("DeliveredBytes"), "bytes delivered",
+		func() uint64 { return c.State.DeliveredBytes })
+	cycles := reg.NewCounterFunc(name("BusyCycles"),
+		"cycles a serializer was sending",
+		func() uint64 { return c.State.BusyCycles })
+	reg.NewFormula(name("BytesPerBusyCycle"),
+		"bytes delivered per cycle a serializer was sending",
+		func() float64 {
+			if cycles.Value() == 0 {
+				return 0
+			}
+
+			return float64(bytes.Value()) / float64(cycles.Value())
+		})
+}
@@ -0,0 +1,59 @@
+package link
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/mem/memprotocol"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/simulation"
+)
+
+// TestMessagesInFlightRoundTrip confirms that the messages on the wire are
+// part of the link's checkpointed state, with their concrete types.
+func TestMessagesInFlightRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ck.tar.gz")
+	const buildID = "link-test"
+
+	sim := simulation.MakeBuilder().WithoutMonitoring().Build()
+	defer func() {
+		sim.Terminate()
+		os.Remove("akita_sim_" + sim.ID() + ".sqlite3")
+	}()
+
+	conn := MakeBuilder().WithRegistrar(sim).Build("Link")
+	a := messaging.NewPort(nil, 4, 4, "A.Port")
+	b := messaging.NewPort(nil, 4, 4, "B.Port")
+	conn.PlugIn(a)
+	conn.PlugIn(b)
+
+	req := memprotocol.ReadReq{Address: 0x40, AccessByteSize: 64}
+	req.ID = 7
+	req.Src = a.AsRemote()
+	req.Dst = b.AsRemote()
+	conn.State.Directions[0].InFlight = []transfer{
+		{Msg: messaging.HeldMsg{Msg: req}, CyclesLeft: 3},
+	}
+
+	if err := sim.SaveCheckpoint(path, buildID); err != nil {
+		t.Fatalf("SaveCheckpoint: %v", err)
+	}
+
+	conn.State = State{}
+
+	if err := sim.LoadCheckpoint(path, buildID); err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+
+	inFlight := conn.State.Directions[0].InFlight
+	if len(conn.State.Directions) != 2 || len(inFlight) != 1 ||
+		inFlight[0].CyclesLeft != 3 {
+		t.Fatalf("the messages in flight were not restored: %+v", conn.State)
+	}
+
+	restored, ok := inFlight[0].Msg.Msg.(memprotocol.ReadReq)
+	if !ok || restored.ID != 7 || restored.Address != 0x40 {
+		t.Fatalf("expected the read request, got %#v", inFlight[0].Msg.Msg)
+	}
+}
@@ -0,0 +1,128 @@
+// Package link provides a connection that models the latency and bandwidth of
+// a point-to-point link or a shared bus, without building a network.
+package link
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// Arbitration selects how the ports plugged into a link share it.
+type Arbitration string
+
+// The arbitration policies.
+const (
+	// ArbitrationNone gives every port its own serializer, as on a
+	// point-to-point link where each direction has its own wires.
+	ArbitrationNone Arbitration = "none"
+	// ArbitrationRoundRobin makes the ports share one serializer, as on a
+	// bus, and grants it to the ports in turn.
+	ArbitrationRoundRobin Arbitration = "round_robin"
+	// ArbitrationPriority makes the ports share one serializer and grants it
+	// to the port plugged in first among those with a message to send.
+	ArbitrationPriority Arbitration = "priority"
+)
+
+// Spec holds immutable configuration for a link.
+type Spec struct {
+	Freq timing.Freq `json:"freq"`
+
+	// Latency is the number of cycles a message spends on the wire after it
+	// is serialized.
+	Latency int `json:"latency"`
+
+	// BytesPerCycle is the bandwidth of a serializer. A message occupies its
+	// serializer for TrafficBytes / BytesPerCycle cycles, rounded up and at
+	// least one. Zero means unlimited bandwidth.
+	BytesPerCycle int `json:"bytes_per_cycle"`
+
+	// BufferSize is the number of messages each direction, i.e. each sending
+	// port, holds in flight. A message stays in the buffer from the cycle it
+	// is taken from the sending port until it is delivered.
+	BufferSize int `json:"buffer_size"`
+
+	Arbitration Arbitration `json:"arbitration"`
+}
+
+// transfer is a message in flight.
+type transfer struct {
+	Msg messaging.HeldMsg `json:"msg"`
+	// CyclesLeft counts down to the cycle the message can be delivered.
+	CyclesLeft int `json:"cycles_left"`
+}
+
+// direction is the buffer of messages sent by one port.
+type direction struct {
+	Src      messaging.RemotePort `json:"src"`
+	InFlight []transfer           `json:"in_flight"`
+	// SerializerBusy counts down the cycles the direction's serializer is
+	// still sending. It is unused when the ports share a serializer.
+	SerializerBusy int `json:"serializer_busy"`
+}
+
+// State holds mutable runtime state for a link.
+type State struct {
+	Directions []direction `json:"directions"`
+	// BusBusy counts down the cycles the shared serializer is still sending.
+	BusBusy  int `json:"bus_busy"`
+	NextPort int `json:"next_port"`
+
+	DeliveredMsgs  uint64 `json:"delivered_msgs"`
+	DeliveredBytes uint64 `json:"delivered_bytes"`
+	BusyCycles     uint64 `json:"busy_cycles"`
+}
+
+// Comp is a link that delivers messages between the ports plugged into it
+// after a latency, at a limited bandwidth.
+type Comp struct {
+	*modeling.Component[Spec, State, modeling.None]
+}
+
+func (c *Comp) mw() *middleware {
+	return c.Middlewares()[0].(*middleware)
+}
+
+// PlugIn connects a port to the link and gives it its own direction.
+func (c *Comp) PlugIn(port messaging.Port) {
+	c.Lock()
+	defer c.Unlock()
+
+	mw := c.mw()
+	if _, found := mw.portIndex[port.AsRemote()]; found {
+		panic(fmt.Sprintf("link %s: port %s is already plugged in",
+			c.Name(), port.Name()))
+	}
+
+	mw.portIndex[port.AsRemote()] = len(mw.ports)
+	mw.ports = append(mw.ports, port)
+	c.State.Directions = append(c.State.Directions,
+		direction{Src: port.AsRemote()})
+	port.SetConnection(c)
+
+	if c.NumHooks() > 0 {
+		c.InvokeHook(hooking.HookCtx{
+			Domain: c,
+			Pos:    messaging.HookPosConnPlugIn,
+			Item:   port,
+		})
+	}
+}
+
+// Unplug is not supported.
+func (c *Comp) Unplug(_ messaging.Port) {
+	panic("not implemented")
+}
+
+// NotifyAvailable is called by a port when it can take messages again.
+func (c *Comp) NotifyAvailable(_ messaging.Port) {
+	c.TickNow()
+}
+
+// NotifySend is called by a port when it has a message to send.
+func (c *Comp) NotifySend() {
+	c.TickNow()
+}
@@ -0,0 +1,187 @@
+package link_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/noc/link"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+type testMsg struct{ messaging.MsgMeta }
+
+// arrival is a message delivered to a port, with the cycle it arrived in.
+type arrival struct {
+	id    uint64
+	cycle timing.VTimeInPicoSec
+}
+
+type arrivalRecorder struct {
+	engine   timing.Engine
+	arrivals []arrival
+}
+
+func (r *arrivalRecorder) Func(ctx hooking.HookCtx) {
+	if ctx.Pos != messaging.HookPosPortMsgRecvd {
+		return
+	}
+
+	r.arrivals = append(r.arrivals, arrival{
+		id:    ctx.Item.(messaging.Msg).Meta().ID,
+		cycle: r.engine.CurrentTime() / 1000,
+	})
+}
+
+type bench struct {
+	engine   timing.Engine
+	link     *link.Comp
+	ports    map[string]messaging.Port
+	recorder *arrivalRecorder
+}
+
+// newBench plugs one port per name into a 1 GHz link.
+func newBench(spec link.Spec, names ...string) *bench {
+	engine := timing.NewSerialEngine()
+	spec.Freq = 1 * timing.GHz
+
+	b := &bench{
+		engine: engine,
+		link: link.MakeBuilder().
+			WithRegistrar(modeling.NewStandaloneRegistrar(engine)).
+			WithSpec(spec).
+			Build("Link"),
+		ports:    map[string]messaging.Port{},
+		recorder: &arrivalRecorder{engine: engine},
+	}
+
+	for _, name := range names {
+		port := messaging.NewPort(nil, 16, 16, name+".Port")
+		port.AcceptHook(b.recorder)
+		b.link.PlugIn(port)
+		b.ports[name] = port
+	}
+
+	return b
+}
+
+func (b *bench) send(id uint64, src, dst string, bytes int) {
+	b.ports[src].Send(testMsg{messaging.MsgMeta{
+		ID:           id,
+		Src:          b.ports[src].AsRemote(),
+		Dst:          b.ports[dst].AsRemote(),
+		TrafficBytes: bytes,
+	}})
+}
+
+func (b *bench) run(t *testing.T) []arrival {
+	t.Helper()
+
+	if err := b.engine.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.recorder.arrivals
+}
+
+func expectArrivals(t *testing.T, got []arrival, want ...arrival) {
+	t.Helper()
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected arrivals %v, got %v", want, got)
+	}
+}
+
+func TestLatencyAndBandwidth(t *testing.T) {
+	spec := link.DefaultSpec()
+	spec.Latency = 5
+	spec.BytesPerCycle = 32
+
+	b := newBench(spec, "A", "B")
+	b.send(1, "A", "B", 64)
+	b.send(2, "A", "B", 64)
+
+	// Each message is serialized for 2 cycles and then spends 5 on the wire;
+	// the second waits for the first to be serialized.
+	expectArrivals(t, b.run(t), arrival{1, 7}, arrival{2, 9})
+
+	if b.link.State.DeliveredBytes != 128 || b.link.State.BusyCycles != 4 {
+		t.Fatalf("unexpected counters %+v", b.link.State)
+	}
+}
+
+func TestDirectionsAreIndependentWithoutArbitration(t *testing.T) {
+	spec := link.DefaultSpec()
+	spec.Latency = 3
+	spec.BytesPerCycle = 16
+
+	b := newBench(spec, "A", "B")
+	b.send(1, "A", "B", 32)
+	b.send(2, "B", "A", 32)
+
+	expectArrivals(t, b.run(t), arrival{1, 5}, arrival{2, 5})
+}
+
+func TestSharedSerializerArbitration(t *testing.T) {
+	for _, c := range []struct {
+		arbitration link.Arbitration
+		order       []uint64
+	}{
+		{link.ArbitrationRoundRobin, []uint64{1, 3, 2, 4}},
+		{link.ArbitrationPriority, []uint64{1, 2, 3, 4}},
+	} {
+		spec := link.DefaultSpec()
+		spec.Latency = 0
+		spec.BytesPerCycle = 16
+		spec.Arbitration = c.arbitration
+
+		b := newBench(spec, "A", "B", "C")
+		b.send(3, "B", "C", 16)
+		b.send(4, "B", "C", 16)
+		b.send(1, "A", "C", 16)
+		b.send(2, "A", "C", 16)
+
+		var order []uint64
+		for i, a := range b.run(t) {
+			order = append(order, a.id)
+
+			if a.cycle != timing.VTimeInPicoSec(i+1) {
+				t.Fatalf("%s: expected one message per cycle, got %v",
+					c.arbitration, b.recorder.arrivals)
+			}
+		}
+
+		if !slices.Equal(order, c.order) {
+			t.Fatalf("%s: expected order %v, got %v",
+				c.arbitration, c.order, order)
+		}
+	}
+}
+
+func TestBufferSizeLimitsMessagesInFlight(t *testing.T) {
+	spec := link.DefaultSpec()
+	spec.Latency = 10
+	spec.BytesPerCycle = 0
+	spec.BufferSize = 1
+
+	b := newBench(spec, "A", "B")
+	for id := uint64(1); id <= 3; id++ {
+		b.send(id, "A", "B", 64)
+	}
+
+	expectArrivals(t, b.run(t), arrival{1, 10}, arrival{2, 20}, arrival{3, 30})
+}
+
+func TestZeroLatencyUnlimitedLinkDeliversInTheSendingCycle(t *testing.T) {
+	spec := link.DefaultSpec()
+	spec.Latency = 0
+	spec.BytesPerCycle = 0
+
+	b := newBench(spec, "A", "B")
+	b.send(1, "A", "B", 64)
+	b.send(2, "A", "B", 64)
+
+	expectArrivals(t, b.run(t), arrival{1, 0}, arrival{2, 0})
+}
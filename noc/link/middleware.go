@@ -0,0 +1,198 @@
+package link
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+)
+
+type middleware struct {
+	comp      *modeling.Component[Spec, State, modeling.None]
+	ports     []messaging.Port
+	portIndex map[messaging.RemotePort]int
+}
+
+// Tick advances the link by one cycle: the messages in flight and the
+// serializers count down, the messages that arrived are delivered, and the
+// serializers take new messages from the sending ports into the buffer space
+// that frees up. A message that needs no cycles is delivered right away.
+func (m *middleware) Tick() bool {
+	madeProgress := m.countDown()
+	madeProgress = m.deliver() || madeProgress
+
+	if m.shared() {
+		madeProgress = m.acceptShared() || madeProgress
+	} else {
+		madeProgress = m.acceptEach() || madeProgress
+	}
+
+	madeProgress = m.deliver() || madeProgress
+
+	return madeProgress
+}
+
+func (m *middleware) shared() bool {
+	return m.comp.Spec().Arbitration != ArbitrationNone
+}
+
+func (m *middleware) countDown() bool {
+	state := &m.comp.State
+	madeProgress := false
+	busy := false
+
+	if state.BusBusy > 0 {
+		state.BusBusy--
+		busy = true
+	}
+
+	for i := range state.Directions {
+		d := &state.Directions[i]
+
+		if d.SerializerBusy > 0 {
+			d.SerializerBusy--
+			busy = true
+		}
+
+		for j := range d.InFlight {
+			if d.InFlight[j].CyclesLeft > 0 {
+				d.InFlight[j].CyclesLeft--
+				madeProgress = true
+			}
+		}
+	}
+
+	if busy {
+		state.BusyCycles++
+		madeProgress = true
+	}
+
+	return madeProgress
+}
+
+// acceptEach lets every direction's serializer take messages from its port.
+func (m *middleware) acceptEach() bool {
+	state := &m.comp.State
+	madeProgress := false
+
+	for i := range state.Directions {
+		d := &state.Directions[i]
+		for d.SerializerBusy == 0 && m.accept(i) {
+			d.SerializerBusy = m.lastSerializeCycles(i)
+			madeProgress = true
+		}
+	}
+
+	return madeProgress
+}
+
+// acceptShared grants the shared serializer to the ports by the arbitration
+// policy.
+func (m *middleware) acceptShared() bool {
+	state := &m.comp.State
+	madeProgress := false
+
+	for state.BusBusy == 0 {
+		granted := m.arbitrate()
+		if granted < 0 || !m.accept(granted) {
+			break
+		}
+
+		state.BusBusy = m.lastSerializeCycles(granted)
+		madeProgress = true
+	}
+
+	return madeProgress
+}
+
+// arbitrate returns the direction granted the shared serializer, or -1 if no
+// direction has a message it can take.
+func (m *middleware) arbitrate() int {
+	state := &m.comp.State
+	numPorts := len(m.ports)
+
+	start := 0
+	if m.comp.Spec().Arbitration == ArbitrationRoundRobin {
+		start = state.NextPort
+	}
+
+	for i := range numPorts {
+		index := (start + i) % numPorts
+		if m.canAccept(index) {
+			state.NextPort = (index + 1) % numPorts
+			return index
+		}
+	}
+
+	return -1
+}
+
+func (m *middleware) canAccept(index int) bool {
+	return len(m.comp.State.Directions[index].InFlight) < m.comp.Spec().BufferSize &&
+		m.ports[index].PeekOutgoing() != nil
+}
+
+// accept takes the next message from a port into its direction's buffer.
+func (m *middleware) accept(index int) bool {
+	if !m.canAccept(index) {
+		return false
+	}
+
+	msg := m.ports[index].RetrieveOutgoing()
+	if _, found := m.portIndex[msg.Meta().Dst]; !found {
+		panic(fmt.Sprintf("link %s: destination %s is not plugged in",
+			m.comp.Name(), msg.Meta().Dst))
+	}
+
+	d := &m.comp.State.Directions[index]
+	d.InFlight = append(d.InFlight, transfer{
+		Msg:        messaging.HeldMsg{Msg: msg},
+		CyclesLeft: m.serializeCycles(msg) + m.comp.Spec().Latency,
+	})
+
+	return true
+}
+
+func (m *middleware) lastSerializeCycles(index int) int {
+	inFlight := m.comp.State.Directions[index].InFlight
+	return m.serializeCycles(inFlight[len(inFlight)-1].Msg.Msg)
+}
+
+func (m *middleware) serializeCycles(msg messaging.Msg) int {
+	bytesPerCycle := m.comp.Spec().BytesPerCycle
+	if bytesPerCycle == 0 {
+		return 0
+	}
+
+	bytes := msg.Meta().TrafficBytes
+
+	return max(1, (bytes+bytesPerCycle-1)/bytesPerCycle)
+}
+
+// deliver delivers the messages that arrived, in the order each direction
+// sent them.
+func (m *middleware) deliver() bool {
+	state := &m.comp.State
+	madeProgress := false
+
+	for i := range state.Directions {
+		d := &state.Directions[i]
+
+		for len(d.InFlight) > 0 && d.InFlight[0].CyclesLeft == 0 {
+			msg := d.InFlight[0].Msg.Msg
+			dst := m.ports[m.portIndex[msg.Meta().Dst]]
+
+			if !dst.CanDeliver() {
+				break
+			}
+
+			dst.Deliver(msg)
+			d.InFlight = d.InFlight[1:]
+			state.DeliveredMsgs++
+			state.DeliveredBytes += uint64(msg.Meta().TrafficBytes)
+			madeProgress = true
+		}
+	}
+
+	return madeProgress
+}
@@ -0,0 +1,20 @@
+// Package profiling measures how much wall-clock time each simulated
+// component costs to simulate.
+//
+// A Go CPU profile (the monitor's /api/profile) tells which functions are
+// expensive, but not which component instance runs them. A Profiler instead
+// times every Handler.Handle call, every component tick, and every
+// Middleware.Tick, and counts the ticks that made no progress, which are pure
+// overhead. Profiling is opt-in, as reading the clock around every call slows
+// the simulation down:
+//
+//	sim := simulation.MakeBuilder().WithProfiling().Build()
+//	// ... run
+//	sim.Profiler().Report().WriteText(os.Stdout, 20)
+//
+// The engine and the components report to Sites, one per handler, component
+// tick, or middleware, which the Profiler creates on demand. A Report
+// aggregates the sites per component and per middleware type. The monitor
+// shows the live report on its Wall Clock page, and the simulation records the
+// final one in the "wall_profile" table of the trace database.
+package profiling
@@ -0,0 +1,149 @@
+package profiling
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kind is what a site times.
+type Kind string
+
+// The kinds of sites.
+const (
+	// KindHandler times the engine's calls to a Handler's Handle.
+	KindHandler Kind = "handler"
+	// KindTick times a component's whole tick, across its middlewares.
+	KindTick Kind = "tick"
+	// KindMiddleware times the calls to one middleware's Tick.
+	KindMiddleware Kind = "middleware"
+)
+
+// A Site accumulates the calls to one handler, component tick, or middleware.
+// Its methods are safe to call concurrently.
+type Site struct {
+	component string
+	kind      Kind
+	typ       string
+
+	calls      atomic.Uint64
+	nanos      atomic.Int64
+	noProgress atomic.Uint64
+}
+
+// Observe records a call that took d.
+func (s *Site) Observe(d time.Duration) {
+	s.calls.Add(1)
+	s.nanos.Add(int64(d))
+}
+
+// ObserveTick records a tick that took d and whether it made progress.
+func (s *Site) ObserveTick(d time.Duration, progress bool) {
+	s.Observe(d)
+
+	if !progress {
+		s.noProgress.Add(1)
+	}
+}
+
+// Time calls f and records the time it took.
+func (s *Site) Time(f func()) {
+	start := time.Now()
+	f()
+	s.Observe(time.Since(start))
+}
+
+func (s *Site) entry() Entry {
+	return Entry{
+		Component:  s.component,
+		Kind:       s.kind,
+		Type:       s.typ,
+		Calls:      s.calls.Load(),
+		WallTime:   time.Duration(s.nanos.Load()),
+		NoProgress: s.noProgress.Load(),
+	}
+}
+
+func (s *Site) reset() {
+	s.calls.Store(0)
+	s.nanos.Store(0)
+	s.noProgress.Store(0)
+}
+
+type siteKey struct {
+	component string
+	kind      Kind
+	typ       string
+}
+
+// A Profiler owns the sites of a simulation.
+type Profiler struct {
+	mu    sync.Mutex
+	sites []*Site
+	index map[siteKey]*Site
+	start time.Time
+}
+
+// NewProfiler creates a profiler with no sites.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		index: make(map[siteKey]*Site),
+		start: time.Now(),
+	}
+}
+
+// Site returns the site of a component's handler, tick, or middleware, creating
+// it on first use. typ names the Go type of the handler or middleware, so that
+// a component with two middlewares of different types gets two sites; sites of
+// the same key are shared.
+func (p *Profiler) Site(component string, kind Kind, typ string) *Site {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := siteKey{component: component, kind: kind, typ: typ}
+	if s, found := p.index[key]; found {
+		return s
+	}
+
+	s := &Site{component: component, kind: kind, typ: typ}
+	p.sites = append(p.sites, s)
+	p.index[key] = s
+
+	return s
+}
+
+// Reset clears every site and restarts the elapsed time, e.g. after a
+// warm-up.
+func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, s := range p.sites {
+		s.reset()
+	}
+
+	p.start = time.Now()
+}
+
+// Entries returns the content of every site, in the order the sites were
+// created.
+func (p *Profiler) Entries() []Entry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entries := make([]Entry, len(p.sites))
+	for i, s := range p.sites {
+		entries[i] = s.entry()
+	}
+
+	return entries
+}
+
+// Report aggregates the sites.
+func (p *Profiler) Report() Report {
+	p.mu.Lock()
+	elapsed := time.Since(p.start)
+	p.mu.Unlock()
+
+	return NewReport(p.Entries(), elapsed)
+}
@@ -0,0 +1,80 @@
+package profiling
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitesAreSharedPerKey(t *testing.T) {
+	p := NewProfiler()
+
+	a := p.Site("GPU.L1", KindMiddleware, "cache.pipelineMW")
+	b := p.Site("GPU.L1", KindMiddleware, "cache.pipelineMW")
+	c := p.Site("GPU.L1", KindMiddleware, "cache.respondMW")
+
+	if a != b || a == c {
+		t.Fatal("expected one site per component, kind, and type")
+	}
+
+	a.ObserveTick(3*time.Millisecond, true)
+	b.ObserveTick(time.Millisecond, false)
+
+	entries := p.Entries()
+	if len(entries) != 2 || entries[0].Calls != 2 ||
+		entries[0].WallTime != 4*time.Millisecond || entries[0].NoProgress != 1 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	p.Reset()
+
+	if e := p.Entries()[0]; e.Calls != 0 || e.WallTime != 0 || e.NoProgress != 0 {
+		t.Fatalf("expected Reset to clear the sites, got %+v", e)
+	}
+}
+
+func TestNewReportAggregates(t *testing.T) {
+	entries := []Entry{
+		{Component: "L1[0]", Kind: KindHandler, Calls: 10, WallTime: 10 * time.Millisecond},
+		{Component: "L1[0]", Kind: KindTick, Calls: 10, WallTime: 9 * time.Millisecond, NoProgress: 4},
+		{Component: "L1[0]", Kind: KindMiddleware, Type: "pipelineMW", Calls: 10, WallTime: 6 * time.Millisecond, NoProgress: 8},
+		{Component: "L1[1]", Kind: KindHandler, Calls: 5, WallTime: 30 * time.Millisecond},
+		{Component: "L1[1]", Kind: KindMiddleware, Type: "pipelineMW", Calls: 5, WallTime: 20 * time.Millisecond, NoProgress: 2},
+		{Component: "L1[1]", Kind: KindMiddleware, Type: "respondMW", Calls: 5, WallTime: time.Millisecond},
+	}
+
+	r := NewReport(entries, time.Second)
+
+	if r.Profiled != 40*time.Millisecond {
+		t.Fatalf("expected 40ms in handlers, got %v", r.Profiled)
+	}
+
+	if len(r.Components) != 2 || r.Components[0].Component != "L1[1]" {
+		t.Fatalf("expected the components sorted by wall time, got %+v", r.Components)
+	}
+
+	l10 := r.Components[1]
+	if l10.Handles != 10 || l10.Ticks != 10 || l10.NoProgressFraction() != 0.4 ||
+		l10.MiddlewareTime != 6*time.Millisecond {
+		t.Fatalf("unexpected summary %+v", l10)
+	}
+
+	pipeline := r.Middlewares[0]
+	if pipeline.Type != "pipelineMW" || pipeline.Components != 2 ||
+		pipeline.Calls != 15 || pipeline.WallTime != 26*time.Millisecond ||
+		pipeline.NoProgress != 10 {
+		t.Fatalf("unexpected middleware summary %+v", pipeline)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "L1[1]") || strings.Contains(out, "L1[0]") ||
+		!strings.Contains(out, "pipelineMW") || strings.Contains(out, "respondMW") {
+		t.Fatalf("expected only the top entry of each table:\n%s", out)
+	}
+}
@@ -0,0 +1,257 @@
+package profiling
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/sarchlab/akita/v5/datarecording"
+)
+
+// TableName is the data recording table that Record writes to.
+const TableName = "wall_profile"
+
+// Entry is the content of one site.
+type Entry struct {
+	Component  string        `json:"component"`
+	Kind       Kind          `json:"kind"`
+	Type       string        `json:"type"`
+	Calls      uint64        `json:"calls"`
+	WallTime   time.Duration `json:"wall_time_ns"`
+	NoProgress uint64        `json:"no_progress"`
+}
+
+// NoProgressFraction returns the fraction of the calls that were ticks
+// without progress.
+func (e Entry) NoProgressFraction() float64 {
+	return fraction(e.NoProgress, e.Calls)
+}
+
+func fraction(n, d uint64) float64 {
+	if d == 0 {
+		return 0
+	}
+
+	return float64(n) / float64(d)
+}
+
+// ComponentSummary is the cost of one component.
+type ComponentSummary struct {
+	Component string `json:"component"`
+
+	// Handles and HandleTime are the engine's calls to the component's
+	// handler. They include the ticks of a ticking component.
+	Handles    uint64        `json:"handles"`
+	HandleTime time.Duration `json:"handle_time_ns"`
+
+	// Ticks and TickTime are the component's ticks, of which NoProgressTicks
+	// made no progress.
+	Ticks           uint64        `json:"ticks"`
+	TickTime        time.Duration `json:"tick_time_ns"`
+	NoProgressTicks uint64        `json:"no_progress_ticks"`
+
+	// MiddlewareTime is the time spent in the component's middlewares.
+	MiddlewareTime time.Duration `json:"middleware_time_ns"`
+}
+
+// WallTime returns the time the component cost: its handler's time, or its
+// tick time for a component whose ticks the engine does not call directly.
+func (c ComponentSummary) WallTime() time.Duration {
+	return max(c.HandleTime, c.TickTime)
+}
+
+// NoProgressFraction returns the fraction of the ticks that made no progress.
+func (c ComponentSummary) NoProgressFraction() float64 {
+	return fraction(c.NoProgressTicks, c.Ticks)
+}
+
+// TypeSummary is the cost of one middleware type across the components that
+// use it.
+type TypeSummary struct {
+	Type       string        `json:"type"`
+	Components int           `json:"components"`
+	Calls      uint64        `json:"calls"`
+	WallTime   time.Duration `json:"wall_time_ns"`
+	NoProgress uint64        `json:"no_progress"`
+}
+
+// NoProgressFraction returns the fraction of the ticks that made no progress.
+func (t TypeSummary) NoProgressFraction() float64 {
+	return fraction(t.NoProgress, t.Calls)
+}
+
+// Report is the aggregated content of a profiler. Components and Middlewares
+// are sorted by wall time, most expensive first.
+type Report struct {
+	// Elapsed is the wall time since the profiler was created or reset.
+	Elapsed time.Duration `json:"elapsed_ns"`
+	// Profiled is the wall time spent in handlers, i.e. simulating.
+	Profiled time.Duration `json:"profiled_ns"`
+
+	Components  []ComponentSummary `json:"components"`
+	Middlewares []TypeSummary      `json:"middlewares"`
+	Entries     []Entry            `json:"entries"`
+}
+
+// NewReport aggregates site entries per component and per middleware type.
+func NewReport(entries []Entry, elapsed time.Duration) Report {
+	r := Report{
+		Elapsed:     elapsed,
+		Components:  []ComponentSummary{},
+		Middlewares: []TypeSummary{},
+		Entries:     entries,
+	}
+
+	comps := make(map[string]*ComponentSummary)
+	types := make(map[string]*TypeSummary)
+
+	for _, e := range entries {
+		c, found := comps[e.Component]
+		if !found {
+			c = &ComponentSummary{Component: e.Component}
+			comps[e.Component] = c
+		}
+
+		switch e.Kind {
+		case KindHandler:
+			c.Handles += e.Calls
+			c.HandleTime += e.WallTime
+			r.Profiled += e.WallTime
+		case KindTick:
+			c.Ticks += e.Calls
+			c.TickTime += e.WallTime
+			c.NoProgressTicks += e.NoProgress
+		case KindMiddleware:
+			c.MiddlewareTime += e.WallTime
+
+			t, found := types[e.Type]
+			if !found {
+				t = &TypeSummary{Type: e.Type}
+				types[e.Type] = t
+			}
+
+			t.Components++
+			t.Calls += e.Calls
+			t.WallTime += e.WallTime
+			t.NoProgress += e.NoProgress
+		}
+	}
+
+	for _, c := range comps {
+		r.Components = append(r.Components, *c)
+	}
+
+	for _, t := range types {
+		r.Middlewares = append(r.Middlewares, *t)
+	}
+
+	sort.Slice(r.Components, func(i, j int) bool {
+		a, b := r.Components[i], r.Components[j]
+		if a.WallTime() != b.WallTime() {
+			return a.WallTime() > b.WallTime()
+		}
+
+		return a.Component < b.Component
+	})
+
+	sort.Slice(r.Middlewares, func(i, j int) bool {
+		a, b := r.Middlewares[i], r.Middlewares[j]
+		if a.WallTime != b.WallTime {
+			return a.WallTime > b.WallTime
+		}
+
+		return a.Type < b.Type
+	})
+
+	return r
+}
+
+// WriteText writes the top most expensive components and middleware types as
+// tables, or all of them if top is not positive.
+func (r Report) WriteText(w io.Writer, top int) error {
+	pw := &printer{w: w}
+
+	pw.printf("Wall-clock profile: %v elapsed, %v in handlers\n\n",
+		r.Elapsed.Round(time.Millisecond), r.Profiled.Round(time.Millisecond))
+
+	pw.printf("%-40s %12s %8s %12s %12s %10s\n",
+		"Component", "Wall time", "Share", "Handles", "Ticks", "No-prog")
+
+	for _, c := range firstN(r.Components, top) {
+		pw.printf("%-40s %12v %7.1f%% %12d %12d %9.1f%%\n",
+			c.Component, c.WallTime().Round(time.Microsecond),
+			100*r.share(c.WallTime()), c.Handles, c.Ticks,
+			100*c.NoProgressFraction())
+	}
+
+	pw.printf("\n%-40s %12s %8s %12s %12s %10s\n",
+		"Middleware type", "Wall time", "Share", "Components", "Ticks", "No-prog")
+
+	for _, t := range firstN(r.Middlewares, top) {
+		pw.printf("%-40s %12v %7.1f%% %12d %12d %9.1f%%\n",
+			t.Type, t.WallTime.Round(time.Microsecond),
+			100*r.share(t.WallTime), t.Components, t.Calls,
+			100*t.NoProgressFraction())
+	}
+
+	return pw.err
+}
+
+func (r Report) share(d time.Duration) float64 {
+	if r.Profiled == 0 {
+		return 0
+	}
+
+	return float64(d) / float64(r.Profiled)
+}
+
+func firstN[T any](list []T, n int) []T {
+	if n <= 0 || n >= len(list) {
+		return list
+	}
+
+	return slices.Clone(list[:n])
+}
+
+type printer struct {
+	w   io.Writer
+	err error
+}
+
+func (p *printer) printf(format string, args ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+// entryRow is one row of the wall_profile table.
+type entryRow struct {
+	Component  string
+	Kind       string
+	Type       string
+	Calls      uint64
+	WallTimeNS int64
+	NoProgress uint64
+}
+
+// Record writes every entry of the report to the "wall_profile" table.
+func (r Report) Record(recorder datarecording.DataRecorder) {
+	if !slices.Contains(recorder.ListTables(), TableName) {
+		recorder.CreateTable(TableName, entryRow{})
+	}
+
+	for _, e := range r.Entries {
+		recorder.InsertData(TableName, entryRow{
+			Component:  e.Component,
+			Kind:       string(e.Kind),
+			Type:       e.Type,
+			Calls:      e.Calls,
+			WallTimeNS: int64(e.WallTime),
+			NoProgress: e.NoProgress,
+		})
+	}
+
+	recorder.Flush()
+}
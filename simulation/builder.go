@@ -6,8 +6,12 @@ import (
 
 	"github.com/rs/xid"
 	"github.com/sarchlab/akita/v5/datarecording"
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/messaging/txcheck"
 
 	"github.com/sarchlab/akita/v5/monitoring2"
+	"github.com/sarchlab/akita/v5/profiling"
 	"github.com/sarchlab/akita/v5/timing"
 	"github.com/sarchlab/akita/v5/tracing"
 )
@@ -21,6 +25,10 @@ type Builder struct {
 	visTracingOnStart bool
 	recordSource      bool
 	sourceFSes        map[string]fs.FS
+	livelockWindow    timing.VTimeInPicoSec
+	protocolChecking  bool
+	txRules           []*txcheck.Rule
+	profiling         bool
 }
 
 // MakeBuilder creates a new builder.
@@ -89,6 +97,44 @@ func (b Builder) WithoutSourceRecording() Builder {
 	return b
 }
 
+// WithLivelockDetection makes Run stop with a livelock HangError when events
+// keep being handled but no component tick or processed event makes progress
+// for the given simulated time while messages are waiting. It hooks every
+// component's ticks and processed events, so it is off by default.
+func (b Builder) WithLivelockDetection(window timing.VTimeInPicoSec) Builder {
+	b.livelockWindow = window
+	return b
+}
+
+// WithProtocolChecking attaches a messaging.ProtocolChecker to every
+// registered port and connection, so traffic that breaks the ports' declared
+// protocol roles panics with a *messaging.ProtocolViolation. It adds a hook
+// to every port, so it is off by default.
+func (b Builder) WithProtocolChecking() Builder {
+	b.protocolChecking = true
+	return b
+}
+
+// WithTransactionChecking attaches a txcheck.Checker enforcing the given
+// rules (e.g. memprotocol.TransactionRule) to every registered port. The
+// checker's report is printed when the simulation terminates and is available
+// at any time through Simulation.TransactionChecker.
+func (b Builder) WithTransactionChecking(rules ...*txcheck.Rule) Builder {
+	b.txRules = append(append([]*txcheck.Rule(nil), b.txRules...), rules...)
+	return b
+}
+
+// WithProfiling measures the wall-clock time of every handler, component
+// tick, and middleware tick, and counts the ticks that make no progress (see
+// package profiling). The report is available through Simulation.Profiler,
+// on the monitor's Wall Clock page, and in the "wall_profile" table of the
+// trace database. Reading the clock around every call slows the simulation
+// down, so it is off by default.
+func (b Builder) WithProfiling() Builder {
+	b.profiling = true
+	return b
+}
+
 func (b Builder) parametersMustBeValid() {
 	if !b.monitorOn && b.monitorPort != 0 {
 		panic("monitor port cannot be set when monitoring is disabled")
@@ -103,7 +149,10 @@ func (b Builder) Build() *Simulation {
 
 	b.createDataRecorder(s)
 	b.createEngine(s)
+	b.createLivelockDetector(s)
+	b.createProfiler(s)
 	b.createIDGenerator(s)
+	b.createTransactionChecker(s)
 	b.createMetaRecorder(s)
 	b.createSourceRecorder(s)
 	b.createTopologyRecorder(s)
@@ -138,13 +187,27 @@ func (b Builder) createTopologyRecorder(s *Simulation) {
 }
 
 func (b Builder) createSimulation() *Simulation {
+	var checker hooking.Hook
+	if b.protocolChecking {
+		checker = messaging.NewProtocolChecker()
+	}
+
 	return &Simulation{
-		id:            xid.New().String(),
-		compNameIndex: make(map[string]int),
-		portNameIndex: make(map[string]int),
-		connNameIndex: make(map[string]int),
-		entityByName:  make(map[string]int),
+		id:              xid.New().String(),
+		protocolChecker: checker,
+		compNameIndex:   make(map[string]int),
+		portNameIndex:   make(map[string]int),
+		connNameIndex:   make(map[string]int),
+		entityByName:    make(map[string]int),
+	}
+}
+
+func (b Builder) createTransactionChecker(s *Simulation) {
+	if len(b.txRules) == 0 {
+		return
 	}
+
+	s.txChecker = txcheck.NewChecker(s.engine, b.txRules...)
 }
 
 func (b Builder) createDataRecorder(s *Simulation) {
@@ -168,6 +231,26 @@ func (b Builder) createEngine(s *Simulation) {
 	}
 }
 
+// createLivelockDetector hooks the engine before it runs; components are
+// hooked as they are registered.
+func (b Builder) createLivelockDetector(s *Simulation) {
+	if b.livelockWindow == 0 {
+		return
+	}
+
+	s.livelock = &livelockDetector{sim: s, window: b.livelockWindow}
+	s.engine.AcceptHook(s.livelock)
+}
+
+func (b Builder) createProfiler(s *Simulation) {
+	if !b.profiling {
+		return
+	}
+
+	s.profiler = profiling.NewProfiler()
+	s.engine.(timing.Profilable).EnableProfiling(s.profiler)
+}
+
 // createIDGenerator registers the process-wide ID generator as an entity so its
 // counter is captured in the state snapshot.
 func (b Builder) createIDGenerator(s *Simulation) {
@@ -199,6 +282,13 @@ func (b Builder) createServer(s *Simulation) {
 	monitor.RegisterEngine(s.engine)
 	monitor.RegisterVisTracer(s.visTracer)
 	monitor.SetTraceDBPath(s.outputPath + ".sqlite3")
+	monitor.RegisterHangAnalyzer(func() any { return s.waitForReport() })
+	monitor.RegisterStatsSource(s.statsJSON)
+
+	if s.profiler != nil {
+		monitor.RegisterProfileSource(func() any { return s.profiler.Report() })
+	}
+
 	monitor.StartServer()
 
 	s.monitor = monitor
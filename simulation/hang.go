@@ -0,0 +1,373 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// HangKind classifies a hang detected by Run.
+type HangKind string
+
+const (
+	// HangDeadlock means the engine ran out of events while messages were
+	// still waiting in port buffers, and the blocked ports form a cycle.
+	HangDeadlock HangKind = "deadlock"
+
+	// HangStall means the engine ran out of events with messages still in
+	// port buffers but no wait-for cycle — typically a component that stopped
+	// ticking while it still had work.
+	HangStall HangKind = "stall"
+
+	// HangLivelock means events kept being handled but no component made
+	// progress for the livelock window, while messages were waiting.
+	HangLivelock HangKind = "livelock"
+)
+
+// BlockedPort is a port that still holds messages when a hang is detected.
+type BlockedPort struct {
+	Port         string `json:"port"`
+	Component    string `json:"component"`
+	Connection   string `json:"connection,omitempty"`
+	Incoming     int    `json:"incoming"`
+	Outgoing     int    `json:"outgoing"`
+	IncomingFull bool   `json:"incoming_full"`
+	OutgoingFull bool   `json:"outgoing_full"`
+}
+
+// WaitEdge says that component From cannot send from FromPort because ToPort,
+// owned by component To and on the same connection, has a full incoming
+// buffer.
+type WaitEdge struct {
+	From       string `json:"from"`
+	FromPort   string `json:"from_port"`
+	Connection string `json:"connection"`
+	ToPort     string `json:"to_port"`
+	To         string `json:"to"`
+}
+
+// HangError is returned by Run when the simulation hangs. Cycle is the
+// wait-for cycle that causes a deadlock, starting and ending at the same
+// component; it is empty when no cycle was found. Blocked lists every port
+// still holding messages.
+type HangError struct {
+	Kind    HangKind              `json:"kind"`
+	Time    timing.VTimeInPicoSec `json:"time"`
+	Cycle   []WaitEdge            `json:"cycle"`
+	Blocked []BlockedPort         `json:"blocked"`
+}
+
+func (e *HangError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "simulation %s at %d ps", e.Kind, e.Time)
+
+	if len(e.Cycle) > 0 {
+		b.WriteString(": ")
+
+		for i, edge := range e.Cycle {
+			if i == 0 {
+				b.WriteString(edge.From)
+			}
+
+			fmt.Fprintf(&b, " -[%s full]-> %s", edge.ToPort, edge.To)
+		}
+	}
+
+	fmt.Fprintf(&b, " (%d blocked ports)", len(e.Blocked))
+
+	return b.String()
+}
+
+// blockablePort is the part of a messaging port the hang analysis reads:
+// whether its buffers can take another message.
+type blockablePort interface {
+	CanSend() bool
+	CanDeliver() bool
+}
+
+// WaitForGraph returns the ports that hold messages and the wait-for edges
+// between their components, for the simulation's current state. It should be
+// called with the engine stopped or paused.
+func (s *Simulation) WaitForGraph() ([]BlockedPort, []WaitEdge) {
+	blocked := []BlockedPort{}
+	byConn := map[string][]BlockedPort{}
+
+	for _, p := range s.ports {
+		bp := BlockedPort{
+			Port:     p.Name(),
+			Incoming: p.NumIncoming(),
+			Outgoing: p.NumOutgoing(),
+		}
+		bp.Component, _ = reflectName(p, "Component")
+		bp.Connection, _ = reflectName(p, "Connection")
+
+		if b, ok := p.(blockablePort); ok {
+			bp.IncomingFull = !b.CanDeliver()
+			bp.OutgoingFull = !b.CanSend()
+		}
+
+		if bp.Connection != "" {
+			byConn[bp.Connection] = append(byConn[bp.Connection], bp)
+		}
+
+		if bp.Incoming > 0 || bp.Outgoing > 0 {
+			blocked = append(blocked, bp)
+		}
+	}
+
+	// A message stuck in an outgoing buffer waits for some port on the same
+	// connection to accept it. Without decoding the destination, every full
+	// peer is a candidate.
+	edges := []WaitEdge{}
+
+	for _, from := range blocked {
+		if from.Outgoing == 0 || from.Connection == "" {
+			continue
+		}
+
+		for _, to := range byConn[from.Connection] {
+			if to.Port == from.Port || !to.IncomingFull {
+				continue
+			}
+
+			edges = append(edges, WaitEdge{
+				From:       from.Component,
+				FromPort:   from.Port,
+				Connection: from.Connection,
+				ToPort:     to.Port,
+				To:         to.Component,
+			})
+		}
+	}
+
+	return blocked, edges
+}
+
+// findWaitCycle returns one cycle in the wait-for graph, or nil. Components
+// are visited in name order so the reported cycle is deterministic.
+func findWaitCycle(edges []WaitEdge) []WaitEdge {
+	out := map[string][]WaitEdge{}
+	for _, e := range edges {
+		out[e.From] = append(out[e.From], e)
+	}
+
+	nodes := make([]string, 0, len(out))
+	for n := range out {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+
+	state := map[string]int{}
+	pathNodes := []string{}
+	pathEdges := []WaitEdge{} // pathEdges[i] leads from pathNodes[i]
+
+	var visit func(n string) []WaitEdge
+	visit = func(n string) []WaitEdge {
+		state[n] = onStack
+		pathNodes = append(pathNodes, n)
+
+		for _, e := range out[n] {
+			switch state[e.To] {
+			case onStack:
+				k := len(pathNodes) - 1
+				for pathNodes[k] != e.To {
+					k--
+				}
+
+				return append(append([]WaitEdge{}, pathEdges[k:]...), e)
+			case unvisited:
+				pathEdges = append(pathEdges, e)
+				if cycle := visit(e.To); cycle != nil {
+					return cycle
+				}
+				pathEdges = pathEdges[:len(pathEdges)-1]
+			}
+		}
+
+		pathNodes = pathNodes[:len(pathNodes)-1]
+		state[n] = done
+
+		return nil
+	}
+
+	for _, n := range nodes {
+		if state[n] == unvisited {
+			if cycle := visit(n); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
+
+// WaitForReport is a snapshot of the wait-for graph, as served to the monitor.
+type WaitForReport struct {
+	Time    timing.VTimeInPicoSec `json:"time"`
+	Blocked []BlockedPort         `json:"blocked"`
+	Edges   []WaitEdge            `json:"edges"`
+	Cycle   []WaitEdge            `json:"cycle"`
+}
+
+func (s *Simulation) waitForReport() WaitForReport {
+	blocked, edges := s.WaitForGraph()
+
+	cycle := findWaitCycle(edges)
+	if cycle == nil {
+		cycle = []WaitEdge{}
+	}
+
+	return WaitForReport{
+		Time:    s.engine.CurrentTime(),
+		Blocked: blocked,
+		Edges:   edges,
+		Cycle:   cycle,
+	}
+}
+
+// analyzeHang builds a HangError for the current state, or returns nil when no
+// port holds a message.
+func (s *Simulation) analyzeHang(kind HangKind) *HangError {
+	blocked, edges := s.WaitForGraph()
+	if len(blocked) == 0 {
+		return nil
+	}
+
+	cycle := findWaitCycle(edges)
+	if kind == HangStall && cycle != nil {
+		kind = HangDeadlock
+	}
+
+	if cycle == nil {
+		cycle = []WaitEdge{}
+	}
+
+	return &HangError{
+		Kind:    kind,
+		Time:    s.engine.CurrentTime(),
+		Cycle:   cycle,
+		Blocked: blocked,
+	}
+}
+
+// Run runs the engine until it has no more events. If messages are still
+// waiting in port buffers when it stops, Run returns a *HangError describing
+// the blocked ports and the wait-for cycle, if any. With livelock detection
+// enabled (see Builder.WithLivelockDetection), Run also returns a
+// *HangError when no component makes progress for the configured window. The
+// engine is stopped with its events still queued, so the monitor can still
+// inspect the simulation.
+func (s *Simulation) Run() error {
+	if s.livelock != nil {
+		s.livelock.reset()
+	}
+
+	if err := s.engine.Run(); err != nil {
+		return err
+	}
+
+	if s.livelock != nil {
+		if hang := s.livelock.detected(); hang != nil {
+			s.reportHang(hang)
+			return hang
+		}
+	}
+
+	if hang := s.analyzeHang(HangStall); hang != nil {
+		s.reportHang(hang)
+		return hang
+	}
+
+	return nil
+}
+
+func (s *Simulation) reportHang(hang *HangError) {
+	if s.monitor != nil {
+		s.monitor.ReportHang(hang)
+	}
+}
+
+// livelockDetector watches component progress. It is an engine hook,
+// evaluated after each event, and a tick and process hook on every hookable
+// component. The builder
+// installs it once, before the engine runs; each Run resets it.
+type livelockDetector struct {
+	sim    *Simulation
+	window timing.VTimeInPicoSec
+
+	mu           sync.Mutex
+	lastProgress timing.VTimeInPicoSec
+	hang         *HangError
+}
+
+// watch hooks a component's ticks, or its processed events if it is event
+// driven.
+func (d *livelockDetector) watch(c Component) {
+	if h, ok := c.(hooking.Hookable); ok {
+		h.AcceptHook(d)
+	}
+}
+
+// reset starts a new window and forgets the livelock of an earlier run.
+func (d *livelockDetector) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastProgress = d.sim.engine.CurrentTime()
+	d.hang = nil
+}
+
+// detected returns the livelock that stopped the last run, if any.
+func (d *livelockDetector) detected() *HangError {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.hang
+}
+
+// Func implements hooking.Hook.
+func (d *livelockDetector) Func(ctx hooking.HookCtx) {
+	switch ctx.Pos {
+	case modeling.HookPosTick, modeling.HookPosProcess:
+		if progress, _ := ctx.Detail.(bool); progress {
+			d.mu.Lock()
+			d.lastProgress = d.sim.engine.CurrentTime()
+			d.mu.Unlock()
+		}
+	case timing.HookPosAfterEvent:
+		d.afterEvent()
+	}
+}
+
+func (d *livelockDetector) afterEvent() {
+	now := d.sim.engine.CurrentTime()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.hang != nil || now-d.lastProgress < d.window {
+		return
+	}
+
+	hang := d.sim.analyzeHang(HangLivelock)
+	if hang == nil {
+		// Nothing is waiting, so the lack of progress is idleness.
+		d.lastProgress = now
+		return
+	}
+
+	d.hang = hang
+	d.sim.engine.(timing.Stoppable).Stop()
+}
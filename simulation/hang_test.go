@@ -0,0 +1,251 @@
+package simulation
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// sinkComponent owns one port and never retrieves what arrives, so its
+// incoming buffer fills up and stays full.
+type sinkComponent struct {
+	hooking.HookableBase
+	*messaging.PortOwnerBase
+
+	name string
+	port messaging.Port
+}
+
+func newSinkComponent(name string) *sinkComponent {
+	c := &sinkComponent{
+		PortOwnerBase: messaging.NewPortOwnerBase(),
+		name:          name,
+	}
+	c.port = messaging.NewPort(c, 1, 1, name+".Port")
+	c.DeclarePort("Port")
+	c.AssignPort("Port", c.port)
+
+	return c
+}
+
+func (c *sinkComponent) Name() string                  { return c.name }
+func (c *sinkComponent) NotifyRecv(messaging.Port)     {}
+func (c *sinkComponent) NotifyPortFree(messaging.Port) {}
+
+type hangTestMsg struct {
+	messaging.MsgMeta
+}
+
+func (m *hangTestMsg) Meta() messaging.MsgMeta { return m.MsgMeta }
+
+func (c *sinkComponent) send(to *sinkComponent) {
+	c.port.Send(&hangTestMsg{MsgMeta: messaging.MsgMeta{
+		ID:  timing.GetIDGenerator().Generate(),
+		Src: c.port.AsRemote(),
+		Dst: to.port.AsRemote(),
+	}})
+}
+
+// buildMutualSendSim connects two sink components and has each send two
+// messages to the other: the first fills the peer's incoming buffer, the
+// second is stuck in the sender's outgoing buffer, so each waits on the other.
+func buildMutualSendSim(b Builder) (*Simulation, func()) {
+	sim := b.WithoutMonitoring().Build()
+
+	a := newSinkComponent("A")
+	c := newSinkComponent("B")
+	sim.RegisterComponent(a)
+	sim.RegisterComponent(c)
+	sim.RegisterPort(a.port)
+	sim.RegisterPort(c.port)
+
+	conn := directconnection.MakeBuilder().WithRegistrar(sim).Build("Conn")
+	conn.PlugIn(a.port)
+	conn.PlugIn(c.port)
+
+	deadlock := func() {
+		a.send(c)
+		c.send(a)
+
+		// Let the connection deliver the first pair before queuing the
+		// second.
+		if err := sim.engine.Run(); err != nil {
+			panic(err)
+		}
+
+		a.send(c)
+		c.send(a)
+	}
+
+	return sim, deadlock
+}
+
+func cleanupSim(sim *Simulation) {
+	sim.Terminate()
+	os.Remove("akita_sim_" + sim.ID() + ".sqlite3")
+}
+
+func TestRunReportsDeadlockCycle(t *testing.T) {
+	sim, deadlock := buildMutualSendSim(MakeBuilder())
+	defer cleanupSim(sim)
+
+	deadlock()
+
+	err := sim.Run()
+
+	var hang *HangError
+	if !errors.As(err, &hang) {
+		t.Fatalf("expected a HangError, got %v", err)
+	}
+
+	if hang.Kind != HangDeadlock {
+		t.Fatalf("expected a deadlock, got %s", hang.Kind)
+	}
+
+	if len(hang.Cycle) != 2 ||
+		hang.Cycle[0].From != "A" || hang.Cycle[0].To != "B" ||
+		hang.Cycle[1].From != "B" || hang.Cycle[1].To != "A" {
+		t.Fatalf("unexpected cycle %+v", hang.Cycle)
+	}
+
+	if hang.Cycle[0].FromPort != "A.Port" || hang.Cycle[0].ToPort != "B.Port" ||
+		hang.Cycle[0].Connection != "Conn" {
+		t.Fatalf("unexpected edge %+v", hang.Cycle[0])
+	}
+
+	if len(hang.Blocked) != 2 || !hang.Blocked[0].IncomingFull {
+		t.Fatalf("unexpected blocked ports %+v", hang.Blocked)
+	}
+}
+
+func TestRunReturnsNilWhenNothingIsWaiting(t *testing.T) {
+	sim, _ := buildMutualSendSim(MakeBuilder())
+	defer cleanupSim(sim)
+
+	if err := sim.Run(); err != nil {
+		t.Fatalf("expected a clean run, got %v", err)
+	}
+}
+
+// spinTicker keeps its component ticking without ever making progress, the
+// shape of a retry loop that never succeeds.
+type spinTicker struct {
+	comp *modeling.TickingComponent
+}
+
+func (t *spinTicker) Tick() bool {
+	t.comp.TickLater()
+	return false
+}
+
+func TestRunReportsLivelock(t *testing.T) {
+	sim, deadlock := buildMutualSendSim(
+		MakeBuilder().WithLivelockDetection(100 * timing.VTimeInPicoSec(1000)))
+	defer cleanupSim(sim)
+
+	deadlock()
+
+	ticker := &spinTicker{}
+	ticker.comp = modeling.NewTickingComponent(
+		"Spinner", sim.GetEngine(), 1*timing.GHz, ticker)
+	sim.RegisterComponent(ticker.comp)
+	ticker.comp.TickLater()
+
+	err := sim.Run()
+
+	var hang *HangError
+	if !errors.As(err, &hang) || hang.Kind != HangLivelock {
+		t.Fatalf("expected a livelock, got %v", err)
+	}
+
+	if hang.Time < 100_000 || len(hang.Cycle) != 2 {
+		t.Fatalf("unexpected livelock report %+v", hang)
+	}
+
+	// The engine stopped rather than blocking in a pause, so the simulation
+	// can run on and detect the livelock again one window later.
+	err = sim.Run()
+
+	var again *HangError
+	if !errors.As(err, &again) || again.Time < hang.Time+100_000 {
+		t.Fatalf("expected a second livelock a window later, got %v", err)
+	}
+}
+
+type stepState struct {
+	Steps int `json:"steps"`
+}
+
+// stepProcessor works through a number of steps, one per nanosecond, the
+// shape of an event-driven component that makes progress without ticking.
+type stepProcessor struct{}
+
+func (stepProcessor) Process(
+	c *modeling.EventDrivenComponent[wakeSpec, stepState, modeling.None],
+	now timing.VTimeInPicoSec,
+) bool {
+	if c.State.Steps == 0 {
+		return false
+	}
+
+	c.State.Steps--
+	c.ScheduleWakeAt(now + 1000)
+
+	return true
+}
+
+func TestRunCountsEventDrivenProgress(t *testing.T) {
+	sim, deadlock := buildMutualSendSim(
+		MakeBuilder().WithLivelockDetection(100 * timing.VTimeInPicoSec(1000)))
+	defer cleanupSim(sim)
+
+	deadlock()
+
+	worker := modeling.NewEventDrivenBuilder[wakeSpec, stepState, modeling.None]().
+		WithEngine(sim.GetEngine()).
+		WithProcessor(stepProcessor{}).
+		Build("Worker")
+	worker.State.Steps = 1000
+	sim.RegisterComponent(worker)
+	worker.ScheduleWakeNow()
+
+	err := sim.Run()
+
+	// The worker keeps making progress for ten windows, so the waiting
+	// messages are reported only as the deadlock left once it finishes.
+	var hang *HangError
+	if !errors.As(err, &hang) || hang.Kind != HangDeadlock {
+		t.Fatalf("expected a deadlock, got %v", err)
+	}
+
+	if worker.State.Steps != 0 {
+		t.Fatalf("expected the worker to finish, %d steps left",
+			worker.State.Steps)
+	}
+}
+
+func TestFindWaitCycleReturnsOnlyTheCycle(t *testing.T) {
+	edges := []WaitEdge{
+		{From: "A", To: "B"},
+		{From: "B", To: "C"},
+		{From: "C", To: "D"},
+		{From: "D", To: "B"},
+	}
+
+	cycle := findWaitCycle(edges)
+	if len(cycle) != 3 ||
+		cycle[0].From != "B" || cycle[1].From != "C" || cycle[2].From != "D" ||
+		cycle[2].To != "B" {
+		t.Fatalf("unexpected cycle %+v", cycle)
+	}
+
+	if findWaitCycle([]WaitEdge{{From: "A", To: "B"}}) != nil {
+		t.Fatal("expected no cycle in an acyclic graph")
+	}
+}
@@ -0,0 +1,130 @@
+package simulation
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/datarecording"
+	"github.com/sarchlab/akita/v5/stats"
+	"github.com/sarchlab/akita/v5/timing"
+	"github.com/sarchlab/akita/v5/tracing"
+)
+
+// phaseDriver runs a function at a scheduled time, standing in for workload
+// code that marks phases.
+type phaseDriver struct{}
+
+type phaseDriverEvent struct {
+	timing.EventBase
+	fn func()
+}
+
+func (phaseDriver) Handle(e timing.Event) error {
+	e.(phaseDriverEvent).fn()
+	return nil
+}
+
+// statsRow mirrors the table structure stats.Registry.Record writes.
+type statsRow struct {
+	Region string  `json:"region"`
+	Time   float64 `json:"time"`
+	Name   string  `json:"name"`
+	Kind   string  `json:"kind"`
+	Value  float64 `json:"value"`
+}
+
+func TestPhasesSegmentStatsAndTracers(t *testing.T) {
+	sim := MakeBuilder().WithoutMonitoring().Build()
+	dbFile := "akita_sim_" + sim.ID() + ".sqlite3"
+	defer os.Remove(dbFile)
+
+	count := sim.Stats().NewCounter("Driver.Count", "work done")
+	all := tracing.NewTotalTimeTracer(func(tracing.TaskStart) bool { return true })
+	roi := tracing.NewTotalTimeTracer(tracing.InPhase(sim, "roi"))
+	sim.AddPhaseListener(all)
+
+	engine := sim.GetEngine()
+	engine.(timing.HandlerRegistrar).RegisterHandler("PhaseDriver", phaseDriver{})
+	at := func(time timing.VTimeInPicoSec, fn func()) {
+		engine.Schedule(phaseDriverEvent{
+			EventBase: timing.MakeEventBase(time, "PhaseDriver"),
+			fn:        fn,
+		})
+	}
+
+	at(10, func() {
+		sim.BeginPhase("warmup")
+		count.Add(5)
+		all.StartTask(tracing.TaskStart{ID: 1, Time: 10})
+		roi.StartTask(tracing.TaskStart{ID: 1, Time: 10})
+	})
+	at(20, func() {
+		sim.BeginPhase("roi")
+		count.Add(3)
+		roi.StartTask(tracing.TaskStart{ID: 2, Time: 20})
+	})
+	var allInROI timing.VTimeInPicoSec
+	at(30, func() {
+		for _, tracer := range []*tracing.TotalTimeTracer{all, roi} {
+			tracer.EndTask(tracing.TaskEnd{ID: 1, Time: 30})
+			tracer.EndTask(tracing.TaskEnd{ID: 2, Time: 30})
+		}
+
+		allInROI = all.TotalTime()
+		sim.EndPhase()
+	})
+
+	if err := sim.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sim.Phase() != "" {
+		t.Fatalf("expected no phase after EndPhase, got %q", sim.Phase())
+	}
+
+	if allInROI != 10 || all.TotalTime() != 0 {
+		t.Fatalf("expected the listener to count 10 ps of the roi and "+
+			"restart after it, got %d and %d", allInROI, all.TotalTime())
+	}
+
+	if roi.TotalTime() != 10 {
+		t.Fatalf("expected the filter to only accept roi tasks, got %d ps",
+			roi.TotalTime())
+	}
+
+	sim.Terminate()
+
+	reader := datarecording.NewReader(dbFile)
+	defer reader.Close()
+	reader.MapTable(phaseTableName, phaseEntry{})
+	reader.MapTable(stats.TableName, statsRow{})
+
+	phases, _, err := reader.Query(context.Background(), phaseTableName,
+		datarecording.QueryParams{OrderBy: "StartTime"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(phases) != 2 ||
+		*phases[0].(*phaseEntry) != (phaseEntry{Name: "warmup", StartTime: 10, EndTime: 20}) ||
+		*phases[1].(*phaseEntry) != (phaseEntry{Name: "roi", StartTime: 20, EndTime: 30}) {
+		t.Fatalf("unexpected phases %v", phases)
+	}
+
+	rows, _, err := reader.Query(context.Background(), stats.TableName,
+		datarecording.QueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]float64{}
+	for _, r := range rows {
+		row := r.(*statsRow)
+		values[row.Region] = row.Value
+	}
+
+	if values["warmup"] != 5 || values["roi"] != 3 || values["end"] != 0 {
+		t.Fatalf("expected the stats segmented by phase, got %v", values)
+	}
+}
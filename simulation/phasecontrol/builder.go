@@ -0,0 +1,62 @@
+package phasecontrol
+
+import (
+	"github.com/sarchlab/akita/v5/modeling"
+)
+
+// Builder builds phasecontrol components. The component declares its
+// "Control" port; the port instance is supplied externally after Build with
+// AssignPort.
+type Builder struct {
+	registrar modeling.Registrar
+	target    Target
+}
+
+// MakeBuilder creates a new Builder.
+func MakeBuilder() Builder {
+	return Builder{}
+}
+
+// WithRegistrar wires the builder to a registrar, which provides the engine
+// and registers the built component.
+func (b Builder) WithRegistrar(reg modeling.Registrar) Builder {
+	b.registrar = reg
+	return b
+}
+
+// WithTarget sets the target whose phases the component begins. It defaults
+// to the registrar when the registrar is a Target, as a simulation is.
+func (b Builder) WithTarget(target Target) Builder {
+	b.target = target
+	return b
+}
+
+// Build creates a new phasecontrol component with the given name.
+func (b Builder) Build(name string) *Comp {
+	if b.registrar == nil {
+		panic("phasecontrol: WithRegistrar is required")
+	}
+
+	target := b.target
+	if target == nil {
+		registrarTarget, ok := b.registrar.(Target)
+		if !ok {
+			panic("phasecontrol: WithTarget is required when the " +
+				"registrar cannot begin phases")
+		}
+
+		target = registrarTarget
+	}
+
+	comp := modeling.NewEventDrivenBuilder[Spec, State, Resources]().
+		WithEngine(b.registrar.GetEngine()).
+		WithResources(Resources{Target: target}).
+		WithProcessor(&processor{}).
+		Build(name)
+
+	comp.DeclarePort("Control", Responder)
+
+	b.registrar.RegisterComponent(comp)
+
+	return comp
+}
@@ -0,0 +1,34 @@
+package phasecontrol
+
+import (
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+)
+
+// A Target begins phases. simulation.Simulation is one.
+type Target interface {
+	BeginPhase(name string)
+}
+
+// Spec is the immutable configuration for a phasecontrol component.
+type Spec struct{}
+
+// pendingRsp is a response waiting for room in the Control port.
+type pendingRsp struct {
+	Dst   messaging.RemotePort
+	RspTo uint64
+	Phase string
+}
+
+// State is the mutable runtime state for a phasecontrol component.
+type State struct {
+	PendingRsps []pendingRsp
+}
+
+// Resources holds the target whose phases the component begins.
+type Resources struct {
+	Target Target
+}
+
+// Comp is the phasecontrol component built on EventDrivenComponent.
+type Comp = modeling.EventDrivenComponent[Spec, State, Resources]
@@ -0,0 +1,14 @@
+// Package phasecontrol lets workload code mark program phases with messages.
+//
+// A phasecontrol component owns a "Control" port that speaks the phase
+// protocol's responder role. When it receives a Req, it begins the requested
+// phase on its target, typically the simulation (see
+// simulation.Simulation.BeginPhase), and answers with a Rsp. A driver model that
+// knows where the warm-up ends sends Req{Phase: "roi"} the same way it sends
+// memcontrolprotocol requests to memory agents:
+//
+//	ctrl := phasecontrol.MakeBuilder().WithRegistrar(sim).Build("PhaseControl")
+//	// assign the "Control" port and connect it to the driver
+//
+// Go code that drives the simulation directly calls sim.BeginPhase instead.
+package phasecontrol
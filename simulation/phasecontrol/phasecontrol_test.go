@@ -0,0 +1,56 @@
+package phasecontrol_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/simulation"
+	"github.com/sarchlab/akita/v5/simulation/phasecontrol"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+func TestReqBeginsPhase(t *testing.T) {
+	sim := simulation.MakeBuilder().WithoutMonitoring().Build()
+	defer func() {
+		sim.Terminate()
+		os.Remove("akita_sim_" + sim.ID() + ".sqlite3")
+	}()
+
+	ctrl := phasecontrol.MakeBuilder().WithRegistrar(sim).Build("PhaseControl")
+	ctrl.AssignPort("Control", modeling.MakePortBuilder().
+		WithRegistrar(sim).
+		WithComponent(ctrl).
+		WithSpec(modeling.PortSpec{BufSize: 4}).
+		Build("Control"))
+
+	driver := messaging.NewPort(nil, 4, 4, "Driver.Phase")
+	conn := directconnection.MakeBuilder().WithRegistrar(sim).Build("Conn")
+	conn.PlugIn(ctrl.GetPortByName("Control"))
+	conn.PlugIn(driver)
+
+	req := phasecontrol.Req{
+		MsgMeta: messaging.MsgMeta{
+			ID:  timing.GetIDGenerator().Generate(),
+			Src: driver.AsRemote(),
+			Dst: ctrl.GetPortByName("Control").AsRemote(),
+		},
+		Phase: "roi",
+	}
+	driver.Send(req)
+
+	if err := sim.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sim.Phase() != "roi" {
+		t.Fatalf("expected phase roi, got %q", sim.Phase())
+	}
+
+	rsp, ok := driver.RetrieveIncoming().(phasecontrol.Rsp)
+	if !ok || rsp.RspTo != req.ID || rsp.Phase != "roi" {
+		t.Fatalf("expected a response to the request, got %+v", rsp)
+	}
+}
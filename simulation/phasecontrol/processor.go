@@ -0,0 +1,76 @@
+package phasecontrol
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// processor implements modeling.EventProcessor[Spec, State, Resources].
+type processor struct{}
+
+func controlPort(comp *Comp) messaging.Port {
+	return comp.GetPortByName("Control")
+}
+
+// Process begins the phases requested on the Control port and responds.
+func (p *processor) Process(comp *Comp, _ timing.VTimeInPicoSec) bool {
+	progress := p.sendResponses(comp)
+	progress = p.processRequests(comp) || progress
+	progress = p.sendResponses(comp) || progress
+
+	return progress
+}
+
+func (p *processor) processRequests(comp *Comp) bool {
+	progress := false
+
+	for {
+		msg := controlPort(comp).RetrieveIncoming()
+		if msg == nil {
+			return progress
+		}
+
+		req, ok := msg.(Req)
+		if !ok {
+			panic(fmt.Sprintf("phasecontrol: cannot handle %T", msg))
+		}
+
+		comp.Resources().Target.BeginPhase(req.Phase)
+
+		comp.State.PendingRsps = append(comp.State.PendingRsps, pendingRsp{
+			Dst:   req.Src,
+			RspTo: req.ID,
+			Phase: req.Phase,
+		})
+		progress = true
+	}
+}
+
+func (p *processor) sendResponses(comp *Comp) bool {
+	progress := false
+	state := &comp.State
+
+	for len(state.PendingRsps) > 0 {
+		if !controlPort(comp).CanSend() {
+			return progress
+		}
+
+		pending := state.PendingRsps[0]
+		controlPort(comp).Send(Rsp{
+			MsgMeta: messaging.MsgMeta{
+				ID:    timing.GetIDGenerator().Generate(),
+				Src:   controlPort(comp).AsRemote(),
+				Dst:   pending.Dst,
+				RspTo: pending.RspTo,
+			},
+			Phase: pending.Phase,
+		})
+
+		state.PendingRsps = state.PendingRsps[1:]
+		progress = true
+	}
+
+	return progress
+}
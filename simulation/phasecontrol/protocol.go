@@ -0,0 +1,39 @@
+package phasecontrol
+
+import (
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/messaging/txcheck"
+)
+
+// Protocol is the phase protocol: a requester asks to begin a phase over a
+// phasecontrol component's "Control" port, and the component responds once
+// the phase has begun.
+var (
+	Protocol = messaging.DefineProtocol("sim.phase",
+		messaging.RoleDef{Name: "requester",
+			Sends: []messaging.Msg{Req{}}},
+		messaging.RoleDef{Name: "responder",
+			Sends: []messaging.Msg{Rsp{}}},
+	)
+	Requester = Protocol.Role("requester")
+	Responder = Protocol.Role("responder")
+)
+
+// TransactionRule pairs every phase request with the response that answers
+// it, for checking traffic with a txcheck.Checker.
+var TransactionRule = txcheck.NewRule(Protocol,
+	txcheck.Answers(Req{}, Rsp{}),
+)
+
+// Req asks to begin a phase. An empty Phase ends the current phase without
+// beginning another.
+type Req struct {
+	messaging.MsgMeta
+	Phase string
+}
+
+// Rsp confirms that the phase of the Req it answers has begun.
+type Rsp struct {
+	messaging.MsgMeta
+	Phase string
+}
@@ -0,0 +1,91 @@
+package simulation
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/datarecording"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/profiling"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// countdownTicker makes progress on every other tick until it runs out.
+type countdownTicker struct {
+	comp *modeling.TickingComponent
+	left int
+}
+
+func (t *countdownTicker) Tick() bool {
+	if t.left == 0 {
+		return false
+	}
+
+	t.left--
+	t.comp.TickLater()
+
+	return t.left%2 == 0
+}
+
+// profileRow mirrors the table structure profiling.Report.Record writes.
+type profileRow struct {
+	Component  string
+	Kind       string
+	Type       string
+	Calls      uint64
+	WallTimeNS int64
+	NoProgress uint64
+}
+
+func TestProfilingTimesHandlersAndTicks(t *testing.T) {
+	sim := MakeBuilder().WithoutMonitoring().WithProfiling().Build()
+	dbFile := "akita_sim_" + sim.ID() + ".sqlite3"
+	defer os.Remove(dbFile)
+
+	ticker := &countdownTicker{left: 10}
+	ticker.comp = modeling.NewTickingComponent(
+		"Countdown", sim.GetEngine(), 1*timing.GHz, ticker)
+	sim.RegisterComponent(ticker.comp)
+	ticker.comp.TickLater()
+
+	if err := sim.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	report := sim.Profiler().Report()
+	if len(report.Components) != 1 {
+		t.Fatalf("expected one profiled component, got %+v", report.Components)
+	}
+
+	c := report.Components[0]
+	if c.Component != "Countdown" || c.Handles != 11 || c.Ticks != 11 ||
+		c.NoProgressTicks != 6 || c.HandleTime < c.TickTime {
+		t.Fatalf("unexpected summary %+v", c)
+	}
+
+	sim.Terminate()
+
+	reader := datarecording.NewReader(dbFile)
+	defer reader.Close()
+	reader.MapTable(profiling.TableName, profileRow{})
+
+	rows, _, err := reader.Query(context.Background(), profiling.TableName,
+		datarecording.QueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("expected a handler and a tick row, got %d", len(rows))
+	}
+}
+
+func TestProfilingIsOffByDefault(t *testing.T) {
+	sim := MakeBuilder().WithoutMonitoring().Build()
+	defer cleanupSim(sim)
+
+	if sim.Profiler() != nil {
+		t.Fatal("expected no profiler without WithProfiling")
+	}
+}
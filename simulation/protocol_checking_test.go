@@ -0,0 +1,59 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/sarchlab/akita/v5/mem/acceptancetests/memaccessagent"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/sysdesc"
+)
+
+func TestProtocolCheckingAcceptsMemTraffic(t *testing.T) {
+	sim := MakeBuilder().WithoutMonitoring().WithProtocolChecking().Build()
+	defer cleanupSim(sim)
+
+	desc, err := sysdesc.Parse([]byte(`
+components:
+  - name: Agent
+    type: memaccessagent
+    spec: {max_address: 4096, write_left: 50, read_left: 50}
+    resources: {low_module: DRAM.Top}
+  - name: DRAM
+    type: idealmemcontroller
+    spec: {capacity: 4096}
+connections:
+  - name: Conn
+    ports: [Agent.Mem, DRAM.Top]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sys, err := desc.Build(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checked := false
+	for _, h := range sys.Port("DRAM.Top").Hooks() {
+		_, checked = h.(*messaging.ProtocolChecker)
+		if checked {
+			break
+		}
+	}
+
+	if !checked {
+		t.Fatal("ports must carry the protocol checker")
+	}
+
+	agent := sys.Components["Agent"].(*memaccessagent.MemAccessAgent)
+	agent.TickLater()
+
+	if err := sim.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if agent.State.ReadLeft > 0 || len(agent.State.PendingReadReq) > 0 {
+		t.Fatalf("agent did not finish: %+v", agent.State)
+	}
+}
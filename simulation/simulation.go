@@ -1,10 +1,20 @@
 package simulation
 
 import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
 	"github.com/sarchlab/akita/v5/datarecording"
+	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/messaging/txcheck"
 
 	"github.com/sarchlab/akita/v5/monitoring2"
 	"github.com/sarchlab/akita/v5/naming"
+	"github.com/sarchlab/akita/v5/profiling"
+	"github.com/sarchlab/akita/v5/simulation/topology"
+	"github.com/sarchlab/akita/v5/stats"
 	"github.com/sarchlab/akita/v5/timing"
 	"github.com/sarchlab/akita/v5/tracing"
 )
@@ -18,6 +28,16 @@ type Simulation struct {
 	metaRecorder     *metaRecorder
 	topologyRecorder *topologyRecorder
 	monitor          *monitoring2.Monitor
+	livelock         *livelockDetector
+	protocolChecker  hooking.Hook
+	txChecker        *txcheck.Checker
+	statsMu          sync.Mutex
+	stats            *stats.Registry
+	phaseMu          sync.Mutex
+	phase            string
+	phaseStart       timing.VTimeInPicoSec
+	phaseListeners   []tracing.PhaseListener
+	profiler         *profiling.Profiler
 
 	components    []Component
 	compNameIndex map[string]int
@@ -26,6 +46,7 @@ type Simulation struct {
 	connections   []Connection
 	connNameIndex map[string]int
 	resources     []Resource
+	domains       []naming.Named
 
 	// entities is the single, flat inventory of every registered runtime object
 	// (components, ports, connections, resources, the engine, and the ID
@@ -107,11 +128,31 @@ func (s *Simulation) RegisterComponent(c naming.Named) {
 		tracing.CollectTrace(hookable, s.visTracer)
 	}
 
+	if p, ok := c.(profiledComponent); ok && s.profiler != nil {
+		p.EnableProfiling(s.profiler, compName)
+	}
+
+	if s.livelock != nil {
+		s.livelock.watch(c)
+	}
+
 	if s.monitor != nil {
 		s.monitor.RegisterComponent(c)
 	}
 }
 
+// profiledComponent is a component that times its ticks and middlewares, as
+// modeling.TickingComponent and modeling.Component do.
+type profiledComponent interface {
+	EnableProfiling(p *profiling.Profiler, component string)
+}
+
+// Profiler returns the wall-clock profiler, or nil unless the simulation was
+// built WithProfiling.
+func (s *Simulation) Profiler() *profiling.Profiler {
+	return s.profiler
+}
+
 // RegisterPort registers a port with the simulation so it can be resolved by
 // name and monitored. Port builders call this through the modeling.Registrar
 // interface, mirroring RegisterComponent — a component is registered when it is
@@ -138,6 +179,14 @@ func (s *Simulation) RegisterPort(p naming.Named) {
 	tracing.CollectIncomingBufferTrace(p)
 	tracing.CollectOutgoingBufferTrace(p)
 
+	if hookable, ok := p.(hooking.Hookable); ok && s.protocolChecker != nil {
+		hookable.AcceptHook(s.protocolChecker)
+	}
+
+	if hookable, ok := p.(hooking.Hookable); ok && s.txChecker != nil {
+		hookable.AcceptHook(s.txChecker)
+	}
+
 	if s.monitor != nil {
 		s.monitor.RegisterPort(port)
 	}
@@ -162,6 +211,10 @@ func (s *Simulation) RegisterConnection(c naming.Named) {
 
 	s.connections = append(s.connections, c)
 	s.connNameIndex[connName] = len(s.connections) - 1
+
+	if hookable, ok := c.(hooking.Hookable); ok && s.protocolChecker != nil {
+		hookable.AcceptHook(s.protocolChecker)
+	}
 }
 
 // Connections returns a copy of the registered connections, in registration
@@ -196,6 +249,32 @@ func (s *Simulation) Resources() []Resource {
 	return append([]Resource(nil), s.resources...)
 }
 
+// RegisterDomain registers a domain, such as a modeling.Domain, so that the
+// topology clusters the components in it. Domains are a description of the
+// structure rather than runtime objects: they are not entities and are not
+// checkpointed. sysdesc registers the domains it builds.
+func (s *Simulation) RegisterDomain(d naming.Named) {
+	for _, registered := range s.domains {
+		if registered.Name() == d.Name() {
+			panic("domain " + d.Name() + " already registered")
+		}
+	}
+
+	s.domains = append(s.domains, d)
+}
+
+// Domains returns a copy of the registered domains, in registration order.
+func (s *Simulation) Domains() []naming.Named {
+	return append([]naming.Named(nil), s.domains...)
+}
+
+// Topology returns the graph of the registered components, clustered by the
+// registered domains, and the connections among the registered ports. Write it
+// with the topology package's WriteDOT, WriteGraphML, or WriteJSON.
+func (s *Simulation) Topology() topology.Graph {
+	return topology.Build(asNamed(s.components), asNamed(s.ports), s.domains)
+}
+
 // GetComponentByName returns the component with the given name.
 func (s *Simulation) GetComponentByName(name string) Component {
 	idx, found := s.compNameIndex[name]
@@ -219,8 +298,167 @@ func (s *Simulation) GetPortByName(name string) Port {
 	return s.ports[idx]
 }
 
+// TransactionChecker returns the checker attached by
+// Builder.WithTransactionChecking, or nil if transaction checking is off.
+func (s *Simulation) TransactionChecker() *txcheck.Checker {
+	return s.txChecker
+}
+
+// Stats returns the simulation's statistics registry, creating it and
+// registering it as a resource named "Stats" on first use, so that stats are
+// checkpointed with the simulation. It implements stats.Provider: components
+// reach it through their registrar with stats.Of.
+func (s *Simulation) Stats() *stats.Registry {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	if s.stats == nil {
+		s.stats = stats.NewRegistry(stats.DefaultName)
+		s.RegisterResource(s.stats)
+	}
+
+	return s.stats
+}
+
+// endStats dumps the stats one last time: as the current phase if one is
+// open, and otherwise as the "end" region.
+func (s *Simulation) endStats() {
+	s.phaseMu.Lock()
+	phase, start := s.phase, s.phaseStart
+	s.phaseMu.Unlock()
+
+	if phase == "" {
+		s.DumpStats("end")
+		return
+	}
+
+	s.closePhase(phase, start, s.engine.CurrentTime())
+}
+
+func (s *Simulation) statsJSON(prefix string) any {
+	s.statsMu.Lock()
+	registry := s.stats
+	s.statsMu.Unlock()
+
+	if registry == nil {
+		return []stats.StatJSON{}
+	}
+
+	return registry.JSON(prefix)
+}
+
+// DumpStats records every stat to the "stats" table of the data recording,
+// labeled with the region name and the current time. Together with
+// ResetStats, it measures regions of interest; BeginPhase does both. When it
+// terminates, the simulation dumps the open phase, or else an "end" region.
+func (s *Simulation) DumpStats(region string) {
+	s.statsMu.Lock()
+	registry := s.stats
+	s.statsMu.Unlock()
+
+	if registry != nil {
+		registry.Record(s.dataRecorder, region, s.engine.CurrentTime())
+	}
+}
+
+// ResetStats restarts the measurement of every stat; see stats.Registry.Reset.
+// BeginPhase resets the stats, too.
+func (s *Simulation) ResetStats() {
+	s.statsMu.Lock()
+	registry := s.stats
+	s.statsMu.Unlock()
+
+	if registry != nil {
+		registry.Reset()
+	}
+}
+
+// phaseTableName is the data recording table that records the phases.
+const phaseTableName = "phase"
+
+// phaseEntry is the table structure of a phase in the data recording.
+type phaseEntry struct {
+	Name      string  `json:"name"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// Phase returns the name of the current program phase, or "" outside of any
+// phase. It implements tracing.PhaseSource, so tracing.InPhase(sim, "roi")
+// filters the tasks that start in the region of interest.
+func (s *Simulation) Phase() string {
+	s.phaseMu.Lock()
+	defer s.phaseMu.Unlock()
+
+	return s.phase
+}
+
+// AddPhaseListener tells the listener, typically a tracer, whenever a new
+// phase begins.
+func (s *Simulation) AddPhaseListener(l tracing.PhaseListener) {
+	s.phaseMu.Lock()
+	defer s.phaseMu.Unlock()
+
+	s.phaseListeners = append(s.phaseListeners, l)
+}
+
+// BeginPhase ends the current phase and begins the named one, e.g. "warmup"
+// and then "roi". Ending a named phase dumps its stats as a region of the same
+// name (see DumpStats) and records the phase with its start and end time in
+// the "phase" table of the data recording. The stats are then reset and the
+// phase listeners told, so that they measure the new phase only. Workload
+// code can also begin phases with messages, through a phasecontrol component.
+func (s *Simulation) BeginPhase(name string) {
+	now := s.engine.CurrentTime()
+
+	s.phaseMu.Lock()
+	prev, start := s.phase, s.phaseStart
+	s.phase, s.phaseStart = name, now
+	listeners := append([]tracing.PhaseListener(nil), s.phaseListeners...)
+	s.phaseMu.Unlock()
+
+	s.closePhase(prev, start, now)
+	s.ResetStats()
+
+	for _, l := range listeners {
+		l.BeginPhase(name, now)
+	}
+}
+
+// EndPhase ends the current phase without beginning another, e.g. to leave
+// the region of interest before cool-down.
+func (s *Simulation) EndPhase() {
+	s.BeginPhase("")
+}
+
+func (s *Simulation) closePhase(name string, start, end timing.VTimeInPicoSec) {
+	if name == "" {
+		return
+	}
+
+	s.DumpStats(name)
+
+	if !slices.Contains(s.dataRecorder.ListTables(), phaseTableName) {
+		s.dataRecorder.CreateTable(phaseTableName, phaseEntry{})
+	}
+
+	s.dataRecorder.InsertData(phaseTableName, phaseEntry{
+		Name:      name,
+		StartTime: float64(start),
+		EndTime:   float64(end),
+	})
+}
+
 // Terminate terminates the simulation.
 func (s *Simulation) Terminate() {
+	s.endStats()
+
+	if s.txChecker != nil {
+		if err := s.txChecker.Check(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
 	if s.monitor != nil {
 		s.monitor.StopServer()
 	}
@@ -233,8 +471,12 @@ func (s *Simulation) Terminate() {
 		s.metaRecorder.End()
 	}
 
+	if s.profiler != nil {
+		s.profiler.Report().Record(s.dataRecorder)
+	}
+
 	if s.topologyRecorder != nil {
-		s.topologyRecorder.Record(s.components, s.ports)
+		s.topologyRecorder.Record(s.components, s.ports, s.connections, s.domains)
 	}
 
 	s.dataRecorder.Close()
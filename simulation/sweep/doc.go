@@ -0,0 +1,6 @@
+// Package sweep runs a system description over a design space of Spec fields.
+// Each point runs in a separate worker process with a fresh
+// simulation.Simulation, and the summary metrics of all points are gathered
+// into one results table. A sweep's output directory records its points, so
+// running the sweep again resumes it.
+package sweep
@@ -0,0 +1,202 @@
+package sweep
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SpaceKind selects how a Space turns its parameters into points.
+type SpaceKind string
+
+const (
+	// Grid takes the Cartesian product of every parameter's values.
+	Grid SpaceKind = "grid"
+
+	// Random draws Samples independent points.
+	Random SpaceKind = "random"
+
+	// LatinHypercube draws Samples points so that each parameter's range is
+	// split into Samples strata and every stratum is sampled exactly once.
+	LatinHypercube SpaceKind = "lhs"
+)
+
+// Param is one swept Spec field. Name is "Component.field", where field is the
+// JSON name of the Spec field, e.g. "DRAM.t_cl". A parameter either lists
+// discrete Values or gives a numeric range [Min, Max]; grid sweeps over a
+// range need Steps evenly spaced values.
+type Param struct {
+	Name    string  `json:"name" yaml:"name"`
+	Values  []any   `json:"values,omitempty" yaml:"values,omitempty"`
+	Min     float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max     float64 `json:"max,omitempty" yaml:"max,omitempty"`
+	Steps   int     `json:"steps,omitempty" yaml:"steps,omitempty"`
+	Integer bool    `json:"integer,omitempty" yaml:"integer,omitempty"`
+}
+
+// Space is a design space over Spec fields.
+type Space struct {
+	Kind    SpaceKind `json:"kind" yaml:"kind"`
+	Samples int       `json:"samples,omitempty" yaml:"samples,omitempty"`
+	Seed    int64     `json:"seed,omitempty" yaml:"seed,omitempty"`
+	Params  []Param   `json:"params" yaml:"params"`
+}
+
+// Point is one configuration in a sweep: a value for every parameter, keyed by
+// parameter name.
+type Point struct {
+	Index  int            `json:"index"`
+	Values map[string]any `json:"values"`
+}
+
+// Points enumerates the space. Random and Latin-hypercube spaces are
+// reproducible from Seed.
+func (s Space) Points() ([]Point, error) {
+	if len(s.Params) == 0 {
+		return nil, fmt.Errorf("sweep: space has no parameters")
+	}
+
+	for _, p := range s.Params {
+		if err := p.validate(s.Kind); err != nil {
+			return nil, err
+		}
+	}
+
+	switch s.Kind {
+	case Grid:
+		return s.gridPoints(), nil
+	case Random, LatinHypercube:
+		if s.Samples <= 0 {
+			return nil, fmt.Errorf("sweep: %s space needs a positive samples count", s.Kind)
+		}
+
+		return s.sampledPoints(), nil
+	default:
+		return nil, fmt.Errorf("sweep: unknown space kind %q", s.Kind)
+	}
+}
+
+func (p Param) validate(kind SpaceKind) error {
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("sweep: parameter without a name")
+	case len(p.Values) > 0:
+		return nil
+	case p.Max < p.Min:
+		return fmt.Errorf("sweep: parameter %s: max is below min", p.Name)
+	case kind == Grid && p.Steps <= 0:
+		return fmt.Errorf("sweep: parameter %s: grid ranges need steps", p.Name)
+	}
+
+	return nil
+}
+
+// gridValues returns the values a parameter takes in a grid.
+func (p Param) gridValues() []any {
+	if len(p.Values) > 0 {
+		return p.Values
+	}
+
+	values := make([]any, p.Steps)
+	for i := range values {
+		frac := 0.0
+		if p.Steps > 1 {
+			frac = float64(i) / float64(p.Steps-1)
+		}
+
+		values[i] = p.at(frac)
+	}
+
+	return values
+}
+
+// at returns the value at frac in [0, 1] of the parameter's range or value
+// list.
+func (p Param) at(frac float64) any {
+	if len(p.Values) > 0 {
+		i := min(int(frac*float64(len(p.Values))), len(p.Values)-1)
+		return p.Values[i]
+	}
+
+	v := p.Min + frac*(p.Max-p.Min)
+	if p.Integer {
+		return int64(math.Round(v))
+	}
+
+	return v
+}
+
+func (s Space) gridPoints() []Point {
+	points := []Point{{Values: map[string]any{}}}
+
+	for _, p := range s.Params {
+		var next []Point
+
+		for _, base := range points {
+			for _, v := range p.gridValues() {
+				values := maps.Clone(base.Values)
+				values[p.Name] = v
+				next = append(next, Point{Values: values})
+			}
+		}
+
+		points = next
+	}
+
+	for i := range points {
+		points[i].Index = i
+	}
+
+	return points
+}
+
+func (s Space) sampledPoints() []Point {
+	rng := rand.New(rand.NewSource(s.Seed))
+	n := s.Samples
+
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{Index: i, Values: map[string]any{}}
+	}
+
+	for _, p := range s.Params {
+		var strata []int
+		if s.Kind == LatinHypercube {
+			strata = rng.Perm(n)
+		}
+
+		for i := range points {
+			u := rng.Float64()
+			if strata != nil {
+				u = (float64(strata[i]) + u) / float64(n)
+			}
+
+			points[i].Values[p.Name] = p.at(u)
+		}
+	}
+
+	return points
+}
+
+// LoadSpace reads a space from a YAML or JSON file.
+func LoadSpace(path string) (Space, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Space{}, fmt.Errorf("sweep: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	space := Space{}
+	if err := dec.Decode(&space); err != nil {
+		return Space{}, fmt.Errorf("sweep: %s: %w", path, err)
+	}
+
+	return space, nil
+}
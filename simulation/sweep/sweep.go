@@ -0,0 +1,441 @@
+package sweep
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sarchlab/akita/v5/sysdesc"
+	"gopkg.in/yaml.v3"
+)
+
+// File names inside a sweep's output directory.
+const (
+	manifestFile = "sweep.json"
+	resultsFile  = "results.csv"
+	systemFile   = "system.yaml"
+	metricsFile  = "metrics.json"
+	resultFile   = "result.json"
+	logFile      = "log.txt"
+)
+
+// Config describes a sweep.
+type Config struct {
+	// Base is the path of the system description every point starts from.
+	Base string
+
+	// Space is the design space over the base description's Spec fields.
+	Space Space
+
+	// OutDir receives one directory per point plus the sweep manifest and the
+	// results table. Running a sweep again into the same directory resumes
+	// it: points that already succeeded are not run again.
+	OutDir string
+
+	// Workers is the number of points run at once. It defaults to the number
+	// of CPUs.
+	Workers int
+
+	// Command is the worker process run for each point. It defaults to the
+	// current executable and arguments, which must then call RunWorker when
+	// IsWorker reports true.
+	Command []string
+}
+
+// Result is the outcome of one point.
+type Result struct {
+	Point   Point              `json:"point"`
+	Status  string             `json:"status"`
+	Error   string             `json:"error,omitempty"`
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+// Result statuses.
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Results is the results table of a sweep: one row per point, with a column
+// per parameter and per metric.
+type Results struct {
+	Params  []string
+	Metrics []string
+	Rows    []Result
+}
+
+// manifest pins what a sweep directory holds so that a resumed run uses the
+// same points even for sampled spaces.
+type manifest struct {
+	BaseSHA256 string  `json:"base_sha256"`
+	Space      Space   `json:"space"`
+	Points     []Point `json:"points"`
+}
+
+// Run runs every point of the sweep that has not yet succeeded in OutDir, in
+// parallel worker processes, and returns the gathered results. It also writes
+// them to results.csv in OutDir. A point that fails is recorded as failed
+// rather than stopping the sweep; it runs again on the next Run.
+func Run(cfg Config) (*Results, error) {
+	base, err := os.ReadFile(cfg.Base)
+	if err != nil {
+		return nil, fmt.Errorf("sweep: %w", err)
+	}
+
+	if _, err := sysdesc.Parse(base); err != nil {
+		return nil, fmt.Errorf("sweep: base %s: %w", cfg.Base, err)
+	}
+
+	if err := os.MkdirAll(cfg.OutDir, 0o755); err != nil {
+		return nil, fmt.Errorf("sweep: %w", err)
+	}
+
+	m, err := loadOrCreateManifest(cfg, base)
+	if err != nil {
+		return nil, err
+	}
+
+	command := cfg.Command
+	if len(command) == 0 {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("sweep: %w", err)
+		}
+
+		command = append([]string{exe}, os.Args[1:]...)
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]Result, len(m.Points))
+	pending := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Go(func() {
+			for i := range pending {
+				results[i] = runPoint(cfg.OutDir, base, command, m.Points[i])
+			}
+		})
+	}
+
+	for i, p := range m.Points {
+		if r, ok := readResult(pointDir(cfg.OutDir, p)); ok && r.Status == StatusOK {
+			results[i] = r
+			continue
+		}
+
+		pending <- i
+	}
+
+	close(pending)
+	wg.Wait()
+
+	table := newResults(cfg.Space, results)
+	if err := table.writeFile(filepath.Join(cfg.OutDir, resultsFile)); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+func loadOrCreateManifest(cfg Config, base []byte) (*manifest, error) {
+	sum := sha256.Sum256(base)
+	path := filepath.Join(cfg.OutDir, manifestFile)
+
+	if data, err := os.ReadFile(path); err == nil {
+		m := &manifest{}
+		if err := json.Unmarshal(data, m); err != nil {
+			return nil, fmt.Errorf("sweep: %s: %w", path, err)
+		}
+
+		if m.BaseSHA256 != hex.EncodeToString(sum[:]) || !sameSpace(m.Space, cfg.Space) {
+			return nil, fmt.Errorf(
+				"sweep: %s holds a different sweep; use a new output directory",
+				cfg.OutDir)
+		}
+
+		return m, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("sweep: %w", err)
+	}
+
+	points, err := cfg.Space.Points()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &manifest{
+		BaseSHA256: hex.EncodeToString(sum[:]),
+		Space:      cfg.Space,
+		Points:     points,
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("sweep: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return nil, fmt.Errorf("sweep: %w", err)
+	}
+
+	// Reload so the points hold the same JSON-decoded values as on a resume.
+	return loadOrCreateManifest(cfg, base)
+}
+
+// sameSpace compares spaces by their JSON form, the form the manifest keeps.
+func sameSpace(a, b Space) bool {
+	var ja, jb any
+
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	_ = json.Unmarshal(da, &ja)
+	_ = json.Unmarshal(db, &jb)
+
+	return reflect.DeepEqual(ja, jb)
+}
+
+func pointDir(outDir string, p Point) string {
+	return filepath.Join(outDir, fmt.Sprintf("point-%05d", p.Index))
+}
+
+func runPoint(outDir string, base []byte, command []string, p Point) Result {
+	dir := pointDir(outDir, p)
+	r := Result{Point: p, Status: StatusFailed}
+
+	if err := preparePoint(dir, base, p); err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	if err := execPoint(dir, command); err != nil {
+		r.Error = err.Error()
+	} else if metrics, err := readMetrics(dir); err != nil {
+		r.Error = err.Error()
+	} else {
+		r.Status = StatusOK
+		r.Metrics = metrics
+	}
+
+	if data, err := json.MarshalIndent(r, "", "  "); err == nil {
+		_ = os.WriteFile(filepath.Join(dir, resultFile), data, 0o644)
+	}
+
+	return r
+}
+
+// preparePoint writes the point's system description: the base with the
+// point's values set on the named Spec fields.
+func preparePoint(dir string, base []byte, p Point) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	desc, err := sysdesc.Parse(base)
+	if err != nil {
+		return err
+	}
+
+	if err := apply(desc, p.Values); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(desc)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, systemFile), data, 0o644)
+}
+
+// apply sets each "Component.field" value on the matching component or
+// connection spec.
+func apply(desc *sysdesc.Description, values map[string]any) error {
+	for name, v := range values {
+		i := strings.LastIndex(name, ".")
+		if i <= 0 {
+			return fmt.Errorf("parameter %q is not of the form Component.field", name)
+		}
+
+		owner, field := name[:i], name[i+1:]
+
+		spec, found := findSpec(desc, owner)
+		if !found {
+			return fmt.Errorf("parameter %q: no component or connection %q", name, owner)
+		}
+
+		if *spec == nil {
+			*spec = map[string]any{}
+		}
+
+		(*spec)[field] = v
+	}
+
+	return nil
+}
+
+func findSpec(desc *sysdesc.Description, owner string) (*map[string]any, bool) {
+	for i := range desc.Components {
+		if desc.Components[i].Name == owner {
+			return &desc.Components[i].Spec, true
+		}
+	}
+
+	for i := range desc.Connections {
+		if desc.Connections[i].Name == owner {
+			return &desc.Connections[i].Spec, true
+		}
+	}
+
+	return nil, false
+}
+
+func execPoint(dir string, command []string) error {
+	log, err := os.Create(filepath.Join(dir, logFile))
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), PointDirEnv+"="+abs)
+	cmd.Stdout = log
+	cmd.Stderr = log
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("worker: %w (see %s)", err, filepath.Join(dir, logFile))
+	}
+
+	return nil
+}
+
+func readMetrics(dir string) (map[string]float64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, metricsFile))
+	if err != nil {
+		return nil, fmt.Errorf("worker wrote no metrics: %w", err)
+	}
+
+	metrics := map[string]float64{}
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return nil, fmt.Errorf("%s: %w", metricsFile, err)
+	}
+
+	return metrics, nil
+}
+
+func readResult(dir string) (Result, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, resultFile))
+	if err != nil {
+		return Result{}, false
+	}
+
+	r := Result{}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Result{}, false
+	}
+
+	return r, true
+}
+
+func newResults(space Space, rows []Result) *Results {
+	t := &Results{Rows: rows}
+
+	for _, p := range space.Params {
+		t.Params = append(t.Params, p.Name)
+	}
+
+	seen := map[string]bool{}
+
+	for _, r := range rows {
+		for name := range r.Metrics {
+			if !seen[name] {
+				seen[name] = true
+				t.Metrics = append(t.Metrics, name)
+			}
+		}
+	}
+
+	sort.Strings(t.Metrics)
+
+	return t
+}
+
+// WriteCSV writes the table with a header row. Missing metrics are left empty.
+func (t *Results) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	header := append([]string{"point", "status"}, t.Params...)
+	header = append(header, t.Metrics...)
+	header = append(header, "error")
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range t.Rows {
+		row := []string{strconv.Itoa(r.Point.Index), r.Status}
+
+		for _, name := range t.Params {
+			row = append(row, fmt.Sprint(r.Point.Values[name]))
+		}
+
+		for _, name := range t.Metrics {
+			if v, found := r.Metrics[name]; found {
+				row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+			} else {
+				row = append(row, "")
+			}
+		}
+
+		row = append(row, r.Error)
+
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func (t *Results) writeFile(path string) error {
+	var buf bytes.Buffer
+	if err := t.WriteCSV(&buf); err != nil {
+		return fmt.Errorf("sweep: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("sweep: %w", err)
+	}
+
+	return nil
+}
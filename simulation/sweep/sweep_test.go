@@ -0,0 +1,170 @@
+package sweep
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/mem/acceptancetests/memaccessagent"
+	"github.com/sarchlab/akita/v5/simulation"
+	"github.com/sarchlab/akita/v5/sysdesc"
+)
+
+// TestMain doubles as the worker process: Run re-executes the test binary
+// with PointDirEnv set.
+func TestMain(m *testing.M) {
+	if IsWorker() {
+		if err := RunWorker(simulation.MakeBuilder(), runAgent); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func runAgent(sim *simulation.Simulation, sys *sysdesc.System) (map[string]float64, error) {
+	agent := sys.Components["Agent"].(*memaccessagent.MemAccessAgent)
+	agent.TickLater()
+
+	if err := sim.Run(); err != nil {
+		return nil, err
+	}
+
+	return map[string]float64{
+		"sim_time_ps": float64(sim.GetEngine().CurrentTime()),
+		"pid":         float64(os.Getpid()),
+	}, nil
+}
+
+const baseSystem = `
+components:
+  - name: Agent
+    type: memaccessagent
+    spec: {max_address: 4096, write_left: 10, read_left: 10}
+    resources: {low_module: DRAM.Top}
+  - name: DRAM
+    type: idealmemcontroller
+    spec: {capacity: 4096}
+connections:
+  - name: Conn
+    ports: [Agent.Mem, DRAM.Top]
+`
+
+func TestSpacePoints(t *testing.T) {
+	grid := Space{Kind: Grid, Params: []Param{
+		{Name: "A.x", Values: []any{1, 2}},
+		{Name: "A.y", Min: 0, Max: 10, Steps: 3, Integer: true},
+	}}
+
+	points, err := grid.Points()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(points) != 6 || points[5].Values["A.x"] != 2 ||
+		points[5].Values["A.y"] != int64(10) || points[1].Values["A.y"] != int64(5) {
+		t.Fatalf("unexpected grid %+v", points)
+	}
+
+	lhs := Space{Kind: LatinHypercube, Samples: 8, Seed: 3, Params: []Param{
+		{Name: "A.x", Min: 0, Max: 8},
+	}}
+
+	points, err = lhs.Points()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strata := []int{}
+	for _, p := range points {
+		strata = append(strata, int(p.Values["A.x"].(float64)))
+	}
+
+	sort.Ints(strata)
+
+	for i, s := range strata {
+		if s != i {
+			t.Fatalf("LHS did not sample every stratum once: %v", strata)
+		}
+	}
+
+	again, _ := lhs.Points()
+	if again[0].Values["A.x"] != points[0].Values["A.x"] {
+		t.Fatal("sampled spaces must be reproducible from the seed")
+	}
+}
+
+func TestRunSweepsAndResumes(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "system.yaml")
+
+	if err := os.WriteFile(base, []byte(baseSystem), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		Base: base,
+		Space: Space{Kind: Grid, Params: []Param{
+			{Name: "DRAM.latency", Values: []any{10, 200}},
+		}},
+		OutDir:  filepath.Join(dir, "out"),
+		Workers: 2,
+		Command: []string{os.Args[0]},
+	}
+
+	results, err := Run(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", results.Rows)
+	}
+
+	for _, r := range results.Rows {
+		if r.Status != StatusOK {
+			t.Fatalf("point %d failed: %s", r.Point.Index, r.Error)
+		}
+	}
+
+	fast, slow := results.Rows[0].Metrics, results.Rows[1].Metrics
+	if fast["sim_time_ps"] >= slow["sim_time_ps"] {
+		t.Fatalf("higher latency should run longer: %v vs %v", fast, slow)
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.OutDir, "point-00001", "akita_sim.sqlite3")); err != nil {
+		t.Fatalf("point has no data recording: %v", err)
+	}
+
+	csv, err := os.ReadFile(filepath.Join(cfg.OutDir, resultsFile))
+	if err != nil || !strings.HasPrefix(string(csv), "point,status,DRAM.latency,pid,sim_time_ps,error\n") {
+		t.Fatalf("unexpected results table %q (%v)", csv, err)
+	}
+
+	// Forget the second point; a resumed sweep reruns only that one.
+	if err := os.Remove(filepath.Join(cfg.OutDir, "point-00001", resultFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := Run(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resumed.Rows[0].Metrics["pid"] != fast["pid"] ||
+		resumed.Rows[1].Metrics["pid"] == slow["pid"] ||
+		resumed.Rows[1].Status != StatusOK {
+		t.Fatalf("resume reran the wrong points: %+v", resumed.Rows)
+	}
+
+	cfg.Space.Params[0].Values = []any{10, 300}
+	if _, err := Run(cfg); err == nil {
+		t.Fatal("expected an error for a different sweep in the same directory")
+	}
+}
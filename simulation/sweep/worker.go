@@ -0,0 +1,76 @@
+package sweep
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sarchlab/akita/v5/simulation"
+	"github.com/sarchlab/akita/v5/sysdesc"
+)
+
+// PointDirEnv names the environment variable that tells a worker process which
+// point directory to run. The directory holds the point's system.yaml; the
+// worker leaves its DataRecorder output there and reports its summary metrics
+// as a flat JSON object of numbers in metrics.json.
+const PointDirEnv = "AKITA_SWEEP_POINT"
+
+// IsWorker reports whether the process was started by Run to simulate one
+// point.
+func IsWorker() bool {
+	return os.Getenv(PointDirEnv) != ""
+}
+
+// RunFunc runs one point's simulation, already built from its description,
+// and returns its summary metrics.
+type RunFunc func(
+	sim *simulation.Simulation,
+	sys *sysdesc.System,
+) (map[string]float64, error)
+
+// RunWorker simulates the point named by PointDirEnv. It builds a fresh
+// Simulation from b, with its data recording written into the point
+// directory, builds the point's system description into it, calls run, and
+// writes the returned metrics for Run to collect. A simulator that sweeps
+// itself calls it early in main:
+//
+//	if sweep.IsWorker() {
+//		if err := sweep.RunWorker(simulation.MakeBuilder(), run); err != nil {
+//			log.Fatal(err)
+//		}
+//		return
+//	}
+func RunWorker(b simulation.Builder, run RunFunc) error {
+	dir := os.Getenv(PointDirEnv)
+	if dir == "" {
+		return fmt.Errorf("sweep: %s is not set", PointDirEnv)
+	}
+
+	desc, err := sysdesc.LoadFile(filepath.Join(dir, systemFile))
+	if err != nil {
+		return err
+	}
+
+	sim := b.WithoutMonitoring().
+		WithOutputFileName(filepath.Join(dir, "akita_sim")).
+		Build()
+	defer sim.Terminate()
+
+	sys, err := desc.Build(sim)
+	if err != nil {
+		return err
+	}
+
+	metrics, err := run(sim, sys)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(metrics, "", "  ")
+	if err != nil {
+		return fmt.Errorf("sweep: %w", err)
+	}
+
+	return os.WriteFile(filepath.Join(dir, metricsFile), data, 0o644)
+}
@@ -0,0 +1,16 @@
+// Package topology describes the structure of a simulation as a graph and
+// exports it to Graphviz DOT, GraphML, and JSON.
+//
+// Components become nodes, clustered by the modeling.Domain they belong to.
+// Connections become edges between the components whose ports they join,
+// annotated with the protocol roles the ports speak and the connection's Spec
+// (a link's latency and bandwidth, for example). A connection that joins more
+// than two ports becomes a hub that every endpoint connects to.
+//
+//	g := sim.Topology()
+//	f, _ := os.Create("topology.dot")
+//	err := topology.WriteDOT(f, g)
+//
+// Daisen serves the same graph, rebuilt from the trace database, at
+// /api/topology/export.
+package topology
@@ -0,0 +1,326 @@
+package topology
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Format is a file format a graph can be written in.
+type Format string
+
+// The export formats.
+const (
+	FormatDOT     Format = "dot"
+	FormatGraphML Format = "graphml"
+	FormatJSON    Format = "json"
+)
+
+// Write writes the graph in the given format.
+func Write(w io.Writer, g Graph, format Format) error {
+	switch format {
+	case FormatDOT:
+		return WriteDOT(w, g)
+	case FormatGraphML:
+		return WriteGraphML(w, g)
+	case FormatJSON:
+		return WriteJSON(w, g)
+	default:
+		return fmt.Errorf("topology: unknown format %q", format)
+	}
+}
+
+// WriteJSON writes the graph as indented JSON.
+func WriteJSON(w io.Writer, g Graph) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(g)
+}
+
+// WriteDOT writes the graph as an undirected Graphviz graph. Domains become
+// nested "cluster_" subgraphs, edges are labeled with the connection's name
+// and parameters, and each end of an edge with the roles its port speaks.
+func WriteDOT(w io.Writer, g Graph) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "graph topology {")
+	fmt.Fprintln(bw, "  compound=true;")
+	fmt.Fprintln(bw, "  node [shape=box];")
+
+	children, members := g.clusterTree()
+	writeDOTClusters(bw, "", children, members, "  ")
+
+	for _, e := range g.Edges {
+		writeDOTEdge(bw, e)
+	}
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+func writeDOTClusters(
+	w io.Writer,
+	parent string,
+	children map[string][]string,
+	members map[string][]Node,
+	indent string,
+) {
+	for _, n := range members[parent] {
+		label := []string{n.Name}
+		if n.Type != "" {
+			label = append(label, n.Type)
+		}
+
+		fmt.Fprintf(w, "%s%s [label=%s];\n", indent, dotID(n.Name), dotLabel(label))
+	}
+
+	for _, c := range children[parent] {
+		fmt.Fprintf(w, "%ssubgraph %s {\n", indent, dotID("cluster_"+c))
+		fmt.Fprintf(w, "%s  label=%s;\n", indent, dotID(c))
+		writeDOTClusters(w, c, children, members, indent+"  ")
+		fmt.Fprintf(w, "%s}\n", indent)
+	}
+}
+
+func writeDOTEdge(w io.Writer, e Edge) {
+	label := append([]string{e.Connection}, paramLines(e.Params)...)
+
+	if len(e.Endpoints) == 2 {
+		a, b := e.Endpoints[0], e.Endpoints[1]
+		fmt.Fprintf(w, "  %s -- %s [label=%s, taillabel=%s, headlabel=%s];\n",
+			dotID(a.Component), dotID(b.Component), dotLabel(label),
+			dotID(strings.Join(a.Roles, ", ")), dotID(strings.Join(b.Roles, ", ")))
+
+		return
+	}
+
+	// A connection that joins one or more than two ports is drawn as a hub.
+	fmt.Fprintf(w, "  %s [shape=diamond, label=%s];\n",
+		dotID(e.Connection), dotLabel(label))
+
+	for _, ep := range e.Endpoints {
+		fmt.Fprintf(w, "  %s -- %s [headlabel=%s];\n",
+			dotID(e.Connection), dotID(ep.Component),
+			dotID(strings.Join(ep.Roles, ", ")))
+	}
+}
+
+// dotID quotes a string as a DOT ID.
+func dotID(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+
+	return `"` + s + `"`
+}
+
+// dotLabel quotes lines as a multi-line DOT label.
+func dotLabel(lines []string) string {
+	quoted := make([]string, len(lines))
+	for i, l := range lines {
+		quoted[i] = strings.Trim(dotID(l), `"`)
+	}
+
+	return `"` + strings.Join(quoted, `\n`) + `"`
+}
+
+// paramLines renders the scalar fields of a Spec as "name=value" lines,
+// sorted by name. Nested fields are left out to keep edge labels short.
+func paramLines(params json.RawMessage) []string {
+	var fields map[string]any
+
+	// Numbers are kept as written, so a frequency does not print as 1e+09.
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.UseNumber()
+
+	if len(params) == 0 || dec.Decode(&fields) != nil {
+		return nil
+	}
+
+	lines := []string{}
+
+	for name, v := range fields {
+		switch v.(type) {
+		case map[string]any, []any, nil:
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("%s=%v", name, v))
+	}
+
+	sort.Strings(lines)
+
+	return lines
+}
+
+// WriteGraphML writes the graph as GraphML. Domains become nodes holding
+// nested graphs. Every connected port is a GraphML port of its component's
+// node, which carries the port's roles. A connection that joins exactly two
+// ports is an edge; any other connection is a hyperedge.
+func WriteGraphML(w io.Writer, g Graph) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(bw, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+
+	keys := []struct{ id, domain string }{
+		{"type", "node"},
+		{"spec", "node"},
+		{"roles", "port"},
+		{"connection", "all"},
+		{"conn_type", "all"},
+		{"params", "all"},
+	}
+	for _, k := range keys {
+		fmt.Fprintf(bw, `  <key id="%s" for="%s" attr.name="%s" attr.type="string"/>`+"\n",
+			k.id, k.domain, k.id)
+	}
+
+	fmt.Fprintln(bw, `  <graph id="topology" edgedefault="undirected">`)
+
+	children, members := g.clusterTree()
+	ports := g.portsByComponent()
+	writeGraphMLClusters(bw, "", children, members, ports, "    ")
+
+	for i, e := range g.Edges {
+		writeGraphMLEdge(bw, i, e)
+	}
+
+	fmt.Fprintln(bw, "  </graph>")
+	fmt.Fprintln(bw, "</graphml>")
+
+	return bw.Flush()
+}
+
+func writeGraphMLClusters(
+	w io.Writer,
+	parent string,
+	children map[string][]string,
+	members map[string][]Node,
+	ports map[string][]Endpoint,
+	indent string,
+) {
+	for _, n := range members[parent] {
+		fmt.Fprintf(w, "%s<node id=\"%s\">\n", indent, xmlEscape(n.Name))
+		writeGraphMLData(w, indent+"  ", "type", n.Type)
+		writeGraphMLData(w, indent+"  ", "spec", string(n.Spec))
+
+		for _, p := range ports[n.Name] {
+			fmt.Fprintf(w, "%s  <port name=\"%s\">\n", indent, xmlEscape(p.Port))
+			writeGraphMLData(w, indent+"    ", "roles", strings.Join(p.Roles, ", "))
+			fmt.Fprintf(w, "%s  </port>\n", indent)
+		}
+
+		fmt.Fprintf(w, "%s</node>\n", indent)
+	}
+
+	for _, c := range children[parent] {
+		id := xmlEscape("cluster_" + c)
+		fmt.Fprintf(w, "%s<node id=\"%s\">\n", indent, id)
+		fmt.Fprintf(w, "%s  <graph id=\"%s:\" edgedefault=\"undirected\">\n", indent, id)
+		writeGraphMLClusters(w, c, children, members, ports, indent+"    ")
+		fmt.Fprintf(w, "%s  </graph>\n", indent)
+		fmt.Fprintf(w, "%s</node>\n", indent)
+	}
+}
+
+func writeGraphMLEdge(w io.Writer, i int, e Edge) {
+	const indent = "    "
+
+	if len(e.Endpoints) == 2 {
+		a, b := e.Endpoints[0], e.Endpoints[1]
+		fmt.Fprintf(w,
+			"%s<edge id=\"e%d\" source=\"%s\" sourceport=\"%s\" target=\"%s\" targetport=\"%s\">\n",
+			indent, i, xmlEscape(a.Component), xmlEscape(a.Port),
+			xmlEscape(b.Component), xmlEscape(b.Port))
+		writeGraphMLEdgeData(w, indent+"  ", e)
+		fmt.Fprintf(w, "%s</edge>\n", indent)
+
+		return
+	}
+
+	fmt.Fprintf(w, "%s<hyperedge id=\"e%d\">\n", indent, i)
+	writeGraphMLEdgeData(w, indent+"  ", e)
+
+	for _, ep := range e.Endpoints {
+		fmt.Fprintf(w, "%s  <endpoint node=\"%s\" port=\"%s\"/>\n",
+			indent, xmlEscape(ep.Component), xmlEscape(ep.Port))
+	}
+
+	fmt.Fprintf(w, "%s</hyperedge>\n", indent)
+}
+
+func writeGraphMLEdgeData(w io.Writer, indent string, e Edge) {
+	writeGraphMLData(w, indent, "connection", e.Connection)
+	writeGraphMLData(w, indent, "conn_type", e.Type)
+	writeGraphMLData(w, indent, "params", string(e.Params))
+}
+
+func writeGraphMLData(w io.Writer, indent, key, value string) {
+	if value == "" {
+		return
+	}
+
+	fmt.Fprintf(w, "%s<data key=\"%s\">%s</data>\n", indent, key, xmlEscape(value))
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+
+	_ = xml.EscapeText(&b, []byte(s))
+
+	return b.String()
+}
+
+// clusterTree returns the child clusters of each cluster and the nodes
+// directly in each cluster, with "" as the root. A node or cluster whose
+// parent is not a cluster of the graph is put at the root.
+func (g Graph) clusterTree() (map[string][]string, map[string][]Node) {
+	known := map[string]bool{"": true}
+	for _, c := range g.Clusters {
+		known[c.Name] = true
+	}
+
+	children := make(map[string][]string)
+
+	for _, c := range g.Clusters {
+		parent := c.Parent
+		if !known[parent] {
+			parent = ""
+		}
+
+		children[parent] = append(children[parent], c.Name)
+	}
+
+	members := make(map[string][]Node)
+
+	for _, n := range g.Nodes {
+		cluster := n.Cluster
+		if !known[cluster] {
+			cluster = ""
+		}
+
+		members[cluster] = append(members[cluster], n)
+	}
+
+	return children, members
+}
+
+// portsByComponent returns the connected ports of each component.
+func (g Graph) portsByComponent() map[string][]Endpoint {
+	ports := make(map[string][]Endpoint)
+
+	for _, e := range g.Edges {
+		for _, ep := range e.Endpoints {
+			ports[ep.Component] = append(ports[ep.Component], ep)
+		}
+	}
+
+	return ports
+}
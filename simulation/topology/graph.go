@@ -0,0 +1,241 @@
+package topology
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/naming"
+)
+
+// Graph is the structure of a simulation.
+type Graph struct {
+	Clusters []Cluster `json:"clusters"`
+	Nodes    []Node    `json:"nodes"`
+	Edges    []Edge    `json:"edges"`
+}
+
+// Cluster is a domain. Parent is the enclosing domain, or empty for a
+// top-level domain.
+type Cluster struct {
+	Name   string `json:"name"`
+	Parent string `json:"parent,omitempty"`
+}
+
+// Node is a component. Cluster is the innermost domain the component belongs
+// to, or empty if it belongs to none. Spec is the component's Spec as JSON, or
+// nil if the component has none.
+type Node struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"`
+	Cluster string          `json:"cluster,omitempty"`
+	Spec    json.RawMessage `json:"spec,omitempty"`
+}
+
+// Edge is a connection and the ports it joins. Type is the Go type of the
+// connection's Spec, and Params the Spec as JSON; both are empty if the
+// connection has no Spec.
+type Edge struct {
+	Connection string          `json:"connection"`
+	Type       string          `json:"type"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	Endpoints  []Endpoint      `json:"endpoints"`
+}
+
+// Endpoint is a port plugged into a connection. Roles are the protocol roles
+// the port's component declared for it, e.g. "mem requester".
+type Endpoint struct {
+	Component string   `json:"component"`
+	Port      string   `json:"port"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+// RoleName returns the name a role has in a graph: the protocol name and the
+// role name, e.g. "mem requester".
+func RoleName(r *messaging.Role) string {
+	return r.Protocol().Name() + " " + r.Name()
+}
+
+// RoleNames returns the roles the port speaks, named by RoleName.
+func RoleNames(port messaging.Port) []string {
+	roles := messaging.PortRolesOf(port)
+	if len(roles) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = RoleName(r)
+	}
+
+	return names
+}
+
+// Build describes the given components, the connections among the given
+// ports, and the domains that cluster the components. A component belongs to
+// the innermost domain that either prefixes its hierarchical name or exposes
+// one of its ports. Components, ports, and domains are named objects so that a
+// simulation can pass its registered entities; ports must be messaging.Ports,
+// and a domain is only searched for exposed ports if it has a Ports method, as
+// modeling.Domain has.
+func Build(components, ports, domains []naming.Named) Graph {
+	g := Graph{
+		Clusters: []Cluster{},
+		Nodes:    []Node{},
+		Edges:    []Edge{},
+	}
+
+	exposedBy := exposedComponents(domains)
+
+	for _, d := range domains {
+		g.Clusters = append(g.Clusters, Cluster{
+			Name:   d.Name(),
+			Parent: innermost(d.Name(), domains, nil),
+		})
+	}
+
+	for _, c := range components {
+		node := Node{
+			Name:    c.Name(),
+			Cluster: innermost(c.Name(), domains, exposedBy[c.Name()]),
+		}
+		node.Type, node.Spec = DescribeSpec(c)
+
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	g.Edges = buildEdges(ports)
+
+	return g
+}
+
+// exposedComponents maps each component name to the domains that expose one
+// of its ports.
+func exposedComponents(domains []naming.Named) map[string][]string {
+	exposedBy := make(map[string][]string)
+
+	for _, d := range domains {
+		owner, ok := d.(interface{ Ports() []messaging.Port })
+		if !ok {
+			continue
+		}
+
+		for _, p := range owner.Ports() {
+			if p.Component() == nil {
+				continue
+			}
+
+			name := p.Component().Name()
+			exposedBy[name] = append(exposedBy[name], d.Name())
+		}
+	}
+
+	return exposedBy
+}
+
+// innermost returns the domain with the longest name among those that prefix
+// name or are listed in extra, or empty if there is none. A domain does not
+// contain itself.
+func innermost(name string, domains []naming.Named, extra []string) string {
+	found := ""
+
+	for _, d := range domains {
+		dn := d.Name()
+		if dn == name {
+			continue
+		}
+
+		contains := strings.HasPrefix(name, dn+".")
+		for _, e := range extra {
+			contains = contains || e == dn
+		}
+
+		if contains && len(dn) > len(found) {
+			found = dn
+		}
+	}
+
+	return found
+}
+
+// buildEdges groups the connected ports by connection, in the order the
+// connections are first seen.
+func buildEdges(ports []naming.Named) []Edge {
+	edges := []Edge{}
+	index := make(map[string]int)
+
+	for _, named := range ports {
+		p, ok := named.(messaging.Port)
+		if !ok {
+			continue
+		}
+
+		conn := connectionOf(p)
+		if conn == nil {
+			continue
+		}
+
+		i, found := index[conn.Name()]
+		if !found {
+			edge := Edge{Connection: conn.Name()}
+			edge.Type, edge.Params = DescribeSpec(conn)
+			edges = append(edges, edge)
+			i = len(edges) - 1
+			index[conn.Name()] = i
+		}
+
+		endpoint := Endpoint{Port: p.Name(), Roles: RoleNames(p)}
+		if p.Component() != nil {
+			endpoint.Component = p.Component().Name()
+		}
+
+		edges[i].Endpoints = append(edges[i].Endpoints, endpoint)
+	}
+
+	return edges
+}
+
+// connectionOf returns the connection a port is plugged into, or nil. The
+// accessor is not part of messaging.Port, so ports without it count as
+// unconnected.
+func connectionOf(p messaging.Port) messaging.Connection {
+	withConn, ok := p.(interface{ Connection() messaging.Connection })
+	if !ok {
+		return nil
+	}
+
+	conn := withConn.Connection()
+	if conn == nil {
+		return nil
+	}
+
+	if v := reflect.ValueOf(conn); v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+
+	return conn
+}
+
+// DescribeSpec returns the Go type and the JSON of the object's Spec, reached
+// by reflection because the Spec accessor of modeling components is generic.
+// Both are empty if the object has no Spec, and the JSON is empty if the Spec
+// does not serialize.
+func DescribeSpec(obj any) (string, json.RawMessage) {
+	m := reflect.ValueOf(obj).MethodByName("Spec")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return "", nil
+	}
+
+	spec := m.Call(nil)[0].Interface()
+	if spec == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return reflect.TypeOf(spec).String(), nil
+	}
+
+	return reflect.TypeOf(spec).String(), data
+}
@@ -0,0 +1,254 @@
+package topology_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/naming"
+	"github.com/sarchlab/akita/v5/simulation/topology"
+	"github.com/sarchlab/akita/v5/sysdesc"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+const agentAndMemory = `
+components:
+  - name: Agent
+    type: memaccessagent
+    spec: {max_address: 4096, write_left: 1, read_left: 1}
+    resources: {low_module: DRAM.Top}
+    ports:
+      Mem: {buf_size: 4}
+  - name: DRAM
+    type: idealmemcontroller
+    spec: {latency: 10, capacity: 4096}
+    ports:
+      Top: {buf_size: 4}
+connections:
+  - name: Conn
+    type: link
+    spec: {latency: 20, bytes_per_cycle: 8}
+    ports: [Agent.Mem, DRAM.Top]
+domains:
+  - name: Memory
+    ports: {Top: DRAM.Top}
+`
+
+func buildGraph(t *testing.T) topology.Graph {
+	t.Helper()
+
+	desc, err := sysdesc.Parse([]byte(agentAndMemory))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	sys, err := desc.Build(modeling.NewStandaloneRegistrar(timing.NewSerialEngine()))
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	var components, ports, domains []naming.Named
+
+	for _, name := range []string{"Agent", "DRAM"} {
+		c := sys.Components[name]
+		components = append(components, c)
+
+		for _, p := range c.(messaging.PortOwner).Ports() {
+			ports = append(ports, p)
+		}
+	}
+
+	domains = append(domains, sys.Domains["Memory"])
+
+	return topology.Build(components, ports, domains)
+}
+
+func TestBuildClustersAndAnnotates(t *testing.T) {
+	g := buildGraph(t)
+
+	if !reflect.DeepEqual(g.Clusters, []topology.Cluster{{Name: "Memory"}}) {
+		t.Fatalf("clusters = %+v", g.Clusters)
+	}
+
+	clusters := map[string]string{}
+	for _, n := range g.Nodes {
+		clusters[n.Name] = n.Cluster
+	}
+
+	if clusters["DRAM"] != "Memory" || clusters["Agent"] != "" {
+		t.Fatalf("node clusters = %v", clusters)
+	}
+
+	if len(g.Edges) != 1 {
+		t.Fatalf("edges = %+v", g.Edges)
+	}
+
+	e := g.Edges[0]
+	if e.Connection != "Conn" || e.Type != "link.Spec" {
+		t.Fatalf("edge = %+v", e)
+	}
+
+	var params struct {
+		Latency       int `json:"latency"`
+		BytesPerCycle int `json:"bytes_per_cycle"`
+	}
+	if err := json.Unmarshal(e.Params, &params); err != nil ||
+		params.Latency != 20 || params.BytesPerCycle != 8 {
+		t.Fatalf("params = %s", e.Params)
+	}
+
+	roles := map[string][]string{}
+	for _, ep := range e.Endpoints {
+		roles[ep.Port] = ep.Roles
+	}
+
+	if !slices.Equal(roles["Agent.Mem"], []string{"mem requester"}) ||
+		!slices.Equal(roles["DRAM.Top"], []string{"mem responder"}) {
+		t.Fatalf("endpoint roles = %v", roles)
+	}
+}
+
+func TestBuildNestsDomainsByName(t *testing.T) {
+	named := func(names ...string) []naming.Named {
+		list := make([]naming.Named, len(names))
+		for i, n := range names {
+			list[i] = modeling.NewDomain(n)
+		}
+
+		return list
+	}
+
+	g := topology.Build(named("GPU.SA[0].L1", "CPU"), nil, named("GPU", "GPU.SA[0]"))
+
+	want := []topology.Cluster{{Name: "GPU"}, {Name: "GPU.SA[0]", Parent: "GPU"}}
+	if !reflect.DeepEqual(g.Clusters, want) {
+		t.Fatalf("clusters = %+v", g.Clusters)
+	}
+
+	if g.Nodes[0].Cluster != "GPU.SA[0]" || g.Nodes[1].Cluster != "" {
+		t.Fatalf("nodes = %+v", g.Nodes)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := topology.WriteDOT(&buf, buildGraph(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`subgraph "cluster_Memory" {`,
+		`"DRAM" [label="DRAM\nidealmemcontroller.Spec"];`,
+		`"Agent" -- "DRAM" [label="Conn\narbitration=none\nbuffer_size=16\n` +
+			`bytes_per_cycle=8\nfreq=1000000000\nlatency=20", ` +
+			`taillabel="mem requester", headlabel="mem responder"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("DOT output lacks %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteDOTDrawsHubs(t *testing.T) {
+	g := topology.Graph{Edges: []topology.Edge{{
+		Connection: "Bus",
+		Endpoints: []topology.Endpoint{
+			{Component: "A", Port: "A.P"},
+			{Component: "B", Port: "B.P"},
+			{Component: "C", Port: "C.P", Roles: []string{"mem responder"}},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := topology.WriteDOT(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"Bus" [shape=diamond, label="Bus"];`) ||
+		!strings.Contains(out, `"Bus" -- "C" [headlabel="mem responder"];`) {
+		t.Fatalf("DOT output lacks the hub:\n%s", out)
+	}
+}
+
+// graphML mirrors the parts of a GraphML document the test checks.
+type graphML struct {
+	Graph struct {
+		Nodes []struct {
+			ID    string `xml:"id,attr"`
+			Graph *struct {
+				Nodes []struct {
+					ID    string `xml:"id,attr"`
+					Ports []struct {
+						Name string `xml:"name,attr"`
+						Data string `xml:"data"`
+					} `xml:"port"`
+				} `xml:"node"`
+			} `xml:"graph"`
+		} `xml:"node"`
+		Edges []struct {
+			Source     string `xml:"source,attr"`
+			SourcePort string `xml:"sourceport,attr"`
+			Target     string `xml:"target,attr"`
+			TargetPort string `xml:"targetport,attr"`
+		} `xml:"edge"`
+	} `xml:"graph"`
+}
+
+func TestWriteGraphML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := topology.WriteGraphML(&buf, buildGraph(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc graphML
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("not XML: %v\n%s", err, buf.String())
+	}
+
+	nodes := doc.Graph.Nodes
+	if len(nodes) != 2 || nodes[0].ID != "Agent" || nodes[1].ID != "cluster_Memory" {
+		t.Fatalf("top-level nodes = %+v", nodes)
+	}
+
+	dram := nodes[1].Graph.Nodes
+	if len(dram) != 1 || dram[0].ID != "DRAM" ||
+		dram[0].Ports[0].Name != "DRAM.Top" || dram[0].Ports[0].Data != "mem responder" {
+		t.Fatalf("cluster nodes = %+v", dram)
+	}
+
+	edges := doc.Graph.Edges
+	if len(edges) != 1 || edges[0].Source != "Agent" || edges[0].SourcePort != "Agent.Mem" ||
+		edges[0].Target != "DRAM" || edges[0].TargetPort != "DRAM.Top" {
+		t.Fatalf("edges = %+v", edges)
+	}
+}
+
+func TestWriteJSONRoundTrips(t *testing.T) {
+	g := buildGraph(t)
+
+	var buf bytes.Buffer
+	if err := topology.Write(&buf, g, topology.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var back topology.Graph
+	if err := json.Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(back.Nodes) != len(g.Nodes) || back.Edges[0].Endpoints[1].Roles[0] != "mem responder" {
+		t.Fatalf("round trip = %+v", back)
+	}
+
+	if err := topology.Write(&buf, g, "svg"); err == nil {
+		t.Fatal("an unknown format was accepted")
+	}
+}
@@ -1,16 +1,29 @@
 package simulation
 
 import (
-	"encoding/json"
 	"reflect"
+	"strings"
 
 	"github.com/sarchlab/akita/v5/datarecording"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/naming"
+	"github.com/sarchlab/akita/v5/simulation/topology"
 )
 
 // componentSpecTableName holds one row per component: its name, the Go type of
-// its spec, and the spec serialized as JSON.
+// its spec, the spec serialized as JSON, and the innermost registered domain
+// the component belongs to.
 const componentSpecTableName = "component_spec"
 
+// connectionSpecTableName holds one row per connection: its name, the Go type
+// of its spec, and the spec serialized as JSON, e.g. a link's latency and
+// bandwidth.
+const connectionSpecTableName = "connection_spec"
+
+// domainTableName holds one row per registered domain and the domain that
+// encloses it.
+const domainTableName = "domain"
+
 // portTableName holds one row per registered port: the component that owns it
 // and the connection it is plugged into (empty when the port is unconnected).
 // It is the complete port inventory, so the index page can show every port of a
@@ -21,19 +34,36 @@ const portTableName = "port"
 
 // componentSpecEntry is one row of the component_spec table. Spec is the
 // JSON-encoded spec, or empty when the component exposes no spec or the spec
-// does not serialize.
+// does not serialize. Domain is empty for a component outside every domain.
 type componentSpecEntry struct {
+	Name   string
+	Type   string
+	Spec   string
+	Domain string
+}
+
+// connectionSpecEntry is one row of the connection_spec table.
+type connectionSpecEntry struct {
 	Name string
 	Type string
 	Spec string
 }
 
+// domainEntry is one row of the domain table. Parent is empty for a top-level
+// domain.
+type domainEntry struct {
+	Name   string
+	Parent string
+}
+
 // portEntry is one row of the port table. Connection is empty for an
-// unconnected port.
+// unconnected port. Roles lists the protocol roles the port speaks, e.g.
+// "mem Requester", separated by ", ".
 type portEntry struct {
 	Component  string
 	Port       string
 	Connection string
+	Roles      string
 }
 
 // named is anything that can report its name. Specs, components, ports, and
@@ -45,14 +75,16 @@ type named interface {
 }
 
 // topologyRecorder records the static structure of a simulation — every
-// component's spec and the full port inventory with its connection graph — into
-// the recording, making it self-describing for tools such as Daisen's index
-// page.
+// component's spec and domain, every connection's spec, the domains, and the
+// full port inventory with its connection graph and protocol roles — into the
+// recording, making it self-describing for tools such as Daisen's index page
+// and topology export.
 //
 // Specs are read through the public Spec accessor that every modeling.Component
 // exposes; because that accessor is generic there is no single non-generic
-// interface to assert against, so the recorder reaches it by reflection. This
-// runs once at Terminate, so its cost is irrelevant to simulation speed.
+// interface to assert against, so the recorder reaches it by reflection (see
+// topology.DescribeSpec). This runs once at Terminate, so its cost is
+// irrelevant to simulation speed.
 type topologyRecorder struct {
 	recorder datarecording.DataRecorder
 }
@@ -62,31 +94,60 @@ type topologyRecorder struct {
 func newTopologyRecorder(recorder datarecording.DataRecorder) *topologyRecorder {
 	r := &topologyRecorder{recorder: recorder}
 	r.recorder.CreateTable(componentSpecTableName, componentSpecEntry{})
+	r.recorder.CreateTable(connectionSpecTableName, connectionSpecEntry{})
+	r.recorder.CreateTable(domainTableName, domainEntry{})
 	r.recorder.CreateTable(portTableName, portEntry{})
 
 	return r
 }
 
-// Record writes the component specs and the port inventory. It is called from
-// Terminate, by which point every component, port, and connection is registered.
-func (r *topologyRecorder) Record(components []Component, ports []Port) {
-	r.recordComponentSpecs(components)
+// Record writes the component and connection specs, the domains, and the port
+// inventory. It is called from Terminate, by which point every component, port,
+// connection, and domain is registered.
+func (r *topologyRecorder) Record(
+	components []Component,
+	ports []Port,
+	connections []Connection,
+	domains []naming.Named,
+) {
+	g := topology.Build(asNamed(components), nil, domains)
+
+	r.recordComponentSpecs(g.Nodes)
+	r.recordConnectionSpecs(connections)
+	r.recordDomains(g.Clusters)
 	r.recordPorts(ports)
 	r.recorder.Flush()
 }
 
-func (r *topologyRecorder) recordComponentSpecs(components []Component) {
-	for _, c := range components {
-		entry := componentSpecEntry{Name: c.Name()}
+func (r *topologyRecorder) recordComponentSpecs(nodes []topology.Node) {
+	for _, n := range nodes {
+		r.recorder.InsertData(componentSpecTableName, componentSpecEntry{
+			Name:   n.Name,
+			Type:   n.Type,
+			Spec:   string(n.Spec),
+			Domain: n.Cluster,
+		})
+	}
+}
 
-		if spec, ok := reflectSpec(c); ok {
-			entry.Type = reflect.TypeOf(spec).String()
-			if data, err := json.Marshal(spec); err == nil {
-				entry.Spec = string(data)
-			}
-		}
+func (r *topologyRecorder) recordConnectionSpecs(connections []Connection) {
+	for _, c := range connections {
+		typ, spec := topology.DescribeSpec(c)
 
-		r.recorder.InsertData(componentSpecTableName, entry)
+		r.recorder.InsertData(connectionSpecTableName, connectionSpecEntry{
+			Name: c.Name(),
+			Type: typ,
+			Spec: string(spec),
+		})
+	}
+}
+
+func (r *topologyRecorder) recordDomains(clusters []topology.Cluster) {
+	for _, c := range clusters {
+		r.recorder.InsertData(domainTableName, domainEntry{
+			Name:   c.Name,
+			Parent: c.Parent,
+		})
 	}
 }
 
@@ -101,30 +162,29 @@ func (r *topologyRecorder) recordPorts(ports []Port) {
 		conn, _ := reflectName(p, "Connection")
 		comp, _ := reflectName(p, "Component")
 
+		roles := ""
+		if mp, ok := p.(messaging.Port); ok {
+			roles = strings.Join(topology.RoleNames(mp), ", ")
+		}
+
 		r.recorder.InsertData(portTableName, portEntry{
 			Component:  comp,
 			Port:       p.Name(),
 			Connection: conn,
+			Roles:      roles,
 		})
 	}
 }
 
-// reflectSpec returns the value of the component's Spec accessor, or ok=false
-// when the component exposes no such accessor.
-func reflectSpec(c Component) (any, bool) {
-	m := reflect.ValueOf(c).MethodByName("Spec")
-	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
-		return nil, false
-	}
-
-	spec := m.Call(nil)[0].Interface()
-	if spec == nil {
-		// A nil interface spec would make reflect.TypeOf(spec).String() panic
-		// in the Terminate teardown path; treat it as "no spec".
-		return nil, false
+// asNamed converts a slice of entities to the named objects the topology
+// package takes.
+func asNamed[E Entity](entities []E) []naming.Named {
+	named := make([]naming.Named, len(entities))
+	for i, e := range entities {
+		named[i] = e
 	}
 
-	return spec, true
+	return named
 }
 
 // reflectName calls a no-argument accessor (e.g. "Connection" or "Component")
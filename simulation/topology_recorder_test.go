@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/sarchlab/akita/v5/datarecording"
+	"github.com/sarchlab/akita/v5/naming"
 )
 
 // fakeSpec is a stand-in component spec that serializes to JSON, mirroring the
@@ -77,7 +78,7 @@ func TestTopologyRecorderRecordsComponentSpecs(t *testing.T) {
 		&specComponent{name: "L1", spec: fakeSpec{Freq: 1000, Mode: "write-through"}},
 		&plainComponent{name: "Agent"},
 	}
-	r.Record(components, nil)
+	r.Record(components, nil, nil, nil)
 	if err := recorder.Close(); err != nil {
 		t.Fatalf("close recorder: %v", err)
 	}
@@ -116,7 +117,7 @@ func TestTopologyRecorderRecordsPorts(t *testing.T) {
 		&fakePort{name: "L2.Bottom", conn: connA, comp: &namedEntity{name: "L2"}},
 		&fakePort{name: "L1.Ctrl", conn: nil, comp: &namedEntity{name: "L1"}},
 	}
-	r.Record(nil, ports)
+	r.Record(nil, ports, nil, nil)
 	if err := recorder.Close(); err != nil {
 		t.Fatalf("close recorder: %v", err)
 	}
@@ -149,6 +150,54 @@ func TestTopologyRecorderRecordsPorts(t *testing.T) {
 	}
 }
 
+func TestTopologyRecorderRecordsDomainsAndConnections(t *testing.T) {
+	path := "test_topology_recorder_domains"
+	dbFile := path + ".sqlite3"
+	os.Remove(dbFile)
+	defer os.Remove(dbFile)
+
+	recorder := datarecording.NewDataRecorder(path)
+	r := newTopologyRecorder(recorder)
+
+	components := []Component{&plainComponent{name: "GPU.SA.L1"}}
+	connections := []Connection{
+		&specComponent{name: "Link", spec: fakeSpec{Freq: 1000, Mode: "bus"}},
+	}
+	domains := []naming.Named{&namedEntity{name: "GPU"}, &namedEntity{name: "GPU.SA"}}
+	r.Record(components, nil, connections, domains)
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("close recorder: %v", err)
+	}
+
+	if got := readComponentSpecs(t, dbFile)["GPU.SA.L1"].Domain; got != "GPU.SA" {
+		t.Fatalf("component domain = %q, want GPU.SA", got)
+	}
+
+	reader := datarecording.NewReader(dbFile)
+	defer reader.Close()
+	reader.MapTable(domainTableName, domainEntry{})
+	reader.MapTable(connectionSpecTableName, connectionSpecEntry{})
+
+	domainRows, _, err := reader.Query(
+		context.Background(), domainTableName, datarecording.QueryParams{})
+	if err != nil {
+		t.Fatalf("query domain: %v", err)
+	}
+	if len(domainRows) != 2 || *domainRows[1].(*domainEntry) != (domainEntry{Name: "GPU.SA", Parent: "GPU"}) {
+		t.Fatalf("unexpected domain rows: %v", domainRows)
+	}
+
+	connRows, _, err := reader.Query(
+		context.Background(), connectionSpecTableName, datarecording.QueryParams{})
+	if err != nil {
+		t.Fatalf("query connection_spec: %v", err)
+	}
+	link := connRows[0].(*connectionSpecEntry)
+	if link.Name != "Link" || link.Spec != `{"freq":1000,"mode":"bus"}` {
+		t.Fatalf("unexpected connection_spec row: %+v", link)
+	}
+}
+
 func readComponentSpecs(
 	t *testing.T,
 	dbFile string,
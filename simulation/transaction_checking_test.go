@@ -0,0 +1,55 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/sarchlab/akita/v5/mem/acceptancetests/memaccessagent"
+	"github.com/sarchlab/akita/v5/mem/memprotocol"
+	"github.com/sarchlab/akita/v5/sysdesc"
+)
+
+func TestTransactionCheckingPairsMemTraffic(t *testing.T) {
+	sim := MakeBuilder().
+		WithoutMonitoring().
+		WithTransactionChecking(memprotocol.TransactionRule).
+		Build()
+	defer cleanupSim(sim)
+
+	desc, err := sysdesc.Parse([]byte(`
+components:
+  - name: Agent
+    type: memaccessagent
+    spec: {max_address: 4096, write_left: 50, read_left: 50}
+    resources: {low_module: DRAM.Top}
+  - name: DRAM
+    type: idealmemcontroller
+    spec: {capacity: 4096}
+connections:
+  - name: Conn
+    ports: [Agent.Mem, DRAM.Top]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sys, err := desc.Build(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent := sys.Components["Agent"].(*memaccessagent.MemAccessAgent)
+	agent.TickLater()
+
+	if err := sim.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	checker := sim.TransactionChecker()
+	if checker == nil {
+		t.Fatal("the simulation must expose its transaction checker")
+	}
+
+	if err := checker.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
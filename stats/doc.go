@@ -0,0 +1,27 @@
+// Package stats provides a registry of named performance counters and
+// statistics.
+//
+// A Registry holds four kinds of stats, each named hierarchically after its
+// owner with package naming, e.g. "GPU[0].DRAM.ReadCommands":
+//
+//   - Counter: a count that only grows, such as requests served. Counters
+//     created with NewCounterFunc read a count a component already keeps in
+//     its State.
+//   - Gauge: a level that goes up and down, such as a queue occupancy.
+//   - Histogram: the distribution of samples, such as latencies.
+//   - Formula: a value computed when read, such as a hit rate.
+//
+// Components register their stats through their builder's registrar:
+//
+//	reg := stats.Of(b.registrar)
+//	hits := reg.NewCounter(naming.BuildName(name, "Hits"), "cache hits")
+//	accesses := reg.NewCounter(naming.BuildName(name, "Accesses"), "accesses")
+//	reg.NewFormula(naming.BuildName(name, "HitRate"), "hits per access",
+//		func() float64 { return float64(hits.Value()) / float64(accesses.Value()) })
+//
+// A simulation.Simulation provides the registry and registers it as a
+// resource, so stats are checkpointed with the simulation. Reset starts a new
+// measurement at a region of interest. The stats are written as gem5-style
+// text (WriteText), as JSON (WriteJSON), or to the "stats" table of a data
+// recording (Record), and the monitor browses them live at /stats.
+package stats
@@ -0,0 +1,123 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/sarchlab/akita/v5/datarecording"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// TableName is the data recording table that Record writes to.
+const TableName = "stats"
+
+// WriteText writes the stats under the prefix in gem5's stats.txt layout: one
+// line per entry with the name, the value, and the description as a comment.
+func (r *Registry) WriteText(w io.Writer, prefix string) error {
+	entries := r.Entries(prefix)
+
+	width := 0
+	for _, e := range entries {
+		width = max(width, len(e.Name))
+	}
+
+	if _, err := fmt.Fprintln(w,
+		"---------- Begin Simulation Statistics ----------"); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		line := fmt.Sprintf("%-*s %20s", width, e.Name, formatValue(e.Value))
+		if e.Desc != "" {
+			line += "  # " + e.Desc
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintln(w,
+		"---------- End Simulation Statistics   ----------")
+
+	return err
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// StatJSON is the JSON form of a stat.
+type StatJSON struct {
+	Name      string             `json:"name"`
+	Kind      Kind               `json:"kind"`
+	Desc      string             `json:"desc,omitempty"`
+	Value     float64            `json:"value"`
+	Histogram *HistogramSnapshot `json:"histogram,omitempty"`
+}
+
+// JSON returns the stats under the prefix in their JSON form. A histogram's
+// value is its mean.
+func (r *Registry) JSON(prefix string) []StatJSON {
+	stats := r.Stats(prefix)
+	list := make([]StatJSON, 0, len(stats))
+
+	for _, s := range stats {
+		j := StatJSON{Name: s.Name(), Kind: s.Kind(), Desc: s.Desc()}
+
+		if h, ok := s.(*Histogram); ok {
+			snapshot := h.Snapshot()
+			j.Histogram = &snapshot
+			j.Value = snapshot.Mean()
+		} else {
+			j.Value = s.Entries()[0].Value
+		}
+
+		list = append(list, j)
+	}
+
+	return list
+}
+
+// WriteJSON writes the JSON form of the stats under the prefix.
+func (r *Registry) WriteJSON(w io.Writer, prefix string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r.JSON(prefix))
+}
+
+// recordEntry is the table structure of stats in a data recording.
+type recordEntry struct {
+	Region string  `json:"region"`
+	Time   float64 `json:"time"`
+	Name   string  `json:"name"`
+	Kind   string  `json:"kind"`
+	Value  float64 `json:"value"`
+}
+
+// Record writes every entry to the "stats" table of a data recording, labeled
+// with a region name, e.g. the region of interest being dumped, and the
+// simulated time.
+func (r *Registry) Record(
+	recorder datarecording.DataRecorder,
+	region string,
+	now timing.VTimeInPicoSec,
+) {
+	if !slices.Contains(recorder.ListTables(), TableName) {
+		recorder.CreateTable(TableName, recordEntry{})
+	}
+
+	for _, e := range r.Entries("") {
+		recorder.InsertData(TableName, recordEntry{
+			Region: region,
+			Time:   float64(now),
+			Name:   e.Name,
+			Kind:   string(e.Kind),
+			Value:  e.Value,
+		})
+	}
+}
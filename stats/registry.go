@@ -0,0 +1,207 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/naming"
+)
+
+// DefaultName is the name of the registry a simulation provides.
+const DefaultName = "Stats"
+
+// Registry holds the statistics of a simulation by name.
+type Registry struct {
+	mu    sync.Mutex
+	name  string
+	stats map[string]Stat
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry(name string) *Registry {
+	return &Registry{name: name, stats: map[string]Stat{}}
+}
+
+// Provider is implemented by registrars that own a registry, such as
+// simulation.Simulation.
+type Provider interface {
+	Stats() *Registry
+}
+
+// Of returns the registry of a registrar. Components call it with the
+// registrar their builder was given:
+//
+//	reads := stats.Of(b.registrar).NewCounter(name+".Reads", "reads served")
+//
+// A registrar that does not provide one, such as a standalone registrar in a
+// unit test, gets a new registry of its own, so components can always register
+// stats.
+func Of(reg modeling.Registrar) *Registry {
+	if p, ok := reg.(Provider); ok {
+		return p.Stats()
+	}
+
+	return NewRegistry(DefaultName)
+}
+
+// Name returns the name of the registry.
+func (r *Registry) Name() string {
+	return r.name
+}
+
+func (r *Registry) add(s Stat) {
+	naming.MustBeValid(s.Name())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, found := r.stats[s.Name()]; found {
+		panic(fmt.Sprintf("stats: %s is already registered", s.Name()))
+	}
+
+	r.stats[s.Name()] = s
+}
+
+// NewCounter registers a counter. It panics if the name is not a valid
+// hierarchical name or is taken.
+func (r *Registry) NewCounter(name, desc string) *Counter {
+	c := &Counter{statBase: statBase{name: name, desc: desc}}
+	r.add(c)
+
+	return c
+}
+
+// NewCounterFunc registers a counter whose count is read from fn, for counts
+// a component already keeps in its State. Reset then only moves the counter's
+// baseline; the component's own count is left alone.
+func (r *Registry) NewCounterFunc(name, desc string, fn func() uint64) *Counter {
+	c := &Counter{statBase: statBase{name: name, desc: desc}, fn: fn}
+	r.add(c)
+
+	return c
+}
+
+// NewGauge registers a gauge.
+func (r *Registry) NewGauge(name, desc string) *Gauge {
+	g := &Gauge{statBase: statBase{name: name, desc: desc}}
+	r.add(g)
+
+	return g
+}
+
+// NewHistogram registers a histogram with the given ascending bucket bounds.
+func (r *Registry) NewHistogram(name, desc string, bounds []float64) *Histogram {
+	if len(bounds) == 0 || !slices.IsSorted(bounds) {
+		panic(fmt.Sprintf("stats: histogram %s needs ascending bounds", name))
+	}
+
+	h := &Histogram{
+		statBase: statBase{name: name, desc: desc},
+		bounds:   append([]float64(nil), bounds...),
+		buckets:  make([]uint64, len(bounds)+1),
+	}
+	r.add(h)
+
+	return h
+}
+
+// NewFormula registers a formula.
+func (r *Registry) NewFormula(name, desc string, fn func() float64) *Formula {
+	f := &Formula{statBase: statBase{name: name, desc: desc}, fn: fn}
+	r.add(f)
+
+	return f
+}
+
+// Lookup returns the stat with the given name.
+func (r *Registry) Lookup(name string) (Stat, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, found := r.stats[name]
+
+	return s, found
+}
+
+// Stats returns the stats whose names are under the given prefix, i.e. equal
+// to it or starting with it and a dot, sorted by name. An empty prefix selects
+// every stat.
+func (r *Registry) Stats(prefix string) []Stat {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]Stat, 0, len(r.stats))
+
+	for name, s := range r.stats {
+		if prefix == "" || name == prefix || strings.HasPrefix(name, prefix+".") {
+			list = append(list, s)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+
+	return list
+}
+
+// Entries returns the scalar values of the stats under the prefix.
+func (r *Registry) Entries(prefix string) []Entry {
+	var entries []Entry
+	for _, s := range r.Stats(prefix) {
+		entries = append(entries, s.Entries()...)
+	}
+
+	return entries
+}
+
+// Reset starts a new measurement: counters and histograms restart from zero.
+// Gauges keep their level, and formulas follow the stats they read.
+func (r *Registry) Reset() {
+	for _, s := range r.Stats("") {
+		s.reset()
+	}
+}
+
+// SaveCheckpoint writes the state of every stat, so that a registry
+// registered as a simulation resource is checkpointed with the simulation.
+func (r *Registry) SaveCheckpoint(w io.Writer) error {
+	payload := map[string]json.RawMessage{}
+
+	for _, s := range r.Stats("") {
+		data, err := s.save()
+		if err != nil {
+			return fmt.Errorf("stats: save %s: %w", s.Name(), err)
+		}
+
+		payload[s.Name()] = data
+	}
+
+	return json.NewEncoder(w).Encode(payload)
+}
+
+// LoadCheckpoint restores the stats saved by SaveCheckpoint into the stats of
+// a rebuilt simulation. Every saved stat must be registered again.
+func (r *Registry) LoadCheckpoint(rd io.Reader) error {
+	var payload map[string]json.RawMessage
+	if err := json.NewDecoder(rd).Decode(&payload); err != nil {
+		return fmt.Errorf("stats: %w", err)
+	}
+
+	for name, data := range payload {
+		s, found := r.Lookup(name)
+		if !found {
+			return fmt.Errorf("stats: checkpoint has stat %s, which is not registered", name)
+		}
+
+		if err := s.load(data); err != nil {
+			return fmt.Errorf("stats: load %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
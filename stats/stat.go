@@ -0,0 +1,352 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Kind is the kind of a statistic.
+type Kind string
+
+// The kinds of statistics.
+const (
+	KindCounter   Kind = "counter"
+	KindGauge     Kind = "gauge"
+	KindHistogram Kind = "histogram"
+	KindFormula   Kind = "formula"
+)
+
+// A Stat is a named statistic in a Registry.
+type Stat interface {
+	// Name returns the hierarchical name, e.g. "GPU[0].DRAM.ReadCommands".
+	Name() string
+	// Desc returns the one-line description.
+	Desc() string
+	Kind() Kind
+
+	// Entries returns the stat as scalar values. Histograms expand into
+	// several entries whose names extend the stat's name with "::".
+	Entries() []Entry
+
+	reset()
+	save() (json.RawMessage, error)
+	load(data json.RawMessage) error
+}
+
+// Entry is one scalar value of a stat.
+type Entry struct {
+	Name  string  `json:"name"`
+	Kind  Kind    `json:"kind"`
+	Value float64 `json:"value"`
+	Desc  string  `json:"desc,omitempty"`
+}
+
+type statBase struct {
+	name string
+	desc string
+}
+
+func (s statBase) Name() string { return s.name }
+func (s statBase) Desc() string { return s.desc }
+
+// Counter is a monotonically increasing count, such as the number of reads.
+// Reset sets it back to zero.
+type Counter struct {
+	statBase
+
+	value atomic.Uint64
+
+	// fn computes the count from state the stat does not own, e.g. a
+	// component's State. Reset then moves the baseline instead.
+	fn       func() uint64
+	baseline atomic.Uint64
+}
+
+// Kind returns KindCounter.
+func (c *Counter) Kind() Kind { return KindCounter }
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds n to the counter. It panics for counters created with
+// NewCounterFunc.
+func (c *Counter) Add(n uint64) {
+	if c.fn != nil {
+		panic(fmt.Sprintf("stats: counter %s is computed and cannot be added to",
+			c.name))
+	}
+
+	c.value.Add(n)
+}
+
+// Value returns the count since the last reset.
+func (c *Counter) Value() uint64 {
+	if c.fn != nil {
+		v, baseline := c.fn(), c.baseline.Load()
+		if v < baseline {
+			// The owner reset its count after the baseline was taken.
+			return v
+		}
+
+		return v - baseline
+	}
+
+	return c.value.Load()
+}
+
+// Entries implements Stat.
+func (c *Counter) Entries() []Entry {
+	return []Entry{{Name: c.name, Kind: KindCounter, Value: float64(c.Value()), Desc: c.desc}}
+}
+
+func (c *Counter) reset() {
+	if c.fn != nil {
+		c.baseline.Store(c.fn())
+		return
+	}
+
+	c.value.Store(0)
+}
+
+type counterState struct {
+	Value    uint64 `json:"value,omitempty"`
+	Baseline uint64 `json:"baseline,omitempty"`
+}
+
+func (c *Counter) save() (json.RawMessage, error) {
+	return json.Marshal(counterState{Value: c.value.Load(), Baseline: c.baseline.Load()})
+}
+
+func (c *Counter) load(data json.RawMessage) error {
+	var s counterState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	c.value.Store(s.Value)
+	c.baseline.Store(s.Baseline)
+
+	return nil
+}
+
+// Gauge is a level that goes up and down, such as a queue occupancy. Reset
+// leaves it unchanged, as a level does not restart at a region of interest.
+type Gauge struct {
+	statBase
+
+	bits atomic.Uint64
+}
+
+// Kind returns KindGauge.
+func (g *Gauge) Kind() Kind { return KindGauge }
+
+// Set sets the gauge.
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Value returns the current level.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// Entries implements Stat.
+func (g *Gauge) Entries() []Entry {
+	return []Entry{{Name: g.name, Kind: KindGauge, Value: g.Value(), Desc: g.desc}}
+}
+
+func (g *Gauge) reset() {}
+
+func (g *Gauge) save() (json.RawMessage, error) {
+	return json.Marshal(g.Value())
+}
+
+func (g *Gauge) load(data json.RawMessage) error {
+	var v float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	g.Set(v)
+
+	return nil
+}
+
+// Formula is a value computed from other stats or from component state when
+// it is read, such as a hit rate. It has no state of its own; written in terms
+// of other stats, it follows their resets.
+type Formula struct {
+	statBase
+
+	fn func() float64
+}
+
+// Kind returns KindFormula.
+func (f *Formula) Kind() Kind { return KindFormula }
+
+// Value evaluates the formula.
+func (f *Formula) Value() float64 {
+	return f.fn()
+}
+
+// Entries implements Stat.
+func (f *Formula) Entries() []Entry {
+	return []Entry{{Name: f.name, Kind: KindFormula, Value: f.Value(), Desc: f.desc}}
+}
+
+func (f *Formula) reset() {}
+
+func (f *Formula) save() (json.RawMessage, error) {
+	return json.RawMessage("null"), nil
+}
+
+func (f *Formula) load(json.RawMessage) error {
+	return nil
+}
+
+// Histogram counts samples into buckets delimited by ascending bounds: one
+// bucket below the first bound, one between each pair of bounds, and one at
+// or above the last bound.
+type Histogram struct {
+	statBase
+
+	mu      sync.Mutex
+	bounds  []float64
+	buckets []uint64
+	count   uint64
+	sum     float64
+	min     float64
+	max     float64
+}
+
+// Kind returns KindHistogram.
+func (h *Histogram) Kind() Kind { return KindHistogram }
+
+// Observe adds a sample.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i := 0
+	for i < len(h.bounds) && v >= h.bounds[i] {
+		i++
+	}
+
+	h.buckets[i]++
+
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+
+	if h.count == 0 || v > h.max {
+		h.max = v
+	}
+
+	h.count++
+	h.sum += v
+}
+
+// HistogramSnapshot is the content of a histogram at one point in time.
+type HistogramSnapshot struct {
+	Bounds  []float64 `json:"bounds"`
+	Buckets []uint64  `json:"buckets"`
+	Count   uint64    `json:"count"`
+	Sum     float64   `json:"sum"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+}
+
+// Mean returns the average sample, or 0 without samples.
+func (s HistogramSnapshot) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return s.Sum / float64(s.Count)
+}
+
+// Snapshot returns a copy of the histogram's content.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return HistogramSnapshot{
+		Bounds:  append([]float64(nil), h.bounds...),
+		Buckets: append([]uint64(nil), h.buckets...),
+		Count:   h.count,
+		Sum:     h.sum,
+		Min:     h.min,
+		Max:     h.max,
+	}
+}
+
+// Entries implements Stat. Besides the summary entries ("::samples",
+// "::mean", "::min", "::max"), there is one entry per bucket, named by its
+// range, e.g. "::10-20", "::<10", and "::>=100".
+func (h *Histogram) Entries() []Entry {
+	s := h.Snapshot()
+	entry := func(suffix string, v float64) Entry {
+		return Entry{Name: h.name + "::" + suffix, Kind: KindHistogram, Value: v}
+	}
+
+	entries := []Entry{
+		{Name: h.name + "::samples", Kind: KindHistogram, Value: float64(s.Count), Desc: h.desc},
+		entry("mean", s.Mean()),
+		entry("min", s.Min),
+		entry("max", s.Max),
+	}
+
+	for i, n := range s.Buckets {
+		entries = append(entries, entry(bucketName(s.Bounds, i), float64(n)))
+	}
+
+	return entries
+}
+
+func bucketName(bounds []float64, i int) string {
+	switch {
+	case i == 0:
+		return fmt.Sprintf("<%g", bounds[0])
+	case i == len(bounds):
+		return fmt.Sprintf(">=%g", bounds[i-1])
+	default:
+		return fmt.Sprintf("%g-%g", bounds[i-1], bounds[i])
+	}
+}
+
+func (h *Histogram) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clear(h.buckets)
+	h.count, h.sum, h.min, h.max = 0, 0, 0, 0
+}
+
+func (h *Histogram) save() (json.RawMessage, error) {
+	return json.Marshal(h.Snapshot())
+}
+
+func (h *Histogram) load(data json.RawMessage) error {
+	var s HistogramSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(s.Buckets) != len(h.buckets) {
+		return fmt.Errorf("histogram %s has %d buckets, checkpoint has %d",
+			h.name, len(h.buckets), len(s.Buckets))
+	}
+
+	copy(h.buckets, s.Buckets)
+	h.count, h.sum, h.min, h.max = s.Count, s.Sum, s.Min, s.Max
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package stats_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/stats"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+func TestStatsResetAtRegionOfInterest(t *testing.T) {
+	reg := stats.NewRegistry("Stats")
+	owned := uint64(0)
+
+	reads := reg.NewCounter("Comp.Reads", "reads")
+	hits := reg.NewCounterFunc("Comp.Hits", "hits", func() uint64 { return owned })
+	queue := reg.NewGauge("Comp.QueueLength", "queue length")
+	rate := reg.NewFormula("Comp.HitRate", "hits per read", func() float64 {
+		return float64(hits.Value()) / float64(reads.Value())
+	})
+
+	reads.Add(4)
+	owned = 3
+	queue.Set(2)
+
+	if rate.Value() != 0.75 {
+		t.Fatalf("expected a hit rate of 0.75, got %v", rate.Value())
+	}
+
+	reg.Reset()
+	reads.Inc()
+	owned = 4
+
+	if reads.Value() != 1 || hits.Value() != 1 || queue.Value() != 2 || rate.Value() != 1 {
+		t.Fatalf("unexpected values after reset: %v %v %v %v",
+			reads.Value(), hits.Value(), queue.Value(), rate.Value())
+	}
+
+	// The owner resetting its own count must not underflow the counter.
+	owned = 0
+	if hits.Value() != 0 {
+		t.Fatalf("expected 0 after the owner reset, got %d", hits.Value())
+	}
+}
+
+func TestHistogramEntries(t *testing.T) {
+	reg := stats.NewRegistry("Stats")
+	latency := reg.NewHistogram("Comp.Latency", "latency", []float64{10, 20})
+
+	for _, v := range []float64{5, 12, 15, 30} {
+		latency.Observe(v)
+	}
+
+	got := map[string]float64{}
+	for _, e := range latency.Entries() {
+		got[e.Name] = e.Value
+	}
+
+	want := map[string]float64{
+		"Comp.Latency::samples": 4, "Comp.Latency::mean": 15.5,
+		"Comp.Latency::min": 5, "Comp.Latency::max": 30,
+		"Comp.Latency::<10": 1, "Comp.Latency::10-20": 2, "Comp.Latency::>=20": 1,
+	}
+
+	for name, v := range want {
+		if got[name] != v {
+			t.Fatalf("expected %s = %v, got %v", name, v, got)
+		}
+	}
+}
+
+func TestRegistryRejectsBadNames(t *testing.T) {
+	reg := stats.NewRegistry("Stats")
+	reg.NewCounter("Comp.Reads", "")
+
+	for _, name := range []string{"Comp.Reads", "comp.reads"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected a panic for %q", name)
+				}
+			}()
+
+			reg.NewCounter(name, "")
+		}()
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	reg := stats.NewRegistry("Stats")
+	reg.NewCounter("GPU[0].L2.Hits", "cache hits").Add(7)
+	reg.NewCounter("GPU[1].L2.Hits", "cache hits").Add(9)
+	reg.NewHistogram("GPU[0].L2.Latency", "", []float64{100}).Observe(42)
+
+	var text bytes.Buffer
+	if err := reg.WriteText(&text, "GPU[0]"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(text.String(), "GPU[0].L2.Hits") ||
+		!strings.Contains(text.String(), "7  # cache hits") ||
+		!strings.Contains(text.String(), "GPU[0].L2.Latency::<100") ||
+		strings.Contains(text.String(), "GPU[1]") {
+		t.Fatalf("unexpected text dump:\n%s", text.String())
+	}
+
+	var out bytes.Buffer
+	if err := reg.WriteJSON(&out, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed []stats.StatJSON
+	if err := json.Unmarshal(out.Bytes(), &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed) != 3 || parsed[1].Histogram == nil || parsed[1].Value != 42 {
+		t.Fatalf("unexpected JSON dump %+v", parsed)
+	}
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	build := func() (*stats.Registry, *stats.Counter, *stats.Histogram) {
+		reg := stats.NewRegistry("Stats")
+		c := reg.NewCounter("Comp.Reads", "")
+		h := reg.NewHistogram("Comp.Latency", "", []float64{10})
+
+		return reg, c, h
+	}
+
+	reg, reads, latency := build()
+	reads.Add(5)
+	latency.Observe(3)
+	latency.Observe(30)
+
+	var buf bytes.Buffer
+	if err := reg.SaveCheckpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, restoredReads, restoredLatency := build()
+	if err := restored.LoadCheckpoint(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	if restoredReads.Value() != 5 || restoredLatency.Snapshot().Max != 30 {
+		t.Fatal("the checkpoint did not restore the stats")
+	}
+
+	if err := stats.NewRegistry("Stats").LoadCheckpoint(
+		bytes.NewReader(buf.Bytes())); err == nil {
+		t.Fatal("expected an error for stats that are not registered")
+	}
+}
+
+func TestOfStandaloneRegistrar(t *testing.T) {
+	reg := stats.Of(modeling.NewStandaloneRegistrar(timing.NewSerialEngine()))
+	if reg == nil {
+		t.Fatal("a standalone registrar must still give a registry")
+	}
+}
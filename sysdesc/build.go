@@ -0,0 +1,413 @@
+package sysdesc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/naming"
+)
+
+// defaultConnectionType is used when a connection entry omits its type.
+const defaultConnectionType = "direct"
+
+// System holds what Build created, keyed by name.
+type System struct {
+	Components  map[string]Component
+	Connections map[string]messaging.Connection
+	Domains     map[string]*modeling.Domain
+
+	registrar modeling.Registrar
+	portSpecs map[string]modeling.PortSpec
+	ports     map[string]messaging.Port
+	plugged   map[string]string
+}
+
+// Port returns the built port for a "Component.Port" reference, or nil if the
+// reference does not name a built port.
+func (s *System) Port(ref string) messaging.Port {
+	return s.ports[ref]
+}
+
+// Build builds every component, port, connection, and domain of the
+// description into the registrar, normally a *simulation.Simulation. It
+// returns an error naming the offending entry if a type is not registered, a
+// spec or resource is invalid, a reference does not resolve, or the ports on
+// a connection do not speak complementary protocol roles.
+func (d *Description) Build(reg modeling.Registrar) (*System, error) {
+	s := &System{
+		Components:  map[string]Component{},
+		Connections: map[string]messaging.Connection{},
+		Domains:     map[string]*modeling.Domain{},
+		registrar:   reg,
+		portSpecs:   map[string]modeling.PortSpec{},
+		ports:       map[string]messaging.Port{},
+		plugged:     map[string]string{},
+	}
+
+	ctxs, err := s.buildComponents(d.Components)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.buildPorts(d.Components); err != nil {
+		return nil, err
+	}
+
+	for _, cd := range d.Connections {
+		if err := s.buildConnection(cd); err != nil {
+			return nil, fmt.Errorf("sysdesc: connection %q: %w", cd.Name, err)
+		}
+	}
+
+	for _, dd := range d.Domains {
+		if err := s.buildDomain(dd); err != nil {
+			return nil, fmt.Errorf("sysdesc: domain %q: %w", dd.Name, err)
+		}
+	}
+
+	for _, ctx := range ctxs {
+		if err := s.finishComponent(ctx); err != nil {
+			return nil, fmt.Errorf("sysdesc: component %q: %w", ctx.Name, err)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *System) buildComponents(descs []ComponentDesc) ([]*BuildContext, error) {
+	ctxs := make([]*BuildContext, 0, len(descs))
+
+	for _, cd := range descs {
+		if cd.Name == "" {
+			return nil, fmt.Errorf("sysdesc: component of type %q has no name", cd.Type)
+		}
+
+		if _, found := s.Components[cd.Name]; found {
+			return nil, fmt.Errorf("sysdesc: component %q is described twice", cd.Name)
+		}
+
+		ctx := &BuildContext{
+			Registrar: s.registrar,
+			Name:      cd.Name,
+			spec:      cd.Spec,
+			resources: cd.Resources,
+			used:      map[string]bool{},
+			system:    s,
+		}
+
+		comp, err := s.buildComponent(ctx, cd.Type)
+		if err != nil {
+			return nil, fmt.Errorf("sysdesc: component %q: %w", cd.Name, err)
+		}
+
+		s.Components[cd.Name] = comp
+		ctxs = append(ctxs, ctx)
+
+		for port, pd := range cd.Ports {
+			if pd.BufSize <= 0 {
+				return nil, fmt.Errorf(
+					"sysdesc: component %q: port %q: buf_size must be positive",
+					cd.Name, port)
+			}
+
+			s.portSpecs[cd.Name+"."+port] = modeling.PortSpec{BufSize: pd.BufSize}
+		}
+	}
+
+	return ctxs, nil
+}
+
+func (s *System) buildComponent(ctx *BuildContext, typeName string) (Component, error) {
+	factory, err := lookupComponentFactory(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var comp Component
+
+	err = recoverBuild(func() error {
+		var err error
+		comp, err = factory(ctx)
+
+		return err
+	})
+
+	return comp, err
+}
+
+// buildPorts builds a port for every port the components declare, in
+// description order so ports register in a stable order. Ports the
+// description lists but the component does not declare are errors.
+func (s *System) buildPorts(descs []ComponentDesc) error {
+	for _, cd := range descs {
+		names := append(sortedKeys(cd.Ports), s.Components[cd.Name].DeclaredPorts()...)
+
+		for _, port := range names {
+			if _, err := s.resolvePort(cd.Name + "." + port); err != nil {
+				return fmt.Errorf("sysdesc: component %q: %w", cd.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *System) finishComponent(ctx *BuildContext) error {
+	for _, wire := range ctx.wiring {
+		if err := recoverBuild(wire); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range sortedKeys(ctx.resources) {
+		if !ctx.used[key] {
+			return fmt.Errorf("unknown resource %q", key)
+		}
+	}
+
+	return nil
+}
+
+// resolvePort returns the port a "Component.Port" reference names, building
+// and assigning it on first use.
+func (s *System) resolvePort(ref string) (messaging.Port, error) {
+	if port, found := s.ports[ref]; found {
+		return port, nil
+	}
+
+	comp, portName, err := s.splitRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := portRoles(comp, portName); err != nil {
+		return nil, err
+	}
+
+	spec, found := s.portSpecs[ref]
+	if !found {
+		spec = modeling.DefaultPortSpec()
+	}
+
+	var port messaging.Port
+
+	err = recoverBuild(func() error {
+		port = modeling.MakePortBuilder().
+			WithRegistrar(s.registrar).
+			WithComponent(comp).
+			WithSpec(spec).
+			Build(portName)
+		comp.AssignPort(portName, port)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.ports[ref] = port
+
+	return port, nil
+}
+
+// splitRef splits a "Component.Port" reference at its last dot, since
+// component names are themselves dotted.
+func (s *System) splitRef(ref string) (Component, string, error) {
+	i := strings.LastIndex(ref, ".")
+	if i <= 0 || i == len(ref)-1 {
+		return nil, "", fmt.Errorf("port reference %q is not of the form Component.Port", ref)
+	}
+
+	comp, found := s.Components[ref[:i]]
+	if !found {
+		return nil, "", fmt.Errorf("port reference %q: no component %q", ref, ref[:i])
+	}
+
+	return comp, ref[i+1:], nil
+}
+
+// pluggedPort is a port on a connection with the roles it was declared with.
+type pluggedPort struct {
+	ref   string
+	port  messaging.Port
+	roles []*messaging.Role
+}
+
+func (s *System) buildConnection(cd ConnectionDesc) error {
+	if _, found := s.Connections[cd.Name]; found {
+		return fmt.Errorf("described twice")
+	}
+
+	if len(cd.Ports) < 2 {
+		return fmt.Errorf("needs at least two ports, has %d", len(cd.Ports))
+	}
+
+	plugged := make([]pluggedPort, 0, len(cd.Ports))
+
+	for _, ref := range cd.Ports {
+		if other, found := s.plugged[ref]; found {
+			return fmt.Errorf("port %s is already plugged into %q", ref, other)
+		}
+
+		port, err := s.resolvePort(ref)
+		if err != nil {
+			return err
+		}
+
+		comp, portName, _ := s.splitRef(ref)
+		roles, _ := portRoles(comp, portName)
+
+		plugged = append(plugged, pluggedPort{ref: ref, port: port, roles: roles})
+		s.plugged[ref] = cd.Name
+	}
+
+	if err := checkRoles(plugged); err != nil {
+		return err
+	}
+
+	typeName := cd.Type
+	if typeName == "" {
+		typeName = defaultConnectionType
+	}
+
+	factory, err := lookupConnectionFactory(typeName)
+	if err != nil {
+		return err
+	}
+
+	ctx := &BuildContext{
+		Registrar: s.registrar,
+		Name:      cd.Name,
+		spec:      cd.Spec,
+		used:      map[string]bool{},
+		system:    s,
+	}
+
+	var conn messaging.Connection
+
+	err = recoverBuild(func() error {
+		var err error
+		if conn, err = factory(ctx); err != nil {
+			return err
+		}
+
+		for _, p := range plugged {
+			conn.PlugIn(p.port)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Connections[cd.Name] = conn
+
+	return nil
+}
+
+// checkRoles requires every typed port on a connection to have a peer that
+// speaks another role of the same protocol, e.g. a memory requester needs a
+// memory responder. Untyped ports are not checked.
+func checkRoles(plugged []pluggedPort) error {
+	for i, p := range plugged {
+		for _, role := range p.roles {
+			if !hasComplement(plugged, i, role) {
+				return fmt.Errorf(
+					"port %s speaks %s %s, but no other port on the connection "+
+						"speaks a complementary %s role",
+					p.ref, role.Protocol().Name(), role.Name(),
+					role.Protocol().Name())
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasComplement(plugged []pluggedPort, self int, role *messaging.Role) bool {
+	for j, q := range plugged {
+		if j == self {
+			continue
+		}
+
+		for _, peer := range q.roles {
+			if peer.Protocol() == role.Protocol() && peer != role {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *System) buildDomain(dd DomainDesc) error {
+	if _, found := s.Domains[dd.Name]; found {
+		return fmt.Errorf("described twice")
+	}
+
+	var domain *modeling.Domain
+
+	if err := recoverBuild(func() error {
+		domain = modeling.NewDomain(dd.Name)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, boundary := range sortedKeys(dd.Ports) {
+		ref := dd.Ports[boundary]
+
+		port, err := s.resolvePort(ref)
+		if err != nil {
+			return err
+		}
+
+		comp, portName, _ := s.splitRef(ref)
+		roles, _ := portRoles(comp, portName)
+
+		domain.DeclarePort(boundary, roles...)
+		domain.AssignPort(boundary, port)
+	}
+
+	s.Domains[dd.Name] = domain
+
+	if dr, ok := s.registrar.(domainRegistrar); ok {
+		dr.RegisterDomain(domain)
+	}
+
+	return nil
+}
+
+// domainRegistrar is a registrar that clusters the components of the domains
+// registered with it, as *simulation.Simulation does for its topology.
+type domainRegistrar interface {
+	RegisterDomain(d naming.Named)
+}
+
+// portRoles returns the roles a component declared for a port, or an error if
+// the component does not declare it.
+func portRoles(comp Component, name string) (roles []*messaging.Role, err error) {
+	defer func() {
+		if recover() != nil {
+			err = fmt.Errorf("component %q does not declare port %q",
+				comp.Name(), name)
+		}
+	}()
+
+	return comp.PortRoles(name), nil
+}
+
+// recoverBuild runs f and turns a builder panic, the way builders reject an
+// invalid configuration, into an error.
+func recoverBuild(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return f()
+}
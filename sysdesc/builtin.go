@@ -0,0 +1,135 @@
+package sysdesc
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v5/mem/acceptancetests/memaccessagent"
+	"github.com/sarchlab/akita/v5/mem/cache/writeback"
+	"github.com/sarchlab/akita/v5/mem/cache/writethroughcache"
+	"github.com/sarchlab/akita/v5/mem/dram"
+	"github.com/sarchlab/akita/v5/mem/idealmemcontroller"
+	"github.com/sarchlab/akita/v5/mem/rob"
+	"github.com/sarchlab/akita/v5/mem/simplebankedmemory"
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/noc/directconnection"
+	"github.com/sarchlab/akita/v5/noc/link"
+)
+
+// The in-tree components whose configuration fits in their Spec. Components
+// that need Go objects wired in (a page table, a translation mapper) are left
+// to hand-written assembly.
+func init() {
+	RegisterComponent("idealmemcontroller", SpecFactory(idealmemcontroller.DefaultSpec,
+		func(reg modeling.Registrar, spec idealmemcontroller.Spec, name string) Component {
+			return idealmemcontroller.MakeBuilder().WithRegistrar(reg).WithSpec(spec).Build(name)
+		}))
+
+	RegisterComponent("simplebankedmemory", SpecFactory(simplebankedmemory.DefaultSpec,
+		func(reg modeling.Registrar, spec simplebankedmemory.Spec, name string) Component {
+			return simplebankedmemory.MakeBuilder().WithRegistrar(reg).WithSpec(spec).Build(name)
+		}))
+
+	RegisterComponent("dram", SpecFactory(dram.DefaultSpec,
+		func(reg modeling.Registrar, spec dram.Spec, name string) Component {
+			return dram.MakeBuilder().WithRegistrar(reg).WithSpec(spec).Build(name)
+		}))
+
+	RegisterComponent("rob", SpecFactory(rob.DefaultSpec,
+		func(reg modeling.Registrar, spec rob.Spec, name string) Component {
+			return rob.MakeBuilder().WithRegistrar(reg).WithSpec(spec).Build(name)
+		}))
+
+	// The caches take their low modules from Spec.RemotePortNames, which the
+	// builders otherwise expect as Resources.RemotePorts.
+	RegisterComponent("writeback", SpecFactory(writeback.DefaultSpec,
+		func(reg modeling.Registrar, spec writeback.Spec, name string) Component {
+			return writeback.MakeBuilder().
+				WithRegistrar(reg).
+				WithSpec(spec).
+				WithResources(writeback.Resources{
+					RemotePorts: remotePorts(spec.RemotePortNames),
+				}).
+				Build(name)
+		}))
+
+	RegisterComponent("writethroughcache", SpecFactory(writethroughcache.DefaultSpec,
+		func(reg modeling.Registrar, spec writethroughcache.Spec, name string) Component {
+			return writethroughcache.MakeBuilder().
+				WithRegistrar(reg).
+				WithSpec(spec).
+				WithResources(writethroughcache.Resources{
+					RemotePorts: remotePorts(spec.RemotePortNames),
+				}).
+				Build(name)
+		}))
+
+	RegisterComponent("memaccessagent", buildMemAccessAgent)
+
+	RegisterConnection(defaultConnectionType,
+		func(ctx *BuildContext) (messaging.Connection, error) {
+			spec := directconnection.DefaultSpec()
+			if err := ctx.DecodeSpec(&spec); err != nil {
+				return nil, err
+			}
+
+			return directconnection.MakeBuilder().
+				WithRegistrar(ctx.Registrar).
+				WithSpec(spec).
+				Build(ctx.Name), nil
+		})
+
+	RegisterConnection("link",
+		func(ctx *BuildContext) (messaging.Connection, error) {
+			spec := link.DefaultSpec()
+			if err := ctx.DecodeSpec(&spec); err != nil {
+				return nil, err
+			}
+
+			return link.MakeBuilder().
+				WithRegistrar(ctx.Registrar).
+				WithSpec(spec).
+				Build(ctx.Name), nil
+		})
+}
+
+func remotePorts(names []string) []messaging.RemotePort {
+	ports := make([]messaging.RemotePort, len(names))
+	for i, n := range names {
+		ports[i] = messaging.RemotePort(n)
+	}
+
+	return ports
+}
+
+// buildMemAccessAgent builds a memory access agent. Its "low_module" resource
+// names the port the agent sends requests to.
+func buildMemAccessAgent(ctx *BuildContext) (Component, error) {
+	spec := memaccessagent.DefaultSpec()
+	if err := ctx.DecodeSpec(&spec); err != nil {
+		return nil, err
+	}
+
+	agent := memaccessagent.MakeBuilder().
+		WithRegistrar(ctx.Registrar).
+		WithSpec(spec).
+		Build(ctx.Name)
+
+	ref, found := ctx.Resource("low_module")
+	if !found {
+		return nil, fmt.Errorf("resource low_module is required")
+	}
+
+	ctx.AfterPorts(func() error {
+		port, err := ctx.Port(ref)
+		if err != nil {
+			return fmt.Errorf("low_module: %w", err)
+		}
+
+		agent.LowModule = port
+
+		return nil
+	})
+
+	return agent, nil
+}
@@ -0,0 +1,96 @@
+package sysdesc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Description is a parsed system description file.
+type Description struct {
+	Components  []ComponentDesc  `json:"components" yaml:"components"`
+	Connections []ConnectionDesc `json:"connections" yaml:"connections"`
+	Domains     []DomainDesc     `json:"domains" yaml:"domains"`
+}
+
+// ComponentDesc describes one component instance.
+type ComponentDesc struct {
+	// Name is the component's hierarchical name, e.g. "GPU[0].L2".
+	Name string `json:"name" yaml:"name"`
+
+	// Type selects the factory registered with RegisterComponent.
+	Type string `json:"type" yaml:"type"`
+
+	// Spec overrides fields of the component's default Spec, keyed by the
+	// fields' JSON names.
+	Spec map[string]any `json:"spec,omitempty" yaml:"spec,omitempty"`
+
+	// Resources names external objects the factory wires in, as references
+	// such as "DRAM.Top". The keys a type accepts are documented with its
+	// factory.
+	Resources map[string]string `json:"resources,omitempty" yaml:"resources,omitempty"`
+
+	// Ports configures the port instances built for the component's declared
+	// ports, keyed by logical port name.
+	Ports map[string]PortDesc `json:"ports,omitempty" yaml:"ports,omitempty"`
+}
+
+// PortDesc configures one port instance.
+type PortDesc struct {
+	BufSize int `json:"buf_size" yaml:"buf_size"`
+}
+
+// ConnectionDesc describes one connection and the ports plugged into it.
+type ConnectionDesc struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Type selects the factory registered with RegisterConnection. It defaults
+	// to "direct".
+	Type string         `json:"type,omitempty" yaml:"type,omitempty"`
+	Spec map[string]any `json:"spec,omitempty" yaml:"spec,omitempty"`
+
+	// Ports lists the plugged ports as "Component.Port" references.
+	Ports []string `json:"ports" yaml:"ports"`
+}
+
+// DomainDesc describes a domain and the internal ports it exposes.
+type DomainDesc struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Ports maps a boundary port name to the internal "Component.Port" it
+	// exposes.
+	Ports map[string]string `json:"ports" yaml:"ports"`
+}
+
+// Parse parses a system description in YAML or JSON. Unknown top-level or
+// per-entry keys are errors.
+func Parse(data []byte) (*Description, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	desc := &Description{}
+	if err := dec.Decode(desc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("sysdesc: %w", err)
+	}
+
+	return desc, nil
+}
+
+// LoadFile reads and parses a system description file.
+func LoadFile(path string) (*Description, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("sysdesc: %w", err)
+	}
+
+	desc, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return desc, nil
+}
@@ -0,0 +1,48 @@
+// Package sysdesc builds a simulated system from a declarative description
+// file instead of hand-written builder code.
+//
+// A description lists components with their type and Spec values, the ports
+// to build for them, the connections that join those ports, and the domains
+// that expose ports at a boundary. It can be written in YAML or JSON:
+//
+//	components:
+//	  - name: Agent
+//	    type: memaccessagent
+//	    spec: {max_address: 1048576, write_left: 100, read_left: 100}
+//	    resources: {low_module: DRAM.Top}
+//	    ports:
+//	      Mem: {buf_size: 4}
+//	  - name: DRAM
+//	    type: idealmemcontroller
+//	    spec: {latency: 100}
+//	connections:
+//	  - name: Conn
+//	    type: link
+//	    spec: {latency: 20, bytes_per_cycle: 64}
+//	    ports: [Agent.Mem, DRAM.Top]
+//	domains:
+//	  - name: Memory
+//	    ports: {Top: DRAM.Top}
+//
+// Spec keys are the JSON names of the component's Spec fields; unset fields
+// keep the builder's DefaultSpec values, and unknown keys are errors. Every
+// port a component declares is built; ports the description does not list
+// use modeling.DefaultPortSpec.
+//
+// Components and connections are built by factories registered under a type
+// name with RegisterComponent and RegisterConnection. The in-tree components
+// are registered by this package; simulators register their own in an init
+// function. Build checks that every port on a connection speaks a protocol
+// role that some other port on the connection complements, so a requester
+// cannot be wired to another requester by mistake:
+//
+//	desc, err := sysdesc.LoadFile("system.yaml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	sys, err := desc.Build(sim)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+package sysdesc
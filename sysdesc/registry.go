@@ -0,0 +1,193 @@
+package sysdesc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sarchlab/akita/v5/messaging"
+	"github.com/sarchlab/akita/v5/modeling"
+)
+
+// Component is what a component factory returns: a messaging component whose
+// declared ports carry protocol roles.
+type Component interface {
+	messaging.Component
+	DeclaredPorts() []string
+	PortRoles(name string) []*messaging.Role
+}
+
+// ComponentFactory builds one component described by a ComponentDesc. It
+// decodes the spec with ctx.DecodeSpec, builds through the component's
+// builder with ctx.Registrar, and returns the component with its ports
+// declared but not yet assigned.
+type ComponentFactory func(ctx *BuildContext) (Component, error)
+
+// ConnectionFactory builds one connection described by a ConnectionDesc. The
+// loader plugs the ports in after it returns.
+type ConnectionFactory func(ctx *BuildContext) (messaging.Connection, error)
+
+var (
+	registryMu          sync.Mutex
+	componentFactories  = map[string]ComponentFactory{}
+	connectionFactories = map[string]ConnectionFactory{}
+)
+
+// RegisterComponent makes a component type available to descriptions. It
+// panics if the type name is already registered.
+func RegisterComponent(typeName string, f ComponentFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, found := componentFactories[typeName]; found {
+		panic(fmt.Sprintf("sysdesc: component type %q already registered", typeName))
+	}
+
+	componentFactories[typeName] = f
+}
+
+// RegisterConnection makes a connection type available to descriptions. It
+// panics if the type name is already registered.
+func RegisterConnection(typeName string, f ConnectionFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, found := connectionFactories[typeName]; found {
+		panic(fmt.Sprintf("sysdesc: connection type %q already registered", typeName))
+	}
+
+	connectionFactories[typeName] = f
+}
+
+// ComponentTypes returns the registered component type names, sorted.
+func ComponentTypes() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	return sortedKeys(componentFactories)
+}
+
+// ConnectionTypes returns the registered connection type names, sorted.
+func ConnectionTypes() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	return sortedKeys(connectionFactories)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func lookupComponentFactory(typeName string) (ComponentFactory, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	f, found := componentFactories[typeName]
+	if !found {
+		return nil, fmt.Errorf("unknown component type %q (registered: %v)",
+			typeName, sortedKeys(componentFactories))
+	}
+
+	return f, nil
+}
+
+func lookupConnectionFactory(typeName string) (ConnectionFactory, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	f, found := connectionFactories[typeName]
+	if !found {
+		return nil, fmt.Errorf("unknown connection type %q (registered: %v)",
+			typeName, sortedKeys(connectionFactories))
+	}
+
+	return f, nil
+}
+
+// BuildContext is what a factory sees of the description entry it builds.
+type BuildContext struct {
+	// Registrar is the registrar the system is built into. Pass it to the
+	// builder's WithRegistrar.
+	Registrar modeling.Registrar
+
+	// Name is the name of the component or connection to build.
+	Name string
+
+	spec      map[string]any
+	resources map[string]string
+	used      map[string]bool
+	system    *System
+	wiring    []func() error
+}
+
+// DecodeSpec overlays the description's spec values onto spec, which should
+// point at a copy of the builder's DefaultSpec. Keys are matched against the
+// fields' JSON names; an unknown key is an error.
+func (c *BuildContext) DecodeSpec(spec any) error {
+	if len(c.spec) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(c.spec)
+	if err != nil {
+		return fmt.Errorf("spec: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(spec); err != nil {
+		return fmt.Errorf("spec: %w", err)
+	}
+
+	return nil
+}
+
+// Resource returns the reference the description gives for a resource key.
+// Keys that no factory asks for are reported as errors after the build.
+func (c *BuildContext) Resource(key string) (string, bool) {
+	c.used[key] = true
+	ref, found := c.resources[key]
+
+	return ref, found
+}
+
+// Port resolves a "Component.Port" reference to the built port. Ports are
+// only available once every component has been built, so call it from a
+// function passed to AfterPorts.
+func (c *BuildContext) Port(ref string) (messaging.Port, error) {
+	return c.system.resolvePort(ref)
+}
+
+// AfterPorts defers wiring that needs other components' ports until all
+// components and ports are built.
+func (c *BuildContext) AfterPorts(f func() error) {
+	c.wiring = append(c.wiring, f)
+}
+
+// SpecFactory returns a ComponentFactory for a builder configured by its Spec
+// alone. defaultSpec supplies the starting values that the description
+// overrides, and build runs the builder.
+func SpecFactory[S any](
+	defaultSpec func() S,
+	build func(reg modeling.Registrar, spec S, name string) Component,
+) ComponentFactory {
+	return func(ctx *BuildContext) (Component, error) {
+		spec := defaultSpec()
+		if err := ctx.DecodeSpec(&spec); err != nil {
+			return nil, err
+		}
+
+		return build(ctx.Registrar, spec, ctx.Name), nil
+	}
+}
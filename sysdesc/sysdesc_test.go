@@ -0,0 +1,231 @@
+package sysdesc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sarchlab/akita/v5/mem/acceptancetests/memaccessagent"
+	"github.com/sarchlab/akita/v5/mem/memprotocol"
+	"github.com/sarchlab/akita/v5/modeling"
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+const agentAndMemory = `
+components:
+  - name: Agent
+    type: memaccessagent
+    spec: {max_address: 4096, write_left: 20, read_left: 20}
+    resources: {low_module: DRAM.Top}
+    ports:
+      Mem: {buf_size: 4}
+  - name: DRAM
+    type: idealmemcontroller
+    spec: {latency: 10, capacity: 4096}
+    ports:
+      Top: {buf_size: 4}
+connections:
+  - name: Conn
+    ports: [Agent.Mem, DRAM.Top]
+domains:
+  - name: Memory
+    ports: {Top: DRAM.Top}
+`
+
+func buildDesc(t *testing.T, text string) (*System, timing.Engine, error) {
+	t.Helper()
+
+	desc, err := Parse([]byte(text))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	engine := timing.NewSerialEngine()
+	sys, err := desc.Build(modeling.NewStandaloneRegistrar(engine))
+
+	return sys, engine, err
+}
+
+func TestBuildRunsDescribedSystem(t *testing.T) {
+	sys, engine, err := buildDesc(t, agentAndMemory)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	agent := sys.Components["Agent"].(*memaccessagent.MemAccessAgent)
+	if agent.LowModule != sys.Port("DRAM.Top") {
+		t.Fatalf("low_module not wired")
+	}
+
+	if agent.Spec().WriteLeft != 20 || agent.Spec().Freq != 1*timing.GHz {
+		t.Fatalf("spec not overlaid on defaults: %+v", agent.Spec())
+	}
+
+	agent.TickLater()
+
+	if err := engine.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if agent.State.WriteLeft > 0 || agent.State.ReadLeft > 0 ||
+		len(agent.State.PendingReadReq) > 0 {
+		t.Fatalf("agent did not finish: %+v", agent.State)
+	}
+
+	domain := sys.Domains["Memory"]
+	if domain.GetPortByName("Top") != sys.Port("DRAM.Top") ||
+		domain.PortRoles("Top")[0] != memprotocol.Responder {
+		t.Fatalf("domain port not exposed with its role")
+	}
+}
+
+func TestBuildRunsSystemOverLink(t *testing.T) {
+	overLink := strings.Replace(agentAndMemory, "  - name: Conn\n",
+		"  - name: Conn\n    type: link\n"+
+			"    spec: {latency: 20, bytes_per_cycle: 8, buffer_size: 2}\n", 1)
+
+	direct := runAgent(t, agentAndMemory)
+	linked := runAgent(t, overLink)
+
+	if linked <= direct {
+		t.Fatalf("the link did not slow the agent down: %d ps, direct %d ps",
+			linked, direct)
+	}
+}
+
+// runAgent builds the description, runs its agent to completion, and returns
+// the time it finished.
+func runAgent(t *testing.T, text string) timing.VTimeInPicoSec {
+	t.Helper()
+
+	sys, engine, err := buildDesc(t, text)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	agent := sys.Components["Agent"].(*memaccessagent.MemAccessAgent)
+	agent.TickLater()
+
+	if err := engine.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if agent.State.WriteLeft > 0 || agent.State.ReadLeft > 0 ||
+		len(agent.State.PendingReadReq) > 0 {
+		t.Fatalf("agent did not finish: %+v", agent.State)
+	}
+
+	return engine.CurrentTime()
+}
+
+func TestLoadFileAcceptsJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "system.json")
+	json := `{
+		"components": [
+			{"name": "A", "type": "idealmemcontroller"},
+			{"name": "B", "type": "simplebankedmemory"}
+		]
+	}`
+
+	if err := os.WriteFile(path, []byte(json), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	desc, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(desc.Components) != 2 || desc.Components[1].Type != "simplebankedmemory" {
+		t.Fatalf("unexpected description %+v", desc)
+	}
+}
+
+func TestBuildRejectsInvalidDescriptions(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "mismatched roles",
+			text: `
+components:
+  - {name: A, type: idealmemcontroller}
+  - {name: B, type: idealmemcontroller}
+connections:
+  - {name: Conn, ports: [A.Top, B.Top]}
+`,
+			want: "no other port on the connection speaks a complementary mem role",
+		},
+		{
+			name: "unknown type",
+			text: `
+components:
+  - {name: A, type: quantumcache}
+`,
+			want: `unknown component type "quantumcache"`,
+		},
+		{
+			name: "unknown spec key",
+			text: `
+components:
+  - {name: A, type: idealmemcontroller, spec: {latncy: 3}}
+`,
+			want: `unknown field "latncy"`,
+		},
+		{
+			name: "undeclared port",
+			text: `
+components:
+  - {name: A, type: idealmemcontroller, ports: {Bottom: {buf_size: 2}}}
+`,
+			want: `does not declare port "Bottom"`,
+		},
+		{
+			name: "unknown resource",
+			text: `
+components:
+  - {name: A, type: idealmemcontroller, resources: {storage: X}}
+`,
+			want: `unknown resource "storage"`,
+		},
+		{
+			name: "port on two connections",
+			text: `
+components:
+  - {name: A, type: memaccessagent, resources: {low_module: M.Top}}
+  - {name: M, type: idealmemcontroller}
+connections:
+  - {name: C1, ports: [A.Mem, M.Top]}
+  - {name: C2, ports: [A.Mem, M.Top]}
+`,
+			want: `already plugged into "C1"`,
+		},
+		{
+			name: "builder rejects spec",
+			text: `
+components:
+  - {name: A, type: dram, spec: {num_channel: 3}}
+`,
+			want: "NumChannel must be a power of 2",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, _, err := buildDesc(t, c.text)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("expected error containing %q, got %v", c.want, err)
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnknownKeys(t *testing.T) {
+	_, err := Parse([]byte("components:\n  - {name: A, typ: dram}\n"))
+	if err == nil || !strings.Contains(err.Error(), "typ") {
+		t.Fatalf("expected an unknown-key error, got %v", err)
+	}
+}
@@ -33,3 +33,11 @@ type Engine interface {
 	// Continue will continue the paused simulation.
 	Continue()
 }
+
+// A Stoppable engine can make a running Run return early, leaving the
+// remaining events queued so that a later Run continues from where it stopped.
+type Stoppable interface {
+	// Stop makes Run return once the events being handled are done. It can be
+	// called from a hook or a handler.
+	Stop()
+}
@@ -6,8 +6,10 @@ import (
 	"reflect"
 	"runtime"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/profiling"
 )
 
 // A ParallelEngine is an event engine that is capable for scheduling event
@@ -16,6 +18,7 @@ type ParallelEngine struct {
 	hooking.HookableBase
 
 	pauseLock              sync.Mutex
+	stopping               atomic.Bool
 	nowLock                sync.RWMutex
 	now                    VTimeInPicoSec
 	runningSecondaryEvents bool
@@ -30,6 +33,7 @@ type ParallelEngine struct {
 	secondaryQueueChan chan EventQueue
 
 	registry map[string]Handler
+	profiler *profiling.Profiler
 }
 
 // Name returns the name of the engine. The engine is registered as a simulation
@@ -72,9 +76,20 @@ func NewParallelEngine() *ParallelEngine {
 
 // RegisterHandler registers a handler with the given name.
 func (e *ParallelEngine) RegisterHandler(name string, handler Handler) {
+	if e.profiler != nil {
+		handler = profileHandler(e.profiler, name, handler)
+	}
+
 	e.registry[name] = handler
 }
 
+// EnableProfiling times the calls to every handler, registered before or
+// after, on the profiler. It must be called before the engine runs.
+func (e *ParallelEngine) EnableProfiling(p *profiling.Profiler) {
+	e.profiler = p
+	profileRegistry(p, e.registry)
+}
+
 func (e *ParallelEngine) readNow() VTimeInPicoSec {
 	var now VTimeInPicoSec
 
@@ -117,12 +132,20 @@ func (e *ParallelEngine) Schedule(evt Event) {
 
 // Run processes all the events scheduled in the ParallelEngine.
 func (e *ParallelEngine) Run() error {
+	e.stopping.Store(false)
+
 	for {
 		if !e.hasMoreEvents() {
 			return nil
 		}
 
 		e.pauseLock.Lock()
+
+		if e.stopping.CompareAndSwap(true, false) {
+			e.pauseLock.Unlock()
+			return nil
+		}
+
 		e.determineWhatToRun()
 		e.runRound()
 
@@ -280,6 +303,12 @@ func (e *ParallelEngine) Continue() {
 	e.pauseLock.Unlock()
 }
 
+// Stop makes Run return after the running round of events. A paused engine
+// returns when it is continued.
+func (e *ParallelEngine) Stop() {
+	e.stopping.Store(true)
+}
+
 // CurrentTime returns the current time at which the engine is at.
 // Specifically, the run time of the current event.
 func (e *ParallelEngine) CurrentTime() VTimeInPicoSec {
@@ -0,0 +1,49 @@
+package timing
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/sarchlab/akita/v5/profiling"
+)
+
+// A Profilable engine times the handlers it dispatches events to when given a
+// profiler (see package profiling).
+type Profilable interface {
+	EnableProfiling(p *profiling.Profiler)
+}
+
+// profiledHandler times the calls to a handler on the handler's site.
+type profiledHandler struct {
+	Handler
+	site *profiling.Site
+}
+
+func (h profiledHandler) Handle(e Event) error {
+	start := time.Now()
+	err := h.Handler.Handle(e)
+	h.site.Observe(time.Since(start))
+
+	return err
+}
+
+// profileHandler wraps a handler registered under name so that its calls are
+// timed. The site is named after the registration name, which is the name of
+// the component for component handlers.
+func profileHandler(p *profiling.Profiler, name string, h Handler) Handler {
+	if _, wrapped := h.(profiledHandler); wrapped {
+		return h
+	}
+
+	return profiledHandler{
+		Handler: h,
+		site:    p.Site(name, profiling.KindHandler, reflect.TypeOf(h).String()),
+	}
+}
+
+// profileRegistry wraps every handler in the registry.
+func profileRegistry(p *profiling.Profiler, registry map[string]Handler) {
+	for name, h := range registry {
+		registry[name] = profileHandler(p, name, h)
+	}
+}
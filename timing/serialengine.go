@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 
 	"github.com/sarchlab/akita/v5/hooking"
+	"github.com/sarchlab/akita/v5/profiling"
 )
 
 // A SerialEngine is an Engine that always run events one after another.
@@ -17,13 +18,14 @@ type SerialEngine struct {
 	queue          *unsafeEventQueue
 	secondaryQueue *unsafeEventQueue
 
-	paused    int32 // atomic: 0 = running, 1 = paused
+	paused    int32 // atomic: 0 = running; bit 0 = paused, bit 1 = stopping
 	pauseMu   sync.Mutex
 	pauseCond *sync.Cond
 
 	singleRunLock sync.Mutex
 
 	registry map[string]Handler
+	profiler *profiling.Profiler
 }
 
 // NewSerialEngine creates a SerialEngine.
@@ -46,9 +48,20 @@ func (e *SerialEngine) Name() string {
 
 // RegisterHandler registers a handler with the given name.
 func (e *SerialEngine) RegisterHandler(name string, handler Handler) {
+	if e.profiler != nil {
+		handler = profileHandler(e.profiler, name, handler)
+	}
+
 	e.registry[name] = handler
 }
 
+// EnableProfiling times the calls to every handler, registered before or
+// after, on the profiler. It must be called before the engine runs.
+func (e *SerialEngine) EnableProfiling(p *profiling.Profiler) {
+	e.profiler = p
+	profileRegistry(p, e.registry)
+}
+
 // Schedule registers an event to happen in the future.
 func (e *SerialEngine) Schedule(evt Event) {
 	if evt.Time() < e.time {
@@ -69,6 +82,7 @@ func (e *SerialEngine) Run() error {
 	e.singleRunLock.Lock()
 	defer e.singleRunLock.Unlock()
 
+	e.clearStop()
 	hasHooks := e.NumHooks() > 0
 
 	for {
@@ -77,8 +91,8 @@ func (e *SerialEngine) Run() error {
 		}
 
 		// Lightweight pause check: atomic load is ~1ns when not paused.
-		if atomic.LoadInt32(&e.paused) != 0 {
-			e.waitForResume()
+		if atomic.LoadInt32(&e.paused) != 0 && e.waitForResume() {
+			return nil
 		}
 
 		e.dispatchNext(hasHooks)
@@ -95,6 +109,7 @@ func (e *SerialEngine) RunUntil(t VTimeInPicoSec) error {
 	e.singleRunLock.Lock()
 	defer e.singleRunLock.Unlock()
 
+	e.clearStop()
 	hasHooks := e.NumHooks() > 0
 
 	for {
@@ -105,8 +120,8 @@ func (e *SerialEngine) RunUntil(t VTimeInPicoSec) error {
 			return nil
 		}
 
-		if atomic.LoadInt32(&e.paused) != 0 {
-			e.waitForResume()
+		if atomic.LoadInt32(&e.paused) != 0 && e.waitForResume() {
+			return nil
 		}
 
 		e.dispatchNext(hasHooks)
@@ -164,13 +179,25 @@ func (e *SerialEngine) nextEventTime() VTimeInPicoSec {
 	return secondary
 }
 
-// waitForResume blocks until the engine is unpaused.
-func (e *SerialEngine) waitForResume() {
+// waitForResume blocks until the engine is unpaused. It returns true, and
+// clears the request, if the engine is asked to stop.
+func (e *SerialEngine) waitForResume() bool {
 	e.pauseMu.Lock()
-	for atomic.LoadInt32(&e.paused) != 0 {
+	defer e.pauseMu.Unlock()
+
+	for {
+		paused := atomic.LoadInt32(&e.paused)
+
+		switch {
+		case paused&serialStopping != 0:
+			atomic.StoreInt32(&e.paused, paused&^serialStopping)
+			return true
+		case paused == 0:
+			return false
+		}
+
 		e.pauseCond.Wait()
 	}
-	e.pauseMu.Unlock()
 }
 
 func (e *SerialEngine) noMoreEvent() bool {
@@ -199,12 +226,27 @@ func (e *SerialEngine) nextEvent() Event {
 	return secondaryEvt
 }
 
+// clearStop drops a stop request left from an earlier run, so Stop only ends
+// a run in progress.
+func (e *SerialEngine) clearStop() {
+	e.pauseMu.Lock()
+	defer e.pauseMu.Unlock()
+
+	atomic.StoreInt32(&e.paused, atomic.LoadInt32(&e.paused)&^serialStopping)
+}
+
+// Bits of SerialEngine.paused.
+const (
+	serialPaused   int32 = 1
+	serialStopping int32 = 2
+)
+
 // Pause prevents the SerialEngine from triggering more events.
 func (e *SerialEngine) Pause() {
 	e.pauseMu.Lock()
 	defer e.pauseMu.Unlock()
 
-	atomic.StoreInt32(&e.paused, 1)
+	atomic.StoreInt32(&e.paused, atomic.LoadInt32(&e.paused)|serialPaused)
 }
 
 // Continue allows the SerialEngine to trigger more events.
@@ -212,7 +254,17 @@ func (e *SerialEngine) Continue() {
 	e.pauseMu.Lock()
 	defer e.pauseMu.Unlock()
 
-	atomic.StoreInt32(&e.paused, 0)
+	atomic.StoreInt32(&e.paused, atomic.LoadInt32(&e.paused)&^serialPaused)
+	e.pauseCond.Broadcast()
+}
+
+// Stop makes Run or RunUntil return after the event being handled, or at
+// once if the engine is paused. The engine stays paused if it was.
+func (e *SerialEngine) Stop() {
+	e.pauseMu.Lock()
+	defer e.pauseMu.Unlock()
+
+	atomic.StoreInt32(&e.paused, atomic.LoadInt32(&e.paused)|serialStopping)
 	e.pauseCond.Broadcast()
 }
 
@@ -100,3 +100,45 @@ func sameStrings(a, b []string) bool {
 
 	return true
 }
+
+type stoppingHandler struct {
+	engine *SerialEngine
+	times  []VTimeInPicoSec
+}
+
+func (h *stoppingHandler) Handle(e Event) error {
+	h.times = append(h.times, e.Time())
+	if e.Time() == 2 {
+		h.engine.Stop()
+	}
+
+	return nil
+}
+
+func TestSerialEngineStopReturnsAndKeepsRemainingEvents(t *testing.T) {
+	ResetIDGenerator()
+
+	engine := NewSerialEngine()
+	handler := &stoppingHandler{engine: engine}
+	engine.RegisterHandler("handler", handler)
+
+	for _, at := range []VTimeInPicoSec{1, 2, 3} {
+		engine.Schedule(MakeEventBase(at, "handler"))
+	}
+
+	if err := engine.Run(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if len(handler.times) != 2 {
+		t.Fatalf("expected Run to stop after the event at 2, ran %v", handler.times)
+	}
+
+	if err := engine.Run(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if len(handler.times) != 3 || handler.times[2] != 3 {
+		t.Fatalf("expected the next Run to continue, ran %v", handler.times)
+	}
+}
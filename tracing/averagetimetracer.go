@@ -53,6 +53,17 @@ func (t *AverageTimeTracer) StartTask(task TaskStart) {
 	t.lock.Unlock()
 }
 
+// BeginPhase implements PhaseListener. The average restarts, and the tasks in
+// flight are forgotten, so that it only covers the tasks of the new phase.
+func (t *AverageTimeTracer) BeginPhase(string, timing.VTimeInPicoSec) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.averageTime = 0
+	t.taskCount = 0
+	clear(t.inflightTasks)
+}
+
 // EndTask records the end of the task
 func (t *AverageTimeTracer) EndTask(task TaskEnd) {
 	t.lock.Lock()
@@ -54,6 +54,25 @@ func (t *BusyTimeTracer) TerminateAllTasks(now timing.VTimeInPicoSec) {
 	t.collapse(now)
 }
 
+// BeginPhase implements PhaseListener. The busy time restarts from zero, and
+// the tasks in flight only count the time they spend in the new phase.
+func (t *BusyTimeTracer) BeginPhase(_ string, now timing.VTimeInPicoSec) {
+	var next *list.Element
+	for e := t.taskTimes.Front(); e != nil; e = next {
+		next = e.Next()
+
+		task := e.Value.(*taskTimeStartEnd)
+		if task.completed {
+			t.taskTimes.Remove(e)
+			continue
+		}
+
+		task.start = max(task.start, now)
+	}
+
+	t.busyTime = 0
+}
+
 func (t *BusyTimeTracer) extendTaskTime(
 	base *taskTimeStartEnd,
 	t2 *taskTimeStartEnd,
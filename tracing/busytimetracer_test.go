@@ -23,6 +23,17 @@ var _ = Describe("BusyTimeTracer", func() {
 		Expect(t.BusyTime()).To(Equal(timing.VTimeInPicoSec(10)))
 	})
 
+	It("should only count the time in the new phase after a phase begins", func() {
+		t.StartTask(TaskStart{ID: 1, Time: 10})
+		t.StartTask(TaskStart{ID: 2, Time: 12})
+		t.EndTask(TaskEnd{ID: 2, Time: 15})
+
+		t.BeginPhase("roi", 20)
+		t.EndTask(TaskEnd{ID: 1, Time: 30})
+
+		Expect(t.BusyTime()).To(Equal(timing.VTimeInPicoSec(10)))
+	})
+
 	It("should track busy time, two tasks", func() {
 		t.StartTask(TaskStart{ID: 1, Time: 10})
 		t.EndTask(TaskEnd{ID: 1, Time: 20})
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"slices"
+
+	"github.com/sarchlab/akita/v5/timing"
+)
+
+// A PhaseSource tells the program phase the simulation is in, such as
+// "warmup" or "roi". simulation.Simulation is one.
+type PhaseSource interface {
+	Phase() string
+}
+
+// A PhaseListener is told when the simulation begins a new phase. The
+// summarizing tracers in this package are listeners: they restart their
+// results, so that after BeginPhase they only describe the new phase.
+type PhaseListener interface {
+	BeginPhase(phase string, now timing.VTimeInPicoSec)
+}
+
+// InPhase returns a TaskFilter that accepts the tasks that start while the
+// source is in one of the given phases.
+func InPhase(src PhaseSource, phases ...string) TaskFilter {
+	return func(TaskStart) bool {
+		return slices.Contains(phases, src.Phase())
+	}
+}
+
+// AllOf returns a TaskFilter that accepts the tasks every filter accepts, for
+// combining InPhase with a filter on the task itself.
+func AllOf(filters ...TaskFilter) TaskFilter {
+	return func(t TaskStart) bool {
+		for _, f := range filters {
+			if !f(t) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
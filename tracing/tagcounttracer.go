@@ -2,6 +2,8 @@ package tracing
 
 import (
 	"sync"
+
+	"github.com/sarchlab/akita/v5/timing"
 )
 
 // TagCountTracer counts how often each tag name is recorded, and how many
@@ -90,6 +92,18 @@ func (t *TagCountTracer) AddTaskTag(tag TaskTag) {
 	}
 }
 
+// BeginPhase implements PhaseListener. The counts restart, and the tasks in
+// flight are forgotten, so that only the tasks of the new phase are counted.
+func (t *TagCountTracer) BeginPhase(string, timing.VTimeInPicoSec) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.tagNames = nil
+	clear(t.inflightTasks)
+	clear(t.tagCount)
+	clear(t.taskWithTagCount)
+}
+
 // EndTask stops tracking the task.
 func (t *TagCountTracer) EndTask(task TaskEnd) {
 	t.lock.Lock()
@@ -45,6 +45,19 @@ func (t *TotalTimeTracer) StartTask(task TaskStart) {
 	t.lock.Unlock()
 }
 
+// BeginPhase implements PhaseListener. The total restarts from zero, and the
+// tasks in flight only count the time they spend in the new phase.
+func (t *TotalTimeTracer) BeginPhase(_ string, now timing.VTimeInPicoSec) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.totalTime = 0
+
+	for id, start := range t.inflightTasks {
+		t.inflightTasks[id] = max(start, now)
+	}
+}
+
 // EndTask records the end of the task
 func (t *TotalTimeTracer) EndTask(task TaskEnd) {
 	t.lock.Lock()